@@ -0,0 +1,163 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+// AIGatewayQuotaPolicy specifies the token and spend budgets of the clients of the AIGatewayRoutes.
+//
+// Unlike the rate limiting with LLMRequestCosts, which only subtracts the usage after the response completes,
+// the budgets are enforced by the AI Gateway filter itself: the estimated usage of each request is reserved
+// before it is sent to the backend, and the request is rejected with 429 when the budget is exhausted. The reservation
+// is then reconciled with the actual usage at the end of the response. All the endpoints with the model in the request
+// body are subject to the budgets.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.conditions[-1:].type`
+// +kubebuilder:metadata:labels="gateway.networking.k8s.io/policy=direct"
+type AIGatewayQuotaPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              AIGatewayQuotaPolicySpec `json:"spec,omitempty"`
+	// Status defines the status details of the AIGatewayQuotaPolicy.
+	Status AIGatewayQuotaPolicyStatus `json:"status,omitempty"`
+}
+
+// AIGatewayQuotaPolicyList contains a list of AIGatewayQuotaPolicy.
+//
+// +kubebuilder:object:root=true
+type AIGatewayQuotaPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AIGatewayQuotaPolicy `json:"items"`
+}
+
+// AIGatewayQuotaPolicySpec details the AIGatewayQuotaPolicy configuration.
+//
+// +kubebuilder:validation:XValidation:rule="self.type == 'Redis' ? has(self.redis) : true", message="redis must be specified when type is Redis"
+type AIGatewayQuotaPolicySpec struct {
+	// TargetRefs are the names of the AIGatewayRoute resources this AIGatewayQuotaPolicy is being attached to.
+	// The policy applies to the models declared by the rules of the targeted routes. Every match of every rule of
+	// the targeted routes must consist only of the exact header matches including the model name header, otherwise
+	// the route is not accepted.
+	//
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=16
+	// +kubebuilder:validation:XValidation:rule="self.all(ref, ref.group == 'aigateway.envoyproxy.io' && ref.kind == 'AIGatewayRoute')", message="targetRefs must reference AIGatewayRoute resources"
+	TargetRefs []gwapiv1a2.LocalPolicyTargetReference `json:"targetRefs"`
+
+	// ClientKeyHeader is the request header whose value identifies the client, e.g. "x-api-key".
+	// Each client has its own budgets. The requests without the header are not subject to the policy.
+	//
+	// The value is hashed before being used as a part of the key of the tracked spend.
+	//
+	// +kubebuilder:validation:MinLength=1
+	ClientKeyHeader string `json:"clientKeyHeader"`
+
+	// Budgets is the list of the token and spend budgets of each client. The request is rejected when any of them is exhausted.
+	//
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=8
+	Budgets []AIGatewayQuotaBudget `json:"budgets"`
+
+	// Type specifies where the spend is tracked.
+	//
+	// Default is Memory.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=Memory;Redis
+	// +kubebuilder:default=Memory
+	Type AIGatewayQuotaStoreType `json:"type,omitempty"`
+
+	// Redis is the configuration of the Redis-compatible server. This is required when the type is Redis.
+	//
+	// +optional
+	Redis *AIGatewayQuotaRedis `json:"redis,omitempty"`
+}
+
+// AIGatewayQuotaBudget is the maximum number of tokens or the maximum spend of a client within the sliding window.
+type AIGatewayQuotaBudget struct {
+	// Type specifies the kind of the usage counted against the budget.
+	//
+	// +kubebuilder:validation:Enum=InputToken;OutputToken;TotalToken;Spend
+	Type AIGatewayQuotaBudgetType `json:"type"`
+
+	// Limit is the maximum number of tokens within the window. For the Spend type, this is the maximum spend
+	// in US cents, e.g. 5000 for $50.
+	//
+	// +kubebuilder:validation:Minimum=1
+	Limit int64 `json:"limit"`
+
+	// Window is the duration of the sliding window, e.g. "1h".
+	Window metav1.Duration `json:"window"`
+}
+
+// AIGatewayQuotaBudgetType specifies the kind of the usage counted against the budget.
+type AIGatewayQuotaBudgetType string
+
+const (
+	// AIGatewayQuotaBudgetTypeInputToken counts the input tokens.
+	AIGatewayQuotaBudgetTypeInputToken AIGatewayQuotaBudgetType = "InputToken"
+	// AIGatewayQuotaBudgetTypeOutputToken counts the output tokens.
+	AIGatewayQuotaBudgetTypeOutputToken AIGatewayQuotaBudgetType = "OutputToken"
+	// AIGatewayQuotaBudgetTypeTotalToken counts the total tokens.
+	AIGatewayQuotaBudgetTypeTotalToken AIGatewayQuotaBudgetType = "TotalToken"
+	// AIGatewayQuotaBudgetTypeSpend counts the cost of the requests, which is calculated from the token usage with
+	// the price of the model served by the backend. See AIGatewayRouteModelBackend.Price. The requests to
+	// the backends without the price cost nothing.
+	//
+	// Since the backend is not known before the request is routed, the cost is only counted at the end of the
+	// response, and the request is rejected only when the budget is already exhausted.
+	AIGatewayQuotaBudgetTypeSpend AIGatewayQuotaBudgetType = "Spend"
+)
+
+// AIGatewayQuotaStoreType specifies where the spend is tracked.
+type AIGatewayQuotaStoreType string
+
+const (
+	// AIGatewayQuotaStoreTypeMemory tracks the spend in memory per AI Gateway filter instance.
+	// The budgets are enforced separately by each instance.
+	AIGatewayQuotaStoreTypeMemory AIGatewayQuotaStoreType = "Memory"
+	// AIGatewayQuotaStoreTypeRedis tracks the spend in a Redis-compatible server shared by all the instances.
+	AIGatewayQuotaStoreTypeRedis AIGatewayQuotaStoreType = "Redis"
+)
+
+// AIGatewayQuotaRedis is the configuration of the Redis-compatible server used by the quota.
+type AIGatewayQuotaRedis struct {
+	// Address is the host:port of the server, e.g. "redis.default.svc.cluster.local:6379".
+	//
+	// +kubebuilder:validation:MinLength=1
+	Address string `json:"address"`
+
+	// KeyPrefix is prepended to each key of the tracked spend stored in the server.
+	//
+	// Default is "aigw:quota:".
+	//
+	// +optional
+	KeyPrefix *string `json:"keyPrefix,omitempty"`
+
+	// Username is the ACL user authenticated with the password. When this is omitted, the password authenticates
+	// the default user.
+	//
+	// +optional
+	Username *string `json:"username,omitempty"`
+
+	// PasswordSecretRef is the reference to the Secret in the same namespace, which holds the password
+	// under the "password" key. When this is omitted, the connections are not authenticated.
+	//
+	// +optional
+	PasswordSecretRef *gwapiv1.SecretObjectReference `json:"passwordSecretRef,omitempty"`
+
+	// TLS enables TLS to the server. When this is omitted, the connections are plain TCP.
+	//
+	// +optional
+	TLS *RedisTLS `json:"tls,omitempty"`
+}
@@ -7,6 +7,7 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
@@ -212,12 +213,671 @@ type AIGatewayRouteSpec struct {
 	// +optional
 	// +kubebuilder:validation:MaxItems=36
 	LLMRequestCosts []LLMRequestCost `json:"llmRequestCosts,omitempty"`
+
+	// ResponseCache enables the exact-match cache of the chat completion responses.
+	//
+	// When enabled, the AI Gateway filter hashes the normalized chat completion request together with the
+	// model name, and returns the cached response for the identical request without calling the backend.
+	// Both streaming and non-streaming requests are served from the same cached response, and the streaming
+	// response is re-emitted as server-sent events. Clients can bypass the cache by sending the
+	// "Cache-Control: no-cache" or "Cache-Control: no-store" request header. The cached responses have
+	// the "x-ai-eg-response-cache: hit" response header.
+	//
+	// Note that when multiple AIGatewayRoute resources are attached to the same Gateway, and
+	// different response caches are configured, the ai-gateway will pick one of them and ignore the rest.
+	//
+	// +optional
+	ResponseCache *ResponseCache `json:"responseCache,omitempty"`
+
+	// Models is the catalog of the virtual models served by this AIGatewayRoute.
+	//
+	// Each virtual model is matched by the rules with the `x-ai-eg-model` header as usual. Its aliases
+	// are resolved by the AI Gateway filter before the routing decision, in other words, the `x-ai-eg-model`
+	// header is set to the name of the virtual model when the request specifies one of the aliases.
+	// For the backend references of a rule matching exactly one virtual model, the model name sent to the backend
+	// is rewritten to the backend-specific name listed in the model's backends, or to the name of the virtual model
+	// if not listed, unless the ModelNameOverride is set on the backend reference.
+	//
+	// The virtual models as well as their aliases are listed in the "/models" endpoint with the metadata.
+	//
+	// For example, the following configuration lets the clients use "claude-sonnet" or "sonnet" regardless
+	// of the provider-specific model IDs:
+	//
+	// ```yaml
+	//	models:
+	//	- name: claude-sonnet
+	//	  aliases: [sonnet]
+	//	  contextWindow: 200000
+	//	  modalities: [Text, Image]
+	//	  backends:
+	//	  - name: aws-bedrock
+	//	    modelName: anthropic.claude-3-5-sonnet-20240620-v1:0
+	//	  - name: gcp-anthropic
+	//	    modelName: claude-3-5-sonnet@20240620
+	// ```
+	//
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	// +kubebuilder:validation:MaxItems=128
+	Models []AIGatewayRouteModel `json:"models,omitempty"`
+
+	// BodyHeaders is the list of the request headers derived from the request body by the AI Gateway filter
+	// before the routing decision, in the same way as the `x-ai-eg-model` header is derived from the model name.
+	// The rules can match these headers to route on the other attributes of the request body, such as the
+	// presence of the tools or the image parts, the response format, the estimated prompt length or the reasoning effort.
+	//
+	// For example, the following configuration lets the rules route the requests with the tools or the JSON schema
+	// response format to a different backend:
+	//
+	// ```yaml
+	//	bodyHeaders:
+	//	- name: x-has-tools
+	//	  cel: "has(request.tools) && size(request.tools) > 0"
+	//	- name: x-response-format
+	//	  jsonPath: "$.response_format.type"
+	//	rules:
+	//	- matches:
+	//	  - headers:
+	//	    - name: x-ai-eg-model
+	//	      value: gpt-4o
+	//	    - name: x-has-tools
+	//	      value: "true"
+	//	  backendRefs:
+	//	  - name: openai
+	// ```
+	//
+	// The headers with the same names sent by the clients are removed when the expression does not produce a value.
+	// Only the JSON request bodies are supported.
+	//
+	// Note that when multiple AIGatewayRoute resources are attached to the same Gateway, and
+	// different expressions are configured for the same header name, the ai-gateway will pick one of them
+	// and ignore the rest.
+	//
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	// +kubebuilder:validation:MaxItems=16
+	BodyHeaders []AIGatewayRouteBodyHeader `json:"bodyHeaders,omitempty"`
+
+	// PIIRedaction scans the text of the requests of this route to the chat completions, the completions, the
+	// Responses and the Anthropic Messages APIs for the personally identifiable information (PII) such as the email
+	// addresses and the credit card numbers, and masks or rejects them before the requests leave the gateway. The
+	// requests to the other endpoints such as the embeddings, the image generation and the audio are not scanned.
+	//
+	// With the Mask action, each detected value is replaced with a placeholder such as "[EMAIL_1]", and the
+	// placeholders in the response are replaced back with the original values, so that the clients see the
+	// original values while the providers never do.
+	//
+	// Every match of every rule of this route must consist only of the exact header matches including the
+	// "x-ai-eg-model" header, so that every request of this route to the above APIs is scanned. Otherwise, the route
+	// is not accepted.
+	//
+	// +optional
+	PIIRedaction *AIGatewayRoutePIIRedaction `json:"piiRedaction,omitempty"`
+
+	// Guardrail configures the external guardrail service, e.g. a moderation or a prompt injection classifier,
+	// consulted by the AI Gateway filter on the requests and responses of this route to the chat completions, the
+	// Responses and the Anthropic Messages APIs. The service can allow them, block them with an error, or mutate
+	// their content.
+	//
+	// Every match of every rule of this route must consist only of the exact header matches including the
+	// "x-ai-eg-model" header, so that every request of this route is checked. Otherwise, the route is not accepted.
+	// With the PIIRedaction, the service sees the masked request.
+	//
+	// +optional
+	Guardrail *AIGatewayRouteGuardrail `json:"guardrail,omitempty"`
+
+	// ToolPolicy governs the function calling of the requests of this route to the chat completions, the Responses
+	// and the Anthropic Messages APIs. It restricts the functions the requests may declare in the tools, and validates
+	// the arguments of the tool calls returned by the backends against the JSON Schema of the parameters of the
+	// declared functions.
+	//
+	// The tool calls are validated in the format of the API after the translation, so this applies to all the
+	// backends regardless of their API schema. Every match of every rule of this route must consist only of the exact
+	// header matches including the "x-ai-eg-model" header, so that every request of this route is governed.
+	// Otherwise, the route is not accepted.
+	//
+	// +optional
+	ToolPolicy *AIGatewayRouteToolPolicy `json:"toolPolicy,omitempty"`
+}
+
+// AIGatewayRoutePIIRedaction configures the detection and the redaction of the PII in the requests.
+type AIGatewayRoutePIIRedaction struct {
+	// Action is what the AI Gateway filter does with the requests containing the PII.
+	//
+	// Mask replaces each detected value with a placeholder, and restores the original values in the response.
+	// Reject rejects the request with the 400 status code without sending it to the backend.
+	//
+	// +kubebuilder:validation:Enum=Mask;Reject
+	// +kubebuilder:default=Mask
+	// +optional
+	Action AIGatewayRoutePIIRedactionAction `json:"action,omitempty"`
+
+	// Detectors is the list of the detectors of the PII, which are applied in this order.
+	//
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=32
+	Detectors []AIGatewayRoutePIIDetector `json:"detectors"`
+}
+
+// AIGatewayRoutePIIRedactionAction is what the AI Gateway filter does with the requests containing the PII.
+type AIGatewayRoutePIIRedactionAction string
+
+const (
+	// AIGatewayRoutePIIRedactionActionMask masks the PII with the placeholders restored in the response.
+	AIGatewayRoutePIIRedactionActionMask AIGatewayRoutePIIRedactionAction = "Mask"
+	// AIGatewayRoutePIIRedactionActionReject rejects the requests containing the PII.
+	AIGatewayRoutePIIRedactionActionReject AIGatewayRoutePIIRedactionAction = "Reject"
+)
+
+// AIGatewayRoutePIIDetector specifies a detector of the PII.
+//
+// +kubebuilder:validation:XValidation:rule="self.type == 'Regex' ? has(self.regex) : !has(self.regex)", message="regex must be specified only for the Regex type"
+type AIGatewayRoutePIIDetector struct {
+	// Name is the name of the detector, which is used in the placeholders of the masked values and in the metrics.
+	// For example, the values detected by the detector named "email" are replaced with "[EMAIL_1]", "[EMAIL_2]" and so on.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=32
+	// +kubebuilder:validation:Pattern=`^[a-z][a-z0-9_]*$`
+	Name string `json:"name"`
+
+	// Type is the type of the detector.
+	//
+	// Email detects the email addresses.
+	// PhoneNumber detects the phone numbers of 10 to 15 digits, optionally with the country code and the separators.
+	// CreditCard detects the credit card numbers of 13 to 19 digits passing the Luhn check.
+	// NationalID detects the US Social Security Numbers in the "123-45-6789" format.
+	// Regex detects the matches of the regular expression given in the Regex field.
+	//
+	// +kubebuilder:validation:Enum=Email;PhoneNumber;CreditCard;NationalID;Regex
+	Type AIGatewayRoutePIIDetectorType `json:"type"`
+
+	// Regex is the regular expression of the Regex type in the RE2 syntax, e.g. `EMP-[0-9]{6}` for the employee IDs.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=1024
+	// +optional
+	Regex *string `json:"regex,omitempty"`
+}
+
+// AIGatewayRoutePIIDetectorType is the type of the detector of the PII.
+type AIGatewayRoutePIIDetectorType string
+
+const (
+	// AIGatewayRoutePIIDetectorTypeEmail detects the email addresses.
+	AIGatewayRoutePIIDetectorTypeEmail AIGatewayRoutePIIDetectorType = "Email"
+	// AIGatewayRoutePIIDetectorTypePhoneNumber detects the phone numbers.
+	AIGatewayRoutePIIDetectorTypePhoneNumber AIGatewayRoutePIIDetectorType = "PhoneNumber"
+	// AIGatewayRoutePIIDetectorTypeCreditCard detects the credit card numbers.
+	AIGatewayRoutePIIDetectorTypeCreditCard AIGatewayRoutePIIDetectorType = "CreditCard"
+	// AIGatewayRoutePIIDetectorTypeNationalID detects the US Social Security Numbers.
+	AIGatewayRoutePIIDetectorTypeNationalID AIGatewayRoutePIIDetectorType = "NationalID"
+	// AIGatewayRoutePIIDetectorTypeRegex detects the matches of a regular expression.
+	AIGatewayRoutePIIDetectorTypeRegex AIGatewayRoutePIIDetectorType = "Regex"
+)
+
+// AIGatewayRouteGuardrail configures the external guardrail service called by the AI Gateway filter.
+//
+// The service receives the check of the request before it is routed, and the check of the response before its end
+// is returned to the client. See the documentation for the contract of the checks and the decisions.
+//
+// +kubebuilder:validation:XValidation:rule="has(self.protocol) && self.protocol == 'GRPC' ? !self.endpoint.contains('://') : self.endpoint.startsWith('http://') || self.endpoint.startsWith('https://')",message="endpoint must be a URL for the HTTP protocol and host:port for the GRPC protocol"
+type AIGatewayRouteGuardrail struct {
+	// Protocol is the protocol used to call the guardrail service.
+	//
+	// HTTP posts the check as JSON to the endpoint and reads the decision from the JSON response.
+	// GRPC calls the "aigateway.guardrail.v1.Guardrail/Check" method with the check and the decision as
+	// google.protobuf.Struct messages of the same JSON.
+	//
+	// +kubebuilder:validation:Enum=HTTP;GRPC
+	// +kubebuilder:default=HTTP
+	// +optional
+	Protocol AIGatewayRouteGuardrailProtocol `json:"protocol,omitempty"`
+
+	// Endpoint is the URL of the guardrail service for the HTTP protocol, e.g. "http://moderation.default:8080/check",
+	// or its host:port for the GRPC protocol, e.g. "moderation.default:9090". The service is called directly by the
+	// AI Gateway filter.
+	//
+	// +kubebuilder:validation:MinLength=1
+	Endpoint string `json:"endpoint"`
+
+	// Phases are the phases in which the guardrail service is called.
+	//
+	// Request checks the request before it is routed to the backend. Response checks the response before its end is
+	// returned to the client, so the streaming responses are held back until they complete up to MaxResponseBytes.
+	//
+	// Default is both.
+	//
+	// +optional
+	// +listType=set
+	// +kubebuilder:validation:MaxItems=2
+	Phases []AIGatewayRouteGuardrailPhase `json:"phases,omitempty"`
+
+	// Timeout is the timeout of each call to the guardrail service.
+	//
+	// Default is 1s.
+	//
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// FailureMode is what happens when the call to the guardrail service fails or times out.
+	//
+	// FailClosed rejects the request, or replaces the response, with the 503 status code.
+	// FailOpen lets the request or the response through as if it were allowed.
+	//
+	// +kubebuilder:validation:Enum=FailOpen;FailClosed
+	// +kubebuilder:default=FailClosed
+	// +optional
+	FailureMode AIGatewayRouteGuardrailFailureMode `json:"failureMode,omitempty"`
+
+	// MaxResponseBytes is the maximum size of the response held back in the memory of the AI Gateway filter until the
+	// guardrail service decides on it, in bytes. A larger response is handled according to the FailureMode as if the
+	// call to the service failed: FailOpen releases the response held back so far and the rest unchecked, and
+	// FailClosed replaces the response with the error.
+	//
+	// Default is 1048576 (1MiB).
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxResponseBytes *int32 `json:"maxResponseBytes,omitempty"`
+}
+
+// AIGatewayRouteGuardrailProtocol is the protocol used to call the guardrail service.
+type AIGatewayRouteGuardrailProtocol string
+
+const (
+	// AIGatewayRouteGuardrailProtocolHTTP calls the guardrail service with JSON over HTTP.
+	AIGatewayRouteGuardrailProtocolHTTP AIGatewayRouteGuardrailProtocol = "HTTP"
+	// AIGatewayRouteGuardrailProtocolGRPC calls the guardrail service over gRPC.
+	AIGatewayRouteGuardrailProtocolGRPC AIGatewayRouteGuardrailProtocol = "GRPC"
+)
+
+// AIGatewayRouteGuardrailPhase is a phase in which the guardrail service is called.
+//
+// +kubebuilder:validation:Enum=Request;Response
+type AIGatewayRouteGuardrailPhase string
+
+const (
+	// AIGatewayRouteGuardrailPhaseRequest checks the request before it is routed to the backend.
+	AIGatewayRouteGuardrailPhaseRequest AIGatewayRouteGuardrailPhase = "Request"
+	// AIGatewayRouteGuardrailPhaseResponse checks the response before its end is returned to the client.
+	AIGatewayRouteGuardrailPhaseResponse AIGatewayRouteGuardrailPhase = "Response"
+)
+
+// AIGatewayRouteGuardrailFailureMode is what happens when the call to the guardrail service fails.
+type AIGatewayRouteGuardrailFailureMode string
+
+const (
+	// AIGatewayRouteGuardrailFailureModeFailOpen lets the request or the response through.
+	AIGatewayRouteGuardrailFailureModeFailOpen AIGatewayRouteGuardrailFailureMode = "FailOpen"
+	// AIGatewayRouteGuardrailFailureModeFailClosed rejects the request or replaces the response.
+	AIGatewayRouteGuardrailFailureModeFailClosed AIGatewayRouteGuardrailFailureMode = "FailClosed"
+)
+
+// AIGatewayRouteToolPolicy configures the governance of the function calling.
+type AIGatewayRouteToolPolicy struct {
+	// AllowedFunctions is the list of the names of the functions the requests may declare in the tools. The requests
+	// declaring the other functions are rejected with the 400 status code.
+	//
+	// When empty, the requests may declare any function.
+	//
+	// +kubebuilder:validation:MaxItems=128
+	// +listType=set
+	// +optional
+	AllowedFunctions []string `json:"allowedFunctions,omitempty"`
+
+	// ValidateArguments validates the arguments of each tool call in the response against the JSON Schema of the
+	// parameters of the called function declared in the request. Regardless of this, the tool calls of the functions
+	// which are not declared in the request or not allowed by AllowedFunctions are always violations.
+	//
+	// Default is true.
+	//
+	// +kubebuilder:default=true
+	// +optional
+	ValidateArguments *bool `json:"validateArguments,omitempty"`
+
+	// Action is what the AI Gateway filter does with the responses containing the tool calls violating this policy.
+	//
+	// Block replaces the response with an error of the "tool_call_violation" type in the format of the API with the
+	// 502 status code. For the streaming response, the events from the first tool call are held back until the end
+	// of the stream, and replaced with the error event.
+	// Rewrite removes the violating tool calls from the response. When no tool call is left, the response no longer
+	// stops for them, e.g. the finish reason of the chat completion choice becomes "stop", and the stop reason of the
+	// Anthropic message becomes "end_turn".
+	//
+	// +kubebuilder:validation:Enum=Block;Rewrite
+	// +kubebuilder:default=Block
+	// +optional
+	Action AIGatewayRouteToolPolicyAction `json:"action,omitempty"`
+}
+
+// AIGatewayRouteToolPolicyAction is what the AI Gateway filter does with the responses violating the tool policy.
+type AIGatewayRouteToolPolicyAction string
+
+const (
+	// AIGatewayRouteToolPolicyActionBlock replaces the violating response with an error.
+	AIGatewayRouteToolPolicyActionBlock AIGatewayRouteToolPolicyAction = "Block"
+	// AIGatewayRouteToolPolicyActionRewrite removes the violating tool calls from the response.
+	AIGatewayRouteToolPolicyActionRewrite AIGatewayRouteToolPolicyAction = "Rewrite"
+)
+
+// AIGatewayRouteBodyHeader specifies a request header derived from the request body.
+//
+// +kubebuilder:validation:XValidation:rule="has(self.cel) != has(self.jsonPath)", message="exactly one of cel or jsonPath must be specified"
+type AIGatewayRouteBodyHeader struct {
+	// Name is the name of the header. This must be lower case, and must not start with "x-ai-eg-" which is reserved
+	// for the headers set by the AI Gateway.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=256
+	// +kubebuilder:validation:Pattern=`^[a-z0-9!#$%&'*+.^_|~-]+$`
+	// +kubebuilder:validation:XValidation:rule="!self.startsWith('x-ai-eg-')", message="name must not start with x-ai-eg-"
+	Name string `json:"name"`
+
+	// CEL is the CEL expression evaluated against the request body. The result must be a string, a bool or a number.
+	//
+	// The expression can use the following variables:
+	//
+	//   - request: the request body parsed as a JSON object, e.g. `request.reasoning_effort`.
+	//   - estimated_prompt_tokens: the estimated number of the tokens of the prompt as a uint, which is the same as
+	//     the estimated input tokens of the quota. This is zero for the image generation and the audio requests.
+	//
+	// For example, `request.messages.exists(m, type(m.content) == list && m.content.exists(c, c.type == 'image_url'))`
+	// is "true" when the chat completion request has an image, and
+	// `estimated_prompt_tokens > uint(100000) ? 'long' : 'short'` classifies the prompt by its length.
+	//
+	// The header is not set when the evaluation fails, e.g. when a field is missing without the `has()` check.
+	//
+	// +optional
+	CEL *string `json:"cel,omitempty"`
+
+	// JSONPath is the JSONPath expression evaluated against the request body, e.g. `$.response_format.type`.
+	// The syntax is the same as the one of kubectl. When multiple values are selected, they are joined with commas.
+	// The values other than the strings and the numbers are encoded as JSON.
+	//
+	// The header is not set when no value is selected.
+	//
+	// +optional
+	JSONPath *string `json:"jsonPath,omitempty"`
+}
+
+// AIGatewayRouteModel defines a virtual model served by the AIGatewayRoute.
+//
+// +kubebuilder:validation:XValidation:rule="!has(self.aliases) || !self.aliases.exists(a, a == self.name)", message="aliases must not contain the model name"
+type AIGatewayRouteModel struct {
+	// Name is the name of the virtual model, which is matched by the rules with the `x-ai-eg-model` header.
+	//
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Aliases is the list of the alternative names of the virtual model accepted in the requests.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=16
+	Aliases []string `json:"aliases,omitempty"`
+
+	// Backends is the list of the backend-specific names of the virtual model.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=16
+	Backends []AIGatewayRouteModelBackend `json:"backends,omitempty"`
+
+	// ContextWindow is the maximum number of tokens of the model context, listed in the "/models" endpoint.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	ContextWindow *int32 `json:"contextWindow,omitempty"`
+
+	// Modalities is the list of the modalities supported by the model, listed in the "/models" endpoint.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=4
+	// +kubebuilder:validation:items:Enum=Text;Image;Audio;Video
+	Modalities []AIGatewayRouteModelModality `json:"modalities,omitempty"`
+
+	// OwnedBy represents the owner of the model, listed in the "/models" endpoint.
+	// This takes precedence over the ModelsOwnedBy of the rules matching this model.
+	//
+	// +optional
+	OwnedBy *string `json:"ownedBy,omitempty"`
+}
+
+// AIGatewayRouteModelBackend specifies the name of the virtual model at a backend.
+type AIGatewayRouteModelBackend struct {
+	// Name is the name of the AIServiceBackend referenced by the rules of the AIGatewayRoute.
+	//
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// ModelName is the name of the model at the backend, e.g. "anthropic.claude-3-5-sonnet-20240620-v1:0".
+	//
+	// +kubebuilder:validation:MinLength=1
+	ModelName string `json:"modelName"`
+
+	// Price is the price of the model at the backend. When specified, the cost of each request in USD is
+	// recorded in the dynamic metadata and the metrics, exposed to the CEL expressions of the LLMRequestCosts,
+	// and used by the LowestCost strategy of the adaptive load balancing and the Spend budgets of the quotas.
+	//
+	// +optional
+	Price *AIGatewayRouteModelPrice `json:"price,omitempty"`
+}
+
+// AIGatewayRouteModelPrice specifies the price of a model at a backend in USD per million tokens.
+//
+// The prices are decimal strings, e.g. "2.5", to avoid the floating point numbers in the API.
+type AIGatewayRouteModelPrice struct {
+	// InputPerMillionTokens is the price of one million input tokens.
+	//
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	InputPerMillionTokens string `json:"inputPerMillionTokens"`
+
+	// CachedInputPerMillionTokens is the price of one million input tokens read from the prompt cache of the provider.
+	//
+	// Defaults to InputPerMillionTokens.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	CachedInputPerMillionTokens *string `json:"cachedInputPerMillionTokens,omitempty"`
+
+	// OutputPerMillionTokens is the price of one million output tokens.
+	//
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	OutputPerMillionTokens string `json:"outputPerMillionTokens"`
+}
+
+// AIGatewayRouteModelModality specifies the modality supported by the model.
+type AIGatewayRouteModelModality string
+
+const (
+	// AIGatewayRouteModelModalityText is the text modality.
+	AIGatewayRouteModelModalityText AIGatewayRouteModelModality = "Text"
+	// AIGatewayRouteModelModalityImage is the image modality.
+	AIGatewayRouteModelModalityImage AIGatewayRouteModelModality = "Image"
+	// AIGatewayRouteModelModalityAudio is the audio modality.
+	AIGatewayRouteModelModalityAudio AIGatewayRouteModelModality = "Audio"
+	// AIGatewayRouteModelModalityVideo is the video modality.
+	AIGatewayRouteModelModalityVideo AIGatewayRouteModelModality = "Video"
+)
+
+// ResponseCache configures the exact-match cache of the chat completion responses.
+//
+// +kubebuilder:validation:XValidation:rule="self.type != 'Redis' || has(self.redis)", message="redis must be specified for the Redis type"
+type ResponseCache struct {
+	// Type specifies where the cached responses are stored.
+	//
+	// Memory stores the responses in the memory of each AI Gateway filter instance with the LRU eviction.
+	// Redis stores the responses in a Redis-compatible server shared by all the instances.
+	//
+	// +kubebuilder:validation:Enum=Memory;Redis
+	// +kubebuilder:default=Memory
+	// +optional
+	Type ResponseCacheType `json:"type,omitempty"`
+
+	// TTL is the duration for which a cached response is served after it is stored.
+	//
+	// Default is 1h.
+	//
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+
+	// MaxEntries is the maximum number of responses held by the Memory cache per AI Gateway filter instance.
+	// The least recently used response is evicted when the limit is reached. This is ignored for the Redis type.
+	//
+	// Default is 1024.
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxEntries *int32 `json:"maxEntries,omitempty"`
+
+	// MaxEntryBytes is the maximum size of a single response to be cached in bytes. Larger responses are not cached.
+	//
+	// Default is 1048576 (1MiB).
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxEntryBytes *int32 `json:"maxEntryBytes,omitempty"`
+
+	// Redis is the configuration of the Redis-compatible server. This is required when the Type is Redis.
+	//
+	// +optional
+	Redis *ResponseCacheRedis `json:"redis,omitempty"`
+
+	// Semantic enables the semantic lookup of the cached responses for the models routed by this AIGatewayRoute.
+	//
+	// When the exact match is not found, the last user message of the request is embedded by the embeddings backend,
+	// and the cached response of the most similar previous request is served if the similarity is above the threshold.
+	// Only the requests that are identical except for the last user message are compared, so the system prompt, the
+	// conversation history and the parameters must match. The responses served by the semantic lookup are attributed
+	// as "semantic" in the "response_cache" field of the dynamic metadata, along with the "response_cache_similarity".
+	//
+	// This is opted in per AIGatewayRoute. The models declared with the exact match of the "x-ai-eg-model" header
+	// in the rules of this AIGatewayRoute are looked up semantically. When multiple AIGatewayRoute resources attached
+	// to the same Gateway configure this, the embeddings backend and the threshold of one of them are used.
+	//
+	// +optional
+	Semantic *SemanticResponseCache `json:"semantic,omitempty"`
+}
+
+// SemanticResponseCache configures the semantic lookup of the cached responses.
+type SemanticResponseCache struct {
+	// EmbeddingBackendRef is the name of the AIServiceBackend in the same namespace used to embed the requests.
+	// The API schema and the BackendSecurityPolicy of the backend are used to call it. The AIServiceBackend must
+	// support the embeddings, i.e. the schema must be one of OpenAI, AWSBedrock, AzureOpenAI or GCPVertexAI.
+	//
+	// +kubebuilder:validation:MinLength=1
+	EmbeddingBackendRef string `json:"embeddingBackendRef"`
+
+	// EmbeddingEndpoint is the base URL of the embeddings backend called directly by the AI Gateway filter,
+	// e.g. "https://api.openai.com". The path of the embeddings endpoint is appended according to the API schema.
+	//
+	// +kubebuilder:validation:Pattern=`^https?://`
+	EmbeddingEndpoint string `json:"embeddingEndpoint"`
+
+	// EmbeddingModel is the name of the embedding model, e.g. "text-embedding-3-small".
+	//
+	// +kubebuilder:validation:MinLength=1
+	EmbeddingModel string `json:"embeddingModel"`
+
+	// EmbeddingTimeout is the timeout of each embedding request. When the embedding fails, the request is
+	// served by the backend without the semantic lookup.
+	//
+	// Default is 1s.
+	//
+	// +optional
+	EmbeddingTimeout *metav1.Duration `json:"embeddingTimeout,omitempty"`
+
+	// SimilarityThreshold is the minimum cosine similarity between the requests for the cached response to be served,
+	// as a decimal string in the range (0, 1], e.g. "0.95". The higher the threshold is, the fewer paraphrases are matched.
+	//
+	// Default is "0.95".
+	//
+	// +kubebuilder:validation:Pattern=`^(0\.[0-9]*[1-9][0-9]*|1(\.0*)?)$`
+	// +optional
+	SimilarityThreshold *string `json:"similarityThreshold,omitempty"`
+
+	// MaxEntries is the maximum number of the embeddings held by the in-memory vector store per AI Gateway
+	// filter instance. The oldest embedding is evicted when the limit is reached.
+	//
+	// Default is 1024.
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxEntries *int32 `json:"maxEntries,omitempty"`
+}
+
+// ResponseCacheType specifies the storage of the response cache.
+type ResponseCacheType string
+
+const (
+	// ResponseCacheTypeMemory stores the responses in memory.
+	ResponseCacheTypeMemory ResponseCacheType = "Memory"
+	// ResponseCacheTypeRedis stores the responses in a Redis-compatible server.
+	ResponseCacheTypeRedis ResponseCacheType = "Redis"
+)
+
+// ResponseCacheRedis is the configuration of the Redis-compatible server used by the response cache.
+type ResponseCacheRedis struct {
+	// Address is the host:port of the server, e.g. "redis.default.svc.cluster.local:6379".
+	//
+	// +kubebuilder:validation:MinLength=1
+	Address string `json:"address"`
+
+	// KeyPrefix is prepended to each cache key stored in the server.
+	//
+	// Default is "aigw:response-cache:".
+	//
+	// +optional
+	KeyPrefix *string `json:"keyPrefix,omitempty"`
+
+	// Username is the ACL user authenticated with the password. When this is omitted, the password authenticates
+	// the default user.
+	//
+	// +optional
+	Username *string `json:"username,omitempty"`
+
+	// PasswordSecretRef is the reference to the Secret in the same namespace, which holds the password
+	// under the "password" key. When this is omitted, the connections are not authenticated.
+	//
+	// +optional
+	PasswordSecretRef *gwapiv1.SecretObjectReference `json:"passwordSecretRef,omitempty"`
+
+	// TLS enables TLS to the server. When this is omitted, the connections are plain TCP.
+	//
+	// +optional
+	TLS *RedisTLS `json:"tls,omitempty"`
+}
+
+// RedisTLS is the TLS configuration of the connections to a Redis-compatible server.
+type RedisTLS struct {
+	// ServerName is the name used to verify the certificate of the server.
+	//
+	// Default is the host of the address.
+	//
+	// +optional
+	ServerName *string `json:"serverName,omitempty"`
+
+	// CACertificateSecretRef is the reference to the Secret in the same namespace, which holds the PEM-encoded
+	// CA certificates used to verify the server under the "ca.crt" key.
+	//
+	// Default is the system root CAs.
+	//
+	// +optional
+	CACertificateSecretRef *gwapiv1.SecretObjectReference `json:"caCertificateSecretRef,omitempty"`
 }
 
 // AIGatewayRouteRule is a rule that defines the routing behavior of the AIGatewayRoute.
 //
 // +kubebuilder:validation:XValidation:rule="!has(self.backendRefs) || size(self.backendRefs) == 0 || (self.backendRefs.all(ref, !has(ref.group) && !has(ref.kind)) || self.backendRefs.all(ref, has(ref.group) && has(ref.kind)))", message="cannot mix InferencePool and AIServiceBackend references in the same rule"
 // +kubebuilder:validation:XValidation:rule="!has(self.backendRefs) || size(self.backendRefs) == 0 || !self.backendRefs.exists(ref, has(ref.group) && has(ref.kind)) || size(self.backendRefs) == 1", message="only one InferencePool backend is allowed per rule"
+// +kubebuilder:validation:XValidation:rule="!has(self.fallback) || !has(self.backendRefs) || !self.backendRefs.exists(ref, has(ref.group) && has(ref.kind))", message="fallback cannot be used with InferencePool backends"
+// +kubebuilder:validation:XValidation:rule="!has(self.adaptiveLoadBalancing) || !has(self.backendRefs) || !self.backendRefs.exists(ref, has(ref.group) && has(ref.kind))", message="adaptiveLoadBalancing cannot be used with InferencePool backends"
+// +kubebuilder:validation:XValidation:rule="!has(self.sessionAffinity) || !has(self.backendRefs) || !self.backendRefs.exists(ref, has(ref.group) && has(ref.kind))", message="sessionAffinity cannot be used with InferencePool backends"
+// +kubebuilder:validation:XValidation:rule="!has(self.sessionAffinity) || !has(self.adaptiveLoadBalancing)", message="sessionAffinity cannot be used with adaptiveLoadBalancing"
+// +kubebuilder:validation:XValidation:rule="!has(self.hedging) || (has(self.backendRefs) && size(self.backendRefs) >= 2 && !self.backendRefs.exists(ref, has(ref.group) && has(ref.kind)))", message="hedging requires at least two AIServiceBackend references"
 type AIGatewayRouteRule struct {
 	// BackendRefs is the list of backends that this rule will route the traffic to.
 	// Each backend can have a weight that determines the traffic distribution.
@@ -258,6 +918,99 @@ type AIGatewayRouteRule struct {
 	// +optional
 	Timeouts *gwapiv1.HTTPRouteTimeouts `json:"timeouts,omitempty"`
 
+	// Fallback configures the retry of the failed requests on the other backends of this rule, typically
+	// the ones with the lower priority, e.g. AWS Bedrock as primary and GCP Vertex AI as secondary.
+	//
+	// Unlike the retry configured with the BackendTrafficPolicy, which only looks at the status code,
+	// the AI Gateway filter classifies the error responses of each backend after translating them
+	// into the OpenAI format, so that provider-specific failures such as the throttling of AWS Bedrock
+	// (ThrottlingException) or GCP Vertex AI (RESOURCE_EXHAUSTED) and context length errors can trigger the retry.
+	// The retried request is translated again into the schema of the newly selected backend.
+	//
+	// Each retry is made on a different priority of the backends when available. Connection failures and
+	// resets are always retried. Note that this overrides the retry configured by the BackendTrafficPolicy
+	// attached to the generated HTTPRoute. Currently, only the chat completion requests are classified.
+	//
+	// +optional
+	Fallback *AIGatewayRouteRuleFallback `json:"fallback,omitempty"`
+
+	// AdaptiveLoadBalancing enables the selection of the backends of this rule based on their observed latencies
+	// and error rates instead of the static weights, so that the traffic moves away from a degraded backend,
+	// e.g. an Azure OpenAI deployment in a region with an elevated latency.
+	//
+	// The AI Gateway filter tracks the time to first token, the inter-token latency and the error rate of each backend
+	// and selects the backend for each request with the probability proportional to its weight divided by its score,
+	// where the score is the sum of the moving averages of the latencies divided by the success rate.
+	// The backends failing consecutively are ejected from the selection for a while.
+	//
+	// This is implemented by generating one additional HTTPRoute rule per backend of this rule, which is matched by
+	// the backend selected by the AI Gateway filter. The other backends of the rule remain available as the lower priority
+	// ones of the selected backend, so this can be combined with Fallback. Only the requests whose model matches the
+	// exact "x-ai-eg-model" header matches of this rule are balanced.
+	//
+	// +optional
+	AdaptiveLoadBalancing *AIGatewayRouteRuleAdaptiveLoadBalancing `json:"adaptiveLoadBalancing,omitempty"`
+
+	// Mirror configures the replay of a sample of the chat completion requests of this rule to a shadow backend,
+	// e.g. a candidate model to be evaluated before switching the traffic to it.
+	//
+	// After the response of the primary backend is served to the client, the AI Gateway filter asynchronously sends
+	// the original request to the shadow backend, translated into its API schema and authenticated with its
+	// BackendSecurityPolicy, and writes the pair of the primary and the shadow responses, along with their latencies
+	// and token usages, to the configured sink for the offline comparison. The shadow response is never returned
+	// to the client, and the failures of the shadow backend and the sink don't affect the client response.
+	//
+	// Only the requests successfully served by the primary backend are mirrored.
+	//
+	// +optional
+	Mirror *AIGatewayRouteRuleMirror `json:"mirror,omitempty"`
+
+	// SessionAffinity sends the requests of the same session to the same backend of this rule, so that the multi-turn
+	// conversations keep hitting the prompt cache of the same provider deployment or region.
+	//
+	// The AI Gateway filter computes the session key of each chat completion request from either a request header or
+	// the leading messages of the conversation, and the backend is selected by the consistent hashing of the key
+	// among the backends of the same priority. The requests without the key are load balanced as usual.
+	//
+	// Only the chat completion requests whose model matches the exact "x-ai-eg-model" header matches of this rule
+	// are keyed.
+	//
+	// +optional
+	SessionAffinity *AIGatewayRouteRuleSessionAffinity `json:"sessionAffinity,omitempty"`
+
+	// Hedging sends the chat completion request in parallel to another backend of this rule when the response of
+	// the backend doesn't start within the delay, and serves the response which starts first. This trades the cost
+	// for the tail latency, e.g. for the latency-critical features like autocomplete.
+	//
+	// The hedged request is sent to the next priority of the backends if any, otherwise to another backend of the same
+	// priority, and is translated into the schema of that backend. Once the response of either request starts,
+	// the other requests are cancelled. The cancelled requests are accounted for with the same input tokens as the
+	// served one in the request costs, since the providers may have processed their prompts.
+	//
+	// This is implemented by the hedging on the per-try timeout of Envoy, which is set by the AI Gateway filter only
+	// on the chat completion requests whose model matches the exact "x-ai-eg-model" header matches of this rule.
+	// This can be combined with Fallback, in which case the number of the retries is shared.
+	//
+	// +optional
+	Hedging *AIGatewayRouteRuleHedging `json:"hedging,omitempty"`
+
+	// RequestPolicy limits the chat completion requests of this rule, e.g. to keep the clients from asking for the
+	// excessive number of tokens or sending the long conversation histories which cost a fortune.
+	//
+	// The AI Gateway filter enforces this before the request is routed. The requests violating the limits are rejected
+	// with the 400 status code and an OpenAI-style error of the "invalid_request_error" type, unless the limit clamps
+	// the request instead. The clamped fields are recorded in the "request_policy_clamped" dynamic metadata.
+	//
+	// Only the chat completion requests are supported. The requests to the other endpoints whose model matches this
+	// rule are rejected with the 400 status code so that they don't bypass the limits.
+	//
+	// Every match of every rule of the route must consist only of the exact header matches including the
+	// "x-ai-eg-model" header, so that every request routed to this rule is limited. Otherwise, the route is not
+	// accepted.
+	//
+	// +optional
+	RequestPolicy *AIGatewayRouteRuleRequestPolicy `json:"requestPolicy,omitempty"`
+
 	// ModelsOwnedBy represents the owner of the running models serving by the backends,
 	// which will be exported as the field of "OwnedBy" in openai-compatible API "/models".
 	//
@@ -286,6 +1039,407 @@ type AIGatewayRouteRule struct {
 	ModelsCreatedAt *metav1.Time `json:"modelsCreatedAt,omitempty"`
 }
 
+// AIGatewayRouteRuleFallback configures the retry of the failed requests on the other backends of the rule.
+type AIGatewayRouteRuleFallback struct {
+	// RetryOn is the list of the failures of the backend on which the request is retried.
+	//
+	// Default is [ServerError, Throttled].
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=3
+	// +kubebuilder:validation:items:Enum=ContextLengthExceeded;Throttled;ServerError
+	RetryOn []AIGatewayRouteRuleFallbackCondition `json:"retryOn,omitempty"`
+
+	// NumRetries is the maximum number of retries of a single request.
+	//
+	// Default is 1.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=10
+	NumRetries *int32 `json:"numRetries,omitempty"`
+}
+
+// AIGatewayRouteRuleAdaptiveLoadBalancing configures the selection of the backends of the rule based on their
+// observed latencies and error rates.
+type AIGatewayRouteRuleAdaptiveLoadBalancing struct {
+	// Strategy is the strategy of the selection of the backends.
+	//
+	// LeastLatency prefers the backends with the lower latency and error rate.
+	// LowestCost selects the cheapest backend which is not ejected by the outlier ejection, falling back to
+	// the next cheapest one. The price of each backend is the sum of the input and output prices specified in the
+	// Models of the AIGatewayRoute for the single model matched by this rule. The backends without a price are
+	// selected only when all the backends with a price are ejected.
+	//
+	// Default is LeastLatency.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=LeastLatency;LowestCost
+	// +kubebuilder:default=LeastLatency
+	Strategy AIGatewayRouteRuleAdaptiveLoadBalancingStrategy `json:"strategy,omitempty"`
+
+	// LatencyWindow is the half-life of the exponentially weighted moving averages of the time to first token
+	// and the inter-token latency of each backend. The shorter the window is, the faster the selection reacts
+	// to the changes of the latencies.
+	//
+	// Default is 30s.
+	//
+	// +optional
+	LatencyWindow *metav1.Duration `json:"latencyWindow,omitempty"`
+
+	// ErrorRateWindow is the half-life of the exponentially weighted moving average of the error rate of each backend.
+	// Only the 5xx and 429 responses are counted as errors.
+	//
+	// Default is 60s.
+	//
+	// +optional
+	ErrorRateWindow *metav1.Duration `json:"errorRateWindow,omitempty"`
+
+	// OutlierEjection configures the temporary removal of the backends failing consecutively from the selection.
+	//
+	// If not specified, the backends are ejected after 5 consecutive errors for 30s, multiplied by the number of
+	// the consecutive ejections, with at most 50% of the backends ejected at the same time.
+	//
+	// +optional
+	OutlierEjection *AIGatewayRouteRuleOutlierEjection `json:"outlierEjection,omitempty"`
+}
+
+// AIGatewayRouteRuleAdaptiveLoadBalancingStrategy is the strategy of the adaptive load balancing.
+type AIGatewayRouteRuleAdaptiveLoadBalancingStrategy string
+
+const (
+	// AIGatewayRouteRuleAdaptiveLoadBalancingStrategyLeastLatency prefers the backends with the lower latency and error rate.
+	AIGatewayRouteRuleAdaptiveLoadBalancingStrategyLeastLatency AIGatewayRouteRuleAdaptiveLoadBalancingStrategy = "LeastLatency"
+	// AIGatewayRouteRuleAdaptiveLoadBalancingStrategyLowestCost selects the cheapest backend.
+	AIGatewayRouteRuleAdaptiveLoadBalancingStrategyLowestCost AIGatewayRouteRuleAdaptiveLoadBalancingStrategy = "LowestCost"
+)
+
+// AIGatewayRouteRuleOutlierEjection configures the temporary removal of the backends failing consecutively.
+type AIGatewayRouteRuleOutlierEjection struct {
+	// ConsecutiveErrors is the number of the consecutive errors of a backend that triggers its ejection.
+	//
+	// Default is 5.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	ConsecutiveErrors *int32 `json:"consecutiveErrors,omitempty"`
+
+	// BaseEjectionTime is the duration of the first ejection of a backend. The duration is multiplied by the number of
+	// the consecutive ejections of the backend.
+	//
+	// Default is 30s.
+	//
+	// +optional
+	BaseEjectionTime *metav1.Duration `json:"baseEjectionTime,omitempty"`
+
+	// MaxEjectionPercent is the maximum percentage of the backends of the rule that can be ejected at the same time.
+	//
+	// Default is 50.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	MaxEjectionPercent *int32 `json:"maxEjectionPercent,omitempty"`
+}
+
+// AIGatewayRouteRuleMirror configures the replay of the requests of the rule to a shadow backend.
+type AIGatewayRouteRuleMirror struct {
+	// BackendRef is the name of the AIServiceBackend in the same namespace to which the requests are mirrored.
+	// The API schema and the BackendSecurityPolicy of the backend are used to call it.
+	//
+	// +kubebuilder:validation:MinLength=1
+	BackendRef string `json:"backendRef"`
+
+	// Endpoint is the base URL of the shadow backend called directly by the AI Gateway filter,
+	// e.g. "https://api.openai.com". The path of the chat completions endpoint is appended according to the API schema.
+	//
+	// +kubebuilder:validation:Pattern=`^https?://`
+	Endpoint string `json:"endpoint"`
+
+	// ModelNameOverride is the name of the model sent to the shadow backend. If not specified, the model of
+	// the original request is sent as is.
+	//
+	// +optional
+	ModelNameOverride string `json:"modelNameOverride,omitempty"`
+
+	// Percentage is the percentage of the requests of this rule that are mirrored.
+	//
+	// Default is 100.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Percentage *int32 `json:"percentage,omitempty"`
+
+	// Timeout is the timeout of each mirrored request.
+	//
+	// Default is 60s.
+	//
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// Sink is where the pairs of the primary and the shadow responses are written.
+	Sink AIGatewayRouteRuleMirrorSink `json:"sink"`
+}
+
+// AIGatewayRouteRuleMirrorSink configures where the mirrored responses are written.
+//
+// Each record contains the original request, the name of the route rule, and for each of the primary and the shadow
+// backends, the name of the backend, the status code, the response in the OpenAI format, the latency and
+// the token usage. The streaming responses are assembled into the non-streaming ones.
+//
+// +kubebuilder:validation:XValidation:rule="self.type == 'File' ? has(self.file) : !has(self.file)",message="file must be specified only for the File type"
+// +kubebuilder:validation:XValidation:rule="self.type == 'OTLPLogs' || !has(self.otlpLogs)",message="otlpLogs must be specified only for the OTLPLogs type"
+type AIGatewayRouteRuleMirrorSink struct {
+	// Type is the type of the sink.
+	//
+	// File appends each record as a line of JSON to a file in the AI Gateway filter container.
+	// OTLPLogs exports each record as an OpenTelemetry log record over OTLP/HTTP.
+	//
+	// +kubebuilder:validation:Enum=File;OTLPLogs
+	Type AIGatewayRouteRuleMirrorSinkType `json:"type"`
+
+	// File configures the File sink.
+	//
+	// +optional
+	File *AIGatewayRouteRuleMirrorFileSink `json:"file,omitempty"`
+
+	// OTLPLogs configures the OTLPLogs sink. If not specified, the standard OTEL_EXPORTER_OTLP_* environment
+	// variables of the AI Gateway filter are used.
+	//
+	// +optional
+	OTLPLogs *AIGatewayRouteRuleMirrorOTLPLogsSink `json:"otlpLogs,omitempty"`
+}
+
+// AIGatewayRouteRuleMirrorSinkType is the type of the sink of the mirrored responses.
+type AIGatewayRouteRuleMirrorSinkType string
+
+const (
+	// AIGatewayRouteRuleMirrorSinkTypeFile appends the records to a file as JSON lines.
+	AIGatewayRouteRuleMirrorSinkTypeFile AIGatewayRouteRuleMirrorSinkType = "File"
+	// AIGatewayRouteRuleMirrorSinkTypeOTLPLogs exports the records as OpenTelemetry log records.
+	AIGatewayRouteRuleMirrorSinkTypeOTLPLogs AIGatewayRouteRuleMirrorSinkType = "OTLPLogs"
+)
+
+// AIGatewayRouteRuleMirrorFileSink configures the file to which the mirrored responses are appended.
+type AIGatewayRouteRuleMirrorFileSink struct {
+	// Path is the absolute path of the file in the AI Gateway filter container. The file is created if it doesn't
+	// exist, and the records are appended otherwise, so its directory must be writable by the filter.
+	//
+	// +kubebuilder:validation:Pattern=`^/`
+	Path string `json:"path"`
+}
+
+// AIGatewayRouteRuleMirrorOTLPLogsSink configures the OTLP/HTTP endpoint to which the mirrored responses are exported.
+type AIGatewayRouteRuleMirrorOTLPLogsSink struct {
+	// Endpoint is the URL of the OTLP/HTTP logs endpoint, e.g. "http://otel-collector:4318/v1/logs".
+	//
+	// +kubebuilder:validation:Pattern=`^https?://`
+	Endpoint string `json:"endpoint"`
+}
+
+// AIGatewayRouteRuleHedging configures the hedging of the requests on the other backends of the rule.
+type AIGatewayRouteRuleHedging struct {
+	// Delay is the time to wait for the response headers of the backend before sending the hedged request, which is
+	// set as the per-try timeout of Envoy. Most providers send the headers of the streaming responses together with
+	// the first token, in which case this is the time to first token. The backends sending the headers earlier are
+	// not hedged even when they are slow to generate the first token.
+	Delay metav1.Duration `json:"delay"`
+
+	// MaxHedgedRequests is the maximum number of the hedged requests of a single request. Each hedged request is sent
+	// after the delay since the previous one.
+	//
+	// Default is 1.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=3
+	MaxHedgedRequests *int32 `json:"maxHedgedRequests,omitempty"`
+}
+
+// AIGatewayRouteRuleRequestPolicy configures the limits of the chat completion requests of the rule.
+//
+// The defaults are set before the limits are enforced, so a default of "max_completion_tokens" is clamped by MaxTokens
+// as well.
+type AIGatewayRouteRuleRequestPolicy struct {
+	// MaxTokens limits both "max_tokens" and "max_completion_tokens" of the requests. The requests without them are
+	// not limited, which can be avoided with the default of "max_completion_tokens".
+	//
+	// +optional
+	MaxTokens *AIGatewayRouteRuleRequestPolicyLimit `json:"maxTokens,omitempty"`
+
+	// MaxMessages limits the number of the messages of the requests. Clamp drops the oldest messages following the
+	// leading system and developer messages, along with the tool messages left without the assistant message calling
+	// them. The requests whose leading system and developer messages alone exceed the limit are rejected.
+	//
+	// +optional
+	MaxMessages *AIGatewayRouteRuleRequestPolicyLimit `json:"maxMessages,omitempty"`
+
+	// MaxN limits "n", the number of the choices generated for each request.
+	//
+	// +optional
+	MaxN *AIGatewayRouteRuleRequestPolicyLimit `json:"maxN,omitempty"`
+
+	// MaxPromptBytes is the maximum size in bytes of the encoded "messages" of the requests.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxPromptBytes *int64 `json:"maxPromptBytes,omitempty"`
+
+	// MaxEstimatedPromptTokens is the maximum number of the tokens of the prompt of the requests, which is estimated
+	// from the size of the text in the same way as the quota without tokenizing it.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxEstimatedPromptTokens *int64 `json:"maxEstimatedPromptTokens,omitempty"`
+
+	// AllowedModalities is the list of the modalities the requests may use. The image content parts use Image, the
+	// input audio content parts and the audio output use Audio, and the text content uses Text.
+	//
+	// When empty, the requests may use any modality.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=3
+	// +kubebuilder:validation:items:Enum=Text;Image;Audio
+	// +listType=set
+	AllowedModalities []AIGatewayRouteModelModality `json:"allowedModalities,omitempty"`
+
+	// ForbiddenFields is the list of the top-level fields of the request body the requests must not set,
+	// e.g. "logprobs" or "audio". The fields set to null are ignored.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=32
+	// +kubebuilder:validation:items:Pattern=`^[a-z_][a-z0-9_]*$`
+	// +listType=set
+	ForbiddenFields []string `json:"forbiddenFields,omitempty"`
+
+	// Defaults is the list of the values set to the top-level fields of the request body which the requests don't
+	// set, e.g. "max_completion_tokens" or "temperature".
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=32
+	// +listType=map
+	// +listMapKey=field
+	Defaults []AIGatewayRouteRuleRequestPolicyDefault `json:"defaults,omitempty"`
+}
+
+// AIGatewayRouteRuleRequestPolicyLimit is a limit of a numeric value of the requests.
+type AIGatewayRouteRuleRequestPolicyLimit struct {
+	// Max is the maximum value.
+	//
+	// +kubebuilder:validation:Minimum=1
+	Max int64 `json:"max"`
+
+	// Action is what the AI Gateway filter does with the requests exceeding the maximum.
+	//
+	// Reject rejects the request.
+	// Clamp lowers the value to the maximum, and lets the request through.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=Reject;Clamp
+	// +kubebuilder:default=Reject
+	Action AIGatewayRouteRuleRequestPolicyLimitAction `json:"action,omitempty"`
+}
+
+// AIGatewayRouteRuleRequestPolicyLimitAction is what the AI Gateway filter does with the requests exceeding a limit.
+type AIGatewayRouteRuleRequestPolicyLimitAction string
+
+const (
+	// AIGatewayRouteRuleRequestPolicyLimitActionReject rejects the requests exceeding the limit.
+	AIGatewayRouteRuleRequestPolicyLimitActionReject AIGatewayRouteRuleRequestPolicyLimitAction = "Reject"
+	// AIGatewayRouteRuleRequestPolicyLimitActionClamp lowers the value of the requests exceeding the limit.
+	AIGatewayRouteRuleRequestPolicyLimitActionClamp AIGatewayRouteRuleRequestPolicyLimitAction = "Clamp"
+)
+
+// AIGatewayRouteRuleRequestPolicyDefault is the default value of a top-level field of the request body.
+//
+// +kubebuilder:validation:XValidation:rule="!(self.field in ['model', 'messages', 'stream'])",message="field must not be model, messages or stream"
+type AIGatewayRouteRuleRequestPolicyDefault struct {
+	// Field is the name of the top-level field of the request body.
+	//
+	// +kubebuilder:validation:Pattern=`^[a-z_][a-z0-9_]*$`
+	Field string `json:"field"`
+
+	// Value is the JSON value set to the field.
+	//
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Value apiextensionsv1.JSON `json:"value"`
+}
+
+// AIGatewayRouteRuleSessionAffinity configures how the session key of the requests is computed.
+//
+// +kubebuilder:validation:XValidation:rule="self.type == 'Header' ? has(self.header) : !has(self.header)",message="header must be specified only for the Header type"
+// +kubebuilder:validation:XValidation:rule="self.type == 'ConversationPrefix' || !has(self.conversationPrefix)",message="conversationPrefix must be specified only for the ConversationPrefix type"
+type AIGatewayRouteRuleSessionAffinity struct {
+	// Type is the source of the session key.
+	//
+	// Header uses the value of a request header set by the client, e.g. "x-session-id".
+	// ConversationPrefix uses the hash of the leading messages of the conversation, which stay the same across
+	// the turns of a conversation, so that no change is required on the client.
+	//
+	// +kubebuilder:validation:Enum=Header;ConversationPrefix
+	Type AIGatewayRouteRuleSessionAffinityType `json:"type"`
+
+	// Header configures the Header type.
+	//
+	// +optional
+	Header *AIGatewayRouteRuleSessionAffinityHeader `json:"header,omitempty"`
+
+	// ConversationPrefix configures the ConversationPrefix type.
+	//
+	// +optional
+	ConversationPrefix *AIGatewayRouteRuleSessionAffinityConversationPrefix `json:"conversationPrefix,omitempty"`
+}
+
+// AIGatewayRouteRuleSessionAffinityType is the source of the session key.
+type AIGatewayRouteRuleSessionAffinityType string
+
+const (
+	// AIGatewayRouteRuleSessionAffinityTypeHeader uses the value of a request header as the session key.
+	AIGatewayRouteRuleSessionAffinityTypeHeader AIGatewayRouteRuleSessionAffinityType = "Header"
+	// AIGatewayRouteRuleSessionAffinityTypeConversationPrefix uses the hash of the leading messages as the session key.
+	AIGatewayRouteRuleSessionAffinityTypeConversationPrefix AIGatewayRouteRuleSessionAffinityType = "ConversationPrefix"
+)
+
+// AIGatewayRouteRuleSessionAffinityHeader configures the request header used as the session key.
+type AIGatewayRouteRuleSessionAffinityHeader struct {
+	// Name is the name of the request header, e.g. "x-session-id".
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=256
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9!#$%&'*+.^_|~-]+$`
+	Name string `json:"name"`
+}
+
+// AIGatewayRouteRuleSessionAffinityConversationPrefix configures the leading messages hashed into the session key.
+type AIGatewayRouteRuleSessionAffinityConversationPrefix struct {
+	// UserMessages is the number of the user messages in the hashed prefix. The prefix consists of all the messages
+	// up to and including the given number of the user messages, e.g. the system prompt and the first user message
+	// by default. The requests with fewer user messages are not keyed.
+	//
+	// Default is 1.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	UserMessages *int32 `json:"userMessages,omitempty"`
+}
+
+// AIGatewayRouteRuleFallbackCondition specifies the kind of the failures of the backend that trigger the retry.
+type AIGatewayRouteRuleFallbackCondition string
+
+const (
+	// AIGatewayRouteRuleFallbackConditionContextLengthExceeded matches the errors of the requests exceeding the
+	// context window of the model. This is useful when the fallback backend serves a model with a larger context window.
+	AIGatewayRouteRuleFallbackConditionContextLengthExceeded AIGatewayRouteRuleFallbackCondition = "ContextLengthExceeded"
+	// AIGatewayRouteRuleFallbackConditionThrottled matches the 429 responses and the throttling errors of the providers
+	// such as ThrottlingException of AWS Bedrock and RESOURCE_EXHAUSTED of GCP Vertex AI.
+	AIGatewayRouteRuleFallbackConditionThrottled AIGatewayRouteRuleFallbackCondition = "Throttled"
+	// AIGatewayRouteRuleFallbackConditionServerError matches the 5xx responses.
+	AIGatewayRouteRuleFallbackConditionServerError AIGatewayRouteRuleFallbackCondition = "ServerError"
+)
+
 // AIGatewayRouteRuleBackendRef is a reference to a backend with a weight.
 // It can reference either an AIServiceBackend or an InferencePool resource.
 //
@@ -6,6 +6,8 @@
 package v1alpha1
 
 import (
+	"time"
+
 	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
 
@@ -82,3 +84,127 @@ func (r *AIGatewayRouteRule) HasAIServiceBackends() bool {
 	}
 	return false
 }
+
+// GetRetryOnOrDefault returns the failures on which the request is retried with the default applied when not specified.
+func (f *AIGatewayRouteRuleFallback) GetRetryOnOrDefault() []AIGatewayRouteRuleFallbackCondition {
+	if f == nil || len(f.RetryOn) == 0 {
+		return []AIGatewayRouteRuleFallbackCondition{
+			AIGatewayRouteRuleFallbackConditionServerError,
+			AIGatewayRouteRuleFallbackConditionThrottled,
+		}
+	}
+	return f.RetryOn
+}
+
+// GetNumRetriesOrDefault returns the maximum number of retries with the default applied when not specified.
+func (f *AIGatewayRouteRuleFallback) GetNumRetriesOrDefault() int32 {
+	if f == nil || f.NumRetries == nil {
+		return 1
+	}
+	return *f.NumRetries
+}
+
+// GetLatencyWindowOrDefault returns the half-life of the latency averages with the default applied when not specified.
+func (a *AIGatewayRouteRuleAdaptiveLoadBalancing) GetLatencyWindowOrDefault() time.Duration {
+	if a == nil || a.LatencyWindow == nil {
+		return 30 * time.Second
+	}
+	return a.LatencyWindow.Duration
+}
+
+// GetErrorRateWindowOrDefault returns the half-life of the error rate average with the default applied when not specified.
+func (a *AIGatewayRouteRuleAdaptiveLoadBalancing) GetErrorRateWindowOrDefault() time.Duration {
+	if a == nil || a.ErrorRateWindow == nil {
+		return 60 * time.Second
+	}
+	return a.ErrorRateWindow.Duration
+}
+
+// GetConsecutiveErrorsOrDefault returns the number of the consecutive errors triggering the ejection with the default
+// applied when not specified.
+func (o *AIGatewayRouteRuleOutlierEjection) GetConsecutiveErrorsOrDefault() int32 {
+	if o == nil || o.ConsecutiveErrors == nil {
+		return 5
+	}
+	return *o.ConsecutiveErrors
+}
+
+// GetBaseEjectionTimeOrDefault returns the duration of the first ejection with the default applied when not specified.
+func (o *AIGatewayRouteRuleOutlierEjection) GetBaseEjectionTimeOrDefault() time.Duration {
+	if o == nil || o.BaseEjectionTime == nil {
+		return 30 * time.Second
+	}
+	return o.BaseEjectionTime.Duration
+}
+
+// GetMaxEjectionPercentOrDefault returns the maximum percentage of the ejected backends with the default applied
+// when not specified.
+func (o *AIGatewayRouteRuleOutlierEjection) GetMaxEjectionPercentOrDefault() int32 {
+	if o == nil || o.MaxEjectionPercent == nil {
+		return 50
+	}
+	return *o.MaxEjectionPercent
+}
+
+// GetPercentageOrDefault returns the percentage of the mirrored requests with the default applied when not specified.
+func (m *AIGatewayRouteRuleMirror) GetPercentageOrDefault() int32 {
+	if m == nil || m.Percentage == nil {
+		return 100
+	}
+	return *m.Percentage
+}
+
+// GetTimeoutOrDefault returns the timeout of each mirrored request with the default applied when not specified.
+func (m *AIGatewayRouteRuleMirror) GetTimeoutOrDefault() time.Duration {
+	if m == nil || m.Timeout == nil {
+		return 60 * time.Second
+	}
+	return m.Timeout.Duration
+}
+
+// GetUserMessagesOrDefault returns the number of the user messages in the hashed prefix with the default applied
+// when not specified.
+func (c *AIGatewayRouteRuleSessionAffinityConversationPrefix) GetUserMessagesOrDefault() int32 {
+	if c == nil || c.UserMessages == nil {
+		return 1
+	}
+	return *c.UserMessages
+}
+
+// GetMaxHedgedRequestsOrDefault returns the maximum number of the hedged requests with the default applied when
+// not specified.
+func (h *AIGatewayRouteRuleHedging) GetMaxHedgedRequestsOrDefault() int32 {
+	if h == nil || h.MaxHedgedRequests == nil {
+		return 1
+	}
+	return *h.MaxHedgedRequests
+}
+
+// ResolveHTTPRouteRuleIndex returns the index of the rule of this spec for which the rule of the generated HTTPRoute
+// at the given index is generated.
+//
+// The generated HTTPRoute has the rules in the same order as this spec, followed by the rule for the requests not
+// matching any of them, and then by one rule per backend reference of each rule with AdaptiveLoadBalancing in order.
+// selectedBackendRef is the index of the backend reference selected by the latter rules, or -1 otherwise.
+// ok is false if the index doesn't correspond to any rule of this spec.
+func (s *AIGatewayRouteSpec) ResolveHTTPRouteRuleIndex(httpRouteRuleIndex int) (ruleIndex, selectedBackendRef int, ok bool) {
+	if httpRouteRuleIndex < 0 || httpRouteRuleIndex == len(s.Rules) {
+		return 0, 0, false
+	}
+	if httpRouteRuleIndex < len(s.Rules) {
+		return httpRouteRuleIndex, -1, true
+	}
+	// Skip the rule for the requests not matching any rule.
+	i := len(s.Rules) + 1
+	for ruleIndex = range s.Rules {
+		rule := &s.Rules[ruleIndex]
+		if rule.AdaptiveLoadBalancing == nil {
+			continue
+		}
+		if httpRouteRuleIndex < i+len(rule.BackendRefs) {
+			return ruleIndex, httpRouteRuleIndex - i, true
+		}
+		i += len(rule.BackendRefs)
+	}
+	return 0, 0, false
+}
@@ -7,8 +7,10 @@ package v1alpha1
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
 	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
@@ -260,3 +262,99 @@ func TestAIGatewayRouteRule_HasAIServiceBackends(t *testing.T) {
 		})
 	}
 }
+
+func TestAIGatewayRouteRuleFallback_Defaults(t *testing.T) {
+	var f *AIGatewayRouteRuleFallback
+	require.Equal(t, []AIGatewayRouteRuleFallbackCondition{
+		AIGatewayRouteRuleFallbackConditionServerError,
+		AIGatewayRouteRuleFallbackConditionThrottled,
+	}, f.GetRetryOnOrDefault())
+	require.Equal(t, int32(1), f.GetNumRetriesOrDefault())
+
+	f = &AIGatewayRouteRuleFallback{
+		RetryOn:    []AIGatewayRouteRuleFallbackCondition{AIGatewayRouteRuleFallbackConditionContextLengthExceeded},
+		NumRetries: ptr.To[int32](3),
+	}
+	require.Equal(t, []AIGatewayRouteRuleFallbackCondition{AIGatewayRouteRuleFallbackConditionContextLengthExceeded}, f.GetRetryOnOrDefault())
+	require.Equal(t, int32(3), f.GetNumRetriesOrDefault())
+}
+
+func TestAIGatewayRouteRuleAdaptiveLoadBalancing_Defaults(t *testing.T) {
+	var a *AIGatewayRouteRuleAdaptiveLoadBalancing
+	require.Equal(t, 30*time.Second, a.GetLatencyWindowOrDefault())
+	require.Equal(t, 60*time.Second, a.GetErrorRateWindowOrDefault())
+	var o *AIGatewayRouteRuleOutlierEjection
+	require.Equal(t, int32(5), o.GetConsecutiveErrorsOrDefault())
+	require.Equal(t, 30*time.Second, o.GetBaseEjectionTimeOrDefault())
+	require.Equal(t, int32(50), o.GetMaxEjectionPercentOrDefault())
+
+	a = &AIGatewayRouteRuleAdaptiveLoadBalancing{
+		LatencyWindow:   &metav1.Duration{Duration: time.Second},
+		ErrorRateWindow: &metav1.Duration{Duration: 2 * time.Second},
+	}
+	require.Equal(t, time.Second, a.GetLatencyWindowOrDefault())
+	require.Equal(t, 2*time.Second, a.GetErrorRateWindowOrDefault())
+	o = &AIGatewayRouteRuleOutlierEjection{
+		ConsecutiveErrors:  ptr.To[int32](1),
+		BaseEjectionTime:   &metav1.Duration{Duration: time.Minute},
+		MaxEjectionPercent: ptr.To[int32](100),
+	}
+	require.Equal(t, int32(1), o.GetConsecutiveErrorsOrDefault())
+	require.Equal(t, time.Minute, o.GetBaseEjectionTimeOrDefault())
+	require.Equal(t, int32(100), o.GetMaxEjectionPercentOrDefault())
+}
+
+func TestAIGatewayRouteRuleMirror_Defaults(t *testing.T) {
+	var m *AIGatewayRouteRuleMirror
+	require.Equal(t, int32(100), m.GetPercentageOrDefault())
+	require.Equal(t, 60*time.Second, m.GetTimeoutOrDefault())
+
+	m = &AIGatewayRouteRuleMirror{Percentage: ptr.To[int32](0), Timeout: &metav1.Duration{Duration: time.Second}}
+	require.Equal(t, int32(0), m.GetPercentageOrDefault())
+	require.Equal(t, time.Second, m.GetTimeoutOrDefault())
+}
+
+func TestAIGatewayRouteRuleSessionAffinityConversationPrefix_Defaults(t *testing.T) {
+	var c *AIGatewayRouteRuleSessionAffinityConversationPrefix
+	require.Equal(t, int32(1), c.GetUserMessagesOrDefault())
+	c = &AIGatewayRouteRuleSessionAffinityConversationPrefix{UserMessages: ptr.To[int32](3)}
+	require.Equal(t, int32(3), c.GetUserMessagesOrDefault())
+}
+
+func TestAIGatewayRouteRuleHedging_Defaults(t *testing.T) {
+	var h *AIGatewayRouteRuleHedging
+	require.Equal(t, int32(1), h.GetMaxHedgedRequestsOrDefault())
+	h = &AIGatewayRouteRuleHedging{MaxHedgedRequests: ptr.To[int32](3)}
+	require.Equal(t, int32(3), h.GetMaxHedgedRequestsOrDefault())
+}
+
+func TestAIGatewayRouteSpec_ResolveHTTPRouteRuleIndex(t *testing.T) {
+	adaptive := &AIGatewayRouteRuleAdaptiveLoadBalancing{}
+	s := &AIGatewayRouteSpec{Rules: []AIGatewayRouteRule{
+		{BackendRefs: []AIGatewayRouteRuleBackendRef{{Name: "a"}, {Name: "b"}}, AdaptiveLoadBalancing: adaptive},
+		{BackendRefs: []AIGatewayRouteRuleBackendRef{{Name: "c"}}},
+		{BackendRefs: []AIGatewayRouteRuleBackendRef{{Name: "d"}, {Name: "e"}, {Name: "f"}}, AdaptiveLoadBalancing: adaptive},
+	}}
+	for _, tc := range []struct {
+		index, expRule, expRef int
+		expOK                  bool
+	}{
+		{index: -1},
+		{index: 0, expRule: 0, expRef: -1, expOK: true},
+		{index: 2, expRule: 2, expRef: -1, expOK: true},
+		// The rule for the requests not matching any rule.
+		{index: 3},
+		{index: 4, expRule: 0, expRef: 0, expOK: true},
+		{index: 5, expRule: 0, expRef: 1, expOK: true},
+		{index: 6, expRule: 2, expRef: 0, expOK: true},
+		{index: 8, expRule: 2, expRef: 2, expOK: true},
+		{index: 9},
+	} {
+		rule, ref, ok := s.ResolveHTTPRouteRuleIndex(tc.index)
+		require.Equal(t, tc.expOK, ok, tc.index)
+		if ok {
+			require.Equal(t, tc.expRule, rule, tc.index)
+			require.Equal(t, tc.expRef, ref, tc.index)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+// AIGatewayVirtualKeyPolicy specifies the virtual keys issued by the gateway to the clients of the AIGatewayRoutes.
+//
+// Instead of handing out the credentials of the providers, each team gets its own virtual key, which can be revoked
+// individually without rotating the provider credentials. The AI Gateway filter validates the virtual key of each
+// request to the models of the targeted routes, and rejects the request with 401 when the key is missing or unknown,
+// and with 403 when the key is not allowed to use the model. The virtual key is stripped from the request before
+// the credential of the provider is injected by the BackendSecurityPolicy, so it is never sent to the backends.
+//
+// The team ID of the key is set to the request header, which can be listed in the metricsRequestHeaderLabels of the
+// controller to attribute the usage to the teams.
+//
+// The keys are read from the Secrets by the controller, and only their SHA-256 hashes are passed to the filter.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.conditions[-1:].type`
+// +kubebuilder:metadata:labels="gateway.networking.k8s.io/policy=direct"
+type AIGatewayVirtualKeyPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              AIGatewayVirtualKeyPolicySpec `json:"spec,omitempty"`
+	// Status defines the status details of the AIGatewayVirtualKeyPolicy.
+	Status AIGatewayVirtualKeyPolicyStatus `json:"status,omitempty"`
+}
+
+// AIGatewayVirtualKeyPolicyList contains a list of AIGatewayVirtualKeyPolicy.
+//
+// +kubebuilder:object:root=true
+type AIGatewayVirtualKeyPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AIGatewayVirtualKeyPolicy `json:"items"`
+}
+
+// AIGatewayVirtualKeyPolicySpec details the AIGatewayVirtualKeyPolicy configuration.
+//
+// +kubebuilder:validation:XValidation:rule="self.type == 'Redis' ? has(self.redis) : true", message="redis must be specified when type is Redis"
+type AIGatewayVirtualKeyPolicySpec struct {
+	// TargetRefs are the names of the AIGatewayRoute resources this AIGatewayVirtualKeyPolicy is being attached to.
+	// The policy applies to the models declared by the rules of the targeted routes. Every match of every rule of
+	// the targeted routes must consist only of the exact header matches including the model name header, otherwise
+	// the route is not accepted.
+	//
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=16
+	// +kubebuilder:validation:XValidation:rule="self.all(ref, ref.group == 'aigateway.envoyproxy.io' && ref.kind == 'AIGatewayRoute')", message="targetRefs must reference AIGatewayRoute resources"
+	TargetRefs []gwapiv1a2.LocalPolicyTargetReference `json:"targetRefs"`
+
+	// ClientKeyHeader is the request header carrying the virtual key. When this is "Authorization", the value is
+	// read in the Bearer scheme in the same way as the OpenAI clients send the API key, and the "x-api-key" header
+	// is also accepted in the same way as the Anthropic clients send the API key. Both headers are stripped.
+	//
+	// Default is "Authorization".
+	//
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	ClientKeyHeader *string `json:"clientKeyHeader,omitempty"`
+
+	// TeamIDHeader is the request header set to the team ID of the virtual key. The value sent by the client is
+	// overwritten.
+	//
+	// Default is "x-ai-eg-team-id".
+	//
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	TeamIDHeader *string `json:"teamIDHeader,omitempty"`
+
+	// Keys is the list of the virtual keys.
+	//
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=256
+	// +listType=map
+	// +listMapKey=name
+	Keys []AIGatewayVirtualKey `json:"keys"`
+
+	// Type specifies where the spend of the budgets of the keys is tracked.
+	//
+	// Default is Memory.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=Memory;Redis
+	// +kubebuilder:default=Memory
+	Type AIGatewayQuotaStoreType `json:"type,omitempty"`
+
+	// Redis is the configuration of the Redis-compatible server. This is required when the type is Redis.
+	//
+	// +optional
+	Redis *AIGatewayQuotaRedis `json:"redis,omitempty"`
+}
+
+// AIGatewayVirtualKey is a virtual key issued to a team.
+type AIGatewayVirtualKey struct {
+	// Name is the unique name of the key within the policy, which is used to identify the key in the logs and
+	// the budgets. Renaming the key resets the spend of its budgets.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// SecretRef is the reference to the Secret in the same namespace as the policy, which holds the virtual key in
+	// the "apiKey" data. The key is revoked by removing it from the policy, or rotated by updating the Secret.
+	SecretRef *gwapiv1.SecretObjectReference `json:"secretRef"`
+
+	// TeamID is the ID of the team to which the key is issued. This is set to the TeamIDHeader of the requests.
+	//
+	// +kubebuilder:validation:MinLength=1
+	TeamID string `json:"teamID"`
+
+	// AllowedModels is the list of the models the key can use. The model of the request is checked after the alias
+	// of the model is resolved.
+	//
+	// When this is empty, the key can use all the models of the targeted routes.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=64
+	AllowedModels []string `json:"allowedModels,omitempty"`
+
+	// Budgets is the list of the token and spend budgets of the key in the same way as the AIGatewayQuotaPolicy.
+	// The request is rejected with 429 when any of them is exhausted.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=8
+	Budgets []AIGatewayQuotaBudget `json:"budgets,omitempty"`
+}
@@ -72,6 +72,72 @@ type AIServiceBackendSpec struct {
 	// +optional
 	BackendSecurityPolicyRef *gwapiv1.LocalObjectReference `json:"backendSecurityPolicyRef,omitempty"`
 
+	// CircuitBreaker stops sending the requests to this backend for a while after its consecutive failures, so that
+	// an outage or an exhausted quota of the provider fails the requests fast, or lets them go to the other backends
+	// of the rule with the fallback, instead of retrying into it.
+	//
+	// The server errors are detected by the outlier detection of Envoy on the clusters of the route rules referencing
+	// this backend. The other failures, such as the throttling errors of the providers that are only known by parsing
+	// the error responses, are detected by the AI Gateway filter on the chat completion requests.
+	//
+	// The current state of the circuit breaker enforced by the AI Gateway filter is reported by the "CircuitBreakerOpen"
+	// condition of the status.
+	//
+	// +optional
+	CircuitBreaker *AIServiceBackendCircuitBreaker `json:"circuitBreaker,omitempty"`
+
 	// TODO: maybe add backend-level LLMRequestCost configuration that overrides the AIGatewayRoute-level LLMRequestCost.
 	// 	That may be useful for the backend that has a different cost calculation logic.
 }
+
+// AIServiceBackendCircuitBreaker configures the circuit breaker of an AIServiceBackend.
+type AIServiceBackendCircuitBreaker struct {
+	// ConsecutiveFailures is the number of the consecutive failures of the backend that opens the circuit breaker.
+	//
+	// Default is 5.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	ConsecutiveFailures *int32 `json:"consecutiveFailures,omitempty"`
+
+	// FailOn is the list of the classes of the errors counted as the failures. The other errors don't affect the
+	// circuit breaker.
+	//
+	// Default is [ServerError, Throttled].
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=2
+	// +kubebuilder:validation:items:Enum=Throttled;ServerError
+	FailOn []AIServiceBackendCircuitBreakerErrorClass `json:"failOn,omitempty"`
+
+	// OpenDuration is how long the circuit breaker stays open before letting the probe requests through.
+	//
+	// Default is 30s.
+	//
+	// +optional
+	OpenDuration *metav1.Duration `json:"openDuration,omitempty"`
+
+	// HalfOpenProbes is the number of the requests let through after the open duration. The circuit breaker is closed
+	// when all of them succeed, and opened again on the first failure.
+	//
+	// This only applies to the failures detected by the AI Gateway filter. Envoy lets all the requests through after
+	// the open duration, and opens the circuit breaker again after the consecutive failures.
+	//
+	// Default is 1.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	HalfOpenProbes *int32 `json:"halfOpenProbes,omitempty"`
+}
+
+// AIServiceBackendCircuitBreakerErrorClass is the class of the errors of the backend counted by the circuit breaker.
+type AIServiceBackendCircuitBreakerErrorClass string
+
+const (
+	// AIServiceBackendCircuitBreakerErrorClassThrottled matches the 429 responses and the throttling errors of the
+	// providers such as ThrottlingException of AWS Bedrock and RESOURCE_EXHAUSTED of GCP Vertex AI.
+	AIServiceBackendCircuitBreakerErrorClassThrottled AIServiceBackendCircuitBreakerErrorClass = "Throttled"
+	// AIServiceBackendCircuitBreakerErrorClassServerError matches the 5xx responses.
+	AIServiceBackendCircuitBreakerErrorClassServerError AIServiceBackendCircuitBreakerErrorClass = "ServerError"
+)
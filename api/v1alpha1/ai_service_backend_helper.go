@@ -0,0 +1,46 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package v1alpha1
+
+import "time"
+
+// GetConsecutiveFailuresOrDefault returns the number of the consecutive failures opening the circuit breaker with
+// the default applied when not specified.
+func (c *AIServiceBackendCircuitBreaker) GetConsecutiveFailuresOrDefault() int32 {
+	if c == nil || c.ConsecutiveFailures == nil {
+		return 5
+	}
+	return *c.ConsecutiveFailures
+}
+
+// GetFailOnOrDefault returns the classes of the errors counted as the failures with the default applied when not
+// specified.
+func (c *AIServiceBackendCircuitBreaker) GetFailOnOrDefault() []AIServiceBackendCircuitBreakerErrorClass {
+	if c == nil || len(c.FailOn) == 0 {
+		return []AIServiceBackendCircuitBreakerErrorClass{
+			AIServiceBackendCircuitBreakerErrorClassServerError,
+			AIServiceBackendCircuitBreakerErrorClassThrottled,
+		}
+	}
+	return c.FailOn
+}
+
+// GetOpenDurationOrDefault returns how long the circuit breaker stays open with the default applied when not specified.
+func (c *AIServiceBackendCircuitBreaker) GetOpenDurationOrDefault() time.Duration {
+	if c == nil || c.OpenDuration == nil {
+		return 30 * time.Second
+	}
+	return c.OpenDuration.Duration
+}
+
+// GetHalfOpenProbesOrDefault returns the number of the probe requests of the half-open circuit breaker with the
+// default applied when not specified.
+func (c *AIServiceBackendCircuitBreaker) GetHalfOpenProbesOrDefault() int32 {
+	if c == nil || c.HalfOpenProbes == nil {
+		return 1
+	}
+	return *c.HalfOpenProbes
+}
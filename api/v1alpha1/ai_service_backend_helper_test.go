@@ -0,0 +1,37 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestAIServiceBackendCircuitBreaker_Defaults(t *testing.T) {
+	var c *AIServiceBackendCircuitBreaker
+	require.Equal(t, int32(5), c.GetConsecutiveFailuresOrDefault())
+	require.Equal(t, []AIServiceBackendCircuitBreakerErrorClass{
+		AIServiceBackendCircuitBreakerErrorClassServerError,
+		AIServiceBackendCircuitBreakerErrorClassThrottled,
+	}, c.GetFailOnOrDefault())
+	require.Equal(t, 30*time.Second, c.GetOpenDurationOrDefault())
+	require.Equal(t, int32(1), c.GetHalfOpenProbesOrDefault())
+
+	c = &AIServiceBackendCircuitBreaker{
+		ConsecutiveFailures: ptr.To[int32](3),
+		FailOn:              []AIServiceBackendCircuitBreakerErrorClass{AIServiceBackendCircuitBreakerErrorClassThrottled},
+		OpenDuration:        &metav1.Duration{Duration: time.Minute},
+		HalfOpenProbes:      ptr.To[int32](2),
+	}
+	require.Equal(t, int32(3), c.GetConsecutiveFailuresOrDefault())
+	require.Equal(t, []AIServiceBackendCircuitBreakerErrorClass{AIServiceBackendCircuitBreakerErrorClassThrottled}, c.GetFailOnOrDefault())
+	require.Equal(t, time.Minute, c.GetOpenDurationOrDefault())
+	require.Equal(t, int32(2), c.GetHalfOpenProbesOrDefault())
+}
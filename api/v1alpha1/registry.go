@@ -14,6 +14,8 @@ func init() {
 	SchemeBuilder.Register(&AIGatewayRoute{}, &AIGatewayRouteList{})
 	SchemeBuilder.Register(&AIServiceBackend{}, &AIServiceBackendList{})
 	SchemeBuilder.Register(&BackendSecurityPolicy{}, &BackendSecurityPolicyList{})
+	SchemeBuilder.Register(&AIGatewayQuotaPolicy{}, &AIGatewayQuotaPolicyList{})
+	SchemeBuilder.Register(&AIGatewayVirtualKeyPolicy{}, &AIGatewayVirtualKeyPolicyList{})
 }
 
 const GroupName = "aigateway.envoyproxy.io"
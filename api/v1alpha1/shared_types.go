@@ -15,7 +15,7 @@ package v1alpha1
 type VersionedAPISchema struct {
 	// Name is the name of the API schema of the AIGatewayRoute or AIServiceBackend.
 	//
-	// +kubebuilder:validation:Enum=OpenAI;AWSBedrock;AzureOpenAI;GCPVertexAI;GCPAnthropic
+	// +kubebuilder:validation:Enum=OpenAI;AWSBedrock;AzureOpenAI;GCPVertexAI;GCPAnthropic;Anthropic
 	Name APISchema `json:"name"`
 
 	// Version is the version of the API schema.
@@ -32,6 +32,9 @@ type VersionedAPISchema struct {
 	//
 	// When the name is set to AzureOpenAI, this version maps to "API Version" in the
 	// Azure OpenAI API documentation (https://learn.microsoft.com/en-us/azure/ai-services/openai/reference#rest-api-versioning).
+	//
+	// When the name is set to Anthropic, this version is sent as the "anthropic-version" header. This defaults to
+	// "2023-06-01" if not set or empty string (https://docs.anthropic.com/en/api/versioning).
 	Version *string `json:"version,omitempty"`
 }
 
@@ -62,6 +65,11 @@ const (
 	//
 	// https://docs.anthropic.com/en/api/claude-on-vertex-ai
 	APISchemaGCPAnthropic APISchema = "GCPAnthropic"
+	// APISchemaAnthropic is the native Anthropic API schema served by api.anthropic.com.
+	// This is usually used together with the BackendSecurityPolicy of type AnthropicAPIKey.
+	//
+	// https://docs.anthropic.com/en/api/messages
+	APISchemaAnthropic APISchema = "Anthropic"
 )
 
 const (
@@ -78,9 +86,9 @@ type LLMRequestCost struct {
 	MetadataKey string `json:"metadataKey"`
 	// Type specifies the type of the request cost. The default is "OutputToken",
 	// and it uses "output token" as the cost. The other types are "InputToken", "TotalToken",
-	// and "CEL".
+	// "Image", "AudioSecond", "Character" and "CEL".
 	//
-	// +kubebuilder:validation:Enum=OutputToken;InputToken;TotalToken;CEL
+	// +kubebuilder:validation:Enum=OutputToken;InputToken;TotalToken;Image;AudioSecond;Character;CEL
 	Type LLMRequestCostType `json:"type"`
 	// CEL is the CEL expression to calculate the cost of the request.
 	// The CEL expression must return a signed or unsigned integer. If the
@@ -93,6 +101,8 @@ type LLMRequestCost struct {
 	//	* input_tokens: the number of input tokens. Type: unsigned integer.
 	//	* output_tokens: the number of output tokens. Type: unsigned integer.
 	//	* total_tokens: the total number of tokens. Type: unsigned integer.
+	//	* audio_seconds: the duration of the transcribed audio in seconds, rounded up. Type: unsigned integer.
+	//	* characters: the number of characters synthesized into speech. Type: unsigned integer.
 	//
 	// For example, the following expressions are valid:
 	//
@@ -115,6 +125,15 @@ const (
 	LLMRequestCostTypeOutputToken LLMRequestCostType = "OutputToken"
 	// LLMRequestCostTypeTotalToken is the cost type of the total token.
 	LLMRequestCostTypeTotalToken LLMRequestCostType = "TotalToken"
+	// LLMRequestCostTypeImage is the cost type of the number of generated images.
+	// This is only reported by the image generation endpoint.
+	LLMRequestCostTypeImage LLMRequestCostType = "Image"
+	// LLMRequestCostTypeAudioSecond is the cost type of the duration of the transcribed audio in seconds.
+	// This is only reported by the audio transcription endpoint.
+	LLMRequestCostTypeAudioSecond LLMRequestCostType = "AudioSecond"
+	// LLMRequestCostTypeCharacter is the cost type of the number of characters synthesized into speech.
+	// This is only reported by the audio speech endpoint.
+	LLMRequestCostTypeCharacter LLMRequestCostType = "Character"
 	// LLMRequestCostTypeCEL is for calculating the cost using the CEL expression.
 	LLMRequestCostTypeCEL LLMRequestCostType = "CEL"
 )
@@ -14,6 +14,9 @@ const (
 	// ConditionTypeNotAccepted is a condition type for the reconciliation result
 	// where resources are not accepted.
 	ConditionTypeNotAccepted = "NotAccepted"
+	// ConditionTypeCircuitBreakerOpen is a condition type for the state of the circuit breaker of AIServiceBackend
+	// where the circuit breaker is open on any of the gateway pods.
+	ConditionTypeCircuitBreakerOpen = "CircuitBreakerOpen"
 )
 
 // AIGatewayRouteStatus contains the conditions by the reconciliation result.
@@ -27,6 +30,15 @@ type AIGatewayRouteStatus struct {
 
 // AIServiceBackendStatus contains the conditions by the reconciliation result.
 type AIServiceBackendStatus struct {
+	// Conditions is the list of conditions by the reconciliation result and the state of the circuit breaker.
+	// Currently, at most one condition of the reconciliation result is set, and it is always the last one.
+	//
+	// Known .status.conditions.type are: "Accepted", "NotAccepted", "CircuitBreakerOpen".
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// BackendSecurityPolicyStatus contains the conditions by the reconciliation result.
+type BackendSecurityPolicyStatus struct {
 	// Conditions is the list of conditions by the reconciliation result.
 	// Currently, at most one condition is set.
 	//
@@ -34,8 +46,17 @@ type AIServiceBackendStatus struct {
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
-// BackendSecurityPolicyStatus contains the conditions by the reconciliation result.
-type BackendSecurityPolicyStatus struct {
+// AIGatewayQuotaPolicyStatus contains the conditions by the reconciliation result.
+type AIGatewayQuotaPolicyStatus struct {
+	// Conditions is the list of conditions by the reconciliation result.
+	// Currently, at most one condition is set.
+	//
+	// Known .status.conditions.type are: "Accepted", "NotAccepted".
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// AIGatewayVirtualKeyPolicyStatus contains the conditions by the reconciliation result.
+type AIGatewayVirtualKeyPolicyStatus struct {
 	// Conditions is the list of conditions by the reconciliation result.
 	// Currently, at most one condition is set.
 	//
@@ -57,6 +57,170 @@ func (in *AIGatewayFilterConfigExternalProcessor) DeepCopy() *AIGatewayFilterCon
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayQuotaBudget) DeepCopyInto(out *AIGatewayQuotaBudget) {
+	*out = *in
+	out.Window = in.Window
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayQuotaBudget.
+func (in *AIGatewayQuotaBudget) DeepCopy() *AIGatewayQuotaBudget {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayQuotaBudget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayQuotaPolicy) DeepCopyInto(out *AIGatewayQuotaPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayQuotaPolicy.
+func (in *AIGatewayQuotaPolicy) DeepCopy() *AIGatewayQuotaPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayQuotaPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AIGatewayQuotaPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayQuotaPolicyList) DeepCopyInto(out *AIGatewayQuotaPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AIGatewayQuotaPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayQuotaPolicyList.
+func (in *AIGatewayQuotaPolicyList) DeepCopy() *AIGatewayQuotaPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayQuotaPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AIGatewayQuotaPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayQuotaPolicySpec) DeepCopyInto(out *AIGatewayQuotaPolicySpec) {
+	*out = *in
+	if in.TargetRefs != nil {
+		in, out := &in.TargetRefs, &out.TargetRefs
+		*out = make([]v1alpha2.LocalPolicyTargetReference, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Budgets != nil {
+		in, out := &in.Budgets, &out.Budgets
+		*out = make([]AIGatewayQuotaBudget, len(*in))
+		copy(*out, *in)
+	}
+	if in.Redis != nil {
+		in, out := &in.Redis, &out.Redis
+		*out = new(AIGatewayQuotaRedis)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayQuotaPolicySpec.
+func (in *AIGatewayQuotaPolicySpec) DeepCopy() *AIGatewayQuotaPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayQuotaPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayQuotaPolicyStatus) DeepCopyInto(out *AIGatewayQuotaPolicyStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayQuotaPolicyStatus.
+func (in *AIGatewayQuotaPolicyStatus) DeepCopy() *AIGatewayQuotaPolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayQuotaPolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayQuotaRedis) DeepCopyInto(out *AIGatewayQuotaRedis) {
+	*out = *in
+	if in.KeyPrefix != nil {
+		in, out := &in.KeyPrefix, &out.KeyPrefix
+		*out = new(string)
+		**out = **in
+	}
+	if in.Username != nil {
+		in, out := &in.Username, &out.Username
+		*out = new(string)
+		**out = **in
+	}
+	if in.PasswordSecretRef != nil {
+		in, out := &in.PasswordSecretRef, &out.PasswordSecretRef
+		*out = new(v1.SecretObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(RedisTLS)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayQuotaRedis.
+func (in *AIGatewayQuotaRedis) DeepCopy() *AIGatewayQuotaRedis {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayQuotaRedis)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AIGatewayRoute) DeepCopyInto(out *AIGatewayRoute) {
 	*out = *in
@@ -84,6 +248,61 @@ func (in *AIGatewayRoute) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteBodyHeader) DeepCopyInto(out *AIGatewayRouteBodyHeader) {
+	*out = *in
+	if in.CEL != nil {
+		in, out := &in.CEL, &out.CEL
+		*out = new(string)
+		**out = **in
+	}
+	if in.JSONPath != nil {
+		in, out := &in.JSONPath, &out.JSONPath
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteBodyHeader.
+func (in *AIGatewayRouteBodyHeader) DeepCopy() *AIGatewayRouteBodyHeader {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteBodyHeader)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteGuardrail) DeepCopyInto(out *AIGatewayRouteGuardrail) {
+	*out = *in
+	if in.Phases != nil {
+		in, out := &in.Phases, &out.Phases
+		*out = make([]AIGatewayRouteGuardrailPhase, len(*in))
+		copy(*out, *in)
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.MaxResponseBytes != nil {
+		in, out := &in.MaxResponseBytes, &out.MaxResponseBytes
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteGuardrail.
+func (in *AIGatewayRouteGuardrail) DeepCopy() *AIGatewayRouteGuardrail {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteGuardrail)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AIGatewayRouteList) DeepCopyInto(out *AIGatewayRouteList) {
 	*out = *in
@@ -98,179 +317,882 @@ func (in *AIGatewayRouteList) DeepCopyInto(out *AIGatewayRouteList) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteList.
-func (in *AIGatewayRouteList) DeepCopy() *AIGatewayRouteList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteList.
+func (in *AIGatewayRouteList) DeepCopy() *AIGatewayRouteList {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AIGatewayRouteList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteModel) DeepCopyInto(out *AIGatewayRouteModel) {
+	*out = *in
+	if in.Aliases != nil {
+		in, out := &in.Aliases, &out.Aliases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Backends != nil {
+		in, out := &in.Backends, &out.Backends
+		*out = make([]AIGatewayRouteModelBackend, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ContextWindow != nil {
+		in, out := &in.ContextWindow, &out.ContextWindow
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Modalities != nil {
+		in, out := &in.Modalities, &out.Modalities
+		*out = make([]AIGatewayRouteModelModality, len(*in))
+		copy(*out, *in)
+	}
+	if in.OwnedBy != nil {
+		in, out := &in.OwnedBy, &out.OwnedBy
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteModel.
+func (in *AIGatewayRouteModel) DeepCopy() *AIGatewayRouteModel {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteModel)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteModelBackend) DeepCopyInto(out *AIGatewayRouteModelBackend) {
+	*out = *in
+	if in.Price != nil {
+		in, out := &in.Price, &out.Price
+		*out = new(AIGatewayRouteModelPrice)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteModelBackend.
+func (in *AIGatewayRouteModelBackend) DeepCopy() *AIGatewayRouteModelBackend {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteModelBackend)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteModelPrice) DeepCopyInto(out *AIGatewayRouteModelPrice) {
+	*out = *in
+	if in.CachedInputPerMillionTokens != nil {
+		in, out := &in.CachedInputPerMillionTokens, &out.CachedInputPerMillionTokens
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteModelPrice.
+func (in *AIGatewayRouteModelPrice) DeepCopy() *AIGatewayRouteModelPrice {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteModelPrice)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRoutePIIDetector) DeepCopyInto(out *AIGatewayRoutePIIDetector) {
+	*out = *in
+	if in.Regex != nil {
+		in, out := &in.Regex, &out.Regex
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRoutePIIDetector.
+func (in *AIGatewayRoutePIIDetector) DeepCopy() *AIGatewayRoutePIIDetector {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRoutePIIDetector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRoutePIIRedaction) DeepCopyInto(out *AIGatewayRoutePIIRedaction) {
+	*out = *in
+	if in.Detectors != nil {
+		in, out := &in.Detectors, &out.Detectors
+		*out = make([]AIGatewayRoutePIIDetector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRoutePIIRedaction.
+func (in *AIGatewayRoutePIIRedaction) DeepCopy() *AIGatewayRoutePIIRedaction {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRoutePIIRedaction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRule) DeepCopyInto(out *AIGatewayRouteRule) {
+	*out = *in
+	if in.BackendRefs != nil {
+		in, out := &in.BackendRefs, &out.BackendRefs
+		*out = make([]AIGatewayRouteRuleBackendRef, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Matches != nil {
+		in, out := &in.Matches, &out.Matches
+		*out = make([]AIGatewayRouteRuleMatch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Timeouts != nil {
+		in, out := &in.Timeouts, &out.Timeouts
+		*out = new(v1.HTTPRouteTimeouts)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Fallback != nil {
+		in, out := &in.Fallback, &out.Fallback
+		*out = new(AIGatewayRouteRuleFallback)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AdaptiveLoadBalancing != nil {
+		in, out := &in.AdaptiveLoadBalancing, &out.AdaptiveLoadBalancing
+		*out = new(AIGatewayRouteRuleAdaptiveLoadBalancing)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Mirror != nil {
+		in, out := &in.Mirror, &out.Mirror
+		*out = new(AIGatewayRouteRuleMirror)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SessionAffinity != nil {
+		in, out := &in.SessionAffinity, &out.SessionAffinity
+		*out = new(AIGatewayRouteRuleSessionAffinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Hedging != nil {
+		in, out := &in.Hedging, &out.Hedging
+		*out = new(AIGatewayRouteRuleHedging)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RequestPolicy != nil {
+		in, out := &in.RequestPolicy, &out.RequestPolicy
+		*out = new(AIGatewayRouteRuleRequestPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ModelsOwnedBy != nil {
+		in, out := &in.ModelsOwnedBy, &out.ModelsOwnedBy
+		*out = new(string)
+		**out = **in
+	}
+	if in.ModelsCreatedAt != nil {
+		in, out := &in.ModelsCreatedAt, &out.ModelsCreatedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRule.
+func (in *AIGatewayRouteRule) DeepCopy() *AIGatewayRouteRule {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleAdaptiveLoadBalancing) DeepCopyInto(out *AIGatewayRouteRuleAdaptiveLoadBalancing) {
+	*out = *in
+	if in.LatencyWindow != nil {
+		in, out := &in.LatencyWindow, &out.LatencyWindow
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ErrorRateWindow != nil {
+		in, out := &in.ErrorRateWindow, &out.ErrorRateWindow
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.OutlierEjection != nil {
+		in, out := &in.OutlierEjection, &out.OutlierEjection
+		*out = new(AIGatewayRouteRuleOutlierEjection)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleAdaptiveLoadBalancing.
+func (in *AIGatewayRouteRuleAdaptiveLoadBalancing) DeepCopy() *AIGatewayRouteRuleAdaptiveLoadBalancing {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleAdaptiveLoadBalancing)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleBackendRef) DeepCopyInto(out *AIGatewayRouteRuleBackendRef) {
+	*out = *in
+	if in.Group != nil {
+		in, out := &in.Group, &out.Group
+		*out = new(string)
+		**out = **in
+	}
+	if in.Kind != nil {
+		in, out := &in.Kind, &out.Kind
+		*out = new(string)
+		**out = **in
+	}
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Priority != nil {
+		in, out := &in.Priority, &out.Priority
+		*out = new(uint32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleBackendRef.
+func (in *AIGatewayRouteRuleBackendRef) DeepCopy() *AIGatewayRouteRuleBackendRef {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleBackendRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleFallback) DeepCopyInto(out *AIGatewayRouteRuleFallback) {
+	*out = *in
+	if in.RetryOn != nil {
+		in, out := &in.RetryOn, &out.RetryOn
+		*out = make([]AIGatewayRouteRuleFallbackCondition, len(*in))
+		copy(*out, *in)
+	}
+	if in.NumRetries != nil {
+		in, out := &in.NumRetries, &out.NumRetries
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleFallback.
+func (in *AIGatewayRouteRuleFallback) DeepCopy() *AIGatewayRouteRuleFallback {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleFallback)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleHedging) DeepCopyInto(out *AIGatewayRouteRuleHedging) {
+	*out = *in
+	out.Delay = in.Delay
+	if in.MaxHedgedRequests != nil {
+		in, out := &in.MaxHedgedRequests, &out.MaxHedgedRequests
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleHedging.
+func (in *AIGatewayRouteRuleHedging) DeepCopy() *AIGatewayRouteRuleHedging {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleHedging)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleMatch) DeepCopyInto(out *AIGatewayRouteRuleMatch) {
+	*out = *in
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make([]v1.HTTPHeaderMatch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleMatch.
+func (in *AIGatewayRouteRuleMatch) DeepCopy() *AIGatewayRouteRuleMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleMirror) DeepCopyInto(out *AIGatewayRouteRuleMirror) {
+	*out = *in
+	if in.Percentage != nil {
+		in, out := &in.Percentage, &out.Percentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	in.Sink.DeepCopyInto(&out.Sink)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleMirror.
+func (in *AIGatewayRouteRuleMirror) DeepCopy() *AIGatewayRouteRuleMirror {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleMirror)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleMirrorFileSink) DeepCopyInto(out *AIGatewayRouteRuleMirrorFileSink) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleMirrorFileSink.
+func (in *AIGatewayRouteRuleMirrorFileSink) DeepCopy() *AIGatewayRouteRuleMirrorFileSink {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleMirrorFileSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleMirrorOTLPLogsSink) DeepCopyInto(out *AIGatewayRouteRuleMirrorOTLPLogsSink) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleMirrorOTLPLogsSink.
+func (in *AIGatewayRouteRuleMirrorOTLPLogsSink) DeepCopy() *AIGatewayRouteRuleMirrorOTLPLogsSink {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleMirrorOTLPLogsSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleMirrorSink) DeepCopyInto(out *AIGatewayRouteRuleMirrorSink) {
+	*out = *in
+	if in.File != nil {
+		in, out := &in.File, &out.File
+		*out = new(AIGatewayRouteRuleMirrorFileSink)
+		**out = **in
+	}
+	if in.OTLPLogs != nil {
+		in, out := &in.OTLPLogs, &out.OTLPLogs
+		*out = new(AIGatewayRouteRuleMirrorOTLPLogsSink)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleMirrorSink.
+func (in *AIGatewayRouteRuleMirrorSink) DeepCopy() *AIGatewayRouteRuleMirrorSink {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleMirrorSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleOutlierEjection) DeepCopyInto(out *AIGatewayRouteRuleOutlierEjection) {
+	*out = *in
+	if in.ConsecutiveErrors != nil {
+		in, out := &in.ConsecutiveErrors, &out.ConsecutiveErrors
+		*out = new(int32)
+		**out = **in
+	}
+	if in.BaseEjectionTime != nil {
+		in, out := &in.BaseEjectionTime, &out.BaseEjectionTime
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.MaxEjectionPercent != nil {
+		in, out := &in.MaxEjectionPercent, &out.MaxEjectionPercent
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleOutlierEjection.
+func (in *AIGatewayRouteRuleOutlierEjection) DeepCopy() *AIGatewayRouteRuleOutlierEjection {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleOutlierEjection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleRequestPolicy) DeepCopyInto(out *AIGatewayRouteRuleRequestPolicy) {
+	*out = *in
+	if in.MaxTokens != nil {
+		in, out := &in.MaxTokens, &out.MaxTokens
+		*out = new(AIGatewayRouteRuleRequestPolicyLimit)
+		**out = **in
+	}
+	if in.MaxMessages != nil {
+		in, out := &in.MaxMessages, &out.MaxMessages
+		*out = new(AIGatewayRouteRuleRequestPolicyLimit)
+		**out = **in
+	}
+	if in.MaxN != nil {
+		in, out := &in.MaxN, &out.MaxN
+		*out = new(AIGatewayRouteRuleRequestPolicyLimit)
+		**out = **in
+	}
+	if in.MaxPromptBytes != nil {
+		in, out := &in.MaxPromptBytes, &out.MaxPromptBytes
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MaxEstimatedPromptTokens != nil {
+		in, out := &in.MaxEstimatedPromptTokens, &out.MaxEstimatedPromptTokens
+		*out = new(int64)
+		**out = **in
+	}
+	if in.AllowedModalities != nil {
+		in, out := &in.AllowedModalities, &out.AllowedModalities
+		*out = make([]AIGatewayRouteModelModality, len(*in))
+		copy(*out, *in)
+	}
+	if in.ForbiddenFields != nil {
+		in, out := &in.ForbiddenFields, &out.ForbiddenFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Defaults != nil {
+		in, out := &in.Defaults, &out.Defaults
+		*out = make([]AIGatewayRouteRuleRequestPolicyDefault, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleRequestPolicy.
+func (in *AIGatewayRouteRuleRequestPolicy) DeepCopy() *AIGatewayRouteRuleRequestPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleRequestPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleRequestPolicyDefault) DeepCopyInto(out *AIGatewayRouteRuleRequestPolicyDefault) {
+	*out = *in
+	in.Value.DeepCopyInto(&out.Value)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleRequestPolicyDefault.
+func (in *AIGatewayRouteRuleRequestPolicyDefault) DeepCopy() *AIGatewayRouteRuleRequestPolicyDefault {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleRequestPolicyDefault)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleRequestPolicyLimit) DeepCopyInto(out *AIGatewayRouteRuleRequestPolicyLimit) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleRequestPolicyLimit.
+func (in *AIGatewayRouteRuleRequestPolicyLimit) DeepCopy() *AIGatewayRouteRuleRequestPolicyLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleRequestPolicyLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleSessionAffinity) DeepCopyInto(out *AIGatewayRouteRuleSessionAffinity) {
+	*out = *in
+	if in.Header != nil {
+		in, out := &in.Header, &out.Header
+		*out = new(AIGatewayRouteRuleSessionAffinityHeader)
+		**out = **in
+	}
+	if in.ConversationPrefix != nil {
+		in, out := &in.ConversationPrefix, &out.ConversationPrefix
+		*out = new(AIGatewayRouteRuleSessionAffinityConversationPrefix)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleSessionAffinity.
+func (in *AIGatewayRouteRuleSessionAffinity) DeepCopy() *AIGatewayRouteRuleSessionAffinity {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleSessionAffinity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleSessionAffinityConversationPrefix) DeepCopyInto(out *AIGatewayRouteRuleSessionAffinityConversationPrefix) {
+	*out = *in
+	if in.UserMessages != nil {
+		in, out := &in.UserMessages, &out.UserMessages
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleSessionAffinityConversationPrefix.
+func (in *AIGatewayRouteRuleSessionAffinityConversationPrefix) DeepCopy() *AIGatewayRouteRuleSessionAffinityConversationPrefix {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleSessionAffinityConversationPrefix)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleSessionAffinityHeader) DeepCopyInto(out *AIGatewayRouteRuleSessionAffinityHeader) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleSessionAffinityHeader.
+func (in *AIGatewayRouteRuleSessionAffinityHeader) DeepCopy() *AIGatewayRouteRuleSessionAffinityHeader {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleSessionAffinityHeader)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteSpec) DeepCopyInto(out *AIGatewayRouteSpec) {
+	*out = *in
+	if in.TargetRefs != nil {
+		in, out := &in.TargetRefs, &out.TargetRefs
+		*out = make([]v1alpha2.LocalPolicyTargetReferenceWithSectionName, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ParentRefs != nil {
+		in, out := &in.ParentRefs, &out.ParentRefs
+		*out = make([]v1.ParentReference, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.APISchema != nil {
+		in, out := &in.APISchema, &out.APISchema
+		*out = new(VersionedAPISchema)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]AIGatewayRouteRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FilterConfig != nil {
+		in, out := &in.FilterConfig, &out.FilterConfig
+		*out = new(AIGatewayFilterConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LLMRequestCosts != nil {
+		in, out := &in.LLMRequestCosts, &out.LLMRequestCosts
+		*out = make([]LLMRequestCost, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ResponseCache != nil {
+		in, out := &in.ResponseCache, &out.ResponseCache
+		*out = new(ResponseCache)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Models != nil {
+		in, out := &in.Models, &out.Models
+		*out = make([]AIGatewayRouteModel, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.BodyHeaders != nil {
+		in, out := &in.BodyHeaders, &out.BodyHeaders
+		*out = make([]AIGatewayRouteBodyHeader, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PIIRedaction != nil {
+		in, out := &in.PIIRedaction, &out.PIIRedaction
+		*out = new(AIGatewayRoutePIIRedaction)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Guardrail != nil {
+		in, out := &in.Guardrail, &out.Guardrail
+		*out = new(AIGatewayRouteGuardrail)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ToolPolicy != nil {
+		in, out := &in.ToolPolicy, &out.ToolPolicy
+		*out = new(AIGatewayRouteToolPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteSpec.
+func (in *AIGatewayRouteSpec) DeepCopy() *AIGatewayRouteSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteStatus) DeepCopyInto(out *AIGatewayRouteStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteStatus.
+func (in *AIGatewayRouteStatus) DeepCopy() *AIGatewayRouteStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteToolPolicy) DeepCopyInto(out *AIGatewayRouteToolPolicy) {
+	*out = *in
+	if in.AllowedFunctions != nil {
+		in, out := &in.AllowedFunctions, &out.AllowedFunctions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ValidateArguments != nil {
+		in, out := &in.ValidateArguments, &out.ValidateArguments
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteToolPolicy.
+func (in *AIGatewayRouteToolPolicy) DeepCopy() *AIGatewayRouteToolPolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(AIGatewayRouteList)
+	out := new(AIGatewayRouteToolPolicy)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *AIGatewayRouteList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AIGatewayRouteRule) DeepCopyInto(out *AIGatewayRouteRule) {
+func (in *AIGatewayVirtualKey) DeepCopyInto(out *AIGatewayVirtualKey) {
 	*out = *in
-	if in.BackendRefs != nil {
-		in, out := &in.BackendRefs, &out.BackendRefs
-		*out = make([]AIGatewayRouteRuleBackendRef, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.Matches != nil {
-		in, out := &in.Matches, &out.Matches
-		*out = make([]AIGatewayRouteRuleMatch, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.Timeouts != nil {
-		in, out := &in.Timeouts, &out.Timeouts
-		*out = new(v1.HTTPRouteTimeouts)
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(v1.SecretObjectReference)
 		(*in).DeepCopyInto(*out)
 	}
-	if in.ModelsOwnedBy != nil {
-		in, out := &in.ModelsOwnedBy, &out.ModelsOwnedBy
-		*out = new(string)
-		**out = **in
+	if in.AllowedModels != nil {
+		in, out := &in.AllowedModels, &out.AllowedModels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
-	if in.ModelsCreatedAt != nil {
-		in, out := &in.ModelsCreatedAt, &out.ModelsCreatedAt
-		*out = (*in).DeepCopy()
+	if in.Budgets != nil {
+		in, out := &in.Budgets, &out.Budgets
+		*out = make([]AIGatewayQuotaBudget, len(*in))
+		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRule.
-func (in *AIGatewayRouteRule) DeepCopy() *AIGatewayRouteRule {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayVirtualKey.
+func (in *AIGatewayVirtualKey) DeepCopy() *AIGatewayVirtualKey {
 	if in == nil {
 		return nil
 	}
-	out := new(AIGatewayRouteRule)
+	out := new(AIGatewayVirtualKey)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AIGatewayRouteRuleBackendRef) DeepCopyInto(out *AIGatewayRouteRuleBackendRef) {
+func (in *AIGatewayVirtualKeyPolicy) DeepCopyInto(out *AIGatewayVirtualKeyPolicy) {
 	*out = *in
-	if in.Group != nil {
-		in, out := &in.Group, &out.Group
-		*out = new(string)
-		**out = **in
-	}
-	if in.Kind != nil {
-		in, out := &in.Kind, &out.Kind
-		*out = new(string)
-		**out = **in
-	}
-	if in.Weight != nil {
-		in, out := &in.Weight, &out.Weight
-		*out = new(int32)
-		**out = **in
-	}
-	if in.Priority != nil {
-		in, out := &in.Priority, &out.Priority
-		*out = new(uint32)
-		**out = **in
-	}
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleBackendRef.
-func (in *AIGatewayRouteRuleBackendRef) DeepCopy() *AIGatewayRouteRuleBackendRef {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayVirtualKeyPolicy.
+func (in *AIGatewayVirtualKeyPolicy) DeepCopy() *AIGatewayVirtualKeyPolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(AIGatewayRouteRuleBackendRef)
+	out := new(AIGatewayVirtualKeyPolicy)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AIGatewayVirtualKeyPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AIGatewayRouteRuleMatch) DeepCopyInto(out *AIGatewayRouteRuleMatch) {
+func (in *AIGatewayVirtualKeyPolicyList) DeepCopyInto(out *AIGatewayVirtualKeyPolicyList) {
 	*out = *in
-	if in.Headers != nil {
-		in, out := &in.Headers, &out.Headers
-		*out = make([]v1.HTTPHeaderMatch, len(*in))
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AIGatewayVirtualKeyPolicy, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleMatch.
-func (in *AIGatewayRouteRuleMatch) DeepCopy() *AIGatewayRouteRuleMatch {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayVirtualKeyPolicyList.
+func (in *AIGatewayVirtualKeyPolicyList) DeepCopy() *AIGatewayVirtualKeyPolicyList {
 	if in == nil {
 		return nil
 	}
-	out := new(AIGatewayRouteRuleMatch)
+	out := new(AIGatewayVirtualKeyPolicyList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AIGatewayVirtualKeyPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AIGatewayRouteSpec) DeepCopyInto(out *AIGatewayRouteSpec) {
+func (in *AIGatewayVirtualKeyPolicySpec) DeepCopyInto(out *AIGatewayVirtualKeyPolicySpec) {
 	*out = *in
 	if in.TargetRefs != nil {
 		in, out := &in.TargetRefs, &out.TargetRefs
-		*out = make([]v1alpha2.LocalPolicyTargetReferenceWithSectionName, len(*in))
+		*out = make([]v1alpha2.LocalPolicyTargetReference, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.ParentRefs != nil {
-		in, out := &in.ParentRefs, &out.ParentRefs
-		*out = make([]v1.ParentReference, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.ClientKeyHeader != nil {
+		in, out := &in.ClientKeyHeader, &out.ClientKeyHeader
+		*out = new(string)
+		**out = **in
 	}
-	if in.APISchema != nil {
-		in, out := &in.APISchema, &out.APISchema
-		*out = new(VersionedAPISchema)
-		(*in).DeepCopyInto(*out)
+	if in.TeamIDHeader != nil {
+		in, out := &in.TeamIDHeader, &out.TeamIDHeader
+		*out = new(string)
+		**out = **in
 	}
-	if in.Rules != nil {
-		in, out := &in.Rules, &out.Rules
-		*out = make([]AIGatewayRouteRule, len(*in))
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		*out = make([]AIGatewayVirtualKey, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.FilterConfig != nil {
-		in, out := &in.FilterConfig, &out.FilterConfig
-		*out = new(AIGatewayFilterConfig)
+	if in.Redis != nil {
+		in, out := &in.Redis, &out.Redis
+		*out = new(AIGatewayQuotaRedis)
 		(*in).DeepCopyInto(*out)
 	}
-	if in.LLMRequestCosts != nil {
-		in, out := &in.LLMRequestCosts, &out.LLMRequestCosts
-		*out = make([]LLMRequestCost, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteSpec.
-func (in *AIGatewayRouteSpec) DeepCopy() *AIGatewayRouteSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayVirtualKeyPolicySpec.
+func (in *AIGatewayVirtualKeyPolicySpec) DeepCopy() *AIGatewayVirtualKeyPolicySpec {
 	if in == nil {
 		return nil
 	}
-	out := new(AIGatewayRouteSpec)
+	out := new(AIGatewayVirtualKeyPolicySpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AIGatewayRouteStatus) DeepCopyInto(out *AIGatewayRouteStatus) {
+func (in *AIGatewayVirtualKeyPolicyStatus) DeepCopyInto(out *AIGatewayVirtualKeyPolicyStatus) {
 	*out = *in
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
@@ -281,12 +1203,12 @@ func (in *AIGatewayRouteStatus) DeepCopyInto(out *AIGatewayRouteStatus) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteStatus.
-func (in *AIGatewayRouteStatus) DeepCopy() *AIGatewayRouteStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayVirtualKeyPolicyStatus.
+func (in *AIGatewayVirtualKeyPolicyStatus) DeepCopy() *AIGatewayVirtualKeyPolicyStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(AIGatewayRouteStatus)
+	out := new(AIGatewayVirtualKeyPolicyStatus)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -318,6 +1240,41 @@ func (in *AIServiceBackend) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIServiceBackendCircuitBreaker) DeepCopyInto(out *AIServiceBackendCircuitBreaker) {
+	*out = *in
+	if in.ConsecutiveFailures != nil {
+		in, out := &in.ConsecutiveFailures, &out.ConsecutiveFailures
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailOn != nil {
+		in, out := &in.FailOn, &out.FailOn
+		*out = make([]AIServiceBackendCircuitBreakerErrorClass, len(*in))
+		copy(*out, *in)
+	}
+	if in.OpenDuration != nil {
+		in, out := &in.OpenDuration, &out.OpenDuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.HalfOpenProbes != nil {
+		in, out := &in.HalfOpenProbes, &out.HalfOpenProbes
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIServiceBackendCircuitBreaker.
+func (in *AIServiceBackendCircuitBreaker) DeepCopy() *AIServiceBackendCircuitBreaker {
+	if in == nil {
+		return nil
+	}
+	out := new(AIServiceBackendCircuitBreaker)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AIServiceBackendList) DeepCopyInto(out *AIServiceBackendList) {
 	*out = *in
@@ -360,6 +1317,11 @@ func (in *AIServiceBackendSpec) DeepCopyInto(out *AIServiceBackendSpec) {
 		*out = new(v1.LocalObjectReference)
 		**out = **in
 	}
+	if in.CircuitBreaker != nil {
+		in, out := &in.CircuitBreaker, &out.CircuitBreaker
+		*out = new(AIServiceBackendCircuitBreaker)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIServiceBackendSpec.
@@ -518,6 +1480,26 @@ func (in *BackendSecurityPolicyAWSCredentials) DeepCopy() *BackendSecurityPolicy
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackendSecurityPolicyAnthropicAPIKey) DeepCopyInto(out *BackendSecurityPolicyAnthropicAPIKey) {
+	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(v1.SecretObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendSecurityPolicyAnthropicAPIKey.
+func (in *BackendSecurityPolicyAnthropicAPIKey) DeepCopy() *BackendSecurityPolicyAnthropicAPIKey {
+	if in == nil {
+		return nil
+	}
+	out := new(BackendSecurityPolicyAnthropicAPIKey)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackendSecurityPolicyAzureCredentials) DeepCopyInto(out *BackendSecurityPolicyAzureCredentials) {
 	*out = *in
@@ -637,6 +1619,11 @@ func (in *BackendSecurityPolicySpec) DeepCopyInto(out *BackendSecurityPolicySpec
 		*out = new(BackendSecurityPolicyGCPCredentials)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.AnthropicAPIKey != nil {
+		in, out := &in.AnthropicAPIKey, &out.AnthropicAPIKey
+		*out = new(BackendSecurityPolicyAnthropicAPIKey)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendSecurityPolicySpec.
@@ -759,6 +1746,136 @@ func (in *LLMRequestCost) DeepCopy() *LLMRequestCost {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RedisTLS) DeepCopyInto(out *RedisTLS) {
+	*out = *in
+	if in.ServerName != nil {
+		in, out := &in.ServerName, &out.ServerName
+		*out = new(string)
+		**out = **in
+	}
+	if in.CACertificateSecretRef != nil {
+		in, out := &in.CACertificateSecretRef, &out.CACertificateSecretRef
+		*out = new(v1.SecretObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisTLS.
+func (in *RedisTLS) DeepCopy() *RedisTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(RedisTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResponseCache) DeepCopyInto(out *ResponseCache) {
+	*out = *in
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.MaxEntries != nil {
+		in, out := &in.MaxEntries, &out.MaxEntries
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxEntryBytes != nil {
+		in, out := &in.MaxEntryBytes, &out.MaxEntryBytes
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Redis != nil {
+		in, out := &in.Redis, &out.Redis
+		*out = new(ResponseCacheRedis)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Semantic != nil {
+		in, out := &in.Semantic, &out.Semantic
+		*out = new(SemanticResponseCache)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResponseCache.
+func (in *ResponseCache) DeepCopy() *ResponseCache {
+	if in == nil {
+		return nil
+	}
+	out := new(ResponseCache)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResponseCacheRedis) DeepCopyInto(out *ResponseCacheRedis) {
+	*out = *in
+	if in.KeyPrefix != nil {
+		in, out := &in.KeyPrefix, &out.KeyPrefix
+		*out = new(string)
+		**out = **in
+	}
+	if in.Username != nil {
+		in, out := &in.Username, &out.Username
+		*out = new(string)
+		**out = **in
+	}
+	if in.PasswordSecretRef != nil {
+		in, out := &in.PasswordSecretRef, &out.PasswordSecretRef
+		*out = new(v1.SecretObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(RedisTLS)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResponseCacheRedis.
+func (in *ResponseCacheRedis) DeepCopy() *ResponseCacheRedis {
+	if in == nil {
+		return nil
+	}
+	out := new(ResponseCacheRedis)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SemanticResponseCache) DeepCopyInto(out *SemanticResponseCache) {
+	*out = *in
+	if in.EmbeddingTimeout != nil {
+		in, out := &in.EmbeddingTimeout, &out.EmbeddingTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.SimilarityThreshold != nil {
+		in, out := &in.SimilarityThreshold, &out.SimilarityThreshold
+		*out = new(string)
+		**out = **in
+	}
+	if in.MaxEntries != nil {
+		in, out := &in.MaxEntries, &out.MaxEntries
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SemanticResponseCache.
+func (in *SemanticResponseCache) DeepCopy() *SemanticResponseCache {
+	if in == nil {
+		return nil
+	}
+	out := new(SemanticResponseCache)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VersionedAPISchema) DeepCopyInto(out *VersionedAPISchema) {
 	*out = *in
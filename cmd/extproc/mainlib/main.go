@@ -152,7 +152,19 @@ func Main(ctx context.Context, args []string, stderr io.Writer) (err error) {
 
 	metricsServer, meter := startMetricsServer(metricsLis, l)
 	chatCompletionMetrics := metrics.NewChatCompletion(meter, metricsRequestHeaderLabels)
+	piiMetrics := metrics.NewPII(meter, metricsRequestHeaderLabels)
+	chatCompletionProcessorMetrics := extproc.ChatCompletionProcessorMetrics{
+		ChatCompletion: chatCompletionMetrics,
+		ResponseCache:  metrics.NewResponseCache(meter, metricsRequestHeaderLabels),
+		BackendAttempt: metrics.NewBackendAttempt(meter, metricsRequestHeaderLabels),
+		Admission:      metrics.NewAdmission(meter, metricsRequestHeaderLabels),
+		PII:            piiMetrics,
+	}
 	embeddingsMetrics := metrics.NewEmbeddings(meter, metricsRequestHeaderLabels)
+	textCompletionMetrics := metrics.NewTextCompletion(meter, metricsRequestHeaderLabels)
+	imageGenerationMetrics := metrics.NewImageGeneration(meter, metricsRequestHeaderLabels)
+	audioTranscriptionMetrics := metrics.NewAudioTranscription(meter, metricsRequestHeaderLabels)
+	audioSpeechMetrics := metrics.NewAudioSpeech(meter, metricsRequestHeaderLabels)
 
 	tracing, err := tracing.NewTracingFromEnv(ctx)
 	if err != nil {
@@ -163,8 +175,16 @@ func Main(ctx context.Context, args []string, stderr io.Writer) (err error) {
 	if err != nil {
 		return fmt.Errorf("failed to create external processor server: %w", err)
 	}
-	server.Register("/v1/chat/completions", extproc.ChatCompletionProcessorFactory(chatCompletionMetrics))
+	metrics.RegisterBackendScores(meter, server.BackendScores)
+	metrics.RegisterCircuitBreakerStates(meter, server.CircuitBreakerStates)
+	server.Register("/v1/chat/completions", extproc.ChatCompletionProcessorFactory(chatCompletionProcessorMetrics))
 	server.Register("/v1/embeddings", extproc.EmbeddingsProcessorFactory(embeddingsMetrics))
+	server.Register("/v1/completions", extproc.CompletionsProcessorFactory(textCompletionMetrics, piiMetrics))
+	server.Register("/v1/responses", extproc.ResponsesProcessorFactory(chatCompletionMetrics, piiMetrics))
+	server.Register("/v1/messages", extproc.MessagesProcessorFactory(chatCompletionMetrics, piiMetrics))
+	server.Register("/v1/images/generations", extproc.ImageGenerationProcessorFactory(imageGenerationMetrics))
+	server.Register("/v1/audio/transcriptions", extproc.AudioTranscriptionProcessorFactory(audioTranscriptionMetrics))
+	server.Register("/v1/audio/speech", extproc.AudioSpeechProcessorFactory(audioSpeechMetrics))
 	server.Register("/v1/models", extproc.NewModelsProcessor)
 
 	if err := extproc.StartConfigWatcher(ctx, flags.configPath, server, l, time.Second*5); err != nil {
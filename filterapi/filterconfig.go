@@ -15,6 +15,7 @@
 package filterapi
 
 import (
+	"encoding/json"
 	"os"
 	"time"
 
@@ -44,6 +45,578 @@ type Config struct {
 	Backends []Backend `json:"backends,omitempty"`
 	// Models is the list of models that this route is aware of. Used to populate the "/models" endpoint in OpenAI-compatible APIs.
 	Models []Model `json:"models,omitempty"`
+	// ResponseCache configures the exact-match cache of the chat completion responses. Optional.
+	// If this is not provided, the responses are not cached.
+	ResponseCache *ResponseCacheConfig `json:"responseCache,omitempty"`
+	// Quotas is the list of the token budgets enforced by the filter per client. Optional.
+	Quotas []QuotaPolicy `json:"quotas,omitempty"`
+	// VirtualKeyPolicies is the list of the virtual keys of the clients validated by the filter. Optional.
+	VirtualKeyPolicies []VirtualKeyPolicy `json:"virtualKeyPolicies,omitempty"`
+	// AdaptiveLoadBalancers is the list of the route rules whose backend is selected by the filter
+	// based on the observed latency and errors. Optional.
+	AdaptiveLoadBalancers []AdaptiveLoadBalancer `json:"adaptiveLoadBalancers,omitempty"`
+	// BodyHeaders is the list of the request headers derived from the request body by the filter
+	// before the routing decision. Optional.
+	BodyHeaders []BodyHeader `json:"bodyHeaders,omitempty"`
+	// Mirrors is the list of the route rules whose requests are mirrored to a shadow backend. Optional.
+	Mirrors []Mirror `json:"mirrors,omitempty"`
+	// SessionAffinities is the list of the route rules whose requests are keyed for the consistent hashing
+	// of the backends. Optional.
+	SessionAffinities []SessionAffinity `json:"sessionAffinities,omitempty"`
+	// Hedgings is the list of the route rules whose chat completion requests are hedged. Optional.
+	Hedgings []Hedging `json:"hedgings,omitempty"`
+	// RequestPolicies is the list of the route rules whose chat completion requests are limited. Optional.
+	RequestPolicies []RequestPolicy `json:"requestPolicies,omitempty"`
+	// CircuitBreakers is the list of the circuit breakers of the AIServiceBackends enforced by the filter. Optional.
+	CircuitBreakers []CircuitBreaker `json:"circuitBreakers,omitempty"`
+	// AdmissionControl is the configuration of the admission of the chat completion requests on the backends by their
+	// priority classes. Optional.
+	AdmissionControl *AdmissionControl `json:"admissionControl,omitempty"`
+	// PIIRedactions is the list of the routes whose requests are scanned for the PII. Optional.
+	PIIRedactions []PIIRedaction `json:"piiRedactions,omitempty"`
+	// Guardrails is the list of the routes whose requests and responses are checked by the external guardrail
+	// services. Optional.
+	Guardrails []Guardrail `json:"guardrails,omitempty"`
+	// ToolPolicies is the list of the routes whose function calling is governed by the filter. Optional.
+	ToolPolicies []ToolPolicy `json:"toolPolicies,omitempty"`
+}
+
+// PIIRedaction corresponds to AIGatewayRoutePIIRedaction in api/v1alpha1/ai_gateway_route.go.
+type PIIRedaction struct {
+	// Name is the unique name of the route, e.g. "namespace/route".
+	Name string `json:"name"`
+	// Matches is the list of the matches of the rules of the route, any of which scans the requests with this
+	// configuration.
+	Matches []RouteRuleMatch `json:"matches"`
+	// Action is what the filter does with the requests containing the PII.
+	Action PIIRedactionAction `json:"action"`
+	// Detectors is the list of the detectors of the PII, which are applied in this order.
+	Detectors []PIIDetector `json:"detectors"`
+}
+
+// PIIRedactionAction is what the filter does with the requests containing the PII.
+type PIIRedactionAction string
+
+const (
+	// PIIRedactionActionMask masks the PII with the placeholders restored in the response.
+	PIIRedactionActionMask PIIRedactionAction = "Mask"
+	// PIIRedactionActionReject rejects the requests containing the PII.
+	PIIRedactionActionReject PIIRedactionAction = "Reject"
+)
+
+// PIIDetector is a detector of the PII.
+type PIIDetector struct {
+	// Name is the name of the detector used in the placeholders and the metrics.
+	Name string `json:"name"`
+	// Type is the type of the detector.
+	Type PIIDetectorType `json:"type"`
+	// Regex is the regular expression of the Regex type.
+	Regex string `json:"regex,omitempty"`
+}
+
+// PIIDetectorType is the type of the detector of the PII.
+type PIIDetectorType string
+
+const (
+	// PIIDetectorTypeEmail detects the email addresses.
+	PIIDetectorTypeEmail PIIDetectorType = "Email"
+	// PIIDetectorTypePhoneNumber detects the phone numbers.
+	PIIDetectorTypePhoneNumber PIIDetectorType = "PhoneNumber"
+	// PIIDetectorTypeCreditCard detects the credit card numbers.
+	PIIDetectorTypeCreditCard PIIDetectorType = "CreditCard"
+	// PIIDetectorTypeNationalID detects the US Social Security Numbers.
+	PIIDetectorTypeNationalID PIIDetectorType = "NationalID"
+	// PIIDetectorTypeRegex detects the matches of a regular expression.
+	PIIDetectorTypeRegex PIIDetectorType = "Regex"
+)
+
+// Guardrail corresponds to AIGatewayRouteGuardrail in api/v1alpha1/ai_gateway_route.go.
+type Guardrail struct {
+	// Name is the unique name of the route, e.g. "namespace/route", which is also sent to the service.
+	Name string `json:"name"`
+	// Matches is the list of the matches of the rules of the route, any of which checks the requests with this
+	// configuration.
+	Matches []RouteRuleMatch `json:"matches"`
+	// Protocol is the protocol used to call the guardrail service.
+	Protocol GuardrailProtocol `json:"protocol"`
+	// Endpoint is the URL of the service for the HTTP protocol, or its host:port for the GRPC protocol.
+	Endpoint string `json:"endpoint"`
+	// Request is true when the requests are checked before they are routed to the backends.
+	Request bool `json:"request,omitempty"`
+	// Response is true when the responses are checked before their ends are returned to the clients.
+	Response bool `json:"response,omitempty"`
+	// Timeout is the timeout of each call to the service. The zero value means no timeout.
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// FailOpen is true when the requests and the responses are let through when the call to the service fails.
+	// Otherwise, they are rejected.
+	FailOpen bool `json:"failOpen,omitempty"`
+	// MaxResponseBytes is the maximum size of the response held back until the service decides on it. The larger
+	// responses are let through unchecked or rejected in the same way as when the call to the service fails. The zero
+	// value means no limit.
+	MaxResponseBytes int `json:"maxResponseBytes,omitempty"`
+}
+
+// GuardrailProtocol is the protocol used to call the guardrail service.
+type GuardrailProtocol string
+
+const (
+	// GuardrailProtocolHTTP posts the checks as JSON over HTTP.
+	GuardrailProtocolHTTP GuardrailProtocol = "HTTP"
+	// GuardrailProtocolGRPC sends the checks as google.protobuf.Struct over gRPC.
+	GuardrailProtocolGRPC GuardrailProtocol = "GRPC"
+)
+
+// ToolPolicy corresponds to AIGatewayRouteToolPolicy in api/v1alpha1/ai_gateway_route.go.
+type ToolPolicy struct {
+	// Name is the unique name of the route, e.g. "namespace/route".
+	Name string `json:"name"`
+	// Matches is the list of the matches of the rules of the route, any of which governs the requests with this
+	// configuration.
+	Matches []RouteRuleMatch `json:"matches"`
+	// AllowedFunctions is the list of the names of the functions the requests may declare. Any function is allowed
+	// when empty.
+	AllowedFunctions []string `json:"allowedFunctions,omitempty"`
+	// ValidateArguments is true when the arguments of the tool calls are validated against the JSON Schema of the
+	// parameters of the called functions.
+	ValidateArguments bool `json:"validateArguments,omitempty"`
+	// Action is what the filter does with the responses containing the violating tool calls.
+	Action ToolPolicyAction `json:"action"`
+}
+
+// ToolPolicyAction is what the filter does with the responses containing the violating tool calls.
+type ToolPolicyAction string
+
+const (
+	// ToolPolicyActionBlock replaces the response with an error.
+	ToolPolicyActionBlock ToolPolicyAction = "Block"
+	// ToolPolicyActionRewrite removes the violating tool calls from the response.
+	ToolPolicyActionRewrite ToolPolicyAction = "Rewrite"
+)
+
+// AdmissionControl is the configuration of the admission controller of the filter, which tracks the concurrent
+// in-flight requests and the tokens per minute of each backend, and sheds the requests of the lower priority classes
+// before the higher ones when the backend is getting saturated or throttled by the provider.
+//
+// The shed requests are rejected with 429 and the Retry-After header, and retried on the other backends when the
+// backend has the fallback.
+type AdmissionControl struct {
+	// Classes is the list of the priority classes in the descending order of the priority, i.e. the first one is the
+	// highest. A request belongs to the first class matching it.
+	Classes []PriorityClass `json:"classes"`
+	// DefaultClass is the name of the class of the requests matching none of the classes. Defaults to the lowest one.
+	DefaultClass string `json:"defaultClass,omitempty"`
+	// ClientKeyHeader is the name of the request header carrying the key of the client, which is looked up in the
+	// ClientKeys of the classes. Optional.
+	ClientKeyHeader string `json:"clientKeyHeader,omitempty"`
+	// Backends is the list of the limits of the backends. The requests to the other backends are always admitted.
+	Backends []AdmissionBackend `json:"backends"`
+	// ThrottledCooldown is how long the backend is regarded as saturated after it throttles a request, during which
+	// only the classes with ShedAt 1 are admitted. Defaults to 10 seconds.
+	ThrottledCooldown time.Duration `json:"throttledCooldown,omitempty"`
+}
+
+// PriorityClass is a priority class of the requests of the admission control.
+type PriorityClass struct {
+	// Name is the name of the class, which is recorded as the priority attribute of the metrics.
+	Name string `json:"name"`
+	// Headers is the map of the request headers which all must match exactly for the request to belong to this class.
+	Headers map[string]string `json:"headers,omitempty"`
+	// ClientKeys is the list of the client keys in the ClientKeyHeader of the AdmissionControl belonging to this class.
+	ClientKeys []string `json:"clientKeys,omitempty"`
+	// ShedAt is the utilization of the backend in the range of (0, 1] above which the requests of this class are shed.
+	// The utilization is the larger of the ratios of the in-flight requests and the tokens of the last minute to the
+	// limits of the backend. Defaults to evenly spaced values decreasing from 1 for the highest class, e.g. 1 and 0.5
+	// for two classes.
+	ShedAt float64 `json:"shedAt,omitempty"`
+}
+
+// AdmissionBackend is the limits of a backend of the admission control.
+type AdmissionBackend struct {
+	// Name is the name of the backend, which is the same as Backend.Name.
+	Name string `json:"name"`
+	// MaxInFlight is the maximum number of the concurrent in-flight requests on the backend. Zero means unlimited.
+	MaxInFlight int `json:"maxInFlight,omitempty"`
+	// TokensPerMinute is the maximum number of the tokens consumed by the requests on the backend in the last minute.
+	// Zero means unlimited.
+	TokensPerMinute int64 `json:"tokensPerMinute,omitempty"`
+}
+
+// CircuitBreaker corresponds to AIServiceBackendCircuitBreaker in api/v1alpha1/ai_service_backend.go.
+//
+// The server errors are detected by the outlier detection of Envoy, so FailOn only contains the classes of the
+// errors which the filter classifies from the error responses.
+type CircuitBreaker struct {
+	// Name is the unique name of the AIServiceBackend, e.g. "backend.namespace".
+	Name string `json:"name"`
+	// Backends is the list of the names of the backends of the route rules referencing the AIServiceBackend,
+	// which share this circuit breaker.
+	Backends []string `json:"backends"`
+	// ConsecutiveFailures is the number of the consecutive failures that opens the circuit breaker.
+	ConsecutiveFailures int `json:"consecutiveFailures"`
+	// FailOn is the list of the classes of the errors counted as the failures.
+	FailOn []CircuitBreakerErrorClass `json:"failOn"`
+	// OpenDuration is how long the circuit breaker stays open before letting the probe requests through.
+	OpenDuration time.Duration `json:"openDuration"`
+	// HalfOpenProbes is the number of the probe requests let through after the open duration.
+	HalfOpenProbes int `json:"halfOpenProbes"`
+}
+
+// CircuitBreakerErrorClass is the class of the errors of the backend counted by the circuit breaker.
+type CircuitBreakerErrorClass string
+
+const (
+	// CircuitBreakerErrorClassThrottled matches the 429 responses and the throttling errors of the providers.
+	CircuitBreakerErrorClassThrottled CircuitBreakerErrorClass = "Throttled"
+	// CircuitBreakerErrorClassServerError matches the 5xx responses.
+	CircuitBreakerErrorClassServerError CircuitBreakerErrorClass = "ServerError"
+)
+
+// Hedging corresponds to AIGatewayRouteRuleHedging in api/v1alpha1/ai_gateway_route.go.
+//
+// The filter sets the per-try timeout of the matched requests to the Delay, on which Envoy sends the hedged requests.
+type Hedging struct {
+	// Name is the unique name of the route rule, e.g. "namespace/route/rule/0".
+	Name string `json:"name"`
+	// Matches is the list of the matches of the route rule, any of which hedges the requests with this configuration.
+	Matches []RouteRuleMatch `json:"matches"`
+	// Delay is the time to wait for the response to start before sending the hedged request.
+	Delay time.Duration `json:"delay"`
+}
+
+// RequestPolicy corresponds to AIGatewayRouteRuleRequestPolicy in api/v1alpha1/ai_gateway_route.go.
+type RequestPolicy struct {
+	// Name is the unique name of the route rule, e.g. "namespace/route/rule/0".
+	Name string `json:"name"`
+	// Matches is the list of the matches of the route rule, any of which limits the requests with this configuration.
+	Matches []RouteRuleMatch `json:"matches"`
+	// MaxTokens limits both max_tokens and max_completion_tokens. Optional.
+	MaxTokens *RequestPolicyLimit `json:"maxTokens,omitempty"`
+	// MaxMessages limits the number of the messages. Optional.
+	MaxMessages *RequestPolicyLimit `json:"maxMessages,omitempty"`
+	// MaxN limits n. Optional.
+	MaxN *RequestPolicyLimit `json:"maxN,omitempty"`
+	// MaxPromptBytes is the maximum size in bytes of the encoded messages. Zero means no limit.
+	MaxPromptBytes int64 `json:"maxPromptBytes,omitempty"`
+	// MaxEstimatedPromptTokens is the maximum number of the estimated tokens of the prompt. Zero means no limit.
+	MaxEstimatedPromptTokens int64 `json:"maxEstimatedPromptTokens,omitempty"`
+	// AllowedModalities is the list of the modalities the requests may use, i.e. "Text", "Image" and "Audio". Any
+	// modality is allowed when empty.
+	AllowedModalities []string `json:"allowedModalities,omitempty"`
+	// ForbiddenFields is the list of the top-level fields of the request body the requests must not set.
+	ForbiddenFields []string `json:"forbiddenFields,omitempty"`
+	// Defaults is the list of the values set to the top-level fields which the requests don't set.
+	Defaults []RequestPolicyDefault `json:"defaults,omitempty"`
+}
+
+// RequestPolicyLimit is a limit of a numeric value of the requests.
+type RequestPolicyLimit struct {
+	// Max is the maximum value.
+	Max int64 `json:"max"`
+	// Clamp is true when the value exceeding the maximum is lowered to it instead of rejecting the request.
+	Clamp bool `json:"clamp,omitempty"`
+}
+
+// RequestPolicyDefault is the default value of a top-level field of the request body.
+type RequestPolicyDefault struct {
+	// Field is the name of the field.
+	Field string `json:"field"`
+	// Value is the JSON value set to the field.
+	Value json.RawMessage `json:"value"`
+}
+
+// SessionAffinity corresponds to AIGatewayRouteRuleSessionAffinity in api/v1alpha1/ai_gateway_route.go.
+//
+// The filter sets the session key of the matched requests to the internalapi.SessionKeyHeaderKey header,
+// which the route hashes on to select the backend.
+type SessionAffinity struct {
+	// Name is the unique name of the route rule, e.g. "namespace/route/rule/0".
+	Name string `json:"name"`
+	// Matches is the list of the matches of the route rule, any of which keys the requests with this configuration.
+	Matches []RouteRuleMatch `json:"matches"`
+	// Type is the source of the session key.
+	Type SessionAffinityType `json:"type"`
+	// Header is the lower-cased name of the request header used as the session key for the Header type.
+	Header string `json:"header,omitempty"`
+	// UserMessages is the number of the user messages in the hashed prefix for the ConversationPrefix type.
+	UserMessages int `json:"userMessages,omitempty"`
+}
+
+// SessionAffinityType is the source of the session key.
+type SessionAffinityType string
+
+const (
+	// SessionAffinityTypeHeader uses the value of a request header as the session key.
+	SessionAffinityTypeHeader SessionAffinityType = "Header"
+	// SessionAffinityTypeConversationPrefix uses the hash of the leading messages as the session key.
+	SessionAffinityTypeConversationPrefix SessionAffinityType = "ConversationPrefix"
+)
+
+// Mirror corresponds to AIGatewayRouteRuleMirror in api/v1alpha1/ai_gateway_route.go.
+//
+// The filter replays the requests served by any of the Backends to the shadow backend after the response.
+type Mirror struct {
+	// Name is the unique name of the route rule, e.g. "namespace/route/rule/0".
+	Name string `json:"name"`
+	// Backends is the list of the names of the primary backends of the route rule.
+	Backends []string `json:"backends"`
+	// Endpoint is the base URL of the shadow backend, e.g. "https://api.openai.com".
+	// The path of the chat completions endpoint is appended by the translation of the backend schema.
+	Endpoint string `json:"endpoint"`
+	// ModelNameOverride is the name of the model sent to the shadow backend. Optional.
+	ModelNameOverride string `json:"modelNameOverride,omitempty"`
+	// Percentage is the percentage of the requests mirrored in the range [0, 100].
+	Percentage int `json:"percentage"`
+	// Timeout is the timeout of each mirrored request. The zero value means no timeout.
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// Backend is the schema and the auth of the shadow backend. The Name is recorded in the sink.
+	Backend Backend `json:"backend"`
+	// Sink is where the mirrored responses are written.
+	Sink MirrorSink `json:"sink"`
+}
+
+// MirrorSink corresponds to AIGatewayRouteRuleMirrorSink in api/v1alpha1/ai_gateway_route.go.
+type MirrorSink struct {
+	// Type is the type of the sink.
+	Type MirrorSinkType `json:"type"`
+	// FilePath is the path of the file to which the records are appended. Set only for the File type.
+	FilePath string `json:"filePath,omitempty"`
+	// OTLPEndpoint is the URL of the OTLP/HTTP logs endpoint. Optional for the OTLPLogs type, in which case
+	// the standard OTEL_EXPORTER_OTLP_* environment variables are used.
+	OTLPEndpoint string `json:"otlpEndpoint,omitempty"`
+}
+
+// MirrorSinkType is the type of the sink of the mirrored responses.
+type MirrorSinkType string
+
+const (
+	// MirrorSinkTypeFile appends the records to a file as JSON lines.
+	MirrorSinkTypeFile MirrorSinkType = "File"
+	// MirrorSinkTypeOTLPLogs exports the records as OpenTelemetry log records.
+	MirrorSinkTypeOTLPLogs MirrorSinkType = "OTLPLogs"
+)
+
+// BodyHeader corresponds to AIGatewayRouteBodyHeader in api/v1alpha1/ai_gateway_route.go.
+//
+// Exactly one of CEL or JSONPath is set.
+type BodyHeader struct {
+	// Name is the lower-cased name of the header.
+	Name string `json:"name"`
+	// CEL is the CEL expression evaluated against the request body.
+	CEL string `json:"cel,omitempty"`
+	// JSONPath is the JSONPath expression evaluated against the request body.
+	JSONPath string `json:"jsonPath,omitempty"`
+}
+
+// AdaptiveLoadBalancer corresponds to AIGatewayRouteRuleAdaptiveLoadBalancing in api/v1alpha1/ai_gateway_route.go.
+//
+// The filter selects one of the backends per request and sets its name to the internalapi.SelectedBackendHeaderKey
+// header, which the generated HTTPRoute matches on to prefer the selected backend.
+type AdaptiveLoadBalancer struct {
+	// Name is the unique name of the route rule, e.g. "namespace/route/rule/0".
+	Name string `json:"name"`
+	// Matches is the list of the matches of the route rule, any of which selects the backends with this balancer.
+	Matches []RouteRuleMatch `json:"matches"`
+	// Strategy is the strategy of the selection of the backends.
+	Strategy AdaptiveLoadBalancerStrategy `json:"strategy,omitempty"`
+	// Backends is the list of the backends of the route rule.
+	Backends []AdaptiveLoadBalancerBackend `json:"backends"`
+	// LatencyWindow is the half-life of the exponentially weighted moving averages of the latencies.
+	LatencyWindow time.Duration `json:"latencyWindow"`
+	// ErrorRateWindow is the half-life of the exponentially weighted moving average of the error rate.
+	ErrorRateWindow time.Duration `json:"errorRateWindow"`
+	// OutlierEjection configures the temporary exclusion of the failing backends. Optional.
+	OutlierEjection *OutlierEjection `json:"outlierEjection,omitempty"`
+}
+
+// AdaptiveLoadBalancerStrategy is the strategy of the selection of the backends.
+type AdaptiveLoadBalancerStrategy string
+
+const (
+	// AdaptiveLoadBalancerStrategyLeastLatency prefers the backends with the lower latency and error rate.
+	// This is the default.
+	AdaptiveLoadBalancerStrategyLeastLatency AdaptiveLoadBalancerStrategy = "LeastLatency"
+	// AdaptiveLoadBalancerStrategyLowestCost selects the cheapest backend which is not ejected.
+	AdaptiveLoadBalancerStrategyLowestCost AdaptiveLoadBalancerStrategy = "LowestCost"
+)
+
+// AdaptiveLoadBalancerBackend is a backend of the route rule with the adaptive load balancing.
+type AdaptiveLoadBalancerBackend struct {
+	// Name is the name of the backend, which is the same as Backend.Name.
+	Name string `json:"name"`
+	// Weight is the static weight of the backend, which is multiplied with the score-based weight.
+	Weight int `json:"weight"`
+	// Price is the price of the model served by the backend, used by the LowestCost strategy. Optional.
+	Price *ModelPrice `json:"price,omitempty"`
+}
+
+// OutlierEjection configures the temporary exclusion of the failing backends from the selection.
+type OutlierEjection struct {
+	// ConsecutiveErrors is the number of the consecutive errors after which the backend is ejected.
+	ConsecutiveErrors int `json:"consecutiveErrors"`
+	// BaseEjectionTime is the duration of the first ejection. It is multiplied by the number of the ejections.
+	BaseEjectionTime time.Duration `json:"baseEjectionTime"`
+	// MaxEjectionPercent is the maximum percentage of the backends ejected at the same time.
+	MaxEjectionPercent int `json:"maxEjectionPercent"`
+}
+
+// QuotaPolicy corresponds to AIGatewayQuotaPolicy in api/v1alpha1/ai_gateway_quota_policy.go.
+//
+// The token spend of each client is tracked over the sliding windows, and the request is rejected
+// when any of the budgets is exhausted.
+type QuotaPolicy struct {
+	// Name is the unique name of the policy, which is a part of the keys of the tracked spend.
+	Name string `json:"name"`
+	// Models is the list of the model names to which the policy applies.
+	// This is derived from the routes targeted by the policy.
+	Models []string `json:"models,omitempty"`
+	// ClientKeyHeader is the request header whose value identifies the client. The requests without
+	// the header are not subject to the policy.
+	ClientKeyHeader string `json:"clientKeyHeader"`
+	// ClientKeys restricts the policy to the clients whose values of the ClientKeyHeader are in the list. Optional.
+	// This is set for the budgets of the virtual keys, which are identified by the name of the key.
+	ClientKeys []string `json:"clientKeys,omitempty"`
+	// Budgets is the list of the token budgets of each client.
+	Budgets []QuotaBudget `json:"budgets"`
+	// Redis configures a Redis-compatible server to share the spend across the filter instances. Optional.
+	// If this is not provided, the spend is tracked in memory per filter instance.
+	Redis *QuotaRedisConfig `json:"redis,omitempty"`
+}
+
+// QuotaBudget is the maximum number of tokens or the maximum spend of a client within the sliding window.
+type QuotaBudget struct {
+	// Type is the kind of the usage counted against the budget.
+	Type QuotaBudgetType `json:"type"`
+	// Limit is the maximum number of tokens within the window, or the maximum spend in micro US dollars for
+	// the QuotaBudgetTypeSpend.
+	Limit int64 `json:"limit"`
+	// Window is the duration of the sliding window.
+	Window time.Duration `json:"window"`
+}
+
+// QuotaBudgetType specifies the kind of the usage counted against the budget.
+type QuotaBudgetType string
+
+const (
+	// QuotaBudgetTypeInputToken counts the input tokens.
+	QuotaBudgetTypeInputToken QuotaBudgetType = "InputToken"
+	// QuotaBudgetTypeOutputToken counts the output tokens.
+	QuotaBudgetTypeOutputToken QuotaBudgetType = "OutputToken"
+	// QuotaBudgetTypeTotalToken counts the total tokens.
+	QuotaBudgetTypeTotalToken QuotaBudgetType = "TotalToken"
+	// QuotaBudgetTypeSpend counts the cost of the requests in micro US dollars, which is calculated from the token
+	// usage with the price of the model served by the backend. See ModelPrice.
+	QuotaBudgetTypeSpend QuotaBudgetType = "Spend"
+)
+
+// QuotaRedisConfig is the configuration of the Redis-compatible server used by the quotas.
+type QuotaRedisConfig struct {
+	// Address is the host:port of the server.
+	Address string `json:"address"`
+	// KeyPrefix is prepended to each key of the tracked spend stored in the server.
+	KeyPrefix string `json:"keyPrefix,omitempty"`
+	// Username is the ACL user authenticated with the Password. Optional.
+	Username string `json:"username,omitempty"`
+	// Password is the password sent with the AUTH command. Optional.
+	Password string `json:"password,omitempty"`
+	// TLS enables TLS to the server. Optional.
+	TLS *RedisTLSConfig `json:"tls,omitempty"`
+}
+
+// RedisTLSConfig is the TLS configuration of the connections to a Redis-compatible server.
+type RedisTLSConfig struct {
+	// ServerName is the name used to verify the certificate of the server. Defaults to the host of the address.
+	ServerName string `json:"serverName,omitempty"`
+	// CACertificate is the PEM-encoded CA certificates used to verify the server. Defaults to the system roots.
+	CACertificate string `json:"caCertificate,omitempty"`
+}
+
+// VirtualKeyPolicy corresponds to AIGatewayVirtualKeyPolicy in api/v1alpha1/ai_gateway_virtual_key_policy.go.
+type VirtualKeyPolicy struct {
+	// Name is the unique name of the policy, e.g. "namespace/name".
+	Name string `json:"name"`
+	// Models is the list of the model names to which the policy applies.
+	// This is derived from the routes targeted by the policy.
+	Models []string `json:"models"`
+	// ClientKeyHeader is the lower-cased name of the request header carrying the virtual key.
+	ClientKeyHeader string `json:"clientKeyHeader"`
+	// TeamIDHeader is the lower-cased name of the request header set to the team ID of the key.
+	TeamIDHeader string `json:"teamIDHeader"`
+	// Keys is the list of the virtual keys.
+	Keys []VirtualKey `json:"keys"`
+}
+
+// VirtualKey is a virtual key of a client.
+type VirtualKey struct {
+	// Name is the unique name of the key, e.g. "namespace/policy/key".
+	Name string `json:"name"`
+	// Hash is the hex-encoded SHA-256 hash of the key. The key itself is never passed to the filter.
+	Hash string `json:"hash"`
+	// TeamID is the ID of the team to which the key is issued.
+	TeamID string `json:"teamID"`
+	// AllowedModels is the list of the models the key can use. When this is empty, the key can use all the models
+	// of the policy.
+	AllowedModels []string `json:"allowedModels,omitempty"`
+}
+
+// ResponseCacheConfig corresponds to ResponseCache in api/v1alpha1/ai_gateway_route.go.
+//
+// The zero value of each limit means that the limit is not enforced.
+type ResponseCacheConfig struct {
+	// TTL is the duration for which a cached response is served after it is stored.
+	TTL time.Duration `json:"ttl,omitempty"`
+	// MaxEntries is the maximum number of responses held by the in-memory cache.
+	// The least recently used response is evicted when the limit is reached.
+	MaxEntries int `json:"maxEntries,omitempty"`
+	// MaxEntryBytes is the maximum size of a single response to be cached. Larger responses are not cached.
+	MaxEntryBytes int `json:"maxEntryBytes,omitempty"`
+	// Redis configures a Redis-compatible server to store the responses instead of the in-memory cache. Optional.
+	Redis *ResponseCacheRedisConfig `json:"redis,omitempty"`
+	// Semantic configures the semantic lookup of the cached responses in addition to the exact match. Optional.
+	Semantic *SemanticResponseCacheConfig `json:"semantic,omitempty"`
+}
+
+// SemanticResponseCacheConfig corresponds to SemanticResponseCache in api/v1alpha1/ai_gateway_route.go.
+//
+// The last user message of the request is embedded by the embeddings backend, and the response of the most
+// similar previous request is served when the similarity is above the threshold.
+type SemanticResponseCacheConfig struct {
+	// Models is the list of the model names for which the semantic lookup is enabled.
+	// This is derived from the routes that opt in to the semantic lookup.
+	Models []string `json:"models,omitempty"`
+	// SimilarityThreshold is the minimum cosine similarity in the range (0, 1] for a cached response to be served.
+	SimilarityThreshold float64 `json:"similarityThreshold"`
+	// MaxEntries is the maximum number of the embeddings held by the vector store.
+	// The oldest embedding is evicted when the limit is reached.
+	MaxEntries int `json:"maxEntries,omitempty"`
+	// Embedding is the embeddings backend used to embed the requests.
+	Embedding SemanticResponseCacheEmbedding `json:"embedding"`
+}
+
+// SemanticResponseCacheEmbedding is the embeddings backend called by the filter to embed the requests.
+type SemanticResponseCacheEmbedding struct {
+	// Endpoint is the base URL of the embeddings backend, e.g. "https://api.openai.com".
+	// The path of the embeddings endpoint is appended by the translation of the backend schema.
+	Endpoint string `json:"endpoint"`
+	// Model is the name of the embedding model sent to the backend.
+	Model string `json:"model"`
+	// Timeout is the timeout of each embedding request. The zero value means no timeout.
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// Backend is the schema and the auth of the embeddings backend. The Name is only used for logging.
+	Backend Backend `json:"backend"`
+}
+
+// ResponseCacheRedisConfig is the configuration of the Redis-compatible server used by the response cache.
+type ResponseCacheRedisConfig struct {
+	// Address is the host:port of the server.
+	Address string `json:"address"`
+	// KeyPrefix is prepended to each cache key stored in the server.
+	KeyPrefix string `json:"keyPrefix,omitempty"`
+	// Username is the ACL user authenticated with the Password. Optional.
+	Username string `json:"username,omitempty"`
+	// Password is the password sent with the AUTH command. Optional.
+	Password string `json:"password,omitempty"`
+	// TLS enables TLS to the server. Optional.
+	TLS *RedisTLSConfig `json:"tls,omitempty"`
 }
 
 // Model corresponds to the OpenAI model object in the OpenAI-compatible APIs
@@ -55,6 +628,13 @@ type Model struct {
 	OwnedBy string
 	// createdAt will be exported as the field of "Created" in OpenAI-compatible API "/models".
 	CreatedAt time.Time
+	// Aliases is the list of the alternative names of the model. The filter rewrites the model name header
+	// from the alias to Name so that the routing is done with Name. Optional.
+	Aliases []string `json:"aliases,omitempty"`
+	// ContextWindow is the maximum number of tokens of the model context. Optional.
+	ContextWindow int `json:"contextWindow,omitempty"`
+	// Modalities is the list of the input and output modalities supported by the model. Optional.
+	Modalities []string `json:"modalities,omitempty"`
 }
 
 // LLMRequestCost specifies "where" the request cost is stored in the filter metadata as well as
@@ -84,6 +664,12 @@ const (
 	LLMRequestCostTypeInputToken LLMRequestCostType = "InputToken"
 	// LLMRequestCostTypeTotalToken specifies that the request cost is calculated from the total token.
 	LLMRequestCostTypeTotalToken LLMRequestCostType = "TotalToken"
+	// LLMRequestCostTypeImage specifies that the request cost is calculated from the number of generated images.
+	LLMRequestCostTypeImage LLMRequestCostType = "Image"
+	// LLMRequestCostTypeAudioSecond specifies that the request cost is calculated from the seconds of the transcribed audio.
+	LLMRequestCostTypeAudioSecond LLMRequestCostType = "AudioSecond"
+	// LLMRequestCostTypeCharacter specifies that the request cost is calculated from the characters synthesized into speech.
+	LLMRequestCostTypeCharacter LLMRequestCostType = "Character"
 	// LLMRequestCostTypeCEL specifies that the request cost is calculated from the CEL expression.
 	LLMRequestCostTypeCEL LLMRequestCostType = "CEL"
 )
@@ -112,6 +698,9 @@ const (
 	// APISchemaGCPAnthropic represents the Google Cloud Anthropic API schema.
 	// Used for Claude models hosted on Google Cloud Vertex AI.
 	APISchemaGCPAnthropic APISchemaName = "GCPAnthropic"
+	// APISchemaAnthropic represents the native Anthropic API schema.
+	// https://docs.anthropic.com/en/api/messages
+	APISchemaAnthropic APISchemaName = "Anthropic"
 )
 
 // RouteRuleName is the name of the route rule.
@@ -128,12 +717,53 @@ type Backend struct {
 	Schema VersionedAPISchema `json:"schema"`
 	// Auth is the authn/z configuration for the backend. Optional.
 	Auth *BackendAuth `json:"auth,omitempty"`
+	// Fallback specifies which failures of this backend are retried on the other backends of the same rule. Optional.
+	//
+	// When set, the error responses of this backend are classified at the upstream filter so that Envoy
+	// retries the request according to the retry policy configured on the route.
+	Fallback *BackendFallback `json:"fallback,omitempty"`
+	// Price is the price of the model served by this backend. Optional.
+	//
+	// When set, the cost of each request in USD is recorded and exposed to the CEL expressions of the request costs.
+	Price *ModelPrice `json:"price,omitempty"`
+}
+
+// ModelPrice corresponds to AIGatewayRouteModelPrice in api/v1alpha1/ai_gateway_route.go.
+//
+// All the prices are in USD per million tokens.
+type ModelPrice struct {
+	// InputPerMillionTokens is the price of the input tokens not read from the prompt cache.
+	InputPerMillionTokens float64 `json:"inputPerMillionTokens"`
+	// CachedInputPerMillionTokens is the price of the input tokens read from the prompt cache.
+	CachedInputPerMillionTokens float64 `json:"cachedInputPerMillionTokens"`
+	// OutputPerMillionTokens is the price of the output tokens.
+	OutputPerMillionTokens float64 `json:"outputPerMillionTokens"`
+}
+
+// BackendFallback corresponds to AIGatewayRouteRuleFallback in api/v1alpha1/ai_gateway_route.go.
+type BackendFallback struct {
+	// RetryOn is the list of the failures on which the request is retried.
+	RetryOn []FallbackCondition `json:"retryOn"`
 }
 
+// FallbackCondition specifies the kind of the failures of the backend that trigger the retry.
+type FallbackCondition string
+
+const (
+	// FallbackConditionContextLengthExceeded matches the errors of the requests exceeding the context window of the model.
+	FallbackConditionContextLengthExceeded FallbackCondition = "ContextLengthExceeded"
+	// FallbackConditionThrottled matches the 429 responses and the throttling errors of the providers.
+	FallbackConditionThrottled FallbackCondition = "Throttled"
+	// FallbackConditionServerError matches the 5xx responses.
+	FallbackConditionServerError FallbackCondition = "ServerError"
+)
+
 // BackendAuth corresponds partially to BackendSecurityPolicy in api/v1alpha1/api.go.
 type BackendAuth struct {
 	// APIKey is a location of the api key secret file.
 	APIKey *APIKeyAuth `json:"apiKey,omitempty"`
+	// AnthropicAPIKey is the Anthropic API key which is sent as the x-api-key header.
+	AnthropicAPIKey *AnthropicAPIKeyAuth `json:"anthropicAPIKey,omitempty"`
 	// AWSAuth specifies the location of the AWS credential file and region.
 	AWSAuth *AWSAuth `json:"aws,omitempty"`
 	// AzureAuth specifies the location of Azure access token file.
@@ -156,6 +786,12 @@ type APIKeyAuth struct {
 	Key string `json:"key"`
 }
 
+// AnthropicAPIKeyAuth defines the Anthropic API key.
+type AnthropicAPIKeyAuth struct {
+	// Key is the API key as a literal string.
+	Key string `json:"key"`
+}
+
 // AzureAuth defines the file containing azure access token that will be mounted to the external proc.
 type AzureAuth struct {
 	// AccessToken is the access token as a literal string.
@@ -10,6 +10,7 @@ import (
 	"os"
 	"path"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -42,6 +43,185 @@ metadataNamespace: ai_gateway_llm_ns
 llmRequestCosts:
 - metadataKey: token_usage_key
   type: OutputToken
+backends:
+- name: bedrock
+  schema:
+    name: AWSBedrock
+  fallback:
+    retryOn: [Throttled, ContextLengthExceeded]
+models:
+- name: claude-sonnet
+  ownedBy: anthropic
+  aliases: [sonnet]
+  contextWindow: 200000
+  modalities: [Text, Image]
+responseCache:
+  ttl: 60000000000
+  maxEntries: 100
+  redis:
+    address: redis:6379
+  semantic:
+    models: [gpt-4o]
+    similarityThreshold: 0.9
+    embedding:
+      endpoint: https://api.openai.com
+      model: text-embedding-3-small
+      backend:
+        name: openai
+        schema:
+          name: OpenAI
+          version: v1
+quotas:
+- name: default/team-budget
+  models: [gpt-4o]
+  clientKeyHeader: x-api-key
+  budgets:
+  - type: TotalToken
+    limit: 100000
+    window: 3600000000000
+  redis:
+    address: redis:6379
+    keyPrefix: "aigw:quota:"
+- name: default/team-keys/key/team-a
+  models: [gpt-4o]
+  clientKeyHeader: x-ai-eg-virtual-key
+  clientKeys: [default/team-keys/team-a]
+  budgets:
+  - type: TotalToken
+    limit: 1000000
+    window: 86400000000000
+virtualKeyPolicies:
+- name: default/team-keys
+  models: [gpt-4o]
+  clientKeyHeader: authorization
+  teamIDHeader: x-ai-eg-team-id
+  keys:
+  - name: default/team-keys/team-a
+    hash: 8879f6a4ae35c420a15d35fed3b8dd07577207803d404f6d4cc4fa829dafa910
+    teamID: team-a
+    allowedModels: [gpt-4o]
+adaptiveLoadBalancers:
+- name: default/route/rule/0
+  matches:
+  - model: gpt-4o
+    headers:
+      x-tier: cheap
+  strategy: LowestCost
+  backends:
+  - name: azure-eastus
+    weight: 1
+    price:
+      inputPerMillionTokens: 2.5
+      cachedInputPerMillionTokens: 1.25
+      outputPerMillionTokens: 10
+  latencyWindow: 30000000000
+  errorRateWindow: 60000000000
+  outlierEjection:
+    consecutiveErrors: 5
+    baseEjectionTime: 30000000000
+    maxEjectionPercent: 50
+bodyHeaders:
+- name: x-has-tools
+  cel: has(request.tools)
+- name: x-response-format
+  jsonPath: $.response_format.type
+mirrors:
+- name: ns/route/rule/0
+  backends: [openai]
+  endpoint: https://api.openai.com
+  modelNameOverride: gpt-5
+  percentage: 10
+  timeout: 60000000000
+  backend:
+    name: candidate.ns
+    schema:
+      name: OpenAI
+  sink:
+    type: File
+    filePath: /tmp/mirror.jsonl
+sessionAffinities:
+- name: ns/route/rule/1
+  matches:
+  - model: claude-sonnet
+  type: ConversationPrefix
+  userMessages: 1
+hedgings:
+- name: ns/route/rule/2
+  matches:
+  - model: autocomplete
+    headers:
+      x-tier: fast
+  delay: 300000000
+requestPolicies:
+- name: ns/route/rule/0
+  matches:
+  - model: gpt-4o
+  maxTokens:
+    max: 4096
+    clamp: true
+  maxMessages:
+    max: 100
+  maxPromptBytes: 1048576
+  allowedModalities:
+  - Text
+  forbiddenFields:
+  - logprobs
+  defaults:
+  - field: temperature
+    value: 0.2
+circuitBreakers:
+- name: vertex.ns
+  backends:
+  - ns/vertex/route/route/rule/0/ref/1
+  consecutiveFailures: 3
+  failOn:
+  - Throttled
+  openDuration: 60000000000
+  halfOpenProbes: 2
+admissionControl:
+  classes:
+  - name: paid
+    headers:
+      x-tier: paid
+    clientKeys:
+    - key-1
+    shedAt: 1
+  - name: free
+  clientKeyHeader: x-client-key
+  backends:
+  - name: openai
+    maxInFlight: 100
+    tokensPerMinute: 1000000
+  throttledCooldown: 5000000000
+piiRedactions:
+- name: ns/route
+  matches:
+  - model: gpt-4o
+  action: Mask
+  detectors:
+  - name: email
+    type: Email
+  - name: employee_id
+    type: Regex
+    regex: EMP-[0-9]{6}
+guardrails:
+- name: ns/route
+  matches:
+  - model: gpt-4o
+  protocol: GRPC
+  endpoint: moderation.default:9090
+  request: true
+  response: true
+  timeout: 1000000000
+  failOpen: true
+toolPolicies:
+- name: ns/route
+  matches:
+  - model: gpt-4o
+  allowedFunctions:
+  - get_weather
+  validateArguments: true
+  action: Rewrite
 `
 	require.NoError(t, os.WriteFile(configPath, []byte(config), 0o600))
 	cfg, err := filterapi.UnmarshalConfigYaml(configPath)
@@ -56,6 +236,176 @@ llmRequestCosts:
 				Type:        filterapi.LLMRequestCostTypeOutputToken,
 			},
 		},
+		Backends: []filterapi.Backend{
+			{
+				Name:   "bedrock",
+				Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock},
+				Fallback: &filterapi.BackendFallback{
+					RetryOn: []filterapi.FallbackCondition{filterapi.FallbackConditionThrottled, filterapi.FallbackConditionContextLengthExceeded},
+				},
+			},
+		},
+		Models: []filterapi.Model{
+			{
+				Name:          "claude-sonnet",
+				OwnedBy:       "anthropic",
+				Aliases:       []string{"sonnet"},
+				ContextWindow: 200000,
+				Modalities:    []string{"Text", "Image"},
+			},
+		},
+		ResponseCache: &filterapi.ResponseCacheConfig{
+			TTL:        time.Minute,
+			MaxEntries: 100,
+			Redis:      &filterapi.ResponseCacheRedisConfig{Address: "redis:6379"},
+			Semantic: &filterapi.SemanticResponseCacheConfig{
+				Models:              []string{"gpt-4o"},
+				SimilarityThreshold: 0.9,
+				Embedding: filterapi.SemanticResponseCacheEmbedding{
+					Endpoint: "https://api.openai.com",
+					Model:    "text-embedding-3-small",
+					Backend: filterapi.Backend{
+						Name:   "openai",
+						Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI, Version: "v1"},
+					},
+				},
+			},
+		},
+		Quotas: []filterapi.QuotaPolicy{
+			{
+				Name:            "default/team-budget",
+				Models:          []string{"gpt-4o"},
+				ClientKeyHeader: "x-api-key",
+				Budgets: []filterapi.QuotaBudget{
+					{Type: filterapi.QuotaBudgetTypeTotalToken, Limit: 100000, Window: time.Hour},
+				},
+				Redis: &filterapi.QuotaRedisConfig{Address: "redis:6379", KeyPrefix: "aigw:quota:"},
+			},
+			{
+				Name:            "default/team-keys/key/team-a",
+				Models:          []string{"gpt-4o"},
+				ClientKeyHeader: "x-ai-eg-virtual-key",
+				ClientKeys:      []string{"default/team-keys/team-a"},
+				Budgets: []filterapi.QuotaBudget{
+					{Type: filterapi.QuotaBudgetTypeTotalToken, Limit: 1000000, Window: 24 * time.Hour},
+				},
+			},
+		},
+		VirtualKeyPolicies: []filterapi.VirtualKeyPolicy{
+			{
+				Name:            "default/team-keys",
+				Models:          []string{"gpt-4o"},
+				ClientKeyHeader: "authorization",
+				TeamIDHeader:    "x-ai-eg-team-id",
+				Keys: []filterapi.VirtualKey{{
+					Name:          "default/team-keys/team-a",
+					Hash:          "8879f6a4ae35c420a15d35fed3b8dd07577207803d404f6d4cc4fa829dafa910",
+					TeamID:        "team-a",
+					AllowedModels: []string{"gpt-4o"},
+				}},
+			},
+		},
+		AdaptiveLoadBalancers: []filterapi.AdaptiveLoadBalancer{
+			{
+				Name:     "default/route/rule/0",
+				Matches:  []filterapi.RouteRuleMatch{{Model: "gpt-4o", Headers: map[string]string{"x-tier": "cheap"}}},
+				Strategy: filterapi.AdaptiveLoadBalancerStrategyLowestCost,
+				Backends: []filterapi.AdaptiveLoadBalancerBackend{{
+					Name:   "azure-eastus",
+					Weight: 1,
+					Price:  &filterapi.ModelPrice{InputPerMillionTokens: 2.5, CachedInputPerMillionTokens: 1.25, OutputPerMillionTokens: 10},
+				}},
+				LatencyWindow:   30 * time.Second,
+				ErrorRateWindow: time.Minute,
+				OutlierEjection: &filterapi.OutlierEjection{ConsecutiveErrors: 5, BaseEjectionTime: 30 * time.Second, MaxEjectionPercent: 50},
+			},
+		},
+		BodyHeaders: []filterapi.BodyHeader{
+			{Name: "x-has-tools", CEL: "has(request.tools)"},
+			{Name: "x-response-format", JSONPath: "$.response_format.type"},
+		},
+		Mirrors: []filterapi.Mirror{
+			{
+				Name:              "ns/route/rule/0",
+				Backends:          []string{"openai"},
+				Endpoint:          "https://api.openai.com",
+				ModelNameOverride: "gpt-5",
+				Percentage:        10,
+				Timeout:           time.Minute,
+				Backend:           filterapi.Backend{Name: "candidate.ns", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}},
+				Sink:              filterapi.MirrorSink{Type: filterapi.MirrorSinkTypeFile, FilePath: "/tmp/mirror.jsonl"},
+			},
+		},
+		SessionAffinities: []filterapi.SessionAffinity{
+			{
+				Name:         "ns/route/rule/1",
+				Matches:      []filterapi.RouteRuleMatch{{Model: "claude-sonnet"}},
+				Type:         filterapi.SessionAffinityTypeConversationPrefix,
+				UserMessages: 1,
+			},
+		},
+		Hedgings: []filterapi.Hedging{
+			{
+				Name:    "ns/route/rule/2",
+				Matches: []filterapi.RouteRuleMatch{{Model: "autocomplete", Headers: map[string]string{"x-tier": "fast"}}},
+				Delay:   300 * time.Millisecond,
+			},
+		},
+		RequestPolicies: []filterapi.RequestPolicy{{
+			Name:              "ns/route/rule/0",
+			Matches:           []filterapi.RouteRuleMatch{{Model: "gpt-4o"}},
+			MaxTokens:         &filterapi.RequestPolicyLimit{Max: 4096, Clamp: true},
+			MaxMessages:       &filterapi.RequestPolicyLimit{Max: 100},
+			MaxPromptBytes:    1048576,
+			AllowedModalities: []string{"Text"},
+			ForbiddenFields:   []string{"logprobs"},
+			Defaults:          []filterapi.RequestPolicyDefault{{Field: "temperature", Value: []byte("0.2")}},
+		}},
+		CircuitBreakers: []filterapi.CircuitBreaker{
+			{
+				Name:                "vertex.ns",
+				Backends:            []string{"ns/vertex/route/route/rule/0/ref/1"},
+				ConsecutiveFailures: 3,
+				FailOn:              []filterapi.CircuitBreakerErrorClass{filterapi.CircuitBreakerErrorClassThrottled},
+				OpenDuration:        time.Minute,
+				HalfOpenProbes:      2,
+			},
+		},
+		AdmissionControl: &filterapi.AdmissionControl{
+			Classes: []filterapi.PriorityClass{
+				{Name: "paid", Headers: map[string]string{"x-tier": "paid"}, ClientKeys: []string{"key-1"}, ShedAt: 1},
+				{Name: "free"},
+			},
+			ClientKeyHeader:   "x-client-key",
+			Backends:          []filterapi.AdmissionBackend{{Name: "openai", MaxInFlight: 100, TokensPerMinute: 1000000}},
+			ThrottledCooldown: 5 * time.Second,
+		},
+		PIIRedactions: []filterapi.PIIRedaction{{
+			Name:    "ns/route",
+			Matches: []filterapi.RouteRuleMatch{{Model: "gpt-4o"}},
+			Action:  filterapi.PIIRedactionActionMask,
+			Detectors: []filterapi.PIIDetector{
+				{Name: "email", Type: filterapi.PIIDetectorTypeEmail},
+				{Name: "employee_id", Type: filterapi.PIIDetectorTypeRegex, Regex: "EMP-[0-9]{6}"},
+			},
+		}},
+		Guardrails: []filterapi.Guardrail{{
+			Name:     "ns/route",
+			Matches:  []filterapi.RouteRuleMatch{{Model: "gpt-4o"}},
+			Protocol: filterapi.GuardrailProtocolGRPC,
+			Endpoint: "moderation.default:9090",
+			Request:  true,
+			Response: true,
+			Timeout:  time.Second,
+			FailOpen: true,
+		}},
+		ToolPolicies: []filterapi.ToolPolicy{{
+			Name:              "ns/route",
+			Matches:           []filterapi.RouteRuleMatch{{Model: "gpt-4o"}},
+			AllowedFunctions:  []string{"get_weather"},
+			ValidateArguments: true,
+			Action:            filterapi.ToolPolicyActionRewrite,
+		}},
 	}
 
 	require.Equal(t, expectedCfg, cfg)
@@ -0,0 +1,58 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package filterapi
+
+// RouteRuleMatch is a match of a rule of the AIGatewayRoute, which the router filter evaluates on the model name
+// and the other request headers.
+type RouteRuleMatch struct {
+	// Model is the model name matched by the rule.
+	Model string `json:"model"`
+	// Headers are the other request headers matched exactly by the rule, keyed by the lower-cased name. Optional.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// RouteRuleMatcher finds the value associated with the RouteRuleMatch matching a request. The zero value has no match.
+type RouteRuleMatcher[T any] struct {
+	byModel map[string][]routeRuleMatcherEntry[T]
+}
+
+// routeRuleMatcherEntry is a match on the other headers than the model name with its value.
+type routeRuleMatcherEntry[T any] struct {
+	headers map[string]string
+	value   T
+}
+
+// Add associates the value with the match.
+func (m *RouteRuleMatcher[T]) Add(match RouteRuleMatch, value T) {
+	if m.byModel == nil {
+		m.byModel = make(map[string][]routeRuleMatcherEntry[T])
+	}
+	m.byModel[match.Model] = append(m.byModel[match.Model], routeRuleMatcherEntry[T]{headers: match.Headers, value: value})
+}
+
+// Find returns the value of the match of the given model and the request headers, or the zero value if there's none.
+// When multiple matches apply, the one matching the most headers is returned in the same way as the precedence of
+// the HTTPRoute rules, and the first added one among them.
+func (m *RouteRuleMatcher[T]) Find(model string, headers map[string]string) T {
+	var ret T
+	matched := -1
+	for _, e := range m.byModel[model] {
+		if len(e.headers) <= matched {
+			continue
+		}
+		ok := true
+		for k, v := range e.headers {
+			if headers[k] != v {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			ret, matched = e.value, len(e.headers)
+		}
+	}
+	return ret
+}
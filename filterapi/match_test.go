@@ -0,0 +1,41 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package filterapi_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestRouteRuleMatcher(t *testing.T) {
+	var m filterapi.RouteRuleMatcher[string]
+	require.Empty(t, m.Find("gpt-4o", nil))
+
+	m.Add(filterapi.RouteRuleMatch{Model: "gpt-4o"}, "any")
+	m.Add(filterapi.RouteRuleMatch{Model: "gpt-4o", Headers: map[string]string{"x-tenant": "a"}}, "tenant-a")
+	m.Add(filterapi.RouteRuleMatch{Model: "gpt-4o", Headers: map[string]string{"x-tenant": "a", "x-tier": "gold"}}, "tenant-a-gold")
+	m.Add(filterapi.RouteRuleMatch{Model: "gpt-4o", Headers: map[string]string{"x-tenant": "b"}}, "tenant-b")
+	m.Add(filterapi.RouteRuleMatch{Model: "gpt-4o", Headers: map[string]string{"x-tenant": "b"}}, "tenant-b-duplicate")
+
+	for _, tc := range []struct {
+		model   string
+		headers map[string]string
+		exp     string
+	}{
+		{model: "gpt-4o", exp: "any"},
+		{model: "gpt-4o", headers: map[string]string{"x-tenant": "a"}, exp: "tenant-a"},
+		{model: "gpt-4o", headers: map[string]string{"x-tenant": "a", "x-tier": "gold"}, exp: "tenant-a-gold"},
+		{model: "gpt-4o", headers: map[string]string{"x-tenant": "a", "x-tier": "silver"}, exp: "tenant-a"},
+		{model: "gpt-4o", headers: map[string]string{"x-tenant": "b"}, exp: "tenant-b"},
+		{model: "gpt-4o", headers: map[string]string{"x-tenant": "c"}, exp: "any"},
+		{model: "gpt-4o-mini", headers: map[string]string{"x-tenant": "a"}, exp: ""},
+	} {
+		require.Equal(t, tc.exp, m.Find(tc.model, tc.headers), "%s %v", tc.model, tc.headers)
+	}
+}
@@ -0,0 +1,297 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package adaptivelb provides the selection of the backends of a route rule based on the observed latencies and errors.
+//
+// Each backend has a score computed from the exponentially weighted moving averages (EWMA) of the time to first token,
+// the inter-token latency and the error rate. A backend is selected randomly with the probability proportional to its
+// static weight divided by its score, so the faster and healthier backends receive more traffic while the others are
+// still probed. The backends failing consecutively are ejected from the selection for a while.
+//
+// With the LowestCost strategy, only the cheapest backends which are not ejected are selected in the same way.
+package adaptivelb
+
+import (
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+const (
+	// minLatency is the lower bound of the latency in seconds used for the score to avoid the division by zero.
+	minLatency = 0.001
+	// minSuccessRate is the lower bound of the success rate used for the score to avoid the division by zero.
+	minSuccessRate = 0.01
+)
+
+// Observation is the outcome of a single request served by a backend.
+type Observation struct {
+	// TimeToFirstToken is the time to the first token of the streaming response, or the time to the whole
+	// response otherwise.
+	TimeToFirstToken time.Duration
+	// InterTokenLatency is the latency between the output tokens of the streaming response. Zero otherwise.
+	InterTokenLatency time.Duration
+	// Failed reports whether the backend failed to serve the request.
+	Failed bool
+}
+
+// Balancer selects the backends of a single route rule.
+type Balancer struct {
+	config   *filterapi.AdaptiveLoadBalancer
+	backends []*backend
+	byName   map[string]*backend
+	now      func() time.Time
+	rand     func() float64
+
+	mu sync.Mutex
+}
+
+type backend struct {
+	name              string
+	weight            float64
+	price             float64
+	timeToFirstToken  ewma
+	interTokenLatency ewma
+	errorRate         ewma
+	consecutiveErrors int
+	ejections         int
+	ejectedUntil      time.Time
+}
+
+// New creates a new [Balancer] from the given configuration.
+func New(config *filterapi.AdaptiveLoadBalancer) *Balancer {
+	b := &Balancer{
+		config: config,
+		byName: make(map[string]*backend, len(config.Backends)),
+		now:    time.Now,
+		rand:   rand.Float64,
+	}
+	for _, cb := range config.Backends {
+		be := &backend{name: cb.Name, weight: float64(max(cb.Weight, 1)), price: math.Inf(1)}
+		if p := cb.Price; p != nil {
+			be.price = p.InputPerMillionTokens + p.OutputPerMillionTokens
+		}
+		b.backends = append(b.backends, be)
+		b.byName[cb.Name] = be
+	}
+	return b
+}
+
+// Name returns the name of the route rule.
+func (b *Balancer) Name() string { return b.config.Name }
+
+// Select returns the name of the selected backend.
+func (b *Balancer) Select() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+
+	// The backends without any observation are scored as well as the best one so that they are probed.
+	unknownLatency := math.Inf(1)
+	for _, be := range b.backends {
+		if be.timeToFirstToken.initialized {
+			unknownLatency = min(unknownLatency, be.latency())
+		}
+	}
+	if math.IsInf(unknownLatency, 1) {
+		unknownLatency = 1
+	}
+
+	// With the LowestCost strategy, only the cheapest backends are selected. The backends without the price are
+	// considered as the most expensive ones.
+	maxPrice := math.Inf(1)
+	if b.config.Strategy == filterapi.AdaptiveLoadBalancerStrategyLowestCost {
+		for _, be := range b.backends {
+			if !now.Before(be.ejectedUntil) && be.price < maxPrice {
+				maxPrice = be.price
+			}
+		}
+	}
+
+	weights := make([]float64, len(b.backends))
+	var total float64
+	for i, be := range b.backends {
+		if now.Before(be.ejectedUntil) || be.price > maxPrice {
+			continue
+		}
+		weights[i] = be.weight / be.score(unknownLatency)
+		total += weights[i]
+	}
+	if total == 0 {
+		// All the backends are ejected, so fall back to the static weights.
+		for i, be := range b.backends {
+			weights[i] = be.weight
+			total += weights[i]
+		}
+	}
+
+	r := b.rand() * total
+	for i, w := range weights {
+		if r < w {
+			return b.backends[i].name
+		}
+		r -= w
+	}
+	// Only reachable due to the floating point errors.
+	for i := len(weights) - 1; i >= 0; i-- {
+		if weights[i] > 0 {
+			return b.backends[i].name
+		}
+	}
+	return b.backends[len(b.backends)-1].name
+}
+
+// Observe records the outcome of a request served by the backend of the given name.
+func (b *Balancer) Observe(name string, o Observation) {
+	be, ok := b.byName[name]
+	if !ok {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+
+	if o.Failed {
+		be.errorRate.update(1, now, b.config.ErrorRateWindow)
+		be.consecutiveErrors++
+		if oe := b.config.OutlierEjection; oe != nil && be.consecutiveErrors >= oe.ConsecutiveErrors &&
+			!now.Before(be.ejectedUntil) && b.canEject(now) {
+			be.ejections++
+			be.ejectedUntil = now.Add(oe.BaseEjectionTime * time.Duration(be.ejections))
+			be.consecutiveErrors = 0
+		}
+		return
+	}
+	be.errorRate.update(0, now, b.config.ErrorRateWindow)
+	be.consecutiveErrors = 0
+	be.ejections = 0
+	be.timeToFirstToken.update(o.TimeToFirstToken.Seconds(), now, b.config.LatencyWindow)
+	if o.InterTokenLatency > 0 {
+		be.interTokenLatency.update(o.InterTokenLatency.Seconds(), now, b.config.LatencyWindow)
+	}
+}
+
+// canEject reports whether one more backend can be ejected within the maximum ejection percent.
+func (b *Balancer) canEject(now time.Time) bool {
+	ejected := 1
+	for _, be := range b.backends {
+		if now.Before(be.ejectedUntil) {
+			ejected++
+		}
+	}
+	return ejected*100 <= len(b.backends)*b.config.OutlierEjection.MaxEjectionPercent
+}
+
+// Scores calls the given function with the name and the current score of each backend. The lower the score is,
+// the more likely the backend is selected. The score of the backends without any observation is zero.
+func (b *Balancer) Scores(f func(name string, score float64)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, be := range b.backends {
+		if !be.timeToFirstToken.initialized && !be.errorRate.initialized {
+			f(be.name, 0)
+			continue
+		}
+		f(be.name, be.score(minLatency))
+	}
+}
+
+// latency returns the sum of the moving averages of the latencies in seconds.
+func (be *backend) latency() float64 {
+	return be.timeToFirstToken.value + be.interTokenLatency.value
+}
+
+// score returns the latency divided by the success rate, where the given latency is used when it is not observed yet.
+func (be *backend) score(unknownLatency float64) float64 {
+	latency := unknownLatency
+	if be.timeToFirstToken.initialized {
+		latency = be.latency()
+	}
+	return max(latency, minLatency) / max(1-be.errorRate.value, minSuccessRate)
+}
+
+// ewma is the exponentially weighted moving average decayed by the elapsed time between the samples.
+type ewma struct {
+	value       float64
+	last        time.Time
+	initialized bool
+}
+
+// update adds the sample at the given time. The weight of the previous average halves every halfLife.
+func (e *ewma) update(sample float64, now time.Time, halfLife time.Duration) {
+	if !e.initialized || halfLife <= 0 {
+		e.value, e.last, e.initialized = sample, now, true
+		return
+	}
+	elapsed := max(now.Sub(e.last), 0)
+	alpha := 1 - math.Exp2(-float64(elapsed)/float64(halfLife))
+	e.value += alpha * (sample - e.value)
+	e.last = now
+}
+
+// Set is the set of the balancers of the route rules.
+type Set struct {
+	balancers []*Balancer
+	matches   filterapi.RouteRuleMatcher[*Balancer]
+	byBackend map[string]*Balancer
+}
+
+// NewSet creates a new [Set] from the given configurations. The balancers of the previous set whose configuration
+// is not changed are reused so that the observations are kept across the configuration updates.
+func NewSet(configs []filterapi.AdaptiveLoadBalancer, prev *Set) *Set {
+	s := &Set{byBackend: make(map[string]*Balancer)}
+	for i := range configs {
+		c := &configs[i]
+		var b *Balancer
+		if prev != nil {
+			for _, pb := range prev.balancers {
+				if reflect.DeepEqual(pb.config, c) {
+					b = pb
+					break
+				}
+			}
+		}
+		if b == nil {
+			b = New(c)
+		}
+		s.balancers = append(s.balancers, b)
+		for _, m := range c.Matches {
+			s.matches.Add(m, b)
+		}
+		for _, be := range c.Backends {
+			s.byBackend[be.Name] = b
+		}
+	}
+	return s
+}
+
+// Find returns the balancer of the route rule matching the given model and the request headers, or nil if there's
+// none or the set is nil. When multiple route rules match, the one matching the most headers is returned in the same
+// way as the precedence of the HTTPRoute rules.
+func (s *Set) Find(model string, headers map[string]string) *Balancer {
+	if s == nil {
+		return nil
+	}
+	return s.matches.Find(model, headers)
+}
+
+// Observe records the outcome of a request served by the backend of the given name. This is no-op if the backend
+// doesn't belong to any balancer.
+func (s *Set) Observe(backend string, o Observation) {
+	if b, ok := s.byBackend[backend]; ok {
+		b.Observe(backend, o)
+	}
+}
+
+// Scores calls the given function with the name of the route rule, the name and the current score of each backend.
+func (s *Set) Scores(f func(rule, backend string, score float64)) {
+	for _, b := range s.balancers {
+		b.Scores(func(name string, score float64) { f(b.Name(), name, score) })
+	}
+}
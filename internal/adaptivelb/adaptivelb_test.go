@@ -0,0 +1,211 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package adaptivelb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func newTestBalancer(t *testing.T, config *filterapi.AdaptiveLoadBalancer) (*Balancer, *time.Time) {
+	t.Helper()
+	b := New(config)
+	now := time.Unix(1000, 0)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func scores(b *Balancer) map[string]float64 {
+	ret := make(map[string]float64)
+	b.Scores(func(name string, score float64) { ret[name] = score })
+	return ret
+}
+
+func TestBalancer_Select(t *testing.T) {
+	b, _ := newTestBalancer(t, &filterapi.AdaptiveLoadBalancer{
+		Name:          "ns/route/rule/0",
+		Backends:      []filterapi.AdaptiveLoadBalancerBackend{{Name: "a", Weight: 1}, {Name: "b", Weight: 1}},
+		LatencyWindow: time.Second,
+	})
+	// Without any observation, the static weights are used.
+	b.rand = func() float64 { return 0.49 }
+	require.Equal(t, "a", b.Select())
+	b.rand = func() float64 { return 0.51 }
+	require.Equal(t, "b", b.Select())
+
+	// "b" is three times slower than "a", so it receives a quarter of the traffic.
+	b.Observe("a", Observation{TimeToFirstToken: 100 * time.Millisecond, InterTokenLatency: 100 * time.Millisecond})
+	b.Observe("b", Observation{TimeToFirstToken: 500 * time.Millisecond, InterTokenLatency: 100 * time.Millisecond})
+	b.rand = func() float64 { return 0.74 }
+	require.Equal(t, "a", b.Select())
+	b.rand = func() float64 { return 0.76 }
+	require.Equal(t, "b", b.Select())
+}
+
+func TestBalancer_Select_unobserved(t *testing.T) {
+	b, _ := newTestBalancer(t, &filterapi.AdaptiveLoadBalancer{
+		Backends: []filterapi.AdaptiveLoadBalancerBackend{{Name: "a"}, {Name: "b"}},
+	})
+	// The backend without any observation is scored as well as the best one.
+	b.Observe("a", Observation{TimeToFirstToken: time.Second})
+	b.rand = func() float64 { return 0.49 }
+	require.Equal(t, "a", b.Select())
+	b.rand = func() float64 { return 0.51 }
+	require.Equal(t, "b", b.Select())
+	require.Equal(t, map[string]float64{"a": 1, "b": 0}, scores(b))
+}
+
+func TestBalancer_Observe_errorRate(t *testing.T) {
+	b, now := newTestBalancer(t, &filterapi.AdaptiveLoadBalancer{
+		Backends:        []filterapi.AdaptiveLoadBalancerBackend{{Name: "a"}},
+		LatencyWindow:   time.Second,
+		ErrorRateWindow: time.Second,
+	})
+	b.Observe("a", Observation{TimeToFirstToken: time.Second})
+	*now = now.Add(time.Second)
+	b.Observe("a", Observation{Failed: true})
+	// The error rate is 0.5 after a half-life, so the score doubles.
+	require.InDelta(t, 2.0, scores(b)["a"], 1e-9)
+
+	// Unknown backends are ignored.
+	b.Observe("unknown", Observation{Failed: true})
+	require.Len(t, scores(b), 1)
+}
+
+func TestBalancer_outlierEjection(t *testing.T) {
+	b, now := newTestBalancer(t, &filterapi.AdaptiveLoadBalancer{
+		Backends: []filterapi.AdaptiveLoadBalancerBackend{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+		OutlierEjection: &filterapi.OutlierEjection{
+			ConsecutiveErrors:  2,
+			BaseEjectionTime:   10 * time.Second,
+			MaxEjectionPercent: 50,
+		},
+	})
+	b.rand = func() float64 { return 0 }
+
+	b.Observe("a", Observation{Failed: true})
+	require.Equal(t, "a", b.Select())
+	b.Observe("a", Observation{Failed: true})
+	require.Equal(t, "b", b.Select())
+
+	// Ejecting "b" as well would exceed the maximum ejection percent.
+	b.Observe("b", Observation{Failed: true})
+	b.Observe("b", Observation{Failed: true})
+	require.Equal(t, "b", b.Select())
+
+	// "a" is back after the ejection time, and the next ejection lasts twice as long.
+	*now = now.Add(10 * time.Second)
+	require.Equal(t, "a", b.Select())
+	b.Observe("a", Observation{Failed: true})
+	b.Observe("a", Observation{Failed: true})
+	*now = now.Add(19 * time.Second)
+	require.Equal(t, "b", b.Select())
+	*now = now.Add(time.Second)
+	require.Equal(t, "a", b.Select())
+}
+
+func TestBalancer_Select_allEjected(t *testing.T) {
+	b, _ := newTestBalancer(t, &filterapi.AdaptiveLoadBalancer{
+		Backends: []filterapi.AdaptiveLoadBalancerBackend{{Name: "a", Weight: 1}, {Name: "b", Weight: 3}},
+		OutlierEjection: &filterapi.OutlierEjection{
+			ConsecutiveErrors:  1,
+			BaseEjectionTime:   time.Minute,
+			MaxEjectionPercent: 100,
+		},
+	})
+	b.Observe("a", Observation{Failed: true})
+	b.Observe("b", Observation{Failed: true})
+	// The static weights are used when all the backends are ejected.
+	b.rand = func() float64 { return 0.24 }
+	require.Equal(t, "a", b.Select())
+	b.rand = func() float64 { return 0.26 }
+	require.Equal(t, "b", b.Select())
+}
+
+func TestEWMA(t *testing.T) {
+	var e ewma
+	now := time.Unix(0, 0)
+	e.update(1, now, time.Second)
+	require.Equal(t, 1.0, e.value)
+	e.update(0, now.Add(time.Second), time.Second)
+	require.InDelta(t, 0.5, e.value, 1e-9)
+	e.update(0, now.Add(3*time.Second), time.Second)
+	require.InDelta(t, 0.125, e.value, 1e-9)
+	// Without the window, the average is the last sample.
+	e.update(1, now.Add(4*time.Second), 0)
+	require.Equal(t, 1.0, e.value)
+}
+
+func TestBalancer_Select_lowestCost(t *testing.T) {
+	b, _ := newTestBalancer(t, &filterapi.AdaptiveLoadBalancer{
+		Strategy: filterapi.AdaptiveLoadBalancerStrategyLowestCost,
+		Backends: []filterapi.AdaptiveLoadBalancerBackend{
+			{Name: "expensive", Price: &filterapi.ModelPrice{InputPerMillionTokens: 2.5, OutputPerMillionTokens: 10}},
+			{Name: "unknown"},
+			{Name: "cheap-a", Price: &filterapi.ModelPrice{InputPerMillionTokens: 0.5, OutputPerMillionTokens: 1.5}},
+			{Name: "cheap-b", Price: &filterapi.ModelPrice{InputPerMillionTokens: 1, OutputPerMillionTokens: 1}},
+		},
+		OutlierEjection: &filterapi.OutlierEjection{ConsecutiveErrors: 1, BaseEjectionTime: time.Minute, MaxEjectionPercent: 50},
+	})
+	// The cheapest backends share the traffic.
+	b.rand = func() float64 { return 0 }
+	require.Equal(t, "cheap-a", b.Select())
+	b.rand = func() float64 { return 0.99 }
+	require.Equal(t, "cheap-b", b.Select())
+
+	// The next cheapest one is selected when the cheapest ones are ejected.
+	b.Observe("cheap-a", Observation{Failed: true})
+	b.Observe("cheap-b", Observation{Failed: true})
+	b.rand = func() float64 { return 0 }
+	require.Equal(t, "expensive", b.Select())
+	b.rand = func() float64 { return 0.99 }
+	require.Equal(t, "expensive", b.Select())
+}
+
+func TestSet(t *testing.T) {
+	configs := []filterapi.AdaptiveLoadBalancer{
+		{Name: "r0", Matches: []filterapi.RouteRuleMatch{{Model: "m0"}}, Backends: []filterapi.AdaptiveLoadBalancerBackend{{Name: "a"}}},
+		{Name: "r1", Matches: []filterapi.RouteRuleMatch{{Model: "m1"}}, Backends: []filterapi.AdaptiveLoadBalancerBackend{{Name: "b"}}},
+	}
+	s := NewSet(configs, nil)
+	require.Equal(t, "r0", s.Find("m0", nil).Name())
+	require.Equal(t, "r1", s.Find("m1", nil).Name())
+	require.Nil(t, s.Find("unknown", nil))
+	require.Nil(t, (*Set)(nil).Find("m0", nil))
+
+	s.Observe("a", Observation{TimeToFirstToken: 2 * time.Second})
+	s.Observe("unknown", Observation{Failed: true})
+	got := make(map[string]float64)
+	s.Scores(func(rule, backend string, score float64) { got[rule+"/"+backend] = score })
+	require.Equal(t, map[string]float64{"r0/a": 2, "r1/b": 0}, got)
+
+	// The unchanged balancer keeps the state while the changed one is reset.
+	s2 := NewSet([]filterapi.AdaptiveLoadBalancer{
+		{Name: "r0", Matches: []filterapi.RouteRuleMatch{{Model: "m0"}}, Backends: []filterapi.AdaptiveLoadBalancerBackend{{Name: "a"}}},
+		{Name: "r1", Matches: []filterapi.RouteRuleMatch{{Model: "m1"}, {Model: "m2"}}, Backends: []filterapi.AdaptiveLoadBalancerBackend{{Name: "b"}}},
+	}, s)
+	require.Same(t, s.Find("m0", nil), s2.Find("m0", nil))
+	require.NotSame(t, s.Find("m1", nil), s2.Find("m1", nil))
+}
+
+func TestSet_Find_headers(t *testing.T) {
+	s := NewSet([]filterapi.AdaptiveLoadBalancer{
+		{Name: "default", Matches: []filterapi.RouteRuleMatch{{Model: "m"}}},
+		{Name: "cheap", Matches: []filterapi.RouteRuleMatch{{Model: "m", Headers: map[string]string{"x-tier": "cheap"}}}},
+		{Name: "cheap-eu", Matches: []filterapi.RouteRuleMatch{
+			{Model: "m", Headers: map[string]string{"x-tier": "cheap", "x-region": "eu"}},
+		}},
+	}, nil)
+	require.Equal(t, "default", s.Find("m", map[string]string{}).Name())
+	require.Equal(t, "default", s.Find("m", map[string]string{"x-tier": "premium"}).Name())
+	require.Equal(t, "cheap", s.Find("m", map[string]string{"x-tier": "cheap"}).Name())
+	require.Equal(t, "cheap", s.Find("m", map[string]string{"x-tier": "cheap", "x-region": "us"}).Name())
+	require.Equal(t, "cheap-eu", s.Find("m", map[string]string{"x-tier": "cheap", "x-region": "eu"}).Name())
+}
@@ -0,0 +1,283 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package admission provides the admission controller of the AI Gateway filter, which sheds the requests of the lower
+// priority classes before the higher ones when the backends are getting saturated or throttled by the providers.
+//
+// The utilization of a backend is the larger of the ratios of the in-flight requests and the tokens consumed in the
+// last minute to the limits of the backend. Each class has the utilization above which its requests are shed, so the
+// lower classes are shed first as the utilization grows. While the backend is throttled by the provider, it is
+// regarded as fully utilized, so only the classes which are never shed are admitted.
+package admission
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+const (
+	// defaultThrottledCooldown is the default of filterapi.AdmissionControl.ThrottledCooldown.
+	defaultThrottledCooldown = 10 * time.Second
+	// inFlightRetryAfter is the Retry-After of the requests shed by the in-flight requests, which complete quickly
+	// compared to the token window.
+	inFlightRetryAfter = time.Second
+	// tokenWindowSeconds is the length of the sliding window of the tokens per minute in seconds.
+	tokenWindowSeconds = 60
+)
+
+// Class is the priority class of a request.
+type Class struct {
+	// Name is the name of the class.
+	Name string
+	// shedAt is the utilization above which the requests of this class are shed.
+	shedAt float64
+}
+
+// Controller is the admission controller of the backends. This is safe for concurrent use.
+type Controller struct {
+	classes         []filterapi.PriorityClass
+	shedAts         []float64
+	defaultClass    int
+	clientKeyHeader string
+	clientKeys      map[string]int
+	cooldown        time.Duration
+	backends        map[string]*backend
+	now             func() time.Time
+}
+
+// backend tracks the usage of a backend.
+type backend struct {
+	limits filterapi.AdmissionBackend
+
+	mu       sync.Mutex
+	inFlight int
+	// tokens is the ring of the tokens consumed per second in the last minute, and seconds is the unix time of
+	// each bucket.
+	tokens  [tokenWindowSeconds]int64
+	seconds [tokenWindowSeconds]int64
+	// throttledUntil is until when the backend is regarded as throttled by the provider.
+	throttledUntil time.Time
+}
+
+// New creates a new Controller from the given configuration. This returns nil when the configuration is nil.
+//
+// The usage of the backends of prev is carried over when their limits are unchanged, so that reloading the
+// configuration doesn't forget the in-flight requests.
+func New(config *filterapi.AdmissionControl, prev *Controller) (*Controller, error) {
+	if config == nil {
+		return nil, nil
+	}
+	if len(config.Classes) == 0 {
+		return nil, errors.New("admission control requires at least one priority class")
+	}
+	c := &Controller{
+		classes:         config.Classes,
+		shedAts:         make([]float64, len(config.Classes)),
+		defaultClass:    len(config.Classes) - 1,
+		clientKeyHeader: config.ClientKeyHeader,
+		clientKeys:      make(map[string]int),
+		cooldown:        config.ThrottledCooldown,
+		backends:        make(map[string]*backend, len(config.Backends)),
+		now:             time.Now,
+	}
+	if c.cooldown <= 0 {
+		c.cooldown = defaultThrottledCooldown
+	}
+	defaultFound := config.DefaultClass == ""
+	for i := range config.Classes {
+		class := &config.Classes[i]
+		switch {
+		case class.ShedAt == 0:
+			c.shedAts[i] = float64(len(config.Classes)-i) / float64(len(config.Classes))
+		case class.ShedAt < 0 || class.ShedAt > 1:
+			return nil, fmt.Errorf("shedAt of the priority class %s must be in the range of (0, 1]: %v", class.Name, class.ShedAt)
+		default:
+			c.shedAts[i] = class.ShedAt
+		}
+		if class.Name == config.DefaultClass {
+			c.defaultClass, defaultFound = i, true
+		}
+		for _, key := range class.ClientKeys {
+			if _, ok := c.clientKeys[key]; !ok {
+				c.clientKeys[key] = i
+			}
+		}
+	}
+	if !defaultFound {
+		return nil, fmt.Errorf("default priority class %s is not found", config.DefaultClass)
+	}
+	for _, limits := range config.Backends {
+		if prev != nil {
+			if b, ok := prev.backends[limits.Name]; ok && b.limits == limits {
+				c.backends[limits.Name] = b
+				continue
+			}
+		}
+		c.backends[limits.Name] = &backend{limits: limits}
+	}
+	return c, nil
+}
+
+// Classify returns the priority class of the request with the given headers. The request belongs to the first class
+// whose headers all match, or which has the client key of the request. Otherwise, it belongs to the default class.
+func (c *Controller) Classify(headers map[string]string) Class {
+	if c == nil {
+		return Class{}
+	}
+	matched := -1
+	if key := headers[c.clientKeyHeader]; c.clientKeyHeader != "" && key != "" {
+		if i, ok := c.clientKeys[key]; ok {
+			matched = i
+		}
+	}
+	for i := range c.classes {
+		if matched >= 0 && i >= matched {
+			break
+		}
+		if headersMatch(c.classes[i].Headers, headers) {
+			matched = i
+			break
+		}
+	}
+	if matched < 0 {
+		matched = c.defaultClass
+	}
+	return Class{Name: c.classes[matched].Name, shedAt: c.shedAts[matched]}
+}
+
+// headersMatch returns true if all the expected headers are in the given headers. An empty expectation matches none.
+func headersMatch(expected, headers map[string]string) bool {
+	if len(expected) == 0 {
+		return false
+	}
+	for k, v := range expected {
+		if headers[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Admit decides whether the request of the given class is admitted on the backend. When admitted, the returned
+// ticket must be released once the request completes. Otherwise, the returned duration is the time after which the
+// client should retry.
+//
+// The requests on the backends without the limits are always admitted with a nil ticket.
+func (c *Controller) Admit(backendName string, class Class) (ticket *Ticket, retryAfter time.Duration, admitted bool) {
+	if c == nil {
+		return nil, 0, true
+	}
+	b, ok := c.backends[backendName]
+	if !ok {
+		return nil, 0, true
+	}
+	now := c.now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if now.Before(b.throttledUntil) && class.shedAt < 1 {
+		return nil, roundUpSeconds(b.throttledUntil.Sub(now)), false
+	}
+	if limit := b.limits.MaxInFlight; limit > 0 && float64(b.inFlight+1) > class.shedAt*float64(limit) {
+		return nil, inFlightRetryAfter, false
+	}
+	if limit := b.limits.TokensPerMinute; limit > 0 {
+		threshold := int64(class.shedAt * float64(limit))
+		if used := b.usedTokens(now); used >= threshold {
+			return nil, b.tokensRetryAfter(now, used-threshold), false
+		}
+	}
+	b.inFlight++
+	return &Ticket{c: c, b: b}, 0, true
+}
+
+// Throttled marks the given backend as throttled by the provider for the cooldown.
+func (c *Controller) Throttled(backendName string) {
+	if c == nil {
+		return
+	}
+	b, ok := c.backends[backendName]
+	if !ok {
+		return
+	}
+	until := c.now().Add(c.cooldown)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if until.After(b.throttledUntil) {
+		b.throttledUntil = until
+	}
+}
+
+// usedTokens returns the tokens consumed in the last minute. b.mu must be held.
+func (b *backend) usedTokens(now time.Time) int64 {
+	sec := now.Unix()
+	var used int64
+	for i := range b.tokens {
+		if sec-b.seconds[i] < tokenWindowSeconds {
+			used += b.tokens[i]
+		}
+	}
+	return used
+}
+
+// tokensRetryAfter returns the time until the given excess of the tokens leaves the window. b.mu must be held.
+func (b *backend) tokensRetryAfter(now time.Time, excess int64) time.Duration {
+	sec := now.Unix()
+	for age := int64(tokenWindowSeconds - 1); age >= 0; age-- {
+		i := (sec - age) % tokenWindowSeconds
+		if b.seconds[i] != sec-age {
+			continue
+		}
+		if excess -= b.tokens[i]; excess < 0 {
+			return time.Duration(tokenWindowSeconds-age) * time.Second
+		}
+	}
+	return tokenWindowSeconds * time.Second
+}
+
+// recordTokens adds the given tokens to the window. b.mu must be held.
+func (b *backend) recordTokens(now time.Time, tokens int64) {
+	sec := now.Unix()
+	i := sec % tokenWindowSeconds
+	if b.seconds[i] != sec {
+		b.seconds[i], b.tokens[i] = sec, 0
+	}
+	b.tokens[i] += tokens
+}
+
+// roundUpSeconds rounds the given duration up to the seconds since Retry-After is in seconds.
+func roundUpSeconds(d time.Duration) time.Duration {
+	if r := d.Truncate(time.Second); r < d {
+		return r + time.Second
+	}
+	return d
+}
+
+// Ticket is the admission of a request on a backend.
+type Ticket struct {
+	c    *Controller
+	b    *backend
+	once sync.Once
+}
+
+// Release releases the in-flight request, and records the given tokens consumed by it. This is safe to call more
+// than once, in which case the later calls are ignored. This is nil-safe.
+func (t *Ticket) Release(tokens int64) {
+	if t == nil {
+		return
+	}
+	t.once.Do(func() {
+		now := t.c.now()
+		t.b.mu.Lock()
+		defer t.b.mu.Unlock()
+		t.b.inFlight--
+		if tokens > 0 {
+			t.b.recordTokens(now, tokens)
+		}
+	})
+}
@@ -0,0 +1,200 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package admission
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestNew(t *testing.T) {
+	c, err := New(nil, nil)
+	require.NoError(t, err)
+	require.Nil(t, c)
+
+	for _, tc := range []struct {
+		name   string
+		config *filterapi.AdmissionControl
+		expErr string
+	}{
+		{name: "no classes", config: &filterapi.AdmissionControl{}, expErr: "admission control requires at least one priority class"},
+		{
+			name:   "invalid shedAt",
+			config: &filterapi.AdmissionControl{Classes: []filterapi.PriorityClass{{Name: "paid", ShedAt: 1.5}}},
+			expErr: "shedAt of the priority class paid must be in the range of (0, 1]: 1.5",
+		},
+		{
+			name:   "unknown default class",
+			config: &filterapi.AdmissionControl{Classes: []filterapi.PriorityClass{{Name: "paid"}}, DefaultClass: "free"},
+			expErr: "default priority class free is not found",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New(tc.config, nil)
+			require.EqualError(t, err, tc.expErr)
+		})
+	}
+}
+
+func TestController_Classify(t *testing.T) {
+	c, err := New(&filterapi.AdmissionControl{
+		Classes: []filterapi.PriorityClass{
+			{Name: "enterprise", ClientKeys: []string{"key-e"}},
+			{Name: "paid", Headers: map[string]string{"x-tier": "paid"}, ClientKeys: []string{"key-p"}},
+			{Name: "standard"},
+			{Name: "free", Headers: map[string]string{"x-tier": "free"}},
+		},
+		DefaultClass:    "standard",
+		ClientKeyHeader: "x-client-key",
+	}, nil)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		headers  map[string]string
+		expClass string
+	}{
+		{headers: map[string]string{}, expClass: "standard"},
+		{headers: map[string]string{"x-tier": "paid"}, expClass: "paid"},
+		{headers: map[string]string{"x-tier": "free"}, expClass: "free"},
+		{headers: map[string]string{"x-client-key": "key-e", "x-tier": "paid"}, expClass: "enterprise"},
+		// The higher class matched by the headers wins over the client key.
+		{headers: map[string]string{"x-client-key": "key-p", "x-tier": "free"}, expClass: "paid"},
+		{headers: map[string]string{"x-client-key": "unknown"}, expClass: "standard"},
+	} {
+		require.Equal(t, tc.expClass, c.Classify(tc.headers).Name, tc.headers)
+	}
+
+	var nilController *Controller
+	require.Empty(t, nilController.Classify(map[string]string{}).Name)
+}
+
+func TestController_Admit(t *testing.T) {
+	newController := func(t *testing.T, backend filterapi.AdmissionBackend) (*Controller, *time.Time) {
+		c, err := New(&filterapi.AdmissionControl{
+			Classes: []filterapi.PriorityClass{
+				{Name: "paid", Headers: map[string]string{"x-tier": "paid"}},
+				{Name: "free"},
+			},
+			Backends:          []filterapi.AdmissionBackend{backend},
+			ThrottledCooldown: 5 * time.Second,
+		}, nil)
+		require.NoError(t, err)
+		now := time.Unix(1000, 0)
+		c.now = func() time.Time { return now }
+		return c, &now
+	}
+	paid := Class{Name: "paid", shedAt: 1}
+	free := Class{Name: "free", shedAt: 0.5}
+
+	t.Run("unlimited backend", func(t *testing.T) {
+		c, _ := newController(t, filterapi.AdmissionBackend{Name: "a", MaxInFlight: 1})
+		ticket, _, admitted := c.Admit("unknown", free)
+		require.True(t, admitted)
+		require.Nil(t, ticket)
+		ticket.Release(10)
+
+		var nilController *Controller
+		_, _, admitted = nilController.Admit("a", free)
+		require.True(t, admitted)
+	})
+	t.Run("in-flight", func(t *testing.T) {
+		c, _ := newController(t, filterapi.AdmissionBackend{Name: "a", MaxInFlight: 4})
+		require.Equal(t, 1.0, c.Classify(map[string]string{"x-tier": "paid"}).shedAt)
+		require.Equal(t, 0.5, c.Classify(map[string]string{}).shedAt)
+
+		var tickets []*Ticket
+		for range 2 {
+			ticket, _, admitted := c.Admit("a", free)
+			require.True(t, admitted)
+			tickets = append(tickets, ticket)
+		}
+		// The free class is shed at the half of the limit, while the paid class is admitted up to the limit.
+		_, retryAfter, admitted := c.Admit("a", free)
+		require.False(t, admitted)
+		require.Equal(t, time.Second, retryAfter)
+		for range 2 {
+			ticket, _, admitted := c.Admit("a", paid)
+			require.True(t, admitted)
+			tickets = append(tickets, ticket)
+		}
+		_, _, admitted = c.Admit("a", paid)
+		require.False(t, admitted)
+
+		// Releasing the same ticket twice only frees one slot.
+		tickets[0].Release(0)
+		tickets[0].Release(0)
+		_, _, admitted = c.Admit("a", free)
+		require.False(t, admitted)
+		_, _, admitted = c.Admit("a", paid)
+		require.True(t, admitted)
+	})
+	t.Run("tokens per minute", func(t *testing.T) {
+		c, now := newController(t, filterapi.AdmissionBackend{Name: "a", TokensPerMinute: 1000})
+		ticket, _, admitted := c.Admit("a", free)
+		require.True(t, admitted)
+		ticket.Release(300)
+		*now = now.Add(10 * time.Second)
+		ticket, _, admitted = c.Admit("a", free)
+		require.True(t, admitted)
+		ticket.Release(300)
+
+		// The free class is shed once the half of the tokens are consumed, until the first 300 tokens leave the window.
+		_, retryAfter, admitted := c.Admit("a", free)
+		require.False(t, admitted)
+		require.Equal(t, 50*time.Second, retryAfter)
+		ticket, _, admitted = c.Admit("a", paid)
+		require.True(t, admitted)
+		ticket.Release(0)
+
+		*now = now.Add(50 * time.Second)
+		_, _, admitted = c.Admit("a", free)
+		require.True(t, admitted)
+	})
+	t.Run("throttled", func(t *testing.T) {
+		c, now := newController(t, filterapi.AdmissionBackend{Name: "a", MaxInFlight: 100})
+		c.Throttled("a")
+		c.Throttled("unknown")
+		*now = now.Add(1500 * time.Millisecond)
+		_, retryAfter, admitted := c.Admit("a", free)
+		require.False(t, admitted)
+		require.Equal(t, 4*time.Second, retryAfter)
+		_, _, admitted = c.Admit("a", paid)
+		require.True(t, admitted)
+
+		*now = now.Add(4 * time.Second)
+		_, _, admitted = c.Admit("a", free)
+		require.True(t, admitted)
+	})
+}
+
+func TestNew_reusesBackends(t *testing.T) {
+	config := &filterapi.AdmissionControl{
+		Classes:  []filterapi.PriorityClass{{Name: "paid"}},
+		Backends: []filterapi.AdmissionBackend{{Name: "a", MaxInFlight: 1}, {Name: "b", MaxInFlight: 1}},
+	}
+	prev, err := New(config, nil)
+	require.NoError(t, err)
+	class := prev.Classify(nil)
+	_, _, admitted := prev.Admit("a", class)
+	require.True(t, admitted)
+	_, _, admitted = prev.Admit("b", class)
+	require.True(t, admitted)
+
+	// The in-flight request on the unchanged backend is carried over, while the changed one starts over.
+	c, err := New(&filterapi.AdmissionControl{
+		Classes:  []filterapi.PriorityClass{{Name: "paid"}},
+		Backends: []filterapi.AdmissionBackend{{Name: "a", MaxInFlight: 1}, {Name: "b", MaxInFlight: 2}},
+	}, prev)
+	require.NoError(t, err)
+	_, _, admitted = c.Admit("a", class)
+	require.False(t, admitted)
+	_, _, admitted = c.Admit("b", class)
+	require.True(t, admitted)
+}
@@ -0,0 +1,401 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package anthropic contains the types of the Anthropic Messages API that are accepted by the gateway as the input.
+//
+// https://docs.anthropic.com/en/api/messages
+package anthropic
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+const (
+	// RoleUser is the role of the user message.
+	RoleUser = "user"
+	// RoleAssistant is the role of the assistant message.
+	RoleAssistant = "assistant"
+
+	// ContentBlockTypeText is the type of the text content block.
+	ContentBlockTypeText = "text"
+	// ContentBlockTypeImage is the type of the image content block.
+	ContentBlockTypeImage = "image"
+	// ContentBlockTypeDocument is the type of the document content block.
+	ContentBlockTypeDocument = "document"
+	// ContentBlockTypeToolUse is the type of the tool use content block.
+	ContentBlockTypeToolUse = "tool_use"
+	// ContentBlockTypeToolResult is the type of the tool result content block.
+	ContentBlockTypeToolResult = "tool_result"
+	// ContentBlockTypeThinking is the type of the thinking content block.
+	ContentBlockTypeThinking = "thinking"
+	// ContentBlockTypeRedactedThinking is the type of the redacted thinking content block.
+	ContentBlockTypeRedactedThinking = "redacted_thinking"
+
+	// SourceTypeBase64 is the type of the base64 encoded image or document source.
+	SourceTypeBase64 = "base64"
+	// SourceTypeURL is the type of the URL image or document source.
+	SourceTypeURL = "url"
+
+	// ToolChoiceTypeAuto lets the model decide whether to use tools.
+	ToolChoiceTypeAuto = "auto"
+	// ToolChoiceTypeAny forces the model to use one of the tools.
+	ToolChoiceTypeAny = "any"
+	// ToolChoiceTypeTool forces the model to use the specified tool.
+	ToolChoiceTypeTool = "tool"
+	// ToolChoiceTypeNone prevents the model from using tools.
+	ToolChoiceTypeNone = "none"
+
+	// StopReasonEndTurn means the model reached a natural stopping point.
+	StopReasonEndTurn = "end_turn"
+	// StopReasonMaxTokens means the model exceeded the requested max_tokens.
+	StopReasonMaxTokens = "max_tokens"
+	// StopReasonStopSequence means one of the custom stop sequences was generated.
+	StopReasonStopSequence = "stop_sequence"
+	// StopReasonToolUse means the model invoked one or more tools.
+	StopReasonToolUse = "tool_use"
+	// StopReasonRefusal means the model refused to respond.
+	StopReasonRefusal = "refusal"
+
+	// StreamEventTypeMessageStart is the type of the first event in the stream.
+	StreamEventTypeMessageStart = "message_start"
+	// StreamEventTypeContentBlockStart is the type of the event that starts a content block.
+	StreamEventTypeContentBlockStart = "content_block_start"
+	// StreamEventTypeContentBlockDelta is the type of the event that carries a delta of a content block.
+	StreamEventTypeContentBlockDelta = "content_block_delta"
+	// StreamEventTypeContentBlockStop is the type of the event that stops a content block.
+	StreamEventTypeContentBlockStop = "content_block_stop"
+	// StreamEventTypeMessageDelta is the type of the event that carries the top-level changes to the message.
+	StreamEventTypeMessageDelta = "message_delta"
+	// StreamEventTypeMessageStop is the type of the last event in the stream.
+	StreamEventTypeMessageStop = "message_stop"
+	// StreamEventTypePing is the type of the ping event.
+	StreamEventTypePing = "ping"
+	// StreamEventTypeError is the type of the error event.
+	StreamEventTypeError = "error"
+
+	// DeltaTypeText is the type of the text delta.
+	DeltaTypeText = "text_delta"
+	// DeltaTypeInputJSON is the type of the partial JSON delta of the tool use input.
+	DeltaTypeInputJSON = "input_json_delta"
+	// DeltaTypeThinking is the type of the thinking delta.
+	DeltaTypeThinking = "thinking_delta"
+	// DeltaTypeSignature is the type of the thinking signature delta.
+	DeltaTypeSignature = "signature_delta"
+
+	// ErrorTypeInvalidRequest is the error type for 400 status code.
+	ErrorTypeInvalidRequest = "invalid_request_error"
+	// ErrorTypeAuthentication is the error type for 401 status code.
+	ErrorTypeAuthentication = "authentication_error"
+	// ErrorTypePermission is the error type for 403 status code.
+	ErrorTypePermission = "permission_error"
+	// ErrorTypeNotFound is the error type for 404 status code.
+	ErrorTypeNotFound = "not_found_error"
+	// ErrorTypeRequestTooLarge is the error type for 413 status code.
+	ErrorTypeRequestTooLarge = "request_too_large"
+	// ErrorTypeRateLimit is the error type for 429 status code.
+	ErrorTypeRateLimit = "rate_limit_error"
+	// ErrorTypeAPI is the error type for 500 status code.
+	ErrorTypeAPI = "api_error"
+	// ErrorTypeOverloaded is the error type for 529 status code.
+	ErrorTypeOverloaded = "overloaded_error"
+)
+
+// MessagesRequest represents a request to the /v1/messages endpoint.
+//
+// https://docs.anthropic.com/en/api/messages
+type MessagesRequest struct {
+	// Model is the model that will complete the prompt.
+	Model string `json:"model"`
+	// Messages are the input messages alternating between the user and the assistant.
+	Messages []MessageParam `json:"messages"`
+	// MaxTokens is the maximum number of tokens to generate before stopping.
+	MaxTokens int64 `json:"max_tokens"`
+	// System is the system prompt either as a string or a list of text blocks.
+	System *SystemPrompt `json:"system,omitempty"`
+	// Metadata is an object describing metadata about the request.
+	Metadata *Metadata `json:"metadata,omitempty"`
+	// StopSequences are the custom text sequences that will cause the model to stop generating.
+	StopSequences []string `json:"stop_sequences,omitempty"`
+	// Stream indicates whether to incrementally stream the response using server-sent events.
+	Stream bool `json:"stream,omitempty"`
+	// Temperature is the amount of randomness injected into the response, ranging from 0.0 to 1.0.
+	Temperature *float64 `json:"temperature,omitempty"`
+	// TopK only samples from the top K options for each subsequent token.
+	TopK *int64 `json:"top_k,omitempty"`
+	// TopP is the nucleus sampling parameter.
+	TopP *float64 `json:"top_p,omitempty"`
+	// Tools are the definitions of the tools that the model may use.
+	Tools []Tool `json:"tools,omitempty"`
+	// ToolChoice specifies how the model should use the provided tools.
+	ToolChoice *ToolChoice `json:"tool_choice,omitempty"`
+	// Thinking is the configuration for enabling extended thinking. This is kept as raw JSON
+	// since it is only meaningful to the Anthropic backends.
+	Thinking json.RawMessage `json:"thinking,omitempty"`
+}
+
+// SystemPrompt is either a string or a list of text blocks.
+type SystemPrompt struct {
+	Text   *string
+	Blocks []ContentBlockParam
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (s *SystemPrompt) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		s.Text = &str
+		return nil
+	}
+	var blocks []ContentBlockParam
+	if err := json.Unmarshal(data, &blocks); err != nil {
+		return errors.New("system must be either a string or an array of text blocks")
+	}
+	s.Blocks = blocks
+	return nil
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (s SystemPrompt) MarshalJSON() ([]byte, error) {
+	if s.Text != nil {
+		return json.Marshal(*s.Text)
+	}
+	return json.Marshal(s.Blocks)
+}
+
+// Metadata is an object describing metadata about the request.
+type Metadata struct {
+	// UserID is an external identifier for the user who is associated with the request.
+	UserID string `json:"user_id,omitempty"`
+}
+
+// MessageParam is a single input message.
+type MessageParam struct {
+	// Role is either "user" or "assistant".
+	Role string `json:"role"`
+	// Content is either a string or a list of content blocks.
+	Content MessageContent `json:"content"`
+}
+
+// MessageContent is either a string or a list of content blocks.
+type MessageContent struct {
+	Text   *string
+	Blocks []ContentBlockParam
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (m *MessageContent) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		m.Text = &str
+		return nil
+	}
+	var blocks []ContentBlockParam
+	if err := json.Unmarshal(data, &blocks); err != nil {
+		return errors.New("content must be either a string or an array of content blocks")
+	}
+	m.Blocks = blocks
+	return nil
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (m MessageContent) MarshalJSON() ([]byte, error) {
+	if m.Text != nil {
+		return json.Marshal(*m.Text)
+	}
+	return json.Marshal(m.Blocks)
+}
+
+// ContentBlockParam is a single content block of the input message. The fields that are set depend on the Type.
+type ContentBlockParam struct {
+	// Type is the type of the content block such as "text", "image", "tool_use" or "tool_result".
+	Type string `json:"type"`
+	// Text is set when the Type is "text".
+	Text string `json:"text,omitempty"`
+	// Source is set when the Type is "image" or "document".
+	Source *Source `json:"source,omitempty"`
+	// ID is set when the Type is "tool_use".
+	ID string `json:"id,omitempty"`
+	// Name is set when the Type is "tool_use".
+	Name string `json:"name,omitempty"`
+	// Input is set when the Type is "tool_use".
+	Input json.RawMessage `json:"input,omitempty"`
+	// ToolUseID is set when the Type is "tool_result".
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	// Content is set when the Type is "tool_result".
+	Content *MessageContent `json:"content,omitempty"`
+	// IsError is set when the Type is "tool_result".
+	IsError *bool `json:"is_error,omitempty"`
+	// Thinking and Signature are set when the Type is "thinking".
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	// Data is set when the Type is "redacted_thinking".
+	Data string `json:"data,omitempty"`
+	// CacheControl is the prompt caching breakpoint of the block.
+	CacheControl json.RawMessage `json:"cache_control,omitempty"`
+}
+
+// Source is the source of the image or document content block.
+type Source struct {
+	// Type is either "base64" or "url".
+	Type string `json:"type"`
+	// MediaType is set when the Type is "base64".
+	MediaType string `json:"media_type,omitempty"`
+	// Data is the base64 encoded data when the Type is "base64".
+	Data string `json:"data,omitempty"`
+	// URL is set when the Type is "url".
+	URL string `json:"url,omitempty"`
+}
+
+// Tool is the definition of a tool that the model may use.
+type Tool struct {
+	// Type is empty or "custom" for the client tools. Otherwise, this is the type of the server tool.
+	Type string `json:"type,omitempty"`
+	// Name is the name of the tool.
+	Name string `json:"name"`
+	// Description is the description of what the tool does.
+	Description string `json:"description,omitempty"`
+	// InputSchema is the JSON schema of the tool input.
+	InputSchema map[string]any `json:"input_schema,omitempty"`
+	// CacheControl is the prompt caching breakpoint of the tool.
+	CacheControl json.RawMessage `json:"cache_control,omitempty"`
+}
+
+// ToolChoice specifies how the model should use the provided tools.
+type ToolChoice struct {
+	// Type is one of "auto", "any", "tool" or "none".
+	Type string `json:"type"`
+	// Name is the name of the tool to use when the Type is "tool".
+	Name string `json:"name,omitempty"`
+	// DisableParallelToolUse disables the parallel tool use when set to true.
+	DisableParallelToolUse *bool `json:"disable_parallel_tool_use,omitempty"`
+}
+
+// Message is the response of the /v1/messages endpoint.
+type Message struct {
+	// ID is the unique object identifier.
+	ID string `json:"id"`
+	// Type is always "message".
+	Type string `json:"type"`
+	// Role is always "assistant".
+	Role string `json:"role"`
+	// Content is the content generated by the model.
+	Content []ContentBlock `json:"content"`
+	// Model is the model that handled the request.
+	Model string `json:"model"`
+	// StopReason is the reason that the model stopped.
+	StopReason *string `json:"stop_reason"`
+	// StopSequence is the custom stop sequence that was generated, if any.
+	StopSequence *string `json:"stop_sequence"`
+	// Usage is the billing and rate-limit usage.
+	Usage Usage `json:"usage"`
+}
+
+// ContentBlock is a single content block of the response message.
+type ContentBlock struct {
+	// Type is one of "text", "tool_use", "thinking" or "redacted_thinking".
+	Type string `json:"type"`
+	// Text is set when the Type is "text". This is always present for the text block even when empty.
+	Text *string `json:"text,omitempty"`
+	// ID, Name and Input are set when the Type is "tool_use".
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+	// Thinking and Signature are set when the Type is "thinking".
+	Thinking  *string `json:"thinking,omitempty"`
+	Signature *string `json:"signature,omitempty"`
+}
+
+// Usage is the billing and rate-limit usage.
+type Usage struct {
+	// InputTokens is the number of input tokens which were used.
+	InputTokens int64 `json:"input_tokens"`
+	// OutputTokens is the number of output tokens which were used.
+	OutputTokens int64 `json:"output_tokens"`
+	// CacheCreationInputTokens is the number of input tokens used to create the cache entry.
+	CacheCreationInputTokens int64 `json:"cache_creation_input_tokens,omitempty"`
+	// CacheReadInputTokens is the number of input tokens read from the cache.
+	CacheReadInputTokens int64 `json:"cache_read_input_tokens,omitempty"`
+}
+
+// StreamEvent is a single server-sent event of the streaming response. The fields that are set depend on the Type.
+//
+// https://docs.anthropic.com/en/docs/build-with-claude/streaming
+type StreamEvent struct {
+	// Type is the type of the event, which is also used as the SSE event name.
+	Type string `json:"type"`
+	// Message is set when the Type is "message_start".
+	Message *Message `json:"message,omitempty"`
+	// Index is set when the Type is one of "content_block_start", "content_block_delta" and "content_block_stop".
+	Index *int `json:"index,omitempty"`
+	// ContentBlock is set when the Type is "content_block_start".
+	ContentBlock *ContentBlock `json:"content_block,omitempty"`
+	// Delta is set when the Type is "content_block_delta" or "message_delta".
+	Delta *StreamDelta `json:"delta,omitempty"`
+	// Usage is set when the Type is "message_delta".
+	Usage *Usage `json:"usage,omitempty"`
+	// Error is set when the Type is "error".
+	Error *ErrorDetail `json:"error,omitempty"`
+}
+
+// StreamDelta is the delta of the content block or the message.
+type StreamDelta struct {
+	// Type is set for the content block delta, which is one of "text_delta", "input_json_delta",
+	// "thinking_delta" and "signature_delta".
+	Type string `json:"type,omitempty"`
+	// Text is set when the Type is "text_delta".
+	Text string `json:"text,omitempty"`
+	// PartialJSON is set when the Type is "input_json_delta".
+	PartialJSON *string `json:"partial_json,omitempty"`
+	// Thinking is set when the Type is "thinking_delta".
+	Thinking string `json:"thinking,omitempty"`
+	// Signature is set when the Type is "signature_delta".
+	Signature string `json:"signature,omitempty"`
+	// StopReason is set for the message delta.
+	StopReason string `json:"stop_reason,omitempty"`
+	// StopSequence is set for the message delta.
+	StopSequence *string `json:"stop_sequence,omitempty"`
+}
+
+// ErrorResponse is the error response of the Anthropic API.
+type ErrorResponse struct {
+	// Type is always "error".
+	Type string `json:"type"`
+	// Error is the detail of the error.
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail is the detail of the error.
+type ErrorDetail struct {
+	// Type is the type of the error such as "invalid_request_error".
+	Type string `json:"type"`
+	// Message is the human-readable error message.
+	Message string `json:"message"`
+}
+
+// ErrorTypeFromStatusCode returns the Anthropic error type that corresponds to the HTTP status code.
+func ErrorTypeFromStatusCode(code int) string {
+	switch code {
+	case 400:
+		return ErrorTypeInvalidRequest
+	case 401:
+		return ErrorTypeAuthentication
+	case 403:
+		return ErrorTypePermission
+	case 404:
+		return ErrorTypeNotFound
+	case 413:
+		return ErrorTypeRequestTooLarge
+	case 429:
+		return ErrorTypeRateLimit
+	case 503, 529:
+		return ErrorTypeOverloaded
+	default:
+		if code >= 400 && code < 500 {
+			return ErrorTypeInvalidRequest
+		}
+		return ErrorTypeAPI
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package anthropic
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestMessagesRequest_Unmarshal(t *testing.T) {
+	t.Run("string contents", func(t *testing.T) {
+		const in = `{"model":"claude","max_tokens":10,"system":"be nice","messages":[{"role":"user","content":"hi"}]}`
+		var req MessagesRequest
+		require.NoError(t, json.Unmarshal([]byte(in), &req))
+		require.Equal(t, "claude", req.Model)
+		require.Equal(t, int64(10), req.MaxTokens)
+		require.Equal(t, &SystemPrompt{Text: ptr.To("be nice")}, req.System)
+		require.Equal(t, []MessageParam{{Role: RoleUser, Content: MessageContent{Text: ptr.To("hi")}}}, req.Messages)
+	})
+	t.Run("block contents", func(t *testing.T) {
+		const in = `{"model":"claude","max_tokens":10,
+"system":[{"type":"text","text":"be nice","cache_control":{"type":"ephemeral"}}],
+"messages":[
+  {"role":"user","content":[{"type":"text","text":"hi"},{"type":"image","source":{"type":"base64","media_type":"image/png","data":"AAA"}}]},
+  {"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"f","input":{"a":1}}]},
+  {"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"ok","is_error":false}]}
+],
+"tools":[{"name":"f","input_schema":{"type":"object"}}],
+"tool_choice":{"type":"tool","name":"f","disable_parallel_tool_use":true},
+"thinking":{"type":"enabled","budget_tokens":1024}}`
+		var req MessagesRequest
+		require.NoError(t, json.Unmarshal([]byte(in), &req))
+		require.Len(t, req.System.Blocks, 1)
+		require.JSONEq(t, `{"type":"ephemeral"}`, string(req.System.Blocks[0].CacheControl))
+		require.Len(t, req.Messages, 3)
+		require.Equal(t, &Source{Type: SourceTypeBase64, MediaType: "image/png", Data: "AAA"}, req.Messages[0].Content.Blocks[1].Source)
+		require.JSONEq(t, `{"a":1}`, string(req.Messages[1].Content.Blocks[0].Input))
+		toolResult := req.Messages[2].Content.Blocks[0]
+		require.Equal(t, "t1", toolResult.ToolUseID)
+		require.Equal(t, &MessageContent{Text: ptr.To("ok")}, toolResult.Content)
+		require.Equal(t, ptr.To(false), toolResult.IsError)
+		require.Equal(t, []Tool{{Name: "f", InputSchema: map[string]any{"type": "object"}}}, req.Tools)
+		require.Equal(t, &ToolChoice{Type: ToolChoiceTypeTool, Name: "f", DisableParallelToolUse: ptr.To(true)}, req.ToolChoice)
+		require.JSONEq(t, `{"type":"enabled","budget_tokens":1024}`, string(req.Thinking))
+
+		// Round trip.
+		out, err := json.Marshal(&req)
+		require.NoError(t, err)
+		require.JSONEq(t, in, string(out))
+	})
+	t.Run("invalid content", func(t *testing.T) {
+		var req MessagesRequest
+		err := json.Unmarshal([]byte(`{"messages":[{"role":"user","content":1}]}`), &req)
+		require.ErrorContains(t, err, "content must be either a string or an array of content blocks")
+		err = json.Unmarshal([]byte(`{"system":1}`), &req)
+		require.ErrorContains(t, err, "system must be either a string or an array of text blocks")
+	})
+}
+
+func TestMessage_Marshal(t *testing.T) {
+	msg := Message{
+		ID:   "msg_1",
+		Type: "message",
+		Role: RoleAssistant,
+		Content: []ContentBlock{
+			{Type: ContentBlockTypeText, Text: ptr.To("")},
+			{Type: ContentBlockTypeToolUse, ID: "t1", Name: "f", Input: json.RawMessage(`{}`)},
+		},
+		Model:      "claude",
+		StopReason: ptr.To(StopReasonToolUse),
+		Usage:      Usage{InputTokens: 1, OutputTokens: 2},
+	}
+	out, err := json.Marshal(msg)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":"msg_1","type":"message","role":"assistant","model":"claude",
+"content":[{"type":"text","text":""},{"type":"tool_use","id":"t1","name":"f","input":{}}],
+"stop_reason":"tool_use","stop_sequence":null,"usage":{"input_tokens":1,"output_tokens":2}}`, string(out))
+}
+
+func TestErrorTypeFromStatusCode(t *testing.T) {
+	for code, exp := range map[int]string{
+		400: ErrorTypeInvalidRequest,
+		401: ErrorTypeAuthentication,
+		403: ErrorTypePermission,
+		404: ErrorTypeNotFound,
+		413: ErrorTypeRequestTooLarge,
+		422: ErrorTypeInvalidRequest,
+		429: ErrorTypeRateLimit,
+		500: ErrorTypeAPI,
+		503: ErrorTypeOverloaded,
+		529: ErrorTypeOverloaded,
+	} {
+		require.Equal(t, exp, ErrorTypeFromStatusCode(code), "code %d", code)
+	}
+}
@@ -486,3 +486,92 @@ type ToolSpecification struct {
 	// Name is a required field.
 	Name *string `json:"name"`
 }
+
+// TitanEmbeddingRequest is the InvokeModel request body of the Amazon Titan text embeddings models.
+// https://docs.aws.amazon.com/bedrock/latest/userguide/model-parameters-titan-embed-text.html
+type TitanEmbeddingRequest struct {
+	// InputText is the text to convert to an embedding.
+	InputText string `json:"inputText"`
+	// Dimensions is the number of dimensions the output embedding should have.
+	// This is only supported by Titan Text Embeddings V2.
+	Dimensions *int `json:"dimensions,omitempty"`
+}
+
+// TitanEmbeddingResponse is the InvokeModel response body of the Amazon Titan text embeddings models.
+type TitanEmbeddingResponse struct {
+	// Embedding is the embedding vector of the input text.
+	Embedding []float64 `json:"embedding"`
+	// InputTextTokenCount is the number of tokens in the input.
+	InputTextTokenCount int `json:"inputTextTokenCount"`
+}
+
+// CohereEmbeddingRequest is the InvokeModel request body of the Cohere Embed models.
+// https://docs.aws.amazon.com/bedrock/latest/userguide/model-parameters-embed.html
+type CohereEmbeddingRequest struct {
+	// Texts is the array of strings for the model to embed.
+	Texts []string `json:"texts"`
+	// InputType prepends special tokens to differentiate each type from one another.
+	InputType string `json:"input_type"`
+	// Truncate specifies how the API handles inputs longer than the maximum token length.
+	Truncate *string `json:"truncate,omitempty"`
+}
+
+// CohereEmbeddingResponse is the InvokeModel response body of the Cohere Embed models.
+type CohereEmbeddingResponse struct {
+	// ID is the identifier for the response.
+	ID string `json:"id"`
+	// Embeddings is the array of embeddings, where each embedding is an array of floats.
+	Embeddings [][]float64 `json:"embeddings"`
+	// Texts is the array of text entries that the embeddings were requested for.
+	Texts []string `json:"texts"`
+}
+
+// TitanImageGenerationRequest is the InvokeModel request body of the Amazon Titan Image Generator and Nova Canvas
+// models for the text to image task.
+// https://docs.aws.amazon.com/bedrock/latest/userguide/model-parameters-titan-image.html
+type TitanImageGenerationRequest struct {
+	// TaskType is the type of the image generation task. This is always "TEXT_IMAGE" for the text to image task.
+	TaskType string `json:"taskType"`
+	// TextToImageParams is the parameters for the text to image task.
+	TextToImageParams TitanTextToImageParams `json:"textToImageParams"`
+	// ImageGenerationConfig is the optional configuration of the generated images.
+	ImageGenerationConfig *TitanImageGenerationConfig `json:"imageGenerationConfig,omitempty"`
+}
+
+// TitanTextToImageParams is the parameters for the text to image task.
+type TitanTextToImageParams struct {
+	// Text is the text prompt to generate the image.
+	Text string `json:"text"`
+}
+
+// TitanImageGenerationConfig is the configuration of the generated images.
+type TitanImageGenerationConfig struct {
+	// NumberOfImages is the number of images to generate.
+	NumberOfImages *int `json:"numberOfImages,omitempty"`
+	// Width is the width of the image in pixels.
+	Width *int `json:"width,omitempty"`
+	// Height is the height of the image in pixels.
+	Height *int `json:"height,omitempty"`
+	// Quality is the quality of the generated images, either "standard" or "premium".
+	Quality string `json:"quality,omitempty"`
+}
+
+// StabilityImageGenerationRequest is the InvokeModel request body of the Stability AI image models.
+// https://docs.aws.amazon.com/bedrock/latest/userguide/model-parameters-diffusion-stable-image-core-text-image-request-response.html
+type StabilityImageGenerationRequest struct {
+	// Prompt is what you wish to see in the output image.
+	Prompt string `json:"prompt"`
+	// AspectRatio controls the aspect ratio of the generated image such as "1:1" or "16:9".
+	AspectRatio string `json:"aspect_ratio,omitempty"`
+	// OutputFormat specifies the format of the output image such as "png" or "jpeg".
+	OutputFormat string `json:"output_format,omitempty"`
+}
+
+// ImageGenerationResponse is the InvokeModel response body of the image generation models. Both Amazon and
+// Stability AI models return the generated images as the base64-encoded strings in the "images" field.
+type ImageGenerationResponse struct {
+	// Images is the list of the base64-encoded generated images.
+	Images []string `json:"images"`
+	// Error is the error message if the request was blocked by the content moderation.
+	Error *string `json:"error,omitempty"`
+}
@@ -32,3 +32,118 @@ type GenerateContentRequest struct {
 	// https://github.com/googleapis/go-genai/blob/6a8184fcaf8bf15f0c566616a7b356560309be9b/types.go#L858
 	SystemInstruction *genai.Content `json:"system_instruction,omitempty"`
 }
+
+// PredictEmbeddingRequest is the request body of the :predict method for the text embeddings models.
+// https://cloud.google.com/vertex-ai/generative-ai/docs/model-reference/text-embeddings-api
+type PredictEmbeddingRequest struct {
+	// Instances is the list of texts to embed.
+	Instances []PredictEmbeddingInstance `json:"instances"`
+	// Parameters is the optional parameters for the embedding.
+	Parameters *PredictEmbeddingParameters `json:"parameters,omitempty"`
+}
+
+// PredictEmbeddingInstance is a single text to embed.
+type PredictEmbeddingInstance struct {
+	// Content is the text to generate the embedding for.
+	Content string `json:"content"`
+	// TaskType is the intended downstream application to help the model produce better embeddings.
+	TaskType string `json:"task_type,omitempty"`
+}
+
+// PredictEmbeddingParameters is the parameters of the :predict method for the text embeddings models.
+type PredictEmbeddingParameters struct {
+	// AutoTruncate specifies whether the input text is truncated when it exceeds the maximum length.
+	AutoTruncate *bool `json:"autoTruncate,omitempty"`
+	// OutputDimensionality is the number of dimensions of the output embeddings.
+	OutputDimensionality *int `json:"outputDimensionality,omitempty"`
+}
+
+// PredictEmbeddingResponse is the response body of the :predict method for the text embeddings models.
+type PredictEmbeddingResponse struct {
+	// Predictions is the list of embeddings in the same order as the instances.
+	Predictions []PredictEmbeddingPrediction `json:"predictions"`
+}
+
+// PredictEmbeddingPrediction is the prediction for a single instance.
+type PredictEmbeddingPrediction struct {
+	Embeddings PredictEmbeddingValues `json:"embeddings"`
+}
+
+// PredictEmbeddingValues is the embedding vector and its statistics.
+type PredictEmbeddingValues struct {
+	// Values is the embedding vector.
+	Values []float64 `json:"values"`
+	// Statistics is the statistics computed from the input text.
+	Statistics PredictEmbeddingStatistics `json:"statistics"`
+}
+
+// PredictEmbeddingStatistics is the statistics computed from the input text.
+type PredictEmbeddingStatistics struct {
+	// TokenCount is the number of tokens of the input text.
+	TokenCount int `json:"token_count"`
+	// Truncated indicates whether the input text was truncated.
+	Truncated bool `json:"truncated"`
+}
+
+// Error is the error response body returned by the GCP APIs.
+// https://cloud.google.com/apis/design/errors#http_mapping
+type Error struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail is the detail of the GCP error.
+type ErrorDetail struct {
+	// Code is the HTTP status code.
+	Code int `json:"code"`
+	// Message is the developer-facing error message.
+	Message string `json:"message"`
+	// Status is the canonical error code such as INVALID_ARGUMENT.
+	Status string `json:"status"`
+}
+
+// PredictImageRequest is the request body of the :predict method for the Imagen models.
+// https://cloud.google.com/vertex-ai/generative-ai/docs/model-reference/imagen-api
+type PredictImageRequest struct {
+	// Instances is the list of prompts. Only a single instance is supported.
+	Instances []PredictImageInstance `json:"instances"`
+	// Parameters is the optional parameters for the image generation.
+	Parameters *PredictImageParameters `json:"parameters,omitempty"`
+}
+
+// PredictImageInstance is the prompt of the image generation.
+type PredictImageInstance struct {
+	// Prompt is the text prompt for the image.
+	Prompt string `json:"prompt"`
+}
+
+// PredictImageParameters is the parameters of the :predict method for the Imagen models.
+type PredictImageParameters struct {
+	// SampleCount is the number of images to generate.
+	SampleCount *int `json:"sampleCount,omitempty"`
+	// AspectRatio is the aspect ratio of the generated images such as "1:1" or "16:9".
+	AspectRatio string `json:"aspectRatio,omitempty"`
+	// OutputOptions is the options of the output image.
+	OutputOptions *PredictImageOutputOptions `json:"outputOptions,omitempty"`
+}
+
+// PredictImageOutputOptions is the options of the output image.
+type PredictImageOutputOptions struct {
+	// MimeType is the MIME type of the output image such as "image/png" or "image/jpeg".
+	MimeType string `json:"mimeType,omitempty"`
+}
+
+// PredictImageResponse is the response body of the :predict method for the Imagen models.
+type PredictImageResponse struct {
+	// Predictions is the list of generated images.
+	Predictions []PredictImagePrediction `json:"predictions"`
+}
+
+// PredictImagePrediction is a single generated image.
+type PredictImagePrediction struct {
+	// BytesBase64Encoded is the base64-encoded generated image.
+	BytesBase64Encoded string `json:"bytesBase64Encoded"`
+	// MimeType is the MIME type of the generated image.
+	MimeType string `json:"mimeType"`
+	// Prompt is the enhanced prompt if the prompt enhancement was used.
+	Prompt string `json:"prompt,omitempty"`
+}
@@ -0,0 +1,104 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package openai
+
+const (
+	// AudioTranscriptionUsageTypeDuration is the usage type reported by the models billed by the audio duration such as "whisper-1".
+	AudioTranscriptionUsageTypeDuration = "duration"
+	// AudioTranscriptionUsageTypeTokens is the usage type reported by the models billed by the tokens such as "gpt-4o-transcribe".
+	AudioTranscriptionUsageTypeTokens = "tokens"
+)
+
+// AudioTranscriptionRequest represents a request to the /v1/audio/transcriptions endpoint.
+// The request is sent as multipart/form-data, so this only holds the non-file form fields.
+// https://platform.openai.com/docs/api-reference/audio/createTranscription
+type AudioTranscriptionRequest struct {
+	// Model is the ID of the model to use such as "whisper-1" or "gpt-4o-transcribe".
+	// Docs: https://platform.openai.com/docs/api-reference/audio/createTranscription#audio-createtranscription-model
+	Model string `json:"model"`
+
+	// Language is the language of the input audio in ISO-639-1 format.
+	// Docs: https://platform.openai.com/docs/api-reference/audio/createTranscription#audio-createtranscription-language
+	Language string `json:"language,omitempty"`
+
+	// Prompt is an optional text to guide the model's style or continue a previous audio segment.
+	// Docs: https://platform.openai.com/docs/api-reference/audio/createTranscription#audio-createtranscription-prompt
+	Prompt string `json:"prompt,omitempty"`
+
+	// ResponseFormat is the format of the output such as "json", "text", "srt", "verbose_json" or "vtt".
+	// Docs: https://platform.openai.com/docs/api-reference/audio/createTranscription#audio-createtranscription-response_format
+	ResponseFormat string `json:"response_format,omitempty"` //nolint:tagliatelle //follow openai api
+
+	// Temperature is the sampling temperature, between 0 and 1.
+	// Docs: https://platform.openai.com/docs/api-reference/audio/createTranscription#audio-createtranscription-temperature
+	Temperature string `json:"temperature,omitempty"`
+}
+
+// AudioTranscriptionResponse represents the JSON response from the /v1/audio/transcriptions endpoint.
+// This is only returned when the response format is "json" or "verbose_json".
+// https://platform.openai.com/docs/api-reference/audio/json-object
+type AudioTranscriptionResponse struct {
+	// Text is the transcribed text.
+	Text string `json:"text"`
+
+	// Language is the language of the input audio. This is only set for "verbose_json".
+	Language string `json:"language,omitempty"`
+
+	// Duration is the duration of the input audio in seconds. This is only set for "verbose_json".
+	Duration *float64 `json:"duration,omitempty"`
+
+	// Usage is the usage statistics for the transcription request.
+	Usage *AudioTranscriptionUsage `json:"usage,omitempty"`
+}
+
+// AudioTranscriptionUsage represents the usage statistics of the transcription request.
+// Depending on Type, either Seconds or the token fields are set.
+// https://platform.openai.com/docs/api-reference/audio/json-object#audio/json-object-usage
+type AudioTranscriptionUsage struct {
+	// Type is the type of the usage, either "duration" or "tokens".
+	Type string `json:"type"`
+
+	// Seconds is the duration of the input audio in seconds.
+	Seconds float64 `json:"seconds,omitempty"`
+
+	// InputTokens is the number of input tokens billed for this request.
+	InputTokens int `json:"input_tokens,omitempty"` //nolint:tagliatelle //follow openai api
+
+	// OutputTokens is the number of output tokens generated.
+	OutputTokens int `json:"output_tokens,omitempty"` //nolint:tagliatelle //follow openai api
+
+	// TotalTokens is the total number of tokens used.
+	TotalTokens int `json:"total_tokens,omitempty"` //nolint:tagliatelle //follow openai api
+}
+
+// AudioSpeechRequest represents a request to the /v1/audio/speech endpoint.
+// The response of this endpoint is the binary audio content.
+// https://platform.openai.com/docs/api-reference/audio/createSpeech
+type AudioSpeechRequest struct {
+	// Model is the ID of the model to use such as "tts-1" or "gpt-4o-mini-tts".
+	// Docs: https://platform.openai.com/docs/api-reference/audio/createSpeech#audio-createspeech-model
+	Model string `json:"model"`
+
+	// Input is the text to generate audio for.
+	// Docs: https://platform.openai.com/docs/api-reference/audio/createSpeech#audio-createspeech-input
+	Input string `json:"input"`
+
+	// Voice is the voice to use when generating the audio such as "alloy".
+	// Docs: https://platform.openai.com/docs/api-reference/audio/createSpeech#audio-createspeech-voice
+	Voice string `json:"voice"`
+
+	// Instructions controls the voice of the generated audio. This does not work with "tts-1" or "tts-1-hd".
+	// Docs: https://platform.openai.com/docs/api-reference/audio/createSpeech#audio-createspeech-instructions
+	Instructions string `json:"instructions,omitempty"`
+
+	// ResponseFormat is the format of the audio such as "mp3", "opus", "aac", "flac", "wav" or "pcm".
+	// Docs: https://platform.openai.com/docs/api-reference/audio/createSpeech#audio-createspeech-response_format
+	ResponseFormat string `json:"response_format,omitempty"` //nolint:tagliatelle //follow openai api
+
+	// Speed is the speed of the generated audio, from 0.25 to 4.0.
+	// Docs: https://platform.openai.com/docs/api-reference/audio/createSpeech#audio-createspeech-speed
+	Speed *float64 `json:"speed,omitempty"`
+}
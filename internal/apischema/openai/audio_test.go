@@ -0,0 +1,65 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package openai
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestAudioTranscriptionResponse_Unmarshal(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   string
+		exp  AudioTranscriptionResponse
+	}{
+		{
+			name: "duration usage",
+			in:   `{"text":"hello","usage":{"type":"duration","seconds":3.5}}`,
+			exp: AudioTranscriptionResponse{
+				Text:  "hello",
+				Usage: &AudioTranscriptionUsage{Type: AudioTranscriptionUsageTypeDuration, Seconds: 3.5},
+			},
+		},
+		{
+			name: "tokens usage",
+			in:   `{"text":"hello","usage":{"type":"tokens","input_tokens":14,"output_tokens":45,"total_tokens":59}}`,
+			exp: AudioTranscriptionResponse{
+				Text: "hello",
+				Usage: &AudioTranscriptionUsage{
+					Type: AudioTranscriptionUsageTypeTokens, InputTokens: 14, OutputTokens: 45, TotalTokens: 59,
+				},
+			},
+		},
+		{
+			name: "verbose json",
+			in:   `{"task":"transcribe","language":"english","duration":8.47,"text":"hello","segments":[]}`,
+			exp:  AudioTranscriptionResponse{Text: "hello", Language: "english", Duration: ptr.To(8.47)},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var resp AudioTranscriptionResponse
+			require.NoError(t, json.Unmarshal([]byte(tc.in), &resp))
+			require.Equal(t, tc.exp, resp)
+		})
+	}
+}
+
+func TestAudioSpeechRequest_Unmarshal(t *testing.T) {
+	const in = `{"model":"tts-1","input":"hello world","voice":"alloy","response_format":"mp3","speed":1.5}`
+	var req AudioSpeechRequest
+	require.NoError(t, json.Unmarshal([]byte(in), &req))
+	require.Equal(t, AudioSpeechRequest{
+		Model:          "tts-1",
+		Input:          "hello world",
+		Voice:          "alloy",
+		ResponseFormat: "mp3",
+		Speed:          ptr.To(1.5),
+	}, req)
+}
@@ -0,0 +1,166 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// CompletionRequest represents a request to the legacy /v1/completions endpoint.
+// https://platform.openai.com/docs/api-reference/completions/create
+type CompletionRequest struct {
+	// Model is the ID of the model to use.
+	// Docs: https://platform.openai.com/docs/api-reference/completions/create#completions-create-model
+	Model string `json:"model"`
+
+	// Prompt is the prompt(s) to generate completions for, encoded as a string, array of strings,
+	// array of tokens, or array of token arrays.
+	// Docs: https://platform.openai.com/docs/api-reference/completions/create#completions-create-prompt
+	Prompt PromptUnion `json:"prompt"`
+
+	// Suffix is the suffix that comes after a completion of inserted text. This is used for the fill-in-the-middle completion.
+	// Docs: https://platform.openai.com/docs/api-reference/completions/create#completions-create-suffix
+	Suffix *string `json:"suffix,omitempty"`
+
+	// BestOf generates best_of completions server-side and returns the "best" one.
+	// Docs: https://platform.openai.com/docs/api-reference/completions/create#completions-create-best_of
+	BestOf *int `json:"best_of,omitempty"` //nolint:tagliatelle //follow openai api
+
+	// Echo echoes back the prompt in addition to the completion.
+	// Docs: https://platform.openai.com/docs/api-reference/completions/create#completions-create-echo
+	Echo bool `json:"echo,omitempty"`
+
+	// FrequencyPenalty penalizes new tokens based on their existing frequency in the text so far.
+	// Docs: https://platform.openai.com/docs/api-reference/completions/create#completions-create-frequency_penalty
+	FrequencyPenalty *float32 `json:"frequency_penalty,omitempty"` //nolint:tagliatelle //follow openai api
+
+	// LogitBias modifies the likelihood of specified tokens appearing in the completion.
+	// Docs: https://platform.openai.com/docs/api-reference/completions/create#completions-create-logit_bias
+	LogitBias map[string]int `json:"logit_bias,omitempty"` //nolint:tagliatelle //follow openai api
+
+	// Logprobs includes the log probabilities on the logprobs most likely output tokens.
+	// Docs: https://platform.openai.com/docs/api-reference/completions/create#completions-create-logprobs
+	Logprobs *int `json:"logprobs,omitempty"`
+
+	// MaxTokens is the maximum number of tokens that can be generated in the completion.
+	// Docs: https://platform.openai.com/docs/api-reference/completions/create#completions-create-max_tokens
+	MaxTokens *int64 `json:"max_tokens,omitempty"` //nolint:tagliatelle //follow openai api
+
+	// N is the number of completions to generate for each prompt.
+	// Docs: https://platform.openai.com/docs/api-reference/completions/create#completions-create-n
+	N *int `json:"n,omitempty"`
+
+	// PresencePenalty penalizes new tokens based on whether they appear in the text so far.
+	// Docs: https://platform.openai.com/docs/api-reference/completions/create#completions-create-presence_penalty
+	PresencePenalty *float32 `json:"presence_penalty,omitempty"` //nolint:tagliatelle //follow openai api
+
+	// Seed makes a best effort to sample deterministically.
+	// Docs: https://platform.openai.com/docs/api-reference/completions/create#completions-create-seed
+	Seed *int `json:"seed,omitempty"`
+
+	// Stop is up to 4 sequences where the API will stop generating further tokens.
+	// Docs: https://platform.openai.com/docs/api-reference/completions/create#completions-create-stop
+	Stop interface{} `json:"stop,omitempty"`
+
+	// Stream streams back partial progress as server-sent events.
+	// Docs: https://platform.openai.com/docs/api-reference/completions/create#completions-create-stream
+	Stream bool `json:"stream,omitempty"`
+
+	// StreamOptions is the options for the streaming response. Only set this when Stream is true.
+	// Docs: https://platform.openai.com/docs/api-reference/completions/create#completions-create-stream_options
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"` //nolint:tagliatelle //follow openai api
+
+	// Temperature is the sampling temperature to use, between 0 and 2.
+	// Docs: https://platform.openai.com/docs/api-reference/completions/create#completions-create-temperature
+	Temperature *float64 `json:"temperature,omitempty"`
+
+	// TopP is the nucleus sampling probability mass.
+	// Docs: https://platform.openai.com/docs/api-reference/completions/create#completions-create-top_p
+	TopP *float64 `json:"top_p,omitempty"` //nolint:tagliatelle //follow openai api
+
+	// User is a unique identifier representing your end-user.
+	// Docs: https://platform.openai.com/docs/api-reference/completions/create#completions-create-user
+	User string `json:"user,omitempty"`
+}
+
+// PromptUnion is the prompt of the [CompletionRequest]. Value is one of string, []string, []int64 or [][]int64.
+type PromptUnion struct {
+	Value interface{}
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (p *PromptUnion) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		p.Value = str
+		return nil
+	}
+	var strs []string
+	if err := json.Unmarshal(data, &strs); err == nil {
+		p.Value = strs
+		return nil
+	}
+	var tokens []int64
+	if err := json.Unmarshal(data, &tokens); err == nil {
+		p.Value = tokens
+		return nil
+	}
+	var tokenArrays [][]int64
+	if err := json.Unmarshal(data, &tokenArrays); err == nil {
+		p.Value = tokenArrays
+		return nil
+	}
+	return errors.New("cannot unmarshal JSON data as string, array of strings, array of tokens or array of token arrays")
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (p PromptUnion) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.Value)
+}
+
+// CompletionResponse represents a response from the legacy /v1/completions endpoint.
+// This is also used for each event of the streaming response, in which case Object is "text_completion" as well.
+// https://platform.openai.com/docs/api-reference/completions/object
+type CompletionResponse struct {
+	// ID is a unique identifier for the completion.
+	ID string `json:"id,omitempty"`
+
+	// Object is always "text_completion".
+	Object string `json:"object,omitempty"`
+
+	// Created is the Unix timestamp (in seconds) of when the completion was created.
+	Created JSONUNIXTime `json:"created,omitzero"`
+
+	// Model is the model used for completion.
+	Model string `json:"model,omitempty"`
+
+	// Choices is the list of completion choices the model generated for the input prompt.
+	Choices []CompletionChoice `json:"choices,omitempty"`
+
+	// SystemFingerprint represents the backend configuration that the model runs with.
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+
+	// Usage is the usage statistics for the completion request. In the streaming response, this is only set
+	// in the last event when stream_options.include_usage is true.
+	Usage *ChatCompletionResponseUsage `json:"usage,omitempty"`
+}
+
+// CompletionChoice is a completion choice generated by the model.
+// https://platform.openai.com/docs/api-reference/completions/object#completions/object-choices
+type CompletionChoice struct {
+	// Text is the generated text.
+	Text string `json:"text"`
+
+	// Index is the index of the choice in the list of choices.
+	Index int `json:"index"`
+
+	// Logprobs is the log probabilities of the output tokens if requested.
+	Logprobs json.RawMessage `json:"logprobs,omitempty"`
+
+	// FinishReason is the reason the model stopped generating tokens such as "stop" or "length".
+	FinishReason string `json:"finish_reason,omitempty"` //nolint:tagliatelle //follow openai api
+}
@@ -0,0 +1,66 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package openai
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestCompletionRequest_Unmarshal(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		in        string
+		expPrompt interface{}
+	}{
+		{name: "string", in: `{"model":"m","prompt":"def fib(n):"}`, expPrompt: "def fib(n):"},
+		{name: "strings", in: `{"model":"m","prompt":["a","b"]}`, expPrompt: []string{"a", "b"}},
+		{name: "tokens", in: `{"model":"m","prompt":[1,2,3]}`, expPrompt: []int64{1, 2, 3}},
+		{name: "token arrays", in: `{"model":"m","prompt":[[1,2],[3]]}`, expPrompt: [][]int64{{1, 2}, {3}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var req CompletionRequest
+			require.NoError(t, json.Unmarshal([]byte(tc.in), &req))
+			require.Equal(t, "m", req.Model)
+			require.Equal(t, tc.expPrompt, req.Prompt.Value)
+
+			// Marshaling back must produce the same JSON.
+			out, err := json.Marshal(req)
+			require.NoError(t, err)
+			require.JSONEq(t, tc.in, string(out))
+		})
+	}
+	t.Run("invalid prompt", func(t *testing.T) {
+		var req CompletionRequest
+		require.ErrorContains(t, json.Unmarshal([]byte(`{"model":"m","prompt":{}}`), &req),
+			"cannot unmarshal JSON data as string, array of strings, array of tokens or array of token arrays")
+	})
+	t.Run("fill in the middle", func(t *testing.T) {
+		var req CompletionRequest
+		const in = `{"model":"m","prompt":"def f(","suffix":"return x","max_tokens":16,"stream":true,"stream_options":{"include_usage":true}}`
+		require.NoError(t, json.Unmarshal([]byte(in), &req))
+		require.Equal(t, ptr.To("return x"), req.Suffix)
+		require.Equal(t, ptr.To[int64](16), req.MaxTokens)
+		require.True(t, req.Stream)
+		require.Equal(t, &StreamOptions{IncludeUsage: true}, req.StreamOptions)
+	})
+}
+
+func TestCompletionResponse_Unmarshal(t *testing.T) {
+	const in = `{"id":"cmpl-1","object":"text_completion","created":1589478378,"model":"m",
+"choices":[{"text":"\n\nThis is a test","index":0,"logprobs":null,"finish_reason":"length"}],
+"usage":{"prompt_tokens":5,"completion_tokens":7,"total_tokens":12}}`
+	var resp CompletionResponse
+	require.NoError(t, json.Unmarshal([]byte(in), &resp))
+	require.Equal(t, "cmpl-1", resp.ID)
+	require.Len(t, resp.Choices, 1)
+	require.Equal(t, "\n\nThis is a test", resp.Choices[0].Text)
+	require.Equal(t, "length", resp.Choices[0].FinishReason)
+	require.Equal(t, &ChatCompletionResponseUsage{PromptTokens: 5, CompletionTokens: 7, TotalTokens: 12}, resp.Usage)
+}
@@ -0,0 +1,91 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package openai
+
+const (
+	// ImageResponseFormatURL is the response format that returns the URLs of the generated images.
+	ImageResponseFormatURL = "url"
+	// ImageResponseFormatB64JSON is the response format that returns the base64-encoded JSON of the generated images.
+	ImageResponseFormatB64JSON = "b64_json"
+)
+
+// ImageGenerationRequest represents a request to the /v1/images/generations endpoint.
+// https://platform.openai.com/docs/api-reference/images/create
+type ImageGenerationRequest struct {
+	// Model is the model to use for image generation.
+	// Docs: https://platform.openai.com/docs/api-reference/images/create#images-create-model
+	Model string `json:"model"`
+
+	// Prompt is a text description of the desired image(s).
+	// Docs: https://platform.openai.com/docs/api-reference/images/create#images-create-prompt
+	Prompt string `json:"prompt"`
+
+	// N is the number of images to generate.
+	// Docs: https://platform.openai.com/docs/api-reference/images/create#images-create-n
+	N *int `json:"n,omitempty"`
+
+	// Size is the size of the generated images such as "1024x1024".
+	// Docs: https://platform.openai.com/docs/api-reference/images/create#images-create-size
+	Size string `json:"size,omitempty"`
+
+	// Quality is the quality of the image that will be generated such as "standard", "hd", "low", "medium" or "high".
+	// Docs: https://platform.openai.com/docs/api-reference/images/create#images-create-quality
+	Quality string `json:"quality,omitempty"`
+
+	// ResponseFormat is the format in which the generated images are returned. Must be one of "url" or "b64_json".
+	// Docs: https://platform.openai.com/docs/api-reference/images/create#images-create-response_format
+	ResponseFormat string `json:"response_format,omitempty"` //nolint:tagliatelle //follow openai api
+
+	// Style is the style of the generated images. Must be one of "vivid" or "natural".
+	// Docs: https://platform.openai.com/docs/api-reference/images/create#images-create-style
+	Style string `json:"style,omitempty"`
+
+	// OutputFormat is the format in which the generated images are returned such as "png", "jpeg" or "webp".
+	// Docs: https://platform.openai.com/docs/api-reference/images/create#images-create-output_format
+	OutputFormat string `json:"output_format,omitempty"` //nolint:tagliatelle //follow openai api
+
+	// Background allows to set transparency for the background of the generated image(s).
+	// Docs: https://platform.openai.com/docs/api-reference/images/create#images-create-background
+	Background string `json:"background,omitempty"`
+
+	// User is a unique identifier representing your end-user.
+	// Docs: https://platform.openai.com/docs/api-reference/images/create#images-create-user
+	User string `json:"user,omitempty"`
+}
+
+// ImageGenerationResponse represents a response from the /v1/images/generations endpoint.
+// https://platform.openai.com/docs/api-reference/images/object
+type ImageGenerationResponse struct {
+	// Created is the Unix timestamp (in seconds) of when the image was created.
+	Created JSONUNIXTime `json:"created"`
+	// Data is the list of generated images.
+	Data []ImageData `json:"data"`
+	// Usage is the token usage information for the image generation. This is only returned by the
+	// token based models such as gpt-image-1.
+	Usage *ImageGenerationUsage `json:"usage,omitempty"`
+}
+
+// ImageData represents a single generated image.
+// https://platform.openai.com/docs/api-reference/images/object#images/object-data
+type ImageData struct {
+	// URL is the URL of the generated image when the response format is "url".
+	URL string `json:"url,omitempty"`
+	// B64JSON is the base64-encoded JSON of the generated image when the response format is "b64_json".
+	B64JSON string `json:"b64_json,omitempty"` //nolint:tagliatelle //follow openai api
+	// RevisedPrompt is the prompt that was used to generate the image if there was any revision to the prompt.
+	RevisedPrompt string `json:"revised_prompt,omitempty"` //nolint:tagliatelle //follow openai api
+}
+
+// ImageGenerationUsage represents the token usage information for the image generation.
+// https://platform.openai.com/docs/api-reference/images/object#images/object-usage
+type ImageGenerationUsage struct {
+	// InputTokens is the number of tokens (images and text) in the input prompt.
+	InputTokens int `json:"input_tokens"` //nolint:tagliatelle //follow openai api
+	// OutputTokens is the number of image tokens in the output image.
+	OutputTokens int `json:"output_tokens"` //nolint:tagliatelle //follow openai api
+	// TotalTokens is the total number of tokens (images and text) used for the image generation.
+	TotalTokens int `json:"total_tokens"` //nolint:tagliatelle //follow openai api
+}
@@ -0,0 +1,47 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package openai
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestImageGenerationRequest_Unmarshal(t *testing.T) {
+	const in = `{"model":"dall-e-3","prompt":"a cat","n":2,"size":"1024x1024","quality":"hd","response_format":"b64_json","style":"vivid"}`
+	var req ImageGenerationRequest
+	require.NoError(t, json.Unmarshal([]byte(in), &req))
+	require.Equal(t, ImageGenerationRequest{
+		Model:          "dall-e-3",
+		Prompt:         "a cat",
+		N:              ptr.To(2),
+		Size:           "1024x1024",
+		Quality:        "hd",
+		ResponseFormat: ImageResponseFormatB64JSON,
+		Style:          "vivid",
+	}, req)
+}
+
+func TestImageGenerationResponse_Marshal(t *testing.T) {
+	resp := ImageGenerationResponse{
+		Created: JSONUNIXTime(time.Unix(1713833628, 0)),
+		Data:    []ImageData{{B64JSON: "aGVsbG8="}, {URL: "https://example.com/a.png", RevisedPrompt: "a cute cat"}},
+		Usage:   &ImageGenerationUsage{InputTokens: 10, OutputTokens: 20, TotalTokens: 30},
+	}
+	b, err := json.Marshal(resp)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"created":1713833628,"data":[{"b64_json":"aGVsbG8="},{"url":"https://example.com/a.png","revised_prompt":"a cute cat"}],
+"usage":{"input_tokens":10,"output_tokens":20,"total_tokens":30}}`, string(b))
+
+	var got ImageGenerationResponse
+	require.NoError(t, json.Unmarshal(b, &got))
+	require.Equal(t, resp.Data, got.Data)
+	require.Equal(t, resp.Usage, got.Usage)
+}
@@ -1058,6 +1058,15 @@ type Model struct {
 	Object string `json:"object"`
 	// OwnedBy is the organization that owns the model.
 	OwnedBy string `json:"owned_by"`
+	// Aliases is the list of the alternative names of the model accepted by the gateway.
+	// This is an Envoy AI Gateway extension, not part of the OpenAI API.
+	Aliases []string `json:"aliases,omitempty"`
+	// ContextWindow is the maximum number of tokens of the model context.
+	// This is an Envoy AI Gateway extension, not part of the OpenAI API.
+	ContextWindow int `json:"context_window,omitempty"`
+	// Modalities is the list of the modalities supported by the model.
+	// This is an Envoy AI Gateway extension, not part of the OpenAI API.
+	Modalities []string `json:"modalities,omitempty"`
 }
 
 // EmbeddingRequest represents a request structure for embeddings API.
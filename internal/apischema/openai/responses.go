@@ -0,0 +1,388 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Responses API item types.
+// https://platform.openai.com/docs/api-reference/responses/create#responses-create-input
+const (
+	ResponseItemTypeMessage            = "message"
+	ResponseItemTypeFunctionCall       = "function_call"
+	ResponseItemTypeFunctionCallOutput = "function_call_output"
+)
+
+// Responses API content part types.
+const (
+	ResponseContentTypeInputText  = "input_text"
+	ResponseContentTypeInputImage = "input_image"
+	ResponseContentTypeOutputText = "output_text"
+	ResponseContentTypeRefusal    = "refusal"
+)
+
+// Responses API response statuses.
+const (
+	ResponseStatusInProgress = "in_progress"
+	ResponseStatusCompleted  = "completed"
+	ResponseStatusIncomplete = "incomplete"
+	ResponseStatusFailed     = "failed"
+)
+
+// Responses API streaming event types.
+// https://platform.openai.com/docs/api-reference/responses-streaming
+const (
+	ResponseStreamEventTypeCreated               = "response.created"
+	ResponseStreamEventTypeInProgress            = "response.in_progress"
+	ResponseStreamEventTypeCompleted             = "response.completed"
+	ResponseStreamEventTypeIncomplete            = "response.incomplete"
+	ResponseStreamEventTypeFailed                = "response.failed"
+	ResponseStreamEventTypeOutputItemAdded       = "response.output_item.added"
+	ResponseStreamEventTypeOutputItemDone        = "response.output_item.done"
+	ResponseStreamEventTypeContentPartAdded      = "response.content_part.added"
+	ResponseStreamEventTypeContentPartDone       = "response.content_part.done"
+	ResponseStreamEventTypeOutputTextDelta       = "response.output_text.delta"
+	ResponseStreamEventTypeOutputTextDone        = "response.output_text.done"
+	ResponseStreamEventTypeFunctionCallArgsDelta = "response.function_call_arguments.delta"
+	ResponseStreamEventTypeFunctionCallArgsDone  = "response.function_call_arguments.done"
+	ResponseStreamEventTypeError                 = "error"
+)
+
+// ResponseRequest represents a request to the /v1/responses endpoint.
+// https://platform.openai.com/docs/api-reference/responses/create
+type ResponseRequest struct {
+	// Model is the model ID used to generate the response.
+	// Docs: https://platform.openai.com/docs/api-reference/responses/create#responses-create-model
+	Model string `json:"model"`
+
+	// Input is the text, image, or file inputs to the model. This is either a plain string
+	// or a list of input items.
+	// Docs: https://platform.openai.com/docs/api-reference/responses/create#responses-create-input
+	Input ResponseInput `json:"input"`
+
+	// Instructions is a system (or developer) message inserted into the model's context.
+	// Docs: https://platform.openai.com/docs/api-reference/responses/create#responses-create-instructions
+	Instructions string `json:"instructions,omitempty"`
+
+	// MaxOutputTokens is an upper bound for the number of tokens that can be generated for a response,
+	// including visible output tokens and reasoning tokens.
+	// Docs: https://platform.openai.com/docs/api-reference/responses/create#responses-create-max_output_tokens
+	MaxOutputTokens *int64 `json:"max_output_tokens,omitempty"` //nolint:tagliatelle //follow openai api
+
+	// Temperature What sampling temperature to use, between 0 and 2.
+	// Docs: https://platform.openai.com/docs/api-reference/responses/create#responses-create-temperature
+	Temperature *float64 `json:"temperature,omitempty"`
+
+	// TopP An alternative to sampling with temperature, called nucleus sampling.
+	// Docs: https://platform.openai.com/docs/api-reference/responses/create#responses-create-top_p
+	TopP *float64 `json:"top_p,omitempty"` //nolint:tagliatelle //follow openai api
+
+	// Stream: If set, the response data will be streamed to the client as server-sent events.
+	// Docs: https://platform.openai.com/docs/api-reference/responses/create#responses-create-stream
+	Stream bool `json:"stream,omitempty"`
+
+	// Tools is an array of tools the model may call while generating a response.
+	// Only function tools can be translated to non-OpenAI backends.
+	// Docs: https://platform.openai.com/docs/api-reference/responses/create#responses-create-tools
+	Tools []ResponseTool `json:"tools,omitempty"`
+
+	// ToolChoice controls which (if any) tool is called by the model. This is either a string
+	// (none, auto, required) or an object such as `{"type": "function", "name": "my_function"}`.
+	// Docs: https://platform.openai.com/docs/api-reference/responses/create#responses-create-tool_choice
+	ToolChoice any `json:"tool_choice,omitempty"` //nolint:tagliatelle //follow openai api
+
+	// ParallelToolCalls enables multiple tools to be returned by the model.
+	// Docs: https://platform.openai.com/docs/api-reference/responses/create#responses-create-parallel_tool_calls
+	ParallelToolCalls *bool `json:"parallel_tool_calls,omitempty"` //nolint:tagliatelle //follow openai api
+
+	// PreviousResponseID is the unique ID of the previous response to the model, used for multi-turn conversations.
+	// This is only meaningful for OpenAI backends since the state is kept by the provider.
+	// Docs: https://platform.openai.com/docs/api-reference/responses/create#responses-create-previous_response_id
+	PreviousResponseID string `json:"previous_response_id,omitempty"` //nolint:tagliatelle //follow openai api
+
+	// Store indicates whether to store the generated model response for later retrieval.
+	// Docs: https://platform.openai.com/docs/api-reference/responses/create#responses-create-store
+	Store *bool `json:"store,omitempty"`
+
+	// Metadata is a set of key-value pairs that can be attached to the response.
+	// Docs: https://platform.openai.com/docs/api-reference/responses/create#responses-create-metadata
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Reasoning configuration options for reasoning models.
+	// Docs: https://platform.openai.com/docs/api-reference/responses/create#responses-create-reasoning
+	Reasoning *Reasoning `json:"reasoning,omitempty"`
+
+	// Text holds configuration options for a text response from the model.
+	// Docs: https://platform.openai.com/docs/api-reference/responses/create#responses-create-text
+	Text *ResponseTextConfig `json:"text,omitempty"`
+
+	// User: A unique identifier representing your end-user.
+	// Docs: https://platform.openai.com/docs/api-reference/responses/create#responses-create-user
+	User string `json:"user,omitempty"`
+}
+
+// ResponseInput is the input of the [ResponseRequest]. Exactly one of Text or Items is set.
+type ResponseInput struct {
+	// Text is set when the input is a plain string, which is equivalent to a single user message.
+	Text *string
+	// Items is set when the input is a list of input items.
+	Items []ResponseInputItem
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (r *ResponseInput) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		r.Text = &str
+		return nil
+	}
+	var items []ResponseInputItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return fmt.Errorf("cannot unmarshal input as string or array of input items: %w", err)
+	}
+	r.Items = items
+	return nil
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (r ResponseInput) MarshalJSON() ([]byte, error) {
+	if r.Text != nil {
+		return json.Marshal(*r.Text)
+	}
+	return json.Marshal(r.Items)
+}
+
+// ResponseInputItem is an item of the input list. Depending on the Type, different fields are set:
+//   - "message" (or empty type with a role): Role and Content.
+//   - "function_call": CallID, Name and Arguments.
+//   - "function_call_output": CallID and Output.
+//
+// https://platform.openai.com/docs/api-reference/responses/create#responses-create-input
+type ResponseInputItem struct {
+	// Type is the type of the item. Defaults to "message" when omitted.
+	Type string `json:"type,omitempty"`
+	// ID is the unique ID of the item, if any.
+	ID string `json:"id,omitempty"`
+	// Role is the role of the message input. One of user, assistant, system, or developer.
+	Role string `json:"role,omitempty"`
+	// Content is the content of the message.
+	Content *ResponseMessageContent `json:"content,omitempty"`
+	// CallID is the unique ID of the function tool call.
+	CallID string `json:"call_id,omitempty"` //nolint:tagliatelle //follow openai api
+	// Name is the name of the function that was called.
+	Name string `json:"name,omitempty"`
+	// Arguments is a JSON string of the arguments passed to the function.
+	Arguments string `json:"arguments,omitempty"`
+	// Output is the output of the function tool call.
+	Output string `json:"output,omitempty"`
+	// Status is the status of the item.
+	Status string `json:"status,omitempty"`
+}
+
+// ResponseMessageContent is the content of a message input item. Exactly one of Text or Parts is set.
+type ResponseMessageContent struct {
+	// Text is set when the content is a plain string.
+	Text *string
+	// Parts is set when the content is a list of content parts.
+	Parts []ResponseContentPart
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (r *ResponseMessageContent) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		r.Text = &str
+		return nil
+	}
+	var parts []ResponseContentPart
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return fmt.Errorf("cannot unmarshal content as string or array of content parts: %w", err)
+	}
+	r.Parts = parts
+	return nil
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (r ResponseMessageContent) MarshalJSON() ([]byte, error) {
+	if r.Text != nil {
+		return json.Marshal(*r.Text)
+	}
+	return json.Marshal(r.Parts)
+}
+
+// ResponseContentPart is a content part of a message in the Responses API, used both for inputs and outputs.
+// https://platform.openai.com/docs/api-reference/responses/object#responses/object-output
+type ResponseContentPart struct {
+	// Type is one of input_text, input_image, output_text or refusal.
+	Type string `json:"type"`
+	// Text is the text content for input_text and output_text.
+	Text string `json:"text,omitempty"`
+	// ImageURL is the URL of the image, or a base64 encoded data URI, for input_image.
+	ImageURL string `json:"image_url,omitempty"` //nolint:tagliatelle //follow openai api
+	// Detail is the detail level of the image for input_image.
+	Detail string `json:"detail,omitempty"`
+	// Refusal is the refusal explanation from the model for the refusal type.
+	Refusal string `json:"refusal,omitempty"`
+	// Annotations are the annotations of the text output. Always an array for output_text.
+	Annotations []any `json:"annotations,omitempty"`
+}
+
+// ResponseTool is a tool definition for the Responses API. Unlike the chat completions API,
+// function definitions are flattened into the tool object.
+// https://platform.openai.com/docs/api-reference/responses/create#responses-create-tools
+type ResponseTool struct {
+	// Type is the type of the tool, e.g. "function".
+	Type string `json:"type"`
+	// Name is the name of the function.
+	Name string `json:"name,omitempty"`
+	// Description is a description of the function.
+	Description string `json:"description,omitempty"`
+	// Parameters is a JSON schema object describing the parameters of the function.
+	Parameters any `json:"parameters,omitempty"`
+	// Strict indicates whether to enforce strict parameter validation.
+	Strict *bool `json:"strict,omitempty"`
+}
+
+// ResponseTextConfig holds configuration options for a text response.
+// https://platform.openai.com/docs/api-reference/responses/create#responses-create-text
+type ResponseTextConfig struct {
+	// Format specifies the format that the model must output.
+	Format *ResponseTextFormat `json:"format,omitempty"`
+}
+
+// ResponseTextFormat specifies the format of a text response. The JSON schema fields are
+// flattened into the object unlike the chat completions API.
+type ResponseTextFormat struct {
+	// Type is one of text, json_object or json_schema.
+	Type string `json:"type"`
+	// Name is the name of the response format for json_schema.
+	Name string `json:"name,omitempty"`
+	// Description of the response format for json_schema.
+	Description string `json:"description,omitempty"`
+	// Schema is the JSON schema for json_schema.
+	Schema any `json:"schema,omitempty"`
+	// Strict indicates whether to enable strict schema adherence for json_schema.
+	Strict *bool `json:"strict,omitempty"`
+}
+
+// Response represents a response object from /v1/responses.
+// https://platform.openai.com/docs/api-reference/responses/object
+type Response struct {
+	// ID is the unique identifier for this response.
+	ID string `json:"id"`
+	// Object is always "response".
+	Object string `json:"object"`
+	// CreatedAt is the Unix timestamp (in seconds) of when this response was created.
+	CreatedAt JSONUNIXTime `json:"created_at"` //nolint:tagliatelle //follow openai api
+	// Status is the status of the response generation.
+	Status string `json:"status"`
+	// Model is the model ID used to generate the response.
+	Model string `json:"model"`
+	// Output is an array of content items generated by the model.
+	Output []ResponseOutputItem `json:"output"`
+	// Usage represents token usage details.
+	Usage *ResponseUsage `json:"usage,omitempty"`
+	// IncompleteDetails holds the details about why the response is incomplete.
+	IncompleteDetails *ResponseIncompleteDetails `json:"incomplete_details,omitempty"` //nolint:tagliatelle //follow openai api
+	// Error is set when the model fails to generate a response.
+	Error *ResponseError `json:"error,omitempty"`
+}
+
+// ResponseOutputItem is an output item of the [Response].
+// https://platform.openai.com/docs/api-reference/responses/object#responses/object-output
+type ResponseOutputItem struct {
+	// Type is either "message" or "function_call".
+	Type string `json:"type"`
+	// ID is the unique ID of the output item.
+	ID string `json:"id,omitempty"`
+	// Status is the status of the output item.
+	Status string `json:"status,omitempty"`
+	// Role is the role of the output message, which is always "assistant".
+	Role string `json:"role,omitempty"`
+	// Content is the content of the output message.
+	Content []ResponseContentPart `json:"content,omitempty"`
+	// CallID is the unique ID of the function tool call generated by the model.
+	CallID string `json:"call_id,omitempty"` //nolint:tagliatelle //follow openai api
+	// Name is the name of the function to run.
+	Name string `json:"name,omitempty"`
+	// Arguments is a JSON string of the arguments to pass to the function.
+	Arguments string `json:"arguments,omitempty"`
+}
+
+// ResponseUsage represents the token usage of a [Response].
+// https://platform.openai.com/docs/api-reference/responses/object#responses/object-usage
+type ResponseUsage struct {
+	// InputTokens is the number of input tokens.
+	InputTokens int `json:"input_tokens"` //nolint:tagliatelle //follow openai api
+	// InputTokensDetails is a detailed breakdown of the input tokens.
+	InputTokensDetails ResponseInputTokensDetails `json:"input_tokens_details"` //nolint:tagliatelle //follow openai api
+	// OutputTokens is the number of output tokens.
+	OutputTokens int `json:"output_tokens"` //nolint:tagliatelle //follow openai api
+	// OutputTokensDetails is a detailed breakdown of the output tokens.
+	OutputTokensDetails ResponseOutputTokensDetails `json:"output_tokens_details"` //nolint:tagliatelle //follow openai api
+	// TotalTokens is the total number of tokens used.
+	TotalTokens int `json:"total_tokens"` //nolint:tagliatelle //follow openai api
+}
+
+// ResponseInputTokensDetails is a detailed breakdown of the input tokens.
+type ResponseInputTokensDetails struct {
+	// CachedTokens is the number of tokens that were retrieved from the cache.
+	CachedTokens int `json:"cached_tokens"` //nolint:tagliatelle //follow openai api
+}
+
+// ResponseOutputTokensDetails is a detailed breakdown of the output tokens.
+type ResponseOutputTokensDetails struct {
+	// ReasoningTokens is the number of reasoning tokens.
+	ReasoningTokens int `json:"reasoning_tokens"` //nolint:tagliatelle //follow openai api
+}
+
+// ResponseIncompleteDetails holds the details about why the response is incomplete.
+type ResponseIncompleteDetails struct {
+	// Reason is the reason why the response is incomplete, e.g. "max_output_tokens" or "content_filter".
+	Reason string `json:"reason"`
+}
+
+// ResponseError is the error object returned when the model fails to generate a [Response].
+type ResponseError struct {
+	// Code is the error code for the response.
+	Code string `json:"code"`
+	// Message is a human-readable description of the error.
+	Message string `json:"message"`
+}
+
+// ResponseStreamEvent is a server-sent event emitted when streaming a response.
+// Only the fields relevant to the event Type are set.
+// https://platform.openai.com/docs/api-reference/responses-streaming
+type ResponseStreamEvent struct {
+	// Type is the type of the event, e.g. "response.output_text.delta".
+	Type string `json:"type"`
+	// SequenceNumber is the sequence number of this event.
+	SequenceNumber int `json:"sequence_number"` //nolint:tagliatelle //follow openai api
+	// Response is set for response.created, response.in_progress, response.completed and similar events.
+	Response *Response `json:"response,omitempty"`
+	// OutputIndex is the index of the output item that this event relates to.
+	OutputIndex *int `json:"output_index,omitempty"` //nolint:tagliatelle //follow openai api
+	// ContentIndex is the index of the content part that this event relates to.
+	ContentIndex *int `json:"content_index,omitempty"` //nolint:tagliatelle //follow openai api
+	// ItemID is the ID of the output item that this event relates to.
+	ItemID string `json:"item_id,omitempty"` //nolint:tagliatelle //follow openai api
+	// Item is set for response.output_item.added and response.output_item.done.
+	Item *ResponseOutputItem `json:"item,omitempty"`
+	// Part is set for response.content_part.added and response.content_part.done.
+	Part *ResponseContentPart `json:"part,omitempty"`
+	// Delta is the text or arguments delta.
+	Delta string `json:"delta,omitempty"`
+	// Text is the final text for response.output_text.done.
+	Text string `json:"text,omitempty"`
+	// Arguments is the final arguments for response.function_call_arguments.done.
+	Arguments string `json:"arguments,omitempty"`
+	// Code is the error code for the error event.
+	Code string `json:"code,omitempty"`
+	// Message is the error message for the error event.
+	Message string `json:"message,omitempty"`
+}
@@ -0,0 +1,106 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package openai
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestResponseRequest_Unmarshal(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		in     string
+		out    ResponseRequest
+		expErr string
+	}{
+		{
+			name: "string input",
+			in:   `{"model":"gpt-4.1","input":"hello","instructions":"be nice","max_output_tokens":10,"stream":true}`,
+			out: ResponseRequest{
+				Model:           "gpt-4.1",
+				Input:           ResponseInput{Text: ptr.To("hello")},
+				Instructions:    "be nice",
+				MaxOutputTokens: ptr.To[int64](10),
+				Stream:          true,
+			},
+		},
+		{
+			name: "item input",
+			in: `{"model":"gpt-4.1","input":[
+{"role":"user","content":"hi"},
+{"type":"message","role":"user","content":[{"type":"input_text","text":"what is this"},{"type":"input_image","image_url":"https://example.com/a.png"}]},
+{"type":"function_call","call_id":"call_1","name":"get_weather","arguments":"{}"},
+{"type":"function_call_output","call_id":"call_1","output":"sunny"}
+],"tools":[{"type":"function","name":"get_weather","parameters":{"type":"object"}}]}`,
+			out: ResponseRequest{
+				Model: "gpt-4.1",
+				Input: ResponseInput{Items: []ResponseInputItem{
+					{Role: "user", Content: &ResponseMessageContent{Text: ptr.To("hi")}},
+					{Type: ResponseItemTypeMessage, Role: "user", Content: &ResponseMessageContent{Parts: []ResponseContentPart{
+						{Type: ResponseContentTypeInputText, Text: "what is this"},
+						{Type: ResponseContentTypeInputImage, ImageURL: "https://example.com/a.png"},
+					}}},
+					{Type: ResponseItemTypeFunctionCall, CallID: "call_1", Name: "get_weather", Arguments: "{}"},
+					{Type: ResponseItemTypeFunctionCallOutput, CallID: "call_1", Output: "sunny"},
+				}},
+				Tools: []ResponseTool{{Type: "function", Name: "get_weather", Parameters: map[string]any{"type": "object"}}},
+			},
+		},
+		{
+			name:   "invalid input",
+			in:     `{"model":"gpt-4.1","input":123}`,
+			expErr: "cannot unmarshal input as string or array of input items",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var req ResponseRequest
+			err := json.Unmarshal([]byte(tc.in), &req)
+			if tc.expErr != "" {
+				require.ErrorContains(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.out, req)
+		})
+	}
+}
+
+func TestResponseRequest_MarshalRoundTrip(t *testing.T) {
+	for _, in := range []string{
+		`{"model":"gpt-4.1","input":"hello"}`,
+		`{"model":"gpt-4.1","input":[{"role":"user","content":[{"type":"input_text","text":"hi"}]}]}`,
+	} {
+		var req ResponseRequest
+		require.NoError(t, json.Unmarshal([]byte(in), &req))
+		out, err := json.Marshal(req)
+		require.NoError(t, err)
+		require.JSONEq(t, in, string(out))
+	}
+}
+
+func TestResponse_Unmarshal(t *testing.T) {
+	const in = `{
+"id":"resp_1","object":"response","created_at":1741476542,"status":"completed","model":"gpt-4.1",
+"output":[{"type":"message","id":"msg_1","status":"completed","role":"assistant","content":[{"type":"output_text","text":"hi","annotations":[]}]}],
+"usage":{"input_tokens":10,"input_tokens_details":{"cached_tokens":2},"output_tokens":5,"output_tokens_details":{"reasoning_tokens":1},"total_tokens":15}
+}`
+	var resp Response
+	require.NoError(t, json.Unmarshal([]byte(in), &resp))
+	require.Equal(t, "resp_1", resp.ID)
+	require.Len(t, resp.Output, 1)
+	require.Equal(t, "hi", resp.Output[0].Content[0].Text)
+	require.Equal(t, &ResponseUsage{
+		InputTokens:         10,
+		InputTokensDetails:  ResponseInputTokensDetails{CachedTokens: 2},
+		OutputTokens:        5,
+		OutputTokensDetails: ResponseOutputTokensDetails{ReasoningTokens: 1},
+		TotalTokens:         15,
+	}, resp.Usage)
+}
@@ -0,0 +1,207 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package bodyheader projects the fields of the request body into the request headers so that the
+// routing rules can match other attributes of the request body than the model name.
+//
+// This exists as a separate package to be used both in the controller to validate the expressions
+// and in the external processor to evaluate them.
+package bodyheader
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"k8s.io/client-go/util/jsonpath"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+const (
+	celRequestKey               = "request"
+	celEstimatedPromptTokensKey = "estimated_prompt_tokens"
+)
+
+var env *cel.Env
+
+func init() {
+	var err error
+	env, err = cel.NewEnv(
+		cel.Variable(celRequestKey, cel.DynType),
+		cel.Variable(celEstimatedPromptTokensKey, cel.UintType),
+	)
+	if err != nil {
+		panic(fmt.Sprintf("cannot create CEL environment: %v", err))
+	}
+}
+
+// NewCELProgram creates a new CEL program from the given expression.
+func NewCELProgram(expr string) (cel.Program, error) {
+	ast, issues := env.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("cannot compile CEL expression: %w", issues.Err())
+	}
+	prog, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create CEL program: %w", err)
+	}
+	return prog, nil
+}
+
+// NewJSONPath parses the given JSONPath expression, e.g. "$.response_format.type" or "{.response_format.type}".
+func NewJSONPath(expr string) (*jsonpath.JSONPath, error) {
+	if !strings.HasPrefix(expr, "{") {
+		expr = "{" + expr + "}"
+	}
+	j := jsonpath.New("").AllowMissingKeys(true)
+	if err := j.Parse(expr); err != nil {
+		return nil, fmt.Errorf("cannot parse JSONPath expression: %w", err)
+	}
+	return j, nil
+}
+
+// Projector projects the fields of the request body into the request headers.
+type Projector struct {
+	headers []header
+}
+
+type header struct {
+	name    string
+	celProg cel.Program
+	// jsonPath is not safe for the concurrent use, so it is guarded by mux.
+	jsonPath *jsonpath.JSONPath
+	mux      sync.Mutex
+}
+
+// New creates a new Projector from the given configurations. This returns nil when no header is configured.
+func New(configs []filterapi.BodyHeader) (*Projector, error) {
+	if len(configs) == 0 {
+		return nil, nil
+	}
+	p := &Projector{headers: make([]header, len(configs))}
+	for i := range configs {
+		c := &configs[i]
+		h := &p.headers[i]
+		h.name = c.Name
+		var err error
+		switch {
+		case c.CEL != "":
+			h.celProg, err = NewCELProgram(c.CEL)
+		case c.JSONPath != "":
+			h.jsonPath, err = NewJSONPath(c.JSONPath)
+		default:
+			err = fmt.Errorf("no expression")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid body header %s: %w", c.Name, err)
+		}
+	}
+	return p, nil
+}
+
+// Names returns the names of the projected headers.
+func (p *Projector) Names() []string {
+	names := make([]string, len(p.headers))
+	for i := range p.headers {
+		names[i] = p.headers[i].name
+	}
+	return names
+}
+
+// Project evaluates the expressions against the given JSON request body, and returns the values of the headers
+// keyed by the name. The headers are omitted when the expression fails, or the result is null or not found.
+// All the headers are omitted when the body is not a JSON object.
+//
+// The estimated number of the tokens of the prompt is given to the CEL expressions as it is, since it depends on
+// the endpoint of the request body.
+func (p *Projector) Project(body []byte, estimatedPromptTokens uint64) map[string]string {
+	var request map[string]any
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil
+	}
+	ret := make(map[string]string, len(p.headers))
+	for i := range p.headers {
+		h := &p.headers[i]
+		var (
+			value string
+			ok    bool
+		)
+		if h.celProg != nil {
+			value, ok = evalCEL(h.celProg, request, estimatedPromptTokens)
+		} else {
+			h.mux.Lock()
+			value, ok = evalJSONPath(h.jsonPath, request)
+			h.mux.Unlock()
+		}
+		if ok {
+			ret[h.name] = value
+		}
+	}
+	return ret
+}
+
+func evalCEL(prog cel.Program, request map[string]any, estimatedPromptTokens uint64) (string, bool) {
+	out, _, err := prog.Eval(map[string]any{
+		celRequestKey:               request,
+		celEstimatedPromptTokensKey: estimatedPromptTokens,
+	})
+	if err != nil || out == nil {
+		return "", false
+	}
+	switch v := out.(type) {
+	case types.String:
+		return string(v), true
+	case types.Bool:
+		return strconv.FormatBool(bool(v)), true
+	case types.Int:
+		return strconv.FormatInt(int64(v), 10), true
+	case types.Uint:
+		return strconv.FormatUint(uint64(v), 10), true
+	case types.Double:
+		return strconv.FormatFloat(float64(v), 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+func evalJSONPath(j *jsonpath.JSONPath, request map[string]any) (string, bool) {
+	results, err := j.FindResults(request)
+	if err != nil {
+		return "", false
+	}
+	var values []string
+	for _, rs := range results {
+		for _, r := range rs {
+			if r.Kind() == reflect.Interface {
+				r = r.Elem()
+			}
+			if !r.IsValid() {
+				continue
+			}
+			switch r.Kind() {
+			case reflect.String:
+				values = append(values, r.String())
+			case reflect.Float64:
+				values = append(values, strconv.FormatFloat(r.Float(), 'f', -1, 64))
+			default:
+				b, err := json.Marshal(r.Interface())
+				if err != nil {
+					continue
+				}
+				values = append(values, string(b))
+			}
+		}
+	}
+	if len(values) == 0 {
+		return "", false
+	}
+	return strings.Join(values, ","), true
+}
@@ -0,0 +1,99 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package bodyheader
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+const chatBody = `{
+  "model": "gpt-4o",
+  "reasoning_effort": "high",
+  "response_format": {"type": "json_schema"},
+  "temperature": 0.7,
+  "max_tokens": 100,
+  "messages": [
+    {"role": "system", "content": "You are helpful."},
+    {"role": "user", "content": [
+      {"type": "text", "text": "What is in this image?"},
+      {"type": "image_url", "image_url": {"url": "data:image/png;base64,AAAAAAAAAAAAAAAAAAAAAAAAAAAA"}}
+    ]}
+  ],
+  "tools": [{"type": "function", "function": {"name": "get_weather"}}]
+}`
+
+func TestNew(t *testing.T) {
+	p, err := New(nil)
+	require.NoError(t, err)
+	require.Nil(t, p)
+
+	_, err = New([]filterapi.BodyHeader{{Name: "x-a", CEL: "request."}})
+	require.ErrorContains(t, err, "invalid body header x-a: cannot compile CEL expression")
+	_, err = New([]filterapi.BodyHeader{{Name: "x-a", JSONPath: "$.a["}})
+	require.ErrorContains(t, err, "invalid body header x-a: cannot parse JSONPath expression")
+	_, err = New([]filterapi.BodyHeader{{Name: "x-a"}})
+	require.ErrorContains(t, err, "invalid body header x-a: no expression")
+
+	p, err = New([]filterapi.BodyHeader{{Name: "x-a", CEL: "'a'"}, {Name: "x-b", JSONPath: "$.b"}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"x-a", "x-b"}, p.Names())
+}
+
+func TestProjector_Project(t *testing.T) {
+	p, err := New([]filterapi.BodyHeader{
+		{Name: "x-has-tools", CEL: "has(request.tools) && size(request.tools) > 0"},
+		{Name: "x-has-image", CEL: "request.messages.exists(m, type(m.content) == list && m.content.exists(c, c.type == 'image_url'))"},
+		{Name: "x-has-audio", CEL: "request.messages.exists(m, type(m.content) == list && m.content.exists(c, c.type == 'input_audio'))"},
+		{Name: "x-response-format", JSONPath: "$.response_format.type"},
+		{Name: "x-reasoning-effort", JSONPath: "{.reasoning_effort}"},
+		{Name: "x-prompt-size", CEL: "estimated_prompt_tokens > uint(10) ? 'long' : 'short'"},
+		{Name: "x-prompt-tokens", CEL: "estimated_prompt_tokens"},
+		{Name: "x-temperature", JSONPath: "$.temperature"},
+		{Name: "x-temperature-cel", CEL: "request.temperature"},
+		{Name: "x-max-tokens", CEL: "int(request.max_tokens)"},
+		{Name: "x-tool-names", JSONPath: "$.tools[*].function.name"},
+		{Name: "x-missing", JSONPath: "$.missing"},
+		{Name: "x-missing-cel", CEL: "request.missing"},
+		{Name: "x-list", CEL: "request.tools"},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]string{
+		"x-has-tools":        "true",
+		"x-has-image":        "true",
+		"x-has-audio":        "false",
+		"x-response-format":  "json_schema",
+		"x-reasoning-effort": "high",
+		"x-prompt-size":      "long",
+		"x-prompt-tokens":    "20",
+		"x-temperature":      "0.7",
+		"x-temperature-cel":  "0.7",
+		"x-max-tokens":       "100",
+		"x-tool-names":       "get_weather",
+	}, p.Project([]byte(chatBody), 20))
+
+	require.Nil(t, p.Project([]byte("not json"), 0))
+	require.Equal(t, map[string]string{"x-has-tools": "false", "x-prompt-size": "short", "x-prompt-tokens": "0"}, p.Project([]byte(`{}`), 0))
+}
+
+func TestProjector_Project_concurrent(t *testing.T) {
+	p, err := New([]filterapi.BodyHeader{{Name: "x-format", JSONPath: "$.response_format.type"}})
+	require.NoError(t, err)
+	var wg sync.WaitGroup
+	wg.Add(100)
+	for range 100 {
+		go func() {
+			defer wg.Done()
+			require.Equal(t, map[string]string{"x-format": "json_schema"}, p.Project([]byte(chatBody), 0))
+		}()
+	}
+	wg.Wait()
+}
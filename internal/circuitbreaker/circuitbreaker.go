@@ -0,0 +1,216 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package circuitbreaker provides the circuit breakers of the AIServiceBackends enforced by the AI Gateway filter.
+//
+// A circuit breaker is closed while the backend is healthy. The consecutive failures of the backend open it, after
+// which the requests to the backend are rejected. After the open duration, it becomes half-open and lets a limited
+// number of the probe requests through. It is closed again when all of them succeed, and opened again on the first
+// failure.
+package circuitbreaker
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+// State is the state of a circuit breaker.
+type State int
+
+const (
+	// StateClosed lets all the requests through.
+	StateClosed State = iota
+	// StateHalfOpen lets the limited number of the probe requests through.
+	StateHalfOpen
+	// StateOpen rejects all the requests.
+	StateOpen
+)
+
+// String implements [fmt.Stringer].
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "open"
+	}
+}
+
+// Breaker is the circuit breaker of a single AIServiceBackend shared by the backends of the route rules referencing it.
+type Breaker struct {
+	config *filterapi.CircuitBreaker
+	now    func() time.Time
+
+	mu    sync.Mutex
+	state State
+	// failures is the number of the consecutive failures while closed.
+	failures int
+	// since is when the breaker was opened, or when the probes were let through while half-open.
+	since time.Time
+	// probes is the number of the probe requests let through while half-open, and succeeded is the number of them
+	// which succeeded.
+	probes, succeeded int
+}
+
+// New creates a new [Breaker] from the given configuration.
+func New(config *filterapi.CircuitBreaker) *Breaker {
+	return &Breaker{config: config, now: time.Now}
+}
+
+// Name returns the name of the AIServiceBackend.
+func (b *Breaker) Name() string { return b.config.Name }
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh()
+	return b.state
+}
+
+// Allow returns true if the request to the backend is let through. While half-open, this counts the request as
+// a probe, whose outcome must be reported by either Success or Failure.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh()
+	switch b.state {
+	case StateClosed:
+		return true
+	case StateHalfOpen:
+		if b.probes < b.config.HalfOpenProbes {
+			b.probes++
+			return true
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// Success reports the successful response of the backend.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case StateClosed:
+		b.failures = 0
+	case StateHalfOpen:
+		b.succeeded++
+		if b.succeeded >= b.config.HalfOpenProbes {
+			b.state, b.failures = StateClosed, 0
+		}
+	}
+}
+
+// Failure reports the error response of the backend of the given class. The errors of the classes not counted by
+// the breaker only release the probe while half-open.
+func (b *Breaker) Failure(class filterapi.CircuitBreakerErrorClass) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !slices.Contains(b.config.FailOn, class) {
+		if b.state == StateHalfOpen && b.probes > b.succeeded {
+			b.probes--
+		}
+		return
+	}
+	switch b.state {
+	case StateClosed:
+		b.failures++
+		if b.failures >= b.config.ConsecutiveFailures {
+			b.open()
+		}
+	case StateHalfOpen:
+		b.open()
+	}
+}
+
+// open opens the breaker. This must be called with the lock held.
+func (b *Breaker) open() {
+	b.state, b.since = StateOpen, b.now()
+}
+
+// refresh makes the open breaker half-open after the open duration. The probes which haven't completed within the
+// open duration, e.g. because the requests were cancelled, are let through again. This must be called with the lock
+// held.
+func (b *Breaker) refresh() {
+	now := b.now()
+	if now.Before(b.since.Add(b.config.OpenDuration)) {
+		return
+	}
+	switch b.state {
+	case StateOpen:
+		b.state, b.since, b.probes, b.succeeded = StateHalfOpen, now, 0, 0
+	case StateHalfOpen:
+		b.since, b.probes = now, b.succeeded
+	}
+}
+
+// Set is the set of the circuit breakers keyed by the names of the backends of the route rules.
+type Set struct {
+	breakers  []*Breaker
+	byBackend map[string]*Breaker
+}
+
+// NewSet creates a new [Set] from the given configurations. This returns nil if there's no configuration.
+// The breakers of the previous set whose settings are not changed are reused so that their states are kept across
+// the configuration updates, even when the route rules referencing the AIServiceBackends are changed.
+func NewSet(configs []filterapi.CircuitBreaker, prev *Set) *Set {
+	if len(configs) == 0 {
+		return nil
+	}
+	s := &Set{byBackend: make(map[string]*Breaker)}
+	for i := range configs {
+		c := &configs[i]
+		var b *Breaker
+		if prev != nil {
+			for _, pb := range prev.breakers {
+				if sameSettings(pb.config, c) {
+					b = pb
+					break
+				}
+			}
+		}
+		if b == nil {
+			b = New(c)
+		}
+		s.breakers = append(s.breakers, b)
+		for _, name := range c.Backends {
+			s.byBackend[name] = b
+		}
+	}
+	return s
+}
+
+// sameSettings returns true if the given configurations are the same except for the backends.
+func sameSettings(a, b *filterapi.CircuitBreaker) bool {
+	ac, bc := *a, *b
+	ac.Backends, bc.Backends = nil, nil
+	return reflect.DeepEqual(ac, bc)
+}
+
+// Find returns the breaker of the given backend, or nil if there's none or the set is nil.
+func (s *Set) Find(backend string) *Breaker {
+	if s == nil {
+		return nil
+	}
+	return s.byBackend[backend]
+}
+
+// States calls the given function with the name of the AIServiceBackend and the current state of each breaker.
+func (s *Set) States(f func(name string, state State)) {
+	if s == nil {
+		return
+	}
+	for _, b := range s.breakers {
+		f(b.Name(), b.State())
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package circuitbreaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func newTestBreaker(probes int) (*Breaker, *time.Time) {
+	now := time.Unix(1000, 0)
+	b := New(&filterapi.CircuitBreaker{
+		Name:                "vertex.ns",
+		ConsecutiveFailures: 2,
+		FailOn:              []filterapi.CircuitBreakerErrorClass{filterapi.CircuitBreakerErrorClassThrottled},
+		OpenDuration:        30 * time.Second,
+		HalfOpenProbes:      probes,
+	})
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestBreaker(t *testing.T) {
+	b, now := newTestBreaker(2)
+	require.Equal(t, "vertex.ns", b.Name())
+	require.Equal(t, StateClosed, b.State())
+
+	// The success resets the consecutive failures, and the errors of the other classes are not counted.
+	b.Failure(filterapi.CircuitBreakerErrorClassThrottled)
+	b.Success()
+	b.Failure(filterapi.CircuitBreakerErrorClassThrottled)
+	b.Failure(filterapi.CircuitBreakerErrorClassServerError)
+	b.Failure("")
+	require.Equal(t, StateClosed, b.State())
+	require.True(t, b.Allow())
+
+	b.Failure(filterapi.CircuitBreakerErrorClassThrottled)
+	require.Equal(t, StateOpen, b.State())
+	require.False(t, b.Allow())
+
+	// After the open duration, only the probes are let through.
+	*now = now.Add(30 * time.Second)
+	require.Equal(t, StateHalfOpen, b.State())
+	require.True(t, b.Allow())
+	require.True(t, b.Allow())
+	require.False(t, b.Allow())
+	b.Success()
+	require.Equal(t, StateHalfOpen, b.State())
+	b.Success()
+	require.Equal(t, StateClosed, b.State())
+	require.True(t, b.Allow())
+}
+
+func TestBreaker_halfOpenFailure(t *testing.T) {
+	b, now := newTestBreaker(1)
+	b.Failure(filterapi.CircuitBreakerErrorClassThrottled)
+	b.Failure(filterapi.CircuitBreakerErrorClassThrottled)
+	*now = now.Add(30 * time.Second)
+
+	// The error not counted by the breaker releases the probe.
+	require.True(t, b.Allow())
+	require.False(t, b.Allow())
+	b.Failure(filterapi.CircuitBreakerErrorClassServerError)
+	require.True(t, b.Allow())
+
+	b.Failure(filterapi.CircuitBreakerErrorClassThrottled)
+	require.Equal(t, StateOpen, b.State())
+	*now = now.Add(29 * time.Second)
+	require.False(t, b.Allow())
+	*now = now.Add(time.Second)
+	require.True(t, b.Allow())
+
+	// The probe which never completes is let through again after the open duration.
+	require.False(t, b.Allow())
+	*now = now.Add(30 * time.Second)
+	require.True(t, b.Allow())
+}
+
+func TestState_String(t *testing.T) {
+	require.Equal(t, "closed", StateClosed.String())
+	require.Equal(t, "half-open", StateHalfOpen.String())
+	require.Equal(t, "open", StateOpen.String())
+}
+
+func TestSet(t *testing.T) {
+	require.Nil(t, NewSet(nil, nil))
+	require.Nil(t, (*Set)(nil).Find("a"))
+	(*Set)(nil).States(func(string, State) { t.Fatal("must not be called") })
+
+	newConfigs := func(failures int, backends ...string) []filterapi.CircuitBreaker {
+		return []filterapi.CircuitBreaker{
+			{
+				Name: "a.ns", Backends: backends, ConsecutiveFailures: failures, OpenDuration: time.Minute,
+				FailOn: []filterapi.CircuitBreakerErrorClass{filterapi.CircuitBreakerErrorClassThrottled},
+			},
+			{Name: "b.ns", Backends: []string{"ns/b/route/r/rule/0/ref/1"}, ConsecutiveFailures: 1},
+		}
+	}
+	s := NewSet(newConfigs(1, "ns/a/route/r/rule/0/ref/0", "ns/a/route/r2/rule/0/ref/0"), nil)
+	a := s.Find("ns/a/route/r/rule/0/ref/0")
+	require.Equal(t, "a.ns", a.Name())
+	require.Same(t, a, s.Find("ns/a/route/r2/rule/0/ref/0"))
+	require.Equal(t, "b.ns", s.Find("ns/b/route/r/rule/0/ref/1").Name())
+	require.Nil(t, s.Find("unknown"))
+
+	a.Failure(filterapi.CircuitBreakerErrorClassThrottled)
+	states := map[string]State{}
+	s.States(func(name string, state State) { states[name] = state })
+	require.Equal(t, map[string]State{"a.ns": StateOpen, "b.ns": StateClosed}, states)
+
+	// The breaker is kept when only the referencing route rules are changed.
+	next := NewSet(newConfigs(1, "ns/a/route/r/rule/0/ref/0"), s)
+	require.Same(t, a, next.Find("ns/a/route/r/rule/0/ref/0"))
+	require.Nil(t, next.Find("ns/a/route/r2/rule/0/ref/0"))
+	require.NotSame(t, a, NewSet(newConfigs(2, "ns/a/route/r/rule/0/ref/0"), s).Find("ns/a/route/r/rule/0/ref/0"))
+}
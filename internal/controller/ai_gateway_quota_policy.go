@@ -0,0 +1,109 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
+)
+
+// AIGatewayQuotaPolicyController implements [reconcile.TypedReconciler] for [aigv1a1.AIGatewayQuotaPolicy].
+//
+// The policies are translated into the filter configuration by the GatewayController, so this only
+// notifies the targeted AIGatewayRoutes about the changes.
+//
+// Exported for testing purposes.
+type AIGatewayQuotaPolicyController struct {
+	client             client.Client
+	kube               kubernetes.Interface
+	logger             logr.Logger
+	aiGatewayRouteChan chan event.GenericEvent
+}
+
+// NewAIGatewayQuotaPolicyController creates a new [reconcile.TypedReconciler] for [aigv1a1.AIGatewayQuotaPolicy].
+func NewAIGatewayQuotaPolicyController(client client.Client, kube kubernetes.Interface, logger logr.Logger, aiGatewayRouteChan chan event.GenericEvent) *AIGatewayQuotaPolicyController {
+	return &AIGatewayQuotaPolicyController{
+		client:             client,
+		kube:               kube,
+		logger:             logger,
+		aiGatewayRouteChan: aiGatewayRouteChan,
+	}
+}
+
+// Reconcile implements the [reconcile.TypedReconciler] for [aigv1a1.AIGatewayQuotaPolicy].
+func (c *AIGatewayQuotaPolicyController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var policy aigv1a1.AIGatewayQuotaPolicy
+	if err := c.client.Get(ctx, req.NamespacedName, &policy); err != nil {
+		if apierrors.IsNotFound(err) {
+			c.logger.Info("Deleting AIGatewayQuotaPolicy",
+				"namespace", req.Namespace, "name", req.Name)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	c.logger.Info("Reconciling AIGatewayQuotaPolicy", "namespace", req.Namespace, "name", req.Name)
+	// Propagate the policy deletion all the way to relevant Gateways.
+	if handleFinalizer(ctx, c.client, c.logger, &policy, c.syncAIGatewayQuotaPolicy) {
+		return ctrl.Result{}, nil
+	}
+	if err := c.syncAIGatewayQuotaPolicy(ctx, &policy); err != nil {
+		c.logger.Error(err, "failed to sync AIGatewayQuotaPolicy")
+		c.updateAIGatewayQuotaPolicyStatus(ctx, &policy, aigv1a1.ConditionTypeNotAccepted, err.Error())
+		return ctrl.Result{}, err
+	}
+	c.updateAIGatewayQuotaPolicyStatus(ctx, &policy, aigv1a1.ConditionTypeAccepted, "AIGatewayQuotaPolicy reconciled successfully")
+	return ctrl.Result{}, nil
+}
+
+// syncAIGatewayQuotaPolicy notifies the targeted AIGatewayRoutes so that the filter configuration is updated.
+func (c *AIGatewayQuotaPolicyController) syncAIGatewayQuotaPolicy(ctx context.Context, policy *aigv1a1.AIGatewayQuotaPolicy) error {
+	for _, targetRef := range policy.Spec.TargetRefs {
+		var aiGatewayRoute aigv1a1.AIGatewayRoute
+		err := c.client.Get(ctx, client.ObjectKey{
+			Name:      string(targetRef.Name),
+			Namespace: policy.Namespace, // targetRefs are local to the policy's namespace.
+		}, &aiGatewayRoute)
+		if err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("failed to get targeted AIGatewayRoute %s: %w", targetRef.Name, err)
+			}
+			c.logger.Info("Targeted AIGatewayRoute not found", "name", string(targetRef.Name), "namespace", policy.Namespace)
+			continue
+		}
+		c.logger.Info("Syncing AIGatewayRoute", "namespace", aiGatewayRoute.Namespace, "name", aiGatewayRoute.Name)
+		c.aiGatewayRouteChan <- event.GenericEvent{Object: &aiGatewayRoute}
+	}
+	return nil
+}
+
+// updateAIGatewayQuotaPolicyStatus updates the status of the AIGatewayQuotaPolicy.
+func (c *AIGatewayQuotaPolicyController) updateAIGatewayQuotaPolicyStatus(ctx context.Context, policy *aigv1a1.AIGatewayQuotaPolicy, conditionType string, message string) {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := c.client.Get(ctx, client.ObjectKey{Name: policy.Name, Namespace: policy.Namespace}, policy); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		policy.Status.Conditions = newConditions(conditionType, message)
+		return c.client.Status().Update(ctx, policy)
+	})
+	if err != nil {
+		c.logger.Error(err, "failed to update AIGatewayQuotaPolicy status",
+			"namespace", policy.Namespace, "name", policy.Name)
+	}
+}
@@ -8,6 +8,7 @@ package controller
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 
 	egv1a1 "github.com/envoyproxy/gateway/api/v1alpha1"
@@ -43,6 +44,10 @@ const (
 	egOwningGatewayNamespaceLabel                      = egAnnotationPrefix + "owning-gateway-namespace"
 	// apiKeyInSecret is the key to store OpenAI API key.
 	apiKeyInSecret = "apiKey"
+	// redisPasswordInSecret is the key to store the password of a Redis-compatible server.
+	redisPasswordInSecret = "password"
+	// redisCACertificateInSecret is the key to store the CA certificates of a Redis-compatible server.
+	redisCACertificateInSecret = "ca.crt"
 )
 
 // AIGatewayRouteController implements [reconcile.TypedReconciler].
@@ -94,6 +99,62 @@ func (c *AIGatewayRouteController) Reconcile(ctx context.Context, req reconcile.
 	return reconcile.Result{}, nil
 }
 
+// validateRouteWideFeatures returns an error if the given AIGatewayRoute has the features applied to the whole route
+// while any of its matches cannot be evaluated by the router filter, since the requests of such a match would bypass
+// the features. The policies are the names of the policies targeting the route, which are applied to it in the same
+// way. See routeExactModelMatches.
+func validateRouteWideFeatures(route *aigv1a1.AIGatewayRoute, policies ...string) error {
+	var features []string
+	if route.Spec.PIIRedaction != nil {
+		features = append(features, "PII redaction")
+	}
+	if route.Spec.Guardrail != nil {
+		features = append(features, "guardrail")
+	}
+	if route.Spec.ToolPolicy != nil {
+		features = append(features, "tool policy")
+	}
+	if slices.ContainsFunc(route.Spec.Rules, func(r aigv1a1.AIGatewayRouteRule) bool { return r.RequestPolicy != nil }) {
+		// The request policy of a rule is enforced by the matches of the rule, but the requests of the inexact matches
+		// of the other rules can still be routed to the rule by the precedence of the HTTPRoute rules.
+		features = append(features, "request policy")
+	}
+	features = append(features, policies...)
+	if len(features) == 0 {
+		return nil
+	}
+	if _, err := routeExactModelMatches(route); err != nil {
+		return fmt.Errorf("invalid %s: %w", strings.Join(features, ", "), err)
+	}
+	return nil
+}
+
+// targetingPolicies returns the names of the policies targeting the given AIGatewayRoute.
+func (c *AIGatewayRouteController) targetingPolicies(ctx context.Context, route *aigv1a1.AIGatewayRoute) ([]string, error) {
+	var quotaPolicies aigv1a1.AIGatewayQuotaPolicyList
+	if err := c.client.List(ctx, &quotaPolicies, client.InNamespace(route.Namespace),
+		client.MatchingFields{k8sClientIndexAIGatewayRouteToTargetingQuotaPolicy: fmt.Sprintf("%s.%s", route.Name, route.Namespace)}); err != nil {
+		return nil, fmt.Errorf("failed to list AIGatewayQuotaPolicy: %w", err)
+	}
+	var virtualKeyPolicies aigv1a1.AIGatewayVirtualKeyPolicyList
+	if err := c.client.List(ctx, &virtualKeyPolicies, client.InNamespace(route.Namespace),
+		client.MatchingFields{k8sClientIndexAIGatewayRouteToTargetingVirtualKeyPolicy: fmt.Sprintf("%s.%s", route.Name, route.Namespace)}); err != nil {
+		return nil, fmt.Errorf("failed to list AIGatewayVirtualKeyPolicy: %w", err)
+	}
+	var ret []string
+	for i := range quotaPolicies.Items {
+		if policy := &quotaPolicies.Items[i]; policy.DeletionTimestamp.IsZero() {
+			ret = append(ret, fmt.Sprintf("AIGatewayQuotaPolicy %s", policy.Name))
+		}
+	}
+	for i := range virtualKeyPolicies.Items {
+		if policy := &virtualKeyPolicies.Items[i]; policy.DeletionTimestamp.IsZero() {
+			ret = append(ret, fmt.Sprintf("AIGatewayVirtualKeyPolicy %s", policy.Name))
+		}
+	}
+	return ret, nil
+}
+
 func FilterConfigSecretPerGatewayName(gwName, gwNamespace string) string {
 	return fmt.Sprintf("%s-%s", gwName, gwNamespace)
 }
@@ -155,6 +216,15 @@ func (c *AIGatewayRouteController) syncAIGatewayRoute(ctx context.Context, aiGat
 		return nil
 	}
 
+	// Reject the route-wide features which the requests of this route could bypass before routing any traffic to it.
+	policies, err := c.targetingPolicies(ctx, aiGatewayRoute)
+	if err != nil {
+		return err
+	}
+	if err = validateRouteWideFeatures(aiGatewayRoute, policies...); err != nil {
+		return err
+	}
+
 	// Check if the static default HTTPRouteFilters exist per AIGatewayRoute.
 	filters := generateHTTPRouteFilters(aiGatewayRoute)
 	for _, base := range filters {
@@ -178,7 +248,7 @@ func (c *AIGatewayRouteController) syncAIGatewayRoute(ctx context.Context, aiGat
 	// Check if the HTTPRoute exists.
 	c.logger.Info("syncing AIGatewayRoute", "namespace", aiGatewayRoute.Namespace, "name", aiGatewayRoute.Name)
 	var httpRoute gwapiv1.HTTPRoute
-	err := c.client.Get(ctx, client.ObjectKey{Name: aiGatewayRoute.Name, Namespace: aiGatewayRoute.Namespace}, &httpRoute)
+	err = c.client.Get(ctx, client.ObjectKey{Name: aiGatewayRoute.Name, Namespace: aiGatewayRoute.Namespace}, &httpRoute)
 	existingRoute := err == nil
 	if apierrors.IsNotFound(err) {
 		// This means that this AIGatewayRoute is a new one.
@@ -231,6 +301,8 @@ func (c *AIGatewayRouteController) newHTTPRoute(ctx context.Context, dst *gwapiv
 		},
 	}}
 	rules := make([]gwapiv1.HTTPRouteRule, 0, len(aiGatewayRoute.Spec.Rules)+1) // +1 for the default rule.
+	// adaptiveRules are the rules with the adaptive load balancing, which are expanded after the default rule.
+	var adaptiveRules []gwapiv1.HTTPRouteRule
 	for i := range aiGatewayRoute.Spec.Rules {
 		rule := &aiGatewayRoute.Spec.Rules[i]
 		var backendRefs []gwapiv1.HTTPBackendRef
@@ -275,6 +347,9 @@ func (c *AIGatewayRouteController) newHTTPRoute(ctx context.Context, dst *gwapiv
 			Filters:     rewriteFilters,
 			Timeouts:    rule.GetTimeoutsOrDefault(),
 		})
+		if rule.AdaptiveLoadBalancing != nil {
+			adaptiveRules = append(adaptiveRules, newAdaptiveLoadBalancingHTTPRouteRules(aiGatewayRoute, i, rules[len(rules)-1])...)
+		}
 	}
 
 	rules = append(rules, gwapiv1.HTTPRouteRule{
@@ -289,6 +364,8 @@ func (c *AIGatewayRouteController) newHTTPRoute(ctx context.Context, dst *gwapiv
 			},
 		}},
 	})
+	// The indexes of these rules must be consistent with aigv1a1.AIGatewayRouteSpec.ResolveHTTPRouteRuleIndex.
+	rules = append(rules, adaptiveRules...)
 
 	dst.Spec.Rules = rules
 
@@ -316,6 +393,33 @@ func (c *AIGatewayRouteController) newHTTPRoute(ctx context.Context, dst *gwapiv
 	return nil
 }
 
+// newAdaptiveLoadBalancingHTTPRouteRules returns the HTTPRoute rules for the AIGatewayRoute rule at the given index
+// with the adaptive load balancing, one per backend reference. Each of them is the copy of the given rule that
+// additionally matches the backend selected by the AI Gateway filter in the internalapi.SelectedBackendHeaderKey
+// header. The extension server prefers the selected backend by lowering the priority of the others.
+func newAdaptiveLoadBalancingHTTPRouteRules(aiGatewayRoute *aigv1a1.AIGatewayRoute, ruleIndex int, base gwapiv1.HTTPRouteRule) []gwapiv1.HTTPRouteRule {
+	rule := &aiGatewayRoute.Spec.Rules[ruleIndex]
+	rules := make([]gwapiv1.HTTPRouteRule, 0, len(rule.BackendRefs))
+	for j := range rule.BackendRefs {
+		selected := gwapiv1.HTTPHeaderMatch{
+			Type:  ptr.To(gwapiv1.HeaderMatchExact),
+			Name:  internalapi.SelectedBackendHeaderKey,
+			Value: internalapi.PerRouteRuleRefBackendName(aiGatewayRoute.Namespace, rule.BackendRefs[j].Name, aiGatewayRoute.Name, ruleIndex, j),
+		}
+		var matches []gwapiv1.HTTPRouteMatch
+		for _, m := range base.Matches {
+			matches = append(matches, gwapiv1.HTTPRouteMatch{Headers: append(slices.Clone(m.Headers), selected)})
+		}
+		if len(matches) == 0 {
+			matches = []gwapiv1.HTTPRouteMatch{{Headers: []gwapiv1.HTTPHeaderMatch{selected}}}
+		}
+		r := base
+		r.Matches = matches
+		rules = append(rules, r)
+	}
+	return rules
+}
+
 // syncGateways synchronizes the gateways referenced by the AIGatewayRoute by sending events to the gateway controller.
 func (c *AIGatewayRouteController) syncGateways(ctx context.Context, aiGatewayRoute *aigv1a1.AIGatewayRoute) error {
 	for _, t := range aiGatewayRoute.Spec.TargetRefs {
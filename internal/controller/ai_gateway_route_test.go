@@ -13,6 +13,7 @@ import (
 	egv1a1 "github.com/envoyproxy/gateway/api/v1alpha1"
 	"github.com/go-logr/logr"
 	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	fake2 "k8s.io/client-go/kubernetes/fake"
@@ -26,6 +27,7 @@ import (
 	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
 	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
 	internaltesting "github.com/envoyproxy/ai-gateway/internal/testing"
 )
 
@@ -109,7 +111,9 @@ func requireNewFakeClientWithIndexes(t *testing.T) client.Client {
 	builder := fake.NewClientBuilder().WithScheme(Scheme).
 		WithStatusSubresource(&aigv1a1.AIGatewayRoute{}).
 		WithStatusSubresource(&aigv1a1.AIServiceBackend{}).
-		WithStatusSubresource(&aigv1a1.BackendSecurityPolicy{})
+		WithStatusSubresource(&aigv1a1.BackendSecurityPolicy{}).
+		WithStatusSubresource(&aigv1a1.AIGatewayQuotaPolicy{}).
+		WithStatusSubresource(&aigv1a1.AIGatewayVirtualKeyPolicy{})
 	err := ApplyIndexing(t.Context(), func(_ context.Context, obj client.Object, field string, extractValue client.IndexerFunc) error {
 		builder = builder.WithIndex(obj, field, extractValue)
 		return nil
@@ -188,6 +192,141 @@ func TestAIGatewayRouterController_syncAIGatewayRoute(t *testing.T) {
 		ok, _ = ctrlutil.HasOwnerReference(notFoundFilter.OwnerReferences, route, fakeClient.Scheme())
 		require.True(t, ok, "expected notFoundFilter to have owner reference to AIGatewayRoute")
 	})
+
+	t.Run("PII redaction without exact model matches", func(t *testing.T) {
+		route := &aigv1a1.AIGatewayRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "pii-route", Namespace: "ns1"},
+			Spec: aigv1a1.AIGatewayRouteSpec{
+				Rules: []aigv1a1.AIGatewayRouteRule{
+					{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "apple"}}},
+				},
+				PIIRedaction: &aigv1a1.AIGatewayRoutePIIRedaction{
+					Detectors: []aigv1a1.AIGatewayRoutePIIDetector{{Name: "email", Type: aigv1a1.AIGatewayRoutePIIDetectorTypeEmail}},
+				},
+			},
+		}
+		require.NoError(t, fakeClient.Create(t.Context(), route, &client.CreateOptions{}))
+		err := s.syncAIGatewayRoute(t.Context(), route)
+		require.ErrorContains(t, err, "invalid PII redaction: rule 0 has no matches")
+
+		// No traffic must be routed to the route.
+		var httpRoute gwapiv1.HTTPRoute
+		err = fakeClient.Get(t.Context(), client.ObjectKey{Name: "pii-route", Namespace: "ns1"}, &httpRoute)
+		require.True(t, apierrors.IsNotFound(err), "expected no HTTPRoute, got %v", err)
+	})
+
+	t.Run("tool policy without exact model matches", func(t *testing.T) {
+		route := &aigv1a1.AIGatewayRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "tool-route", Namespace: "ns1"},
+			Spec: aigv1a1.AIGatewayRouteSpec{
+				Rules: []aigv1a1.AIGatewayRouteRule{
+					{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "apple"}}},
+				},
+				ToolPolicy: &aigv1a1.AIGatewayRouteToolPolicy{AllowedFunctions: []string{"get_weather"}},
+			},
+		}
+		require.NoError(t, fakeClient.Create(t.Context(), route, &client.CreateOptions{}))
+		err := s.syncAIGatewayRoute(t.Context(), route)
+		require.ErrorContains(t, err, "invalid tool policy: rule 0 has no matches")
+
+		var httpRoute gwapiv1.HTTPRoute
+		err = fakeClient.Get(t.Context(), client.ObjectKey{Name: "tool-route", Namespace: "ns1"}, &httpRoute)
+		require.True(t, apierrors.IsNotFound(err), "expected no HTTPRoute, got %v", err)
+	})
+
+	t.Run("request policy with inexact model matches", func(t *testing.T) {
+		route := &aigv1a1.AIGatewayRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "request-policy-route", Namespace: "ns1"},
+			Spec: aigv1a1.AIGatewayRouteSpec{
+				Rules: []aigv1a1.AIGatewayRouteRule{
+					{
+						Matches: []aigv1a1.AIGatewayRouteRuleMatch{{Headers: []gwapiv1.HTTPHeaderMatch{
+							{Name: aigv1a1.AIModelHeaderKey, Value: "gpt-4o"},
+						}}},
+						BackendRefs:   []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "apple"}},
+						RequestPolicy: &aigv1a1.AIGatewayRouteRuleRequestPolicy{MaxN: &aigv1a1.AIGatewayRouteRuleRequestPolicyLimit{Max: 1}},
+					},
+					// The requests of this rule can be routed to the rule above by the precedence of the HTTPRoute rules.
+					{
+						Matches: []aigv1a1.AIGatewayRouteRuleMatch{{Headers: []gwapiv1.HTTPHeaderMatch{
+							{Type: ptr.To(gwapiv1.HeaderMatchRegularExpression), Name: aigv1a1.AIModelHeaderKey, Value: "gpt-.*"},
+						}}},
+						BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "apple"}},
+					},
+				},
+			},
+		}
+		require.NoError(t, fakeClient.Create(t.Context(), route, &client.CreateOptions{}))
+		err := s.syncAIGatewayRoute(t.Context(), route)
+		require.ErrorContains(t, err, "invalid request policy: match 0 of rule 1 must consist only of the exact header matches")
+
+		var httpRoute gwapiv1.HTTPRoute
+		err = fakeClient.Get(t.Context(), client.ObjectKey{Name: "request-policy-route", Namespace: "ns1"}, &httpRoute)
+		require.True(t, apierrors.IsNotFound(err), "expected no HTTPRoute, got %v", err)
+	})
+
+	t.Run("quota policy with inexact model matches", func(t *testing.T) {
+		route := &aigv1a1.AIGatewayRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "quota-route", Namespace: "ns1"},
+			Spec: aigv1a1.AIGatewayRouteSpec{
+				Rules: []aigv1a1.AIGatewayRouteRule{{
+					Matches: []aigv1a1.AIGatewayRouteRuleMatch{{Headers: []gwapiv1.HTTPHeaderMatch{
+						{Type: ptr.To(gwapiv1.HeaderMatchRegularExpression), Name: aigv1a1.AIModelHeaderKey, Value: "gpt-.*"},
+					}}},
+					BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "apple"}},
+				}},
+			},
+		}
+		require.NoError(t, fakeClient.Create(t.Context(), route, &client.CreateOptions{}))
+		require.NoError(t, fakeClient.Create(t.Context(), &aigv1a1.AIGatewayQuotaPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: "ns1"},
+			Spec: aigv1a1.AIGatewayQuotaPolicySpec{TargetRefs: []gwapiv1a2.LocalPolicyTargetReference{
+				{Group: "aigateway.envoyproxy.io", Kind: "AIGatewayRoute", Name: "quota-route"},
+			}},
+		}))
+		err := s.syncAIGatewayRoute(t.Context(), route)
+		require.ErrorContains(t, err, "invalid AIGatewayQuotaPolicy quota: match 0 of rule 0 must consist only of the exact header matches")
+
+		var httpRoute gwapiv1.HTTPRoute
+		err = fakeClient.Get(t.Context(), client.ObjectKey{Name: "quota-route", Namespace: "ns1"}, &httpRoute)
+		require.True(t, apierrors.IsNotFound(err), "expected no HTTPRoute, got %v", err)
+	})
+
+	t.Run("virtual key policy with inexact model matches", func(t *testing.T) {
+		route := &aigv1a1.AIGatewayRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "virtual-key-route", Namespace: "ns1"},
+			Spec: aigv1a1.AIGatewayRouteSpec{
+				Rules: []aigv1a1.AIGatewayRouteRule{
+					{
+						Matches: []aigv1a1.AIGatewayRouteRuleMatch{{Headers: []gwapiv1.HTTPHeaderMatch{
+							{Name: aigv1a1.AIModelHeaderKey, Value: "gpt-4o"},
+						}}},
+						BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "apple"}},
+					},
+					// The requests of this rule would be routed without the virtual key.
+					{
+						Matches: []aigv1a1.AIGatewayRouteRuleMatch{{Headers: []gwapiv1.HTTPHeaderMatch{
+							{Type: ptr.To(gwapiv1.HeaderMatchRegularExpression), Name: aigv1a1.AIModelHeaderKey, Value: "gpt-.*"},
+						}}},
+						BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "apple"}},
+					},
+				},
+			},
+		}
+		require.NoError(t, fakeClient.Create(t.Context(), route, &client.CreateOptions{}))
+		require.NoError(t, fakeClient.Create(t.Context(), &aigv1a1.AIGatewayVirtualKeyPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "keys", Namespace: "ns1"},
+			Spec: aigv1a1.AIGatewayVirtualKeyPolicySpec{TargetRefs: []gwapiv1a2.LocalPolicyTargetReference{
+				{Group: "aigateway.envoyproxy.io", Kind: "AIGatewayRoute", Name: "virtual-key-route"},
+			}},
+		}))
+		err := s.syncAIGatewayRoute(t.Context(), route)
+		require.ErrorContains(t, err, "invalid AIGatewayVirtualKeyPolicy keys: match 0 of rule 1 must consist only of the exact header matches")
+
+		var httpRoute gwapiv1.HTTPRoute
+		err = fakeClient.Get(t.Context(), client.ObjectKey{Name: "virtual-key-route", Namespace: "ns1"}, &httpRoute)
+		require.True(t, apierrors.IsNotFound(err), "expected no HTTPRoute, got %v", err)
+	})
 }
 
 func Test_newHTTPRoute(t *testing.T) {
@@ -390,6 +529,41 @@ func Test_buildPriorityAnnotation(t *testing.T) {
 	require.Equal(t, "0:orange:0,0:apple:1,0:pineapple:2", annotation)
 }
 
+func Test_newAdaptiveLoadBalancingHTTPRouteRules(t *testing.T) {
+	route := &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "ns"},
+		Spec: aigv1a1.AIGatewayRouteSpec{Rules: []aigv1a1.AIGatewayRouteRule{
+			{
+				BackendRefs:           []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "a"}, {Name: "b"}},
+				AdaptiveLoadBalancing: &aigv1a1.AIGatewayRouteRuleAdaptiveLoadBalancing{},
+			},
+		}},
+	}
+	modelMatch := gwapiv1.HTTPHeaderMatch{Name: aigv1a1.AIModelHeaderKey, Value: "m"}
+	selected := func(name string) gwapiv1.HTTPHeaderMatch {
+		return gwapiv1.HTTPHeaderMatch{Type: ptr.To(gwapiv1.HeaderMatchExact), Name: internalapi.SelectedBackendHeaderKey, Value: name}
+	}
+	base := gwapiv1.HTTPRouteRule{
+		BackendRefs: []gwapiv1.HTTPBackendRef{{BackendRef: gwapiv1.BackendRef{BackendObjectReference: gwapiv1.BackendObjectReference{Name: "a"}}}},
+		Matches:     []gwapiv1.HTTPRouteMatch{{Headers: []gwapiv1.HTTPHeaderMatch{modelMatch}}},
+		Timeouts:    &gwapiv1.HTTPRouteTimeouts{Request: ptr.To[gwapiv1.Duration]("60s")},
+	}
+	rules := newAdaptiveLoadBalancingHTTPRouteRules(route, 0, base)
+	require.Len(t, rules, 2)
+	for i, name := range []string{"ns/a/route/route/rule/0/ref/0", "ns/b/route/route/rule/0/ref/1"} {
+		require.Equal(t, base.BackendRefs, rules[i].BackendRefs)
+		require.Equal(t, base.Timeouts, rules[i].Timeouts)
+		require.Equal(t, []gwapiv1.HTTPRouteMatch{{Headers: []gwapiv1.HTTPHeaderMatch{modelMatch, selected(name)}}}, rules[i].Matches)
+	}
+	// The base rule is not modified.
+	require.Equal(t, []gwapiv1.HTTPHeaderMatch{modelMatch}, base.Matches[0].Headers)
+
+	// The rule without matches only matches the selected backend.
+	base.Matches = nil
+	rules = newAdaptiveLoadBalancingHTTPRouteRules(route, 0, base)
+	require.Equal(t, []gwapiv1.HTTPRouteMatch{{Headers: []gwapiv1.HTTPHeaderMatch{selected("ns/a/route/route/rule/0/ref/0")}}}, rules[0].Matches)
+}
+
 func TestAIGatewayRouteController_backend(t *testing.T) {
 	fakeClient := requireNewFakeClientWithIndexes(t)
 	kube := fake2.NewClientset()
@@ -0,0 +1,70 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	fake2 "k8s.io/client-go/kubernetes/fake"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
+	internaltesting "github.com/envoyproxy/ai-gateway/internal/testing"
+)
+
+func TestAIGatewayVirtualKeyPolicyController_Reconcile(t *testing.T) {
+	eventCh := internaltesting.NewControllerEventChan[*aigv1a1.AIGatewayRoute]()
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	c := NewAIGatewayVirtualKeyPolicyController(fakeClient, fake2.NewClientset(), ctrl.Log, eventCh.Ch)
+	const namespace = "default"
+
+	route := &aigv1a1.AIGatewayRoute{ObjectMeta: metav1.ObjectMeta{Name: "myroute", Namespace: namespace}}
+	require.NoError(t, fakeClient.Create(t.Context(), route))
+	require.NoError(t, fakeClient.Create(t.Context(), &aigv1a1.AIGatewayVirtualKeyPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "mypolicy", Namespace: namespace},
+		Spec: aigv1a1.AIGatewayVirtualKeyPolicySpec{
+			TargetRefs: []gwapiv1a2.LocalPolicyTargetReference{
+				{Group: "aigateway.envoyproxy.io", Kind: "AIGatewayRoute", Name: "myroute"},
+				// Non-existent routes are ignored.
+				{Group: "aigateway.envoyproxy.io", Kind: "AIGatewayRoute", Name: "nonexistent"},
+			},
+			Keys: []aigv1a1.AIGatewayVirtualKey{
+				{Name: "team-a", SecretRef: &gwapiv1.SecretObjectReference{Name: "team-a-key"}, TeamID: "team-a"},
+			},
+		},
+	}))
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: "mypolicy"}}
+	_, err := c.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	items := eventCh.RequireItemsEventually(t, 1)
+	require.Len(t, items, 1)
+	require.Equal(t, "myroute", items[0].Name)
+
+	var policy aigv1a1.AIGatewayVirtualKeyPolicy
+	require.NoError(t, fakeClient.Get(t.Context(), req.NamespacedName, &policy))
+	require.Len(t, policy.Status.Conditions, 1)
+	require.Equal(t, aigv1a1.ConditionTypeAccepted, policy.Status.Conditions[0].Type)
+	require.Equal(t, "AIGatewayVirtualKeyPolicy reconciled successfully", policy.Status.Conditions[0].Message)
+	require.Contains(t, policy.Finalizers, aiGatewayControllerFinalizer)
+
+	// The deletion is propagated to the targeted routes as well.
+	require.NoError(t, fakeClient.Delete(t.Context(), &policy))
+	_, err = c.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	items = eventCh.RequireItemsEventually(t, 1)
+	require.Equal(t, "myroute", items[0].Name)
+
+	// Not found is not an error.
+	_, err = c.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+}
@@ -10,7 +10,10 @@ import (
 	"fmt"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -29,15 +32,19 @@ type AIBackendController struct {
 	kube               kubernetes.Interface
 	logger             logr.Logger
 	aiGatewayRouteChan chan event.GenericEvent
+	// circuitBreakerStates returns the states of the circuit breakers enforced by the external processor of the
+	// given gateway pod. This is scrapeCircuitBreakerStates except in tests.
+	circuitBreakerStates func(ctx context.Context, pod *corev1.Pod) (map[string]int64, error)
 }
 
 // NewAIServiceBackendController creates a new [reconcile.TypedReconciler] for [aigv1a1.AIServiceBackend].
 func NewAIServiceBackendController(client client.Client, kube kubernetes.Interface, logger logr.Logger, aiGatewayRouteChan chan event.GenericEvent) *AIBackendController {
 	return &AIBackendController{
-		client:             client,
-		kube:               kube,
-		logger:             logger,
-		aiGatewayRouteChan: aiGatewayRouteChan,
+		client:               client,
+		kube:                 kube,
+		logger:               logger,
+		aiGatewayRouteChan:   aiGatewayRouteChan,
+		circuitBreakerStates: scrapeCircuitBreakerStates,
 	}
 }
 
@@ -111,7 +118,12 @@ func (c *AIBackendController) updateAIServiceBackendStatus(ctx context.Context,
 			}
 			return err
 		}
-		backend.Status.Conditions = newConditions(conditionType, message)
+		conditions := newConditions(conditionType, message)
+		// The state of the circuit breaker is updated separately by StartCircuitBreakerStatusUpdates.
+		if cb := meta.FindStatusCondition(backend.Status.Conditions, aigv1a1.ConditionTypeCircuitBreakerOpen); cb != nil && backend.Spec.CircuitBreaker != nil {
+			conditions = append([]metav1.Condition{*cb}, conditions...)
+		}
+		backend.Status.Conditions = conditions
 		return c.client.Status().Update(ctx, backend)
 	})
 	if err != nil {
@@ -0,0 +1,257 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
+)
+
+const (
+	// circuitBreakerStatusInterval is the interval of updating the CircuitBreakerOpen condition of AIServiceBackends.
+	circuitBreakerStatusInterval = 10 * time.Second
+	// circuitBreakerStateMetricName is the name of the gauge of the circuit breaker states exposed by the external
+	// processor. See internal/metrics/circuit_breaker_metrics.go.
+	circuitBreakerStateMetricName = "aigw_backend_circuit_breaker_state"
+	// circuitBreakerStateMetricBackendLabel is the label of the gauge holding the name of the AIServiceBackend.
+	circuitBreakerStateMetricBackendLabel = "aigw_backend_name"
+	// circuitBreakerStateOpen and circuitBreakerStateHalfOpen are the values of the gauge.
+	// See internal/circuitbreaker.State.
+	circuitBreakerStateHalfOpen = 1
+	circuitBreakerStateOpen     = 2
+)
+
+// StartCircuitBreakerStatusUpdates periodically updates the CircuitBreakerOpen condition of the AIServiceBackends
+// with the circuit breaker until the given context is cancelled.
+//
+// The circuit breakers are enforced by the external processors independently, so the states are collected from the
+// metrics of the external processors of all the gateway pods referencing the backend, and the breaker is reported
+// as open if it's open on any of them.
+func (c *AIBackendController) StartCircuitBreakerStatusUpdates(ctx context.Context) error {
+	ticker := time.NewTicker(circuitBreakerStatusInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			c.syncCircuitBreakerStatuses(ctx)
+		}
+	}
+}
+
+// syncCircuitBreakerStatuses updates the CircuitBreakerOpen condition of all the AIServiceBackends with the circuit
+// breaker.
+func (c *AIBackendController) syncCircuitBreakerStatuses(ctx context.Context) {
+	var backends aigv1a1.AIServiceBackendList
+	if err := c.client.List(ctx, &backends); err != nil {
+		c.logger.Error(err, "failed to list AIServiceBackends")
+		return
+	}
+	// The states scraped from each pod are cached since the pods are shared among the backends.
+	scraped := make(map[string]map[string]int64)
+	for i := range backends.Items {
+		backend := &backends.Items[i]
+		if backend.Spec.CircuitBreaker == nil {
+			continue
+		}
+		pods, err := c.circuitBreakerPods(ctx, backend)
+		if err != nil {
+			c.logger.Error(err, "failed to list the gateway pods of AIServiceBackend",
+				"namespace", backend.Namespace, "name", backend.Name)
+			continue
+		}
+		var states []int64
+		for j := range pods {
+			pod := &pods[j]
+			s, ok := scraped[pod.Status.PodIP]
+			if !ok {
+				s, err = c.circuitBreakerStates(ctx, pod)
+				if err != nil {
+					c.logger.Info("failed to get the circuit breaker states", "pod", pod.Name, "error", err.Error())
+				}
+				scraped[pod.Status.PodIP] = s
+			}
+			if state, ok := s[fmt.Sprintf("%s.%s", backend.Name, backend.Namespace)]; ok {
+				states = append(states, state)
+			}
+		}
+		c.updateCircuitBreakerCondition(ctx, backend, circuitBreakerCondition(states))
+	}
+}
+
+// circuitBreakerPods returns the running gateway pods of the Gateways referenced by the AIGatewayRoutes referencing
+// the given AIServiceBackend.
+func (c *AIBackendController) circuitBreakerPods(ctx context.Context, backend *aigv1a1.AIServiceBackend) ([]corev1.Pod, error) {
+	var aiGatewayRoutes aigv1a1.AIGatewayRouteList
+	key := fmt.Sprintf("%s.%s", backend.Name, backend.Namespace)
+	if err := c.client.List(ctx, &aiGatewayRoutes, client.MatchingFields{k8sClientIndexBackendToReferencingAIGatewayRoute: key}); err != nil {
+		return nil, fmt.Errorf("failed to list AIGatewayRouteList: %w", err)
+	}
+	var pods []corev1.Pod
+	seen := make(map[string]struct{})
+	for i := range aiGatewayRoutes.Items {
+		route := &aiGatewayRoutes.Items[i]
+		var gateways []string
+		for _, t := range route.Spec.TargetRefs {
+			gateways = append(gateways, string(t.Name))
+		}
+		for _, p := range route.Spec.ParentRefs {
+			gateways = append(gateways, string(p.Name))
+		}
+		for _, gw := range gateways {
+			gwKey := fmt.Sprintf("%s.%s", gw, route.Namespace)
+			if _, ok := seen[gwKey]; ok {
+				continue
+			}
+			seen[gwKey] = struct{}{}
+			ps, err := c.kube.CoreV1().Pods("").List(ctx, metav1.ListOptions{LabelSelector: fmt.Sprintf(
+				"%s=%s,%s=%s", egOwningGatewayNameLabel, gw, egOwningGatewayNamespaceLabel, route.Namespace,
+			)})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list pods: %w", err)
+			}
+			for _, pod := range ps.Items {
+				if pod.Status.Phase == corev1.PodRunning && pod.Status.PodIP != "" {
+					pods = append(pods, pod)
+				}
+			}
+		}
+	}
+	return pods, nil
+}
+
+// scrapeCircuitBreakerStates returns the states of the circuit breakers keyed by the name of the AIServiceBackend
+// scraped from the metrics endpoint of the external processor of the given pod.
+func scrapeCircuitBreakerStates(ctx context.Context, pod *corev1.Pod) (map[string]int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	url := fmt.Sprintf("http://%s/metrics", net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(extProcMetricsPort)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scrape metrics: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return parseCircuitBreakerStates(resp.Body)
+}
+
+// parseCircuitBreakerStates parses the states of the circuit breakers from the metrics in the Prometheus text format.
+func parseCircuitBreakerStates(r io.Reader) (map[string]int64, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse metrics: %w", err)
+	}
+	ret := make(map[string]int64)
+	family, ok := families[circuitBreakerStateMetricName]
+	if !ok {
+		return ret, nil
+	}
+	for _, m := range family.GetMetric() {
+		for _, l := range m.GetLabel() {
+			if l.GetName() == circuitBreakerStateMetricBackendLabel {
+				ret[l.GetValue()] = int64(m.GetGauge().GetValue())
+			}
+		}
+	}
+	return ret, nil
+}
+
+// circuitBreakerCondition returns the CircuitBreakerOpen condition for the given states of the circuit breaker on the
+// gateway pods.
+func circuitBreakerCondition(states []int64) metav1.Condition {
+	condition := metav1.Condition{Type: aigv1a1.ConditionTypeCircuitBreakerOpen}
+	if len(states) == 0 {
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = "NoData"
+		condition.Message = "The circuit breaker state is not reported by any gateway pod"
+		return condition
+	}
+	var open, halfOpen int
+	for _, s := range states {
+		switch s {
+		case circuitBreakerStateOpen:
+			open++
+		case circuitBreakerStateHalfOpen:
+			halfOpen++
+		}
+	}
+	switch {
+	case open > 0:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "Open"
+		condition.Message = fmt.Sprintf("The circuit breaker is open on %d of %d gateway pods", open, len(states))
+	case halfOpen > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "HalfOpen"
+		condition.Message = fmt.Sprintf("The circuit breaker is half-open on %d of %d gateway pods", halfOpen, len(states))
+	default:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "Closed"
+		condition.Message = fmt.Sprintf("The circuit breaker is closed on all %d gateway pods", len(states))
+	}
+	return condition
+}
+
+// updateCircuitBreakerCondition updates the CircuitBreakerOpen condition of the AIServiceBackend if it has changed.
+func (c *AIBackendController) updateCircuitBreakerCondition(ctx context.Context, backend *aigv1a1.AIServiceBackend, condition metav1.Condition) {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := c.client.Get(ctx, client.ObjectKey{Name: backend.Name, Namespace: backend.Namespace}, backend); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		if existing := meta.FindStatusCondition(backend.Status.Conditions, condition.Type); existing != nil &&
+			existing.Status == condition.Status && existing.Reason == condition.Reason && existing.Message == condition.Message {
+			return nil
+		}
+		backend.Status.Conditions = setCircuitBreakerCondition(backend.Status.Conditions, condition)
+		return c.client.Status().Update(ctx, backend)
+	})
+	if err != nil {
+		c.logger.Error(err, "failed to update AIServiceBackend status",
+			"namespace", backend.Namespace, "name", backend.Name)
+	}
+}
+
+// setCircuitBreakerCondition returns the conditions with the CircuitBreakerOpen condition replaced by the given one.
+// The condition is placed first so that the condition of the reconciliation result stays the last one, and the last
+// transition time is kept unless the status changes.
+func setCircuitBreakerCondition(conditions []metav1.Condition, condition metav1.Condition) []metav1.Condition {
+	condition.LastTransitionTime = metav1.Now()
+	ret := []metav1.Condition{condition}
+	for _, cond := range conditions {
+		if cond.Type != aigv1a1.ConditionTypeCircuitBreakerOpen {
+			ret = append(ret, cond)
+		} else if cond.Status == condition.Status {
+			ret[0].LastTransitionTime = cond.LastTransitionTime
+		}
+	}
+	return ret
+}
@@ -0,0 +1,161 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	fake2 "k8s.io/client-go/kubernetes/fake"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
+	internaltesting "github.com/envoyproxy/ai-gateway/internal/testing"
+)
+
+func TestAIServiceBackendController_syncCircuitBreakerStatuses(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	kube := fake2.NewClientset()
+	eventChan := internaltesting.NewControllerEventChan[*aigv1a1.AIGatewayRoute]()
+	c := NewAIServiceBackendController(fakeClient, kube, ctrl.Log, eventChan.Ch)
+	states := map[string]map[string]int64{
+		"10.0.0.1": {"vertex.default": circuitBreakerStateOpen},
+		"10.0.0.2": {"vertex.default": 0},
+	}
+	c.circuitBreakerStates = func(_ context.Context, pod *corev1.Pod) (map[string]int64, error) {
+		return states[pod.Status.PodIP], nil
+	}
+
+	require.NoError(t, fakeClient.Create(t.Context(), &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "myroute", Namespace: "default"},
+		Spec: aigv1a1.AIGatewayRouteSpec{
+			TargetRefs: []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{{
+				LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{
+					Name: "gtw", Kind: "Gateway", Group: "gateway.networking.k8s.io",
+				},
+			}},
+			Rules: []aigv1a1.AIGatewayRouteRule{{
+				Matches:     []aigv1a1.AIGatewayRouteRuleMatch{{}},
+				BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "vertex"}, {Name: "openai"}},
+			}},
+		},
+	}))
+	require.NoError(t, fakeClient.Create(t.Context(), &aigv1a1.AIServiceBackend{
+		ObjectMeta: metav1.ObjectMeta{Name: "vertex", Namespace: "default"},
+		Spec:       aigv1a1.AIServiceBackendSpec{CircuitBreaker: &aigv1a1.AIServiceBackendCircuitBreaker{}},
+	}))
+	require.NoError(t, fakeClient.Create(t.Context(), &aigv1a1.AIServiceBackend{
+		ObjectMeta: metav1.ObjectMeta{Name: "openai", Namespace: "default"},
+	}))
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+		_, err := kube.CoreV1().Pods("envoy-gateway-system").Create(t.Context(), &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "envoy-" + strings.ReplaceAll(ip, ".", "-"), Namespace: "envoy-gateway-system",
+				Labels: map[string]string{egOwningGatewayNameLabel: "gtw", egOwningGatewayNamespaceLabel: "default"},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, PodIP: ip},
+		}, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	requireCondition := func(t *testing.T, name string) *metav1.Condition {
+		var backend aigv1a1.AIServiceBackend
+		require.NoError(t, fakeClient.Get(t.Context(), client.ObjectKey{Name: name, Namespace: "default"}, &backend))
+		return meta.FindStatusCondition(backend.Status.Conditions, aigv1a1.ConditionTypeCircuitBreakerOpen)
+	}
+
+	// The circuit breaker is reported open when it is open on any of the pods.
+	c.syncCircuitBreakerStatuses(t.Context())
+	cond := requireCondition(t, "vertex")
+	require.NotNil(t, cond)
+	require.Equal(t, metav1.ConditionTrue, cond.Status)
+	require.Equal(t, "The circuit breaker is open on 1 of 2 gateway pods", cond.Message)
+	require.Nil(t, requireCondition(t, "openai"))
+
+	// The condition is kept by the reconciliation.
+	_, err := c.Reconcile(t.Context(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "vertex"}})
+	require.NoError(t, err)
+	var backend aigv1a1.AIServiceBackend
+	require.NoError(t, fakeClient.Get(t.Context(), client.ObjectKey{Name: "vertex", Namespace: "default"}, &backend))
+	require.Len(t, backend.Status.Conditions, 2)
+	require.Equal(t, aigv1a1.ConditionTypeCircuitBreakerOpen, backend.Status.Conditions[0].Type)
+	require.Equal(t, aigv1a1.ConditionTypeAccepted, backend.Status.Conditions[1].Type)
+
+	states["10.0.0.1"]["vertex.default"] = 0
+	c.syncCircuitBreakerStatuses(t.Context())
+	cond = requireCondition(t, "vertex")
+	require.Equal(t, metav1.ConditionFalse, cond.Status)
+	require.Equal(t, "Closed", cond.Reason)
+}
+
+func Test_parseCircuitBreakerStates(t *testing.T) {
+	states, err := parseCircuitBreakerStates(strings.NewReader(`# HELP aigw_backend_circuit_breaker_state The state of the circuit breaker
+# TYPE aigw_backend_circuit_breaker_state gauge
+aigw_backend_circuit_breaker_state{aigw_backend_name="vertex.default",otel_scope_name="envoyproxy/ai-gateway"} 2
+aigw_backend_circuit_breaker_state{aigw_backend_name="anthropic.default",otel_scope_name="envoyproxy/ai-gateway"} 0
+# TYPE target_info gauge
+target_info{service_name="ai-gateway"} 1
+`))
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"vertex.default": 2, "anthropic.default": 0}, states)
+
+	states, err = parseCircuitBreakerStates(strings.NewReader(""))
+	require.NoError(t, err)
+	require.Empty(t, states)
+
+	_, err = parseCircuitBreakerStates(strings.NewReader("invalid metrics{"))
+	require.ErrorContains(t, err, "failed to parse metrics")
+}
+
+func Test_circuitBreakerCondition(t *testing.T) {
+	for _, tc := range []struct {
+		states    []int64
+		expStatus metav1.ConditionStatus
+		expReason string
+	}{
+		{states: nil, expStatus: metav1.ConditionUnknown, expReason: "NoData"},
+		{states: []int64{0, 0}, expStatus: metav1.ConditionFalse, expReason: "Closed"},
+		{states: []int64{0, circuitBreakerStateHalfOpen}, expStatus: metav1.ConditionFalse, expReason: "HalfOpen"},
+		{states: []int64{circuitBreakerStateHalfOpen, circuitBreakerStateOpen}, expStatus: metav1.ConditionTrue, expReason: "Open"},
+	} {
+		cond := circuitBreakerCondition(tc.states)
+		require.Equal(t, aigv1a1.ConditionTypeCircuitBreakerOpen, cond.Type)
+		require.Equal(t, tc.expStatus, cond.Status)
+		require.Equal(t, tc.expReason, cond.Reason)
+	}
+}
+
+func Test_setCircuitBreakerCondition(t *testing.T) {
+	past := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	accepted := metav1.Condition{Type: aigv1a1.ConditionTypeAccepted, Status: metav1.ConditionTrue}
+	conditions := setCircuitBreakerCondition([]metav1.Condition{accepted}, circuitBreakerCondition([]int64{0}))
+	require.Len(t, conditions, 2)
+	require.Equal(t, aigv1a1.ConditionTypeCircuitBreakerOpen, conditions[0].Type)
+	require.Equal(t, accepted, conditions[1])
+
+	// The last transition time is kept while the status is unchanged.
+	conditions[0].LastTransitionTime = past
+	conditions = setCircuitBreakerCondition(conditions, circuitBreakerCondition([]int64{circuitBreakerStateHalfOpen}))
+	require.Len(t, conditions, 2)
+	require.Equal(t, "HalfOpen", conditions[0].Reason)
+	require.Equal(t, past, conditions[0].LastTransitionTime)
+
+	conditions = setCircuitBreakerCondition(conditions, circuitBreakerCondition([]int64{circuitBreakerStateOpen}))
+	require.Len(t, conditions, 2)
+	require.Equal(t, metav1.ConditionTrue, conditions[0].Status)
+	require.NotEqual(t, past, conditions[0].LastTransitionTime)
+	require.Equal(t, accepted, conditions[1])
+}
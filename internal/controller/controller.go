@@ -122,6 +122,9 @@ func StartControllers(ctx context.Context, mgr manager.Manager, config *rest.Con
 		Complete(backendC); err != nil {
 		return fmt.Errorf("failed to create controller for AIServiceBackend: %w", err)
 	}
+	if err = mgr.Add(manager.RunnableFunc(backendC.StartCircuitBreakerStatusUpdates)); err != nil {
+		return fmt.Errorf("failed to add the circuit breaker status updates of AIServiceBackend: %w", err)
+	}
 
 	backendSecurityPolicyEventChan := make(chan event.GenericEvent, 100)
 	backendSecurityPolicyC := NewBackendSecurityPolicyController(c, kubernetes.NewForConfigOrDie(config), logger.
@@ -136,6 +139,25 @@ func StartControllers(ctx context.Context, mgr manager.Manager, config *rest.Con
 		return fmt.Errorf("failed to create controller for BackendSecurityPolicy: %w", err)
 	}
 
+	quotaPolicyC := NewAIGatewayQuotaPolicyController(c, kubernetes.NewForConfigOrDie(config), logger.
+		WithName("ai-gateway-quota-policy"), aiGatewayRouteEventChan)
+	if err = TypedControllerBuilderForCRD(mgr, &aigv1a1.AIGatewayQuotaPolicy{}).
+		Complete(quotaPolicyC); err != nil {
+		return fmt.Errorf("failed to create controller for AIGatewayQuotaPolicy: %w", err)
+	}
+
+	virtualKeyPolicyEventChan := make(chan event.GenericEvent, 100)
+	virtualKeyPolicyC := NewAIGatewayVirtualKeyPolicyController(c, kubernetes.NewForConfigOrDie(config), logger.
+		WithName("ai-gateway-virtual-key-policy"), aiGatewayRouteEventChan)
+	if err = TypedControllerBuilderForCRD(mgr, &aigv1a1.AIGatewayVirtualKeyPolicy{}).
+		WatchesRawSource(source.Channel(
+			virtualKeyPolicyEventChan,
+			&handler.EnqueueRequestForObject{},
+		)).
+		Complete(virtualKeyPolicyC); err != nil {
+		return fmt.Errorf("failed to create controller for AIGatewayVirtualKeyPolicy: %w", err)
+	}
+
 	// Check if InferencePool CRD exists before creating the controller.
 	crdClient, err := apiextensionsclientset.NewForConfig(config)
 	if err != nil {
@@ -163,7 +185,7 @@ func StartControllers(ctx context.Context, mgr manager.Manager, config *rest.Con
 	}
 
 	secretC := NewSecretController(c, kubernetes.NewForConfigOrDie(config), logger.
-		WithName("secret"), backendSecurityPolicyEventChan)
+		WithName("secret"), backendSecurityPolicyEventChan, virtualKeyPolicyEventChan)
 	// Do not use TypedControllerBuilderForCRD for secret, as changing a secret content doesn't change the generation.
 	if err = ctrl.NewControllerManagedBy(mgr).
 		For(&corev1.Secret{}).
@@ -217,6 +239,15 @@ const (
 	// k8sClientIndexAIServiceBackendToTargetingBackendSecurityPolicy is the index name that maps from an AIServiceBackend
 	// to the BackendSecurityPolicy whose targetRefs contains the AIServiceBackend.
 	k8sClientIndexAIServiceBackendToTargetingBackendSecurityPolicy = "AIServiceBackendToTargetingBackendSecurityPolicy"
+	// k8sClientIndexAIGatewayRouteToTargetingQuotaPolicy is the index name that maps from an AIGatewayRoute
+	// to the AIGatewayQuotaPolicy whose targetRefs contains the AIGatewayRoute.
+	k8sClientIndexAIGatewayRouteToTargetingQuotaPolicy = "AIGatewayRouteToTargetingQuotaPolicy"
+	// k8sClientIndexAIGatewayRouteToTargetingVirtualKeyPolicy is the index name that maps from an AIGatewayRoute
+	// to the AIGatewayVirtualKeyPolicy whose targetRefs contains the AIGatewayRoute.
+	k8sClientIndexAIGatewayRouteToTargetingVirtualKeyPolicy = "AIGatewayRouteToTargetingVirtualKeyPolicy"
+	// k8sClientIndexSecretToReferencingVirtualKeyPolicy is the index name that maps from a Secret
+	// to the AIGatewayVirtualKeyPolicy whose keys reference it.
+	k8sClientIndexSecretToReferencingVirtualKeyPolicy = "SecretToReferencingVirtualKeyPolicy"
 )
 
 // ApplyIndexing applies indexing to the given indexer. This is exported for testing purposes.
@@ -246,6 +277,21 @@ func ApplyIndexing(ctx context.Context, indexer func(ctx context.Context, obj cl
 	if err != nil {
 		return fmt.Errorf("failed to index field for BackendSecurityPolicy targetRefs: %w", err)
 	}
+	err = indexer(ctx, &aigv1a1.AIGatewayQuotaPolicy{},
+		k8sClientIndexAIGatewayRouteToTargetingQuotaPolicy, aiGatewayQuotaPolicyTargetRefsIndexFunc)
+	if err != nil {
+		return fmt.Errorf("failed to index field for AIGatewayQuotaPolicy targetRefs: %w", err)
+	}
+	err = indexer(ctx, &aigv1a1.AIGatewayVirtualKeyPolicy{},
+		k8sClientIndexAIGatewayRouteToTargetingVirtualKeyPolicy, aiGatewayVirtualKeyPolicyTargetRefsIndexFunc)
+	if err != nil {
+		return fmt.Errorf("failed to index field for AIGatewayVirtualKeyPolicy targetRefs: %w", err)
+	}
+	err = indexer(ctx, &aigv1a1.AIGatewayVirtualKeyPolicy{},
+		k8sClientIndexSecretToReferencingVirtualKeyPolicy, aiGatewayVirtualKeyPolicySecretsIndexFunc)
+	if err != nil {
+		return fmt.Errorf("failed to create index from Secret to AIGatewayVirtualKeyPolicy: %w", err)
+	}
 	return nil
 }
 
@@ -289,6 +335,9 @@ func backendSecurityPolicyIndexFunc(o client.Object) []string {
 	case aigv1a1.BackendSecurityPolicyTypeAPIKey:
 		apiKey := backendSecurityPolicy.Spec.APIKey
 		key = getSecretNameAndNamespace(apiKey.SecretRef, backendSecurityPolicy.Namespace)
+	case aigv1a1.BackendSecurityPolicyTypeAnthropicAPIKey:
+		apiKey := backendSecurityPolicy.Spec.AnthropicAPIKey
+		key = getSecretNameAndNamespace(apiKey.SecretRef, backendSecurityPolicy.Namespace)
 	case aigv1a1.BackendSecurityPolicyTypeAWSCredentials:
 		awsCreds := backendSecurityPolicy.Spec.AWSCredentials
 		if awsCreds.CredentialsFile != nil {
@@ -309,6 +358,34 @@ func backendSecurityPolicyTargetRefsIndexFunc(o client.Object) []string {
 	return ret
 }
 
+func aiGatewayQuotaPolicyTargetRefsIndexFunc(o client.Object) []string {
+	quotaPolicy := o.(*aigv1a1.AIGatewayQuotaPolicy)
+	var ret []string
+	for _, targetRef := range quotaPolicy.Spec.TargetRefs {
+		ret = append(ret, fmt.Sprintf("%s.%s", targetRef.Name, quotaPolicy.Namespace))
+	}
+	return ret
+}
+
+func aiGatewayVirtualKeyPolicyTargetRefsIndexFunc(o client.Object) []string {
+	virtualKeyPolicy := o.(*aigv1a1.AIGatewayVirtualKeyPolicy)
+	var ret []string
+	for _, targetRef := range virtualKeyPolicy.Spec.TargetRefs {
+		ret = append(ret, fmt.Sprintf("%s.%s", targetRef.Name, virtualKeyPolicy.Namespace))
+	}
+	return ret
+}
+
+func aiGatewayVirtualKeyPolicySecretsIndexFunc(o client.Object) []string {
+	virtualKeyPolicy := o.(*aigv1a1.AIGatewayVirtualKeyPolicy)
+	var ret []string
+	for _, key := range virtualKeyPolicy.Spec.Keys {
+		// The Secrets are local to the policy's namespace.
+		ret = append(ret, fmt.Sprintf("%s.%s", key.SecretRef.Name, virtualKeyPolicy.Namespace))
+	}
+	return ret
+}
+
 func getSecretNameAndNamespace(secretRef *gwapiv1.SecretObjectReference, namespace string) string {
 	if secretRef.Namespace != nil {
 		return fmt.Sprintf("%s.%s", secretRef.Name, *secretRef.Namespace)
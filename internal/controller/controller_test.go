@@ -103,6 +103,19 @@ func Test_backendSecurityPolicyIndexFunc(t *testing.T) {
 			},
 			expKey: "some-secret2.ns",
 		},
+		{
+			name: "anthropic api key",
+			backendSecurityPolicy: &aigv1a1.BackendSecurityPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "some-backend-security-policy-anthropic", Namespace: "ns"},
+				Spec: aigv1a1.BackendSecurityPolicySpec{
+					Type: aigv1a1.BackendSecurityPolicyTypeAnthropicAPIKey,
+					AnthropicAPIKey: &aigv1a1.BackendSecurityPolicyAnthropicAPIKey{
+						SecretRef: &gwapiv1.SecretObjectReference{Name: "anthropic-secret"},
+					},
+				},
+			},
+			expKey: "anthropic-secret.ns",
+		},
 		{
 			name: "aws credentials with namespace",
 			backendSecurityPolicy: &aigv1a1.BackendSecurityPolicy{
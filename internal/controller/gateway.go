@@ -9,6 +9,9 @@ import (
 	"cmp"
 	"context"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	egv1a1 "github.com/envoyproxy/gateway/api/v1alpha1"
@@ -28,9 +31,12 @@ import (
 
 	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
 	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/bodyheader"
 	"github.com/envoyproxy/ai-gateway/internal/controller/rotators"
 	"github.com/envoyproxy/ai-gateway/internal/internalapi"
 	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
+	"github.com/envoyproxy/ai-gateway/internal/pii"
+	"github.com/envoyproxy/ai-gateway/internal/virtualkey"
 )
 
 const (
@@ -38,6 +44,32 @@ const (
 	FilterConfigKeyInSecret = "filter-config.yaml" //nolint: gosec
 	// defaultOwnedBy is the default value for the ModelsOwnedBy field in the filter config.
 	defaultOwnedBy = "Envoy AI Gateway"
+	// defaultResponseCacheTTL is the default value for the ResponseCache.TTL field.
+	defaultResponseCacheTTL = time.Hour
+	// defaultResponseCacheMaxEntries is the default value for the ResponseCache.MaxEntries field.
+	defaultResponseCacheMaxEntries = 1024
+	// defaultResponseCacheMaxEntryBytes is the default value for the ResponseCache.MaxEntryBytes field.
+	defaultResponseCacheMaxEntryBytes = 1 << 20
+	// defaultResponseCacheRedisKeyPrefix is the default value for the ResponseCache.Redis.KeyPrefix field.
+	defaultResponseCacheRedisKeyPrefix = "aigw:response-cache:"
+	// defaultSemanticResponseCacheEmbeddingTimeout is the default value for the SemanticResponseCache.EmbeddingTimeout field.
+	defaultSemanticResponseCacheEmbeddingTimeout = time.Second
+	// defaultSemanticResponseCacheSimilarityThreshold is the default value for the SemanticResponseCache.SimilarityThreshold field.
+	defaultSemanticResponseCacheSimilarityThreshold = "0.95"
+	// defaultSemanticResponseCacheMaxEntries is the default value for the SemanticResponseCache.MaxEntries field.
+	defaultSemanticResponseCacheMaxEntries = 1024
+	// defaultQuotaRedisKeyPrefix is the default value for the QuotaPolicy.Redis.KeyPrefix field.
+	defaultQuotaRedisKeyPrefix = "aigw:quota:"
+	// defaultVirtualKeyClientKeyHeader is the default value for the VirtualKeyPolicy.ClientKeyHeader field.
+	defaultVirtualKeyClientKeyHeader = "Authorization"
+	// defaultVirtualKeyTeamIDHeader is the default value for the VirtualKeyPolicy.TeamIDHeader field.
+	defaultVirtualKeyTeamIDHeader = "x-ai-eg-team-id"
+	// defaultGuardrailTimeout is the default value for the Guardrail.Timeout field.
+	defaultGuardrailTimeout = time.Second
+	// defaultGuardrailMaxResponseBytes is the default value for the Guardrail.MaxResponseBytes field.
+	defaultGuardrailMaxResponseBytes = 1 << 20
+	// microUSDPerCent converts the limits of the spend budgets in US cents into micro US dollars of the filter.
+	microUSDPerCent = 10_000
 )
 
 // NewGatewayController creates a new reconcile.TypedReconciler for gwapiv1.Gateway.
@@ -178,6 +210,711 @@ func schemaToFilterAPI(schema aigv1a1.VersionedAPISchema) filterapi.VersionedAPI
 	return ret
 }
 
+// fallbackToFilterAPI converts an aigv1a1.AIGatewayRouteRuleFallback to filterapi.BackendFallback with the defaults applied.
+// Returns nil if the fallback is not configured.
+func fallbackToFilterAPI(f *aigv1a1.AIGatewayRouteRuleFallback) *filterapi.BackendFallback {
+	if f == nil {
+		return nil
+	}
+	ret := &filterapi.BackendFallback{}
+	for _, cond := range f.GetRetryOnOrDefault() {
+		ret.RetryOn = append(ret.RetryOn, filterapi.FallbackCondition(cond))
+	}
+	return ret
+}
+
+// adaptiveLoadBalancingToFilterAPI converts the AdaptiveLoadBalancing of the rule at the given index of the
+// AIGatewayRoute to filterapi.AdaptiveLoadBalancer with the defaults applied. The backends with zero weight are
+// never selected. The prices of the backends are taken from the given catalog model if not nil.
+//
+// Only the matches with the exact model name header and the other exact headers are converted since the others
+// cannot be evaluated by the router filter. The requests not matched by any converted match are routed with the
+// static weights.
+func adaptiveLoadBalancingToFilterAPI(route *aigv1a1.AIGatewayRoute, ruleIndex int, cm *aigv1a1.AIGatewayRouteModel) (filterapi.AdaptiveLoadBalancer, error) {
+	rule := &route.Spec.Rules[ruleIndex]
+	a := rule.AdaptiveLoadBalancing
+	ret := filterapi.AdaptiveLoadBalancer{
+		Name:            fmt.Sprintf("%s/%s/rule/%d", route.Namespace, route.Name, ruleIndex),
+		Strategy:        filterapi.AdaptiveLoadBalancerStrategy(a.Strategy),
+		LatencyWindow:   a.GetLatencyWindowOrDefault(),
+		ErrorRateWindow: a.GetErrorRateWindowOrDefault(),
+		OutlierEjection: &filterapi.OutlierEjection{
+			ConsecutiveErrors:  int(a.OutlierEjection.GetConsecutiveErrorsOrDefault()),
+			BaseEjectionTime:   a.OutlierEjection.GetBaseEjectionTimeOrDefault(),
+			MaxEjectionPercent: int(a.OutlierEjection.GetMaxEjectionPercentOrDefault()),
+		},
+	}
+	forEachExactModelMatch(rule, func(model string, headers map[string]string) {
+		ret.Matches = append(ret.Matches, filterapi.RouteRuleMatch{Model: model, Headers: headers})
+	})
+	for j := range rule.BackendRefs {
+		backendRef := &rule.BackendRefs[j]
+		weight := ptr.Deref(backendRef.Weight, 1)
+		if weight == 0 {
+			continue
+		}
+		price, err := catalogPriceForBackend(cm, backendRef.Name)
+		if err != nil {
+			return ret, err
+		}
+		ret.Backends = append(ret.Backends, filterapi.AdaptiveLoadBalancerBackend{
+			Name:   internalapi.PerRouteRuleRefBackendName(route.Namespace, backendRef.Name, route.Name, ruleIndex, j),
+			Weight: int(weight),
+			Price:  price,
+		})
+	}
+	return ret, nil
+}
+
+// forEachExactModelMatch calls the given function with the model name and the other headers, keyed by the lower-cased
+// name, of each match of the rule consisting only of the exact header matches including the model name header.
+// The other matches are skipped since they cannot be evaluated by the router filter.
+func forEachExactModelMatch(rule *aigv1a1.AIGatewayRouteRule, f func(model string, headers map[string]string)) {
+	for i := range rule.Matches {
+		if model, headers, ok := exactModelMatch(&rule.Matches[i]); ok {
+			f(model, headers)
+		}
+	}
+}
+
+// exactModelMatch returns the model name and the other headers, keyed by the lower-cased name, of the given match if
+// it consists only of the exact header matches including the model name header.
+func exactModelMatch(m *aigv1a1.AIGatewayRouteRuleMatch) (model string, headers map[string]string, ok bool) {
+	for _, h := range m.Headers {
+		if h.Type != nil && *h.Type != gwapiv1.HeaderMatchExact {
+			return "", nil, false
+		}
+		if string(h.Name) == aigv1a1.AIModelHeaderKey {
+			model = h.Value
+			continue
+		}
+		if headers == nil {
+			headers = make(map[string]string, len(m.Headers))
+		}
+		headers[strings.ToLower(string(h.Name))] = h.Value
+	}
+	return model, headers, model != ""
+}
+
+// routeExactModelMatches returns the matches of all the rules of the given AIGatewayRoute converted in the same way as
+// forEachExactModelMatch, for the features applied to the whole route. Since the requests not matched by any
+// converted match would bypass such a feature, an error is returned if any rule has no matches or has a match which
+// cannot be evaluated by the router filter.
+func routeExactModelMatches(route *aigv1a1.AIGatewayRoute) ([]filterapi.RouteRuleMatch, error) {
+	var ret []filterapi.RouteRuleMatch
+	for i := range route.Spec.Rules {
+		rule := &route.Spec.Rules[i]
+		if len(rule.Matches) == 0 {
+			return nil, fmt.Errorf("rule %d has no matches, while each match must have the exact %s header match", i, aigv1a1.AIModelHeaderKey)
+		}
+		for j := range rule.Matches {
+			model, headers, ok := exactModelMatch(&rule.Matches[j])
+			if !ok {
+				return nil, fmt.Errorf("match %d of rule %d must consist only of the exact header matches including the %s header",
+					j, i, aigv1a1.AIModelHeaderKey)
+			}
+			ret = append(ret, filterapi.RouteRuleMatch{Model: model, Headers: headers})
+		}
+	}
+	return ret, nil
+}
+
+// sessionAffinityToFilterAPI converts the SessionAffinity of the rule at the given index of the AIGatewayRoute to
+// filterapi.SessionAffinity with the defaults applied. Only the matches with the exact model name header and the
+// other exact headers are converted in the same way as adaptiveLoadBalancingToFilterAPI.
+func sessionAffinityToFilterAPI(route *aigv1a1.AIGatewayRoute, ruleIndex int) (filterapi.SessionAffinity, error) {
+	rule := &route.Spec.Rules[ruleIndex]
+	a := rule.SessionAffinity
+	ret := filterapi.SessionAffinity{Name: fmt.Sprintf("%s/%s/rule/%d", route.Namespace, route.Name, ruleIndex)}
+	switch a.Type {
+	case aigv1a1.AIGatewayRouteRuleSessionAffinityTypeHeader:
+		if a.Header == nil {
+			return ret, fmt.Errorf("header must be specified for the Header type")
+		}
+		ret.Type = filterapi.SessionAffinityTypeHeader
+		ret.Header = strings.ToLower(a.Header.Name)
+	case aigv1a1.AIGatewayRouteRuleSessionAffinityTypeConversationPrefix:
+		ret.Type = filterapi.SessionAffinityTypeConversationPrefix
+		ret.UserMessages = int(a.ConversationPrefix.GetUserMessagesOrDefault())
+	default:
+		return ret, fmt.Errorf("unknown session affinity type: %s", a.Type)
+	}
+	forEachExactModelMatch(rule, func(model string, headers map[string]string) {
+		ret.Matches = append(ret.Matches, filterapi.RouteRuleMatch{Model: model, Headers: headers})
+	})
+	return ret, nil
+}
+
+// hedgingToFilterAPI converts the Hedging of the rule at the given index of the AIGatewayRoute to filterapi.Hedging.
+// Only the matches with the exact model name header and the other exact headers are converted in the same way as
+// adaptiveLoadBalancingToFilterAPI.
+func hedgingToFilterAPI(route *aigv1a1.AIGatewayRoute, ruleIndex int) filterapi.Hedging {
+	rule := &route.Spec.Rules[ruleIndex]
+	ret := filterapi.Hedging{
+		Name:  fmt.Sprintf("%s/%s/rule/%d", route.Namespace, route.Name, ruleIndex),
+		Delay: rule.Hedging.Delay.Duration,
+	}
+	forEachExactModelMatch(rule, func(model string, headers map[string]string) {
+		ret.Matches = append(ret.Matches, filterapi.RouteRuleMatch{Model: model, Headers: headers})
+	})
+	return ret
+}
+
+// requestPolicyToFilterAPI converts the RequestPolicy of the rule at the given index of the AIGatewayRoute to
+// filterapi.RequestPolicy. The matches are converted in the same way as hedgingToFilterAPI. This returns an error if
+// the route has a match the request policy cannot be applied to in the same way as guardrailToFilterAPI.
+func requestPolicyToFilterAPI(route *aigv1a1.AIGatewayRoute, ruleIndex int) (filterapi.RequestPolicy, error) {
+	rule := &route.Spec.Rules[ruleIndex]
+	p := rule.RequestPolicy
+	limit := func(l *aigv1a1.AIGatewayRouteRuleRequestPolicyLimit) *filterapi.RequestPolicyLimit {
+		if l == nil {
+			return nil
+		}
+		return &filterapi.RequestPolicyLimit{Max: l.Max, Clamp: l.Action == aigv1a1.AIGatewayRouteRuleRequestPolicyLimitActionClamp}
+	}
+	ret := filterapi.RequestPolicy{
+		Name:            fmt.Sprintf("%s/%s/rule/%d", route.Namespace, route.Name, ruleIndex),
+		MaxTokens:       limit(p.MaxTokens),
+		MaxMessages:     limit(p.MaxMessages),
+		MaxN:            limit(p.MaxN),
+		ForbiddenFields: p.ForbiddenFields,
+	}
+	if p.MaxPromptBytes != nil {
+		ret.MaxPromptBytes = *p.MaxPromptBytes
+	}
+	if p.MaxEstimatedPromptTokens != nil {
+		ret.MaxEstimatedPromptTokens = *p.MaxEstimatedPromptTokens
+	}
+	for _, m := range p.AllowedModalities {
+		ret.AllowedModalities = append(ret.AllowedModalities, string(m))
+	}
+	for _, d := range p.Defaults {
+		ret.Defaults = append(ret.Defaults, filterapi.RequestPolicyDefault{Field: d.Field, Value: d.Value.Raw})
+	}
+	if _, err := routeExactModelMatches(route); err != nil {
+		return ret, err
+	}
+	forEachExactModelMatch(rule, func(model string, headers map[string]string) {
+		ret.Matches = append(ret.Matches, filterapi.RouteRuleMatch{Model: model, Headers: headers})
+	})
+	return ret, nil
+}
+
+// piiRedactionToFilterAPI converts the PIIRedaction of the given AIGatewayRoute to filterapi.PIIRedaction. The matches
+// of all the rules of the route are converted by routeExactModelMatches.
+func piiRedactionToFilterAPI(route *aigv1a1.AIGatewayRoute) (filterapi.PIIRedaction, error) {
+	r := route.Spec.PIIRedaction
+	ret := filterapi.PIIRedaction{
+		Name:   fmt.Sprintf("%s/%s", route.Namespace, route.Name),
+		Action: filterapi.PIIRedactionAction(r.Action),
+	}
+	for _, d := range r.Detectors {
+		fd := filterapi.PIIDetector{Name: d.Name, Type: filterapi.PIIDetectorType(d.Type)}
+		if d.Regex != nil {
+			fd.Regex = *d.Regex
+		}
+		ret.Detectors = append(ret.Detectors, fd)
+	}
+	// Sanity check the detectors.
+	if _, err := pii.NewRedactor(&ret); err != nil {
+		return ret, err
+	}
+	matches, err := routeExactModelMatches(route)
+	if err != nil {
+		return ret, err
+	}
+	ret.Matches = matches
+	return ret, nil
+}
+
+// guardrailToFilterAPI converts the Guardrail of the given AIGatewayRoute to filterapi.Guardrail. The matches of all
+// the rules of the route are converted by routeExactModelMatches.
+func guardrailToFilterAPI(route *aigv1a1.AIGatewayRoute) (filterapi.Guardrail, error) {
+	g := route.Spec.Guardrail
+	ret := filterapi.Guardrail{
+		Name:             fmt.Sprintf("%s/%s", route.Namespace, route.Name),
+		Protocol:         filterapi.GuardrailProtocolHTTP,
+		Endpoint:         g.Endpoint,
+		Request:          len(g.Phases) == 0 || slices.Contains(g.Phases, aigv1a1.AIGatewayRouteGuardrailPhaseRequest),
+		Response:         len(g.Phases) == 0 || slices.Contains(g.Phases, aigv1a1.AIGatewayRouteGuardrailPhaseResponse),
+		Timeout:          defaultGuardrailTimeout,
+		FailOpen:         g.FailureMode == aigv1a1.AIGatewayRouteGuardrailFailureModeFailOpen,
+		MaxResponseBytes: int(ptr.Deref(g.MaxResponseBytes, defaultGuardrailMaxResponseBytes)),
+	}
+	if g.Protocol == aigv1a1.AIGatewayRouteGuardrailProtocolGRPC {
+		ret.Protocol = filterapi.GuardrailProtocolGRPC
+	}
+	if g.Timeout != nil {
+		ret.Timeout = g.Timeout.Duration
+	}
+	matches, err := routeExactModelMatches(route)
+	if err != nil {
+		return ret, err
+	}
+	ret.Matches = matches
+	return ret, nil
+}
+
+// toolPolicyToFilterAPI converts the ToolPolicy of the given AIGatewayRoute to filterapi.ToolPolicy. This returns an
+// error if the route has a match the tool policy cannot be applied to in the same way as guardrailToFilterAPI.
+func toolPolicyToFilterAPI(route *aigv1a1.AIGatewayRoute) (filterapi.ToolPolicy, error) {
+	p := route.Spec.ToolPolicy
+	ret := filterapi.ToolPolicy{
+		Name:              fmt.Sprintf("%s/%s", route.Namespace, route.Name),
+		AllowedFunctions:  p.AllowedFunctions,
+		ValidateArguments: ptr.Deref(p.ValidateArguments, true),
+		Action:            filterapi.ToolPolicyActionBlock,
+	}
+	if p.Action == aigv1a1.AIGatewayRouteToolPolicyActionRewrite {
+		ret.Action = filterapi.ToolPolicyActionRewrite
+	}
+	matches, err := routeExactModelMatches(route)
+	if err != nil {
+		return ret, err
+	}
+	ret.Matches = matches
+	return ret, nil
+}
+
+// appendCircuitBreakerBackend adds the per-route-rule-ref backend name of the AIServiceBackend to the
+// filterapi.CircuitBreaker of the AIServiceBackend, creating the circuit breaker if it doesn't exist yet.
+//
+// Only the throttling is classified by the filter since Envoy detects the server errors via the outlier detection of
+// the cluster configured by the extension server, so nothing is added if the circuit breaker only fails on the server
+// errors.
+func appendCircuitBreakerBackend(cbs []filterapi.CircuitBreaker, backend *aigv1a1.AIServiceBackend, backendName string) []filterapi.CircuitBreaker {
+	cb := backend.Spec.CircuitBreaker
+	if cb == nil {
+		return cbs
+	}
+	name := fmt.Sprintf("%s.%s", backend.Name, backend.Namespace)
+	for i := range cbs {
+		if cbs[i].Name == name {
+			cbs[i].Backends = append(cbs[i].Backends, backendName)
+			return cbs
+		}
+	}
+	var failOn []filterapi.CircuitBreakerErrorClass
+	for _, class := range cb.GetFailOnOrDefault() {
+		if class == aigv1a1.AIServiceBackendCircuitBreakerErrorClassThrottled {
+			failOn = append(failOn, filterapi.CircuitBreakerErrorClassThrottled)
+		}
+	}
+	if len(failOn) == 0 {
+		return cbs
+	}
+	return append(cbs, filterapi.CircuitBreaker{
+		Name:                name,
+		Backends:            []string{backendName},
+		ConsecutiveFailures: int(cb.GetConsecutiveFailuresOrDefault()),
+		FailOn:              failOn,
+		OpenDuration:        cb.GetOpenDurationOrDefault(),
+		HalfOpenProbes:      int(cb.GetHalfOpenProbesOrDefault()),
+	})
+}
+
+// catalogModelToFilterAPI populates the given filterapi.Model with the metadata of the aigv1a1.AIGatewayRouteModel.
+func catalogModelToFilterAPI(cm *aigv1a1.AIGatewayRouteModel, model *filterapi.Model) {
+	model.Aliases = cm.Aliases
+	model.ContextWindow = int(ptr.Deref(cm.ContextWindow, 0))
+	for _, modality := range cm.Modalities {
+		model.Modalities = append(model.Modalities, string(modality))
+	}
+	if cm.OwnedBy != nil {
+		model.OwnedBy = *cm.OwnedBy
+	}
+}
+
+// catalogModelNameForBackend returns the name of the virtual model at the given AIServiceBackend.
+// The name of the virtual model is returned if the backend-specific name is not specified so that
+// the requests using the aliases are sent with the name of the virtual model.
+func catalogModelNameForBackend(cm *aigv1a1.AIGatewayRouteModel, backendName string) string {
+	for _, b := range cm.Backends {
+		if b.Name == backendName {
+			return b.ModelName
+		}
+	}
+	return cm.Name
+}
+
+// catalogPriceForBackend returns the price of the virtual model at the given AIServiceBackend, or nil if the
+// catalog model is nil or the price is not specified for the backend.
+func catalogPriceForBackend(cm *aigv1a1.AIGatewayRouteModel, backendName string) (*filterapi.ModelPrice, error) {
+	if cm == nil {
+		return nil, nil
+	}
+	for _, b := range cm.Backends {
+		if b.Name != backendName || b.Price == nil {
+			continue
+		}
+		parse := func(field, v string) (float64, error) {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid %s of model %s at backend %s: %w", field, cm.Name, backendName, err)
+			}
+			return f, nil
+		}
+		var ret filterapi.ModelPrice
+		var err error
+		if ret.InputPerMillionTokens, err = parse("inputPerMillionTokens", b.Price.InputPerMillionTokens); err != nil {
+			return nil, err
+		}
+		if ret.OutputPerMillionTokens, err = parse("outputPerMillionTokens", b.Price.OutputPerMillionTokens); err != nil {
+			return nil, err
+		}
+		ret.CachedInputPerMillionTokens = ret.InputPerMillionTokens
+		if b.Price.CachedInputPerMillionTokens != nil {
+			if ret.CachedInputPerMillionTokens, err = parse("cachedInputPerMillionTokens", *b.Price.CachedInputPerMillionTokens); err != nil {
+				return nil, err
+			}
+		}
+		return &ret, nil
+	}
+	return nil, nil
+}
+
+// responseCacheToFilterAPI converts an aigv1a1.ResponseCache to filterapi.ResponseCacheConfig with the defaults applied.
+// The Secrets of the Redis server are read from the given namespace.
+func (c *GatewayController) responseCacheToFilterAPI(ctx context.Context, namespace string, rc *aigv1a1.ResponseCache) (*filterapi.ResponseCacheConfig, error) {
+	ret := &filterapi.ResponseCacheConfig{
+		TTL:           defaultResponseCacheTTL,
+		MaxEntries:    int(ptr.Deref(rc.MaxEntries, defaultResponseCacheMaxEntries)),
+		MaxEntryBytes: int(ptr.Deref(rc.MaxEntryBytes, defaultResponseCacheMaxEntryBytes)),
+	}
+	if rc.TTL != nil {
+		ret.TTL = rc.TTL.Duration
+	}
+	if rc.Type == aigv1a1.ResponseCacheTypeRedis && rc.Redis != nil {
+		ret.MaxEntries = 0
+		password, tlsConfig, err := c.redisCredentialsToFilterAPI(ctx, namespace, rc.Redis.PasswordSecretRef, rc.Redis.TLS)
+		if err != nil {
+			return nil, err
+		}
+		ret.Redis = &filterapi.ResponseCacheRedisConfig{
+			Address:   rc.Redis.Address,
+			KeyPrefix: ptr.Deref(rc.Redis.KeyPrefix, defaultResponseCacheRedisKeyPrefix),
+			Username:  ptr.Deref(rc.Redis.Username, ""),
+			Password:  password,
+			TLS:       tlsConfig,
+		}
+	}
+	return ret, nil
+}
+
+// redisCredentialsToFilterAPI reads the password and the CA certificates of a Redis-compatible server from the Secrets
+// in the given namespace, and returns the password with the filterapi.RedisTLSConfig, which is nil when TLS is disabled.
+func (c *GatewayController) redisCredentialsToFilterAPI(ctx context.Context, namespace string, passwordRef *gwapiv1.SecretObjectReference, tlsConfig *aigv1a1.RedisTLS) (string, *filterapi.RedisTLSConfig, error) {
+	var password string
+	if passwordRef != nil {
+		var err error
+		password, err = c.getSecretData(ctx, namespace, string(passwordRef.Name), redisPasswordInSecret)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read the redis password: %w", err)
+		}
+	}
+	if tlsConfig == nil {
+		return password, nil, nil
+	}
+	ret := &filterapi.RedisTLSConfig{ServerName: ptr.Deref(tlsConfig.ServerName, "")}
+	if ref := tlsConfig.CACertificateSecretRef; ref != nil {
+		ca, err := c.getSecretData(ctx, namespace, string(ref.Name), redisCACertificateInSecret)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read the redis CA certificate: %w", err)
+		}
+		ret.CACertificate = ca
+	}
+	return password, ret, nil
+}
+
+// appendBodyHeaders appends the BodyHeaders of the given AIGatewayRoute to headers and returns the result.
+// The headers already declared by another AIGatewayRoute attached to the same Gateway are skipped.
+func (c *GatewayController) appendBodyHeaders(headers []filterapi.BodyHeader, route *aigv1a1.AIGatewayRoute) ([]filterapi.BodyHeader, error) {
+	for _, h := range route.Spec.BodyHeaders {
+		if slices.ContainsFunc(headers, func(b filterapi.BodyHeader) bool { return b.Name == h.Name }) {
+			c.logger.Info("BodyHeader with the same name already exists, skipping", "name", h.Name, "route", route.Name)
+			continue
+		}
+		fh := filterapi.BodyHeader{Name: h.Name}
+		var err error
+		switch {
+		case h.CEL != nil:
+			fh.CEL = *h.CEL
+			_, err = bodyheader.NewCELProgram(fh.CEL)
+		case h.JSONPath != nil:
+			fh.JSONPath = *h.JSONPath
+			_, err = bodyheader.NewJSONPath(fh.JSONPath)
+		default:
+			err = fmt.Errorf("no expression")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid body header %s: %w", h.Name, err)
+		}
+		headers = append(headers, fh)
+	}
+	return headers, nil
+}
+
+// appendQuotaPolicies appends the AIGatewayQuotaPolicies targeting the given AIGatewayRoute to quotas and returns the result.
+// Each policy appears once in quotas, keyed by its namespaced name, and accumulates the models of all the routes it targets.
+func (c *GatewayController) appendQuotaPolicies(ctx context.Context, quotas []filterapi.QuotaPolicy, route *aigv1a1.AIGatewayRoute, routeModels []string) ([]filterapi.QuotaPolicy, error) {
+	var policies aigv1a1.AIGatewayQuotaPolicyList
+	if err := c.client.List(ctx, &policies, client.InNamespace(route.Namespace),
+		client.MatchingFields{k8sClientIndexAIGatewayRouteToTargetingQuotaPolicy: fmt.Sprintf("%s.%s", route.Name, route.Namespace)}); err != nil {
+		return nil, fmt.Errorf("failed to list AIGatewayQuotaPolicy: %w", err)
+	}
+	for i := range policies.Items {
+		policy := &policies.Items[i]
+		if !policy.DeletionTimestamp.IsZero() {
+			continue
+		}
+		name := fmt.Sprintf("%s/%s", policy.Namespace, policy.Name)
+		idx := slices.IndexFunc(quotas, func(q filterapi.QuotaPolicy) bool { return q.Name == name })
+		if idx < 0 {
+			q, err := c.quotaPolicyToFilterAPI(ctx, policy.Namespace, name, &policy.Spec)
+			if err != nil {
+				return nil, err
+			}
+			quotas = append(quotas, *q)
+			idx = len(quotas) - 1
+		}
+		quotas[idx].Models = append(quotas[idx].Models, routeModels...)
+	}
+	return quotas, nil
+}
+
+// quotaPolicyToFilterAPI converts an aigv1a1.AIGatewayQuotaPolicySpec to filterapi.QuotaPolicy with the defaults applied.
+// The models are filled by the caller.
+func (c *GatewayController) quotaPolicyToFilterAPI(ctx context.Context, namespace, name string, spec *aigv1a1.AIGatewayQuotaPolicySpec) (*filterapi.QuotaPolicy, error) {
+	budgets, err := quotaBudgetsToFilterAPI(spec.Budgets)
+	if err != nil {
+		return nil, err
+	}
+	redis, err := c.quotaRedisToFilterAPI(ctx, namespace, spec.Type, spec.Redis)
+	if err != nil {
+		return nil, err
+	}
+	return &filterapi.QuotaPolicy{
+		Name:            name,
+		ClientKeyHeader: spec.ClientKeyHeader,
+		Budgets:         budgets,
+		Redis:           redis,
+	}, nil
+}
+
+// quotaBudgetsToFilterAPI converts the list of aigv1a1.AIGatewayQuotaBudget to the filter API.
+func quotaBudgetsToFilterAPI(budgets []aigv1a1.AIGatewayQuotaBudget) ([]filterapi.QuotaBudget, error) {
+	var ret []filterapi.QuotaBudget
+	for _, b := range budgets {
+		fb := filterapi.QuotaBudget{Limit: b.Limit, Window: b.Window.Duration}
+		switch b.Type {
+		case aigv1a1.AIGatewayQuotaBudgetTypeInputToken:
+			fb.Type = filterapi.QuotaBudgetTypeInputToken
+		case aigv1a1.AIGatewayQuotaBudgetTypeOutputToken:
+			fb.Type = filterapi.QuotaBudgetTypeOutputToken
+		case aigv1a1.AIGatewayQuotaBudgetTypeTotalToken:
+			fb.Type = filterapi.QuotaBudgetTypeTotalToken
+		case aigv1a1.AIGatewayQuotaBudgetTypeSpend:
+			fb.Type = filterapi.QuotaBudgetTypeSpend
+			// The limit is in US cents, which is tracked in micro US dollars by the filter.
+			fb.Limit = b.Limit * microUSDPerCent
+		default:
+			return nil, fmt.Errorf("unknown quota budget type: %s", b.Type)
+		}
+		ret = append(ret, fb)
+	}
+	return ret, nil
+}
+
+// quotaRedisToFilterAPI returns the filterapi.QuotaRedisConfig with the defaults applied when the store type is Redis,
+// or nil otherwise. The Secrets of the server are read from the given namespace.
+func (c *GatewayController) quotaRedisToFilterAPI(ctx context.Context, namespace string, storeType aigv1a1.AIGatewayQuotaStoreType, redis *aigv1a1.AIGatewayQuotaRedis) (*filterapi.QuotaRedisConfig, error) {
+	if storeType != aigv1a1.AIGatewayQuotaStoreTypeRedis || redis == nil {
+		return nil, nil
+	}
+	password, tlsConfig, err := c.redisCredentialsToFilterAPI(ctx, namespace, redis.PasswordSecretRef, redis.TLS)
+	if err != nil {
+		return nil, err
+	}
+	return &filterapi.QuotaRedisConfig{
+		Address:   redis.Address,
+		KeyPrefix: ptr.Deref(redis.KeyPrefix, defaultQuotaRedisKeyPrefix),
+		Username:  ptr.Deref(redis.Username, ""),
+		Password:  password,
+		TLS:       tlsConfig,
+	}, nil
+}
+
+// appendVirtualKeyPolicies appends the AIGatewayVirtualKeyPolicies targeting the given AIGatewayRoute to policies and
+// returns the result. Each policy appears once in policies, keyed by its namespaced name, and accumulates the models of
+// all the routes it targets.
+//
+// The budgets of each key are appended to quotas as a QuotaPolicy restricted to the key, in the same way.
+func (c *GatewayController) appendVirtualKeyPolicies(ctx context.Context, policies []filterapi.VirtualKeyPolicy, quotas []filterapi.QuotaPolicy, route *aigv1a1.AIGatewayRoute, routeModels []string) ([]filterapi.VirtualKeyPolicy, []filterapi.QuotaPolicy, error) {
+	var list aigv1a1.AIGatewayVirtualKeyPolicyList
+	if err := c.client.List(ctx, &list, client.InNamespace(route.Namespace),
+		client.MatchingFields{k8sClientIndexAIGatewayRouteToTargetingVirtualKeyPolicy: fmt.Sprintf("%s.%s", route.Name, route.Namespace)}); err != nil {
+		return nil, nil, fmt.Errorf("failed to list AIGatewayVirtualKeyPolicy: %w", err)
+	}
+	for i := range list.Items {
+		policy := &list.Items[i]
+		if !policy.DeletionTimestamp.IsZero() {
+			continue
+		}
+		name := fmt.Sprintf("%s/%s", policy.Namespace, policy.Name)
+		idx := slices.IndexFunc(policies, func(p filterapi.VirtualKeyPolicy) bool { return p.Name == name })
+		if idx < 0 {
+			p, keyQuotas, err := c.virtualKeyPolicyToFilterAPI(ctx, name, policy)
+			if err != nil {
+				return nil, nil, err
+			}
+			policies = append(policies, *p)
+			quotas = append(quotas, keyQuotas...)
+			idx = len(policies) - 1
+		}
+		policies[idx].Models = append(policies[idx].Models, routeModels...)
+		for j := range quotas {
+			if strings.HasPrefix(quotas[j].Name, name+"/key/") {
+				quotas[j].Models = append(quotas[j].Models, routeModels...)
+			}
+		}
+	}
+	return policies, quotas, nil
+}
+
+// virtualKeyPolicyToFilterAPI converts an aigv1a1.AIGatewayVirtualKeyPolicy to filterapi.VirtualKeyPolicy with the
+// defaults applied, and returns the QuotaPolicies of the keys with budgets. The models are filled by the caller.
+//
+// The keys are read from the Secrets and only their hashes are passed to the filter. The key whose Secret cannot be
+// read is skipped so that its requests are rejected rather than failing the configuration of the whole gateway.
+func (c *GatewayController) virtualKeyPolicyToFilterAPI(ctx context.Context, name string, policy *aigv1a1.AIGatewayVirtualKeyPolicy) (*filterapi.VirtualKeyPolicy, []filterapi.QuotaPolicy, error) {
+	spec := &policy.Spec
+	ret := &filterapi.VirtualKeyPolicy{
+		Name:            name,
+		ClientKeyHeader: strings.ToLower(ptr.Deref(spec.ClientKeyHeader, defaultVirtualKeyClientKeyHeader)),
+		TeamIDHeader:    strings.ToLower(ptr.Deref(spec.TeamIDHeader, defaultVirtualKeyTeamIDHeader)),
+	}
+	redis, err := c.quotaRedisToFilterAPI(ctx, policy.Namespace, spec.Type, spec.Redis)
+	if err != nil {
+		return nil, nil, err
+	}
+	var quotas []filterapi.QuotaPolicy
+	for i := range spec.Keys {
+		key := &spec.Keys[i]
+		keyName := fmt.Sprintf("%s/%s", name, key.Name)
+		if len(key.Budgets) > 0 {
+			budgets, err := quotaBudgetsToFilterAPI(key.Budgets)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid budgets of the virtual key %s: %w", keyName, err)
+			}
+			quotas = append(quotas, filterapi.QuotaPolicy{
+				Name:            fmt.Sprintf("%s/key/%s", name, key.Name),
+				ClientKeyHeader: internalapi.VirtualKeyHeaderKey,
+				ClientKeys:      []string{keyName},
+				Budgets:         budgets,
+				Redis:           redis,
+			})
+		}
+		if key.SecretRef == nil {
+			continue
+		}
+		value, err := c.getSecretData(ctx, policy.Namespace, string(key.SecretRef.Name), apiKeyInSecret)
+		if err != nil {
+			c.logger.Error(err, "failed to read the virtual key, skipping", "policy", name, "key", key.Name)
+			continue
+		}
+		ret.Keys = append(ret.Keys, filterapi.VirtualKey{
+			Name:          keyName,
+			Hash:          virtualkey.Hash(strings.TrimSpace(value)),
+			TeamID:        key.TeamID,
+			AllowedModels: key.AllowedModels,
+		})
+	}
+	return ret, quotas, nil
+}
+
+// semanticResponseCacheToFilterAPI converts an aigv1a1.SemanticResponseCache to filterapi.SemanticResponseCacheConfig
+// with the defaults applied. The embeddings backend is resolved from the AIServiceBackend in the given namespace.
+//
+// The Models field is left empty to be populated by the caller from the routes that opt in.
+func (c *GatewayController) semanticResponseCacheToFilterAPI(ctx context.Context, namespace string, sem *aigv1a1.SemanticResponseCache) (*filterapi.SemanticResponseCacheConfig, error) {
+	threshold, err := strconv.ParseFloat(ptr.Deref(sem.SimilarityThreshold, defaultSemanticResponseCacheSimilarityThreshold), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid similarity threshold: %w", err)
+	}
+	ret := &filterapi.SemanticResponseCacheConfig{
+		SimilarityThreshold: threshold,
+		MaxEntries:          int(ptr.Deref(sem.MaxEntries, defaultSemanticResponseCacheMaxEntries)),
+		Embedding: filterapi.SemanticResponseCacheEmbedding{
+			Endpoint: sem.EmbeddingEndpoint,
+			Model:    sem.EmbeddingModel,
+			Timeout:  defaultSemanticResponseCacheEmbeddingTimeout,
+		},
+	}
+	if sem.EmbeddingTimeout != nil {
+		ret.Embedding.Timeout = sem.EmbeddingTimeout.Duration
+	}
+	backendObj, bsp, err := c.backendWithMaybeBSP(ctx, namespace, sem.EmbeddingBackendRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AIServiceBackend %s: %w", sem.EmbeddingBackendRef, err)
+	}
+	ret.Embedding.Backend = filterapi.Backend{
+		Name:   fmt.Sprintf("%s.%s", sem.EmbeddingBackendRef, namespace),
+		Schema: schemaToFilterAPI(backendObj.Spec.APISchema),
+	}
+	if bsp != nil {
+		ret.Embedding.Backend.Auth, err = c.bspToFilterAPIBackendAuth(ctx, bsp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create embedding backend auth: %w", err)
+		}
+	}
+	return ret, nil
+}
+
+// mirrorToFilterAPI converts the Mirror of the rule at the given index of the route to the filter API.
+func (c *GatewayController) mirrorToFilterAPI(ctx context.Context, route *aigv1a1.AIGatewayRoute, ruleIndex int) (filterapi.Mirror, error) {
+	rule := &route.Spec.Rules[ruleIndex]
+	m := rule.Mirror
+	ret := filterapi.Mirror{
+		Name:              fmt.Sprintf("%s/%s/rule/%d", route.Namespace, route.Name, ruleIndex),
+		Endpoint:          m.Endpoint,
+		ModelNameOverride: m.ModelNameOverride,
+		Percentage:        int(m.GetPercentageOrDefault()),
+		Timeout:           m.GetTimeoutOrDefault(),
+	}
+	for j := range rule.BackendRefs {
+		ret.Backends = append(ret.Backends, internalapi.PerRouteRuleRefBackendName(route.Namespace, rule.BackendRefs[j].Name, route.Name, ruleIndex, j))
+	}
+	switch m.Sink.Type {
+	case aigv1a1.AIGatewayRouteRuleMirrorSinkTypeFile:
+		ret.Sink.Type = filterapi.MirrorSinkTypeFile
+		if m.Sink.File != nil {
+			ret.Sink.FilePath = m.Sink.File.Path
+		}
+	case aigv1a1.AIGatewayRouteRuleMirrorSinkTypeOTLPLogs:
+		ret.Sink.Type = filterapi.MirrorSinkTypeOTLPLogs
+		if m.Sink.OTLPLogs != nil {
+			ret.Sink.OTLPEndpoint = m.Sink.OTLPLogs.Endpoint
+		}
+	default:
+		return ret, fmt.Errorf("unknown mirror sink type: %s", m.Sink.Type)
+	}
+	backendObj, bsp, err := c.backendWithMaybeBSP(ctx, route.Namespace, m.BackendRef)
+	if err != nil {
+		return ret, fmt.Errorf("failed to get AIServiceBackend %s: %w", m.BackendRef, err)
+	}
+	ret.Backend = filterapi.Backend{
+		Name:   fmt.Sprintf("%s.%s", m.BackendRef, route.Namespace),
+		Schema: schemaToFilterAPI(backendObj.Spec.APISchema),
+	}
+	if bsp != nil {
+		ret.Backend.Auth, err = c.bspToFilterAPIBackendAuth(ctx, bsp)
+		if err != nil {
+			return ret, fmt.Errorf("failed to create mirror backend auth: %w", err)
+		}
+	}
+	return ret, nil
+}
+
 // reconcileFilterConfigSecret updates the filter config secret for the external processor.
 func (c *GatewayController) reconcileFilterConfigSecret(ctx context.Context, configSecretName, configSecretNamespace string, aiGatewayRoutes []aigv1a1.AIGatewayRoute, uuid string) error {
 	// Precondition: aiGatewayRoutes is not empty as we early return if it is empty.
@@ -185,11 +922,20 @@ func (c *GatewayController) reconcileFilterConfigSecret(ctx context.Context, con
 	ec.ModelNameHeaderKey = aigv1a1.AIModelHeaderKey
 	var err error
 	llmCosts := map[string]struct{}{}
+	var semanticCache *filterapi.SemanticResponseCacheConfig
 	for i := range aiGatewayRoutes {
 		aiGatewayRoute := &aiGatewayRoutes[i]
 		spec := aiGatewayRoute.Spec
+		var routeModels []string
+		catalog := make(map[string]*aigv1a1.AIGatewayRouteModel, len(spec.Models))
+		for i := range spec.Models {
+			catalog[spec.Models[i].Name] = &spec.Models[i]
+		}
+		declaredCatalogModels := make(map[string]struct{}, len(spec.Models))
 		for i := range spec.Rules {
 			rule := &spec.Rules[i]
+			var ruleCatalogModels []*aigv1a1.AIGatewayRouteModel
+			var ruleModels []string
 			for _, m := range rule.Matches {
 				for _, h := range m.Headers {
 					// If explicitly set to something that is not an exact match, skip.
@@ -199,11 +945,21 @@ func (c *GatewayController) reconcileFilterConfigSecret(ctx context.Context, con
 					if (h.Type != nil && *h.Type != gwapiv1.HeaderMatchExact) || string(h.Name) != aigv1a1.AIModelHeaderKey {
 						continue
 					}
-					ec.Models = append(ec.Models, filterapi.Model{
+					model := filterapi.Model{
 						Name:      h.Value,
 						CreatedAt: ptr.Deref[metav1.Time](rule.ModelsCreatedAt, aiGatewayRoute.CreationTimestamp).UTC(),
 						OwnedBy:   ptr.Deref(rule.ModelsOwnedBy, defaultOwnedBy),
-					})
+					}
+					if cm, ok := catalog[h.Value]; ok {
+						catalogModelToFilterAPI(cm, &model)
+						if !slices.Contains(ruleCatalogModels, cm) {
+							ruleCatalogModels = append(ruleCatalogModels, cm)
+						}
+						declaredCatalogModels[h.Value] = struct{}{}
+					}
+					ec.Models = append(ec.Models, model)
+					routeModels = append(routeModels, h.Value)
+					ruleModels = append(ruleModels, h.Value)
 				}
 			}
 			for j := range rule.BackendRefs {
@@ -223,6 +979,17 @@ func (c *GatewayController) reconcileFilterConfigSecret(ctx context.Context, con
 						return fmt.Errorf("failed to get AIServiceBackend %s: %w", b.Name, err)
 					}
 					b.Schema = schemaToFilterAPI(backendObj.Spec.APISchema)
+					b.Fallback = fallbackToFilterAPI(rule.Fallback)
+					ec.CircuitBreakers = appendCircuitBreakerBackend(ec.CircuitBreakers, backendObj, b.Name)
+					if len(ruleCatalogModels) == 1 {
+						if b.ModelNameOverride == "" {
+							b.ModelNameOverride = catalogModelNameForBackend(ruleCatalogModels[0], backendRef.Name)
+						}
+						b.Price, err = catalogPriceForBackend(ruleCatalogModels[0], backendRef.Name)
+						if err != nil {
+							return err
+						}
+					}
 					if bsp != nil {
 						b.Auth, err = c.bspToFilterAPIBackendAuth(ctx, bsp)
 						if err != nil {
@@ -233,6 +1000,51 @@ func (c *GatewayController) reconcileFilterConfigSecret(ctx context.Context, con
 
 				ec.Backends = append(ec.Backends, b)
 			}
+			if rule.AdaptiveLoadBalancing != nil && len(ruleModels) > 0 {
+				var cm *aigv1a1.AIGatewayRouteModel
+				if len(ruleCatalogModels) == 1 {
+					cm = ruleCatalogModels[0]
+				}
+				var lb filterapi.AdaptiveLoadBalancer
+				lb, err = adaptiveLoadBalancingToFilterAPI(aiGatewayRoute, i, cm)
+				if err != nil {
+					return err
+				}
+				if len(lb.Matches) > 0 {
+					ec.AdaptiveLoadBalancers = append(ec.AdaptiveLoadBalancers, lb)
+				}
+			}
+			if rule.Mirror != nil {
+				var m filterapi.Mirror
+				m, err = c.mirrorToFilterAPI(ctx, aiGatewayRoute, i)
+				if err != nil {
+					return fmt.Errorf("failed to configure mirror: %w", err)
+				}
+				ec.Mirrors = append(ec.Mirrors, m)
+			}
+			if rule.SessionAffinity != nil {
+				var sa filterapi.SessionAffinity
+				sa, err = sessionAffinityToFilterAPI(aiGatewayRoute, i)
+				if err != nil {
+					return fmt.Errorf("failed to configure session affinity: %w", err)
+				}
+				if len(sa.Matches) > 0 {
+					ec.SessionAffinities = append(ec.SessionAffinities, sa)
+				}
+			}
+			if rule.Hedging != nil {
+				if h := hedgingToFilterAPI(aiGatewayRoute, i); len(h.Matches) > 0 {
+					ec.Hedgings = append(ec.Hedgings, h)
+				}
+			}
+			if rule.RequestPolicy != nil {
+				var p filterapi.RequestPolicy
+				p, err = requestPolicyToFilterAPI(aiGatewayRoute, i)
+				if err != nil {
+					return fmt.Errorf("failed to configure request policy: %w", err)
+				}
+				ec.RequestPolicies = append(ec.RequestPolicies, p)
+			}
 
 			for _, cost := range aiGatewayRoute.Spec.LLMRequestCosts {
 				fc := filterapi.LLMRequestCost{MetadataKey: cost.MetadataKey}
@@ -249,6 +1061,12 @@ func (c *GatewayController) reconcileFilterConfigSecret(ctx context.Context, con
 					fc.Type = filterapi.LLMRequestCostTypeOutputToken
 				case aigv1a1.LLMRequestCostTypeTotalToken:
 					fc.Type = filterapi.LLMRequestCostTypeTotalToken
+				case aigv1a1.LLMRequestCostTypeImage:
+					fc.Type = filterapi.LLMRequestCostTypeImage
+				case aigv1a1.LLMRequestCostTypeAudioSecond:
+					fc.Type = filterapi.LLMRequestCostTypeAudioSecond
+				case aigv1a1.LLMRequestCostTypeCharacter:
+					fc.Type = filterapi.LLMRequestCostTypeCharacter
 				case aigv1a1.LLMRequestCostTypeCEL:
 					fc.Type = filterapi.LLMRequestCostTypeCEL
 					expr := *cost.CEL
@@ -265,6 +1083,87 @@ func (c *GatewayController) reconcileFilterConfigSecret(ctx context.Context, con
 				llmCosts[cost.MetadataKey] = struct{}{}
 			}
 		}
+		// The catalog models not matched by the rules with the model name header are still declared
+		// so that they are listed and their aliases are resolved.
+		for i := range spec.Models {
+			cm := &spec.Models[i]
+			if _, ok := declaredCatalogModels[cm.Name]; ok {
+				continue
+			}
+			model := filterapi.Model{
+				Name:      cm.Name,
+				CreatedAt: aiGatewayRoute.CreationTimestamp.UTC(),
+				OwnedBy:   defaultOwnedBy,
+			}
+			catalogModelToFilterAPI(cm, &model)
+			ec.Models = append(ec.Models, model)
+		}
+		if rc := spec.ResponseCache; rc != nil {
+			if ec.ResponseCache != nil {
+				c.logger.Info("ResponseCache is already configured by another AIGatewayRoute, skipping", "route", aiGatewayRoute.Name)
+			} else {
+				ec.ResponseCache, err = c.responseCacheToFilterAPI(ctx, aiGatewayRoute.Namespace, rc)
+				if err != nil {
+					return fmt.Errorf("failed to configure response cache: %w", err)
+				}
+			}
+			if sem := rc.Semantic; sem != nil {
+				if semanticCache == nil {
+					semanticCache, err = c.semanticResponseCacheToFilterAPI(ctx, aiGatewayRoute.Namespace, sem)
+					if err != nil {
+						return fmt.Errorf("failed to configure semantic response cache: %w", err)
+					}
+				}
+				// The semantic lookup is opted in per route, so every route contributes its models.
+				semanticCache.Models = append(semanticCache.Models, routeModels...)
+			}
+		}
+		ec.Quotas, err = c.appendQuotaPolicies(ctx, ec.Quotas, aiGatewayRoute, routeModels)
+		if err != nil {
+			return fmt.Errorf("failed to configure quotas: %w", err)
+		}
+		ec.VirtualKeyPolicies, ec.Quotas, err = c.appendVirtualKeyPolicies(ctx, ec.VirtualKeyPolicies, ec.Quotas, aiGatewayRoute, routeModels)
+		if err != nil {
+			return fmt.Errorf("failed to configure virtual keys: %w", err)
+		}
+		ec.BodyHeaders, err = c.appendBodyHeaders(ec.BodyHeaders, aiGatewayRoute)
+		if err != nil {
+			return fmt.Errorf("failed to configure body headers: %w", err)
+		}
+		if spec.PIIRedaction != nil {
+			var r filterapi.PIIRedaction
+			r, err = piiRedactionToFilterAPI(aiGatewayRoute)
+			if err != nil {
+				return fmt.Errorf("failed to configure PII redaction: %w", err)
+			}
+			ec.PIIRedactions = append(ec.PIIRedactions, r)
+		}
+		if spec.Guardrail != nil {
+			var g filterapi.Guardrail
+			g, err = guardrailToFilterAPI(aiGatewayRoute)
+			if err != nil {
+				return fmt.Errorf("failed to configure guardrail: %w", err)
+			}
+			ec.Guardrails = append(ec.Guardrails, g)
+		}
+		if spec.ToolPolicy != nil {
+			var p filterapi.ToolPolicy
+			p, err = toolPolicyToFilterAPI(aiGatewayRoute)
+			if err != nil {
+				return fmt.Errorf("failed to configure tool policy: %w", err)
+			}
+			ec.ToolPolicies = append(ec.ToolPolicies, p)
+		}
+	}
+	ec.Quotas = slices.DeleteFunc(ec.Quotas, func(q filterapi.QuotaPolicy) bool {
+		if len(q.Models) == 0 {
+			c.logger.Info("AIGatewayQuotaPolicy targets no models, skipping", "policy", q.Name)
+			return true
+		}
+		return false
+	})
+	if semanticCache != nil {
+		ec.ResponseCache.Semantic = semanticCache
 	}
 
 	ec.MetadataNamespace = aigv1a1.AIGatewayFilterMetadataNamespace
@@ -309,6 +1208,13 @@ func (c *GatewayController) bspToFilterAPIBackendAuth(ctx context.Context, backe
 			return nil, fmt.Errorf("failed to get secret %s: %w", secretName, err)
 		}
 		return &filterapi.BackendAuth{APIKey: &filterapi.APIKeyAuth{Key: apiKey}}, nil
+	case aigv1a1.BackendSecurityPolicyTypeAnthropicAPIKey:
+		secretName := string(backendSecurityPolicy.Spec.AnthropicAPIKey.SecretRef.Name)
+		apiKey, err := c.getSecretData(ctx, namespace, secretName, apiKeyInSecret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get secret %s: %w", secretName, err)
+		}
+		return &filterapi.BackendAuth{AnthropicAPIKey: &filterapi.AnthropicAPIKeyAuth{Key: apiKey}}, nil
 	case aigv1a1.BackendSecurityPolicyTypeAWSCredentials:
 		var secretName string
 		if awsCred := backendSecurityPolicy.Spec.AWSCredentials; awsCred.CredentialsFile != nil {
@@ -74,13 +74,13 @@ func (g *gatewayMutator) Default(ctx context.Context, obj runtime.Object) error
 }
 
 // buildExtProcArgs builds all command line arguments for the extproc container.
-func (g *gatewayMutator) buildExtProcArgs(filterConfigFullPath string, extProcMetricsPort, extProcHealthPort int) []string {
+func (g *gatewayMutator) buildExtProcArgs(filterConfigFullPath string, metricsPort, healthPort int) []string {
 	args := []string{
 		"-configPath", filterConfigFullPath,
 		"-logLevel", g.extProcLogLevel,
 		"-extProcAddr", "unix://" + g.udsPath,
-		"-metricsPort", fmt.Sprintf("%d", extProcMetricsPort),
-		"-healthPort", fmt.Sprintf("%d", extProcHealthPort),
+		"-metricsPort", fmt.Sprintf("%d", metricsPort),
+		"-healthPort", fmt.Sprintf("%d", healthPort),
 	}
 
 	// Add metrics header label mapping if configured.
@@ -94,6 +94,9 @@ func (g *gatewayMutator) buildExtProcArgs(filterConfigFullPath string, extProcMe
 const (
 	mutationNamePrefix   = "ai-gateway-"
 	extProcContainerName = mutationNamePrefix + "extproc"
+	// extProcMetricsPort is the port of the metrics endpoint of the external processor, which is also scraped by the
+	// controller for the states of the circuit breakers.
+	extProcMetricsPort = 1064
 )
 
 func (g *gatewayMutator) mutatePod(ctx context.Context, pod *corev1.Pod, gatewayName, gatewayNamespace string) error {
@@ -153,7 +156,6 @@ func (g *gatewayMutator) mutatePod(ctx context.Context, pod *corev1.Pod, gateway
 		}
 	}
 	const (
-		extProcMetricsPort    = 1064
 		extProcHealthPort     = 1065
 		filterConfigMountPath = "/etc/filter-config"
 		filterConfigFullPath  = filterConfigMountPath + "/" + FilterConfigKeyInSecret
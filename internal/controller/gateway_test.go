@@ -7,6 +7,7 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
 	"strconv"
 	"testing"
 	"time"
@@ -16,6 +17,7 @@ import (
 	"go.uber.org/zap/zapcore"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	fake2 "k8s.io/client-go/kubernetes/fake"
@@ -30,6 +32,7 @@ import (
 	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
 	"github.com/envoyproxy/ai-gateway/filterapi"
 	"github.com/envoyproxy/ai-gateway/internal/controller/rotators"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
 )
 
 func TestGatewayController_Reconcile(t *testing.T) {
@@ -192,6 +195,9 @@ func TestGatewayController_reconcileFilterConfigSecret(t *testing.T) {
 					{MetadataKey: "foo", Type: aigv1a1.LLMRequestCostTypeInputToken},
 					{MetadataKey: "bar", Type: aigv1a1.LLMRequestCostTypeOutputToken},
 					{MetadataKey: "baz", Type: aigv1a1.LLMRequestCostTypeTotalToken},
+					{MetadataKey: "qux", Type: aigv1a1.LLMRequestCostTypeImage},
+					{MetadataKey: "quux", Type: aigv1a1.LLMRequestCostTypeAudioSecond},
+					{MetadataKey: "corge", Type: aigv1a1.LLMRequestCostTypeCharacter},
 				},
 			},
 		},
@@ -239,17 +245,100 @@ func TestGatewayController_reconcileFilterConfigSecret(t *testing.T) {
 		require.True(t, ok)
 		var fc filterapi.Config
 		require.NoError(t, yaml.Unmarshal([]byte(configStr), &fc))
-		require.Len(t, fc.LLMRequestCosts, 4)
+		require.Len(t, fc.LLMRequestCosts, 7)
 		require.Equal(t, filterapi.LLMRequestCostTypeInputToken, fc.LLMRequestCosts[0].Type)
 		require.Equal(t, filterapi.LLMRequestCostTypeOutputToken, fc.LLMRequestCosts[1].Type)
 		require.Equal(t, filterapi.LLMRequestCostTypeTotalToken, fc.LLMRequestCosts[2].Type)
-		require.Equal(t, filterapi.LLMRequestCostTypeCEL, fc.LLMRequestCosts[3].Type)
-		require.Equal(t, `backend == 'foo.default' ?  input_tokens + output_tokens : total_tokens`, fc.LLMRequestCosts[3].CEL)
+		require.Equal(t, filterapi.LLMRequestCostTypeImage, fc.LLMRequestCosts[3].Type)
+		require.Equal(t, filterapi.LLMRequestCostTypeAudioSecond, fc.LLMRequestCosts[4].Type)
+		require.Equal(t, filterapi.LLMRequestCostTypeCharacter, fc.LLMRequestCosts[5].Type)
+		require.Equal(t, filterapi.LLMRequestCostTypeCEL, fc.LLMRequestCosts[6].Type)
+		require.Equal(t, `backend == 'foo.default' ?  input_tokens + output_tokens : total_tokens`, fc.LLMRequestCosts[6].CEL)
 		require.Len(t, fc.Models, 1)
 		require.Equal(t, "mymodel", fc.Models[0].Name)
 	}
 }
 
+func TestGatewayController_reconcileFilterConfigSecret_modelCatalog(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	kube := fake2.NewClientset()
+	c := NewGatewayController(fakeClient, kube, ctrl.Log,
+		"docker.io/envoyproxy/ai-gateway-extproc:latest", false, nil)
+
+	const gwNamespace = "ns"
+	for _, name := range []string{"aws", "gcp", "openai"} {
+		require.NoError(t, fakeClient.Create(t.Context(), &aigv1a1.AIServiceBackend{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: gwNamespace},
+			Spec: aigv1a1.AIServiceBackendSpec{
+				BackendRef: gwapiv1.BackendObjectReference{Name: "some-backend", Namespace: ptr.To[gwapiv1.Namespace](gwNamespace)},
+			},
+		}))
+	}
+	modelMatch := func(model string) []aigv1a1.AIGatewayRouteRuleMatch {
+		return []aigv1a1.AIGatewayRouteRuleMatch{{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: model}}}}
+	}
+	routes := []aigv1a1.AIGatewayRoute{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "route1", Namespace: gwNamespace},
+			Spec: aigv1a1.AIGatewayRouteSpec{
+				Rules: []aigv1a1.AIGatewayRouteRule{
+					{
+						Matches: modelMatch("claude-sonnet"),
+						BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{
+							{Name: "aws"},
+							{Name: "gcp"},
+							{Name: "openai", ModelNameOverride: "explicit"},
+						},
+					},
+					{
+						Matches:     modelMatch("gpt-4o"),
+						BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "openai"}},
+					},
+				},
+				Models: []aigv1a1.AIGatewayRouteModel{
+					{
+						Name:          "claude-sonnet",
+						Aliases:       []string{"sonnet"},
+						ContextWindow: ptr.To[int32](200000),
+						Modalities:    []aigv1a1.AIGatewayRouteModelModality{aigv1a1.AIGatewayRouteModelModalityText},
+						OwnedBy:       ptr.To("anthropic"),
+						Backends: []aigv1a1.AIGatewayRouteModelBackend{
+							{Name: "aws", ModelName: "anthropic.claude-3-5-sonnet-20240620-v1:0"},
+							{Name: "openai", ModelName: "ignored"},
+						},
+					},
+					{Name: "unmatched", Aliases: []string{"um"}},
+				},
+			},
+		},
+	}
+
+	configName := FilterConfigSecretPerGatewayName("gw", gwNamespace)
+	require.NoError(t, c.reconcileFilterConfigSecret(t.Context(), configName, gwNamespace, routes, "foouuid"))
+	secret, err := kube.CoreV1().Secrets(gwNamespace).Get(t.Context(), configName, metav1.GetOptions{})
+	require.NoError(t, err)
+	var fc filterapi.Config
+	require.NoError(t, yaml.Unmarshal([]byte(secret.StringData[FilterConfigKeyInSecret]), &fc))
+
+	require.Len(t, fc.Models, 3)
+	require.Equal(t, "claude-sonnet", fc.Models[0].Name)
+	require.Equal(t, []string{"sonnet"}, fc.Models[0].Aliases)
+	require.Equal(t, 200000, fc.Models[0].ContextWindow)
+	require.Equal(t, []string{"Text"}, fc.Models[0].Modalities)
+	require.Equal(t, "anthropic", fc.Models[0].OwnedBy)
+	require.Equal(t, "gpt-4o", fc.Models[1].Name)
+	require.Nil(t, fc.Models[1].Aliases)
+	require.Equal(t, "unmatched", fc.Models[2].Name)
+	require.Equal(t, []string{"um"}, fc.Models[2].Aliases)
+	require.Equal(t, defaultOwnedBy, fc.Models[2].OwnedBy)
+
+	require.Len(t, fc.Backends, 4)
+	require.Equal(t, "anthropic.claude-3-5-sonnet-20240620-v1:0", fc.Backends[0].ModelNameOverride)
+	require.Equal(t, "claude-sonnet", fc.Backends[1].ModelNameOverride)
+	require.Equal(t, "explicit", fc.Backends[2].ModelNameOverride)
+	require.Empty(t, fc.Backends[3].ModelNameOverride)
+}
+
 func TestGatewayController_bspToFilterAPIBackendAuth(t *testing.T) {
 	fakeClient := requireNewFakeClientWithIndexes(t)
 	kube := fake2.NewClientset()
@@ -695,6 +784,902 @@ func Test_schemaToFilterAPI(t *testing.T) {
 	}
 }
 
+func Test_fallbackToFilterAPI(t *testing.T) {
+	require.Nil(t, fallbackToFilterAPI(nil))
+	require.Equal(t, &filterapi.BackendFallback{RetryOn: []filterapi.FallbackCondition{
+		filterapi.FallbackConditionServerError,
+		filterapi.FallbackConditionThrottled,
+	}}, fallbackToFilterAPI(&aigv1a1.AIGatewayRouteRuleFallback{}))
+	require.Equal(t, &filterapi.BackendFallback{RetryOn: []filterapi.FallbackCondition{
+		filterapi.FallbackConditionThrottled,
+	}}, fallbackToFilterAPI(&aigv1a1.AIGatewayRouteRuleFallback{
+		RetryOn: []aigv1a1.AIGatewayRouteRuleFallbackCondition{aigv1a1.AIGatewayRouteRuleFallbackConditionThrottled},
+	}))
+}
+
+func Test_adaptiveLoadBalancingToFilterAPI(t *testing.T) {
+	regex := gwapiv1.HeaderMatchRegularExpression
+	route := &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "ns"},
+		Spec: aigv1a1.AIGatewayRouteSpec{Rules: []aigv1a1.AIGatewayRouteRule{
+			{},
+			{
+				Matches: []aigv1a1.AIGatewayRouteRuleMatch{
+					{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "m"}}},
+					{Headers: []gwapiv1.HTTPHeaderMatch{
+						{Name: aigv1a1.AIModelHeaderKey, Value: "m"},
+						{Name: "X-Tier", Value: "cheap"},
+					}},
+					{Headers: []gwapiv1.HTTPHeaderMatch{
+						{Name: aigv1a1.AIModelHeaderKey, Value: "m"},
+						{Name: "x-team", Value: "a.*", Type: &regex},
+					}},
+					{Headers: []gwapiv1.HTTPHeaderMatch{{Name: "x-tier", Value: "cheap"}}},
+				},
+				BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{
+					{Name: "a"},
+					{Name: "b", Weight: ptr.To[int32](3)},
+					{Name: "c", Weight: ptr.To[int32](0)},
+				},
+				AdaptiveLoadBalancing: &aigv1a1.AIGatewayRouteRuleAdaptiveLoadBalancing{
+					Strategy:        aigv1a1.AIGatewayRouteRuleAdaptiveLoadBalancingStrategyLowestCost,
+					LatencyWindow:   &metav1.Duration{Duration: time.Second},
+					OutlierEjection: &aigv1a1.AIGatewayRouteRuleOutlierEjection{ConsecutiveErrors: ptr.To[int32](2)},
+				},
+			},
+		}},
+	}
+	cm := &aigv1a1.AIGatewayRouteModel{
+		Name: "m",
+		Backends: []aigv1a1.AIGatewayRouteModelBackend{
+			{Name: "a", ModelName: "m-a", Price: &aigv1a1.AIGatewayRouteModelPrice{InputPerMillionTokens: "2.5", OutputPerMillionTokens: "10"}},
+		},
+	}
+	actual, err := adaptiveLoadBalancingToFilterAPI(route, 1, cm)
+	require.NoError(t, err)
+	require.Equal(t, filterapi.AdaptiveLoadBalancer{
+		Name: "ns/route/rule/1",
+		Matches: []filterapi.RouteRuleMatch{
+			{Model: "m"},
+			{Model: "m", Headers: map[string]string{"x-tier": "cheap"}},
+		},
+		Strategy: filterapi.AdaptiveLoadBalancerStrategyLowestCost,
+		Backends: []filterapi.AdaptiveLoadBalancerBackend{
+			{
+				Name:   "ns/a/route/route/rule/1/ref/0",
+				Weight: 1,
+				Price:  &filterapi.ModelPrice{InputPerMillionTokens: 2.5, CachedInputPerMillionTokens: 2.5, OutputPerMillionTokens: 10},
+			},
+			{Name: "ns/b/route/route/rule/1/ref/1", Weight: 3},
+		},
+		LatencyWindow:   time.Second,
+		ErrorRateWindow: time.Minute,
+		OutlierEjection: &filterapi.OutlierEjection{
+			ConsecutiveErrors:  2,
+			BaseEjectionTime:   30 * time.Second,
+			MaxEjectionPercent: 50,
+		},
+	}, actual)
+}
+
+func Test_sessionAffinityToFilterAPI(t *testing.T) {
+	newRoute := func(sa *aigv1a1.AIGatewayRouteRuleSessionAffinity) *aigv1a1.AIGatewayRoute {
+		return &aigv1a1.AIGatewayRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "ns"},
+			Spec: aigv1a1.AIGatewayRouteSpec{Rules: []aigv1a1.AIGatewayRouteRule{{
+				Matches: []aigv1a1.AIGatewayRouteRuleMatch{
+					{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "m"}}},
+					{Headers: []gwapiv1.HTTPHeaderMatch{
+						{Name: aigv1a1.AIModelHeaderKey, Value: "m"},
+						{Name: "X-Region", Value: "eu"},
+					}},
+					{Headers: []gwapiv1.HTTPHeaderMatch{{Name: "x-region", Value: "us"}}},
+				},
+				SessionAffinity: sa,
+			}}},
+		}
+	}
+	matches := []filterapi.RouteRuleMatch{
+		{Model: "m"},
+		{Model: "m", Headers: map[string]string{"x-region": "eu"}},
+	}
+
+	actual, err := sessionAffinityToFilterAPI(newRoute(&aigv1a1.AIGatewayRouteRuleSessionAffinity{
+		Type:   aigv1a1.AIGatewayRouteRuleSessionAffinityTypeHeader,
+		Header: &aigv1a1.AIGatewayRouteRuleSessionAffinityHeader{Name: "X-Session-ID"},
+	}), 0)
+	require.NoError(t, err)
+	require.Equal(t, filterapi.SessionAffinity{
+		Name:    "ns/route/rule/0",
+		Matches: matches,
+		Type:    filterapi.SessionAffinityTypeHeader,
+		Header:  "x-session-id",
+	}, actual)
+
+	actual, err = sessionAffinityToFilterAPI(newRoute(&aigv1a1.AIGatewayRouteRuleSessionAffinity{
+		Type: aigv1a1.AIGatewayRouteRuleSessionAffinityTypeConversationPrefix,
+	}), 0)
+	require.NoError(t, err)
+	require.Equal(t, filterapi.SessionAffinity{
+		Name:         "ns/route/rule/0",
+		Matches:      matches,
+		Type:         filterapi.SessionAffinityTypeConversationPrefix,
+		UserMessages: 1,
+	}, actual)
+
+	_, err = sessionAffinityToFilterAPI(newRoute(&aigv1a1.AIGatewayRouteRuleSessionAffinity{Type: "Unknown"}), 0)
+	require.EqualError(t, err, "unknown session affinity type: Unknown")
+}
+
+func Test_hedgingToFilterAPI(t *testing.T) {
+	route := &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "ns"},
+		Spec: aigv1a1.AIGatewayRouteSpec{Rules: []aigv1a1.AIGatewayRouteRule{{
+			Matches: []aigv1a1.AIGatewayRouteRuleMatch{
+				{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "m"}}},
+				{Headers: []gwapiv1.HTTPHeaderMatch{
+					{Name: aigv1a1.AIModelHeaderKey, Value: "m"},
+					{Name: "X-Tier", Value: "fast"},
+				}},
+				{Headers: []gwapiv1.HTTPHeaderMatch{{Name: "x-tier", Value: "slow"}}},
+			},
+			Hedging: &aigv1a1.AIGatewayRouteRuleHedging{Delay: metav1.Duration{Duration: 300 * time.Millisecond}},
+		}}},
+	}
+	require.Equal(t, filterapi.Hedging{
+		Name: "ns/route/rule/0",
+		Matches: []filterapi.RouteRuleMatch{
+			{Model: "m"},
+			{Model: "m", Headers: map[string]string{"x-tier": "fast"}},
+		},
+		Delay: 300 * time.Millisecond,
+	}, hedgingToFilterAPI(route, 0))
+}
+
+func Test_requestPolicyToFilterAPI(t *testing.T) {
+	route := &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "ns"},
+		Spec: aigv1a1.AIGatewayRouteSpec{Rules: []aigv1a1.AIGatewayRouteRule{
+			{Matches: []aigv1a1.AIGatewayRouteRuleMatch{{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "other"}}}}},
+			{
+				Matches: []aigv1a1.AIGatewayRouteRuleMatch{
+					{Headers: []gwapiv1.HTTPHeaderMatch{
+						{Name: aigv1a1.AIModelHeaderKey, Value: "m"},
+						{Name: "X-Tier", Value: "free"},
+					}},
+				},
+				RequestPolicy: &aigv1a1.AIGatewayRouteRuleRequestPolicy{
+					MaxTokens: &aigv1a1.AIGatewayRouteRuleRequestPolicyLimit{
+						Max: 4096, Action: aigv1a1.AIGatewayRouteRuleRequestPolicyLimitActionClamp,
+					},
+					MaxMessages: &aigv1a1.AIGatewayRouteRuleRequestPolicyLimit{
+						Max: 100, Action: aigv1a1.AIGatewayRouteRuleRequestPolicyLimitActionReject,
+					},
+					MaxPromptBytes:    ptr.To[int64](1 << 20),
+					AllowedModalities: []aigv1a1.AIGatewayRouteModelModality{aigv1a1.AIGatewayRouteModelModalityText},
+					ForbiddenFields:   []string{"logprobs"},
+					Defaults: []aigv1a1.AIGatewayRouteRuleRequestPolicyDefault{
+						{Field: "temperature", Value: apiextensionsv1.JSON{Raw: []byte(`0.2`)}},
+					},
+				},
+			},
+		}},
+	}
+	p, err := requestPolicyToFilterAPI(route, 1)
+	require.NoError(t, err)
+	require.Equal(t, filterapi.RequestPolicy{
+		Name:              "ns/route/rule/1",
+		Matches:           []filterapi.RouteRuleMatch{{Model: "m", Headers: map[string]string{"x-tier": "free"}}},
+		MaxTokens:         &filterapi.RequestPolicyLimit{Max: 4096, Clamp: true},
+		MaxMessages:       &filterapi.RequestPolicyLimit{Max: 100},
+		MaxPromptBytes:    1 << 20,
+		AllowedModalities: []string{"Text"},
+		ForbiddenFields:   []string{"logprobs"},
+		Defaults:          []filterapi.RequestPolicyDefault{{Field: "temperature", Value: json.RawMessage(`0.2`)}},
+	}, p)
+
+	// The requests of the inexact match of the other rule can bypass the policy.
+	route.Spec.Rules[0].Matches[0].Headers[0].Type = ptr.To(gwapiv1.HeaderMatchRegularExpression)
+	_, err = requestPolicyToFilterAPI(route, 1)
+	require.ErrorContains(t, err, "match 0 of rule 0 must consist only of the exact header matches")
+}
+
+func Test_piiRedactionToFilterAPI(t *testing.T) {
+	newRoute := func(r *aigv1a1.AIGatewayRoutePIIRedaction) *aigv1a1.AIGatewayRoute {
+		return &aigv1a1.AIGatewayRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "ns"},
+			Spec: aigv1a1.AIGatewayRouteSpec{
+				Rules: []aigv1a1.AIGatewayRouteRule{
+					{Matches: []aigv1a1.AIGatewayRouteRuleMatch{
+						{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "a"}}},
+					}},
+					{Matches: []aigv1a1.AIGatewayRouteRuleMatch{
+						{Headers: []gwapiv1.HTTPHeaderMatch{
+							{Name: aigv1a1.AIModelHeaderKey, Value: "b"},
+							{Name: "X-Tenant", Value: "acme"},
+						}},
+					}},
+				},
+				PIIRedaction: r,
+			},
+		}
+	}
+	actual, err := piiRedactionToFilterAPI(newRoute(&aigv1a1.AIGatewayRoutePIIRedaction{
+		Action: aigv1a1.AIGatewayRoutePIIRedactionActionReject,
+		Detectors: []aigv1a1.AIGatewayRoutePIIDetector{
+			{Name: "email", Type: aigv1a1.AIGatewayRoutePIIDetectorTypeEmail},
+			{Name: "employee_id", Type: aigv1a1.AIGatewayRoutePIIDetectorTypeRegex, Regex: ptr.To(`EMP-[0-9]{6}`)},
+		},
+	}))
+	require.NoError(t, err)
+	require.Equal(t, filterapi.PIIRedaction{
+		Name: "ns/route",
+		Matches: []filterapi.RouteRuleMatch{
+			{Model: "a"},
+			{Model: "b", Headers: map[string]string{"x-tenant": "acme"}},
+		},
+		Action: filterapi.PIIRedactionActionReject,
+		Detectors: []filterapi.PIIDetector{
+			{Name: "email", Type: filterapi.PIIDetectorTypeEmail},
+			{Name: "employee_id", Type: filterapi.PIIDetectorTypeRegex, Regex: `EMP-[0-9]{6}`},
+		},
+	}, actual)
+
+	_, err = piiRedactionToFilterAPI(newRoute(&aigv1a1.AIGatewayRoutePIIRedaction{
+		Detectors: []aigv1a1.AIGatewayRoutePIIDetector{{Name: "bad", Type: aigv1a1.AIGatewayRoutePIIDetectorTypeRegex, Regex: ptr.To("(")}},
+	}))
+	require.ErrorContains(t, err, "invalid regex of the detector bad")
+
+	route := newRoute(&aigv1a1.AIGatewayRoutePIIRedaction{
+		Detectors: []aigv1a1.AIGatewayRoutePIIDetector{{Name: "email", Type: aigv1a1.AIGatewayRoutePIIDetectorTypeEmail}},
+	})
+	route.Spec.Rules[1].Matches = append(route.Spec.Rules[1].Matches,
+		aigv1a1.AIGatewayRouteRuleMatch{Headers: []gwapiv1.HTTPHeaderMatch{{Name: "x-tenant", Value: "other"}}})
+	_, err = piiRedactionToFilterAPI(route)
+	require.ErrorContains(t, err, "match 1 of rule 1 must consist only of the exact header matches")
+}
+
+func Test_routeExactModelMatches(t *testing.T) {
+	newRoute := func(rules ...aigv1a1.AIGatewayRouteRule) *aigv1a1.AIGatewayRoute {
+		return &aigv1a1.AIGatewayRoute{Spec: aigv1a1.AIGatewayRouteSpec{Rules: rules}}
+	}
+	modelMatch := func(model string, extra ...gwapiv1.HTTPHeaderMatch) aigv1a1.AIGatewayRouteRuleMatch {
+		return aigv1a1.AIGatewayRouteRuleMatch{
+			Headers: append([]gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: model}}, extra...),
+		}
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		matches, err := routeExactModelMatches(newRoute(
+			aigv1a1.AIGatewayRouteRule{Matches: []aigv1a1.AIGatewayRouteRuleMatch{modelMatch("a"), modelMatch("b")}},
+			aigv1a1.AIGatewayRouteRule{Matches: []aigv1a1.AIGatewayRouteRuleMatch{
+				modelMatch("c", gwapiv1.HTTPHeaderMatch{Type: ptr.To(gwapiv1.HeaderMatchExact), Name: "X-Tenant", Value: "acme"}),
+			}},
+		))
+		require.NoError(t, err)
+		require.Equal(t, []filterapi.RouteRuleMatch{
+			{Model: "a"}, {Model: "b"}, {Model: "c", Headers: map[string]string{"x-tenant": "acme"}},
+		}, matches)
+	})
+	for _, tc := range []struct {
+		name   string
+		rules  []aigv1a1.AIGatewayRouteRule
+		expErr string
+	}{
+		{
+			name:   "no matches",
+			rules:  []aigv1a1.AIGatewayRouteRule{{Matches: []aigv1a1.AIGatewayRouteRuleMatch{modelMatch("a")}}, {}},
+			expErr: "rule 1 has no matches",
+		},
+		{
+			name: "regex model",
+			rules: []aigv1a1.AIGatewayRouteRule{{Matches: []aigv1a1.AIGatewayRouteRuleMatch{{Headers: []gwapiv1.HTTPHeaderMatch{
+				{Type: ptr.To(gwapiv1.HeaderMatchRegularExpression), Name: aigv1a1.AIModelHeaderKey, Value: "gpt-.*"},
+			}}}}},
+			expErr: "match 0 of rule 0 must consist only of the exact header matches",
+		},
+		{
+			name: "regex header",
+			rules: []aigv1a1.AIGatewayRouteRule{{Matches: []aigv1a1.AIGatewayRouteRuleMatch{
+				modelMatch("a"),
+				modelMatch("b", gwapiv1.HTTPHeaderMatch{Type: ptr.To(gwapiv1.HeaderMatchRegularExpression), Name: "x-tenant", Value: ".*"}),
+			}}},
+			expErr: "match 1 of rule 0 must consist only of the exact header matches",
+		},
+		{
+			name:   "no model",
+			rules:  []aigv1a1.AIGatewayRouteRule{{Matches: []aigv1a1.AIGatewayRouteRuleMatch{{}}}},
+			expErr: "match 0 of rule 0 must consist only of the exact header matches",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := routeExactModelMatches(newRoute(tc.rules...))
+			require.ErrorContains(t, err, tc.expErr)
+		})
+	}
+}
+
+func Test_guardrailToFilterAPI(t *testing.T) {
+	newRoute := func(g *aigv1a1.AIGatewayRouteGuardrail) *aigv1a1.AIGatewayRoute {
+		return &aigv1a1.AIGatewayRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "ns"},
+			Spec: aigv1a1.AIGatewayRouteSpec{
+				Rules: []aigv1a1.AIGatewayRouteRule{
+					{Matches: []aigv1a1.AIGatewayRouteRuleMatch{
+						{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "a"}}},
+					}},
+					{Matches: []aigv1a1.AIGatewayRouteRuleMatch{
+						{Headers: []gwapiv1.HTTPHeaderMatch{
+							{Name: aigv1a1.AIModelHeaderKey, Value: "b"},
+							{Name: "X-Tenant", Value: "acme"},
+						}},
+					}},
+				},
+				Guardrail: g,
+			},
+		}
+	}
+	actual, err := guardrailToFilterAPI(newRoute(&aigv1a1.AIGatewayRouteGuardrail{Endpoint: "http://moderation.ns:8080/check"}))
+	require.NoError(t, err)
+	require.Equal(t, filterapi.Guardrail{
+		Name: "ns/route",
+		Matches: []filterapi.RouteRuleMatch{
+			{Model: "a"},
+			{Model: "b", Headers: map[string]string{"x-tenant": "acme"}},
+		},
+		Protocol:         filterapi.GuardrailProtocolHTTP,
+		Endpoint:         "http://moderation.ns:8080/check",
+		Request:          true,
+		Response:         true,
+		Timeout:          time.Second,
+		MaxResponseBytes: 1 << 20,
+	}, actual)
+
+	actual, err = guardrailToFilterAPI(newRoute(&aigv1a1.AIGatewayRouteGuardrail{
+		Protocol:         aigv1a1.AIGatewayRouteGuardrailProtocolGRPC,
+		Endpoint:         "moderation.ns:9090",
+		Phases:           []aigv1a1.AIGatewayRouteGuardrailPhase{aigv1a1.AIGatewayRouteGuardrailPhaseResponse},
+		Timeout:          &metav1.Duration{Duration: 200 * time.Millisecond},
+		FailureMode:      aigv1a1.AIGatewayRouteGuardrailFailureModeFailOpen,
+		MaxResponseBytes: ptr.To[int32](4096),
+	}))
+	require.NoError(t, err)
+	require.Equal(t, filterapi.GuardrailProtocolGRPC, actual.Protocol)
+	require.Equal(t, "moderation.ns:9090", actual.Endpoint)
+	require.False(t, actual.Request)
+	require.True(t, actual.Response)
+	require.Equal(t, 200*time.Millisecond, actual.Timeout)
+	require.True(t, actual.FailOpen)
+	require.Equal(t, 4096, actual.MaxResponseBytes)
+
+	route := newRoute(&aigv1a1.AIGatewayRouteGuardrail{Endpoint: "http://moderation.ns:8080/check"})
+	route.Spec.Rules = append(route.Spec.Rules, aigv1a1.AIGatewayRouteRule{})
+	_, err = guardrailToFilterAPI(route)
+	require.ErrorContains(t, err, "rule 2 has no matches")
+}
+
+func Test_toolPolicyToFilterAPI(t *testing.T) {
+	newRoute := func(p *aigv1a1.AIGatewayRouteToolPolicy) *aigv1a1.AIGatewayRoute {
+		return &aigv1a1.AIGatewayRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "ns"},
+			Spec: aigv1a1.AIGatewayRouteSpec{
+				Rules: []aigv1a1.AIGatewayRouteRule{
+					{Matches: []aigv1a1.AIGatewayRouteRuleMatch{
+						{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "a"}}},
+					}},
+					{Matches: []aigv1a1.AIGatewayRouteRuleMatch{
+						{Headers: []gwapiv1.HTTPHeaderMatch{
+							{Name: aigv1a1.AIModelHeaderKey, Value: "b"},
+							{Name: "X-Tenant", Value: "acme"},
+						}},
+					}},
+				},
+				ToolPolicy: p,
+			},
+		}
+	}
+	actual, err := toolPolicyToFilterAPI(newRoute(&aigv1a1.AIGatewayRouteToolPolicy{}))
+	require.NoError(t, err)
+	require.Equal(t, filterapi.ToolPolicy{
+		Name: "ns/route",
+		Matches: []filterapi.RouteRuleMatch{
+			{Model: "a"},
+			{Model: "b", Headers: map[string]string{"x-tenant": "acme"}},
+		},
+		ValidateArguments: true,
+		Action:            filterapi.ToolPolicyActionBlock,
+	}, actual)
+
+	actual, err = toolPolicyToFilterAPI(newRoute(&aigv1a1.AIGatewayRouteToolPolicy{
+		AllowedFunctions:  []string{"get_weather", "get_time"},
+		ValidateArguments: ptr.To(false),
+		Action:            aigv1a1.AIGatewayRouteToolPolicyActionRewrite,
+	}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"get_weather", "get_time"}, actual.AllowedFunctions)
+	require.False(t, actual.ValidateArguments)
+	require.Equal(t, filterapi.ToolPolicyActionRewrite, actual.Action)
+
+	// The rule matching any model would let the requests bypass the tool policy.
+	route := newRoute(&aigv1a1.AIGatewayRouteToolPolicy{})
+	route.Spec.Rules[1].Matches = append(route.Spec.Rules[1].Matches, aigv1a1.AIGatewayRouteRuleMatch{
+		Headers: []gwapiv1.HTTPHeaderMatch{{Name: "X-Tenant", Value: "acme"}},
+	})
+	_, err = toolPolicyToFilterAPI(route)
+	require.ErrorContains(t, err, "match 1 of rule 1 must consist only of the exact header matches")
+}
+
+func Test_appendCircuitBreakerBackend(t *testing.T) {
+	noBreaker := &aigv1a1.AIServiceBackend{ObjectMeta: metav1.ObjectMeta{Name: "openai", Namespace: "ns"}}
+	serverErrorOnly := &aigv1a1.AIServiceBackend{
+		ObjectMeta: metav1.ObjectMeta{Name: "bedrock", Namespace: "ns"},
+		Spec: aigv1a1.AIServiceBackendSpec{CircuitBreaker: &aigv1a1.AIServiceBackendCircuitBreaker{
+			FailOn: []aigv1a1.AIServiceBackendCircuitBreakerErrorClass{aigv1a1.AIServiceBackendCircuitBreakerErrorClassServerError},
+		}},
+	}
+	defaults := &aigv1a1.AIServiceBackend{
+		ObjectMeta: metav1.ObjectMeta{Name: "vertex", Namespace: "ns"},
+		Spec:       aigv1a1.AIServiceBackendSpec{CircuitBreaker: &aigv1a1.AIServiceBackendCircuitBreaker{}},
+	}
+	custom := &aigv1a1.AIServiceBackend{
+		ObjectMeta: metav1.ObjectMeta{Name: "anthropic", Namespace: "ns"},
+		Spec: aigv1a1.AIServiceBackendSpec{CircuitBreaker: &aigv1a1.AIServiceBackendCircuitBreaker{
+			ConsecutiveFailures: ptr.To[int32](3),
+			FailOn:              []aigv1a1.AIServiceBackendCircuitBreakerErrorClass{aigv1a1.AIServiceBackendCircuitBreakerErrorClassThrottled},
+			OpenDuration:        &metav1.Duration{Duration: time.Minute},
+			HalfOpenProbes:      ptr.To[int32](2),
+		}},
+	}
+
+	var cbs []filterapi.CircuitBreaker
+	cbs = appendCircuitBreakerBackend(cbs, noBreaker, "ns/openai/route/route/rule/0/ref/0")
+	cbs = appendCircuitBreakerBackend(cbs, serverErrorOnly, "ns/bedrock/route/route/rule/0/ref/1")
+	require.Empty(t, cbs)
+	cbs = appendCircuitBreakerBackend(cbs, defaults, "ns/vertex/route/route/rule/0/ref/2")
+	cbs = appendCircuitBreakerBackend(cbs, custom, "ns/anthropic/route/route/rule/0/ref/3")
+	// The same AIServiceBackend referenced by another rule shares the circuit breaker.
+	cbs = appendCircuitBreakerBackend(cbs, defaults, "ns/vertex/route/route/rule/1/ref/0")
+	require.Equal(t, []filterapi.CircuitBreaker{
+		{
+			Name:                "vertex.ns",
+			Backends:            []string{"ns/vertex/route/route/rule/0/ref/2", "ns/vertex/route/route/rule/1/ref/0"},
+			ConsecutiveFailures: 5,
+			FailOn:              []filterapi.CircuitBreakerErrorClass{filterapi.CircuitBreakerErrorClassThrottled},
+			OpenDuration:        30 * time.Second,
+			HalfOpenProbes:      1,
+		},
+		{
+			Name:                "anthropic.ns",
+			Backends:            []string{"ns/anthropic/route/route/rule/0/ref/3"},
+			ConsecutiveFailures: 3,
+			FailOn:              []filterapi.CircuitBreakerErrorClass{filterapi.CircuitBreakerErrorClassThrottled},
+			OpenDuration:        time.Minute,
+			HalfOpenProbes:      2,
+		},
+	}, cbs)
+}
+
+func Test_catalogPriceForBackend(t *testing.T) {
+	cm := &aigv1a1.AIGatewayRouteModel{
+		Name: "m",
+		Backends: []aigv1a1.AIGatewayRouteModelBackend{
+			{Name: "no-price", ModelName: "m"},
+			{Name: "priced", ModelName: "m", Price: &aigv1a1.AIGatewayRouteModelPrice{
+				InputPerMillionTokens:       "3",
+				CachedInputPerMillionTokens: ptr.To("0.3"),
+				OutputPerMillionTokens:      "15",
+			}},
+			{Name: "invalid", ModelName: "m", Price: &aigv1a1.AIGatewayRouteModelPrice{InputPerMillionTokens: "x", OutputPerMillionTokens: "1"}},
+		},
+	}
+	price, err := catalogPriceForBackend(nil, "priced")
+	require.NoError(t, err)
+	require.Nil(t, price)
+	price, err = catalogPriceForBackend(cm, "no-price")
+	require.NoError(t, err)
+	require.Nil(t, price)
+	price, err = catalogPriceForBackend(cm, "unknown")
+	require.NoError(t, err)
+	require.Nil(t, price)
+	price, err = catalogPriceForBackend(cm, "priced")
+	require.NoError(t, err)
+	require.Equal(t, &filterapi.ModelPrice{InputPerMillionTokens: 3, CachedInputPerMillionTokens: 0.3, OutputPerMillionTokens: 15}, price)
+	_, err = catalogPriceForBackend(cm, "invalid")
+	require.ErrorContains(t, err, "invalid inputPerMillionTokens of model m at backend invalid")
+}
+
+func TestGatewayController_responseCacheToFilterAPI(t *testing.T) {
+	kube := fake2.NewClientset()
+	c := NewGatewayController(requireNewFakeClientWithIndexes(t), kube, ctrl.Log, "ai-gateway-extproc:v2", false, nil)
+	_, err := kube.CoreV1().Secrets("ns").Create(t.Context(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "redis", Namespace: "ns"},
+		Data:       map[string][]byte{redisPasswordInSecret: []byte("secret"), redisCACertificateInSecret: []byte("ca")},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	for _, tc := range []struct {
+		name     string
+		in       *aigv1a1.ResponseCache
+		expected *filterapi.ResponseCacheConfig
+	}{
+		{
+			name:     "defaults",
+			in:       &aigv1a1.ResponseCache{},
+			expected: &filterapi.ResponseCacheConfig{TTL: time.Hour, MaxEntries: 1024, MaxEntryBytes: 1 << 20},
+		},
+		{
+			name: "memory",
+			in: &aigv1a1.ResponseCache{
+				Type:          aigv1a1.ResponseCacheTypeMemory,
+				TTL:           &metav1.Duration{Duration: time.Minute},
+				MaxEntries:    ptr.To[int32](10),
+				MaxEntryBytes: ptr.To[int32](100),
+			},
+			expected: &filterapi.ResponseCacheConfig{TTL: time.Minute, MaxEntries: 10, MaxEntryBytes: 100},
+		},
+		{
+			name: "redis",
+			in: &aigv1a1.ResponseCache{
+				Type:  aigv1a1.ResponseCacheTypeRedis,
+				Redis: &aigv1a1.ResponseCacheRedis{Address: "redis:6379"},
+			},
+			expected: &filterapi.ResponseCacheConfig{
+				TTL: time.Hour, MaxEntryBytes: 1 << 20,
+				Redis: &filterapi.ResponseCacheRedisConfig{Address: "redis:6379", KeyPrefix: "aigw:response-cache:"},
+			},
+		},
+		{
+			name: "redis with auth and tls",
+			in: &aigv1a1.ResponseCache{
+				Type: aigv1a1.ResponseCacheTypeRedis,
+				Redis: &aigv1a1.ResponseCacheRedis{
+					Address:           "redis:6379",
+					Username:          ptr.To("user"),
+					PasswordSecretRef: &gwapiv1.SecretObjectReference{Name: "redis"},
+					TLS: &aigv1a1.RedisTLS{
+						ServerName:             ptr.To("redis.example"),
+						CACertificateSecretRef: &gwapiv1.SecretObjectReference{Name: "redis"},
+					},
+				},
+			},
+			expected: &filterapi.ResponseCacheConfig{
+				TTL: time.Hour, MaxEntryBytes: 1 << 20,
+				Redis: &filterapi.ResponseCacheRedisConfig{
+					Address: "redis:6379", KeyPrefix: "aigw:response-cache:", Username: "user", Password: "secret",
+					TLS: &filterapi.RedisTLSConfig{ServerName: "redis.example", CACertificate: "ca"},
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := c.responseCacheToFilterAPI(t.Context(), "ns", tc.in)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+
+	_, err = c.responseCacheToFilterAPI(t.Context(), "ns", &aigv1a1.ResponseCache{
+		Type:  aigv1a1.ResponseCacheTypeRedis,
+		Redis: &aigv1a1.ResponseCacheRedis{Address: "redis:6379", PasswordSecretRef: &gwapiv1.SecretObjectReference{Name: "missing"}},
+	})
+	require.ErrorContains(t, err, "failed to read the redis password")
+	_, err = c.responseCacheToFilterAPI(t.Context(), "ns", &aigv1a1.ResponseCache{
+		Type: aigv1a1.ResponseCacheTypeRedis,
+		Redis: &aigv1a1.ResponseCacheRedis{
+			Address: "redis:6379",
+			TLS:     &aigv1a1.RedisTLS{CACertificateSecretRef: &gwapiv1.SecretObjectReference{Name: "missing"}},
+		},
+	})
+	require.ErrorContains(t, err, "failed to read the redis CA certificate")
+}
+
+func TestGatewayController_semanticResponseCacheToFilterAPI(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	c := NewGatewayController(fakeClient, fake2.NewClientset(), ctrl.Log, "ai-gateway-extproc:v2", false, nil)
+	sem := &aigv1a1.SemanticResponseCache{
+		EmbeddingBackendRef: "embedding",
+		EmbeddingEndpoint:   "https://api.openai.com",
+		EmbeddingModel:      "text-embedding-3-small",
+	}
+
+	_, err := c.semanticResponseCacheToFilterAPI(t.Context(), "foo", sem)
+	require.ErrorContains(t, err, `aiservicebackends.aigateway.envoyproxy.io "embedding" not found`)
+
+	require.NoError(t, fakeClient.Create(t.Context(), &aigv1a1.AIServiceBackend{
+		ObjectMeta: metav1.ObjectMeta{Name: "embedding", Namespace: "foo"},
+		Spec: aigv1a1.AIServiceBackendSpec{
+			APISchema: aigv1a1.VersionedAPISchema{Name: aigv1a1.APISchemaOpenAI, Version: ptr.To("v1")},
+		},
+	}))
+	actual, err := c.semanticResponseCacheToFilterAPI(t.Context(), "foo", sem)
+	require.NoError(t, err)
+	require.Equal(t, &filterapi.SemanticResponseCacheConfig{
+		SimilarityThreshold: 0.95,
+		MaxEntries:          1024,
+		Embedding: filterapi.SemanticResponseCacheEmbedding{
+			Endpoint: "https://api.openai.com",
+			Model:    "text-embedding-3-small",
+			Timeout:  time.Second,
+			Backend: filterapi.Backend{
+				Name:   "embedding.foo",
+				Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI, Version: "v1"},
+			},
+		},
+	}, actual)
+
+	sem.SimilarityThreshold = ptr.To("0.8")
+	sem.MaxEntries = ptr.To[int32](10)
+	sem.EmbeddingTimeout = &metav1.Duration{Duration: 5 * time.Second}
+	actual, err = c.semanticResponseCacheToFilterAPI(t.Context(), "foo", sem)
+	require.NoError(t, err)
+	require.Equal(t, 0.8, actual.SimilarityThreshold)
+	require.Equal(t, 10, actual.MaxEntries)
+	require.Equal(t, 5*time.Second, actual.Embedding.Timeout)
+
+	sem.SimilarityThreshold = ptr.To("high")
+	_, err = c.semanticResponseCacheToFilterAPI(t.Context(), "foo", sem)
+	require.ErrorContains(t, err, "invalid similarity threshold")
+}
+
+func TestGatewayController_mirrorToFilterAPI(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	c := NewGatewayController(fakeClient, fake2.NewClientset(), ctrl.Log, "ai-gateway-extproc:v2", false, nil)
+	route := &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "foo"},
+		Spec: aigv1a1.AIGatewayRouteSpec{Rules: []aigv1a1.AIGatewayRouteRule{
+			{},
+			{
+				BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "a"}, {Name: "b"}},
+				Mirror: &aigv1a1.AIGatewayRouteRuleMirror{
+					BackendRef:        "candidate",
+					Endpoint:          "https://api.openai.com",
+					ModelNameOverride: "gpt-5",
+					Sink: aigv1a1.AIGatewayRouteRuleMirrorSink{
+						Type: aigv1a1.AIGatewayRouteRuleMirrorSinkTypeFile,
+						File: &aigv1a1.AIGatewayRouteRuleMirrorFileSink{Path: "/tmp/mirror.jsonl"},
+					},
+				},
+			},
+		}},
+	}
+
+	_, err := c.mirrorToFilterAPI(t.Context(), route, 1)
+	require.ErrorContains(t, err, `aiservicebackends.aigateway.envoyproxy.io "candidate" not found`)
+
+	require.NoError(t, fakeClient.Create(t.Context(), &aigv1a1.AIServiceBackend{
+		ObjectMeta: metav1.ObjectMeta{Name: "candidate", Namespace: "foo"},
+		Spec: aigv1a1.AIServiceBackendSpec{
+			APISchema: aigv1a1.VersionedAPISchema{Name: aigv1a1.APISchemaOpenAI, Version: ptr.To("v1")},
+		},
+	}))
+	actual, err := c.mirrorToFilterAPI(t.Context(), route, 1)
+	require.NoError(t, err)
+	require.Equal(t, filterapi.Mirror{
+		Name: "foo/route/rule/1",
+		Backends: []string{
+			internalapi.PerRouteRuleRefBackendName("foo", "a", "route", 1, 0),
+			internalapi.PerRouteRuleRefBackendName("foo", "b", "route", 1, 1),
+		},
+		Endpoint:          "https://api.openai.com",
+		ModelNameOverride: "gpt-5",
+		Percentage:        100,
+		Timeout:           60 * time.Second,
+		Backend: filterapi.Backend{
+			Name:   "candidate.foo",
+			Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI, Version: "v1"},
+		},
+		Sink: filterapi.MirrorSink{Type: filterapi.MirrorSinkTypeFile, FilePath: "/tmp/mirror.jsonl"},
+	}, actual)
+
+	route.Spec.Rules[1].Mirror.Percentage = ptr.To[int32](10)
+	route.Spec.Rules[1].Mirror.Timeout = &metav1.Duration{Duration: 5 * time.Second}
+	route.Spec.Rules[1].Mirror.Sink = aigv1a1.AIGatewayRouteRuleMirrorSink{
+		Type:     aigv1a1.AIGatewayRouteRuleMirrorSinkTypeOTLPLogs,
+		OTLPLogs: &aigv1a1.AIGatewayRouteRuleMirrorOTLPLogsSink{Endpoint: "http://otel-collector:4318/v1/logs"},
+	}
+	actual, err = c.mirrorToFilterAPI(t.Context(), route, 1)
+	require.NoError(t, err)
+	require.Equal(t, 10, actual.Percentage)
+	require.Equal(t, 5*time.Second, actual.Timeout)
+	require.Equal(t, filterapi.MirrorSink{Type: filterapi.MirrorSinkTypeOTLPLogs, OTLPEndpoint: "http://otel-collector:4318/v1/logs"}, actual.Sink)
+
+	route.Spec.Rules[1].Mirror.Sink = aigv1a1.AIGatewayRouteRuleMirrorSink{Type: "Unknown"}
+	_, err = c.mirrorToFilterAPI(t.Context(), route, 1)
+	require.ErrorContains(t, err, "unknown mirror sink type: Unknown")
+}
+
+func TestGatewayController_quotaPolicyToFilterAPI(t *testing.T) {
+	kube := fake2.NewClientset()
+	c := NewGatewayController(requireNewFakeClientWithIndexes(t), kube, ctrl.Log, "ai-gateway-extproc:v2", false, nil)
+	spec := &aigv1a1.AIGatewayQuotaPolicySpec{
+		ClientKeyHeader: "x-api-key",
+		Budgets: []aigv1a1.AIGatewayQuotaBudget{
+			{Type: aigv1a1.AIGatewayQuotaBudgetTypeInputToken, Limit: 100, Window: metav1.Duration{Duration: time.Minute}},
+			{Type: aigv1a1.AIGatewayQuotaBudgetTypeOutputToken, Limit: 200, Window: metav1.Duration{Duration: time.Hour}},
+			{Type: aigv1a1.AIGatewayQuotaBudgetTypeTotalToken, Limit: 300, Window: metav1.Duration{Duration: 24 * time.Hour}},
+			{Type: aigv1a1.AIGatewayQuotaBudgetTypeSpend, Limit: 5000, Window: metav1.Duration{Duration: 24 * time.Hour}},
+		},
+	}
+	actual, err := c.quotaPolicyToFilterAPI(t.Context(), "ns", "ns/policy", spec)
+	require.NoError(t, err)
+	require.Equal(t, &filterapi.QuotaPolicy{
+		Name:            "ns/policy",
+		ClientKeyHeader: "x-api-key",
+		Budgets: []filterapi.QuotaBudget{
+			{Type: filterapi.QuotaBudgetTypeInputToken, Limit: 100, Window: time.Minute},
+			{Type: filterapi.QuotaBudgetTypeOutputToken, Limit: 200, Window: time.Hour},
+			{Type: filterapi.QuotaBudgetTypeTotalToken, Limit: 300, Window: 24 * time.Hour},
+			// $50 in micro US dollars.
+			{Type: filterapi.QuotaBudgetTypeSpend, Limit: 50_000_000, Window: 24 * time.Hour},
+		},
+	}, actual)
+
+	spec.Type = aigv1a1.AIGatewayQuotaStoreTypeRedis
+	spec.Redis = &aigv1a1.AIGatewayQuotaRedis{Address: "redis:6379"}
+	actual, err = c.quotaPolicyToFilterAPI(t.Context(), "ns", "ns/policy", spec)
+	require.NoError(t, err)
+	require.Equal(t, &filterapi.QuotaRedisConfig{Address: "redis:6379", KeyPrefix: "aigw:quota:"}, actual.Redis)
+
+	spec.Redis.PasswordSecretRef = &gwapiv1.SecretObjectReference{Name: "redis"}
+	spec.Redis.TLS = &aigv1a1.RedisTLS{}
+	_, err = c.quotaPolicyToFilterAPI(t.Context(), "ns", "ns/policy", spec)
+	require.ErrorContains(t, err, "failed to read the redis password")
+	_, err = kube.CoreV1().Secrets("ns").Create(t.Context(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "redis", Namespace: "ns"},
+		Data:       map[string][]byte{redisPasswordInSecret: []byte("secret")},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	actual, err = c.quotaPolicyToFilterAPI(t.Context(), "ns", "ns/policy", spec)
+	require.NoError(t, err)
+	require.Equal(t, &filterapi.QuotaRedisConfig{
+		Address: "redis:6379", KeyPrefix: "aigw:quota:", Password: "secret", TLS: &filterapi.RedisTLSConfig{},
+	}, actual.Redis)
+
+	spec.Budgets[0].Type = "Unknown"
+	_, err = c.quotaPolicyToFilterAPI(t.Context(), "ns", "ns/policy", spec)
+	require.ErrorContains(t, err, "unknown quota budget type: Unknown")
+}
+
+func TestGatewayController_appendQuotaPolicies(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	c := NewGatewayController(fakeClient, fake2.NewClientset(), ctrl.Log, "ai-gateway-extproc:v2", false, nil)
+	routeA := &aigv1a1.AIGatewayRoute{ObjectMeta: metav1.ObjectMeta{Name: "route-a", Namespace: "ns"}}
+	routeB := &aigv1a1.AIGatewayRoute{ObjectMeta: metav1.ObjectMeta{Name: "route-b", Namespace: "ns"}}
+
+	quotas, err := c.appendQuotaPolicies(t.Context(), nil, routeA, []string{"model-a"})
+	require.NoError(t, err)
+	require.Empty(t, quotas)
+
+	require.NoError(t, fakeClient.Create(t.Context(), &aigv1a1.AIGatewayQuotaPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "ns"},
+		Spec: aigv1a1.AIGatewayQuotaPolicySpec{
+			TargetRefs: []gwapiv1a2.LocalPolicyTargetReference{
+				{Group: "aigateway.envoyproxy.io", Kind: "AIGatewayRoute", Name: "route-a"},
+				{Group: "aigateway.envoyproxy.io", Kind: "AIGatewayRoute", Name: "route-b"},
+			},
+			ClientKeyHeader: "x-api-key",
+			Budgets: []aigv1a1.AIGatewayQuotaBudget{
+				{Type: aigv1a1.AIGatewayQuotaBudgetTypeTotalToken, Limit: 100, Window: metav1.Duration{Duration: time.Minute}},
+			},
+		},
+	}))
+
+	quotas, err = c.appendQuotaPolicies(t.Context(), nil, routeA, []string{"model-a"})
+	require.NoError(t, err)
+	quotas, err = c.appendQuotaPolicies(t.Context(), quotas, routeB, []string{"model-b1", "model-b2"})
+	require.NoError(t, err)
+	require.Equal(t, []filterapi.QuotaPolicy{{
+		Name:            "ns/policy",
+		Models:          []string{"model-a", "model-b1", "model-b2"},
+		ClientKeyHeader: "x-api-key",
+		Budgets:         []filterapi.QuotaBudget{{Type: filterapi.QuotaBudgetTypeTotalToken, Limit: 100, Window: time.Minute}},
+	}}, quotas)
+}
+
+func TestGatewayController_appendVirtualKeyPolicies(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	kube := fake2.NewClientset()
+	c := NewGatewayController(fakeClient, kube, ctrl.Log, "ai-gateway-extproc:v2", false, nil)
+	routeA := &aigv1a1.AIGatewayRoute{ObjectMeta: metav1.ObjectMeta{Name: "route-a", Namespace: "ns"}}
+	routeB := &aigv1a1.AIGatewayRoute{ObjectMeta: metav1.ObjectMeta{Name: "route-b", Namespace: "ns"}}
+
+	policies, quotas, err := c.appendVirtualKeyPolicies(t.Context(), nil, nil, routeA, []string{"model-a"})
+	require.NoError(t, err)
+	require.Empty(t, policies)
+	require.Empty(t, quotas)
+
+	_, err = kube.CoreV1().Secrets("ns").Create(t.Context(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "ns"},
+		Data:       map[string][]byte{apiKeyInSecret: []byte("sk-team-a\n")},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, fakeClient.Create(t.Context(), &aigv1a1.AIGatewayVirtualKeyPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "keys", Namespace: "ns"},
+		Spec: aigv1a1.AIGatewayVirtualKeyPolicySpec{
+			TargetRefs: []gwapiv1a2.LocalPolicyTargetReference{
+				{Group: "aigateway.envoyproxy.io", Kind: "AIGatewayRoute", Name: "route-a"},
+				{Group: "aigateway.envoyproxy.io", Kind: "AIGatewayRoute", Name: "route-b"},
+			},
+			Keys: []aigv1a1.AIGatewayVirtualKey{
+				{
+					Name:          "team-a",
+					SecretRef:     &gwapiv1.SecretObjectReference{Name: "team-a"},
+					TeamID:        "a",
+					AllowedModels: []string{"model-a"},
+					Budgets: []aigv1a1.AIGatewayQuotaBudget{
+						{Type: aigv1a1.AIGatewayQuotaBudgetTypeTotalToken, Limit: 100, Window: metav1.Duration{Duration: time.Minute}},
+					},
+				},
+				// The Secret doesn't exist, so the key is skipped.
+				{Name: "team-b", SecretRef: &gwapiv1.SecretObjectReference{Name: "team-b"}, TeamID: "b"},
+			},
+			Type:  aigv1a1.AIGatewayQuotaStoreTypeRedis,
+			Redis: &aigv1a1.AIGatewayQuotaRedis{Address: "redis:6379"},
+		},
+	}))
+
+	policies, quotas, err = c.appendVirtualKeyPolicies(t.Context(), nil, nil, routeA, []string{"model-a"})
+	require.NoError(t, err)
+	policies, quotas, err = c.appendVirtualKeyPolicies(t.Context(), policies, quotas, routeB, []string{"model-b"})
+	require.NoError(t, err)
+	require.Equal(t, []filterapi.VirtualKeyPolicy{{
+		Name:            "ns/keys",
+		Models:          []string{"model-a", "model-b"},
+		ClientKeyHeader: "authorization",
+		TeamIDHeader:    "x-ai-eg-team-id",
+		Keys: []filterapi.VirtualKey{{
+			Name:          "ns/keys/team-a",
+			Hash:          "8879f6a4ae35c420a15d35fed3b8dd07577207803d404f6d4cc4fa829dafa910",
+			TeamID:        "a",
+			AllowedModels: []string{"model-a"},
+		}},
+	}}, policies)
+	require.Equal(t, []filterapi.QuotaPolicy{{
+		Name:            "ns/keys/key/team-a",
+		Models:          []string{"model-a", "model-b"},
+		ClientKeyHeader: internalapi.VirtualKeyHeaderKey,
+		ClientKeys:      []string{"ns/keys/team-a"},
+		Budgets:         []filterapi.QuotaBudget{{Type: filterapi.QuotaBudgetTypeTotalToken, Limit: 100, Window: time.Minute}},
+		Redis:           &filterapi.QuotaRedisConfig{Address: "redis:6379", KeyPrefix: defaultQuotaRedisKeyPrefix},
+	}}, quotas)
+}
+
+func TestGatewayController_appendBodyHeaders(t *testing.T) {
+	c := NewGatewayController(requireNewFakeClientWithIndexes(t), fake2.NewClientset(), ctrl.Log, "ai-gateway-extproc:v2", false, nil)
+	routeA := &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "ns"},
+		Spec: aigv1a1.AIGatewayRouteSpec{BodyHeaders: []aigv1a1.AIGatewayRouteBodyHeader{
+			{Name: "x-has-tools", CEL: ptr.To("has(request.tools)")},
+			{Name: "x-response-format", JSONPath: ptr.To("$.response_format.type")},
+		}},
+	}
+	routeB := &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "ns"},
+		Spec: aigv1a1.AIGatewayRouteSpec{BodyHeaders: []aigv1a1.AIGatewayRouteBodyHeader{
+			{Name: "x-has-tools", CEL: ptr.To("true")},
+			{Name: "x-effort", JSONPath: ptr.To("$.reasoning_effort")},
+		}},
+	}
+
+	headers, err := c.appendBodyHeaders(nil, routeA)
+	require.NoError(t, err)
+	headers, err = c.appendBodyHeaders(headers, routeB)
+	require.NoError(t, err)
+	require.Equal(t, []filterapi.BodyHeader{
+		{Name: "x-has-tools", CEL: "has(request.tools)"},
+		{Name: "x-response-format", JSONPath: "$.response_format.type"},
+		{Name: "x-effort", JSONPath: "$.reasoning_effort"},
+	}, headers)
+
+	_, err = c.appendBodyHeaders(nil, &aigv1a1.AIGatewayRoute{Spec: aigv1a1.AIGatewayRouteSpec{
+		BodyHeaders: []aigv1a1.AIGatewayRouteBodyHeader{{Name: "x-invalid", CEL: ptr.To("request.")}},
+	}})
+	require.ErrorContains(t, err, "invalid body header x-invalid: cannot compile CEL expression")
+	_, err = c.appendBodyHeaders(nil, &aigv1a1.AIGatewayRoute{Spec: aigv1a1.AIGatewayRouteSpec{
+		BodyHeaders: []aigv1a1.AIGatewayRouteBodyHeader{{Name: "x-invalid", JSONPath: ptr.To("$.a[")}},
+	}})
+	require.ErrorContains(t, err, "invalid body header x-invalid: cannot parse JSONPath expression")
+}
+
 func TestGatewayController_backendWithMaybeBSP(t *testing.T) {
 	fakeClient := requireNewFakeClientWithIndexes(t)
 	kube := fake2.NewClientset()
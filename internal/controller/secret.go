@@ -27,17 +27,19 @@ type secretController struct {
 	kubeClient                     kubernetes.Interface
 	logger                         logr.Logger
 	backendSecurityPolicyEventChan chan event.GenericEvent
+	virtualKeyPolicyEventChan      chan event.GenericEvent
 }
 
 // NewSecretController creates a new reconcile.TypedReconciler[reconcile.Request] for corev1.Secret.
 func NewSecretController(client client.Client, kubeClient kubernetes.Interface,
-	logger logr.Logger, backendSecurityPolicyEventChan chan event.GenericEvent,
+	logger logr.Logger, backendSecurityPolicyEventChan, virtualKeyPolicyEventChan chan event.GenericEvent,
 ) reconcile.TypedReconciler[reconcile.Request] {
 	return &secretController{
 		client:                         client,
 		kubeClient:                     kubeClient,
 		logger:                         logger,
 		backendSecurityPolicyEventChan: backendSecurityPolicyEventChan,
+		virtualKeyPolicyEventChan:      virtualKeyPolicyEventChan,
 	}
 }
 
@@ -74,5 +76,21 @@ func (c *secretController) syncSecret(ctx context.Context, namespace, name strin
 			"namespace", backendSecurityPolicy.Namespace, "name", backendSecurityPolicy.Name)
 		c.backendSecurityPolicyEventChan <- event.GenericEvent{Object: backendSecurityPolicy}
 	}
+
+	var virtualKeyPolicies aigv1a1.AIGatewayVirtualKeyPolicyList
+	err = c.client.List(ctx, &virtualKeyPolicies,
+		client.MatchingFields{
+			k8sClientIndexSecretToReferencingVirtualKeyPolicy: fmt.Sprintf("%s.%s", name, namespace),
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to list AIGatewayVirtualKeyPolicyList: %w", err)
+	}
+	for i := range virtualKeyPolicies.Items {
+		virtualKeyPolicy := &virtualKeyPolicies.Items[i]
+		c.logger.Info("Syncing AIGatewayVirtualKeyPolicy",
+			"namespace", virtualKeyPolicy.Namespace, "name", virtualKeyPolicy.Name)
+		c.virtualKeyPolicyEventChan <- event.GenericEvent{Object: virtualKeyPolicy}
+	}
 	return nil
 }
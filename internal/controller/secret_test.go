@@ -24,8 +24,9 @@ import (
 
 func TestSecretController_Reconcile(t *testing.T) {
 	eventCh := internaltesting.NewControllerEventChan[*aigv1a1.BackendSecurityPolicy]()
+	virtualKeyEventCh := internaltesting.NewControllerEventChan[*aigv1a1.AIGatewayVirtualKeyPolicy]()
 	fakeClient := requireNewFakeClientWithIndexes(t)
-	c := NewSecretController(fakeClient, fake2.NewClientset(), ctrl.Log, eventCh.Ch)
+	c := NewSecretController(fakeClient, fake2.NewClientset(), ctrl.Log, eventCh.Ch, virtualKeyEventCh.Ch)
 
 	err := fakeClient.Create(t.Context(), &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "default"},
@@ -56,6 +57,16 @@ func TestSecretController_Reconcile(t *testing.T) {
 	for _, bsp := range originals {
 		require.NoError(t, fakeClient.Create(t.Context(), bsp))
 	}
+	// Create a virtual key policy that references the secret.
+	virtualKeyPolicy := &aigv1a1.AIGatewayVirtualKeyPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "keys", Namespace: "default"},
+		Spec: aigv1a1.AIGatewayVirtualKeyPolicySpec{
+			Keys: []aigv1a1.AIGatewayVirtualKey{
+				{Name: "team-a", SecretRef: &gwapiv1.SecretObjectReference{Name: "mysecret"}, TeamID: "team-a"},
+			},
+		},
+	}
+	require.NoError(t, fakeClient.Create(t.Context(), virtualKeyPolicy))
 
 	_, err = c.Reconcile(t.Context(), reconcile.Request{NamespacedName: types.NamespacedName{
 		Namespace: "default", Name: "mysecret",
@@ -69,6 +80,8 @@ func TestSecretController_Reconcile(t *testing.T) {
 		return originals[i].Name < originals[j].Name
 	})
 	require.Equal(t, originals, actual)
+	virtualKeyPolicies := virtualKeyEventCh.RequireItemsEventually(t, 1)
+	require.Equal(t, virtualKeyPolicy.Name, virtualKeyPolicies[0].Name)
 
 	// Test the case where the Secret is being deleted.
 	err = fakeClient.Delete(t.Context(), &corev1.Secret{
@@ -19,6 +19,7 @@ import (
 	endpointv3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
 	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
 	httpconnectionmanagerv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	httpv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
@@ -181,6 +182,193 @@ func Test_maybeModifyCluster(t *testing.T) {
 	})
 }
 
+func Test_maybeModifyRoutesWithFallback(t *testing.T) {
+	c := newFakeClient()
+	err := c.Create(t.Context(), &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "myroute", Namespace: "ns"},
+		Spec: aigv1a1.AIGatewayRouteSpec{
+			Rules: []aigv1a1.AIGatewayRouteRule{
+				{
+					BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "aaa"}},
+				},
+				{
+					BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{
+						{Name: "aaa", Priority: ptr.To[uint32](0)},
+						{Name: "bbb", Priority: ptr.To[uint32](1)},
+					},
+					Fallback: &aigv1a1.AIGatewayRouteRuleFallback{NumRetries: ptr.To[int32](2)},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	newRoute := func(cluster string) *routev3.Route {
+		return &routev3.Route{Action: &routev3.Route_Route{Route: &routev3.RouteAction{
+			ClusterSpecifier: &routev3.RouteAction_Cluster{Cluster: cluster},
+		}}}
+	}
+	withoutFallback := newRoute("httproute/ns/myroute/rule/0")
+	withFallback := newRoute("httproute/ns/myroute/rule/1")
+	notFound := newRoute("httproute/ns/nonexistent/rule/1")
+	outOfRange := newRoute("httproute/ns/myroute/rule/99")
+	other := newRoute("foo")
+	direct := &routev3.Route{Action: &routev3.Route_DirectResponse{}}
+
+	s := New(c, logr.Discard(), udsPath, false)
+	s.maybeModifyRoutesWithFallback([]*routev3.RouteConfiguration{{VirtualHosts: []*routev3.VirtualHost{
+		{Routes: []*routev3.Route{withoutFallback, withFallback, notFound, outOfRange, other, direct}},
+	}}})
+	require.Nil(t, withoutFallback.GetRoute().RetryPolicy)
+	require.Nil(t, notFound.GetRoute().RetryPolicy)
+	require.Nil(t, outOfRange.GetRoute().RetryPolicy)
+	require.Nil(t, other.GetRoute().RetryPolicy)
+
+	policy := withFallback.GetRoute().RetryPolicy
+	require.NotNil(t, policy)
+	require.Equal(t, "connect-failure,refused-stream,reset,retriable-headers", policy.RetryOn)
+	require.Equal(t, uint32(2), policy.NumRetries.GetValue())
+	require.Len(t, policy.RetriableHeaders, 1)
+	require.Equal(t, internalapi.FallbackRetryHeaderKey, policy.RetriableHeaders[0].Name)
+}
+
+func Test_maybeModifyCluster_fallback(t *testing.T) {
+	c := newFakeClient()
+	err := c.Create(t.Context(), &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "myroute", Namespace: "ns"},
+		Spec: aigv1a1.AIGatewayRouteSpec{
+			Rules: []aigv1a1.AIGatewayRouteRule{
+				{
+					BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "aaa"}},
+					Fallback:    &aigv1a1.AIGatewayRouteRuleFallback{},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	cluster := &clusterv3.Cluster{
+		Name: "httproute/ns/myroute/rule/0",
+		LoadAssignment: &endpointv3.ClusterLoadAssignment{
+			Endpoints: []*endpointv3.LocalityLbEndpoints{{LbEndpoints: []*endpointv3.LbEndpoint{{}}}},
+		},
+	}
+	s := New(c, logr.Discard(), udsPath, false)
+	s.maybeModifyCluster(cluster)
+
+	po := &httpv3.HttpProtocolOptions{}
+	require.NoError(t, cluster.TypedExtensionProtocolOptions["envoy.extensions.upstreams.http.v3.HttpProtocolOptions"].UnmarshalTo(po))
+	require.Equal(t, aiGatewayExtProcName, po.HttpFilters[0].Name)
+	extProcConfig := &extprocv3.ExternalProcessor{}
+	require.NoError(t, po.HttpFilters[0].GetTypedConfig().UnmarshalTo(extProcConfig))
+	require.Equal(t, extprocv3.ProcessingMode_SEND, extProcConfig.ProcessingMode.ResponseHeaderMode)
+	require.Equal(t, extprocv3.ProcessingMode_NONE, extProcConfig.ProcessingMode.ResponseBodyMode)
+}
+
+func Test_maybeModifyCluster_circuitBreaker(t *testing.T) {
+	c := newFakeClient()
+	require.NoError(t, c.Create(t.Context(), &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "myroute", Namespace: "ns"},
+		Spec: aigv1a1.AIGatewayRouteSpec{
+			Rules: []aigv1a1.AIGatewayRouteRule{
+				{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "aaa"}, {Name: "bbb"}}},
+				{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "bbb"}}},
+			},
+		},
+	}))
+	require.NoError(t, c.Create(t.Context(), &aigv1a1.AIServiceBackend{
+		ObjectMeta: metav1.ObjectMeta{Name: "aaa", Namespace: "ns"},
+		Spec: aigv1a1.AIServiceBackendSpec{CircuitBreaker: &aigv1a1.AIServiceBackendCircuitBreaker{
+			ConsecutiveFailures: ptr.To[int32](3),
+		}},
+	}))
+	require.NoError(t, c.Create(t.Context(), &aigv1a1.AIServiceBackend{
+		ObjectMeta: metav1.ObjectMeta{Name: "bbb", Namespace: "ns"},
+	}))
+
+	newCluster := func(name string, endpoints int) *clusterv3.Cluster {
+		cluster := &clusterv3.Cluster{Name: name, LoadAssignment: &endpointv3.ClusterLoadAssignment{}}
+		for range endpoints {
+			cluster.LoadAssignment.Endpoints = append(cluster.LoadAssignment.Endpoints,
+				&endpointv3.LocalityLbEndpoints{LbEndpoints: []*endpointv3.LbEndpoint{{}}})
+		}
+		return cluster
+	}
+	responseHeaderMode := func(t *testing.T, cluster *clusterv3.Cluster) extprocv3.ProcessingMode_HeaderSendMode {
+		po := &httpv3.HttpProtocolOptions{}
+		require.NoError(t, cluster.TypedExtensionProtocolOptions["envoy.extensions.upstreams.http.v3.HttpProtocolOptions"].UnmarshalTo(po))
+		extProcConfig := &extprocv3.ExternalProcessor{}
+		require.NoError(t, po.HttpFilters[0].GetTypedConfig().UnmarshalTo(extProcConfig))
+		return extProcConfig.ProcessingMode.ResponseHeaderMode
+	}
+	s := New(c, logr.Discard(), udsPath, false)
+
+	withBreaker := newCluster("httproute/ns/myroute/rule/0", 2)
+	s.maybeModifyCluster(withBreaker)
+	require.NotNil(t, withBreaker.OutlierDetection)
+	require.Equal(t, uint32(3), withBreaker.OutlierDetection.Consecutive_5Xx.GetValue())
+	require.Equal(t, extprocv3.ProcessingMode_SEND, responseHeaderMode(t, withBreaker))
+
+	withoutBreaker := newCluster("httproute/ns/myroute/rule/1", 1)
+	s.maybeModifyCluster(withoutBreaker)
+	require.Nil(t, withoutBreaker.OutlierDetection)
+	require.Equal(t, extprocv3.ProcessingMode_SKIP, responseHeaderMode(t, withoutBreaker))
+}
+
+func Test_maybeModifyCluster_adaptiveLoadBalancing(t *testing.T) {
+	c := newFakeClient()
+	err := c.Create(t.Context(), &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "myroute", Namespace: "ns"},
+		Spec: aigv1a1.AIGatewayRouteSpec{
+			Rules: []aigv1a1.AIGatewayRouteRule{
+				{
+					BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{
+						{Name: "aaa"}, {Name: "bbb", Priority: ptr.To[uint32](1)}, {Name: "ccc"},
+					},
+					AdaptiveLoadBalancing: &aigv1a1.AIGatewayRouteRuleAdaptiveLoadBalancing{},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	newCluster := func(name string) *clusterv3.Cluster {
+		cluster := &clusterv3.Cluster{Name: name, LoadAssignment: &endpointv3.ClusterLoadAssignment{}}
+		for range 3 {
+			cluster.LoadAssignment.Endpoints = append(cluster.LoadAssignment.Endpoints,
+				&endpointv3.LocalityLbEndpoints{LbEndpoints: []*endpointv3.LbEndpoint{{}}})
+		}
+		return cluster
+	}
+	s := New(c, logr.Discard(), udsPath, false)
+	// The rule index 1 is the route-not-found rule, followed by the rules selecting each backend.
+	for _, tc := range []struct {
+		cluster       string
+		expPriorities []uint32
+	}{
+		{cluster: "httproute/ns/myroute/rule/0", expPriorities: []uint32{0, 1, 0}},
+		{cluster: "httproute/ns/myroute/rule/2", expPriorities: []uint32{0, 2, 1}},
+		{cluster: "httproute/ns/myroute/rule/3", expPriorities: []uint32{1, 0, 1}},
+		{cluster: "httproute/ns/myroute/rule/4", expPriorities: []uint32{1, 2, 0}},
+	} {
+		t.Run(tc.cluster, func(t *testing.T) {
+			cluster := newCluster(tc.cluster)
+			s.maybeModifyCluster(cluster)
+			for i, endpoints := range cluster.LoadAssignment.Endpoints {
+				require.Equal(t, tc.expPriorities[i], endpoints.Priority)
+				// The backend names are the same as the original rule.
+				md := endpoints.LbEndpoints[0].Metadata.FilterMetadata[internalapi.InternalEndpointMetadataNamespace]
+				require.Equal(t, internalapi.PerRouteRuleRefBackendName("ns", []string{"aaa", "bbb", "ccc"}[i], "myroute", 0, i),
+					md.Fields[internalapi.InternalMetadataBackendNameKey].GetStringValue())
+			}
+		})
+	}
+
+	cluster := newCluster("httproute/ns/myroute/rule/1")
+	s.maybeModifyCluster(cluster)
+	require.Nil(t, cluster.LoadAssignment.Endpoints[0].LbEndpoints[0].Metadata)
+}
+
 // Helper function to create an InferencePool ExtensionResource.
 func createInferencePoolExtensionResource(name, namespace string) *egextension.ExtensionResource {
 	unstructuredObj := &unstructured.Unstructured{
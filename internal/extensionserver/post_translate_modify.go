@@ -7,6 +7,7 @@ package extensionserver
 
 import (
 	"context"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -23,7 +24,10 @@ import (
 	header_mutationv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/header_mutation/v3"
 	upstream_codecv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/upstream_codec/v3"
 	httpconnectionmanagerv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	previous_hostsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/retry/host/previous_hosts/v3"
+	previous_prioritiesv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/retry/priority/previous_priorities/v3"
 	httpv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
+	matcherv3 "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	"google.golang.org/protobuf/types/known/anypb"
 	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/structpb"
@@ -66,6 +70,15 @@ func (s *Server) PostTranslateModify(_ context.Context, req *egextension.PostTra
 	// Modify listeners and routes to support InferencePool backends.
 	s.maybeModifyListenerAndRoutes(req.Listeners, req.Routes)
 
+	// Configure the retry policy on the routes whose rule has the fallback configured.
+	s.maybeModifyRoutesWithFallback(req.Routes)
+
+	// Configure the hedge policy on the routes whose rule has the hedging configured.
+	s.maybeModifyRoutesWithHedging(req.Routes)
+
+	// Configure the hash policy on the routes whose rule has the session affinity configured.
+	s.maybeModifyRoutesWithSessionAffinity(req.Routes)
+
 	// Ensure the AI Gateway external processor UDS cluster exists.
 	// This cluster is used for communication with the AI Gateway's main external processor.
 	if !extProcUDSExist {
@@ -172,8 +185,10 @@ func (s *Server) maybeModifyCluster(cluster *clusterv3.Cluster) {
 		return
 	}
 
-	// Get the backend from the HTTPRoute object.
-	if httpRouteRuleIndex >= len(aigwRoute.Spec.Rules) {
+	// Get the backend from the HTTPRoute object. The rules generated for the adaptive load balancing are mapped to
+	// the original rule so that the backends have the same names.
+	httpRouteRuleIndex, selectedBackendRef, ok := aigwRoute.Spec.ResolveHTTPRouteRuleIndex(httpRouteRuleIndex)
+	if !ok {
 		s.log.Info("HTTPRoute rule index out of range",
 			"cluster_name", cluster.Name, "rule_index", httpRouteRuleIndexStr)
 		return
@@ -181,6 +196,7 @@ func (s *Server) maybeModifyCluster(cluster *clusterv3.Cluster) {
 	httpRouteRule := &aigwRoute.Spec.Rules[httpRouteRuleIndex]
 
 	// Only process LoadAssignment for non-InferencePool backends.
+	var circuitBreakers []*aigv1a1.AIServiceBackendCircuitBreaker
 	if pool == nil {
 		if cluster.LoadAssignment == nil {
 			s.log.Info("LoadAssignment is nil", "cluster_name", cluster.Name)
@@ -199,6 +215,15 @@ func (s *Server) maybeModifyCluster(cluster *clusterv3.Cluster) {
 			if backendRef.Priority != nil {
 				endpoints.Priority = *backendRef.Priority
 			}
+			if selectedBackendRef >= 0 {
+				// The backend selected by the adaptive load balancing takes the highest priority, and the others
+				// remain available for the fallback in their original order.
+				if i == selectedBackendRef {
+					endpoints.Priority = 0
+				} else {
+					endpoints.Priority++
+				}
+			}
 			// We populate the same metadata for all endpoints in the LoadAssignment.
 			// This is because currently, an extproc cannot retrieve the endpoint set level metadata.
 			for _, endpoint := range endpoints.LbEndpoints {
@@ -221,6 +246,17 @@ func (s *Server) maybeModifyCluster(cluster *clusterv3.Cluster) {
 				)
 			}
 		}
+		if httpRouteRule.SessionAffinity != nil {
+			// The backends are selected by the consistent hashing of the session key set by the router filter.
+			// See maybeModifyRoutesWithSessionAffinity for the hash policy of the routes.
+			cluster.LbPolicy = clusterv3.Cluster_MAGLEV
+			cluster.LbConfig = nil
+			cluster.LoadBalancingPolicy = nil
+		}
+		circuitBreakers = s.circuitBreakersOfRule(aigwRoute.Namespace, httpRouteRule)
+		if od := buildOutlierDetection(circuitBreakers); od != nil {
+			cluster.OutlierDetection = od
+		}
 	} else {
 		// we can only specify one backend in a rule for InferencePool.
 		backendRef := httpRouteRule.BackendRefs[0]
@@ -287,6 +323,15 @@ func (s *Server) maybeModifyCluster(cluster *clusterv3.Cluster) {
 		ResponseHeaderMode: extprocv3.ProcessingMode_SKIP,
 		ResponseBodyMode:   extprocv3.ProcessingMode_NONE,
 	}
+	if httpRouteRule.Fallback != nil || httpRouteRule.Hedging != nil || len(circuitBreakers) > 0 {
+		// When the fallback is configured, the upstream filter needs to see the response of each attempt to classify
+		// the error and to signal the router filter to retry via the header. The body is only buffered on errors.
+		// When the hedging is configured, the upstream filter tags the response with the attempt number so that the
+		// router filter can tell which of the concurrent attempts is served.
+		// When the circuit breaker is configured, the upstream filter classifies the error of each attempt in the same
+		// way as the fallback to count the failures.
+		extProcConfig.ProcessingMode.ResponseHeaderMode = extprocv3.ProcessingMode_SEND
+	}
 	extProcConfig.GrpcService = &corev3.GrpcService{
 		TargetSpecifier: &corev3.GrpcService_EnvoyGrpc_{
 			EnvoyGrpc: &corev3.GrpcService_EnvoyGrpc{
@@ -341,6 +386,67 @@ func (s *Server) maybeModifyCluster(cluster *clusterv3.Cluster) {
 	cluster.TypedExtensionProtocolOptions[httpProtocolOptions] = mustToAny(po)
 }
 
+// circuitBreakersOfRule returns the circuit breakers of the AIServiceBackends referenced by the given rule. The
+// backends which are not found are ignored since the cluster is reconfigured once they are created.
+func (s *Server) circuitBreakersOfRule(namespace string, rule *aigv1a1.AIGatewayRouteRule) []*aigv1a1.AIServiceBackendCircuitBreaker {
+	var ret []*aigv1a1.AIServiceBackendCircuitBreaker
+	for _, ref := range rule.BackendRefs {
+		if ref.IsInferencePool() {
+			continue
+		}
+		var backend aigv1a1.AIServiceBackend
+		if err := s.k8sClient.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: ref.Name}, &backend); err != nil {
+			if !apierrors.IsNotFound(err) {
+				s.log.Error(err, "failed to get AIServiceBackend", "namespace", namespace, "name", ref.Name)
+			}
+			continue
+		}
+		if backend.Spec.CircuitBreaker != nil {
+			ret = append(ret, backend.Spec.CircuitBreaker)
+		}
+	}
+	return ret
+}
+
+// buildOutlierDetection returns the outlier detection of the cluster which ejects the backends on the consecutive
+// server errors, or nil if none of the given circuit breakers fails on the server errors.
+//
+// The outlier detection is configured per cluster, i.e. per rule, while each backend of the rule is a separate host
+// ejected on its own. When the circuit breakers of the backends of the rule differ, the smallest number of the
+// consecutive failures and the longest open duration are used. Envoy has no half-open state, so the ejected host
+// simply receives the requests again after the open duration.
+func buildOutlierDetection(cbs []*aigv1a1.AIServiceBackendCircuitBreaker) *clusterv3.OutlierDetection {
+	var od *clusterv3.OutlierDetection
+	for _, cb := range cbs {
+		if !slices.Contains(cb.GetFailOnOrDefault(), aigv1a1.AIServiceBackendCircuitBreakerErrorClassServerError) {
+			continue
+		}
+		failures := uint32(cb.GetConsecutiveFailuresOrDefault()) // #nosec G115
+		openDuration := cb.GetOpenDurationOrDefault()
+		if od == nil {
+			od = &clusterv3.OutlierDetection{
+				Consecutive_5Xx:          wrapperspb.UInt32(failures),
+				EnforcingConsecutive_5Xx: wrapperspb.UInt32(100),
+				BaseEjectionTime:         durationpb.New(openDuration),
+				MaxEjectionTime:          durationpb.New(openDuration),
+				MaxEjectionPercent:       wrapperspb.UInt32(100),
+				// Only the consecutive server errors eject the hosts.
+				EnforcingSuccessRate:               wrapperspb.UInt32(0),
+				EnforcingConsecutiveGatewayFailure: wrapperspb.UInt32(0),
+			}
+			continue
+		}
+		if failures < od.Consecutive_5Xx.GetValue() {
+			od.Consecutive_5Xx = wrapperspb.UInt32(failures)
+		}
+		if openDuration > od.BaseEjectionTime.AsDuration() {
+			od.BaseEjectionTime = durationpb.New(openDuration)
+			od.MaxEjectionTime = durationpb.New(openDuration)
+		}
+	}
+	return od
+}
+
 // maybeModifyListenerAndRoutes modifies listeners and routes to support InferencePool backends.
 // This function performs the following operations:
 // 1. Identifies listeners and routes that use InferencePool backends
@@ -417,6 +523,185 @@ func (s *Server) maybeModifyListenerAndRoutes(listeners []*listenerv3.Listener,
 	}
 }
 
+// maybeModifyRoutesWithFallback configures the retry policy on the routes pointing to the cluster of an AIGatewayRoute rule
+// with the fallback configured. The upstream external processor classifies the error response of each attempt and sets
+// the internalapi.FallbackRetryHeaderKey header when the request should be retried, so the retry is triggered by the
+// "retriable-headers" condition. The previous priorities are excluded on each retry so that the retry goes to the next
+// priority, i.e. the next backend that may well be a different provider with a different schema.
+func (s *Server) maybeModifyRoutesWithFallback(routes []*routev3.RouteConfiguration) {
+	for _, routeCfg := range routes {
+		for _, vh := range routeCfg.VirtualHosts {
+			for _, route := range vh.Routes {
+				action := route.GetRoute()
+				if action == nil {
+					continue
+				}
+				rule := s.getAIGatewayRouteRuleByClusterName(action.GetCluster())
+				if rule == nil || rule.Fallback == nil {
+					continue
+				}
+				s.log.Info("configuring fallback retry policy", "route", route.Name, "cluster", action.GetCluster())
+				action.RetryPolicy = buildFallbackRetryPolicy(action.RetryPolicy, rule.Fallback)
+			}
+		}
+	}
+}
+
+// maybeModifyRoutesWithHedging configures the hedge policy on the routes pointing to the cluster of an AIGatewayRoute
+// rule with the hedging configured. The router filter sets the per-try timeout of the matching chat completion
+// requests to the delay of the hedging via the x-envoy-upstream-rq-per-try-timeout-ms header, and Envoy sends another
+// attempt to a different backend when the timeout fires instead of cancelling the first one. The first response wins,
+// and the other attempts are cancelled. The other requests have no per-try timeout, so they are never hedged.
+func (s *Server) maybeModifyRoutesWithHedging(routes []*routev3.RouteConfiguration) {
+	for _, routeCfg := range routes {
+		for _, vh := range routeCfg.VirtualHosts {
+			for _, route := range vh.Routes {
+				action := route.GetRoute()
+				if action == nil {
+					continue
+				}
+				rule := s.getAIGatewayRouteRuleByClusterName(action.GetCluster())
+				if rule == nil || rule.Hedging == nil {
+					continue
+				}
+				s.log.Info("configuring hedge policy", "route", route.Name, "cluster", action.GetCluster())
+				action.HedgePolicy = &routev3.HedgePolicy{HedgeOnPerTryTimeout: true}
+				action.RetryPolicy = buildHedgingRetryPolicy(action.RetryPolicy, rule.Hedging)
+			}
+		}
+	}
+}
+
+// maybeModifyRoutesWithSessionAffinity configures the hash policy on the routes pointing to the cluster of an
+// AIGatewayRoute rule with the session affinity configured. The router filter sets the session key of the request to
+// the internalapi.SessionKeyHeaderKey header, which is hashed by the Maglev load balancer of the cluster to select
+// the same backend for the same session. The requests without the header are load balanced randomly.
+func (s *Server) maybeModifyRoutesWithSessionAffinity(routes []*routev3.RouteConfiguration) {
+	for _, routeCfg := range routes {
+		for _, vh := range routeCfg.VirtualHosts {
+			for _, route := range vh.Routes {
+				action := route.GetRoute()
+				if action == nil {
+					continue
+				}
+				rule := s.getAIGatewayRouteRuleByClusterName(action.GetCluster())
+				if rule == nil || rule.SessionAffinity == nil {
+					continue
+				}
+				s.log.Info("configuring session affinity hash policy", "route", route.Name, "cluster", action.GetCluster())
+				action.HashPolicy = buildSessionAffinityHashPolicy(action.HashPolicy)
+			}
+		}
+	}
+}
+
+// buildSessionAffinityHashPolicy returns the given hash policy with the session key header prepended, if not yet.
+// The session key takes the precedence over the existing hash policies, if any, by being terminal.
+func buildSessionAffinityHashPolicy(existing []*routev3.RouteAction_HashPolicy) []*routev3.RouteAction_HashPolicy {
+	for _, p := range existing {
+		if p.GetHeader().GetHeaderName() == internalapi.SessionKeyHeaderKey {
+			return existing
+		}
+	}
+	return append([]*routev3.RouteAction_HashPolicy{{
+		PolicySpecifier: &routev3.RouteAction_HashPolicy_Header_{
+			Header: &routev3.RouteAction_HashPolicy_Header{HeaderName: internalapi.SessionKeyHeaderKey},
+		},
+		Terminal: true,
+	}}, existing...)
+}
+
+// getAIGatewayRouteRuleByClusterName returns the AIGatewayRoute rule corresponding to the given cluster name
+// in the format of "httproute/<namespace>/<name>/rule/<index_of_rule>". Returns nil if not found.
+func (s *Server) getAIGatewayRouteRuleByClusterName(clusterName string) *aigv1a1.AIGatewayRouteRule {
+	parts := strings.Split(clusterName, "/")
+	if len(parts) != 5 || parts[0] != "httproute" {
+		return nil
+	}
+	httpRouteRuleIndex, err := strconv.Atoi(parts[4])
+	if err != nil {
+		return nil
+	}
+	var aigwRoute aigv1a1.AIGatewayRoute
+	if err = s.k8sClient.Get(context.Background(), client.ObjectKey{Namespace: parts[1], Name: parts[2]}, &aigwRoute); err != nil {
+		if !apierrors.IsNotFound(err) {
+			s.log.Error(err, "failed to get AIGatewayRoute object", "namespace", parts[1], "name", parts[2])
+		}
+		return nil
+	}
+	ruleIndex, _, ok := aigwRoute.Spec.ResolveHTTPRouteRuleIndex(httpRouteRuleIndex)
+	if !ok {
+		return nil
+	}
+	return &aigwRoute.Spec.Rules[ruleIndex]
+}
+
+// buildFallbackRetryPolicy builds the retry policy for the fallback on top of the existing one, if any.
+func buildFallbackRetryPolicy(existing *routev3.RetryPolicy, fallback *aigv1a1.AIGatewayRouteRuleFallback) *routev3.RetryPolicy {
+	policy := existing
+	if policy == nil {
+		policy = &routev3.RetryPolicy{}
+	}
+	policy.RetryOn = mergeRetryOn(policy.RetryOn, "connect-failure", "refused-stream", "reset", "retriable-headers")
+	policy.NumRetries = wrapperspb.UInt32(uint32(fallback.GetNumRetriesOrDefault())) // #nosec G115
+	policy.RetriableHeaders = append(policy.RetriableHeaders, &routev3.HeaderMatcher{
+		Name: internalapi.FallbackRetryHeaderKey,
+		HeaderMatchSpecifier: &routev3.HeaderMatcher_StringMatch{
+			StringMatch: &matcherv3.StringMatcher{MatchPattern: &matcherv3.StringMatcher_Exact{Exact: "true"}},
+		},
+	})
+	setPreviousBackendsRetryPredicates(policy)
+	return policy
+}
+
+// buildHedgingRetryPolicy builds the retry policy for the hedging on top of the existing one, if any, e.g. the one
+// built by buildFallbackRetryPolicy. Envoy only hedges the request when the retry policy allows another attempt, and
+// each hedged request counts as a retry.
+func buildHedgingRetryPolicy(existing *routev3.RetryPolicy, hedging *aigv1a1.AIGatewayRouteRuleHedging) *routev3.RetryPolicy {
+	policy := existing
+	if policy == nil {
+		policy = &routev3.RetryPolicy{}
+	}
+	policy.RetryOn = mergeRetryOn(policy.RetryOn, "connect-failure", "refused-stream", "reset")
+	maxHedged := uint32(hedging.GetMaxHedgedRequestsOrDefault()) // #nosec G115
+	if policy.NumRetries.GetValue() < maxHedged {
+		policy.NumRetries = wrapperspb.UInt32(maxHedged)
+	}
+	if policy.RetryPriority == nil && len(policy.RetryHostPredicate) == 0 {
+		setPreviousBackendsRetryPredicates(policy)
+	}
+	return policy
+}
+
+// mergeRetryOn returns the given comma-separated retry conditions with the existing ones appended, if not included.
+func mergeRetryOn(existing string, conds ...string) string {
+	retryOn := conds
+	for _, cond := range strings.Split(existing, ",") {
+		if cond != "" && !slices.Contains(retryOn, cond) {
+			retryOn = append(retryOn, cond)
+		}
+	}
+	return strings.Join(retryOn, ",")
+}
+
+// setPreviousBackendsRetryPredicates configures the retry policy to exclude the previously attempted priorities and
+// hosts, so that each attempt goes to a different backend.
+func setPreviousBackendsRetryPredicates(policy *routev3.RetryPolicy) {
+	policy.RetryPriority = &routev3.RetryPolicy_RetryPriority{
+		Name: "envoy.retry_priorities.previous_priorities",
+		ConfigType: &routev3.RetryPolicy_RetryPriority_TypedConfig{
+			TypedConfig: mustToAny(&previous_prioritiesv3.PreviousPrioritiesConfig{UpdateFrequency: 1}),
+		},
+	}
+	policy.RetryHostPredicate = []*routev3.RetryPolicy_RetryHostPredicate{{
+		Name: "envoy.retry_host_predicates.previous_hosts",
+		ConfigType: &routev3.RetryPolicy_RetryHostPredicate_TypedConfig{
+			TypedConfig: mustToAny(&previous_hostsv3.PreviousHostsPredicate{}),
+		},
+	}}
+	policy.HostSelectionRetryMaxAttempts = 5
+}
+
 // patchListenerWithInferencePoolFilters adds the necessary HTTP filters to the listener to support InferencePool backends.
 func (s *Server) patchListenerWithInferencePoolFilters(listener *listenerv3.Listener, inferencePools []*gwaiev1.InferencePool) {
 	// First, get the filter chains from the listener.
@@ -7,6 +7,7 @@ package extensionserver
 
 import (
 	"testing"
+	"time"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
@@ -14,8 +15,11 @@ import (
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/types/known/anypb"
 	"google.golang.org/protobuf/types/known/structpb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
+	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
 	"github.com/envoyproxy/ai-gateway/internal/internalapi"
 )
 
@@ -329,3 +333,99 @@ func Test_shouldAIGatewayExtProcBeInserted(t *testing.T) {
 		require.Equal(t, tt.expected, result)
 	}
 }
+
+func Test_buildFallbackRetryPolicy(t *testing.T) {
+	t.Run("new", func(t *testing.T) {
+		policy := buildFallbackRetryPolicy(nil, &aigv1a1.AIGatewayRouteRuleFallback{})
+		require.Equal(t, "connect-failure,refused-stream,reset,retriable-headers", policy.RetryOn)
+		require.Equal(t, uint32(1), policy.NumRetries.GetValue())
+		require.Len(t, policy.RetriableHeaders, 1)
+		require.Equal(t, internalapi.FallbackRetryHeaderKey, policy.RetriableHeaders[0].Name)
+		require.Equal(t, "true", policy.RetriableHeaders[0].GetStringMatch().GetExact())
+		require.Equal(t, "envoy.retry_priorities.previous_priorities", policy.RetryPriority.Name)
+		require.Len(t, policy.RetryHostPredicate, 1)
+		require.Equal(t, "envoy.retry_host_predicates.previous_hosts", policy.RetryHostPredicate[0].Name)
+		require.Equal(t, int64(5), policy.HostSelectionRetryMaxAttempts)
+	})
+	t.Run("existing", func(t *testing.T) {
+		existing := &routev3.RetryPolicy{
+			RetryOn:          "5xx,reset",
+			RetriableHeaders: []*routev3.HeaderMatcher{{Name: "foo"}},
+		}
+		policy := buildFallbackRetryPolicy(existing, &aigv1a1.AIGatewayRouteRuleFallback{NumRetries: ptr.To[int32](3)})
+		require.Same(t, existing, policy)
+		require.Equal(t, "connect-failure,refused-stream,reset,retriable-headers,5xx", policy.RetryOn)
+		require.Equal(t, uint32(3), policy.NumRetries.GetValue())
+		require.Len(t, policy.RetriableHeaders, 2)
+		require.Equal(t, "foo", policy.RetriableHeaders[0].Name)
+		require.Equal(t, internalapi.FallbackRetryHeaderKey, policy.RetriableHeaders[1].Name)
+	})
+}
+
+func Test_buildHedgingRetryPolicy(t *testing.T) {
+	t.Run("new", func(t *testing.T) {
+		policy := buildHedgingRetryPolicy(nil, &aigv1a1.AIGatewayRouteRuleHedging{MaxHedgedRequests: ptr.To[int32](2)})
+		require.Equal(t, "connect-failure,refused-stream,reset", policy.RetryOn)
+		require.Equal(t, uint32(2), policy.NumRetries.GetValue())
+		require.Empty(t, policy.RetriableHeaders)
+		require.Equal(t, "envoy.retry_priorities.previous_priorities", policy.RetryPriority.Name)
+		require.Len(t, policy.RetryHostPredicate, 1)
+		require.Equal(t, "envoy.retry_host_predicates.previous_hosts", policy.RetryHostPredicate[0].Name)
+	})
+	t.Run("fallback", func(t *testing.T) {
+		existing := buildFallbackRetryPolicy(nil, &aigv1a1.AIGatewayRouteRuleFallback{NumRetries: ptr.To[int32](3)})
+		policy := buildHedgingRetryPolicy(existing, &aigv1a1.AIGatewayRouteRuleHedging{})
+		require.Same(t, existing, policy)
+		require.Equal(t, "connect-failure,refused-stream,reset,retriable-headers", policy.RetryOn)
+		// The larger number of the retries is kept.
+		require.Equal(t, uint32(3), policy.NumRetries.GetValue())
+		require.Len(t, policy.RetriableHeaders, 1)
+	})
+}
+
+func Test_buildOutlierDetection(t *testing.T) {
+	throttledOnly := &aigv1a1.AIServiceBackendCircuitBreaker{
+		FailOn: []aigv1a1.AIServiceBackendCircuitBreakerErrorClass{aigv1a1.AIServiceBackendCircuitBreakerErrorClassThrottled},
+	}
+	require.Nil(t, buildOutlierDetection(nil))
+	require.Nil(t, buildOutlierDetection([]*aigv1a1.AIServiceBackendCircuitBreaker{throttledOnly}))
+
+	od := buildOutlierDetection([]*aigv1a1.AIServiceBackendCircuitBreaker{throttledOnly, {}})
+	require.Equal(t, uint32(5), od.Consecutive_5Xx.GetValue())
+	require.Equal(t, uint32(100), od.EnforcingConsecutive_5Xx.GetValue())
+	require.Equal(t, uint32(0), od.EnforcingSuccessRate.GetValue())
+	require.Equal(t, 30*time.Second, od.BaseEjectionTime.AsDuration())
+	require.Equal(t, 30*time.Second, od.MaxEjectionTime.AsDuration())
+	require.Equal(t, uint32(100), od.MaxEjectionPercent.GetValue())
+
+	// The smallest number of the failures and the longest open duration are used.
+	od = buildOutlierDetection([]*aigv1a1.AIServiceBackendCircuitBreaker{
+		{ConsecutiveFailures: ptr.To[int32](10), OpenDuration: &metav1.Duration{Duration: time.Minute}},
+		{ConsecutiveFailures: ptr.To[int32](3), OpenDuration: &metav1.Duration{Duration: 10 * time.Second}},
+	})
+	require.Equal(t, uint32(3), od.Consecutive_5Xx.GetValue())
+	require.Equal(t, time.Minute, od.BaseEjectionTime.AsDuration())
+	require.Equal(t, time.Minute, od.MaxEjectionTime.AsDuration())
+}
+
+func Test_buildSessionAffinityHashPolicy(t *testing.T) {
+	t.Run("new", func(t *testing.T) {
+		policy := buildSessionAffinityHashPolicy(nil)
+		require.Len(t, policy, 1)
+		require.Equal(t, internalapi.SessionKeyHeaderKey, policy[0].GetHeader().GetHeaderName())
+		require.True(t, policy[0].Terminal)
+	})
+	t.Run("existing", func(t *testing.T) {
+		existing := []*routev3.RouteAction_HashPolicy{{
+			PolicySpecifier: &routev3.RouteAction_HashPolicy_Header_{
+				Header: &routev3.RouteAction_HashPolicy_Header{HeaderName: "x-user-id"},
+			},
+		}}
+		policy := buildSessionAffinityHashPolicy(existing)
+		require.Len(t, policy, 2)
+		require.Equal(t, internalapi.SessionKeyHeaderKey, policy[0].GetHeader().GetHeaderName())
+		require.Equal(t, "x-user-id", policy[1].GetHeader().GetHeaderName())
+		// Already configured.
+		require.Equal(t, policy, buildSessionAffinityHashPolicy(policy))
+	})
+}
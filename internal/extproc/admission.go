@@ -0,0 +1,48 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// admissionShedResponse returns the immediate response of the upstream filter shedding the attempt of the given
+// priority class on the backend. Envoy handles it in the same way as the response of the backend, so the attempt is
+// retried on the other backends via the internalapi.FallbackRetryHeaderKey header when retry is true.
+func admissionShedResponse(backend, priority string, retryAfter time.Duration, retry bool) *extprocv3.ProcessingResponse {
+	body, _ := json.Marshal(openai.Error{
+		Type: "error",
+		Error: openai.ErrorType{
+			Type:    fallbackErrorTypeShed,
+			Message: fmt.Sprintf("the request of the priority class %s is shed by the backend %s", priority, backend),
+		},
+	})
+	headers := []*corev3.HeaderValueOption{
+		{Header: &corev3.HeaderValue{Key: "content-type", RawValue: []byte("application/json")}},
+		{Header: &corev3.HeaderValue{Key: "retry-after", RawValue: []byte(strconv.Itoa(int(retryAfter / time.Second)))}},
+	}
+	if retry {
+		headers = append(headers, fallbackRetryHeaderMutation().SetHeaders...)
+	}
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status:  &typev3.HttpStatus{Code: typev3.StatusCode_TooManyRequests},
+				Headers: &extprocv3.HeaderMutation{SetHeaders: headers},
+				Body:    body,
+			},
+		},
+	}
+}
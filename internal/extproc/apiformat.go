@@ -0,0 +1,403 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/guardrail"
+	"github.com/envoyproxy/ai-gateway/internal/responsecache"
+)
+
+// errorFormat is the format of the error responses of an API.
+type errorFormat interface {
+	// errorBody returns the error response body of the API with the given error.
+	errorBody(e *openai.ErrorType) []byte
+}
+
+// apiFormat is the format of the requests and the responses of an API checked by the guardrail and the tool policy.
+type apiFormat interface {
+	errorFormat
+	// api returns the API sent to the guardrail service with the checks.
+	api() guardrail.API
+	// assembleStream assembles the server-sent events of the streaming response into the non-streaming response.
+	assembleStream(body []byte) ([]byte, error)
+	// streamBody converts the non-streaming response into the server-sent events of the streaming response.
+	streamBody(resp []byte) ([]byte, error)
+	// errorEvents returns the server-sent events ending the streaming response with the given error.
+	errorEvents(e *openai.ErrorType) []byte
+}
+
+// errorResponse returns the immediate response with the given status and the error in the given format.
+func errorResponse(f errorFormat, status typev3.StatusCode, e *openai.ErrorType) *extprocv3.ProcessingResponse {
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status: &typev3.HttpStatus{Code: status},
+				Headers: &extprocv3.HeaderMutation{SetHeaders: []*corev3.HeaderValueOption{
+					{Header: &corev3.HeaderValue{Key: "content-type", RawValue: []byte("application/json")}},
+				}},
+				Body: f.errorBody(e),
+			},
+		},
+	}
+}
+
+// openAIErrorFormat is the errorFormat of the OpenAI-compatible endpoints, which is used as is by the endpoints
+// without the other features of the apiFormat, such as the completions, the embeddings, the image generation and
+// the audio endpoints.
+type openAIErrorFormat struct{}
+
+func (openAIErrorFormat) errorBody(e *openai.ErrorType) []byte {
+	body, _ := json.Marshal(openai.Error{Type: "error", Error: *e})
+	return body
+}
+
+// chatCompletionFormat is the apiFormat of the chat completions API.
+type chatCompletionFormat struct {
+	// includeUsage is true when the streaming response includes the usage chunk.
+	includeUsage bool
+}
+
+func (chatCompletionFormat) api() guardrail.API { return guardrail.APIChatCompletions }
+
+func (chatCompletionFormat) assembleStream(body []byte) ([]byte, error) {
+	return responsecache.AssembleChatCompletionStream(body)
+}
+
+func (f chatCompletionFormat) streamBody(resp []byte) ([]byte, error) {
+	return responsecache.ChatCompletionStreamBody(resp, f.includeUsage)
+}
+
+func (chatCompletionFormat) errorBody(e *openai.ErrorType) []byte {
+	return openAIErrorFormat{}.errorBody(e)
+}
+
+func (f chatCompletionFormat) errorEvents(e *openai.ErrorType) []byte {
+	return []byte("data: " + string(f.errorBody(e)) + "\n\ndata: [DONE]\n\n")
+}
+
+// responsesFormat is the apiFormat of the Responses API.
+type responsesFormat struct{}
+
+func (responsesFormat) api() guardrail.API { return guardrail.APIResponses }
+
+// assembleStream implements [apiFormat.assembleStream]. The final event of the stream carries the whole
+// response, which is returned as is.
+func (responsesFormat) assembleStream(body []byte) ([]byte, error) {
+	var resp json.RawMessage
+	err := forEachSSEData(body, func(data []byte) error {
+		var event struct {
+			Type     string          `json:"type"`
+			Response json.RawMessage `json:"response"`
+		}
+		if err := json.Unmarshal(data, &event); err != nil {
+			return fmt.Errorf("failed to unmarshal event: %w", err)
+		}
+		switch event.Type {
+		case openai.ResponseStreamEventTypeCompleted, openai.ResponseStreamEventTypeIncomplete, openai.ResponseStreamEventTypeFailed:
+			resp = event.Response
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) == 0 {
+		return nil, errors.New("stream is not terminated with the response")
+	}
+	return resp, nil
+}
+
+// streamBody implements [apiFormat.streamBody]. Each output item is sent with its whole content as a single
+// delta.
+func (responsesFormat) streamBody(body []byte) ([]byte, error) {
+	var resp openai.Response
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	var out []byte
+	var seq int
+	appendEvent := func(event *openai.ResponseStreamEvent) {
+		event.SequenceNumber = seq
+		seq++
+		out = appendSSEEvent(out, event.Type, event)
+	}
+	created := resp
+	created.Status, created.Output, created.Usage = openai.ResponseStatusInProgress, []openai.ResponseOutputItem{}, nil
+	appendEvent(&openai.ResponseStreamEvent{Type: openai.ResponseStreamEventTypeCreated, Response: &created})
+	for i := range resp.Output {
+		item := &resp.Output[i]
+		outputIndex := i
+		added := *item
+		added.Status, added.Content, added.Arguments = openai.ResponseStatusInProgress, nil, ""
+		appendEvent(&openai.ResponseStreamEvent{Type: openai.ResponseStreamEventTypeOutputItemAdded, OutputIndex: &outputIndex, Item: &added})
+		for j := range item.Content {
+			part := &item.Content[j]
+			contentIndex := j
+			empty := openai.ResponseContentPart{Type: part.Type, Annotations: part.Annotations}
+			appendEvent(&openai.ResponseStreamEvent{
+				Type: openai.ResponseStreamEventTypeContentPartAdded, OutputIndex: &outputIndex, ContentIndex: &contentIndex,
+				ItemID: item.ID, Part: &empty,
+			})
+			if part.Type == openai.ResponseContentTypeOutputText {
+				appendEvent(&openai.ResponseStreamEvent{
+					Type: openai.ResponseStreamEventTypeOutputTextDelta, OutputIndex: &outputIndex, ContentIndex: &contentIndex,
+					ItemID: item.ID, Delta: part.Text,
+				})
+				appendEvent(&openai.ResponseStreamEvent{
+					Type: openai.ResponseStreamEventTypeOutputTextDone, OutputIndex: &outputIndex, ContentIndex: &contentIndex,
+					ItemID: item.ID, Text: part.Text,
+				})
+			}
+			appendEvent(&openai.ResponseStreamEvent{
+				Type: openai.ResponseStreamEventTypeContentPartDone, OutputIndex: &outputIndex, ContentIndex: &contentIndex,
+				ItemID: item.ID, Part: part,
+			})
+		}
+		if item.Type == openai.ResponseItemTypeFunctionCall {
+			appendEvent(&openai.ResponseStreamEvent{
+				Type: openai.ResponseStreamEventTypeFunctionCallArgsDelta, OutputIndex: &outputIndex, ItemID: item.ID, Delta: item.Arguments,
+			})
+			appendEvent(&openai.ResponseStreamEvent{
+				Type: openai.ResponseStreamEventTypeFunctionCallArgsDone, OutputIndex: &outputIndex, ItemID: item.ID, Arguments: item.Arguments,
+			})
+		}
+		appendEvent(&openai.ResponseStreamEvent{Type: openai.ResponseStreamEventTypeOutputItemDone, OutputIndex: &outputIndex, Item: item})
+	}
+	final := openai.ResponseStreamEventTypeCompleted
+	switch resp.Status {
+	case openai.ResponseStatusIncomplete:
+		final = openai.ResponseStreamEventTypeIncomplete
+	case openai.ResponseStatusFailed:
+		final = openai.ResponseStreamEventTypeFailed
+	}
+	appendEvent(&openai.ResponseStreamEvent{Type: final, Response: &resp})
+	return out, nil
+}
+
+func (responsesFormat) errorBody(e *openai.ErrorType) []byte {
+	return openAIErrorFormat{}.errorBody(e)
+}
+
+func (responsesFormat) errorEvents(e *openai.ErrorType) []byte {
+	code := e.Type
+	if e.Code != nil {
+		code = *e.Code
+	}
+	return appendSSEEvent(nil, openai.ResponseStreamEventTypeError, &openai.ResponseStreamEvent{
+		Type: openai.ResponseStreamEventTypeError, Code: code, Message: e.Message,
+	})
+}
+
+// messagesFormat is the apiFormat of the Anthropic Messages API.
+type messagesFormat struct{}
+
+func (messagesFormat) api() guardrail.API { return guardrail.APIMessages }
+
+// assembleStream implements [apiFormat.assembleStream].
+func (messagesFormat) assembleStream(body []byte) ([]byte, error) {
+	var msg *anthropic.Message
+	var stopped bool
+	// inputs are the partial JSON of the inputs of the tool use blocks by their indexes.
+	inputs := map[int][]byte{}
+	err := forEachSSEData(body, func(data []byte) error {
+		var event anthropic.StreamEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return fmt.Errorf("failed to unmarshal event: %w", err)
+		}
+		if event.Type == anthropic.StreamEventTypeMessageStart {
+			if event.Message == nil {
+				return errors.New("message_start event without the message")
+			}
+			msg = event.Message
+			return nil
+		}
+		if msg == nil {
+			return nil
+		}
+		var block *anthropic.ContentBlock
+		if event.Index != nil && *event.Index >= 0 && *event.Index < len(msg.Content) {
+			block = &msg.Content[*event.Index]
+		}
+		switch event.Type {
+		case anthropic.StreamEventTypeContentBlockStart:
+			if event.Index == nil || event.ContentBlock == nil || *event.Index < 0 {
+				return errors.New("invalid content_block_start event")
+			}
+			for len(msg.Content) <= *event.Index {
+				msg.Content = append(msg.Content, anthropic.ContentBlock{})
+			}
+			msg.Content[*event.Index] = *event.ContentBlock
+		case anthropic.StreamEventTypeContentBlockDelta:
+			if block == nil || event.Delta == nil {
+				return errors.New("invalid content_block_delta event")
+			}
+			switch event.Delta.Type {
+			case anthropic.DeltaTypeText:
+				text := event.Delta.Text
+				if block.Text != nil {
+					text = *block.Text + text
+				}
+				block.Text = &text
+			case anthropic.DeltaTypeInputJSON:
+				if event.Delta.PartialJSON != nil {
+					inputs[*event.Index] = append(inputs[*event.Index], *event.Delta.PartialJSON...)
+				}
+			case anthropic.DeltaTypeThinking:
+				thinking := event.Delta.Thinking
+				if block.Thinking != nil {
+					thinking = *block.Thinking + thinking
+				}
+				block.Thinking = &thinking
+			case anthropic.DeltaTypeSignature:
+				signature := event.Delta.Signature
+				block.Signature = &signature
+			}
+		case anthropic.StreamEventTypeContentBlockStop:
+			if block == nil {
+				return errors.New("invalid content_block_stop event")
+			}
+			if input, ok := inputs[*event.Index]; ok {
+				block.Input = input
+			}
+		case anthropic.StreamEventTypeMessageDelta:
+			if event.Delta != nil {
+				if event.Delta.StopReason != "" {
+					stopReason := event.Delta.StopReason
+					msg.StopReason = &stopReason
+				}
+				if event.Delta.StopSequence != nil {
+					msg.StopSequence = event.Delta.StopSequence
+				}
+			}
+			if event.Usage != nil {
+				msg.Usage.OutputTokens = event.Usage.OutputTokens
+				if event.Usage.InputTokens > 0 {
+					msg.Usage.InputTokens = event.Usage.InputTokens
+				}
+			}
+		case anthropic.StreamEventTypeMessageStop:
+			stopped = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !stopped {
+		return nil, errors.New("stream is not terminated with the message_stop event")
+	}
+	return json.Marshal(msg)
+}
+
+// streamBody implements [apiFormat.streamBody]. Each content block is sent with its whole content as a single
+// delta.
+func (messagesFormat) streamBody(body []byte) ([]byte, error) {
+	var msg anthropic.Message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
+	}
+	start := msg
+	start.Content, start.StopReason, start.StopSequence = []anthropic.ContentBlock{}, nil, nil
+	out := appendSSEEvent(nil, anthropic.StreamEventTypeMessageStart, &anthropic.StreamEvent{
+		Type: anthropic.StreamEventTypeMessageStart, Message: &start,
+	})
+	for i := range msg.Content {
+		block := msg.Content[i]
+		index := i
+		var deltas []anthropic.StreamDelta
+		switch block.Type {
+		case anthropic.ContentBlockTypeText:
+			if block.Text != nil {
+				deltas = append(deltas, anthropic.StreamDelta{Type: anthropic.DeltaTypeText, Text: *block.Text})
+			}
+			empty := ""
+			block.Text = &empty
+		case anthropic.ContentBlockTypeToolUse:
+			if len(block.Input) > 0 {
+				input := string(block.Input)
+				deltas = append(deltas, anthropic.StreamDelta{Type: anthropic.DeltaTypeInputJSON, PartialJSON: &input})
+			}
+			block.Input = json.RawMessage("{}")
+		case anthropic.ContentBlockTypeThinking:
+			if block.Thinking != nil {
+				deltas = append(deltas, anthropic.StreamDelta{Type: anthropic.DeltaTypeThinking, Thinking: *block.Thinking})
+			}
+			if block.Signature != nil {
+				deltas = append(deltas, anthropic.StreamDelta{Type: anthropic.DeltaTypeSignature, Signature: *block.Signature})
+			}
+			empty := ""
+			block.Thinking, block.Signature = &empty, nil
+		}
+		out = appendSSEEvent(out, anthropic.StreamEventTypeContentBlockStart, &anthropic.StreamEvent{
+			Type: anthropic.StreamEventTypeContentBlockStart, Index: &index, ContentBlock: &block,
+		})
+		for j := range deltas {
+			out = appendSSEEvent(out, anthropic.StreamEventTypeContentBlockDelta, &anthropic.StreamEvent{
+				Type: anthropic.StreamEventTypeContentBlockDelta, Index: &index, Delta: &deltas[j],
+			})
+		}
+		out = appendSSEEvent(out, anthropic.StreamEventTypeContentBlockStop, &anthropic.StreamEvent{
+			Type: anthropic.StreamEventTypeContentBlockStop, Index: &index,
+		})
+	}
+	delta := anthropic.StreamDelta{StopSequence: msg.StopSequence}
+	if msg.StopReason != nil {
+		delta.StopReason = *msg.StopReason
+	}
+	out = appendSSEEvent(out, anthropic.StreamEventTypeMessageDelta, &anthropic.StreamEvent{
+		Type: anthropic.StreamEventTypeMessageDelta, Delta: &delta, Usage: &msg.Usage,
+	})
+	return appendSSEEvent(out, anthropic.StreamEventTypeMessageStop, &anthropic.StreamEvent{Type: anthropic.StreamEventTypeMessageStop}), nil
+}
+
+func (messagesFormat) errorBody(e *openai.ErrorType) []byte {
+	body, _ := json.Marshal(anthropic.ErrorResponse{Type: "error", Error: anthropic.ErrorDetail{Type: e.Type, Message: e.Message}})
+	return body
+}
+
+func (messagesFormat) errorEvents(e *openai.ErrorType) []byte {
+	return appendSSEEvent(nil, anthropic.StreamEventTypeError, &anthropic.StreamEvent{
+		Type: anthropic.StreamEventTypeError, Error: &anthropic.ErrorDetail{Type: e.Type, Message: e.Message},
+	})
+}
+
+// forEachSSEData calls the given function with the data of each server-sent event of the given body.
+func forEachSSEData(body []byte, f func(data []byte) error) error {
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:"))
+		if !ok {
+			continue
+		}
+		if err := f(bytes.TrimSpace(data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendSSEEvent appends the server-sent event of the given type with the JSON of the given data to the buffer.
+func appendSSEEvent(out []byte, eventType string, data any) []byte {
+	b, err := json.Marshal(data)
+	if err != nil {
+		panic(fmt.Errorf("BUG: failed to marshal event: %w", err))
+	}
+	out = append(out, "event: "...)
+	out = append(out, eventType...)
+	out = append(out, "\ndata: "...)
+	out = append(out, b...)
+	return append(out, '\n', '\n')
+}
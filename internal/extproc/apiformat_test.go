@@ -0,0 +1,103 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/guardrail"
+)
+
+func Test_responsesFormat(t *testing.T) {
+	f := responsesFormat{}
+	const resp = `{"id":"resp_1","object":"response","created_at":1,"status":"completed","model":"gpt-4o",` +
+		`"output":[{"type":"message","id":"msg_1","status":"completed","role":"assistant","content":[{"type":"output_text","text":"Hello!"}]},` +
+		`{"type":"function_call","id":"fc_1","status":"completed","call_id":"call_1","name":"get_weather","arguments":"{\"city\":\"Paris\"}"}],` +
+		`"usage":{"input_tokens":1,"input_tokens_details":{"cached_tokens":0},"output_tokens":2,"output_tokens_details":{"reasoning_tokens":0},"total_tokens":3}}`
+
+	stream, err := f.streamBody([]byte(resp))
+	require.NoError(t, err)
+	var types []string
+	require.NoError(t, forEachSSEData(stream, func(data []byte) error {
+		var event openai.ResponseStreamEvent
+		require.NoError(t, json.Unmarshal(data, &event))
+		require.Equal(t, len(types), event.SequenceNumber)
+		types = append(types, event.Type)
+		return nil
+	}))
+	require.Equal(t, []string{
+		"response.created",
+		"response.output_item.added", "response.content_part.added", "response.output_text.delta",
+		"response.output_text.done", "response.content_part.done", "response.output_item.done",
+		"response.output_item.added", "response.function_call_arguments.delta", "response.function_call_arguments.done",
+		"response.output_item.done",
+		"response.completed",
+	}, types)
+
+	// The final event carries the whole response.
+	assembled, err := f.assembleStream(stream)
+	require.NoError(t, err)
+	require.JSONEq(t, resp, string(assembled))
+
+	_, err = f.assembleStream([]byte("event: response.created\ndata: {\"type\":\"response.created\"}\n\n"))
+	require.ErrorContains(t, err, "stream is not terminated with the response")
+
+	require.Equal(t, "event: error\ndata: {\"type\":\"error\",\"sequence_number\":0,\"code\":\"guardrail_blocked\",\"message\":\"flagged\"}\n\n",
+		string(f.errorEvents(&openai.ErrorType{Type: guardrail.BlockedErrorType, Message: "flagged"})))
+}
+
+func Test_messagesFormat(t *testing.T) {
+	f := messagesFormat{}
+	const stream = "event: message_start\n" +
+		`data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"model":"claude","stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":5,"output_tokens":1}}}` + "\n\n" +
+		"event: content_block_start\n" +
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}` + "\n\n" +
+		"event: ping\ndata: {\"type\":\"ping\"}\n\n" +
+		"event: content_block_delta\n" +
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}` + "\n\n" +
+		"event: content_block_delta\n" +
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" world"}}` + "\n\n" +
+		"event: content_block_stop\n" +
+		`data: {"type":"content_block_stop","index":0}` + "\n\n" +
+		"event: content_block_start\n" +
+		`data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"tu_1","name":"get_weather","input":{}}}` + "\n\n" +
+		"event: content_block_delta\n" +
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}` + "\n\n" +
+		"event: content_block_delta\n" +
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"Paris\"}"}}` + "\n\n" +
+		"event: content_block_stop\n" +
+		`data: {"type":"content_block_stop","index":1}` + "\n\n" +
+		"event: message_delta\n" +
+		`data: {"type":"message_delta","delta":{"stop_reason":"tool_use","stop_sequence":null},"usage":{"output_tokens":7}}` + "\n\n" +
+		"event: message_stop\n" +
+		`data: {"type":"message_stop"}` + "\n\n"
+	const msg = `{"id":"msg_1","type":"message","role":"assistant","model":"claude","stop_reason":"tool_use","stop_sequence":null,` +
+		`"content":[{"type":"text","text":"Hello world"},{"type":"tool_use","id":"tu_1","name":"get_weather","input":{"city":"Paris"}}],` +
+		`"usage":{"input_tokens":5,"output_tokens":7}}`
+
+	assembled, err := f.assembleStream([]byte(stream))
+	require.NoError(t, err)
+	require.JSONEq(t, msg, string(assembled))
+
+	// The re-emitted stream is assembled into the same message.
+	reEmitted, err := f.streamBody(assembled)
+	require.NoError(t, err)
+	reAssembled, err := f.assembleStream(reEmitted)
+	require.NoError(t, err)
+	require.JSONEq(t, msg, string(reAssembled))
+
+	_, err = f.assembleStream([]byte(stream[:len(stream)-len("event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")]))
+	require.ErrorContains(t, err, "stream is not terminated with the message_stop event")
+
+	require.JSONEq(t, `{"type":"error","error":{"type":"guardrail_blocked","message":"flagged"}}`,
+		string(f.errorBody(&openai.ErrorType{Type: guardrail.BlockedErrorType, Message: "flagged"})))
+	require.Equal(t, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"guardrail_blocked\",\"message\":\"flagged\"}}\n\n",
+		string(f.errorEvents(&openai.ErrorType{Type: guardrail.BlockedErrorType, Message: "flagged"})))
+}
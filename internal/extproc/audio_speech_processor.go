@@ -0,0 +1,380 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/backendauth"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+	"github.com/envoyproxy/ai-gateway/internal/quota"
+	tracing "github.com/envoyproxy/ai-gateway/internal/tracing/api"
+)
+
+// AudioSpeechProcessorFactory returns a factory method to instantiate the audio speech processor.
+func AudioSpeechProcessorFactory(am metrics.AudioMetrics) ProcessorFactory {
+	return func(config *processorConfig, requestHeaders map[string]string, logger *slog.Logger, _ tracing.Tracing, isUpstreamFilter bool) (Processor, error) {
+		logger = logger.With("processor", "audio_speech", "isUpstreamFilter", fmt.Sprintf("%v", isUpstreamFilter))
+		if !isUpstreamFilter {
+			return &audioSpeechProcessorRouterFilter{
+				config:         config,
+				requestHeaders: requestHeaders,
+				logger:         logger,
+			}, nil
+		}
+		return &audioSpeechProcessorUpstreamFilter{
+			config:         config,
+			requestHeaders: requestHeaders,
+			logger:         logger,
+			metrics:        am,
+		}, nil
+	}
+}
+
+// audioSpeechProcessorRouterFilter implements [Processor] for the `/v1/audio/speech` endpoint.
+//
+// This is primarily used to select the route for the request based on the model name.
+type audioSpeechProcessorRouterFilter struct {
+	passThroughProcessor
+	quotaTracker
+	// upstreamFilter is the upstream filter that is used to process the request at the upstream filter.
+	// This will be updated when the request is retried.
+	//
+	// On the response handling path, we don't need to do any operation until successful, so we use the implementation
+	// of the upstream filter to handle the response at the router filter.
+	//
+	// TODO: this is a bit of a hack and dirty workaround, so revert this to a cleaner design later.
+	upstreamFilter Processor
+	logger         *slog.Logger
+	config         *processorConfig
+	requestHeaders map[string]string
+	// originalRequestBody is the original request body that is passed to the upstream filter.
+	// This is used to perform the transformation of the request body on the original input
+	// when the request is retried.
+	originalRequestBody    *openai.AudioSpeechRequest
+	originalRequestBodyRaw []byte
+	// upstreamFilterCount is the number of upstream filters that have been processed.
+	// This is used to determine if the request is a retry request.
+	upstreamFilterCount int
+}
+
+// ProcessResponseHeaders implements [Processor.ProcessResponseHeaders].
+func (a *audioSpeechProcessorRouterFilter) ProcessResponseHeaders(ctx context.Context, headerMap *corev3.HeaderMap) (*extprocv3.ProcessingResponse, error) {
+	// If the request failed to route and/or immediate response was returned before the upstream filter was set,
+	// a.upstreamFilter can be nil.
+	if a.upstreamFilter != nil { // See the comment on the "upstreamFilter" field.
+		return a.upstreamFilter.ProcessResponseHeaders(ctx, headerMap)
+	}
+	return a.passThroughProcessor.ProcessResponseHeaders(ctx, headerMap)
+}
+
+// ProcessResponseBody implements [Processor.ProcessResponseBody].
+func (a *audioSpeechProcessorRouterFilter) ProcessResponseBody(ctx context.Context, body *extprocv3.HttpBody) (resp *extprocv3.ProcessingResponse, err error) {
+	// If the request failed to route and/or immediate response was returned before the upstream filter was set,
+	// a.upstreamFilter can be nil.
+	if a.upstreamFilter != nil { // See the comment on the "upstreamFilter" field.
+		resp, err = a.upstreamFilter.ProcessResponseBody(ctx, body)
+	} else {
+		resp, err = a.passThroughProcessor.ProcessResponseBody(ctx, body)
+	}
+	a.reconcileAtEnd(ctx, body)
+	return
+}
+
+// ProcessRequestBody implements [Processor.ProcessRequestBody].
+func (a *audioSpeechProcessorRouterFilter) ProcessRequestBody(ctx context.Context, rawBody *extprocv3.HttpBody) (*extprocv3.ProcessingResponse, error) {
+	model, body, err := parseOpenAIAudioSpeechBody(rawBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request body: %w", err)
+	}
+	model = a.config.resolveModelAlias(model)
+	keyHeaders, keyRemovedHeaders, resp := a.config.checkVirtualKey(model, a.requestHeaders)
+	if resp != nil {
+		return resp, nil
+	}
+	if resp = a.config.rejectUnsupportedRequestPolicy(openAIErrorFormat{}, model, a.requestHeaders); resp != nil {
+		return resp, nil
+	}
+
+	// The audio speech is counted in characters, not tokens, so the request is only rejected when the budget is already
+	// exhausted.
+	resp = a.reserveQuota(ctx, a.config, a.logger, &a.upstreamFilter, openAIErrorFormat{}, model, a.requestHeaders, quota.Usage{})
+	if resp != nil {
+		return resp, nil
+	}
+
+	a.requestHeaders[a.config.modelNameHeaderKey] = model
+
+	var additionalHeaders []*corev3.HeaderValueOption
+	additionalHeaders = append(additionalHeaders, &corev3.HeaderValueOption{
+		// Set the model name to the request header with the key `x-ai-eg-model`.
+		Header: &corev3.HeaderValue{Key: a.config.modelNameHeaderKey, RawValue: []byte(model)},
+	}, &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{Key: originalPathHeader, RawValue: []byte(a.requestHeaders[":path"])},
+	})
+	bodyHeaders, removedHeaders := a.config.projectBodyHeaders(rawBody.Body, a.requestHeaders, quota.Usage{})
+	additionalHeaders = append(additionalHeaders, bodyHeaders...)
+	additionalHeaders = append(additionalHeaders, keyHeaders...)
+	removedHeaders = append(removedHeaders, keyRemovedHeaders...)
+	a.originalRequestBody = body
+	a.originalRequestBodyRaw = rawBody.Body
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_RequestBody{
+			RequestBody: &extprocv3.BodyResponse{
+				Response: &extprocv3.CommonResponse{
+					HeaderMutation: &extprocv3.HeaderMutation{
+						SetHeaders:    additionalHeaders,
+						RemoveHeaders: removedHeaders,
+					},
+					ClearRouteCache: true,
+				},
+			},
+		},
+	}, nil
+}
+
+// audioSpeechProcessorUpstreamFilter implements [Processor] for the `/v1/audio/speech` endpoint at the upstream filter.
+//
+// This is created per retry and handles the translation as well as the authentication of the request.
+type audioSpeechProcessorUpstreamFilter struct {
+	logger                 *slog.Logger
+	config                 *processorConfig
+	requestHeaders         map[string]string
+	responseHeaders        map[string]string
+	responseEncoding       string
+	modelNameOverride      string
+	backendName            string
+	handler                backendauth.Handler
+	originalRequestBodyRaw []byte
+	originalRequestBody    *openai.AudioSpeechRequest
+	translator             translator.OpenAIAudioSpeechTranslator
+	// onRetry is true if this is a retry request at the upstream filter.
+	onRetry bool
+	// cost is the cost of the request that is accumulated during the processing of the response.
+	costs translator.LLMTokenUsage
+	// metrics tracking.
+	metrics metrics.AudioMetrics
+}
+
+// selectTranslator selects the translator based on the output schema.
+func (a *audioSpeechProcessorUpstreamFilter) selectTranslator(out filterapi.VersionedAPISchema) error {
+	switch out.Name {
+	case filterapi.APISchemaOpenAI:
+		a.translator = translator.NewAudioSpeechOpenAIToOpenAITranslator(out.Version, a.modelNameOverride)
+	case filterapi.APISchemaAzureOpenAI:
+		a.translator = translator.NewAudioSpeechOpenAIToAzureOpenAITranslator(out.Version, a.modelNameOverride)
+	default:
+		return fmt.Errorf("unsupported API schema: backend=%s", out)
+	}
+	return nil
+}
+
+// ProcessRequestHeaders implements [Processor.ProcessRequestHeaders].
+//
+// At the upstream filter, we already have the original request body at request headers phase.
+// So, we simply do the translation and upstream auth at this stage, and send them back to Envoy
+// with the status CONTINUE_AND_REPLACE. This will allows Envoy to not send the request body again
+// to the extproc.
+func (a *audioSpeechProcessorUpstreamFilter) ProcessRequestHeaders(ctx context.Context, _ *corev3.HeaderMap) (res *extprocv3.ProcessingResponse, err error) {
+	defer func() {
+		if err != nil {
+			a.metrics.RecordRequestCompletion(ctx, false, a.requestHeaders)
+		}
+	}()
+
+	// Start tracking metrics for this request.
+	a.metrics.StartRequest(a.requestHeaders)
+	a.metrics.SetModel(a.requestHeaders[a.config.modelNameHeaderKey])
+
+	headerMutation, bodyMutation, err := a.translator.RequestBody(a.originalRequestBodyRaw, a.originalRequestBody, a.onRetry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform request: %w", err)
+	}
+	if headerMutation == nil {
+		headerMutation = &extprocv3.HeaderMutation{}
+	} else {
+		for _, h := range headerMutation.SetHeaders {
+			a.requestHeaders[h.Header.Key] = string(h.Header.RawValue)
+		}
+	}
+	if h := a.handler; h != nil {
+		if err = h.Do(ctx, a.requestHeaders, headerMutation, bodyMutation); err != nil {
+			return nil, fmt.Errorf("failed to do auth request: %w", err)
+		}
+	}
+
+	var dm *structpb.Struct
+	if bm := bodyMutation.GetBody(); bm != nil {
+		dm = buildContentLengthDynamicMetadataOnRequest(a.config, len(bm))
+	}
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_RequestHeaders{
+			RequestHeaders: &extprocv3.HeadersResponse{
+				Response: &extprocv3.CommonResponse{
+					HeaderMutation: headerMutation, BodyMutation: bodyMutation,
+					Status: extprocv3.CommonResponse_CONTINUE_AND_REPLACE,
+				},
+			},
+		},
+		DynamicMetadata: dm,
+	}, nil
+}
+
+// ProcessRequestBody implements [Processor.ProcessRequestBody].
+func (a *audioSpeechProcessorUpstreamFilter) ProcessRequestBody(context.Context, *extprocv3.HttpBody) (res *extprocv3.ProcessingResponse, err error) {
+	panic("BUG: ProcessRequestBody should not be called in the upstream filter")
+}
+
+// ProcessResponseHeaders implements [Processor.ProcessResponseHeaders].
+func (a *audioSpeechProcessorUpstreamFilter) ProcessResponseHeaders(ctx context.Context, headers *corev3.HeaderMap) (res *extprocv3.ProcessingResponse, err error) {
+	defer func() {
+		if err != nil {
+			a.metrics.RecordRequestCompletion(ctx, false, a.requestHeaders)
+		}
+	}()
+
+	a.responseHeaders = headersToMap(headers)
+	if enc := a.responseHeaders["content-encoding"]; enc != "" {
+		a.responseEncoding = enc
+	}
+	headerMutation, err := a.translator.ResponseHeaders(a.responseHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform response headers: %w", err)
+	}
+	return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseHeaders{
+		ResponseHeaders: &extprocv3.HeadersResponse{
+			Response: &extprocv3.CommonResponse{HeaderMutation: headerMutation},
+		},
+	}}, nil
+}
+
+// ProcessResponseBody implements [Processor.ProcessResponseBody].
+func (a *audioSpeechProcessorUpstreamFilter) ProcessResponseBody(ctx context.Context, body *extprocv3.HttpBody) (res *extprocv3.ProcessingResponse, err error) {
+	defer func() {
+		a.metrics.RecordRequestCompletion(ctx, err == nil, a.requestHeaders)
+	}()
+	var br io.Reader
+	var isGzip bool
+	switch a.responseEncoding {
+	case "gzip":
+		br, err = gzip.NewReader(bytes.NewReader(body.Body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode gzip: %w", err)
+		}
+		isGzip = true
+	default:
+		br = bytes.NewReader(body.Body)
+	}
+
+	// Assume all responses have a valid status code header.
+	if code, _ := strconv.Atoi(a.responseHeaders[":status"]); !isGoodStatusCode(code) {
+		var headerMutation *extprocv3.HeaderMutation
+		var bodyMutation *extprocv3.BodyMutation
+		headerMutation, bodyMutation, err = a.translator.ResponseError(a.responseHeaders, br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transform response error: %w", err)
+		}
+		return &extprocv3.ProcessingResponse{
+			Response: &extprocv3.ProcessingResponse_ResponseBody{
+				ResponseBody: &extprocv3.BodyResponse{
+					Response: &extprocv3.CommonResponse{
+						HeaderMutation: headerMutation,
+						BodyMutation:   bodyMutation,
+					},
+				},
+			},
+		}, nil
+	}
+
+	headerMutation, bodyMutation, tokenUsage, err := a.translator.ResponseBody(a.responseHeaders, br, body.EndOfStream)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform response: %w", err)
+	}
+	if bodyMutation != nil && isGzip {
+		if headerMutation == nil {
+			headerMutation = &extprocv3.HeaderMutation{}
+		}
+		// TODO: this is a hotfix, we should update this to recompress since its in the header
+		// If the response was gzipped, ensure we remove the content-encoding header.
+		//
+		// This is only needed when the transformation is actually modifying the body. When the backend
+		// is in OpenAI format (and it's the first try before any retry), the response body is not modified,
+		// so we don't need to remove the header in that case.
+		headerMutation.RemoveHeaders = append(headerMutation.RemoveHeaders, "content-encoding")
+	}
+
+	resp := &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ResponseBody{
+			ResponseBody: &extprocv3.BodyResponse{
+				Response: &extprocv3.CommonResponse{
+					HeaderMutation: headerMutation,
+					BodyMutation:   bodyMutation,
+				},
+			},
+		},
+	}
+
+	// Accumulate the number of synthesized characters.
+	a.costs.Characters += tokenUsage.Characters
+
+	// Update metrics with token usage.
+	a.metrics.RecordTokenUsage(ctx, tokenUsage.InputTokens, tokenUsage.OutputTokens, tokenUsage.TotalTokens, a.requestHeaders)
+
+	if body.EndOfStream && a.config.recordsCosts() {
+		resp.DynamicMetadata, err = buildDynamicMetadata(a.config, &a.costs, a.requestHeaders, a.modelNameOverride, a.backendName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build dynamic metadata: %w", err)
+		}
+	}
+
+	return resp, nil
+}
+
+// SetBackend implements [Processor.SetBackend].
+func (a *audioSpeechProcessorUpstreamFilter) SetBackend(ctx context.Context, b *filterapi.Backend, backendHandler backendauth.Handler, routeProcessor Processor) (err error) {
+	defer func() {
+		a.metrics.RecordRequestCompletion(ctx, err == nil, a.requestHeaders)
+	}()
+	rp, ok := routeProcessor.(*audioSpeechProcessorRouterFilter)
+	if !ok {
+		panic("BUG: expected routeProcessor to be of type *audioSpeechProcessorRouterFilter")
+	}
+	rp.upstreamFilterCount++
+	a.metrics.SetBackend(b)
+	a.modelNameOverride = b.ModelNameOverride
+	a.backendName = b.Name
+	if err = a.selectTranslator(b.Schema); err != nil {
+		return fmt.Errorf("failed to select translator: %w", err)
+	}
+	a.handler = backendHandler
+	a.originalRequestBody = rp.originalRequestBody
+	a.originalRequestBodyRaw = rp.originalRequestBodyRaw
+	a.onRetry = rp.upstreamFilterCount > 1
+	rp.upstreamFilter = a
+	return
+}
+
+func parseOpenAIAudioSpeechBody(body *extprocv3.HttpBody) (modelName string, rb *openai.AudioSpeechRequest, err error) {
+	var openAIReq openai.AudioSpeechRequest
+	if err := json.Unmarshal(body.Body, &openAIReq); err != nil {
+		return "", nil, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	return openAIReq.Model, &openAIReq, nil
+}
@@ -0,0 +1,42 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package backendauth
+
+import (
+	"context"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+// anthropicAPIKeyHeader is the header name used by the Anthropic API for the api key.
+const anthropicAPIKeyHeader = "x-api-key"
+
+// anthropicAPIKeyHandler implements [Handler] for Anthropic api key authz.
+type anthropicAPIKeyHandler struct {
+	apiKey string
+}
+
+func newAnthropicAPIKeyHandler(auth *filterapi.AnthropicAPIKeyAuth) (Handler, error) {
+	return &anthropicAPIKeyHandler{apiKey: strings.TrimSpace(auth.Key)}, nil
+}
+
+// Do implements [Handler.Do].
+//
+// Sets the api key as the x-api-key header instead of the authorization header. The authorization header sent by
+// the client is removed so that the credential of the client is not forwarded to the backend.
+func (a *anthropicAPIKeyHandler) Do(_ context.Context, requestHeaders map[string]string, headerMut *extprocv3.HeaderMutation, _ *extprocv3.BodyMutation) error {
+	requestHeaders[anthropicAPIKeyHeader] = a.apiKey
+	headerMut.SetHeaders = append(headerMut.SetHeaders, &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{Key: anthropicAPIKeyHeader, RawValue: []byte(a.apiKey)},
+	})
+	delete(requestHeaders, "authorization")
+	headerMut.RemoveHeaders = append(headerMut.RemoveHeaders, "authorization")
+	return nil
+}
@@ -0,0 +1,49 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package backendauth
+
+import (
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestNewAnthropicAPIKeyHandler(t *testing.T) {
+	auth := filterapi.AnthropicAPIKeyAuth{Key: "test \n"}
+	handler, err := newAnthropicAPIKeyHandler(&auth)
+	require.NoError(t, err)
+	require.NotNil(t, handler)
+	// apiKey should be trimmed.
+	require.Equal(t, "test", handler.(*anthropicAPIKeyHandler).apiKey)
+}
+
+func TestAnthropicAPIKeyHandler_Do(t *testing.T) {
+	auth := filterapi.AnthropicAPIKeyAuth{Key: "test"}
+	handler, err := newAnthropicAPIKeyHandler(&auth)
+	require.NoError(t, err)
+	require.NotNil(t, handler)
+
+	requestHeaders := map[string]string{":method": "POST", "authorization": "Bearer sk-client"}
+	headerMut := &extprocv3.HeaderMutation{
+		SetHeaders: []*corev3.HeaderValueOption{
+			{Header: &corev3.HeaderValue{Key: ":path", Value: "/v1/messages"}},
+		},
+	}
+	err = handler.Do(t.Context(), requestHeaders, headerMut, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, "test", requestHeaders["x-api-key"])
+	// The credential of the client is not forwarded to the backend.
+	require.NotContains(t, requestHeaders, "authorization")
+	require.Equal(t, []string{"authorization"}, headerMut.RemoveHeaders)
+	require.Len(t, headerMut.SetHeaders, 2)
+	require.Equal(t, "x-api-key", headerMut.SetHeaders[1].Header.Key)
+	require.Equal(t, []byte("test"), headerMut.SetHeaders[1].Header.GetRawValue())
+}
@@ -34,6 +34,12 @@ aws_secret_access_key = test
 				APIKey: &filterapi.APIKeyAuth{Key: "TEST"},
 			},
 		},
+		{
+			name: "AnthropicAPIKey",
+			config: &filterapi.BackendAuth{
+				AnthropicAPIKey: &filterapi.AnthropicAPIKeyAuth{Key: "TEST"},
+			},
+		},
 		{
 			name: "AzureAuth",
 			config: &filterapi.BackendAuth{
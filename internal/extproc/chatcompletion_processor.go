@@ -13,26 +13,56 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/tidwall/sjson"
 	"google.golang.org/protobuf/types/known/structpb"
 
 	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/adaptivelb"
+	"github.com/envoyproxy/ai-gateway/internal/admission"
 	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/circuitbreaker"
 	"github.com/envoyproxy/ai-gateway/internal/extproc/backendauth"
 	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+	"github.com/envoyproxy/ai-gateway/internal/guardrail"
+	"github.com/envoyproxy/ai-gateway/internal/hedging"
 	"github.com/envoyproxy/ai-gateway/internal/internalapi"
 	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
 	"github.com/envoyproxy/ai-gateway/internal/metrics"
+	"github.com/envoyproxy/ai-gateway/internal/mirror"
+	"github.com/envoyproxy/ai-gateway/internal/pii"
+	"github.com/envoyproxy/ai-gateway/internal/quota"
+	"github.com/envoyproxy/ai-gateway/internal/responsecache"
+	"github.com/envoyproxy/ai-gateway/internal/toolpolicy"
 	tracing "github.com/envoyproxy/ai-gateway/internal/tracing/api"
 )
 
+// ChatCompletionProcessorMetrics is the set of the metrics recorded by the chat completion processor.
+type ChatCompletionProcessorMetrics struct {
+	// ChatCompletion is recorded for every request.
+	ChatCompletion metrics.ChatCompletionMetrics
+	// ResponseCache is only recorded when the response cache is configured.
+	ResponseCache metrics.ResponseCacheMetrics
+	// BackendAttempt is only recorded for the backends with the fallback configured.
+	BackendAttempt metrics.BackendAttemptMetrics
+	// Admission is only recorded for the backends with the limits of the admission control.
+	Admission metrics.AdmissionMetrics
+	// PII is only recorded for the routes with the PII redaction.
+	PII metrics.PIIMetrics
+}
+
 // ChatCompletionProcessorFactory returns a factory method to instantiate the chat completion processor.
-func ChatCompletionProcessorFactory(ccm metrics.ChatCompletionMetrics) ProcessorFactory {
+func ChatCompletionProcessorFactory(m ChatCompletionProcessorMetrics) ProcessorFactory {
 	return func(config *processorConfig, requestHeaders map[string]string, logger *slog.Logger, tracing tracing.Tracing, isUpstreamFilter bool) (Processor, error) {
 		logger = logger.With("processor", "chat-completion", "isUpstreamFilter", fmt.Sprintf("%v", isUpstreamFilter))
 		if !isUpstreamFilter {
@@ -41,13 +71,17 @@ func ChatCompletionProcessorFactory(ccm metrics.ChatCompletionMetrics) Processor
 				tracer:         tracing.ChatCompletionTracer(),
 				requestHeaders: requestHeaders,
 				logger:         logger,
+				piiMetrics:     m.PII,
 			}, nil
 		}
 		return &chatCompletionProcessorUpstreamFilter{
-			config:         config,
-			requestHeaders: requestHeaders,
-			logger:         logger,
-			metrics:        ccm,
+			config:                config,
+			requestHeaders:        requestHeaders,
+			logger:                logger,
+			metrics:               m.ChatCompletion,
+			responseCacheMetrics:  m.ResponseCache,
+			backendAttemptMetrics: m.BackendAttempt,
+			admissionMetrics:      m.Admission,
 		}, nil
 	}
 }
@@ -57,6 +91,7 @@ func ChatCompletionProcessorFactory(ccm metrics.ChatCompletionMetrics) Processor
 // This is primarily used to select the route for the request based on the model name.
 type chatCompletionProcessorRouterFilter struct {
 	passThroughProcessor
+	quotaTracker
 	// upstreamFilter is the upstream filter that is used to process the request at the upstream filter.
 	// This will be updated when the request is retried.
 	//
@@ -83,10 +118,63 @@ type chatCompletionProcessorRouterFilter struct {
 	// upstreamFilterCount is the number of upstream filters that have been processed.
 	// This is used to determine if the request is a retry request.
 	upstreamFilterCount int
+	// responseCached is set to true when the response cache applies to this request, i.e. the response cache is
+	// configured and not bypassed by the client. The cache is looked up by the upstream filter since the response
+	// depends on the backend selected for the request.
+	responseCached bool
+	// hedged is true when the request matches a route rule with the hedging. In that case, the attempts are in flight
+	// concurrently, so the upstream filters are tracked in hedgedAttempts, and upstreamFilter is selected by the
+	// response headers of the served attempt. See selectHedgedAttempt.
+	hedged bool
+	// upstreamMu guards upstreamFilter, upstreamFilterCount, hedgedAttempts and upstreamSelected while the request is hedged.
+	upstreamMu     sync.Mutex
+	hedgedAttempts []*chatCompletionProcessorUpstreamFilter
+	// upstreamSelected is set to true once the response starts, after which upstreamFilter is not updated.
+	upstreamSelected bool
+	// admissionClass is the priority class of the request when the admission control is configured.
+	admissionClass admission.Class
+	// admissionTickets are the admissions of the attempts of the request on the backends, which are released at the
+	// latest when the stream of this filter ends. See Close. Guarded by upstreamMu.
+	admissionTickets []*admission.Ticket
+	// piiRestorer restores the values masked by the PII redaction in the response, and nil when nothing is masked.
+	piiRestorer *pii.Restorer
+	piiMetrics  metrics.PIIMetrics
+	// guardrail is the guardrail of the route matching the request, otherwise nil.
+	guardrail *guardrail.Guardrail
+	// guardrailMutated is set to true when the request body is mutated by the guardrail.
+	guardrailMutated bool
+	// guardrailDecision is the decision of the guardrail on the request, which is recorded on the span.
+	guardrailDecision *guardrail.Decision
+	// toolValidator validates the tool calls of the response when the tool policy of the route applies to the request
+	// declaring the functions, otherwise nil.
+	toolValidator *toolpolicy.Validator
+	// requestPolicyMutated is set to true when the request body is changed by the request policy.
+	requestPolicyMutated bool
+	// requestPolicyClamped is the list of the fields clamped by the request policy.
+	requestPolicyClamped []string
+}
+
+// Close implements [processorCloser.Close].
+//
+// The attempts normally release their admissions once their outcomes are known, so this only releases the ones of
+// the attempts left behind, e.g. when the client cancels the request. Likewise, the quota reservation is normally
+// reconciled at the end of the response, so this only reconciles the one of the request whose stream is aborted
+// or fails before the end of the response.
+func (c *chatCompletionProcessorRouterFilter) Close() {
+	c.upstreamMu.Lock()
+	defer c.upstreamMu.Unlock()
+	for _, t := range c.admissionTickets {
+		t.Release(0)
+	}
+	c.admissionTickets = nil
+	c.quotaTracker.Close()
 }
 
 // ProcessResponseHeaders implements [Processor.ProcessResponseHeaders].
 func (c *chatCompletionProcessorRouterFilter) ProcessResponseHeaders(ctx context.Context, headerMap *corev3.HeaderMap) (*extprocv3.ProcessingResponse, error) {
+	if c.hedged {
+		c.selectHedgedAttempt(headerMap)
+	}
 	// If the request failed to route and/or immediate response was returned before the upstream filter was set,
 	// c.upstreamFilter can be nil.
 	if c.upstreamFilter != nil { // See the comment on the "upstreamFilter" field.
@@ -95,6 +183,28 @@ func (c *chatCompletionProcessorRouterFilter) ProcessResponseHeaders(ctx context
 	return c.passThroughProcessor.ProcessResponseHeaders(ctx, headerMap)
 }
 
+// selectHedgedAttempt selects the upstream filter of the attempt whose response is served to the client, which is
+// identified by the internalapi.HedgeAttemptHeaderKey response header set by the upstream filter. The other attempts
+// which haven't received their responses are cancelled by Envoy, and counted on the selected upstream filter to
+// account for their token usage.
+func (c *chatCompletionProcessorRouterFilter) selectHedgedAttempt(headers *corev3.HeaderMap) {
+	attempt, _ := strconv.Atoi(headersToMap(headers)[internalapi.HedgeAttemptHeaderKey])
+	c.upstreamMu.Lock()
+	defer c.upstreamMu.Unlock()
+	c.upstreamSelected = true
+	if attempt < 1 || attempt > len(c.hedgedAttempts) {
+		// The response is not from any attempt, e.g. the local reply of Envoy.
+		return
+	}
+	selected := c.hedgedAttempts[attempt-1]
+	c.upstreamFilter = selected
+	for _, a := range c.hedgedAttempts {
+		if a != selected && !a.responded {
+			selected.cancelledAttempts++
+		}
+	}
+}
+
 // ProcessResponseBody implements [Processor.ProcessResponseBody].
 func (c *chatCompletionProcessorRouterFilter) ProcessResponseBody(ctx context.Context, body *extprocv3.HttpBody) (resp *extprocv3.ProcessingResponse, err error) {
 	// If the request failed to route and/or immediate response was returned before the upstream filter was set,
@@ -104,6 +214,7 @@ func (c *chatCompletionProcessorRouterFilter) ProcessResponseBody(ctx context.Co
 	} else {
 		resp, err = c.passThroughProcessor.ProcessResponseBody(ctx, body)
 	}
+	c.reconcileAtEnd(ctx, body)
 	if c.span == nil {
 		return
 	}
@@ -122,6 +233,9 @@ func (c *chatCompletionProcessorRouterFilter) ProcessResponseBody(ctx context.Co
 
 	// End the span when response processing is complete.
 	if body.EndOfStream {
+		if upstream, ok := c.upstreamFilter.(*chatCompletionProcessorUpstreamFilter); ok && upstream.responseGuardrail != nil {
+			recordGuardrail(c.span, guardrail.PhaseResponse, upstream.responseGuardrail.decision)
+		}
 		c.span.EndSpan(statusCode, body.Body)
 	}
 
@@ -134,9 +248,43 @@ func (c *chatCompletionProcessorRouterFilter) ProcessRequestBody(ctx context.Con
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse request body: %w", err)
 	}
-	if body.Stream && (body.StreamOptions == nil || !body.StreamOptions.IncludeUsage) && len(c.config.requestCosts) > 0 {
-		// If the request is a streaming request and cost metrics are configured, we need to include usage in the response
-		// to avoid the bypassing of the token usage calculation.
+	model = c.config.resolveModelAlias(model)
+	// The virtual key is validated before anything else so that the requests of the unknown clients cost nothing.
+	keyHeaders, keyRemovedHeaders, resp := c.config.checkVirtualKey(model, c.requestHeaders)
+	if resp != nil {
+		return resp, nil
+	}
+	// The request policy is applied next so that the rejected requests don't cost anything, and the rest sees the
+	// request with the defaults and the clamps applied.
+	if resp, err := c.applyRequestPolicy(model, rawBody, body); err != nil || resp != nil {
+		return resp, err
+	}
+	// The PII is redacted before anything else sees the body, e.g. the response cache and the quota estimation.
+	c.piiRestorer, resp, err = c.config.redactPII(ctx, c.piiMetrics, chatCompletionFormat{}, model, c.requestHeaders, rawBody,
+		func(r *pii.Redactor, raw []byte) ([]byte, pii.Result, error) { return r.Redact(raw, body) })
+	if err != nil || resp != nil {
+		return resp, err
+	}
+	// The guardrail sees the request after the redaction so that the PII is not sent to the guardrail service either.
+	if resp, err := c.checkRequestGuardrail(ctx, model, rawBody, body); err != nil || resp != nil {
+		return resp, err
+	}
+	// The tool policy sees the functions declared by the request after the guardrail may have mutated it.
+	if resp := c.checkToolPolicy(model, body); resp != nil {
+		return resp, nil
+	}
+	// The masked requests are not cached since the response restored for one client must not be served to another
+	// whose different values are masked the same way.
+	c.responseCached = c.config.responseCache != nil && !isResponseCacheBypassed(c.requestHeaders) && c.piiRestorer == nil
+	estimate := quota.EstimateChatCompletion(body)
+	resp = c.reserveQuota(ctx, c.config, c.logger, &c.upstreamFilter, chatCompletionFormat{}, model, c.requestHeaders, estimate)
+	if resp != nil {
+		return resp, nil
+	}
+	if body.Stream && (body.StreamOptions == nil || !body.StreamOptions.IncludeUsage) &&
+		(c.config.recordsCosts() || c.quotaReservation != nil) {
+		// If the request is a streaming request and cost metrics or quotas are configured, we need to include usage
+		// in the response to avoid the bypassing of the token usage calculation.
 		body.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
 		// Rewrite the original bytes to include the stream_options.include_usage=true so that forcing the request body
 		// mutation, which uses this raw body, will also result in the stream_options.include_usage=true.
@@ -151,6 +299,7 @@ func (c *chatCompletionProcessorRouterFilter) ProcessRequestBody(ctx context.Con
 	}
 
 	c.requestHeaders[c.config.modelNameHeaderKey] = model
+	c.admissionClass = c.config.admission.Classify(c.requestHeaders)
 
 	var additionalHeaders []*corev3.HeaderValueOption
 	additionalHeaders = append(additionalHeaders, &corev3.HeaderValueOption{
@@ -159,12 +308,44 @@ func (c *chatCompletionProcessorRouterFilter) ProcessRequestBody(ctx context.Con
 	}, &corev3.HeaderValueOption{
 		Header: &corev3.HeaderValue{Key: originalPathHeader, RawValue: []byte(c.requestHeaders[":path"])},
 	})
+	bodyHeaders, removedHeaders := c.config.projectBodyHeaders(rawBody.Body, c.requestHeaders, estimate)
+	additionalHeaders = append(additionalHeaders, bodyHeaders...)
+	additionalHeaders = append(additionalHeaders, keyHeaders...)
+	removedHeaders = append(removedHeaders, keyRemovedHeaders...)
+	if lb := c.config.loadBalancers.Find(model, c.requestHeaders); lb != nil {
+		selected := lb.Select()
+		c.requestHeaders[internalapi.SelectedBackendHeaderKey] = selected
+		additionalHeaders = append(additionalHeaders, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: internalapi.SelectedBackendHeaderKey, RawValue: []byte(selected)},
+		})
+	} else if _, ok := c.requestHeaders[internalapi.SelectedBackendHeaderKey]; ok {
+		// Don't let the clients pick the backend by themselves.
+		delete(c.requestHeaders, internalapi.SelectedBackendHeaderKey)
+		removedHeaders = append(removedHeaders, internalapi.SelectedBackendHeaderKey)
+	}
+	if key := c.config.sessionAffinities.Key(model, c.requestHeaders, body.Messages); key != "" {
+		c.requestHeaders[internalapi.SessionKeyHeaderKey] = key
+		additionalHeaders = append(additionalHeaders, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: internalapi.SessionKeyHeaderKey, RawValue: []byte(key)},
+		})
+	} else if _, ok := c.requestHeaders[internalapi.SessionKeyHeaderKey]; ok {
+		// Don't let the clients pin the backend by themselves.
+		delete(c.requestHeaders, internalapi.SessionKeyHeaderKey)
+		removedHeaders = append(removedHeaders, internalapi.SessionKeyHeaderKey)
+	}
+	if h := c.config.hedgings.Find(model, c.requestHeaders); h != nil {
+		c.hedged = true
+		additionalHeaders = append(additionalHeaders, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: hedging.PerTryTimeoutHeaderKey, RawValue: []byte(hedging.PerTryTimeout(h.Delay))},
+		})
+	}
 	c.originalRequestBody = body
 	c.originalRequestBodyRaw = rawBody.Body
 
 	// Tracing may need to inject headers, so create a header mutation here.
 	headerMutation := &extprocv3.HeaderMutation{
-		SetHeaders: additionalHeaders,
+		SetHeaders:    additionalHeaders,
+		RemoveHeaders: removedHeaders,
 	}
 	c.span = c.tracer.StartSpanAndInjectHeaders(
 		ctx,
@@ -173,6 +354,9 @@ func (c *chatCompletionProcessorRouterFilter) ProcessRequestBody(ctx context.Con
 		body,
 		rawBody.Body,
 	)
+	if c.span != nil {
+		recordGuardrail(c.span, guardrail.PhaseRequest, c.guardrailDecision)
+	}
 
 	return &extprocv3.ProcessingResponse{
 		Response: &extprocv3.ProcessingResponse_RequestBody{
@@ -183,9 +367,27 @@ func (c *chatCompletionProcessorRouterFilter) ProcessRequestBody(ctx context.Con
 				},
 			},
 		},
+		DynamicMetadata: c.requestPolicyDynamicMetadata(),
 	}, nil
 }
 
+const (
+	// responseCacheTypeExact is the attribution of the responses served by the exact match.
+	responseCacheTypeExact = "exact"
+	// responseCacheTypeSemantic is the attribution of the responses served by the semantic lookup.
+	responseCacheTypeSemantic = "semantic"
+)
+
+// responseCacheHeader is the response header set on the responses served from the response cache.
+const responseCacheHeader = "x-ai-eg-response-cache"
+
+// isResponseCacheBypassed returns true if the client opts out of the response cache with the cache-control header.
+// In that case, the response is neither served from nor stored in the cache.
+func isResponseCacheBypassed(requestHeaders map[string]string) bool {
+	cc := strings.ToLower(requestHeaders["cache-control"])
+	return strings.Contains(cc, "no-cache") || strings.Contains(cc, "no-store")
+}
+
 // chatCompletionProcessorUpstreamFilter implements [Processor] for the `/v1/chat/completion` endpoint at the upstream filter.
 //
 // This is created per retry and handles the translation as well as the authentication of the request.
@@ -197,20 +399,86 @@ type chatCompletionProcessorUpstreamFilter struct {
 	responseEncoding       string
 	modelNameOverride      string
 	backendName            string
+	backend                *filterapi.Backend
 	handler                backendauth.Handler
 	originalRequestBodyRaw []byte
 	originalRequestBody    *openai.ChatCompletionRequest
 	translator             translator.OpenAIChatCompletionTranslator
 	// onRetry is true if this is a retry request at the upstream filter.
 	onRetry bool
+	// setHeaderKeys are the request headers set by the translation and the authentication of this attempt.
+	setHeaderKeys []string
+	// staleHeaderKeys are the request headers set by the previous attempt which are not in the original request.
+	// Envoy retries the request with the headers mutated by the previous attempt, so the ones not set again by this
+	// attempt are removed, e.g. the credentials of the previous backend when the retry is made on a different schema.
+	staleHeaderKeys []string
 	// cost is the cost of the request that is accumulated during the processing of the response.
 	costs translator.LLMTokenUsage
 	// metrics tracking.
-	metrics metrics.ChatCompletionMetrics
+	metrics               metrics.ChatCompletionMetrics
+	backendAttemptMetrics metrics.BackendAttemptMetrics
+	admissionMetrics      metrics.AdmissionMetrics
 	// stream is set to true if the request is a streaming request.
 	stream bool
 	// See the comment on the `forcedStreamOptionIncludeUsage` field in the router filter.
 	forcedStreamOptionIncludeUsage bool
+	// See the comment on the `responseCached` field in the router filter.
+	responseCached bool
+	// responseCacheKey is the key of the response cache for this attempt on the cache miss. This is empty when the
+	// response cache doesn't apply to the request, in which case the response is not stored.
+	responseCacheKey     string
+	responseCacheMetrics metrics.ResponseCacheMetrics
+	// servedFromCache is set to true when this attempt is served from the response cache without reaching the backend.
+	servedFromCache bool
+	// See the comment on the `piiRestorer` field in the router filter.
+	piiRestorer *pii.Restorer
+	// See the comment on the `guardrail` field in the router filter.
+	guardrail *guardrail.Guardrail
+	// See the comment on the `guardrailMutated` field in the router filter.
+	guardrailMutated bool
+	// responseGuardrail holds back the response until the guardrail decides on it. This is nil when the guardrail
+	// doesn't check the response.
+	responseGuardrail *responseGuardrail
+	// toolPolicy validates the tool calls of the response. This is nil when the tool policy doesn't apply to the
+	// request. See the comment on the `toolValidator` field in the router filter.
+	toolPolicy *responseToolPolicy
+	// See the comment on the `requestPolicyMutated` field in the router filter.
+	requestPolicyMutated bool
+	// semanticQuery is the embedded request on the semantic response cache miss. This is associated with
+	// the response cache key once the response is stored, so that similar requests can be served from the cache.
+	semanticQuery *responsecache.SemanticQuery
+	// responseCacheBuf accumulates the response body in the OpenAI format to be stored in the response cache.
+	responseCacheBuf []byte
+	// attemptStart is the time when this attempt is sent to the backend.
+	attemptStart time.Time
+	// observed is set to true once the outcome of this attempt is reported to the adaptive load balancing.
+	observed bool
+	// mirror is the mirror of the route rule when this request is sampled to be mirrored, otherwise nil.
+	mirror *mirror.Mirror
+	// mirrorBuf accumulates the response body in the OpenAI format to be recorded as the primary response.
+	mirrorBuf []byte
+	// router is the router filter of the hedged request, otherwise nil.
+	router *chatCompletionProcessorRouterFilter
+	// attempt is the number of this attempt starting from 1.
+	attempt int
+	// responded is set to true once the response of this hedged attempt is received. Guarded by router.upstreamMu.
+	responded bool
+	// cancelledAttempts is the number of the other attempts of the hedged request cancelled when the response of this
+	// attempt is served. Their token usage is estimated from the usage of this attempt.
+	cancelledAttempts int
+	// circuitBreaker is the circuit breaker of the backend which let this attempt through, until the outcome of this
+	// attempt is reported to it. Otherwise nil.
+	circuitBreaker *circuitbreaker.Breaker
+	// rejected is set to true when this attempt is rejected by the filter without reaching the backend, i.e. by the
+	// open circuit breaker or the admission control of the backend.
+	rejected bool
+	// admissionTicket is the admission of this attempt on the backend with the limits of the admission control,
+	// otherwise nil. This is released once the outcome of this attempt is known.
+	admissionTicket *admission.Ticket
+	// admissionClass is the priority class of the request when the admission control is configured.
+	admissionClass admission.Class
+	// shedRetryAfter is the Retry-After of this attempt when it is shed by the admission control, otherwise zero.
+	shedRetryAfter time.Duration
 }
 
 // selectTranslator selects the translator based on the output schema.
@@ -226,6 +494,8 @@ func (c *chatCompletionProcessorUpstreamFilter) selectTranslator(out filterapi.V
 		c.translator = translator.NewChatCompletionOpenAIToGCPVertexAITranslator(c.modelNameOverride)
 	case filterapi.APISchemaGCPAnthropic:
 		c.translator = translator.NewChatCompletionOpenAIToGCPAnthropicTranslator(out.Version, c.modelNameOverride)
+	case filterapi.APISchemaAnthropic:
+		c.translator = translator.NewChatCompletionOpenAIToAnthropicTranslator(out.Version, c.modelNameOverride)
 	default:
 		return fmt.Errorf("unsupported API schema: backend=%s", out)
 	}
@@ -245,15 +515,43 @@ func (c *chatCompletionProcessorUpstreamFilter) ProcessRequestHeaders(ctx contex
 		}
 	}()
 
+	if c.responseCached {
+		if resp := c.lookupResponseCache(ctx); resp != nil {
+			return resp, nil
+		}
+	}
+
 	// Start tracking metrics for this request.
 	c.metrics.StartRequest(c.requestHeaders)
 	c.metrics.SetModel(c.requestHeaders[c.config.modelNameHeaderKey])
+	c.attemptStart = time.Now()
+
+	if cb := c.config.circuitBreakers.Find(c.backendName); cb != nil {
+		if !cb.Allow() {
+			c.rejected = true
+			c.admissionTicket.Release(0)
+			retried := c.backend.Fallback != nil
+			c.recordAttempt(ctx, fallbackErrorTypeCircuitOpen, retried)
+			return circuitOpenResponse(c.backendName, retried), nil
+		}
+		c.circuitBreaker = cb
+	}
+	if c.shedRetryAfter > 0 {
+		c.rejected = true
+		retried := c.backend.Fallback != nil
+		c.recordAttempt(ctx, fallbackErrorTypeShed, retried)
+		return admissionShedResponse(c.backendName, c.admissionClass.Name, c.shedRetryAfter, retried), nil
+	}
 
 	// We force the body mutation in the following cases:
 	// * The request is a retry request because the body mutation might have happened the previous iteration.
 	// * The request is a streaming request, and the IncludeUsage option is set to false since we need to ensure that
 	//	the token usage is calculated correctly without being bypassed.
-	forceBodyMutation := c.onRetry || c.forcedStreamOptionIncludeUsage
+	// * The PII is masked in the request body.
+	// * The request body is mutated by the guardrail.
+	// * The request body is changed by the request policy.
+	forceBodyMutation := c.onRetry || c.forcedStreamOptionIncludeUsage || c.piiRestorer != nil || c.guardrailMutated ||
+		c.requestPolicyMutated
 	headerMutation, bodyMutation, err := c.translator.RequestBody(c.originalRequestBodyRaw, c.originalRequestBody, forceBodyMutation)
 	if err != nil {
 		return nil, fmt.Errorf("failed to transform request: %w", err)
@@ -270,6 +568,15 @@ func (c *chatCompletionProcessorUpstreamFilter) ProcessRequestHeaders(ctx contex
 			return nil, fmt.Errorf("failed to do auth request: %w", err)
 		}
 	}
+	for _, h := range headerMutation.SetHeaders {
+		c.setHeaderKeys = append(c.setHeaderKeys, h.Header.Key)
+	}
+	for _, k := range c.staleHeaderKeys {
+		if !slices.Contains(c.setHeaderKeys, k) {
+			headerMutation.RemoveHeaders = append(headerMutation.RemoveHeaders, k)
+			delete(c.requestHeaders, k)
+		}
+	}
 
 	var dm *structpb.Struct
 	if bm := bodyMutation.GetBody(); bm != nil {
@@ -305,9 +612,33 @@ func (c *chatCompletionProcessorUpstreamFilter) ProcessResponseHeaders(ctx conte
 	if enc := c.responseHeaders["content-encoding"]; enc != "" {
 		c.responseEncoding = enc
 	}
-	headerMutation, err := c.translator.ResponseHeaders(c.responseHeaders)
-	if err != nil {
-		return nil, fmt.Errorf("failed to transform response headers: %w", err)
+	var headerMutation *extprocv3.HeaderMutation
+	// The immediate response rejecting the attempt is neither from the backend nor in its schema.
+	if !c.rejected && !c.servedFromCache {
+		code, _ := strconv.Atoi(c.responseHeaders[":status"])
+		if isBackendFailure(code) {
+			c.observeBackend(true)
+		}
+		if code == http.StatusTooManyRequests {
+			c.config.admission.Throttled(c.backendName)
+		}
+		headerMutation, err = c.translator.ResponseHeaders(c.responseHeaders)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transform response headers: %w", err)
+		}
+	}
+	if _, ok := c.responseHeaders[internalapi.FallbackRetryHeaderKey]; ok {
+		// The retries are exhausted, so don't leak the internal header to the client.
+		if headerMutation == nil {
+			headerMutation = &extprocv3.HeaderMutation{}
+		}
+		headerMutation.RemoveHeaders = append(headerMutation.RemoveHeaders, internalapi.FallbackRetryHeaderKey)
+	}
+	if _, ok := c.responseHeaders[internalapi.HedgeAttemptHeaderKey]; ok {
+		if headerMutation == nil {
+			headerMutation = &extprocv3.HeaderMutation{}
+		}
+		headerMutation.RemoveHeaders = append(headerMutation.RemoveHeaders, internalapi.HedgeAttemptHeaderKey)
 	}
 	var mode *extprocv3http.ProcessingMode
 	if c.stream && c.responseHeaders[":status"] == "200" {
@@ -321,11 +652,160 @@ func (c *chatCompletionProcessorUpstreamFilter) ProcessResponseHeaders(ctx conte
 	}, ModeOverride: mode}, nil
 }
 
+// ClassifyResponseHeaders implements [upstreamResponseClassifier.ClassifyResponseHeaders].
+//
+// The body of the error response is buffered so that it can be classified before the headers reach the router.
+func (c *chatCompletionProcessorUpstreamFilter) ClassifyResponseHeaders(ctx context.Context, headers *extprocv3.HttpHeaders) (*extprocv3.ProcessingResponse, error) {
+	c.responseHeaders = headersToMap(headers.Headers)
+	resp := &extprocv3.HeadersResponse{}
+	ret := &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseHeaders{ResponseHeaders: resp}}
+	code, _ := strconv.Atoi(c.responseHeaders[":status"])
+	switch {
+	case isGoodStatusCode(code):
+		c.recordAttempt(ctx, "", false)
+		c.observeCircuitBreaker("")
+	case headers.EndOfStream:
+		// There's no body, so classify the response only with the status code.
+		if c.classifyError(ctx, code, nil) {
+			resp.Response = &extprocv3.CommonResponse{HeaderMutation: fallbackRetryHeaderMutation()}
+		}
+	default:
+		ret.ModeOverride = &extprocv3http.ProcessingMode{ResponseBodyMode: extprocv3http.ProcessingMode_BUFFERED}
+	}
+	if c.router != nil {
+		c.router.upstreamMu.Lock()
+		c.responded = true
+		c.router.upstreamMu.Unlock()
+		// Tag the response so that the router filter can tell which attempt is served.
+		if resp.Response == nil {
+			resp.Response = &extprocv3.CommonResponse{HeaderMutation: &extprocv3.HeaderMutation{}}
+		}
+		resp.Response.HeaderMutation.SetHeaders = append(resp.Response.HeaderMutation.SetHeaders, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: internalapi.HedgeAttemptHeaderKey, RawValue: []byte(strconv.Itoa(c.attempt))},
+		})
+	}
+	return ret, nil
+}
+
+// ClassifyResponseBody implements [upstreamResponseClassifier.ClassifyResponseBody].
+//
+// The error body is translated into the OpenAI format in the same way as the final response, but the translated body
+// is only used for the classification. The response itself is processed at the router filter if it is not retried.
+func (c *chatCompletionProcessorUpstreamFilter) ClassifyResponseBody(ctx context.Context, body *extprocv3.HttpBody) (*extprocv3.ProcessingResponse, error) {
+	code, _ := strconv.Atoi(c.responseHeaders[":status"])
+	raw := body.Body
+	if c.responseHeaders["content-encoding"] == "gzip" {
+		r, err := gzip.NewReader(bytes.NewReader(body.Body))
+		if err == nil {
+			raw, err = io.ReadAll(r)
+		}
+		if err != nil {
+			c.logger.Debug("failed to decode the gzip error response for the fallback", slog.String("error", err.Error()))
+			raw = nil
+		}
+	}
+	var openAIErr *openai.Error
+	if raw != nil {
+		_, bodyMutation, err := c.translator.ResponseError(c.responseHeaders, bytes.NewReader(raw))
+		if err != nil {
+			c.logger.Debug("failed to translate the error response for the fallback", slog.String("error", err.Error()))
+		} else if b := bodyMutation.GetBody(); b != nil {
+			raw = b
+		}
+		var e openai.Error
+		if json.Unmarshal(raw, &e) == nil {
+			openAIErr = &e
+		}
+	}
+	resp := &extprocv3.BodyResponse{}
+	if c.classifyError(ctx, code, openAIErr) {
+		resp.Response = &extprocv3.CommonResponse{HeaderMutation: fallbackRetryHeaderMutation()}
+	}
+	return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseBody{ResponseBody: resp}}, nil
+}
+
+// classifyError classifies the error response of the backend, and returns true if the request should be
+// retried on the other backends.
+func (c *chatCompletionProcessorUpstreamFilter) classifyError(ctx context.Context, code int, openAIErr *openai.Error) bool {
+	errorType := classifyBackendError(code, openAIErr)
+	retried := shouldFallback(c.backend.Fallback, errorType)
+	c.recordAttempt(ctx, errorType, retried)
+	c.observeCircuitBreaker(errorType)
+	if errorType == fallbackErrorTypeThrottled {
+		c.config.admission.Throttled(c.backendName)
+	}
+	if retried {
+		// The response of this attempt is not served, so the backend is done with it.
+		c.admissionTicket.Release(0)
+	}
+	if isBackendFailure(code) {
+		c.observeBackend(true)
+	}
+	return retried
+}
+
+// observeBackend reports the outcome of this attempt to the adaptive load balancing of the backend, if any.
+// This is called at most once per attempt: with the failure on the error responses, and with the latencies
+// at the end of the successful response.
+func (c *chatCompletionProcessorUpstreamFilter) observeBackend(failed bool) {
+	if c.observed || c.config == nil || c.config.loadBalancers == nil {
+		return
+	}
+	c.observed = true
+	o := adaptivelb.Observation{Failed: failed}
+	if !failed {
+		if c.stream {
+			o.TimeToFirstToken = time.Duration(c.metrics.GetTimeToFirstTokenMs() * float64(time.Millisecond))
+			o.InterTokenLatency = time.Duration(c.metrics.GetInterTokenLatencyMs() * float64(time.Millisecond))
+		}
+		if o.TimeToFirstToken == 0 {
+			// The time to first token of the non-streaming response is the time to the whole response.
+			o.TimeToFirstToken = time.Since(c.attemptStart)
+		}
+	}
+	c.config.loadBalancers.Observe(c.backendName, o)
+}
+
+// observeCircuitBreaker reports the outcome of this attempt to the circuit breaker of the backend, if any. errorType is
+// empty on the successful response. This is called at most once per attempt.
+func (c *chatCompletionProcessorUpstreamFilter) observeCircuitBreaker(errorType string) {
+	cb := c.circuitBreaker
+	if cb == nil {
+		return
+	}
+	c.circuitBreaker = nil
+	if errorType == "" {
+		cb.Success()
+	} else {
+		cb.Failure(circuitBreakerErrorClass(errorType))
+	}
+}
+
+// recordAttempt records the outcome of this attempt on the backend.
+func (c *chatCompletionProcessorUpstreamFilter) recordAttempt(ctx context.Context, errorType string, retried bool) {
+	if c.backendAttemptMetrics == nil {
+		return
+	}
+	c.backendAttemptMetrics.RecordAttempt(ctx, c.backend, c.requestHeaders[c.config.modelNameHeaderKey], errorType, retried, c.requestHeaders)
+}
+
 // ProcessResponseBody implements [Processor.ProcessResponseBody].
 func (c *chatCompletionProcessorUpstreamFilter) ProcessResponseBody(ctx context.Context, body *extprocv3.HttpBody) (res *extprocv3.ProcessingResponse, err error) {
+	if c.servedFromCache {
+		// The cached response is already in the OpenAI format, and this attempt is not counted as a request.
+		return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseBody{ResponseBody: &extprocv3.BodyResponse{}}}, nil
+	}
 	defer func() {
 		c.metrics.RecordRequestCompletion(ctx, err == nil, c.requestHeaders)
+		if body.EndOfStream {
+			c.admissionTicket.Release(int64(hedging.EstimateWithCancelledAttempts(c.costs, c.cancelledAttempts).TotalTokens))
+		}
 	}()
+	if c.rejected {
+		// The response is the immediate response of this filter, which is already in the OpenAI format.
+		return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseBody{ResponseBody: &extprocv3.BodyResponse{}}}, nil
+	}
+
 	var br io.Reader
 	var isGzip bool
 	switch c.responseEncoding {
@@ -359,10 +839,48 @@ func (c *chatCompletionProcessorUpstreamFilter) ProcessResponseBody(ctx context.
 		}, nil
 	}
 
+	var decoded []byte
+	checksResponse := c.responseGuardrail != nil
+	if c.piiRestorer != nil || checksResponse || c.toolPolicy != nil {
+		// Keep the decoded body to restore the masked values, to check it with the guardrail, or to validate the tool
+		// calls, when the translator doesn't mutate it.
+		if decoded, err = io.ReadAll(br); err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		br = bytes.NewReader(decoded)
+	}
 	headerMutation, bodyMutation, tokenUsage, err := c.translator.ResponseBody(c.responseHeaders, br, body.EndOfStream)
 	if err != nil {
 		return nil, fmt.Errorf("failed to transform response: %w", err)
 	}
+	var blocked *extprocv3.ImmediateResponse
+	if c.toolPolicy != nil {
+		// The tool calls are validated in the OpenAI format regardless of the translator of the backend.
+		part := decoded
+		if bodyMutation != nil {
+			part = bodyMutation.GetBody()
+		}
+		bodyMutation, blocked = c.toolPolicy.check(part, body.EndOfStream)
+		if c.toolPolicy.violated {
+			// The response violating the policy must not be served to the other clients.
+			c.responseCacheKey = ""
+		}
+	}
+	if checksResponse && blocked == nil {
+		// The guardrail sees the response before the masked values are restored in the same way as the request.
+		part := decoded
+		if bodyMutation != nil {
+			part = bodyMutation.GetBody()
+		}
+		bodyMutation, blocked = c.responseGuardrail.check(ctx, part, body.EndOfStream)
+		if c.responseGuardrail.decision != nil && c.responseGuardrail.decision.Action == guardrail.ActionBlock {
+			// The blocked response must not be served to the other clients.
+			c.responseCacheKey = ""
+		}
+	}
+	if c.piiRestorer != nil && blocked == nil {
+		bodyMutation = restorePII(c.piiRestorer, c.stream, decoded, bodyMutation, body.EndOfStream)
+	}
 	if bodyMutation != nil && isGzip {
 		if headerMutation == nil {
 			headerMutation = &extprocv3.HeaderMutation{}
@@ -387,13 +905,29 @@ func (c *chatCompletionProcessorUpstreamFilter) ProcessResponseBody(ctx context.
 		},
 	}
 
+	if blocked != nil {
+		// The blocked response is replaced while the usage of the backend is still accounted below.
+		resp.Response = &extprocv3.ProcessingResponse_ImmediateResponse{ImmediateResponse: blocked}
+	}
+
+	if c.responseCacheKey != "" {
+		c.storeResponseInCache(ctx, body, bodyMutation, isGzip)
+	}
+
 	// TODO: we need to investigate if we need to accumulate the token usage for streaming responses.
 	c.costs.InputTokens += tokenUsage.InputTokens
 	c.costs.OutputTokens += tokenUsage.OutputTokens
 	c.costs.TotalTokens += tokenUsage.TotalTokens
+	c.costs.CachedInputTokens += tokenUsage.CachedInputTokens
+	if c.mirror != nil {
+		c.mirrorResponse(body, bodyMutation, isGzip)
+	}
 
 	// Update metrics with token usage.
 	c.metrics.RecordTokenUsage(ctx, tokenUsage.InputTokens, tokenUsage.OutputTokens, tokenUsage.TotalTokens, c.requestHeaders)
+	if price := c.config.backendPrice(c.backendName); price != nil {
+		c.metrics.RecordCost(ctx, llmcostcel.USDCost(price, tokenUsage.InputTokens, tokenUsage.CachedInputTokens, tokenUsage.OutputTokens), c.requestHeaders)
+	}
 	if c.stream {
 		// Token latency is only recorded for streaming responses, otherwise it doesn't make sense since
 		// these metrics are defined as a difference between the two output events.
@@ -404,8 +938,13 @@ func (c *chatCompletionProcessorUpstreamFilter) ProcessResponseBody(ctx context.
 		// chunk by chunk, we only want to drop a specific line before the last chunk.
 	}
 
-	if body.EndOfStream && len(c.config.requestCosts) > 0 {
-		metadata, err := buildDynamicMetadata(c.config, &c.costs, c.requestHeaders, c.modelNameOverride, c.backendName)
+	if body.EndOfStream {
+		c.observeBackend(false)
+	}
+
+	if body.EndOfStream && c.config.recordsCosts() {
+		costs := hedging.EstimateWithCancelledAttempts(c.costs, c.cancelledAttempts)
+		metadata, err := buildDynamicMetadata(c.config, &costs, c.requestHeaders, c.modelNameOverride, c.backendName)
 		if err != nil {
 			return nil, fmt.Errorf("failed to build dynamic metadata: %w", err)
 		}
@@ -413,6 +952,13 @@ func (c *chatCompletionProcessorUpstreamFilter) ProcessResponseBody(ctx context.
 			// Adding token latency information to metadata.
 			c.mergeWithTokenLatencyMetadata(metadata)
 		}
+		if metadata != nil && c.cancelledAttempts > 0 {
+			// The costs include the estimated usage of the cancelled attempts, which is labeled so that it can be told
+			// apart from the usage reported by the backend.
+			fields := metadata.Fields[c.config.metadataNamespace].GetStructValue().Fields
+			fields[hedging.CancelledAttemptsMetadataKey] = structpb.NewNumberValue(float64(c.cancelledAttempts))
+			fields[hedging.EstimatedInputTokensMetadataKey] = structpb.NewNumberValue(float64(costs.InputTokens - c.costs.InputTokens))
+		}
 		resp.DynamicMetadata = metadata
 	}
 
@@ -429,10 +975,19 @@ func (c *chatCompletionProcessorUpstreamFilter) SetBackend(ctx context.Context,
 	if !ok {
 		panic("BUG: expected routeProcessor to be of type *chatCompletionProcessorRouterFilter")
 	}
+	if rp.hedged {
+		// The hedged attempts are set concurrently with each other and with the response of the earlier attempts.
+		rp.upstreamMu.Lock()
+		defer rp.upstreamMu.Unlock()
+		c.router = rp
+		rp.hedgedAttempts = append(rp.hedgedAttempts, c)
+	}
 	rp.upstreamFilterCount++
+	c.attempt = rp.upstreamFilterCount
 	c.metrics.SetBackend(b)
 	c.modelNameOverride = b.ModelNameOverride
 	c.backendName = b.Name
+	c.backend = b
 	if err = c.selectTranslator(b.Schema); err != nil {
 		return fmt.Errorf("failed to select translator: %w", err)
 	}
@@ -446,11 +1001,260 @@ func (c *chatCompletionProcessorUpstreamFilter) SetBackend(ctx context.Context,
 			c.logger.Debug("selected backend", slog.String("picked_endpoint", pickedEndpoint), slog.String("backendName", b.Name), slog.String("modelNameOverride", c.modelNameOverride))
 		}
 	}
-	rp.upstreamFilter = c
+	if prev, ok := rp.upstreamFilter.(*chatCompletionProcessorUpstreamFilter); ok {
+		for _, k := range prev.setHeaderKeys {
+			if _, inOriginal := rp.requestHeaders[k]; !inOriginal && !strings.HasPrefix(k, ":") {
+				c.staleHeaderKeys = append(c.staleHeaderKeys, k)
+			}
+		}
+	}
+	if !rp.upstreamSelected {
+		rp.upstreamFilter = c
+	}
 	c.forcedStreamOptionIncludeUsage = rp.forcedStreamOptionIncludeUsage
+	c.responseCached = rp.responseCached
+	c.piiRestorer = rp.piiRestorer
+	c.guardrail = rp.guardrail
+	c.guardrailMutated = rp.guardrailMutated
+	opts := c.originalRequestBody.StreamOptions
+	includeUsage := opts != nil && opts.IncludeUsage
+	c.responseGuardrail = newResponseGuardrail(c.guardrail, chatCompletionFormat{includeUsage: includeUsage},
+		c.logger, c.requestHeaders[c.config.modelNameHeaderKey], c.originalRequestBodyRaw, c.stream)
+	c.toolPolicy = newResponseToolPolicy(rp.toolValidator, chatCompletionFormat{includeUsage: includeUsage}, c.logger, c.stream, includeUsage)
+	c.requestPolicyMutated = rp.requestPolicyMutated
+	if m := c.config.mirrors.Find(b.Name); m != nil && m.Sample() {
+		c.mirror = m
+	}
+	c.admit(ctx, rp)
 	return
 }
 
+// admit decides whether this attempt is admitted on the backend by the admission control. The admission is tracked
+// by the router filter so that it is released even if this attempt is left behind, and the shed attempt is rejected
+// by ProcessRequestHeaders.
+func (c *chatCompletionProcessorUpstreamFilter) admit(ctx context.Context, rp *chatCompletionProcessorRouterFilter) {
+	c.admissionClass = rp.admissionClass
+	ticket, retryAfter, admitted := c.config.admission.Admit(c.backendName, c.admissionClass)
+	if ticket == nil && admitted {
+		// The backend has no limits.
+		return
+	}
+	if !admitted {
+		c.shedRetryAfter = retryAfter
+	} else {
+		c.admissionTicket = ticket
+		if !rp.hedged {
+			// Otherwise, the lock is already held by SetBackend.
+			rp.upstreamMu.Lock()
+			defer rp.upstreamMu.Unlock()
+		}
+		rp.admissionTickets = append(rp.admissionTickets, ticket)
+	}
+	if c.admissionMetrics != nil {
+		c.admissionMetrics.RecordAdmission(ctx, c.backendName, c.admissionClass.Name, admitted, c.requestHeaders)
+	}
+}
+
+// mirrorResponse buffers the successful response body in the OpenAI format, and replays the original request to
+// the shadow backend of the mirror at the end of the stream along with the buffered response as the primary one.
+//
+// The replay happens in the background, so this doesn't affect the response served to the client.
+func (c *chatCompletionProcessorUpstreamFilter) mirrorResponse(body *extprocv3.HttpBody, bodyMutation *extprocv3.BodyMutation, isGzip bool) {
+	switch {
+	case bodyMutation.GetBody() != nil:
+		c.mirrorBuf = append(c.mirrorBuf, bodyMutation.GetBody()...)
+	case isGzip:
+		// The body is passed through in the compressed form, so give up mirroring this request.
+		c.mirror, c.mirrorBuf = nil, nil
+		return
+	default:
+		c.mirrorBuf = append(c.mirrorBuf, body.Body...)
+	}
+	if !body.EndOfStream {
+		return
+	}
+
+	respBody, err := mirror.ResponseBody(c.mirrorBuf, c.stream)
+	c.mirrorBuf = nil
+	if err != nil {
+		c.logger.Warn("failed to assemble the streaming response for the mirror", slog.String("error", err.Error()))
+		return
+	}
+	status, _ := strconv.Atoi(c.responseHeaders[":status"])
+	c.mirror.Replay(c.originalRequestBodyRaw, c.originalRequestBody, mirror.Response{
+		Backend:    c.backendName,
+		StatusCode: status,
+		Body:       respBody,
+		LatencyMs:  float64(time.Since(c.attemptStart).Microseconds()) / 1000,
+		Usage:      mirror.UsageFromLLMTokenUsage(c.costs),
+	})
+}
+
+// lookupResponseCache looks up the response cache for the request on the backend of this attempt, and returns the
+// immediate response with the cached response on a hit. On a miss, this returns nil and the key is remembered to store
+// the response.
+//
+// The key includes the backend since the backends of the same model, e.g. the weighted ones or the fallbacks with
+// the model name overrides, don't necessarily return the same response.
+//
+// The errors of the cache are not propagated to the client since the request can still be served by the backend.
+func (c *chatCompletionProcessorUpstreamFilter) lookupResponseCache(ctx context.Context) *extprocv3.ProcessingResponse {
+	model := c.requestHeaders[c.config.modelNameHeaderKey]
+	key, err := responsecache.Key(c.backendName, model, c.originalRequestBody)
+	if err != nil {
+		c.logger.Warn("failed to build the response cache key", slog.String("error", err.Error()))
+		return nil
+	}
+	cached, hit, err := c.config.responseCache.Get(ctx, key)
+	if err != nil {
+		c.logger.Warn("failed to get the cached response", slog.String("error", err.Error()))
+	}
+	c.responseCacheMetrics.RecordLookup(ctx, hit, model, c.requestHeaders)
+	if hit {
+		if resp := c.responseCacheHitResponse(cached, responseCacheTypeExact, 1); resp != nil {
+			return resp
+		}
+	}
+	c.responseCacheKey = key
+	if c.config.semanticCache != nil {
+		return c.lookupSemanticCache(ctx, model)
+	}
+	return nil
+}
+
+// lookupSemanticCache looks up the cached response of the most similar request when the exact match is not found.
+// On a miss, the embedding of the request is remembered to associate it with the response stored in the cache.
+func (c *chatCompletionProcessorUpstreamFilter) lookupSemanticCache(ctx context.Context, model string) *extprocv3.ProcessingResponse {
+	semantic := c.config.semanticCache
+	q, err := semantic.Query(ctx, c.backendName, model, c.originalRequestBody)
+	if err != nil {
+		c.logger.Warn("failed to build the semantic response cache query", slog.String("error", err.Error()))
+		return nil
+	} else if q == nil {
+		return nil
+	}
+	c.semanticQuery = q
+
+	key, similarity, hit, err := semantic.Lookup(ctx, q)
+	if err != nil {
+		c.logger.Warn("failed to look up the semantic response cache", slog.String("error", err.Error()))
+	}
+	var cached []byte
+	if hit {
+		cached, hit, err = c.config.responseCache.Get(ctx, key)
+		if err != nil {
+			c.logger.Warn("failed to get the cached response", slog.String("error", err.Error()))
+		} else if !hit {
+			// The cached response has been evicted or expired, so the embedding is no longer useful.
+			if err = semantic.Forget(ctx, q, key); err != nil {
+				c.logger.Warn("failed to forget the semantic response cache entry", slog.String("error", err.Error()))
+			}
+		}
+	}
+	c.responseCacheMetrics.RecordSemanticLookup(ctx, hit, model, c.requestHeaders)
+	if !hit {
+		return nil
+	}
+	return c.responseCacheHitResponse(cached, responseCacheTypeSemantic, similarity)
+}
+
+// responseCacheHitResponse returns the immediate response serving the cached response in the way the client requested.
+// The cache type and the similarity to the cached request are recorded in the dynamic metadata for the attribution.
+//
+// This returns nil if the cached response cannot be re-emitted, in which case the request is served by the backend.
+func (c *chatCompletionProcessorUpstreamFilter) responseCacheHitResponse(cached []byte, cacheType string, similarity float32) *extprocv3.ProcessingResponse {
+	body := c.originalRequestBody
+	contentType := "application/json"
+	if body.Stream {
+		var err error
+		// The usage forced by the router filter is not what the client asked for.
+		includeUsage := body.StreamOptions != nil && body.StreamOptions.IncludeUsage && !c.forcedStreamOptionIncludeUsage
+		cached, err = responsecache.ChatCompletionStreamBody(cached, includeUsage)
+		if err != nil {
+			c.logger.Warn("failed to re-emit the cached response as a stream", slog.String("error", err.Error()))
+			return nil
+		}
+		contentType = "text/event-stream"
+	}
+	c.servedFromCache = true
+	c.admissionTicket.Release(0)
+	headers := []*corev3.HeaderValueOption{
+		{Header: &corev3.HeaderValue{Key: "content-type", RawValue: []byte(contentType)}},
+		{Header: &corev3.HeaderValue{Key: responseCacheHeader, RawValue: []byte("hit")}},
+	}
+	if c.router != nil {
+		c.router.upstreamMu.Lock()
+		c.responded = true
+		c.router.upstreamMu.Unlock()
+		// Tag the response so that the router filter can tell which attempt is served.
+		headers = append(headers, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: internalapi.HedgeAttemptHeaderKey, RawValue: []byte(strconv.Itoa(c.attempt))},
+		})
+	}
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status:  &typev3.HttpStatus{Code: typev3.StatusCode_OK},
+				Headers: &extprocv3.HeaderMutation{SetHeaders: headers},
+				Body:    cached,
+			},
+		},
+		DynamicMetadata: &structpb.Struct{
+			Fields: map[string]*structpb.Value{
+				c.config.metadataNamespace: structpb.NewStructValue(&structpb.Struct{
+					Fields: map[string]*structpb.Value{
+						"response_cache":            structpb.NewStringValue(cacheType),
+						"response_cache_similarity": structpb.NewNumberValue(float64(similarity)),
+					},
+				}),
+			},
+		},
+	}
+}
+
+// storeResponseInCache buffers the successful response body in the OpenAI format, and stores it in the response cache
+// at the end of the stream. The streaming response is assembled into the non-streaming one before being stored.
+//
+// Failing to store the response doesn't fail the request, since the response has already been served by the backend.
+func (c *chatCompletionProcessorUpstreamFilter) storeResponseInCache(ctx context.Context, body *extprocv3.HttpBody, bodyMutation *extprocv3.BodyMutation, isGzip bool) {
+	switch {
+	case bodyMutation.GetBody() != nil:
+		c.responseCacheBuf = append(c.responseCacheBuf, bodyMutation.GetBody()...)
+	case isGzip:
+		// The body is passed through in the compressed form, so give up caching this response.
+		c.responseCacheKey, c.responseCacheBuf = "", nil
+		return
+	default:
+		c.responseCacheBuf = append(c.responseCacheBuf, body.Body...)
+	}
+	if !body.EndOfStream {
+		return
+	}
+
+	value := c.responseCacheBuf
+	c.responseCacheBuf = nil
+	if c.stream {
+		var err error
+		value, err = responsecache.AssembleChatCompletionStream(value)
+		if err != nil {
+			c.logger.Warn("failed to assemble the streaming response for the response cache", slog.String("error", err.Error()))
+			return
+		}
+	}
+	if limit := c.config.responseCacheConfig.MaxEntryBytes; limit > 0 && len(value) > limit {
+		return
+	}
+	if err := c.config.responseCache.Set(ctx, c.responseCacheKey, value); err != nil {
+		c.logger.Warn("failed to store the response in the response cache", slog.String("error", err.Error()))
+		return
+	}
+	if c.semanticQuery != nil && c.config.semanticCache != nil {
+		if err := c.config.semanticCache.Add(ctx, c.semanticQuery, c.responseCacheKey); err != nil {
+			c.logger.Warn("failed to store the embedding in the semantic response cache", slog.String("error", err.Error()))
+		}
+	}
+}
+
 func (c *chatCompletionProcessorUpstreamFilter) mergeWithTokenLatencyMetadata(metadata *structpb.Struct) {
 	timeToFirstTokenMs := c.metrics.GetTimeToFirstTokenMs()
 	interTokenLatencyMs := c.metrics.GetInterTokenLatencyMs()
@@ -511,6 +1315,12 @@ func buildDynamicMetadata(config *processorConfig, costs *translator.LLMTokenUsa
 			cost = costs.OutputTokens
 		case filterapi.LLMRequestCostTypeTotalToken:
 			cost = costs.TotalTokens
+		case filterapi.LLMRequestCostTypeImage:
+			cost = costs.ImageCount
+		case filterapi.LLMRequestCostTypeAudioSecond:
+			cost = costs.AudioSeconds
+		case filterapi.LLMRequestCostTypeCharacter:
+			cost = costs.Characters
 		case filterapi.LLMRequestCostTypeCEL:
 			costU64, err := llmcostcel.EvaluateProgram(
 				rc.celProg,
@@ -519,6 +1329,10 @@ func buildDynamicMetadata(config *processorConfig, costs *translator.LLMTokenUsa
 				costs.InputTokens,
 				costs.OutputTokens,
 				costs.TotalTokens,
+				costs.AudioSeconds,
+				costs.Characters,
+				costs.CachedInputTokens,
+				config.backendPrice(backendName),
 			)
 			if err != nil {
 				return nil, fmt.Errorf("failed to evaluate CEL expression: %w", err)
@@ -530,6 +1344,12 @@ func buildDynamicMetadata(config *processorConfig, costs *translator.LLMTokenUsa
 		metadata[rc.MetadataKey] = &structpb.Value{Kind: &structpb.Value_NumberValue{NumberValue: float64(cost)}}
 	}
 
+	if price := config.backendPrice(backendName); price != nil {
+		metadata["cost_usd"] = &structpb.Value{Kind: &structpb.Value_NumberValue{
+			NumberValue: llmcostcel.USDCost(price, costs.InputTokens, costs.CachedInputTokens, costs.OutputTokens),
+		}}
+	}
+
 	if modelNameOverride != "" {
 		metadata["model_name_override"] = &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: modelNameOverride}}
 	}
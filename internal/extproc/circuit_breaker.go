@@ -0,0 +1,59 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// circuitBreakerErrorClass returns the class of the given error type of the failed attempt counted by the circuit
+// breakers, or an empty string if the error type is not counted by any circuit breaker.
+func circuitBreakerErrorClass(errorType string) filterapi.CircuitBreakerErrorClass {
+	switch errorType {
+	case fallbackErrorTypeThrottled:
+		return filterapi.CircuitBreakerErrorClassThrottled
+	case fallbackErrorTypeServerError:
+		return filterapi.CircuitBreakerErrorClassServerError
+	default:
+		return ""
+	}
+}
+
+// circuitOpenResponse returns the immediate response of the upstream filter rejecting the attempt on the backend whose
+// circuit breaker is open. Envoy handles it in the same way as the response of the backend, so the attempt is retried
+// on the other backends via the internalapi.FallbackRetryHeaderKey header when retry is true.
+func circuitOpenResponse(backend string, retry bool) *extprocv3.ProcessingResponse {
+	body, _ := json.Marshal(openai.Error{
+		Type: "error",
+		Error: openai.ErrorType{
+			Type:    fallbackErrorTypeCircuitOpen,
+			Message: fmt.Sprintf("the circuit breaker of the backend %s is open", backend),
+		},
+	})
+	headers := []*corev3.HeaderValueOption{
+		{Header: &corev3.HeaderValue{Key: "content-type", RawValue: []byte("application/json")}},
+	}
+	if retry {
+		headers = append(headers, fallbackRetryHeaderMutation().SetHeaders...)
+	}
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status:  &typev3.HttpStatus{Code: typev3.StatusCode_ServiceUnavailable},
+				Headers: &extprocv3.HeaderMutation{SetHeaders: headers},
+				Body:    body,
+			},
+		},
+	}
+}
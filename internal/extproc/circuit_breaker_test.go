@@ -0,0 +1,38 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+func Test_circuitBreakerErrorClass(t *testing.T) {
+	require.Equal(t, filterapi.CircuitBreakerErrorClassThrottled, circuitBreakerErrorClass(fallbackErrorTypeThrottled))
+	require.Equal(t, filterapi.CircuitBreakerErrorClassServerError, circuitBreakerErrorClass(fallbackErrorTypeServerError))
+	require.Empty(t, circuitBreakerErrorClass(fallbackErrorTypeContextLengthExceeded))
+	require.Empty(t, circuitBreakerErrorClass(fallbackErrorTypeOther))
+}
+
+func Test_circuitOpenResponse(t *testing.T) {
+	resp := circuitOpenResponse("vertex", false).GetImmediateResponse()
+	require.Equal(t, 503, int(resp.Status.Code))
+	require.Len(t, resp.Headers.SetHeaders, 1)
+	var body openai.Error
+	require.NoError(t, json.Unmarshal(resp.Body, &body))
+	require.Equal(t, fallbackErrorTypeCircuitOpen, body.Error.Type)
+	require.Equal(t, "the circuit breaker of the backend vertex is open", body.Error.Message)
+
+	resp = circuitOpenResponse("vertex", true).GetImmediateResponse()
+	require.Len(t, resp.Headers.SetHeaders, 2)
+	require.Equal(t, internalapi.FallbackRetryHeaderKey, resp.Headers.SetHeaders[1].Header.Key)
+}
@@ -0,0 +1,441 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/tidwall/sjson"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/backendauth"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+	"github.com/envoyproxy/ai-gateway/internal/pii"
+	"github.com/envoyproxy/ai-gateway/internal/quota"
+	tracing "github.com/envoyproxy/ai-gateway/internal/tracing/api"
+)
+
+// CompletionsProcessorFactory returns a factory method to instantiate the legacy completions processor.
+//
+// The metrics are expected to be created by [metrics.NewTextCompletion] since the legacy completions have
+// the same token semantics as the chat completions including the streaming. The [metrics.PIIMetrics] is only recorded
+// for the routes with the PII redaction.
+func CompletionsProcessorFactory(ccm metrics.ChatCompletionMetrics, pm metrics.PIIMetrics) ProcessorFactory {
+	return func(config *processorConfig, requestHeaders map[string]string, logger *slog.Logger, _ tracing.Tracing, isUpstreamFilter bool) (Processor, error) {
+		logger = logger.With("processor", "completions", "isUpstreamFilter", fmt.Sprintf("%v", isUpstreamFilter))
+		if !isUpstreamFilter {
+			return &completionsProcessorRouterFilter{
+				config:         config,
+				requestHeaders: requestHeaders,
+				logger:         logger,
+				piiMetrics:     pm,
+			}, nil
+		}
+		return &completionsProcessorUpstreamFilter{
+			config:         config,
+			requestHeaders: requestHeaders,
+			logger:         logger,
+			metrics:        ccm,
+		}, nil
+	}
+}
+
+// completionsProcessorRouterFilter implements [Processor] for the `/v1/completions` endpoint.
+//
+// This is primarily used to select the route for the request based on the model name.
+type completionsProcessorRouterFilter struct {
+	passThroughProcessor
+	quotaTracker
+	// upstreamFilter is the upstream filter that is used to process the request at the upstream filter.
+	// This will be updated when the request is retried.
+	//
+	// On the response handling path, we don't need to do any operation until successful, so we use the implementation
+	// of the upstream filter to handle the response at the router filter.
+	upstreamFilter Processor
+	logger         *slog.Logger
+	config         *processorConfig
+	requestHeaders map[string]string
+	// originalRequestBody is the original request body that is passed to the upstream filter.
+	// This is used to perform the transformation of the request body on the original input
+	// when the request is retried.
+	originalRequestBody    *openai.CompletionRequest
+	originalRequestBodyRaw []byte
+	// forcedStreamOptionIncludeUsage is set to true if the original request is a streaming request and has the
+	// stream_options.include_usage=false. In that case, we force the option to be true to ensure that the token usage is calculated correctly.
+	forcedStreamOptionIncludeUsage bool
+	// upstreamFilterCount is the number of upstream filters that have been processed.
+	// This is used to determine if the request is a retry request.
+	upstreamFilterCount int
+	// piiRestorer restores the values masked by the PII redaction in the response, and nil when nothing is masked.
+	piiRestorer *pii.Restorer
+	piiMetrics  metrics.PIIMetrics
+}
+
+// ProcessResponseHeaders implements [Processor.ProcessResponseHeaders].
+func (c *completionsProcessorRouterFilter) ProcessResponseHeaders(ctx context.Context, headerMap *corev3.HeaderMap) (*extprocv3.ProcessingResponse, error) {
+	// If the request failed to route and/or immediate response was returned before the upstream filter was set,
+	// c.upstreamFilter can be nil.
+	if c.upstreamFilter != nil { // See the comment on the "upstreamFilter" field.
+		return c.upstreamFilter.ProcessResponseHeaders(ctx, headerMap)
+	}
+	return c.passThroughProcessor.ProcessResponseHeaders(ctx, headerMap)
+}
+
+// ProcessResponseBody implements [Processor.ProcessResponseBody].
+func (c *completionsProcessorRouterFilter) ProcessResponseBody(ctx context.Context, body *extprocv3.HttpBody) (resp *extprocv3.ProcessingResponse, err error) {
+	// If the request failed to route and/or immediate response was returned before the upstream filter was set,
+	// c.upstreamFilter can be nil.
+	if c.upstreamFilter != nil { // See the comment on the "upstreamFilter" field.
+		resp, err = c.upstreamFilter.ProcessResponseBody(ctx, body)
+	} else {
+		resp, err = c.passThroughProcessor.ProcessResponseBody(ctx, body)
+	}
+	c.reconcileAtEnd(ctx, body)
+	return
+}
+
+// ProcessRequestBody implements [Processor.ProcessRequestBody].
+func (c *completionsProcessorRouterFilter) ProcessRequestBody(ctx context.Context, rawBody *extprocv3.HttpBody) (*extprocv3.ProcessingResponse, error) {
+	model, body, err := parseOpenAICompletionBody(rawBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request body: %w", err)
+	}
+	model = c.config.resolveModelAlias(model)
+	keyHeaders, keyRemovedHeaders, resp := c.config.checkVirtualKey(model, c.requestHeaders)
+	if resp != nil {
+		return resp, nil
+	}
+	if resp = c.config.rejectUnsupportedRequestPolicy(openAIErrorFormat{}, model, c.requestHeaders); resp != nil {
+		return resp, nil
+	}
+	// The PII is redacted before anything else sees the body, e.g. the quota estimation.
+	c.piiRestorer, resp, err = c.config.redactPII(ctx, c.piiMetrics, openAIErrorFormat{}, model, c.requestHeaders, rawBody,
+		func(r *pii.Redactor, raw []byte) ([]byte, pii.Result, error) { return r.RedactCompletion(raw, body) })
+	if err != nil || resp != nil {
+		return resp, err
+	}
+	estimate := quota.EstimateCompletion(body)
+	resp = c.reserveQuota(ctx, c.config, c.logger, &c.upstreamFilter, openAIErrorFormat{}, model, c.requestHeaders, estimate)
+	if resp != nil {
+		return resp, nil
+	}
+	if body.Stream && (body.StreamOptions == nil || !body.StreamOptions.IncludeUsage) &&
+		(c.config.recordsCosts() || c.quotaReservation != nil) {
+		// If the request is a streaming request and cost metrics or quotas are configured, we need to include usage
+		// in the response to avoid the bypassing of the token usage calculation.
+		body.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+		// Rewrite the original bytes to include the stream_options.include_usage=true so that forcing the request body
+		// mutation, which uses this raw body, will also result in the stream_options.include_usage=true.
+		rawBody.Body, err = sjson.SetBytesOptions(rawBody.Body, "stream_options.include_usage", true, translator.SJSONOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set stream_options: %w", err)
+		}
+		c.forcedStreamOptionIncludeUsage = true
+	}
+
+	c.requestHeaders[c.config.modelNameHeaderKey] = model
+
+	var additionalHeaders []*corev3.HeaderValueOption
+	additionalHeaders = append(additionalHeaders, &corev3.HeaderValueOption{
+		// Set the model name to the request header with the key `x-ai-eg-model`.
+		Header: &corev3.HeaderValue{Key: c.config.modelNameHeaderKey, RawValue: []byte(model)},
+	}, &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{Key: originalPathHeader, RawValue: []byte(c.requestHeaders[":path"])},
+	})
+	bodyHeaders, removedHeaders := c.config.projectBodyHeaders(rawBody.Body, c.requestHeaders, estimate)
+	additionalHeaders = append(additionalHeaders, bodyHeaders...)
+	additionalHeaders = append(additionalHeaders, keyHeaders...)
+	removedHeaders = append(removedHeaders, keyRemovedHeaders...)
+	c.originalRequestBody = body
+	c.originalRequestBodyRaw = rawBody.Body
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_RequestBody{
+			RequestBody: &extprocv3.BodyResponse{
+				Response: &extprocv3.CommonResponse{
+					HeaderMutation: &extprocv3.HeaderMutation{
+						SetHeaders:    additionalHeaders,
+						RemoveHeaders: removedHeaders,
+					},
+					ClearRouteCache: true,
+				},
+			},
+		},
+	}, nil
+}
+
+// completionsProcessorUpstreamFilter implements [Processor] for the `/v1/completions` endpoint at the upstream filter.
+//
+// This is created per retry and handles the translation as well as the authentication of the request.
+type completionsProcessorUpstreamFilter struct {
+	logger                 *slog.Logger
+	config                 *processorConfig
+	requestHeaders         map[string]string
+	responseHeaders        map[string]string
+	responseEncoding       string
+	modelNameOverride      string
+	backendName            string
+	handler                backendauth.Handler
+	originalRequestBodyRaw []byte
+	originalRequestBody    *openai.CompletionRequest
+	translator             translator.OpenAICompletionTranslator
+	// onRetry is true if this is a retry request at the upstream filter.
+	onRetry bool
+	// cost is the cost of the request that is accumulated during the processing of the response.
+	costs translator.LLMTokenUsage
+	// metrics tracking.
+	metrics metrics.ChatCompletionMetrics
+	// stream is set to true if the request is a streaming request.
+	stream bool
+	// See the comment on the `forcedStreamOptionIncludeUsage` field in the router filter.
+	forcedStreamOptionIncludeUsage bool
+	// See the comment on the `piiRestorer` field in the router filter.
+	piiRestorer *pii.Restorer
+}
+
+// selectTranslator selects the translator based on the output schema.
+func (c *completionsProcessorUpstreamFilter) selectTranslator(out filterapi.VersionedAPISchema) error {
+	switch out.Name {
+	case filterapi.APISchemaOpenAI:
+		c.translator = translator.NewCompletionOpenAIToOpenAITranslator(out.Version, c.modelNameOverride)
+	default:
+		return fmt.Errorf("unsupported API schema: backend=%s", out)
+	}
+	return nil
+}
+
+// ProcessRequestHeaders implements [Processor.ProcessRequestHeaders].
+//
+// At the upstream filter, we already have the original request body at request headers phase.
+// So, we simply do the translation and upstream auth at this stage, and send them back to Envoy
+// with the status CONTINUE_AND_REPLACE. This will allows Envoy to not send the request body again
+// to the extproc.
+func (c *completionsProcessorUpstreamFilter) ProcessRequestHeaders(ctx context.Context, _ *corev3.HeaderMap) (res *extprocv3.ProcessingResponse, err error) {
+	defer func() {
+		if err != nil {
+			c.metrics.RecordRequestCompletion(ctx, false, c.requestHeaders)
+		}
+	}()
+
+	// Start tracking metrics for this request.
+	c.metrics.StartRequest(c.requestHeaders)
+	c.metrics.SetModel(c.requestHeaders[c.config.modelNameHeaderKey])
+
+	// We force the body mutation in the following cases:
+	// * The request is a retry request because the body mutation might have happened the previous iteration.
+	// * The request is a streaming request, and the IncludeUsage option is forced to true by the router filter.
+	// * The PII is masked in the request body.
+	forceBodyMutation := c.onRetry || c.forcedStreamOptionIncludeUsage || c.piiRestorer != nil
+	headerMutation, bodyMutation, err := c.translator.RequestBody(c.originalRequestBodyRaw, c.originalRequestBody, forceBodyMutation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform request: %w", err)
+	}
+	if headerMutation == nil {
+		headerMutation = &extprocv3.HeaderMutation{}
+	} else {
+		for _, h := range headerMutation.SetHeaders {
+			c.requestHeaders[h.Header.Key] = string(h.Header.RawValue)
+		}
+	}
+	if h := c.handler; h != nil {
+		if err = h.Do(ctx, c.requestHeaders, headerMutation, bodyMutation); err != nil {
+			return nil, fmt.Errorf("failed to do auth request: %w", err)
+		}
+	}
+
+	var dm *structpb.Struct
+	if bm := bodyMutation.GetBody(); bm != nil {
+		dm = buildContentLengthDynamicMetadataOnRequest(c.config, len(bm))
+	}
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_RequestHeaders{
+			RequestHeaders: &extprocv3.HeadersResponse{
+				Response: &extprocv3.CommonResponse{
+					HeaderMutation: headerMutation, BodyMutation: bodyMutation,
+					Status: extprocv3.CommonResponse_CONTINUE_AND_REPLACE,
+				},
+			},
+		},
+		DynamicMetadata: dm,
+	}, nil
+}
+
+// ProcessRequestBody implements [Processor.ProcessRequestBody].
+func (c *completionsProcessorUpstreamFilter) ProcessRequestBody(context.Context, *extprocv3.HttpBody) (res *extprocv3.ProcessingResponse, err error) {
+	panic("BUG: ProcessRequestBody should not be called in the upstream filter")
+}
+
+// ProcessResponseHeaders implements [Processor.ProcessResponseHeaders].
+func (c *completionsProcessorUpstreamFilter) ProcessResponseHeaders(ctx context.Context, headers *corev3.HeaderMap) (res *extprocv3.ProcessingResponse, err error) {
+	defer func() {
+		if err != nil {
+			c.metrics.RecordRequestCompletion(ctx, false, c.requestHeaders)
+		}
+	}()
+
+	c.responseHeaders = headersToMap(headers)
+	if enc := c.responseHeaders["content-encoding"]; enc != "" {
+		c.responseEncoding = enc
+	}
+	headerMutation, err := c.translator.ResponseHeaders(c.responseHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform response headers: %w", err)
+	}
+	var mode *extprocv3http.ProcessingMode
+	if c.stream && c.responseHeaders[":status"] == "200" {
+		// We only stream the response if the status code is 200 and the response is a stream.
+		mode = &extprocv3http.ProcessingMode{ResponseBodyMode: extprocv3http.ProcessingMode_STREAMED}
+	}
+	return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseHeaders{
+		ResponseHeaders: &extprocv3.HeadersResponse{
+			Response: &extprocv3.CommonResponse{HeaderMutation: headerMutation},
+		},
+	}, ModeOverride: mode}, nil
+}
+
+// ProcessResponseBody implements [Processor.ProcessResponseBody].
+func (c *completionsProcessorUpstreamFilter) ProcessResponseBody(ctx context.Context, body *extprocv3.HttpBody) (res *extprocv3.ProcessingResponse, err error) {
+	defer func() {
+		c.metrics.RecordRequestCompletion(ctx, err == nil, c.requestHeaders)
+	}()
+	var br io.Reader
+	var isGzip bool
+	switch c.responseEncoding {
+	case "gzip":
+		br, err = gzip.NewReader(bytes.NewReader(body.Body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode gzip: %w", err)
+		}
+		isGzip = true
+	default:
+		br = bytes.NewReader(body.Body)
+	}
+
+	// Assume all responses have a valid status code header.
+	if code, _ := strconv.Atoi(c.responseHeaders[":status"]); !isGoodStatusCode(code) {
+		var headerMutation *extprocv3.HeaderMutation
+		var bodyMutation *extprocv3.BodyMutation
+		headerMutation, bodyMutation, err = c.translator.ResponseError(c.responseHeaders, br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transform response error: %w", err)
+		}
+		return &extprocv3.ProcessingResponse{
+			Response: &extprocv3.ProcessingResponse_ResponseBody{
+				ResponseBody: &extprocv3.BodyResponse{
+					Response: &extprocv3.CommonResponse{
+						HeaderMutation: headerMutation,
+						BodyMutation:   bodyMutation,
+					},
+				},
+			},
+		}, nil
+	}
+
+	var decoded []byte
+	if c.piiRestorer != nil {
+		// Keep the decoded body to restore the PII in it when the translator doesn't mutate it.
+		if decoded, err = io.ReadAll(br); err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		br = bytes.NewReader(decoded)
+	}
+	headerMutation, bodyMutation, tokenUsage, err := c.translator.ResponseBody(c.responseHeaders, br, body.EndOfStream)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform response: %w", err)
+	}
+	if c.piiRestorer != nil {
+		bodyMutation = restorePII(c.piiRestorer, c.stream, decoded, bodyMutation, body.EndOfStream)
+	}
+	if bodyMutation != nil && isGzip {
+		if headerMutation == nil {
+			headerMutation = &extprocv3.HeaderMutation{}
+		}
+		// If the response was gzipped, ensure we remove the content-encoding header since the mutated body is not compressed.
+		headerMutation.RemoveHeaders = append(headerMutation.RemoveHeaders, "content-encoding")
+	}
+
+	resp := &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ResponseBody{
+			ResponseBody: &extprocv3.BodyResponse{
+				Response: &extprocv3.CommonResponse{
+					HeaderMutation: headerMutation,
+					BodyMutation:   bodyMutation,
+				},
+			},
+		},
+	}
+
+	c.costs.InputTokens += tokenUsage.InputTokens
+	c.costs.OutputTokens += tokenUsage.OutputTokens
+	c.costs.TotalTokens += tokenUsage.TotalTokens
+
+	// Update metrics with token usage.
+	c.metrics.RecordTokenUsage(ctx, tokenUsage.InputTokens, tokenUsage.OutputTokens, tokenUsage.TotalTokens, c.requestHeaders)
+	if price := c.config.backendPrice(c.backendName); price != nil {
+		c.metrics.RecordCost(ctx, llmcostcel.USDCost(price, tokenUsage.InputTokens, tokenUsage.CachedInputTokens, tokenUsage.OutputTokens), c.requestHeaders)
+	}
+	if c.stream {
+		// Token latency is only recorded for streaming responses, otherwise it doesn't make sense since
+		// these metrics are defined as a difference between the two output events.
+		c.metrics.RecordTokenLatency(ctx, tokenUsage.OutputTokens, c.requestHeaders)
+	}
+
+	if body.EndOfStream && c.config.recordsCosts() {
+		resp.DynamicMetadata, err = buildDynamicMetadata(c.config, &c.costs, c.requestHeaders, c.modelNameOverride, c.backendName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build dynamic metadata: %w", err)
+		}
+	}
+	return resp, nil
+}
+
+// SetBackend implements [Processor.SetBackend].
+func (c *completionsProcessorUpstreamFilter) SetBackend(ctx context.Context, b *filterapi.Backend, backendHandler backendauth.Handler, routeProcessor Processor) (err error) {
+	defer func() {
+		c.metrics.RecordRequestCompletion(ctx, err == nil, c.requestHeaders)
+	}()
+	rp, ok := routeProcessor.(*completionsProcessorRouterFilter)
+	if !ok {
+		panic("BUG: expected routeProcessor to be of type *completionsProcessorRouterFilter")
+	}
+	rp.upstreamFilterCount++
+	c.metrics.SetBackend(b)
+	c.modelNameOverride = b.ModelNameOverride
+	c.backendName = b.Name
+	if err = c.selectTranslator(b.Schema); err != nil {
+		return fmt.Errorf("failed to select translator: %w", err)
+	}
+	c.handler = backendHandler
+	c.originalRequestBody = rp.originalRequestBody
+	c.originalRequestBodyRaw = rp.originalRequestBodyRaw
+	c.onRetry = rp.upstreamFilterCount > 1
+	c.stream = c.originalRequestBody.Stream
+	c.forcedStreamOptionIncludeUsage = rp.forcedStreamOptionIncludeUsage
+	c.piiRestorer = rp.piiRestorer
+	rp.upstreamFilter = c
+	return
+}
+
+func parseOpenAICompletionBody(body *extprocv3.HttpBody) (modelName string, rb *openai.CompletionRequest, err error) {
+	var openAIReq openai.CompletionRequest
+	if err := json.Unmarshal(body.Body, &openAIReq); err != nil {
+		return "", nil, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	return openAIReq.Model, &openAIReq, nil
+}
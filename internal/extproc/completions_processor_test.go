@@ -0,0 +1,349 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+	"github.com/envoyproxy/ai-gateway/internal/pii"
+	tracing "github.com/envoyproxy/ai-gateway/internal/tracing/api"
+)
+
+func TestCompletions_Schema(t *testing.T) {
+	t.Run("on route", func(t *testing.T) {
+		p, err := CompletionsProcessorFactory(nil, nil)(&processorConfig{}, nil, slog.Default(), tracing.NoopTracing{}, false)
+		require.NoError(t, err)
+		require.IsType(t, &completionsProcessorRouterFilter{}, p)
+	})
+	t.Run("on upstream", func(t *testing.T) {
+		p, err := CompletionsProcessorFactory(nil, nil)(&processorConfig{}, nil, slog.Default(), tracing.NoopTracing{}, true)
+		require.NoError(t, err)
+		require.IsType(t, &completionsProcessorUpstreamFilter{}, p)
+	})
+}
+
+func Test_completionsProcessorUpstreamFilter_SelectTranslator(t *testing.T) {
+	r := &completionsProcessorUpstreamFilter{}
+	t.Run("unsupported", func(t *testing.T) {
+		err := r.selectTranslator(filterapi.VersionedAPISchema{Name: "Bar", Version: "v123"})
+		require.ErrorContains(t, err, "unsupported API schema: backend={Bar v123}")
+	})
+	t.Run("OpenAI", func(t *testing.T) {
+		require.NoError(t, r.selectTranslator(filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}))
+		require.NotNil(t, r.translator)
+	})
+}
+
+func completionsBodyFromModel(_ *testing.T, model string, stream bool) []byte {
+	return fmt.Appendf(nil, `{"model":"%s","prompt":"def fib(n):","stream":%v}`, model, stream)
+}
+
+func Test_completionsProcessorRouterFilter_ProcessRequestBody(t *testing.T) {
+	t.Run("body parser error", func(t *testing.T) {
+		p := &completionsProcessorRouterFilter{}
+		_, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte("nonjson")})
+		require.ErrorContains(t, err, "invalid character 'o' in literal null")
+	})
+	t.Run("ok", func(t *testing.T) {
+		headers := map[string]string{":path": "/v1/completions"}
+		const modelKey = "x-ai-gateway-model-key"
+		p := &completionsProcessorRouterFilter{
+			config:         &processorConfig{modelNameHeaderKey: modelKey},
+			requestHeaders: headers,
+			logger:         slog.Default(),
+		}
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: completionsBodyFromModel(t, "some-model", false)})
+		require.NoError(t, err)
+		re, ok := resp.Response.(*extprocv3.ProcessingResponse_RequestBody)
+		require.True(t, ok)
+		require.True(t, re.RequestBody.GetResponse().GetClearRouteCache())
+		setHeaders := re.RequestBody.GetResponse().GetHeaderMutation().SetHeaders
+		require.Len(t, setHeaders, 2)
+		require.Equal(t, modelKey, setHeaders[0].Header.Key)
+		require.Equal(t, "some-model", string(setHeaders[0].Header.RawValue))
+		require.Equal(t, originalPathHeader, setHeaders[1].Header.Key)
+		require.Equal(t, "/v1/completions", string(setHeaders[1].Header.RawValue))
+		require.Equal(t, "some-model", headers[modelKey])
+		require.Equal(t, "def fib(n):", p.originalRequestBody.Prompt.Value)
+		require.False(t, p.forcedStreamOptionIncludeUsage)
+	})
+	t.Run("stream with costs forces include_usage", func(t *testing.T) {
+		const modelKey = "x-ai-gateway-model-key"
+		p := &completionsProcessorRouterFilter{
+			config: &processorConfig{
+				modelNameHeaderKey: modelKey,
+				requestCosts:       []processorConfigRequestCost{{LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeOutputToken}}},
+			},
+			requestHeaders: map[string]string{":path": "/v1/completions"},
+			logger:         slog.Default(),
+		}
+		_, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: completionsBodyFromModel(t, "some-model", true)})
+		require.NoError(t, err)
+		require.True(t, p.forcedStreamOptionIncludeUsage)
+		require.True(t, p.originalRequestBody.StreamOptions.IncludeUsage)
+		require.JSONEq(t, `{"model":"some-model","prompt":"def fib(n):","stream":true,"stream_options":{"include_usage":true}}`,
+			string(p.originalRequestBodyRaw))
+	})
+}
+
+func Test_completionsProcessorUpstreamFilter_ProcessRequestHeaders(t *testing.T) {
+	const modelKey = "x-ai-gateway-model-key"
+	for _, onRetry := range []bool{false, true} {
+		t.Run(fmt.Sprintf("ok/onRetry=%v", onRetry), func(t *testing.T) {
+			someBody := completionsBodyFromModel(t, "some-model", false)
+			headers := map[string]string{":path": "/v1/completions", modelKey: "some-model"}
+			headerMut := &extprocv3.HeaderMutation{
+				SetHeaders: []*corev3.HeaderValueOption{{Header: &corev3.HeaderValue{Key: "foo", RawValue: []byte("bar")}}},
+			}
+			bodyMut := &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: []byte("some body")}}
+			var expBody openai.CompletionRequest
+			require.NoError(t, json.Unmarshal(someBody, &expBody))
+			mt := &mockCompletionTranslator{
+				t: t, expRequestBody: &expBody, retHeaderMutation: headerMut, retBodyMutation: bodyMut,
+				expForceRequestBodyMutation: onRetry,
+			}
+			mm := &mockChatCompletionMetrics{}
+			p := &completionsProcessorUpstreamFilter{
+				config:                 &processorConfig{modelNameHeaderKey: modelKey, metadataNamespace: "ns"},
+				requestHeaders:         headers,
+				logger:                 slog.Default(),
+				metrics:                mm,
+				translator:             mt,
+				originalRequestBodyRaw: someBody,
+				originalRequestBody:    &expBody,
+				handler:                &mockBackendAuthHandler{},
+				onRetry:                onRetry,
+			}
+			resp, err := p.ProcessRequestHeaders(t.Context(), nil)
+			require.NoError(t, err)
+			commonRes := resp.Response.(*extprocv3.ProcessingResponse_RequestHeaders).RequestHeaders.Response
+			require.Equal(t, headerMut, commonRes.HeaderMutation)
+			require.Equal(t, bodyMut, commonRes.BodyMutation)
+			require.Equal(t, extprocv3.CommonResponse_CONTINUE_AND_REPLACE, commonRes.Status)
+			require.Equal(t, "bar", headers["foo"])
+			require.Equal(t, float64(len("some body")),
+				resp.DynamicMetadata.Fields["ns"].GetStructValue().Fields["content_length"].GetNumberValue())
+			mm.RequireRequestNotCompleted(t)
+			mm.RequireSelectedModel(t, "some-model")
+		})
+	}
+	t.Run("translator error", func(t *testing.T) {
+		someBody := completionsBodyFromModel(t, "some-model", false)
+		var body openai.CompletionRequest
+		require.NoError(t, json.Unmarshal(someBody, &body))
+		mm := &mockChatCompletionMetrics{}
+		p := &completionsProcessorUpstreamFilter{
+			config:              &processorConfig{modelNameHeaderKey: modelKey},
+			requestHeaders:      map[string]string{modelKey: "some-model"},
+			logger:              slog.Default(),
+			metrics:             mm,
+			translator:          &mockCompletionTranslator{t: t, expRequestBody: &body, retErr: errors.New("test error")},
+			originalRequestBody: &body,
+		}
+		_, err := p.ProcessRequestHeaders(t.Context(), nil)
+		require.ErrorContains(t, err, "failed to transform request: test error")
+		mm.RequireRequestFailure(t)
+	})
+}
+
+func Test_completionsProcessorUpstreamFilter_ProcessResponseHeaders(t *testing.T) {
+	t.Run("error translation", func(t *testing.T) {
+		mm := &mockChatCompletionMetrics{}
+		mt := &mockCompletionTranslator{t: t, expHeaders: make(map[string]string), retErr: errors.New("test error")}
+		p := &completionsProcessorUpstreamFilter{translator: mt, metrics: mm}
+		_, err := p.ProcessResponseHeaders(t.Context(), nil)
+		require.ErrorContains(t, err, "test error")
+		mm.RequireRequestFailure(t)
+	})
+	for _, stream := range []bool{false, true} {
+		t.Run(fmt.Sprintf("ok/stream=%v", stream), func(t *testing.T) {
+			inHeaders := &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}}
+			mm := &mockChatCompletionMetrics{}
+			mt := &mockCompletionTranslator{t: t, expHeaders: map[string]string{":status": "200"}}
+			p := &completionsProcessorUpstreamFilter{translator: mt, metrics: mm, stream: stream}
+			res, err := p.ProcessResponseHeaders(t.Context(), inHeaders)
+			require.NoError(t, err)
+			if stream {
+				require.Equal(t, extprocv3http.ProcessingMode_STREAMED, res.ModeOverride.ResponseBodyMode)
+			} else {
+				require.Nil(t, res.ModeOverride)
+			}
+			mm.RequireRequestNotCompleted(t)
+		})
+	}
+}
+
+func Test_completionsProcessorUpstreamFilter_ProcessResponseBody(t *testing.T) {
+	t.Run("error translation", func(t *testing.T) {
+		mm := &mockChatCompletionMetrics{}
+		mt := &mockCompletionTranslator{t: t, retErr: errors.New("test error")}
+		p := &completionsProcessorUpstreamFilter{translator: mt, metrics: mm, responseHeaders: map[string]string{":status": "200"}}
+		_, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{})
+		require.ErrorContains(t, err, "test error")
+		mm.RequireRequestFailure(t)
+		mm.RequireTokensRecorded(t, 0)
+	})
+	t.Run("ok", func(t *testing.T) {
+		inBody := &extprocv3.HttpBody{Body: []byte("some-body"), EndOfStream: true}
+		expBodyMut := &extprocv3.BodyMutation{}
+		expHeadMut := &extprocv3.HeaderMutation{}
+		mm := &mockChatCompletionMetrics{}
+		mt := &mockCompletionTranslator{
+			t: t, expResponseBody: inBody,
+			retBodyMutation: expBodyMut, retHeaderMutation: expHeadMut,
+			retUsedToken: translator.LLMTokenUsage{InputTokens: 1, OutputTokens: 2, TotalTokens: 3},
+		}
+		p := &completionsProcessorUpstreamFilter{
+			translator: mt,
+			logger:     slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})),
+			metrics:    mm,
+			config: &processorConfig{
+				metadataNamespace: "ai_gateway_llm_ns",
+				requestCosts: []processorConfigRequestCost{
+					{LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeInputToken, MetadataKey: "input_token_usage"}},
+					{LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeOutputToken, MetadataKey: "output_token_usage"}},
+					{LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeTotalToken, MetadataKey: "total_token_usage"}},
+				},
+			},
+			backendName:     "some_backend",
+			responseHeaders: map[string]string{":status": "200"},
+			stream:          true,
+		}
+		res, err := p.ProcessResponseBody(t.Context(), inBody)
+		require.NoError(t, err)
+		commonRes := res.Response.(*extprocv3.ProcessingResponse_ResponseBody).ResponseBody.Response
+		require.Equal(t, expBodyMut, commonRes.BodyMutation)
+		require.Equal(t, expHeadMut, commonRes.HeaderMutation)
+		mm.RequireRequestSuccess(t)
+		mm.RequireTokensRecorded(t, 1)
+
+		ns := res.DynamicMetadata.Fields["ai_gateway_llm_ns"].GetStructValue()
+		require.Equal(t, float64(1), ns.Fields["input_token_usage"].GetNumberValue())
+		require.Equal(t, float64(2), ns.Fields["output_token_usage"].GetNumberValue())
+		require.Equal(t, float64(3), ns.Fields["total_token_usage"].GetNumberValue())
+		require.Equal(t, "some_backend", ns.Fields["backend_name"].GetStringValue())
+	})
+	t.Run("error response", func(t *testing.T) {
+		inBody := &extprocv3.HttpBody{Body: []byte("some-body"), EndOfStream: true}
+		mt := &mockCompletionTranslator{t: t}
+		p := &completionsProcessorUpstreamFilter{
+			translator:      mt,
+			metrics:         &mockChatCompletionMetrics{},
+			config:          &processorConfig{},
+			responseHeaders: map[string]string{":status": "500"},
+		}
+		_, err := p.ProcessResponseBody(t.Context(), inBody)
+		require.NoError(t, err)
+		require.True(t, mt.responseErrorCalled)
+	})
+}
+
+func Test_completionsProcessorUpstreamFilter_SetBackend(t *testing.T) {
+	t.Run("unsupported", func(t *testing.T) {
+		mm := &mockChatCompletionMetrics{}
+		p := &completionsProcessorUpstreamFilter{
+			config:         &processorConfig{},
+			requestHeaders: map[string]string{},
+			logger:         slog.Default(),
+			metrics:        mm,
+		}
+		err := p.SetBackend(t.Context(), &filterapi.Backend{
+			Name:   "some-backend",
+			Schema: filterapi.VersionedAPISchema{Name: "some-schema", Version: "v10.0"},
+		}, nil, &completionsProcessorRouterFilter{})
+		require.ErrorContains(t, err, "unsupported API schema: backend={some-schema v10.0}")
+		mm.RequireRequestFailure(t)
+		mm.RequireSelectedBackend(t, "some-backend")
+	})
+	t.Run("ok", func(t *testing.T) {
+		mm := &mockChatCompletionMetrics{}
+		p := &completionsProcessorUpstreamFilter{
+			config:         &processorConfig{},
+			requestHeaders: map[string]string{},
+			logger:         slog.Default(),
+			metrics:        mm,
+		}
+		rp := &completionsProcessorRouterFilter{
+			originalRequestBody:            &openai.CompletionRequest{Stream: true},
+			forcedStreamOptionIncludeUsage: true,
+			upstreamFilterCount:            1,
+		}
+		err := p.SetBackend(t.Context(), &filterapi.Backend{
+			Name:              "some-backend",
+			ModelNameOverride: "override",
+			Schema:            filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+		}, nil, rp)
+		require.NoError(t, err)
+		require.True(t, p.stream)
+		require.True(t, p.onRetry)
+		require.True(t, p.forcedStreamOptionIncludeUsage)
+		require.Equal(t, "override", p.modelNameOverride)
+		require.Equal(t, p, rp.upstreamFilter)
+	})
+}
+
+func TestCompletionsProcessorRouterFilter_ProcessResponseHeaders_ProcessResponseBody(t *testing.T) {
+	p := &completionsProcessorRouterFilter{}
+	_, err := p.ProcessResponseHeaders(t.Context(), nil)
+	require.NoError(t, err)
+	_, err = p.ProcessResponseBody(t.Context(), nil)
+	require.NoError(t, err)
+}
+
+func Test_completionsProcessor_PIIRedaction(t *testing.T) {
+	s, err := pii.NewSet([]filterapi.PIIRedaction{{
+		Name:      "ns/route",
+		Matches:   []filterapi.RouteRuleMatch{{Model: "gpt-3.5-turbo-instruct"}},
+		Detectors: []filterapi.PIIDetector{{Name: "email", Type: filterapi.PIIDetectorTypeEmail}},
+	}})
+	require.NoError(t, err)
+	config := &processorConfig{modelNameHeaderKey: "x-ai-gateway-model-key", piiRedactions: s}
+	pm := &mockPIIMetrics{}
+	p := &completionsProcessorRouterFilter{
+		config: config, requestHeaders: map[string]string{":path": "/v1/completions"}, logger: slog.Default(), piiMetrics: pm,
+	}
+	_, err = p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{
+		Body: []byte(`{"model":"gpt-3.5-turbo-instruct","prompt":"mail me at alice@example.com"}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"email/Mask/1"}, pm.detections)
+	upstream := &completionsProcessorUpstreamFilter{
+		config: config, requestHeaders: p.requestHeaders, logger: slog.Default(), metrics: &mockChatCompletionMetrics{},
+	}
+	require.NoError(t, upstream.SetBackend(t.Context(), &filterapi.Backend{
+		Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+	}, nil, p))
+
+	// The masked body is sent to the backend.
+	resp, err := upstream.ProcessRequestHeaders(t.Context(), nil)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"model":"gpt-3.5-turbo-instruct","prompt":"mail me at [EMAIL_1]"}`,
+		string(resp.GetRequestHeaders().GetResponse().GetBodyMutation().GetBody()))
+
+	// The masked value is restored in the response.
+	_, err = upstream.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}})
+	require.NoError(t, err)
+	resp, err = upstream.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{
+		Body:        []byte(`{"choices":[{"index":0,"text":"Sent to [EMAIL_1]."}]}`),
+		EndOfStream: true,
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"choices":[{"index":0,"text":"Sent to alice@example.com."}]}`,
+		string(resp.GetResponseBody().GetResponse().GetBodyMutation().GetBody()))
+}
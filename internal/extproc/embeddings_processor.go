@@ -24,6 +24,7 @@ import (
 	"github.com/envoyproxy/ai-gateway/internal/extproc/backendauth"
 	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
 	"github.com/envoyproxy/ai-gateway/internal/metrics"
+	"github.com/envoyproxy/ai-gateway/internal/quota"
 	tracing "github.com/envoyproxy/ai-gateway/internal/tracing/api"
 )
 
@@ -52,6 +53,7 @@ func EmbeddingsProcessorFactory(em metrics.EmbeddingsMetrics) ProcessorFactory {
 // This is primarily used to select the route for the request based on the model name.
 type embeddingsProcessorRouterFilter struct {
 	passThroughProcessor
+	quotaTracker
 	// upstreamFilter is the upstream filter that is used to process the request at the upstream filter.
 	// This will be updated when the request is retried.
 	//
@@ -84,21 +86,38 @@ func (e *embeddingsProcessorRouterFilter) ProcessResponseHeaders(ctx context.Con
 }
 
 // ProcessResponseBody implements [Processor.ProcessResponseBody].
-func (e *embeddingsProcessorRouterFilter) ProcessResponseBody(ctx context.Context, body *extprocv3.HttpBody) (*extprocv3.ProcessingResponse, error) {
+func (e *embeddingsProcessorRouterFilter) ProcessResponseBody(ctx context.Context, body *extprocv3.HttpBody) (resp *extprocv3.ProcessingResponse, err error) {
 	// If the request failed to route and/or immediate response was returned before the upstream filter was set,
 	// e.upstreamFilter can be nil.
 	if e.upstreamFilter != nil { // See the comment on the "upstreamFilter" field.
-		return e.upstreamFilter.ProcessResponseBody(ctx, body)
+		resp, err = e.upstreamFilter.ProcessResponseBody(ctx, body)
+	} else {
+		resp, err = e.passThroughProcessor.ProcessResponseBody(ctx, body)
 	}
-	return e.passThroughProcessor.ProcessResponseBody(ctx, body)
+	e.reconcileAtEnd(ctx, body)
+	return
 }
 
 // ProcessRequestBody implements [Processor.ProcessRequestBody].
-func (e *embeddingsProcessorRouterFilter) ProcessRequestBody(_ context.Context, rawBody *extprocv3.HttpBody) (*extprocv3.ProcessingResponse, error) {
+func (e *embeddingsProcessorRouterFilter) ProcessRequestBody(ctx context.Context, rawBody *extprocv3.HttpBody) (*extprocv3.ProcessingResponse, error) {
 	model, body, err := parseOpenAIEmbeddingBody(rawBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse request body: %w", err)
 	}
+	model = e.config.resolveModelAlias(model)
+	keyHeaders, keyRemovedHeaders, resp := e.config.checkVirtualKey(model, e.requestHeaders)
+	if resp != nil {
+		return resp, nil
+	}
+	if resp = e.config.rejectUnsupportedRequestPolicy(openAIErrorFormat{}, model, e.requestHeaders); resp != nil {
+		return resp, nil
+	}
+
+	estimate := quota.EstimateEmbedding(body)
+	resp = e.reserveQuota(ctx, e.config, e.logger, &e.upstreamFilter, openAIErrorFormat{}, model, e.requestHeaders, estimate)
+	if resp != nil {
+		return resp, nil
+	}
 
 	e.requestHeaders[e.config.modelNameHeaderKey] = model
 
@@ -109,6 +128,10 @@ func (e *embeddingsProcessorRouterFilter) ProcessRequestBody(_ context.Context,
 	}, &corev3.HeaderValueOption{
 		Header: &corev3.HeaderValue{Key: originalPathHeader, RawValue: []byte(e.requestHeaders[":path"])},
 	})
+	bodyHeaders, removedHeaders := e.config.projectBodyHeaders(rawBody.Body, e.requestHeaders, estimate)
+	additionalHeaders = append(additionalHeaders, bodyHeaders...)
+	additionalHeaders = append(additionalHeaders, keyHeaders...)
+	removedHeaders = append(removedHeaders, keyRemovedHeaders...)
 	e.originalRequestBody = body
 	e.originalRequestBodyRaw = rawBody.Body
 	return &extprocv3.ProcessingResponse{
@@ -116,7 +139,8 @@ func (e *embeddingsProcessorRouterFilter) ProcessRequestBody(_ context.Context,
 			RequestBody: &extprocv3.BodyResponse{
 				Response: &extprocv3.CommonResponse{
 					HeaderMutation: &extprocv3.HeaderMutation{
-						SetHeaders: additionalHeaders,
+						SetHeaders:    additionalHeaders,
+						RemoveHeaders: removedHeaders,
 					},
 					ClearRouteCache: true,
 				},
@@ -153,6 +177,12 @@ func (e *embeddingsProcessorUpstreamFilter) selectTranslator(out filterapi.Versi
 	switch out.Name {
 	case filterapi.APISchemaOpenAI:
 		e.translator = translator.NewEmbeddingOpenAIToOpenAITranslator(out.Version, e.modelNameOverride)
+	case filterapi.APISchemaAWSBedrock:
+		e.translator = translator.NewEmbeddingOpenAIToAWSBedrockTranslator(e.modelNameOverride)
+	case filterapi.APISchemaGCPVertexAI:
+		e.translator = translator.NewEmbeddingOpenAIToGCPVertexAITranslator(e.modelNameOverride)
+	case filterapi.APISchemaAzureOpenAI:
+		e.translator = translator.NewEmbeddingOpenAIToAzureOpenAITranslator(out.Version, e.modelNameOverride)
 	default:
 		return fmt.Errorf("unsupported API schema: backend=%s", out)
 	}
@@ -311,7 +341,7 @@ func (e *embeddingsProcessorUpstreamFilter) ProcessResponseBody(ctx context.Cont
 	// Update metrics with token usage.
 	e.metrics.RecordTokenUsage(ctx, tokenUsage.InputTokens, tokenUsage.TotalTokens, e.requestHeaders)
 
-	if body.EndOfStream && len(e.config.requestCosts) > 0 {
+	if body.EndOfStream && e.config.recordsCosts() {
 		resp.DynamicMetadata, err = buildDynamicMetadata(e.config, &e.costs, e.requestHeaders, e.modelNameOverride, e.backendName)
 		if err != nil {
 			return nil, fmt.Errorf("failed to build dynamic metadata: %w", err)
@@ -15,13 +15,16 @@ import (
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/stretchr/testify/require"
 
 	"github.com/envoyproxy/ai-gateway/filterapi"
 	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
 	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
 	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
+	"github.com/envoyproxy/ai-gateway/internal/requestpolicy"
 	tracing "github.com/envoyproxy/ai-gateway/internal/tracing/api"
+	"github.com/envoyproxy/ai-gateway/internal/virtualkey"
 )
 
 func TestEmbeddings_Schema(t *testing.T) {
@@ -47,11 +50,18 @@ func Test_embeddingsProcessorUpstreamFilter_SelectTranslator(t *testing.T) {
 		err := e.selectTranslator(filterapi.VersionedAPISchema{Name: "Bar", Version: "v123"})
 		require.ErrorContains(t, err, "unsupported API schema: backend={Bar v123}")
 	})
-	t.Run("supported openai", func(t *testing.T) {
-		err := e.selectTranslator(filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI})
-		require.NoError(t, err)
-		require.NotNil(t, e.translator)
-	})
+	for _, s := range []filterapi.APISchemaName{
+		filterapi.APISchemaOpenAI,
+		filterapi.APISchemaAWSBedrock,
+		filterapi.APISchemaGCPVertexAI,
+		filterapi.APISchemaAzureOpenAI,
+	} {
+		t.Run(string(s), func(t *testing.T) {
+			e.translator = nil
+			require.NoError(t, e.selectTranslator(filterapi.VersionedAPISchema{Name: s}))
+			require.NotNil(t, e.translator)
+		})
+	}
 }
 
 func Test_embeddingsProcessorRouterFilter_ProcessRequestBody(t *testing.T) {
@@ -83,6 +93,31 @@ func Test_embeddingsProcessorRouterFilter_ProcessRequestBody(t *testing.T) {
 		require.Equal(t, "x-ai-eg-original-path", setHeaders[1].Header.Key)
 		require.Equal(t, "/foo", string(setHeaders[1].Header.RawValue))
 	})
+
+	t.Run("virtual key", func(t *testing.T) {
+		config := &processorConfig{
+			modelNameHeaderKey: "x-ai-gateway-model-key",
+			virtualKeys: virtualkey.NewSet([]filterapi.VirtualKeyPolicy{{
+				Name:            "ns/keys",
+				Models:          []string{"some-model"},
+				ClientKeyHeader: "x-api-key",
+				TeamIDHeader:    "x-team",
+				Keys:            []filterapi.VirtualKey{{Name: "ns/keys/a", Hash: virtualkey.Hash("sk-a"), TeamID: "team-a"}},
+			}}),
+		}
+		p := &embeddingsProcessorRouterFilter{config: config, requestHeaders: map[string]string{":path": "/foo"}, logger: slog.Default()}
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: embeddingBodyFromModel(t, "some-model")})
+		require.NoError(t, err)
+		require.Equal(t, typev3.StatusCode_Unauthorized, resp.GetImmediateResponse().GetStatus().GetCode())
+
+		p = &embeddingsProcessorRouterFilter{config: config, requestHeaders: map[string]string{":path": "/foo", "x-api-key": "sk-a"}, logger: slog.Default()}
+		resp, err = p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: embeddingBodyFromModel(t, "some-model")})
+		require.NoError(t, err)
+		hm := resp.GetRequestBody().GetResponse().GetHeaderMutation()
+		require.Equal(t, []string{"x-api-key"}, hm.RemoveHeaders)
+		require.Equal(t, "x-team", hm.SetHeaders[2].Header.Key)
+		require.Equal(t, "team-a", string(hm.SetHeaders[2].Header.RawValue))
+	})
 }
 
 func Test_embeddingsProcessorUpstreamFilter_ProcessResponseHeaders(t *testing.T) {
@@ -350,3 +385,25 @@ func TestEmbeddingsProcessorRouterFilter_ProcessResponseHeaders_ProcessResponseB
 		require.IsType(t, &extprocv3.HeaderMutation{}, re.ResponseBody.Response.HeaderMutation)
 	})
 }
+
+func Test_embeddingsProcessorRouterFilter_RequestPolicy(t *testing.T) {
+	p := &embeddingsProcessorRouterFilter{
+		config: &processorConfig{
+			modelNameHeaderKey: "x-ai-gateway-model-key",
+			requestPolicies: requestpolicy.NewSet([]filterapi.RequestPolicy{{
+				Name: "ns/route/rule/0", Matches: []filterapi.RouteRuleMatch{{Model: "text-embedding-3-small"}},
+			}}),
+		},
+		requestHeaders: map[string]string{":path": "/v1/embeddings"},
+		logger:         slog.Default(),
+	}
+	resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{
+		Body: []byte(`{"model":"text-embedding-3-small","input":"hello"}`),
+	})
+	require.NoError(t, err)
+	ir := resp.GetImmediateResponse()
+	require.NotNil(t, ir)
+	require.Equal(t, typev3.StatusCode_BadRequest, ir.Status.Code)
+	require.JSONEq(t, `{"type":"error","error":{"type":"invalid_request_error","code":"request_policy_violation",`+
+		`"message":"the request policy of the route only supports the chat completions API"}}`, string(ir.Body))
+}
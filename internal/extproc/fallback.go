@@ -0,0 +1,106 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+// The error types of the failed attempts on the backends, which are reported in the metrics.
+const (
+	fallbackErrorTypeContextLengthExceeded = "context_length_exceeded"
+	fallbackErrorTypeThrottled             = "throttled"
+	fallbackErrorTypeServerError           = "server_error"
+	fallbackErrorTypeCircuitOpen           = "circuit_open"
+	fallbackErrorTypeShed                  = "shed"
+	fallbackErrorTypeOther                 = "_OTHER"
+)
+
+// contextLengthExceededMessages are the lower-cased fragments of the error messages of the providers
+// which indicate that the request exceeds the context window of the model.
+var contextLengthExceededMessages = []string{
+	"context length",
+	"context window",
+	"maximum context",
+	"input is too long",
+	"prompt is too long",
+	"too many tokens",
+	"exceeds the maximum number of tokens",
+}
+
+// throttledErrorTypes are the error types of the providers which indicate the throttling regardless of the status code.
+// These are matched as prefixes since e.g. the error type of AWS Bedrock can be followed by the URL of the service.
+var throttledErrorTypes = []string{
+	"ThrottlingException", // AWS Bedrock.
+	"RESOURCE_EXHAUSTED",  // GCP Vertex AI.
+	"rate_limit_error",    // Anthropic.
+}
+
+// classifyBackendError returns the error type of the failed response of the backend. openAIErr is the error
+// body translated into the OpenAI format by the translator, and can be nil when the body is not available.
+func classifyBackendError(status int, openAIErr *openai.Error) string {
+	if openAIErr != nil {
+		if code := openAIErr.Error.Code; code != nil && *code == fallbackErrorTypeContextLengthExceeded {
+			return fallbackErrorTypeContextLengthExceeded
+		}
+		if status == http.StatusBadRequest || status == http.StatusRequestEntityTooLarge {
+			msg := strings.ToLower(openAIErr.Error.Message)
+			for _, m := range contextLengthExceededMessages {
+				if strings.Contains(msg, m) {
+					return fallbackErrorTypeContextLengthExceeded
+				}
+			}
+		}
+		for _, t := range throttledErrorTypes {
+			if strings.HasPrefix(openAIErr.Error.Type, t) {
+				return fallbackErrorTypeThrottled
+			}
+		}
+	}
+	switch {
+	case status == http.StatusTooManyRequests:
+		return fallbackErrorTypeThrottled
+	case status >= 500:
+		return fallbackErrorTypeServerError
+	default:
+		return fallbackErrorTypeOther
+	}
+}
+
+// shouldFallback returns true if the failure of the given error type is retried on the other backends.
+func shouldFallback(f *filterapi.BackendFallback, errorType string) bool {
+	if f == nil {
+		return false
+	}
+	var c filterapi.FallbackCondition
+	switch errorType {
+	case fallbackErrorTypeContextLengthExceeded:
+		c = filterapi.FallbackConditionContextLengthExceeded
+	case fallbackErrorTypeThrottled:
+		c = filterapi.FallbackConditionThrottled
+	case fallbackErrorTypeServerError:
+		c = filterapi.FallbackConditionServerError
+	default:
+		return false
+	}
+	return slices.Contains(f.RetryOn, c)
+}
+
+// fallbackRetryHeaderMutation returns the header mutation which makes Envoy retry the request on the other backends.
+func fallbackRetryHeaderMutation() *extprocv3.HeaderMutation {
+	return &extprocv3.HeaderMutation{SetHeaders: []*corev3.HeaderValueOption{{
+		Header: &corev3.HeaderValue{Key: internalapi.FallbackRetryHeaderKey, RawValue: []byte("true")},
+	}}}
+}
@@ -0,0 +1,88 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+)
+
+func Test_classifyBackendError(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		status int
+		err    *openai.Error
+		exp    string
+	}{
+		{name: "no body 429", status: 429, exp: fallbackErrorTypeThrottled},
+		{name: "no body 503", status: 503, exp: fallbackErrorTypeServerError},
+		{name: "no body 400", status: 400, exp: fallbackErrorTypeOther},
+		{
+			name: "openai context length", status: 400,
+			err: &openai.Error{Error: openai.ErrorType{Type: "invalid_request_error", Code: ptr.To("context_length_exceeded")}},
+			exp: fallbackErrorTypeContextLengthExceeded,
+		},
+		{
+			name: "aws bedrock context length", status: 400,
+			err: &openai.Error{Error: openai.ErrorType{Type: "ValidationException", Message: "Input is too long for requested model."}},
+			exp: fallbackErrorTypeContextLengthExceeded,
+		},
+		{
+			name: "gcp vertex ai context length", status: 400,
+			err: &openai.Error{Error: openai.ErrorType{Type: "INVALID_ARGUMENT", Message: "The input token count exceeds the maximum number of tokens allowed."}},
+			exp: fallbackErrorTypeContextLengthExceeded,
+		},
+		{
+			name: "context length message on server error", status: 500,
+			err: &openai.Error{Error: openai.ErrorType{Message: "context window"}},
+			exp: fallbackErrorTypeServerError,
+		},
+		{
+			name: "aws bedrock throttling", status: 400,
+			err: &openai.Error{Error: openai.ErrorType{Type: "ThrottlingException:http://internal.amazon.com/coral/com.amazon.bedrock/"}},
+			exp: fallbackErrorTypeThrottled,
+		},
+		{
+			name: "gcp vertex ai throttling", status: 429,
+			err: &openai.Error{Error: openai.ErrorType{Type: "RESOURCE_EXHAUSTED"}},
+			exp: fallbackErrorTypeThrottled,
+		},
+		{
+			name: "other", status: 401,
+			err: &openai.Error{Error: openai.ErrorType{Type: "authentication_error"}},
+			exp: fallbackErrorTypeOther,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.exp, classifyBackendError(tc.status, tc.err))
+		})
+	}
+}
+
+func Test_shouldFallback(t *testing.T) {
+	require.False(t, shouldFallback(nil, fallbackErrorTypeServerError))
+	f := &filterapi.BackendFallback{RetryOn: []filterapi.FallbackCondition{
+		filterapi.FallbackConditionServerError, filterapi.FallbackConditionContextLengthExceeded,
+	}}
+	require.True(t, shouldFallback(f, fallbackErrorTypeServerError))
+	require.True(t, shouldFallback(f, fallbackErrorTypeContextLengthExceeded))
+	require.False(t, shouldFallback(f, fallbackErrorTypeThrottled))
+	require.False(t, shouldFallback(f, fallbackErrorTypeOther))
+	require.False(t, shouldFallback(f, ""))
+}
+
+func Test_fallbackRetryHeaderMutation(t *testing.T) {
+	hm := fallbackRetryHeaderMutation()
+	require.Len(t, hm.SetHeaders, 1)
+	require.Equal(t, internalapi.FallbackRetryHeaderKey, hm.SetHeaders[0].Header.Key)
+	require.Equal(t, []byte("true"), hm.SetHeaders[0].Header.RawValue)
+}
@@ -0,0 +1,283 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/guardrail"
+	tracing "github.com/envoyproxy/ai-gateway/internal/tracing/api"
+)
+
+// guardrailErrorType is the type of the OpenAI error returned when the guardrail service fails closed.
+const guardrailErrorType = "guardrail_error"
+
+// guardrailRequest checks the request in the given format with the guardrail. When the guardrail mutates the request,
+// both the raw and the parsed body are replaced, and mutated is true. This returns the immediate response when the
+// request is blocked.
+//
+// The returned decision is the one recorded on the span, which is never nil unless err is set.
+func guardrailRequest[T any](ctx context.Context, logger *slog.Logger, g *guardrail.Guardrail, f apiFormat, model string, rawBody *extprocv3.HttpBody, body *T) (d *guardrail.Decision, mutated bool, resp *extprocv3.ProcessingResponse, err error) {
+	d, err = g.Check(ctx, &guardrail.Check{Phase: guardrail.PhaseRequest, API: f.api(), Model: model, Request: rawBody.Body})
+	if err != nil {
+		logger.Warn("failed to check the request with the guardrail", slog.String("error", err.Error()))
+		d = guardrailFailedDecision(err)
+	}
+	switch d.Action {
+	case guardrail.ActionBlock:
+		return d, false, guardrailBlockedResponse(f, d), nil
+	case guardrail.ActionMutate:
+		var m T
+		if err = json.Unmarshal(d.Request, &m); err != nil {
+			return nil, false, nil, fmt.Errorf("failed to parse the request mutated by the guardrail: %w", err)
+		}
+		rawBody.Body, *body = d.Request, m
+		return d, true, nil, nil
+	}
+	return d, false, nil, nil
+}
+
+// checkRequestGuardrail checks the request with the guardrail of the route when it applies to the request. When the
+// guardrail mutates the request, both the raw and the parsed body are replaced. This returns the immediate response
+// when the request is blocked, otherwise nil.
+//
+// The guardrail is remembered to check the response as well.
+func (c *chatCompletionProcessorRouterFilter) checkRequestGuardrail(ctx context.Context, model string, rawBody *extprocv3.HttpBody, body *openai.ChatCompletionRequest) (*extprocv3.ProcessingResponse, error) {
+	g := c.config.guardrails.Find(model, c.requestHeaders)
+	if g == nil {
+		return nil, nil
+	}
+	c.guardrail = g
+	if !g.ChecksRequest() {
+		return nil, nil
+	}
+	d, mutated, resp, err := guardrailRequest(ctx, c.logger, g, chatCompletionFormat{}, model, rawBody, body)
+	if err != nil {
+		return nil, err
+	}
+	if resp != nil {
+		// The span is not started for the blocked request otherwise, so start it here to record the decision.
+		if span := c.tracer.StartSpanAndInjectHeaders(ctx, c.requestHeaders, &extprocv3.HeaderMutation{}, body, rawBody.Body); span != nil {
+			recordGuardrail(span, guardrail.PhaseRequest, d)
+			span.EndSpan(d.Status, resp.GetImmediateResponse().Body)
+		}
+		return resp, nil
+	}
+	c.guardrailMutated = mutated
+	c.guardrailDecision = d
+	return nil, nil
+}
+
+// checkRequestGuardrail checks the request with the guardrail of the route when it applies to the request, and
+// returns the immediate response when the request is blocked. The decision is recorded in the log since the
+// requests of this API are not traced, including when the request is blocked.
+//
+// The guardrail is remembered to check the response as well.
+func (r *responsesProcessorRouterFilter) checkRequestGuardrail(ctx context.Context, model string, rawBody *extprocv3.HttpBody, body *openai.ResponseRequest) (*extprocv3.ProcessingResponse, error) {
+	g := r.config.guardrails.Find(model, r.requestHeaders)
+	if g == nil {
+		return nil, nil
+	}
+	r.guardrail = g
+	if !g.ChecksRequest() {
+		return nil, nil
+	}
+	d, mutated, resp, err := guardrailRequest(ctx, r.logger, g, responsesFormat{}, model, rawBody, body)
+	if err != nil {
+		return nil, err
+	}
+	r.guardrailDecision = d
+	recordGuardrail(guardrailLog{r.logger}, guardrail.PhaseRequest, d)
+	r.guardrailMutated = mutated
+	return resp, nil
+}
+
+// checkRequestGuardrail checks the request with the guardrail of the route when it applies to the request, and
+// returns the immediate response when the request is blocked. The decision is recorded in the log since the
+// requests of this API are not traced, including when the request is blocked.
+//
+// The guardrail is remembered to check the response as well.
+func (r *messagesProcessorRouterFilter) checkRequestGuardrail(ctx context.Context, model string, rawBody *extprocv3.HttpBody, body *anthropic.MessagesRequest) (*extprocv3.ProcessingResponse, error) {
+	g := r.config.guardrails.Find(model, r.requestHeaders)
+	if g == nil {
+		return nil, nil
+	}
+	r.guardrail = g
+	if !g.ChecksRequest() {
+		return nil, nil
+	}
+	d, mutated, resp, err := guardrailRequest(ctx, r.logger, g, messagesFormat{}, model, rawBody, body)
+	if err != nil {
+		return nil, err
+	}
+	r.guardrailDecision = d
+	recordGuardrail(guardrailLog{r.logger}, guardrail.PhaseRequest, d)
+	r.guardrailMutated = mutated
+	return resp, nil
+}
+
+// responseGuardrail holds back the response of an attempt in the format of the API, i.e. the body mutation by the
+// translator or the decoded response body when the translator doesn't mutate it, and checks it with the guardrail at
+// the end of the stream. The streaming response is assembled into the non-streaming one to be checked, and re-emitted
+// as a stream when the guardrail mutates it.
+//
+// The response is held back up to the MaxResponseBytes of the guardrail. The larger response is released unchecked
+// or replaced with the error in the same way as when the guardrail service cannot be reached.
+type responseGuardrail struct {
+	guardrail *guardrail.Guardrail
+	format    apiFormat
+	logger    *slog.Logger
+	model     string
+	// request is the request body sent to the backend, which is sent to the guardrail service with the response.
+	request []byte
+	stream  bool
+	buf     []byte
+	// decision is the decision of the guardrail on the response, which is recorded on the span by the chat completion
+	// processor. After this is set, the rest of the response is released as is unless it's blocked.
+	decision *guardrail.Decision
+}
+
+// newResponseGuardrail returns the responseGuardrail of an attempt, or nil if the guardrail doesn't check the
+// responses.
+func newResponseGuardrail(g *guardrail.Guardrail, f apiFormat, logger *slog.Logger, model string, request []byte, stream bool) *responseGuardrail {
+	if g == nil || !g.ChecksResponse() {
+		return nil
+	}
+	return &responseGuardrail{guardrail: g, format: f, logger: logger, model: model, request: request, stream: stream}
+}
+
+// check holds back the given part of the response until the guardrail decides on the whole response. The returned
+// body mutation releases the response allowed or mutated by the guardrail. When the guardrail blocks the
+// non-streaming response, this returns the immediate response replacing it instead. The blocked streaming response is
+// ended with the error events since the response headers have already been sent to the client.
+func (r *responseGuardrail) check(ctx context.Context, part []byte, endOfStream bool) (*extprocv3.BodyMutation, *extprocv3.ImmediateResponse) {
+	if r.decision != nil {
+		if r.decision.Action == guardrail.ActionBlock {
+			part = []byte{}
+		}
+		return &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: part}}, nil
+	}
+	r.buf = append(r.buf, part...)
+	var d *guardrail.Decision
+	var err error
+	if limit := r.guardrail.MaxResponseBytes(); limit > 0 && len(r.buf) > limit {
+		d, err = r.fail(fmt.Errorf("response exceeds the %d bytes held back", limit))
+	} else if !endOfStream {
+		// Nothing is released until the guardrail decides on the whole response.
+		return &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: []byte{}}}, nil
+	} else {
+		d, err = r.decide(ctx)
+	}
+	if err != nil {
+		r.logger.Warn("failed to check the response with the guardrail", slog.String("error", err.Error()))
+		d = guardrailFailedDecision(err)
+	}
+	r.decision = d
+
+	out := r.buf
+	r.buf = nil
+	switch d.Action {
+	case guardrail.ActionBlock:
+		if !r.stream {
+			return nil, guardrailBlockedResponse(r.format, d).GetImmediateResponse()
+		}
+		out = r.format.errorEvents(d.Error)
+	case guardrail.ActionMutate:
+		out = d.Response
+	}
+	return &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: out}}, nil
+}
+
+// decide calls the guardrail with the response held back. For the streaming response, the response is assembled
+// before the call, and the response of the mutate decision is re-emitted as a stream. When either fails, the
+// guardrail fails open or closed in the same way as when the service cannot be reached.
+func (r *responseGuardrail) decide(ctx context.Context) (*guardrail.Decision, error) {
+	resp := r.buf
+	if r.stream {
+		var err error
+		if resp, err = r.format.assembleStream(r.buf); err != nil {
+			return r.fail(fmt.Errorf("failed to assemble the streaming response: %w", err))
+		}
+	}
+	d, err := r.guardrail.Check(ctx, &guardrail.Check{
+		Phase:    guardrail.PhaseResponse,
+		API:      r.format.api(),
+		Model:    r.model,
+		Request:  r.request,
+		Response: resp,
+	})
+	if err != nil || d.Action != guardrail.ActionMutate || !r.stream {
+		return d, err
+	}
+	if d.Response, err = r.format.streamBody(d.Response); err != nil {
+		return r.fail(fmt.Errorf("failed to re-emit the mutated response as a stream: %w", err))
+	}
+	return d, nil
+}
+
+// fail returns the allow decision with the given error as the reason if the guardrail fails open, otherwise the
+// error.
+func (r *responseGuardrail) fail(err error) (*guardrail.Decision, error) {
+	err = fmt.Errorf("guardrail %s failed: %w", r.guardrail.Name(), err)
+	if r.guardrail.FailOpen() {
+		return &guardrail.Decision{Action: guardrail.ActionAllow, Reason: err.Error()}, nil
+	}
+	return nil, err
+}
+
+// guardrailFailedDecision returns the decision blocking the request or the response when the guardrail fails closed.
+// The error is only recorded as the reason, and not returned to the client.
+func guardrailFailedDecision(err error) *guardrail.Decision {
+	return &guardrail.Decision{
+		Action: guardrail.ActionBlock,
+		Reason: err.Error(),
+		Status: http.StatusServiceUnavailable,
+		Error:  &openai.ErrorType{Type: guardrailErrorType, Message: "the guardrail service is unavailable"},
+	}
+}
+
+// guardrailBlockedResponse returns the immediate response with the error of the given block decision in the given
+// format.
+func guardrailBlockedResponse(f apiFormat, d *guardrail.Decision) *extprocv3.ProcessingResponse {
+	return errorResponse(f, typev3.StatusCode(d.Status), d.Error) //nolint:gosec
+}
+
+// guardrailRecorder records the decisions of the guardrail, which is implemented by [tracing.ChatCompletionSpan].
+type guardrailRecorder interface {
+	RecordGuardrail(phase, action, reason string)
+}
+
+var _ guardrailRecorder = tracing.ChatCompletionSpan(nil)
+
+// guardrailLog is the guardrailRecorder recording the decisions in the log, which is used for the APIs whose requests
+// are not traced.
+type guardrailLog struct {
+	logger *slog.Logger
+}
+
+// RecordGuardrail implements [guardrailRecorder.RecordGuardrail].
+func (l guardrailLog) RecordGuardrail(phase, action, reason string) {
+	l.logger.Info("the guardrail decided", slog.String("phase", phase), slog.String("action", action),
+		slog.String("reason", reason))
+}
+
+// recordGuardrail records the given decision of the guardrail with the recorder, e.g. on the span. This is no-op if
+// the decision is nil.
+func recordGuardrail(r guardrailRecorder, phase guardrail.Phase, d *guardrail.Decision) {
+	if d == nil {
+		return
+	}
+	r.RecordGuardrail(string(phase), string(d.Action), d.Reason)
+}
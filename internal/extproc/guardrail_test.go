@@ -0,0 +1,68 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/guardrail"
+)
+
+func Test_responseGuardrail_MaxResponseBytes(t *testing.T) {
+	service := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Fatal("the guardrail service must not be called")
+	}))
+	defer service.Close()
+	newResponseGuardrailWith := func(t *testing.T, failOpen, stream bool) *responseGuardrail {
+		g, err := guardrail.New(&filterapi.Guardrail{
+			Name: "ns/route", Endpoint: service.URL, Response: true, FailOpen: failOpen, MaxResponseBytes: 10,
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = g.Close() })
+		return newResponseGuardrail(g, chatCompletionFormat{}, slog.Default(), "gpt-4o", nil, stream)
+	}
+
+	t.Run("fail open", func(t *testing.T) {
+		r := newResponseGuardrailWith(t, true, true)
+		bm, blocked := r.check(t.Context(), []byte("data: 1\n\n"), false)
+		require.Nil(t, blocked)
+		require.Empty(t, bm.GetBody())
+		// The response held back so far and the rest are released unchecked.
+		bm, blocked = r.check(t.Context(), []byte("data: 2\n\n"), false)
+		require.Nil(t, blocked)
+		require.Equal(t, "data: 1\n\ndata: 2\n\n", string(bm.GetBody()))
+		bm, _ = r.check(t.Context(), []byte("data: [DONE]\n\n"), true)
+		require.Equal(t, "data: [DONE]\n\n", string(bm.GetBody()))
+		require.Equal(t, guardrail.ActionAllow, r.decision.Action)
+		require.Contains(t, r.decision.Reason, "response exceeds the 10 bytes held back")
+	})
+
+	t.Run("fail closed", func(t *testing.T) {
+		r := newResponseGuardrailWith(t, false, true)
+		_, _ = r.check(t.Context(), []byte("data: 1\n\n"), false)
+		bm, blocked := r.check(t.Context(), []byte("data: 2\n\n"), false)
+		require.Nil(t, blocked)
+		require.Equal(t, `data: {"type":"error","error":{"type":"guardrail_error","message":"the guardrail service is unavailable"}}`+
+			"\n\ndata: [DONE]\n\n", string(bm.GetBody()))
+		// The rest of the blocked response is dropped.
+		bm, _ = r.check(t.Context(), []byte("data: [DONE]\n\n"), true)
+		require.Empty(t, bm.GetBody())
+	})
+
+	t.Run("fail closed non-streaming", func(t *testing.T) {
+		r := newResponseGuardrailWith(t, false, false)
+		bm, blocked := r.check(t.Context(), []byte(`{"choices":[]}`), true)
+		require.Nil(t, bm)
+		require.NotNil(t, blocked)
+		require.Equal(t, http.StatusServiceUnavailable, int(blocked.Status.Code))
+	})
+}
@@ -0,0 +1,387 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/backendauth"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+	"github.com/envoyproxy/ai-gateway/internal/quota"
+	tracing "github.com/envoyproxy/ai-gateway/internal/tracing/api"
+)
+
+// ImageGenerationProcessorFactory returns a factory method to instantiate the image generation processor.
+func ImageGenerationProcessorFactory(im metrics.ImageGenerationMetrics) ProcessorFactory {
+	return func(config *processorConfig, requestHeaders map[string]string, logger *slog.Logger, _ tracing.Tracing, isUpstreamFilter bool) (Processor, error) {
+		logger = logger.With("processor", "image_generation", "isUpstreamFilter", fmt.Sprintf("%v", isUpstreamFilter))
+		if !isUpstreamFilter {
+			return &imageGenerationProcessorRouterFilter{
+				config:         config,
+				requestHeaders: requestHeaders,
+				logger:         logger,
+			}, nil
+		}
+		return &imageGenerationProcessorUpstreamFilter{
+			config:         config,
+			requestHeaders: requestHeaders,
+			logger:         logger,
+			metrics:        im,
+		}, nil
+	}
+}
+
+// imageGenerationProcessorRouterFilter implements [Processor] for the `/v1/images/generations` endpoint.
+//
+// This is primarily used to select the route for the request based on the model name.
+type imageGenerationProcessorRouterFilter struct {
+	passThroughProcessor
+	quotaTracker
+	// upstreamFilter is the upstream filter that is used to process the request at the upstream filter.
+	// This will be updated when the request is retried.
+	//
+	// On the response handling path, we don't need to do any operation until successful, so we use the implementation
+	// of the upstream filter to handle the response at the router filter.
+	//
+	// TODO: this is a bit of a hack and dirty workaround, so revert this to a cleaner design later.
+	upstreamFilter Processor
+	logger         *slog.Logger
+	config         *processorConfig
+	requestHeaders map[string]string
+	// originalRequestBody is the original request body that is passed to the upstream filter.
+	// This is used to perform the transformation of the request body on the original input
+	// when the request is retried.
+	originalRequestBody    *openai.ImageGenerationRequest
+	originalRequestBodyRaw []byte
+	// upstreamFilterCount is the number of upstream filters that have been processed.
+	// This is used to determine if the request is a retry request.
+	upstreamFilterCount int
+}
+
+// ProcessResponseHeaders implements [Processor.ProcessResponseHeaders].
+func (i *imageGenerationProcessorRouterFilter) ProcessResponseHeaders(ctx context.Context, headerMap *corev3.HeaderMap) (*extprocv3.ProcessingResponse, error) {
+	// If the request failed to route and/or immediate response was returned before the upstream filter was set,
+	// i.upstreamFilter can be nil.
+	if i.upstreamFilter != nil { // See the comment on the "upstreamFilter" field.
+		return i.upstreamFilter.ProcessResponseHeaders(ctx, headerMap)
+	}
+	return i.passThroughProcessor.ProcessResponseHeaders(ctx, headerMap)
+}
+
+// ProcessResponseBody implements [Processor.ProcessResponseBody].
+func (i *imageGenerationProcessorRouterFilter) ProcessResponseBody(ctx context.Context, body *extprocv3.HttpBody) (resp *extprocv3.ProcessingResponse, err error) {
+	// If the request failed to route and/or immediate response was returned before the upstream filter was set,
+	// i.upstreamFilter can be nil.
+	if i.upstreamFilter != nil { // See the comment on the "upstreamFilter" field.
+		resp, err = i.upstreamFilter.ProcessResponseBody(ctx, body)
+	} else {
+		resp, err = i.passThroughProcessor.ProcessResponseBody(ctx, body)
+	}
+	i.reconcileAtEnd(ctx, body)
+	return
+}
+
+// ProcessRequestBody implements [Processor.ProcessRequestBody].
+func (i *imageGenerationProcessorRouterFilter) ProcessRequestBody(ctx context.Context, rawBody *extprocv3.HttpBody) (*extprocv3.ProcessingResponse, error) {
+	model, body, err := parseOpenAIImageGenerationBody(rawBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request body: %w", err)
+	}
+	model = i.config.resolveModelAlias(model)
+	keyHeaders, keyRemovedHeaders, resp := i.config.checkVirtualKey(model, i.requestHeaders)
+	if resp != nil {
+		return resp, nil
+	}
+	if resp = i.config.rejectUnsupportedRequestPolicy(openAIErrorFormat{}, model, i.requestHeaders); resp != nil {
+		return resp, nil
+	}
+
+	// The usage of the image generation is not known up front, so the request is only rejected when the budget is
+	// already exhausted.
+	resp = i.reserveQuota(ctx, i.config, i.logger, &i.upstreamFilter, openAIErrorFormat{}, model, i.requestHeaders, quota.Usage{})
+	if resp != nil {
+		return resp, nil
+	}
+
+	i.requestHeaders[i.config.modelNameHeaderKey] = model
+
+	var additionalHeaders []*corev3.HeaderValueOption
+	additionalHeaders = append(additionalHeaders, &corev3.HeaderValueOption{
+		// Set the model name to the request header with the key `x-ai-eg-model`.
+		Header: &corev3.HeaderValue{Key: i.config.modelNameHeaderKey, RawValue: []byte(model)},
+	}, &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{Key: originalPathHeader, RawValue: []byte(i.requestHeaders[":path"])},
+	})
+	bodyHeaders, removedHeaders := i.config.projectBodyHeaders(rawBody.Body, i.requestHeaders, quota.Usage{})
+	additionalHeaders = append(additionalHeaders, bodyHeaders...)
+	additionalHeaders = append(additionalHeaders, keyHeaders...)
+	removedHeaders = append(removedHeaders, keyRemovedHeaders...)
+	i.originalRequestBody = body
+	i.originalRequestBodyRaw = rawBody.Body
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_RequestBody{
+			RequestBody: &extprocv3.BodyResponse{
+				Response: &extprocv3.CommonResponse{
+					HeaderMutation: &extprocv3.HeaderMutation{
+						SetHeaders:    additionalHeaders,
+						RemoveHeaders: removedHeaders,
+					},
+					ClearRouteCache: true,
+				},
+			},
+		},
+	}, nil
+}
+
+// imageGenerationProcessorUpstreamFilter implements [Processor] for the `/v1/images/generations` endpoint at the upstream filter.
+//
+// This is created per retry and handles the translation as well as the authentication of the request.
+type imageGenerationProcessorUpstreamFilter struct {
+	logger                 *slog.Logger
+	config                 *processorConfig
+	requestHeaders         map[string]string
+	responseHeaders        map[string]string
+	responseEncoding       string
+	modelNameOverride      string
+	backendName            string
+	handler                backendauth.Handler
+	originalRequestBodyRaw []byte
+	originalRequestBody    *openai.ImageGenerationRequest
+	translator             translator.OpenAIImageGenerationTranslator
+	// onRetry is true if this is a retry request at the upstream filter.
+	onRetry bool
+	// cost is the cost of the request that is accumulated during the processing of the response.
+	costs translator.LLMTokenUsage
+	// metrics tracking.
+	metrics metrics.ImageGenerationMetrics
+}
+
+// selectTranslator selects the translator based on the output schema.
+func (i *imageGenerationProcessorUpstreamFilter) selectTranslator(out filterapi.VersionedAPISchema) error {
+	switch out.Name {
+	case filterapi.APISchemaOpenAI:
+		i.translator = translator.NewImageGenerationOpenAIToOpenAITranslator(out.Version, i.modelNameOverride)
+	case filterapi.APISchemaAWSBedrock:
+		i.translator = translator.NewImageGenerationOpenAIToAWSBedrockTranslator(i.modelNameOverride)
+	case filterapi.APISchemaGCPVertexAI:
+		i.translator = translator.NewImageGenerationOpenAIToGCPVertexAITranslator(i.modelNameOverride)
+	case filterapi.APISchemaAzureOpenAI:
+		i.translator = translator.NewImageGenerationOpenAIToAzureOpenAITranslator(out.Version, i.modelNameOverride)
+	default:
+		return fmt.Errorf("unsupported API schema: backend=%s", out)
+	}
+	return nil
+}
+
+// ProcessRequestHeaders implements [Processor.ProcessRequestHeaders].
+//
+// At the upstream filter, we already have the original request body at request headers phase.
+// So, we simply do the translation and upstream auth at this stage, and send them back to Envoy
+// with the status CONTINUE_AND_REPLACE. This will allows Envoy to not send the request body again
+// to the extproc.
+func (i *imageGenerationProcessorUpstreamFilter) ProcessRequestHeaders(ctx context.Context, _ *corev3.HeaderMap) (res *extprocv3.ProcessingResponse, err error) {
+	defer func() {
+		if err != nil {
+			i.metrics.RecordRequestCompletion(ctx, false, i.requestHeaders)
+		}
+	}()
+
+	// Start tracking metrics for this request.
+	i.metrics.StartRequest(i.requestHeaders)
+	i.metrics.SetModel(i.requestHeaders[i.config.modelNameHeaderKey])
+
+	headerMutation, bodyMutation, err := i.translator.RequestBody(i.originalRequestBodyRaw, i.originalRequestBody, i.onRetry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform request: %w", err)
+	}
+	if headerMutation == nil {
+		headerMutation = &extprocv3.HeaderMutation{}
+	} else {
+		for _, h := range headerMutation.SetHeaders {
+			i.requestHeaders[h.Header.Key] = string(h.Header.RawValue)
+		}
+	}
+	if h := i.handler; h != nil {
+		if err = h.Do(ctx, i.requestHeaders, headerMutation, bodyMutation); err != nil {
+			return nil, fmt.Errorf("failed to do auth request: %w", err)
+		}
+	}
+
+	var dm *structpb.Struct
+	if bm := bodyMutation.GetBody(); bm != nil {
+		dm = buildContentLengthDynamicMetadataOnRequest(i.config, len(bm))
+	}
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_RequestHeaders{
+			RequestHeaders: &extprocv3.HeadersResponse{
+				Response: &extprocv3.CommonResponse{
+					HeaderMutation: headerMutation, BodyMutation: bodyMutation,
+					Status: extprocv3.CommonResponse_CONTINUE_AND_REPLACE,
+				},
+			},
+		},
+		DynamicMetadata: dm,
+	}, nil
+}
+
+// ProcessRequestBody implements [Processor.ProcessRequestBody].
+func (i *imageGenerationProcessorUpstreamFilter) ProcessRequestBody(context.Context, *extprocv3.HttpBody) (res *extprocv3.ProcessingResponse, err error) {
+	panic("BUG: ProcessRequestBody should not be called in the upstream filter")
+}
+
+// ProcessResponseHeaders implements [Processor.ProcessResponseHeaders].
+func (i *imageGenerationProcessorUpstreamFilter) ProcessResponseHeaders(ctx context.Context, headers *corev3.HeaderMap) (res *extprocv3.ProcessingResponse, err error) {
+	defer func() {
+		if err != nil {
+			i.metrics.RecordRequestCompletion(ctx, false, i.requestHeaders)
+		}
+	}()
+
+	i.responseHeaders = headersToMap(headers)
+	if enc := i.responseHeaders["content-encoding"]; enc != "" {
+		i.responseEncoding = enc
+	}
+	headerMutation, err := i.translator.ResponseHeaders(i.responseHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform response headers: %w", err)
+	}
+	return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseHeaders{
+		ResponseHeaders: &extprocv3.HeadersResponse{
+			Response: &extprocv3.CommonResponse{HeaderMutation: headerMutation},
+		},
+	}}, nil
+}
+
+// ProcessResponseBody implements [Processor.ProcessResponseBody].
+func (i *imageGenerationProcessorUpstreamFilter) ProcessResponseBody(ctx context.Context, body *extprocv3.HttpBody) (res *extprocv3.ProcessingResponse, err error) {
+	defer func() {
+		i.metrics.RecordRequestCompletion(ctx, err == nil, i.requestHeaders)
+	}()
+	var br io.Reader
+	var isGzip bool
+	switch i.responseEncoding {
+	case "gzip":
+		br, err = gzip.NewReader(bytes.NewReader(body.Body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode gzip: %w", err)
+		}
+		isGzip = true
+	default:
+		br = bytes.NewReader(body.Body)
+	}
+
+	// Assume all responses have a valid status code header.
+	if code, _ := strconv.Atoi(i.responseHeaders[":status"]); !isGoodStatusCode(code) {
+		var headerMutation *extprocv3.HeaderMutation
+		var bodyMutation *extprocv3.BodyMutation
+		headerMutation, bodyMutation, err = i.translator.ResponseError(i.responseHeaders, br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transform response error: %w", err)
+		}
+		return &extprocv3.ProcessingResponse{
+			Response: &extprocv3.ProcessingResponse_ResponseBody{
+				ResponseBody: &extprocv3.BodyResponse{
+					Response: &extprocv3.CommonResponse{
+						HeaderMutation: headerMutation,
+						BodyMutation:   bodyMutation,
+					},
+				},
+			},
+		}, nil
+	}
+
+	headerMutation, bodyMutation, tokenUsage, err := i.translator.ResponseBody(i.responseHeaders, br, body.EndOfStream)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform response: %w", err)
+	}
+	if bodyMutation != nil && isGzip {
+		if headerMutation == nil {
+			headerMutation = &extprocv3.HeaderMutation{}
+		}
+		// TODO: this is a hotfix, we should update this to recompress since its in the header
+		// If the response was gzipped, ensure we remove the content-encoding header.
+		//
+		// This is only needed when the transformation is actually modifying the body. When the backend
+		// is in OpenAI format (and it's the first try before any retry), the response body is not modified,
+		// so we don't need to remove the header in that case.
+		headerMutation.RemoveHeaders = append(headerMutation.RemoveHeaders, "content-encoding")
+	}
+
+	resp := &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ResponseBody{
+			ResponseBody: &extprocv3.BodyResponse{
+				Response: &extprocv3.CommonResponse{
+					HeaderMutation: headerMutation,
+					BodyMutation:   bodyMutation,
+				},
+			},
+		},
+	}
+
+	// Accumulate token usage and the number of generated images.
+	i.costs.InputTokens += tokenUsage.InputTokens
+	i.costs.OutputTokens += tokenUsage.OutputTokens
+	i.costs.TotalTokens += tokenUsage.TotalTokens
+	i.costs.ImageCount += tokenUsage.ImageCount
+
+	// Update metrics with token usage.
+	i.metrics.RecordTokenUsage(ctx, tokenUsage.InputTokens, tokenUsage.OutputTokens, tokenUsage.TotalTokens, i.requestHeaders)
+
+	if body.EndOfStream && i.config.recordsCosts() {
+		resp.DynamicMetadata, err = buildDynamicMetadata(i.config, &i.costs, i.requestHeaders, i.modelNameOverride, i.backendName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build dynamic metadata: %w", err)
+		}
+	}
+
+	return resp, nil
+}
+
+// SetBackend implements [Processor.SetBackend].
+func (i *imageGenerationProcessorUpstreamFilter) SetBackend(ctx context.Context, b *filterapi.Backend, backendHandler backendauth.Handler, routeProcessor Processor) (err error) {
+	defer func() {
+		i.metrics.RecordRequestCompletion(ctx, err == nil, i.requestHeaders)
+	}()
+	rp, ok := routeProcessor.(*imageGenerationProcessorRouterFilter)
+	if !ok {
+		panic("BUG: expected routeProcessor to be of type *imageGenerationProcessorRouterFilter")
+	}
+	rp.upstreamFilterCount++
+	i.metrics.SetBackend(b)
+	i.modelNameOverride = b.ModelNameOverride
+	i.backendName = b.Name
+	if err = i.selectTranslator(b.Schema); err != nil {
+		return fmt.Errorf("failed to select translator: %w", err)
+	}
+	i.handler = backendHandler
+	i.originalRequestBody = rp.originalRequestBody
+	i.originalRequestBodyRaw = rp.originalRequestBodyRaw
+	i.onRetry = rp.upstreamFilterCount > 1
+	rp.upstreamFilter = i
+	return
+}
+
+func parseOpenAIImageGenerationBody(body *extprocv3.HttpBody) (modelName string, rb *openai.ImageGenerationRequest, err error) {
+	var openAIReq openai.ImageGenerationRequest
+	if err := json.Unmarshal(body.Body, &openAIReq); err != nil {
+		return "", nil, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	return openAIReq.Model, &openAIReq, nil
+}
@@ -0,0 +1,363 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
+	tracing "github.com/envoyproxy/ai-gateway/internal/tracing/api"
+)
+
+func TestImageGeneration_Schema(t *testing.T) {
+	t.Run("supported openai / on route", func(t *testing.T) {
+		cfg := &processorConfig{}
+		routeFilter, err := ImageGenerationProcessorFactory(nil)(cfg, nil, slog.Default(), tracing.NoopTracing{}, false)
+		require.NoError(t, err)
+		require.NotNil(t, routeFilter)
+		require.IsType(t, &imageGenerationProcessorRouterFilter{}, routeFilter)
+	})
+	t.Run("supported openai / on upstream", func(t *testing.T) {
+		cfg := &processorConfig{}
+		routeFilter, err := ImageGenerationProcessorFactory(nil)(cfg, nil, slog.Default(), tracing.NoopTracing{}, true)
+		require.NoError(t, err)
+		require.NotNil(t, routeFilter)
+		require.IsType(t, &imageGenerationProcessorUpstreamFilter{}, routeFilter)
+	})
+}
+
+func Test_imageGenerationProcessorUpstreamFilter_SelectTranslator(t *testing.T) {
+	i := &imageGenerationProcessorUpstreamFilter{}
+	t.Run("unsupported", func(t *testing.T) {
+		err := i.selectTranslator(filterapi.VersionedAPISchema{Name: "Bar", Version: "v123"})
+		require.ErrorContains(t, err, "unsupported API schema: backend={Bar v123}")
+	})
+	for _, s := range []filterapi.APISchemaName{
+		filterapi.APISchemaOpenAI,
+		filterapi.APISchemaAWSBedrock,
+		filterapi.APISchemaGCPVertexAI,
+		filterapi.APISchemaAzureOpenAI,
+	} {
+		t.Run(string(s), func(t *testing.T) {
+			i.translator = nil
+			require.NoError(t, i.selectTranslator(filterapi.VersionedAPISchema{Name: s}))
+			require.NotNil(t, i.translator)
+		})
+	}
+}
+
+func Test_imageGenerationProcessorRouterFilter_ProcessRequestBody(t *testing.T) {
+	t.Run("body parser error", func(t *testing.T) {
+		p := &imageGenerationProcessorRouterFilter{}
+		_, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte("nonjson")})
+		require.ErrorContains(t, err, "invalid character 'o' in literal null")
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		headers := map[string]string{":path": "/foo"}
+		const modelKey = "x-ai-gateway-model-key"
+		p := &imageGenerationProcessorRouterFilter{
+			config:         &processorConfig{modelNameHeaderKey: modelKey},
+			requestHeaders: headers,
+			logger:         slog.Default(),
+		}
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: imageGenerationBodyFromModel(t, "some-model")})
+		require.NoError(t, err)
+		require.NotNil(t, resp)
+		re, ok := resp.Response.(*extprocv3.ProcessingResponse_RequestBody)
+		require.True(t, ok)
+		require.NotNil(t, re)
+		require.NotNil(t, re.RequestBody)
+		setHeaders := re.RequestBody.GetResponse().GetHeaderMutation().SetHeaders
+		require.Len(t, setHeaders, 2)
+		require.Equal(t, modelKey, setHeaders[0].Header.Key)
+		require.Equal(t, "some-model", string(setHeaders[0].Header.RawValue))
+		require.Equal(t, "x-ai-eg-original-path", setHeaders[1].Header.Key)
+		require.Equal(t, "/foo", string(setHeaders[1].Header.RawValue))
+	})
+}
+
+func Test_imageGenerationProcessorUpstreamFilter_ProcessResponseHeaders(t *testing.T) {
+	t.Run("error translation", func(t *testing.T) {
+		mm := &mockImageGenerationMetrics{}
+		mt := &mockImageGenerationTranslator{t: t, expHeaders: make(map[string]string)}
+		p := &imageGenerationProcessorUpstreamFilter{
+			translator: mt,
+			metrics:    mm,
+		}
+		mt.retErr = errors.New("test error")
+		_, err := p.ProcessResponseHeaders(t.Context(), nil)
+		require.ErrorContains(t, err, "test error")
+		mm.RequireRequestFailure(t)
+	})
+	t.Run("ok", func(t *testing.T) {
+		inHeaders := &corev3.HeaderMap{
+			Headers: []*corev3.HeaderValue{{Key: "foo", Value: "bar"}, {Key: "dog", RawValue: []byte("cat")}},
+		}
+		expHeaders := map[string]string{"foo": "bar", "dog": "cat"}
+		mm := &mockImageGenerationMetrics{}
+		mt := &mockImageGenerationTranslator{t: t, expHeaders: expHeaders}
+		p := &imageGenerationProcessorUpstreamFilter{
+			translator: mt,
+			metrics:    mm,
+		}
+		res, err := p.ProcessResponseHeaders(t.Context(), inHeaders)
+		require.NoError(t, err)
+		commonRes := res.Response.(*extprocv3.ProcessingResponse_ResponseHeaders).ResponseHeaders.Response
+		require.Equal(t, mt.retHeaderMutation, commonRes.HeaderMutation)
+		mm.RequireRequestNotCompleted(t)
+	})
+}
+
+func imageGenerationBodyFromModel(_ *testing.T, model string) []byte {
+	return fmt.Appendf(nil, `{"model":"%s","prompt":"a cat"}`, model)
+}
+
+func Test_imageGenerationProcessorUpstreamFilter_ProcessResponseBody(t *testing.T) {
+	t.Run("error translation", func(t *testing.T) {
+		mm := &mockImageGenerationMetrics{}
+		mt := &mockImageGenerationTranslator{t: t}
+		p := &imageGenerationProcessorUpstreamFilter{
+			translator:      mt,
+			metrics:         mm,
+			responseHeaders: map[string]string{":status": "200"},
+		}
+		mt.retErr = errors.New("test error")
+		_, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{})
+		require.ErrorContains(t, err, "test error")
+		mm.RequireRequestFailure(t)
+		mm.RequireTokensRecorded(t, 0)
+	})
+	t.Run("ok", func(t *testing.T) {
+		inBody := &extprocv3.HttpBody{Body: []byte("some-body"), EndOfStream: true}
+		expBodyMut := &extprocv3.BodyMutation{}
+		expHeadMut := &extprocv3.HeaderMutation{}
+		mm := &mockImageGenerationMetrics{}
+		mt := &mockImageGenerationTranslator{
+			t: t, expResponseBody: inBody,
+			retBodyMutation: expBodyMut, retHeaderMutation: expHeadMut,
+			retUsedToken: translator.LLMTokenUsage{InputTokens: 123, OutputTokens: 100, TotalTokens: 223, ImageCount: 2},
+		}
+
+		celProgInt, err := llmcostcel.NewProgram("54321")
+		require.NoError(t, err)
+		celProgUint, err := llmcostcel.NewProgram("uint(9999)")
+		require.NoError(t, err)
+		p := &imageGenerationProcessorUpstreamFilter{
+			translator: mt,
+			logger:     slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})),
+			metrics:    mm,
+			config: &processorConfig{
+				metadataNamespace: "ai_gateway_llm_ns",
+				requestCosts: []processorConfigRequestCost{
+					{LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeInputToken, MetadataKey: "input_token_usage"}},
+					{LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeTotalToken, MetadataKey: "total_token_usage"}},
+					{LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeImage, MetadataKey: "image_count"}},
+					{
+						celProg:        celProgInt,
+						LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeCEL, MetadataKey: "cel_int"},
+					},
+					{
+						celProg:        celProgUint,
+						LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeCEL, MetadataKey: "cel_uint"},
+					},
+				},
+			},
+			backendName:       "some_backend",
+			modelNameOverride: "some_model",
+			responseHeaders:   map[string]string{":status": "200"},
+		}
+		res, err := p.ProcessResponseBody(t.Context(), inBody)
+		require.NoError(t, err)
+		commonRes := res.Response.(*extprocv3.ProcessingResponse_ResponseBody).ResponseBody.Response
+		require.Equal(t, expBodyMut, commonRes.BodyMutation)
+		require.Equal(t, expHeadMut, commonRes.HeaderMutation)
+		mm.RequireRequestSuccess(t)
+		mm.RequireTokensRecorded(t, 1)
+
+		md := res.DynamicMetadata
+		require.NotNil(t, md)
+		require.Equal(t, float64(123), md.Fields["ai_gateway_llm_ns"].
+			GetStructValue().Fields["input_token_usage"].GetNumberValue())
+		require.Equal(t, float64(223), md.Fields["ai_gateway_llm_ns"].
+			GetStructValue().Fields["total_token_usage"].GetNumberValue())
+		require.Equal(t, float64(2), md.Fields["ai_gateway_llm_ns"].
+			GetStructValue().Fields["image_count"].GetNumberValue())
+		require.Equal(t, float64(54321), md.Fields["ai_gateway_llm_ns"].
+			GetStructValue().Fields["cel_int"].GetNumberValue())
+		require.Equal(t, float64(9999), md.Fields["ai_gateway_llm_ns"].
+			GetStructValue().Fields["cel_uint"].GetNumberValue())
+		require.Equal(t, "some_backend", md.Fields["ai_gateway_llm_ns"].
+			GetStructValue().Fields["backend_name"].GetStringValue())
+		require.Equal(t, "some_model", md.Fields["ai_gateway_llm_ns"].
+			GetStructValue().Fields["model_name_override"].GetStringValue())
+	})
+
+	t.Run("error/streaming", func(t *testing.T) {
+		inBody := &extprocv3.HttpBody{Body: []byte("some-body"), EndOfStream: true}
+		mm := &mockImageGenerationMetrics{}
+		mt := &mockImageGenerationTranslator{t: t, expResponseBody: inBody}
+		p := &imageGenerationProcessorUpstreamFilter{
+			translator:        mt,
+			logger:            slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})),
+			metrics:           mm,
+			config:            &processorConfig{},
+			backendName:       "some_backend",
+			modelNameOverride: "some_model",
+			responseHeaders:   map[string]string{":status": "500"},
+		}
+		res, err := p.ProcessResponseBody(t.Context(), inBody)
+		require.NoError(t, err)
+		commonRes := res.Response.(*extprocv3.ProcessingResponse_ResponseBody).ResponseBody.Response
+		require.NotNil(t, commonRes)
+		require.True(t, mt.responseErrorCalled)
+	})
+}
+
+func Test_imageGenerationProcessorUpstreamFilter_SetBackend(t *testing.T) {
+	headers := map[string]string{":path": "/foo"}
+	mm := &mockImageGenerationMetrics{}
+	p := &imageGenerationProcessorUpstreamFilter{
+		config:         &processorConfig{},
+		requestHeaders: headers,
+		logger:         slog.Default(),
+		metrics:        mm,
+	}
+	err := p.SetBackend(t.Context(), &filterapi.Backend{
+		Name:   "some-backend",
+		Schema: filterapi.VersionedAPISchema{Name: "some-schema", Version: "v10.0"},
+	}, nil, &imageGenerationProcessorRouterFilter{})
+	require.ErrorContains(t, err, "unsupported API schema: backend={some-schema v10.0}")
+	mm.RequireRequestFailure(t)
+	mm.RequireTokensRecorded(t, 0)
+	mm.RequireSelectedBackend(t, "some-backend")
+}
+
+func Test_imageGenerationProcessorUpstreamFilter_ProcessRequestHeaders(t *testing.T) {
+	const modelKey = "x-ai-gateway-model-key"
+	t.Run("translator error", func(t *testing.T) {
+		headers := map[string]string{":path": "/foo", modelKey: "some-model"}
+		someBody := imageGenerationBodyFromModel(t, "some-model")
+		var body openai.ImageGenerationRequest
+		require.NoError(t, json.Unmarshal(someBody, &body))
+		tr := &mockImageGenerationTranslator{t: t, retErr: errors.New("test error"), expRequestBody: &body}
+		mm := &mockImageGenerationMetrics{}
+		p := &imageGenerationProcessorUpstreamFilter{
+			config: &processorConfig{
+				modelNameHeaderKey: modelKey,
+			},
+			requestHeaders:         headers,
+			logger:                 slog.Default(),
+			metrics:                mm,
+			translator:             tr,
+			originalRequestBodyRaw: someBody,
+			originalRequestBody:    &body,
+		}
+		_, err := p.ProcessRequestHeaders(t.Context(), nil)
+		require.ErrorContains(t, err, "failed to transform request: test error")
+		mm.RequireRequestFailure(t)
+		mm.RequireTokensRecorded(t, 0)
+		mm.RequireSelectedModel(t, "some-model")
+	})
+	t.Run("ok", func(t *testing.T) {
+		someBody := imageGenerationBodyFromModel(t, "some-model")
+		headers := map[string]string{":path": "/foo", modelKey: "some-model"}
+		headerMut := &extprocv3.HeaderMutation{
+			SetHeaders: []*corev3.HeaderValueOption{{Header: &corev3.HeaderValue{Key: "foo", RawValue: []byte("bar")}}},
+		}
+		bodyMut := &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: []byte("some body")}}
+
+		var expBody openai.ImageGenerationRequest
+		require.NoError(t, json.Unmarshal(someBody, &expBody))
+		mt := &mockImageGenerationTranslator{t: t, expRequestBody: &expBody, retHeaderMutation: headerMut, retBodyMutation: bodyMut}
+		mm := &mockImageGenerationMetrics{}
+		p := &imageGenerationProcessorUpstreamFilter{
+			config:                 &processorConfig{modelNameHeaderKey: modelKey},
+			requestHeaders:         headers,
+			logger:                 slog.Default(),
+			metrics:                mm,
+			translator:             mt,
+			originalRequestBodyRaw: someBody,
+			originalRequestBody:    &expBody,
+			handler:                &mockBackendAuthHandler{},
+		}
+		resp, err := p.ProcessRequestHeaders(t.Context(), nil)
+		require.NoError(t, err)
+		require.Equal(t, mt, p.translator)
+		require.NotNil(t, resp)
+		commonRes := resp.Response.(*extprocv3.ProcessingResponse_RequestHeaders).RequestHeaders.Response
+		require.Equal(t, headerMut, commonRes.HeaderMutation)
+		require.Equal(t, bodyMut, commonRes.BodyMutation)
+
+		mm.RequireRequestNotCompleted(t)
+		mm.RequireSelectedModel(t, "some-model")
+	})
+}
+
+func TestImageGeneration_ParseBody(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		jsonBody := `{"model":"gpt-image-1","prompt":"a cat","n":2}`
+		modelName, rb, err := parseOpenAIImageGenerationBody(&extprocv3.HttpBody{Body: []byte(jsonBody)})
+		require.NoError(t, err)
+		require.Equal(t, "gpt-image-1", modelName)
+		require.NotNil(t, rb)
+		require.Equal(t, "gpt-image-1", rb.Model)
+		require.Equal(t, "a cat", rb.Prompt)
+		require.Equal(t, 2, *rb.N)
+	})
+	t.Run("error", func(t *testing.T) {
+		modelName, rb, err := parseOpenAIImageGenerationBody(&extprocv3.HttpBody{})
+		require.Error(t, err)
+		require.Empty(t, modelName)
+		require.Nil(t, rb)
+	})
+}
+
+func TestImageGenerationProcessorRouterFilter_ProcessResponseHeaders_ProcessResponseBody(t *testing.T) {
+	t.Run("no ok path with passthrough", func(t *testing.T) {
+		p := &imageGenerationProcessorRouterFilter{}
+		_, err := p.ProcessResponseHeaders(t.Context(), nil)
+		require.NoError(t, err)
+		_, err = p.ProcessResponseBody(t.Context(), nil)
+		require.NoError(t, err)
+	})
+	t.Run("ok path with upstream filter", func(t *testing.T) {
+		p := &imageGenerationProcessorRouterFilter{
+			upstreamFilter: &imageGenerationProcessorUpstreamFilter{
+				translator: &mockImageGenerationTranslator{t: t, expHeaders: map[string]string{}},
+				logger:     slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})),
+				metrics:    &mockImageGenerationMetrics{},
+				config:     &processorConfig{metadataNamespace: ""},
+			},
+		}
+		resp, err := p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{}})
+		require.NoError(t, err)
+		require.NotNil(t, resp)
+
+		resp, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte("some body")})
+		require.NoError(t, err)
+		require.NotNil(t, resp)
+		re, ok := resp.Response.(*extprocv3.ProcessingResponse_ResponseBody)
+		require.True(t, ok)
+		require.NotNil(t, re)
+		require.NotNil(t, re.ResponseBody)
+		require.NotNil(t, re.ResponseBody.Response)
+		require.IsType(t, &extprocv3.BodyMutation{}, re.ResponseBody.Response.BodyMutation)
+		require.IsType(t, &extprocv3.HeaderMutation{}, re.ResponseBody.Response.HeaderMutation)
+	})
+}
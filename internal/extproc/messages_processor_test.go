@@ -0,0 +1,600 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+	"github.com/envoyproxy/ai-gateway/internal/guardrail"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/pii"
+	"github.com/envoyproxy/ai-gateway/internal/quota"
+	"github.com/envoyproxy/ai-gateway/internal/requestpolicy"
+	"github.com/envoyproxy/ai-gateway/internal/toolpolicy"
+	tracing "github.com/envoyproxy/ai-gateway/internal/tracing/api"
+	"github.com/envoyproxy/ai-gateway/internal/virtualkey"
+)
+
+func TestMessages_Schema(t *testing.T) {
+	t.Run("on route", func(t *testing.T) {
+		p, err := MessagesProcessorFactory(nil, nil)(&processorConfig{}, nil, slog.Default(), tracing.NoopTracing{}, false)
+		require.NoError(t, err)
+		require.IsType(t, &messagesProcessorRouterFilter{}, p)
+	})
+	t.Run("on upstream", func(t *testing.T) {
+		p, err := MessagesProcessorFactory(nil, nil)(&processorConfig{}, nil, slog.Default(), tracing.NoopTracing{}, true)
+		require.NoError(t, err)
+		require.IsType(t, &messagesProcessorUpstreamFilter{}, p)
+	})
+}
+
+func Test_messagesProcessorUpstreamFilter_SelectTranslator(t *testing.T) {
+	r := &messagesProcessorUpstreamFilter{}
+	t.Run("unsupported", func(t *testing.T) {
+		err := r.selectTranslator(filterapi.VersionedAPISchema{Name: "Bar", Version: "v123"})
+		require.ErrorContains(t, err, "unsupported API schema: backend={Bar v123}")
+	})
+	for _, s := range []filterapi.APISchemaName{
+		filterapi.APISchemaOpenAI,
+		filterapi.APISchemaAWSBedrock,
+		filterapi.APISchemaGCPAnthropic,
+		filterapi.APISchemaAnthropic,
+	} {
+		t.Run(string(s), func(t *testing.T) {
+			r.translator = nil
+			require.NoError(t, r.selectTranslator(filterapi.VersionedAPISchema{Name: s}))
+			require.NotNil(t, r.translator)
+		})
+	}
+}
+
+func messagesBodyFromModel(_ *testing.T, model string, stream bool) []byte {
+	return fmt.Appendf(nil, `{"model":"%s","max_tokens":10,"messages":[{"role":"user","content":"hello"}],"stream":%v}`, model, stream)
+}
+
+func Test_messagesProcessorRouterFilter_ProcessRequestBody(t *testing.T) {
+	t.Run("body parser error", func(t *testing.T) {
+		p := &messagesProcessorRouterFilter{}
+		_, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte("nonjson")})
+		require.ErrorContains(t, err, "invalid character 'o' in literal null")
+	})
+	t.Run("ok", func(t *testing.T) {
+		headers := map[string]string{":path": "/v1/messages"}
+		const modelKey = "x-ai-gateway-model-key"
+		p := &messagesProcessorRouterFilter{
+			config:         &processorConfig{modelNameHeaderKey: modelKey},
+			requestHeaders: headers,
+			logger:         slog.Default(),
+		}
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: messagesBodyFromModel(t, "some-model", false)})
+		require.NoError(t, err)
+		re, ok := resp.Response.(*extprocv3.ProcessingResponse_RequestBody)
+		require.True(t, ok)
+		require.True(t, re.RequestBody.GetResponse().GetClearRouteCache())
+		setHeaders := re.RequestBody.GetResponse().GetHeaderMutation().SetHeaders
+		require.Len(t, setHeaders, 2)
+		require.Equal(t, modelKey, setHeaders[0].Header.Key)
+		require.Equal(t, "some-model", string(setHeaders[0].Header.RawValue))
+		require.Equal(t, originalPathHeader, setHeaders[1].Header.Key)
+		require.Equal(t, "/v1/messages", string(setHeaders[1].Header.RawValue))
+		require.Equal(t, "some-model", headers[modelKey])
+		require.Equal(t, "hello", *p.originalRequestBody.Messages[0].Content.Text)
+	})
+}
+
+func Test_messagesProcessorUpstreamFilter_ProcessRequestHeaders(t *testing.T) {
+	const modelKey = "x-ai-gateway-model-key"
+	for _, onRetry := range []bool{false, true} {
+		t.Run(fmt.Sprintf("ok/onRetry=%v", onRetry), func(t *testing.T) {
+			someBody := messagesBodyFromModel(t, "some-model", false)
+			headers := map[string]string{":path": "/v1/messages", modelKey: "some-model"}
+			headerMut := &extprocv3.HeaderMutation{
+				SetHeaders: []*corev3.HeaderValueOption{{Header: &corev3.HeaderValue{Key: "foo", RawValue: []byte("bar")}}},
+			}
+			bodyMut := &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: []byte("some body")}}
+			var expBody anthropic.MessagesRequest
+			require.NoError(t, json.Unmarshal(someBody, &expBody))
+			mt := &mockMessagesTranslator{
+				t: t, expRequestBody: &expBody, retHeaderMutation: headerMut, retBodyMutation: bodyMut,
+				expForceRequestBodyMutation: onRetry,
+			}
+			mm := &mockChatCompletionMetrics{}
+			p := &messagesProcessorUpstreamFilter{
+				config:                 &processorConfig{modelNameHeaderKey: modelKey, metadataNamespace: "ns"},
+				requestHeaders:         headers,
+				logger:                 slog.Default(),
+				metrics:                mm,
+				translator:             mt,
+				originalRequestBodyRaw: someBody,
+				originalRequestBody:    &expBody,
+				handler:                &mockBackendAuthHandler{},
+				onRetry:                onRetry,
+			}
+			resp, err := p.ProcessRequestHeaders(t.Context(), nil)
+			require.NoError(t, err)
+			commonRes := resp.Response.(*extprocv3.ProcessingResponse_RequestHeaders).RequestHeaders.Response
+			require.Equal(t, headerMut, commonRes.HeaderMutation)
+			require.Equal(t, bodyMut, commonRes.BodyMutation)
+			require.Equal(t, extprocv3.CommonResponse_CONTINUE_AND_REPLACE, commonRes.Status)
+			require.Equal(t, "bar", headers["foo"])
+			require.Equal(t, float64(len("some body")),
+				resp.DynamicMetadata.Fields["ns"].GetStructValue().Fields["content_length"].GetNumberValue())
+			mm.RequireRequestNotCompleted(t)
+			mm.RequireSelectedModel(t, "some-model")
+		})
+	}
+	t.Run("translator error", func(t *testing.T) {
+		someBody := messagesBodyFromModel(t, "some-model", false)
+		var body anthropic.MessagesRequest
+		require.NoError(t, json.Unmarshal(someBody, &body))
+		mm := &mockChatCompletionMetrics{}
+		p := &messagesProcessorUpstreamFilter{
+			config:              &processorConfig{modelNameHeaderKey: modelKey},
+			requestHeaders:      map[string]string{modelKey: "some-model"},
+			logger:              slog.Default(),
+			metrics:             mm,
+			translator:          &mockMessagesTranslator{t: t, expRequestBody: &body, retErr: errors.New("test error")},
+			originalRequestBody: &body,
+		}
+		_, err := p.ProcessRequestHeaders(t.Context(), nil)
+		require.ErrorContains(t, err, "failed to transform request: test error")
+		mm.RequireRequestFailure(t)
+	})
+}
+
+func Test_messagesProcessorUpstreamFilter_ProcessResponseHeaders(t *testing.T) {
+	t.Run("error translation", func(t *testing.T) {
+		mm := &mockChatCompletionMetrics{}
+		mt := &mockMessagesTranslator{t: t, expHeaders: make(map[string]string), retErr: errors.New("test error")}
+		p := &messagesProcessorUpstreamFilter{translator: mt, metrics: mm}
+		_, err := p.ProcessResponseHeaders(t.Context(), nil)
+		require.ErrorContains(t, err, "test error")
+		mm.RequireRequestFailure(t)
+	})
+	for _, stream := range []bool{false, true} {
+		t.Run(fmt.Sprintf("ok/stream=%v", stream), func(t *testing.T) {
+			inHeaders := &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}}
+			mm := &mockChatCompletionMetrics{}
+			mt := &mockMessagesTranslator{t: t, expHeaders: map[string]string{":status": "200"}}
+			p := &messagesProcessorUpstreamFilter{translator: mt, metrics: mm, stream: stream}
+			res, err := p.ProcessResponseHeaders(t.Context(), inHeaders)
+			require.NoError(t, err)
+			if stream {
+				require.Equal(t, extprocv3http.ProcessingMode_STREAMED, res.ModeOverride.ResponseBodyMode)
+			} else {
+				require.Nil(t, res.ModeOverride)
+			}
+			mm.RequireRequestNotCompleted(t)
+		})
+	}
+}
+
+func Test_messagesProcessorUpstreamFilter_ProcessResponseBody(t *testing.T) {
+	t.Run("error translation", func(t *testing.T) {
+		mm := &mockChatCompletionMetrics{}
+		mt := &mockMessagesTranslator{t: t, retErr: errors.New("test error")}
+		p := &messagesProcessorUpstreamFilter{translator: mt, metrics: mm, responseHeaders: map[string]string{":status": "200"}}
+		_, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{})
+		require.ErrorContains(t, err, "test error")
+		mm.RequireRequestFailure(t)
+		mm.RequireTokensRecorded(t, 0)
+	})
+	t.Run("ok", func(t *testing.T) {
+		inBody := &extprocv3.HttpBody{Body: []byte("some-body"), EndOfStream: true}
+		expBodyMut := &extprocv3.BodyMutation{}
+		expHeadMut := &extprocv3.HeaderMutation{}
+		mm := &mockChatCompletionMetrics{}
+		mt := &mockMessagesTranslator{
+			t: t, expResponseBody: inBody,
+			retBodyMutation: expBodyMut, retHeaderMutation: expHeadMut,
+			retUsedToken: translator.LLMTokenUsage{InputTokens: 1, OutputTokens: 2, TotalTokens: 3},
+		}
+		p := &messagesProcessorUpstreamFilter{
+			translator: mt,
+			logger:     slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})),
+			metrics:    mm,
+			config: &processorConfig{
+				metadataNamespace: "ai_gateway_llm_ns",
+				requestCosts: []processorConfigRequestCost{
+					{LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeInputToken, MetadataKey: "input_token_usage"}},
+					{LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeOutputToken, MetadataKey: "output_token_usage"}},
+					{LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeTotalToken, MetadataKey: "total_token_usage"}},
+				},
+			},
+			backendName:     "some_backend",
+			responseHeaders: map[string]string{":status": "200"},
+			stream:          true,
+		}
+		res, err := p.ProcessResponseBody(t.Context(), inBody)
+		require.NoError(t, err)
+		commonRes := res.Response.(*extprocv3.ProcessingResponse_ResponseBody).ResponseBody.Response
+		require.Equal(t, expBodyMut, commonRes.BodyMutation)
+		require.Equal(t, expHeadMut, commonRes.HeaderMutation)
+		mm.RequireRequestSuccess(t)
+		mm.RequireTokensRecorded(t, 1)
+
+		ns := res.DynamicMetadata.Fields["ai_gateway_llm_ns"].GetStructValue()
+		require.Equal(t, float64(1), ns.Fields["input_token_usage"].GetNumberValue())
+		require.Equal(t, float64(2), ns.Fields["output_token_usage"].GetNumberValue())
+		require.Equal(t, float64(3), ns.Fields["total_token_usage"].GetNumberValue())
+		require.Equal(t, "some_backend", ns.Fields["backend_name"].GetStringValue())
+	})
+	t.Run("error response", func(t *testing.T) {
+		inBody := &extprocv3.HttpBody{Body: []byte("some-body"), EndOfStream: true}
+		mt := &mockMessagesTranslator{t: t}
+		p := &messagesProcessorUpstreamFilter{
+			translator:      mt,
+			metrics:         &mockChatCompletionMetrics{},
+			config:          &processorConfig{},
+			responseHeaders: map[string]string{":status": "500"},
+		}
+		_, err := p.ProcessResponseBody(t.Context(), inBody)
+		require.NoError(t, err)
+		require.True(t, mt.responseErrorCalled)
+	})
+}
+
+func Test_messagesProcessorUpstreamFilter_SetBackend(t *testing.T) {
+	t.Run("unsupported", func(t *testing.T) {
+		mm := &mockChatCompletionMetrics{}
+		p := &messagesProcessorUpstreamFilter{
+			config:         &processorConfig{},
+			requestHeaders: map[string]string{},
+			logger:         slog.Default(),
+			metrics:        mm,
+		}
+		err := p.SetBackend(t.Context(), &filterapi.Backend{
+			Name:   "some-backend",
+			Schema: filterapi.VersionedAPISchema{Name: "some-schema", Version: "v10.0"},
+		}, nil, &messagesProcessorRouterFilter{})
+		require.ErrorContains(t, err, "unsupported API schema: backend={some-schema v10.0}")
+		mm.RequireRequestFailure(t)
+		mm.RequireSelectedBackend(t, "some-backend")
+	})
+	t.Run("ok", func(t *testing.T) {
+		mm := &mockChatCompletionMetrics{}
+		p := &messagesProcessorUpstreamFilter{
+			config:         &processorConfig{},
+			requestHeaders: map[string]string{},
+			logger:         slog.Default(),
+			metrics:        mm,
+		}
+		rp := &messagesProcessorRouterFilter{originalRequestBody: &anthropic.MessagesRequest{Stream: true}, upstreamFilterCount: 1}
+		err := p.SetBackend(t.Context(), &filterapi.Backend{
+			Name:              "some-backend",
+			ModelNameOverride: "override",
+			Schema:            filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock},
+		}, nil, rp)
+		require.NoError(t, err)
+		require.True(t, p.stream)
+		require.True(t, p.onRetry)
+		require.Equal(t, "override", p.modelNameOverride)
+		require.Equal(t, p, rp.upstreamFilter)
+	})
+}
+
+func TestMessagesProcessorRouterFilter_ProcessResponseHeaders_ProcessResponseBody(t *testing.T) {
+	p := &messagesProcessorRouterFilter{}
+	_, err := p.ProcessResponseHeaders(t.Context(), nil)
+	require.NoError(t, err)
+	_, err = p.ProcessResponseBody(t.Context(), nil)
+	require.NoError(t, err)
+}
+
+func Test_messagesProcessor_Guardrail(t *testing.T) {
+	var decide func(check *guardrail.Check) *guardrail.Decision
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var check guardrail.Check
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&check))
+		require.Equal(t, guardrail.APIMessages, check.API)
+		require.Equal(t, "claude", check.Model)
+		_ = json.NewEncoder(w).Encode(decide(&check))
+	}))
+	defer service.Close()
+	s, err := guardrail.NewSet([]filterapi.Guardrail{{
+		Name: "ns/route", Matches: []filterapi.RouteRuleMatch{{Model: "claude"}}, Endpoint: service.URL, Request: true, Response: true,
+	}}, nil)
+	require.NoError(t, err)
+	defer func() { _ = s.CloseExcept(nil) }()
+	config := &processorConfig{modelNameHeaderKey: "x-ai-gateway-model-key", guardrails: s}
+	newRouter := func() *messagesProcessorRouterFilter {
+		return &messagesProcessorRouterFilter{
+			config: config, requestHeaders: map[string]string{":path": "/v1/messages"}, logger: slog.Default(),
+		}
+	}
+
+	t.Run("block request", func(t *testing.T) {
+		decide = func(*guardrail.Check) *guardrail.Decision {
+			return &guardrail.Decision{Action: guardrail.ActionBlock, Status: http.StatusForbidden}
+		}
+		p := newRouter()
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: messagesBodyFromModel(t, "claude", false)})
+		require.NoError(t, err)
+		ir := resp.GetImmediateResponse()
+		require.NotNil(t, ir)
+		require.Equal(t, http.StatusForbidden, int(ir.Status.Code))
+		require.Equal(t, guardrail.ActionBlock, p.guardrailDecision.Action)
+		// The error is in the format of the Anthropic API.
+		require.JSONEq(t, `{"type":"error","error":{"type":"guardrail_blocked","message":"the request is blocked by the guardrail"}}`, string(ir.Body))
+	})
+
+	t.Run("mutate streaming response", func(t *testing.T) {
+		decide = func(check *guardrail.Check) *guardrail.Decision {
+			if check.Phase == guardrail.PhaseRequest {
+				return &guardrail.Decision{Action: guardrail.ActionAllow}
+			}
+			require.JSONEq(t, `{"id":"msg_1","type":"message","role":"assistant","model":"claude","content":[{"type":"text","text":"secret"}],`+
+				`"stop_reason":"end_turn","stop_sequence":null,"usage":{"input_tokens":1,"output_tokens":2}}`, string(check.Response))
+			return &guardrail.Decision{Action: guardrail.ActionMutate, Response: []byte(`{"id":"msg_1","type":"message","role":"assistant",` +
+				`"model":"claude","content":[{"type":"text","text":"[redacted]"}],"stop_reason":"end_turn","stop_sequence":null,"usage":{"input_tokens":1,"output_tokens":2}}`)}
+		}
+		p := newRouter()
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: messagesBodyFromModel(t, "claude", true)})
+		require.NoError(t, err)
+		require.Nil(t, resp.GetImmediateResponse())
+		upstream := &messagesProcessorUpstreamFilter{
+			config: config, requestHeaders: p.requestHeaders, logger: slog.Default(), metrics: &mockChatCompletionMetrics{},
+		}
+		require.NoError(t, upstream.SetBackend(t.Context(), &filterapi.Backend{
+			Name: "anthropic", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAnthropic},
+		}, nil, p))
+		_, err = upstream.ProcessRequestHeaders(t.Context(), nil)
+		require.NoError(t, err)
+		_, err = p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}})
+		require.NoError(t, err)
+
+		var out []byte
+		for i, chunk := range []string{
+			"event: message_start\n" + `data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],` +
+				`"model":"claude","stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":1,"output_tokens":0}}}` + "\n\n" +
+				"event: content_block_start\n" + `data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}` + "\n\n",
+			"event: content_block_delta\n" + `data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"secret"}}` + "\n\n" +
+				"event: content_block_stop\n" + `data: {"type":"content_block_stop","index":0}` + "\n\n" +
+				"event: message_delta\n" + `data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":2}}` + "\n\n" +
+				"event: message_stop\n" + `data: {"type":"message_stop"}` + "\n\n",
+		} {
+			resp, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte(chunk), EndOfStream: i == 1})
+			require.NoError(t, err)
+			body := resp.GetResponseBody().GetResponse().GetBodyMutation().GetBody()
+			if i == 0 {
+				require.Empty(t, body, "the response must be held back")
+			}
+			out = append(out, body...)
+		}
+		require.Contains(t, string(out), `"text":"[redacted]"`)
+		require.NotContains(t, string(out), "secret")
+	})
+}
+
+func Test_messagesProcessor_ToolPolicy(t *testing.T) {
+	config := &processorConfig{modelNameHeaderKey: "x-ai-gateway-model-key", toolPolicies: toolpolicy.NewSet([]filterapi.ToolPolicy{{
+		Name: "ns/route", Matches: []filterapi.RouteRuleMatch{{Model: "claude"}}, AllowedFunctions: []string{"get_weather"},
+		Action: filterapi.ToolPolicyActionBlock,
+	}})}
+	newRouter := func() *messagesProcessorRouterFilter {
+		return &messagesProcessorRouterFilter{
+			config: config, requestHeaders: map[string]string{":path": "/v1/messages"}, logger: slog.Default(),
+		}
+	}
+
+	t.Run("not allowed", func(t *testing.T) {
+		resp, err := newRouter().ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(
+			`{"model":"claude","max_tokens":10,"messages":[{"role":"user","content":"hi"}],"tools":[{"name":"rm","input_schema":{"type":"object"}}]}`)})
+		require.NoError(t, err)
+		ir := resp.GetImmediateResponse()
+		require.NotNil(t, ir)
+		require.Equal(t, http.StatusBadRequest, int(ir.Status.Code))
+		// The error is in the format of the Anthropic API.
+		require.JSONEq(t, `{"type":"error","error":{"type":"tool_not_allowed","message":"the request declares the tools not allowed by the route: rm"}}`, string(ir.Body))
+	})
+
+	t.Run("block streaming response", func(t *testing.T) {
+		p := newRouter()
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(
+			`{"model":"claude","max_tokens":10,"stream":true,"messages":[{"role":"user","content":"hi"}],"tools":[{"name":"get_weather"}]}`)})
+		require.NoError(t, err)
+		require.Nil(t, resp.GetImmediateResponse())
+		upstream := &messagesProcessorUpstreamFilter{
+			config: config, requestHeaders: p.requestHeaders, logger: slog.Default(), metrics: &mockChatCompletionMetrics{},
+		}
+		require.NoError(t, upstream.SetBackend(t.Context(), &filterapi.Backend{
+			Name: "anthropic", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAnthropic},
+		}, nil, p))
+		_, err = upstream.ProcessRequestHeaders(t.Context(), nil)
+		require.NoError(t, err)
+		_, err = p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}})
+		require.NoError(t, err)
+
+		const text = "event: message_start\n" + `data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],` +
+			`"model":"claude","stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":1,"output_tokens":0}}}` + "\n\n" +
+			"event: content_block_start\n" + `data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}` + "\n\n" +
+			"event: content_block_stop\n" + `data: {"type":"content_block_stop","index":0}` + "\n\n"
+		var out []byte
+		for i, chunk := range []string{
+			text,
+			"event: content_block_start\n" + `data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"tu_1","name":"rm","input":{}}}` + "\n\n" +
+				"event: content_block_stop\n" + `data: {"type":"content_block_stop","index":1}` + "\n\n",
+			"event: message_delta\n" + `data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":2}}` + "\n\n" +
+				"event: message_stop\n" + `data: {"type":"message_stop"}` + "\n\n",
+		} {
+			resp, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte(chunk), EndOfStream: i == 2})
+			require.NoError(t, err)
+			body := resp.GetResponseBody().GetResponse().GetBodyMutation().GetBody()
+			if i == 0 {
+				// The events are released until the first tool use.
+				require.Equal(t, text, string(body))
+			}
+			out = append(out, body...)
+		}
+		require.Equal(t, text+"event: error\n"+`data: {"type":"error","error":{"type":"tool_call_violation",`+
+			`"message":"the response contains the tool calls violating the tool policy of the route: rm: the function is not allowed"}}`+"\n\n", string(out))
+	})
+}
+
+func Test_messagesProcessor_VirtualKey(t *testing.T) {
+	limiter, err := quota.New([]filterapi.QuotaPolicy{{
+		Name:            "ns/keys/key/a",
+		ClientKeyHeader: internalapi.VirtualKeyHeaderKey,
+		ClientKeys:      []string{"ns/keys/a"},
+		Budgets:         []filterapi.QuotaBudget{{Type: filterapi.QuotaBudgetTypeTotalToken, Limit: 20, Window: time.Hour}},
+	}})
+	require.NoError(t, err)
+	config := &processorConfig{
+		modelNameHeaderKey: "x-ai-gateway-model-key",
+		quota:              limiter,
+		virtualKeys: virtualkey.NewSet([]filterapi.VirtualKeyPolicy{{
+			Name:            "ns/keys",
+			Models:          []string{"claude"},
+			ClientKeyHeader: "authorization",
+			TeamIDHeader:    "x-ai-eg-team-id",
+			Keys:            []filterapi.VirtualKey{{Name: "ns/keys/a", Hash: virtualkey.Hash("sk-a"), TeamID: "team-a"}},
+		}}),
+	}
+	process := func() (*messagesProcessorRouterFilter, *extprocv3.ProcessingResponse) {
+		// The Anthropic clients send the key in the x-api-key header.
+		p := &messagesProcessorRouterFilter{
+			config: config, requestHeaders: map[string]string{":path": "/v1/messages", "x-api-key": "sk-a"}, logger: slog.Default(),
+		}
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(
+			`{"model":"claude","max_tokens":10,"messages":[{"role":"user","content":"hi"}]}`)})
+		require.NoError(t, err)
+		return p, resp
+	}
+
+	p, resp := process()
+	require.Nil(t, resp.GetImmediateResponse())
+	require.Contains(t, resp.GetRequestBody().GetResponse().GetHeaderMutation().GetRemoveHeaders(), "x-api-key")
+	require.NotContains(t, p.requestHeaders, "x-api-key")
+	require.NotNil(t, p.quotaReservation)
+
+	upstream := &messagesProcessorUpstreamFilter{
+		config: config, requestHeaders: p.requestHeaders, logger: slog.Default(), metrics: &mockChatCompletionMetrics{},
+	}
+	require.NoError(t, upstream.SetBackend(t.Context(), &filterapi.Backend{
+		Name: "anthropic", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAnthropic},
+	}, nil, p))
+	_, err = upstream.ProcessRequestHeaders(t.Context(), nil)
+	require.NoError(t, err)
+	_, err = p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}})
+	require.NoError(t, err)
+	_, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte(`{"id":"msg_1","type":"message","role":"assistant",` +
+		`"content":[{"type":"text","text":"hello"}],"model":"claude","stop_reason":"end_turn","usage":{"input_tokens":10,"output_tokens":15}}`), EndOfStream: true})
+	require.NoError(t, err)
+	require.Nil(t, p.quotaReservation)
+
+	// The actual usage exhausted the budget.
+	_, resp = process()
+	ir := resp.GetImmediateResponse()
+	require.NotNil(t, ir)
+	require.Equal(t, http.StatusTooManyRequests, int(ir.Status.Code))
+	require.JSONEq(t, `{"type":"error","error":{"type":"tokens","message":"quota ns/keys/key/a exceeded: TotalToken budget of 20 tokens per 1h0m0s"}}`, string(ir.Body))
+}
+
+func Test_messagesProcessor_PIIRedaction(t *testing.T) {
+	newConfig := func(t *testing.T, action filterapi.PIIRedactionAction) *processorConfig {
+		s, err := pii.NewSet([]filterapi.PIIRedaction{{
+			Name:      "ns/route",
+			Matches:   []filterapi.RouteRuleMatch{{Model: "claude"}},
+			Action:    action,
+			Detectors: []filterapi.PIIDetector{{Name: "email", Type: filterapi.PIIDetectorTypeEmail}},
+		}})
+		require.NoError(t, err)
+		return &processorConfig{modelNameHeaderKey: "x-ai-gateway-model-key", piiRedactions: s}
+	}
+	newRouter := func(config *processorConfig) *messagesProcessorRouterFilter {
+		return &messagesProcessorRouterFilter{
+			config: config, requestHeaders: map[string]string{":path": "/v1/messages"}, logger: slog.Default(),
+		}
+	}
+	const body = `{"model":"claude","max_tokens":100,"messages":[{"role":"user","content":"mail me at alice@example.com"}]}`
+
+	t.Run("reject", func(t *testing.T) {
+		p := newRouter(newConfig(t, filterapi.PIIRedactionActionReject))
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(body)})
+		require.NoError(t, err)
+		ir := resp.GetImmediateResponse()
+		require.NotNil(t, ir)
+		require.Equal(t, http.StatusBadRequest, int(ir.Status.Code))
+		// The error is in the format of the Anthropic API.
+		require.JSONEq(t, `{"type":"error","error":{"type":"pii_detected","message":"the request contains personally identifiable information detected by email"}}`, string(ir.Body))
+	})
+
+	t.Run("mask", func(t *testing.T) {
+		config := newConfig(t, filterapi.PIIRedactionActionMask)
+		p := newRouter(config)
+		_, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(body)})
+		require.NoError(t, err)
+		upstream := &messagesProcessorUpstreamFilter{
+			config: config, requestHeaders: p.requestHeaders, logger: slog.Default(), metrics: &mockChatCompletionMetrics{},
+		}
+		require.NoError(t, upstream.SetBackend(t.Context(), &filterapi.Backend{
+			Name: "anthropic", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAnthropic},
+		}, nil, p))
+
+		// The masked body is sent to the backend.
+		resp, err := upstream.ProcessRequestHeaders(t.Context(), nil)
+		require.NoError(t, err)
+		sent := string(resp.GetRequestHeaders().GetResponse().GetBodyMutation().GetBody())
+		require.Contains(t, sent, "mail me at [EMAIL_1]")
+		require.NotContains(t, sent, "alice@example.com")
+
+		// The masked value is restored in the response.
+		_, err = upstream.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}})
+		require.NoError(t, err)
+		resp, err = upstream.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{
+			Body: []byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude","content":[{"type":"text","text":"Sent to [EMAIL_1]."}],` +
+				`"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":2}}`),
+			EndOfStream: true,
+		})
+		require.NoError(t, err)
+		require.Contains(t, string(resp.GetResponseBody().GetResponse().GetBodyMutation().GetBody()), `"text":"Sent to alice@example.com."`)
+	})
+}
+
+func Test_messagesProcessor_RequestPolicy(t *testing.T) {
+	config := &processorConfig{
+		modelNameHeaderKey: "x-ai-gateway-model-key",
+		requestPolicies: requestpolicy.NewSet([]filterapi.RequestPolicy{{
+			Name:      "ns/route/rule/0",
+			Matches:   []filterapi.RouteRuleMatch{{Model: "claude"}},
+			MaxTokens: &filterapi.RequestPolicyLimit{Max: 4096},
+		}}),
+	}
+	newRouter := func() *messagesProcessorRouterFilter {
+		return &messagesProcessorRouterFilter{
+			config: config, requestHeaders: map[string]string{":path": "/v1/messages"}, logger: slog.Default(),
+		}
+	}
+
+	// The request policy only supports the chat completions, so the requests it applies to are rejected.
+	resp, err := newRouter().ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: messagesBodyFromModel(t, "claude", false)})
+	require.NoError(t, err)
+	ir := resp.GetImmediateResponse()
+	require.NotNil(t, ir)
+	require.Equal(t, http.StatusBadRequest, int(ir.Status.Code))
+	require.JSONEq(t, `{"type":"error","error":{"type":"invalid_request_error","message":"the request policy of the route only supports the chat completions API"}}`, string(ir.Body))
+
+	// The requests of the other rules are not affected.
+	resp, err = newRouter().ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: messagesBodyFromModel(t, "claude-haiku", false)})
+	require.NoError(t, err)
+	require.Nil(t, resp.GetImmediateResponse())
+}
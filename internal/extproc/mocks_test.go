@@ -7,6 +7,7 @@ package extproc
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"log/slog"
 	"testing"
@@ -19,6 +20,7 @@ import (
 	"google.golang.org/grpc/metadata"
 
 	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
 	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
 	"github.com/envoyproxy/ai-gateway/internal/extproc/backendauth"
 	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
@@ -26,9 +28,14 @@ import (
 )
 
 var (
-	_ Processor                                 = &mockProcessor{}
-	_ translator.OpenAIChatCompletionTranslator = &mockTranslator{}
-	_ translator.OpenAIEmbeddingTranslator      = &mockEmbeddingTranslator{}
+	_ Processor                                     = &mockProcessor{}
+	_ translator.OpenAIChatCompletionTranslator     = &mockTranslator{}
+	_ translator.OpenAIEmbeddingTranslator          = &mockEmbeddingTranslator{}
+	_ translator.AnthropicMessagesTranslator        = &mockMessagesTranslator{}
+	_ translator.OpenAIImageGenerationTranslator    = &mockImageGenerationTranslator{}
+	_ translator.OpenAIAudioTranscriptionTranslator = &mockAudioTranscriptionTranslator{}
+	_ translator.OpenAIAudioSpeechTranslator        = &mockAudioSpeechTranslator{}
+	_ translator.OpenAICompletionTranslator         = &mockCompletionTranslator{}
 )
 
 func newMockProcessor(_ *processorConfig, _ *slog.Logger) Processor {
@@ -170,6 +177,7 @@ type mockChatCompletionMetrics struct {
 	requestErrorCount   int
 	tokenUsageCount     int
 	tokenLatencyCount   int
+	costUSD             float64
 	timeToFirstToken    float64
 	interTokenLatency   float64
 }
@@ -188,6 +196,11 @@ func (m *mockChatCompletionMetrics) RecordTokenUsage(_ context.Context, _, _, _
 	m.tokenUsageCount++
 }
 
+// RecordCost implements [metrics.ChatCompletion].
+func (m *mockChatCompletionMetrics) RecordCost(_ context.Context, costUSD float64, _ map[string]string, _ ...attribute.KeyValue) {
+	m.costUSD += costUSD
+}
+
 // RecordTokenLatency implements [metrics.ChatCompletion].
 func (m *mockChatCompletionMetrics) RecordTokenLatency(_ context.Context, _ uint32, _ map[string]string, _ ...attribute.KeyValue) {
 	m.tokenLatencyCount++
@@ -250,6 +263,73 @@ func (m *mockChatCompletionMetrics) RequireTokensRecorded(t *testing.T, count in
 
 var _ metrics.ChatCompletionMetrics = &mockChatCompletionMetrics{}
 
+// mockResponseCacheMetrics implements [metrics.ResponseCacheMetrics] for testing.
+type mockResponseCacheMetrics struct {
+	hits           int
+	misses         int
+	semanticHits   int
+	semanticMisses int
+}
+
+// RecordLookup implements [metrics.ResponseCacheMetrics].
+func (m *mockResponseCacheMetrics) RecordLookup(_ context.Context, hit bool, _ string, _ map[string]string) {
+	if hit {
+		m.hits++
+	} else {
+		m.misses++
+	}
+}
+
+// RecordSemanticLookup implements [metrics.ResponseCacheMetrics].
+func (m *mockResponseCacheMetrics) RecordSemanticLookup(_ context.Context, hit bool, _ string, _ map[string]string) {
+	if hit {
+		m.semanticHits++
+	} else {
+		m.semanticMisses++
+	}
+}
+
+var _ metrics.ResponseCacheMetrics = &mockResponseCacheMetrics{}
+
+// mockBackendAttemptMetrics implements [metrics.BackendAttemptMetrics] for testing.
+type mockBackendAttemptMetrics struct {
+	// attempts are the recorded attempts in the form of "<backend>/<error type>/<retried>".
+	attempts []string
+}
+
+// RecordAttempt implements [metrics.BackendAttemptMetrics].
+func (m *mockBackendAttemptMetrics) RecordAttempt(_ context.Context, backend *filterapi.Backend, _ string, errorType string, retried bool, _ map[string]string) {
+	m.attempts = append(m.attempts, fmt.Sprintf("%s/%s/%v", backend.Name, errorType, retried))
+}
+
+var _ metrics.BackendAttemptMetrics = &mockBackendAttemptMetrics{}
+
+// mockAdmissionMetrics implements [metrics.AdmissionMetrics] for testing.
+type mockAdmissionMetrics struct {
+	// admissions are the recorded admissions in the form of "<backend>/<priority>/<admitted>".
+	admissions []string
+}
+
+// RecordAdmission implements [metrics.AdmissionMetrics].
+func (m *mockAdmissionMetrics) RecordAdmission(_ context.Context, backend string, priority string, admitted bool, _ map[string]string) {
+	m.admissions = append(m.admissions, fmt.Sprintf("%s/%s/%v", backend, priority, admitted))
+}
+
+var _ metrics.AdmissionMetrics = &mockAdmissionMetrics{}
+
+// mockPIIMetrics implements [metrics.PIIMetrics] for testing.
+type mockPIIMetrics struct {
+	// detections are the recorded detections in the form of "<detector>/<action>/<count>".
+	detections []string
+}
+
+// RecordDetections implements [metrics.PIIMetrics].
+func (m *mockPIIMetrics) RecordDetections(_ context.Context, detector string, action string, count int, _ map[string]string) {
+	m.detections = append(m.detections, fmt.Sprintf("%s/%s/%d", detector, action, count))
+}
+
+var _ metrics.PIIMetrics = &mockPIIMetrics{}
+
 // mockEmbeddingTranslator implements [translator.OpenAIEmbeddingTranslator] for testing.
 type mockEmbeddingTranslator struct {
 	t                   *testing.T
@@ -359,6 +439,265 @@ func (m *mockEmbeddingsMetrics) RequireTokensRecorded(t *testing.T, count int) {
 
 var _ metrics.EmbeddingsMetrics = &mockEmbeddingsMetrics{}
 
+// mockImageGenerationTranslator implements [translator.OpenAIImageGenerationTranslator] for testing.
+type mockImageGenerationTranslator struct {
+	t                   *testing.T
+	expHeaders          map[string]string
+	expRequestBody      *openai.ImageGenerationRequest
+	expResponseBody     *extprocv3.HttpBody
+	retHeaderMutation   *extprocv3.HeaderMutation
+	retBodyMutation     *extprocv3.BodyMutation
+	retUsedToken        translator.LLMTokenUsage
+	responseErrorCalled bool
+	retErr              error
+}
+
+// RequestBody implements [translator.OpenAIImageGenerationTranslator].
+func (m *mockImageGenerationTranslator) RequestBody(_ []byte, body *openai.ImageGenerationRequest, _ bool) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error) {
+	require.Equal(m.t, m.expRequestBody, body)
+	return m.retHeaderMutation, m.retBodyMutation, m.retErr
+}
+
+// ResponseHeaders implements [translator.OpenAIImageGenerationTranslator].
+func (m *mockImageGenerationTranslator) ResponseHeaders(headers map[string]string) (headerMutation *extprocv3.HeaderMutation, err error) {
+	require.Equal(m.t, m.expHeaders, headers)
+	return m.retHeaderMutation, m.retErr
+}
+
+// ResponseBody implements [translator.OpenAIImageGenerationTranslator].
+func (m *mockImageGenerationTranslator) ResponseBody(_ map[string]string, body io.Reader, _ bool) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage translator.LLMTokenUsage, err error) {
+	if m.expResponseBody != nil {
+		buf, err := io.ReadAll(body)
+		require.NoError(m.t, err)
+		require.Equal(m.t, m.expResponseBody.Body, buf)
+	}
+	return m.retHeaderMutation, m.retBodyMutation, m.retUsedToken, m.retErr
+}
+
+// ResponseError implements [translator.OpenAIImageGenerationTranslator].
+func (m *mockImageGenerationTranslator) ResponseError(map[string]string, io.Reader) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error) {
+	m.responseErrorCalled = true
+	return nil, nil, nil
+}
+
+// mockImageGenerationMetrics implements [metrics.ImageGenerationMetrics] for testing.
+type mockImageGenerationMetrics struct {
+	requestStart        time.Time
+	model               string
+	backend             string
+	requestSuccessCount int
+	requestErrorCount   int
+	tokenUsageCount     int
+}
+
+// StartRequest implements [metrics.ImageGenerationMetrics].
+func (m *mockImageGenerationMetrics) StartRequest(_ map[string]string) { m.requestStart = time.Now() }
+
+// SetModel implements [metrics.ImageGenerationMetrics].
+func (m *mockImageGenerationMetrics) SetModel(model string) { m.model = model }
+
+// SetBackend implements [metrics.ImageGenerationMetrics].
+func (m *mockImageGenerationMetrics) SetBackend(backend *filterapi.Backend) { m.backend = backend.Name }
+
+// RecordTokenUsage implements [metrics.ImageGenerationMetrics].
+func (m *mockImageGenerationMetrics) RecordTokenUsage(_ context.Context, _, _, _ uint32, _ map[string]string, _ ...attribute.KeyValue) {
+	m.tokenUsageCount++
+}
+
+// RecordRequestCompletion implements [metrics.ImageGenerationMetrics].
+func (m *mockImageGenerationMetrics) RecordRequestCompletion(_ context.Context, success bool, _ map[string]string, _ ...attribute.KeyValue) {
+	if success {
+		m.requestSuccessCount++
+	} else {
+		m.requestErrorCount++
+	}
+}
+
+// RequireSelectedModel asserts the model set on the metrics.
+func (m *mockImageGenerationMetrics) RequireSelectedModel(t *testing.T, model string) {
+	require.Equal(t, model, m.model)
+}
+
+// RequireSelectedBackend asserts the backend set on the metrics.
+func (m *mockImageGenerationMetrics) RequireSelectedBackend(t *testing.T, backend string) {
+	require.Equal(t, backend, m.backend)
+}
+
+// RequireRequestFailure asserts the request was marked as a failure.
+func (m *mockImageGenerationMetrics) RequireRequestFailure(t *testing.T) {
+	require.Equal(t, 0, m.requestSuccessCount)
+	require.Equal(t, 1, m.requestErrorCount)
+}
+
+// RequireRequestNotCompleted asserts the request was not completed.
+func (m *mockImageGenerationMetrics) RequireRequestNotCompleted(t *testing.T) {
+	require.Equal(t, 0, m.requestSuccessCount)
+	require.Equal(t, 0, m.requestErrorCount)
+}
+
+// RequireRequestSuccess asserts the request was marked as a success.
+func (m *mockImageGenerationMetrics) RequireRequestSuccess(t *testing.T) {
+	require.Equal(t, 1, m.requestSuccessCount)
+	require.Equal(t, 0, m.requestErrorCount)
+}
+
+// RequireTokensRecorded asserts the number of tokens recorded.
+func (m *mockImageGenerationMetrics) RequireTokensRecorded(t *testing.T, count int) {
+	require.Equal(t, count, m.tokenUsageCount)
+}
+
+var _ metrics.ImageGenerationMetrics = &mockImageGenerationMetrics{}
+
+// mockAudioTranscriptionTranslator implements [translator.OpenAIAudioTranscriptionTranslator] for testing.
+type mockAudioTranscriptionTranslator struct {
+	t                   *testing.T
+	expHeaders          map[string]string
+	expRequestBody      *openai.AudioTranscriptionRequest
+	expResponseBody     *extprocv3.HttpBody
+	retHeaderMutation   *extprocv3.HeaderMutation
+	retBodyMutation     *extprocv3.BodyMutation
+	retUsedToken        translator.LLMTokenUsage
+	responseErrorCalled bool
+	retErr              error
+}
+
+// RequestBody implements [translator.OpenAIAudioTranscriptionTranslator].
+func (m *mockAudioTranscriptionTranslator) RequestBody(_ []byte, _ string, body *openai.AudioTranscriptionRequest, _ bool) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error) {
+	require.Equal(m.t, m.expRequestBody, body)
+	return m.retHeaderMutation, m.retBodyMutation, m.retErr
+}
+
+// ResponseHeaders implements [translator.OpenAIAudioTranscriptionTranslator].
+func (m *mockAudioTranscriptionTranslator) ResponseHeaders(headers map[string]string) (headerMutation *extprocv3.HeaderMutation, err error) {
+	require.Equal(m.t, m.expHeaders, headers)
+	return m.retHeaderMutation, m.retErr
+}
+
+// ResponseBody implements [translator.OpenAIAudioTranscriptionTranslator].
+func (m *mockAudioTranscriptionTranslator) ResponseBody(_ map[string]string, body io.Reader, _ bool) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage translator.LLMTokenUsage, err error) {
+	if m.expResponseBody != nil {
+		buf, err := io.ReadAll(body)
+		require.NoError(m.t, err)
+		require.Equal(m.t, m.expResponseBody.Body, buf)
+	}
+	return m.retHeaderMutation, m.retBodyMutation, m.retUsedToken, m.retErr
+}
+
+// ResponseError implements [translator.OpenAIAudioTranscriptionTranslator].
+func (m *mockAudioTranscriptionTranslator) ResponseError(map[string]string, io.Reader) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error) {
+	m.responseErrorCalled = true
+	return nil, nil, nil
+}
+
+// mockAudioSpeechTranslator implements [translator.OpenAIAudioSpeechTranslator] for testing.
+type mockAudioSpeechTranslator struct {
+	t                   *testing.T
+	expHeaders          map[string]string
+	expRequestBody      *openai.AudioSpeechRequest
+	expResponseBody     *extprocv3.HttpBody
+	retHeaderMutation   *extprocv3.HeaderMutation
+	retBodyMutation     *extprocv3.BodyMutation
+	retUsedToken        translator.LLMTokenUsage
+	responseErrorCalled bool
+	retErr              error
+}
+
+// RequestBody implements [translator.OpenAIAudioSpeechTranslator].
+func (m *mockAudioSpeechTranslator) RequestBody(_ []byte, body *openai.AudioSpeechRequest, _ bool) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error) {
+	require.Equal(m.t, m.expRequestBody, body)
+	return m.retHeaderMutation, m.retBodyMutation, m.retErr
+}
+
+// ResponseHeaders implements [translator.OpenAIAudioSpeechTranslator].
+func (m *mockAudioSpeechTranslator) ResponseHeaders(headers map[string]string) (headerMutation *extprocv3.HeaderMutation, err error) {
+	require.Equal(m.t, m.expHeaders, headers)
+	return m.retHeaderMutation, m.retErr
+}
+
+// ResponseBody implements [translator.OpenAIAudioSpeechTranslator].
+func (m *mockAudioSpeechTranslator) ResponseBody(_ map[string]string, body io.Reader, _ bool) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage translator.LLMTokenUsage, err error) {
+	if m.expResponseBody != nil {
+		buf, err := io.ReadAll(body)
+		require.NoError(m.t, err)
+		require.Equal(m.t, m.expResponseBody.Body, buf)
+	}
+	return m.retHeaderMutation, m.retBodyMutation, m.retUsedToken, m.retErr
+}
+
+// ResponseError implements [translator.OpenAIAudioSpeechTranslator].
+func (m *mockAudioSpeechTranslator) ResponseError(map[string]string, io.Reader) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error) {
+	m.responseErrorCalled = true
+	return nil, nil, nil
+}
+
+// mockAudioMetrics implements [metrics.AudioMetrics] for testing.
+type mockAudioMetrics struct {
+	requestStart        time.Time
+	model               string
+	backend             string
+	requestSuccessCount int
+	requestErrorCount   int
+	tokenUsageCount     int
+}
+
+// StartRequest implements [metrics.AudioMetrics].
+func (m *mockAudioMetrics) StartRequest(_ map[string]string) { m.requestStart = time.Now() }
+
+// SetModel implements [metrics.AudioMetrics].
+func (m *mockAudioMetrics) SetModel(model string) { m.model = model }
+
+// SetBackend implements [metrics.AudioMetrics].
+func (m *mockAudioMetrics) SetBackend(backend *filterapi.Backend) { m.backend = backend.Name }
+
+// RecordTokenUsage implements [metrics.AudioMetrics].
+func (m *mockAudioMetrics) RecordTokenUsage(_ context.Context, _, _, _ uint32, _ map[string]string, _ ...attribute.KeyValue) {
+	m.tokenUsageCount++
+}
+
+// RecordRequestCompletion implements [metrics.AudioMetrics].
+func (m *mockAudioMetrics) RecordRequestCompletion(_ context.Context, success bool, _ map[string]string, _ ...attribute.KeyValue) {
+	if success {
+		m.requestSuccessCount++
+	} else {
+		m.requestErrorCount++
+	}
+}
+
+// RequireSelectedModel asserts the model set on the metrics.
+func (m *mockAudioMetrics) RequireSelectedModel(t *testing.T, model string) {
+	require.Equal(t, model, m.model)
+}
+
+// RequireSelectedBackend asserts the backend set on the metrics.
+func (m *mockAudioMetrics) RequireSelectedBackend(t *testing.T, backend string) {
+	require.Equal(t, backend, m.backend)
+}
+
+// RequireRequestFailure asserts the request was marked as a failure.
+func (m *mockAudioMetrics) RequireRequestFailure(t *testing.T) {
+	require.Equal(t, 0, m.requestSuccessCount)
+	require.Equal(t, 1, m.requestErrorCount)
+}
+
+// RequireRequestNotCompleted asserts the request was not completed.
+func (m *mockAudioMetrics) RequireRequestNotCompleted(t *testing.T) {
+	require.Equal(t, 0, m.requestSuccessCount)
+	require.Equal(t, 0, m.requestErrorCount)
+}
+
+// RequireRequestSuccess asserts the request was marked as a success.
+func (m *mockAudioMetrics) RequireRequestSuccess(t *testing.T) {
+	require.Equal(t, 1, m.requestSuccessCount)
+	require.Equal(t, 0, m.requestErrorCount)
+}
+
+// RequireTokensRecorded asserts the number of tokens recorded.
+func (m *mockAudioMetrics) RequireTokensRecorded(t *testing.T, count int) {
+	require.Equal(t, count, m.tokenUsageCount)
+}
+
+var _ metrics.AudioMetrics = &mockAudioMetrics{}
+
 // mockBackendAuthHandler implements [backendauth.Handler] for testing.
 type mockBackendAuthHandler struct{}
 
@@ -366,3 +705,132 @@ type mockBackendAuthHandler struct{}
 func (m *mockBackendAuthHandler) Do(context.Context, map[string]string, *extprocv3.HeaderMutation, *extprocv3.BodyMutation) error {
 	return nil
 }
+
+// mockResponsesTranslator implements [translator.OpenAIResponsesTranslator] for testing.
+type mockResponsesTranslator struct {
+	t                           *testing.T
+	expHeaders                  map[string]string
+	expRequestBody              *openai.ResponseRequest
+	expResponseBody             *extprocv3.HttpBody
+	expForceRequestBodyMutation bool
+	retHeaderMutation           *extprocv3.HeaderMutation
+	retBodyMutation             *extprocv3.BodyMutation
+	retUsedToken                translator.LLMTokenUsage
+	responseErrorCalled         bool
+	retErr                      error
+}
+
+// RequestBody implements [translator.OpenAIResponsesTranslator].
+func (m *mockResponsesTranslator) RequestBody(_ []byte, body *openai.ResponseRequest, forceRequestBodyMutation bool) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error) {
+	require.Equal(m.t, m.expRequestBody, body)
+	require.Equal(m.t, m.expForceRequestBodyMutation, forceRequestBodyMutation)
+	return m.retHeaderMutation, m.retBodyMutation, m.retErr
+}
+
+// ResponseHeaders implements [translator.OpenAIResponsesTranslator].
+func (m *mockResponsesTranslator) ResponseHeaders(headers map[string]string) (headerMutation *extprocv3.HeaderMutation, err error) {
+	require.Equal(m.t, m.expHeaders, headers)
+	return m.retHeaderMutation, m.retErr
+}
+
+// ResponseBody implements [translator.OpenAIResponsesTranslator].
+func (m *mockResponsesTranslator) ResponseBody(_ map[string]string, body io.Reader, _ bool) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage translator.LLMTokenUsage, err error) {
+	if m.expResponseBody != nil {
+		buf, err := io.ReadAll(body)
+		require.NoError(m.t, err)
+		require.Equal(m.t, m.expResponseBody.Body, buf)
+	}
+	return m.retHeaderMutation, m.retBodyMutation, m.retUsedToken, m.retErr
+}
+
+// ResponseError implements [translator.OpenAIResponsesTranslator].
+func (m *mockResponsesTranslator) ResponseError(map[string]string, io.Reader) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error) {
+	m.responseErrorCalled = true
+	return nil, nil, nil
+}
+
+// mockCompletionTranslator implements [translator.OpenAICompletionTranslator] for testing.
+type mockCompletionTranslator struct {
+	t                           *testing.T
+	expHeaders                  map[string]string
+	expRequestBody              *openai.CompletionRequest
+	expResponseBody             *extprocv3.HttpBody
+	expForceRequestBodyMutation bool
+	retHeaderMutation           *extprocv3.HeaderMutation
+	retBodyMutation             *extprocv3.BodyMutation
+	retUsedToken                translator.LLMTokenUsage
+	responseErrorCalled         bool
+	retErr                      error
+}
+
+// RequestBody implements [translator.OpenAICompletionTranslator].
+func (m *mockCompletionTranslator) RequestBody(_ []byte, body *openai.CompletionRequest, forceRequestBodyMutation bool) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error) {
+	require.Equal(m.t, m.expRequestBody, body)
+	require.Equal(m.t, m.expForceRequestBodyMutation, forceRequestBodyMutation)
+	return m.retHeaderMutation, m.retBodyMutation, m.retErr
+}
+
+// ResponseHeaders implements [translator.OpenAICompletionTranslator].
+func (m *mockCompletionTranslator) ResponseHeaders(headers map[string]string) (headerMutation *extprocv3.HeaderMutation, err error) {
+	require.Equal(m.t, m.expHeaders, headers)
+	return m.retHeaderMutation, m.retErr
+}
+
+// ResponseBody implements [translator.OpenAICompletionTranslator].
+func (m *mockCompletionTranslator) ResponseBody(_ map[string]string, body io.Reader, _ bool) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage translator.LLMTokenUsage, err error) {
+	if m.expResponseBody != nil {
+		buf, err := io.ReadAll(body)
+		require.NoError(m.t, err)
+		require.Equal(m.t, m.expResponseBody.Body, buf)
+	}
+	return m.retHeaderMutation, m.retBodyMutation, m.retUsedToken, m.retErr
+}
+
+// ResponseError implements [translator.OpenAICompletionTranslator].
+func (m *mockCompletionTranslator) ResponseError(map[string]string, io.Reader) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error) {
+	m.responseErrorCalled = true
+	return nil, nil, nil
+}
+
+// mockMessagesTranslator implements [translator.AnthropicMessagesTranslator] for testing.
+type mockMessagesTranslator struct {
+	t                           *testing.T
+	expHeaders                  map[string]string
+	expRequestBody              *anthropic.MessagesRequest
+	expResponseBody             *extprocv3.HttpBody
+	expForceRequestBodyMutation bool
+	retHeaderMutation           *extprocv3.HeaderMutation
+	retBodyMutation             *extprocv3.BodyMutation
+	retUsedToken                translator.LLMTokenUsage
+	responseErrorCalled         bool
+	retErr                      error
+}
+
+// RequestBody implements [translator.AnthropicMessagesTranslator].
+func (m *mockMessagesTranslator) RequestBody(_ []byte, body *anthropic.MessagesRequest, forceRequestBodyMutation bool) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error) {
+	require.Equal(m.t, m.expRequestBody, body)
+	require.Equal(m.t, m.expForceRequestBodyMutation, forceRequestBodyMutation)
+	return m.retHeaderMutation, m.retBodyMutation, m.retErr
+}
+
+// ResponseHeaders implements [translator.AnthropicMessagesTranslator].
+func (m *mockMessagesTranslator) ResponseHeaders(headers map[string]string) (headerMutation *extprocv3.HeaderMutation, err error) {
+	require.Equal(m.t, m.expHeaders, headers)
+	return m.retHeaderMutation, m.retErr
+}
+
+// ResponseBody implements [translator.AnthropicMessagesTranslator].
+func (m *mockMessagesTranslator) ResponseBody(_ map[string]string, body io.Reader, _ bool) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage translator.LLMTokenUsage, err error) {
+	if m.expResponseBody != nil {
+		buf, err := io.ReadAll(body)
+		require.NoError(m.t, err)
+		require.Equal(m.t, m.expResponseBody.Body, buf)
+	}
+	return m.retHeaderMutation, m.retBodyMutation, m.retUsedToken, m.retErr
+}
+
+// ResponseError implements [translator.AnthropicMessagesTranslator].
+func (m *mockMessagesTranslator) ResponseError(map[string]string, io.Reader) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error) {
+	m.responseErrorCalled = true
+	return nil, nil, nil
+}
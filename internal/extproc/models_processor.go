@@ -44,12 +44,23 @@ func NewModelsProcessor(config *processorConfig, _ map[string]string, logger *sl
 		Data:   make([]openai.Model, 0, len(config.declaredModels)),
 	}
 	for _, m := range config.declaredModels {
-		models.Data = append(models.Data, openai.Model{
-			ID:      m.Name,
-			Object:  "model",
-			OwnedBy: m.OwnedBy,
-			Created: openai.JSONUNIXTime(m.CreatedAt),
-		})
+		model := openai.Model{
+			ID:            m.Name,
+			Object:        "model",
+			OwnedBy:       m.OwnedBy,
+			Created:       openai.JSONUNIXTime(m.CreatedAt),
+			Aliases:       m.Aliases,
+			ContextWindow: m.ContextWindow,
+			Modalities:    m.Modalities,
+		}
+		models.Data = append(models.Data, model)
+		// Aliases are listed as the separate models as well so that clients can discover them as valid IDs.
+		for _, alias := range m.Aliases {
+			aliasModel := model
+			aliasModel.ID = alias
+			aliasModel.Aliases = nil
+			models.Data = append(models.Data, aliasModel)
+		}
 	}
 	return &modelsProcessor{logger: logger, models: models}, nil
 }
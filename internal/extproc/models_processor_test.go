@@ -79,3 +79,33 @@ func headers(in []*corev3.HeaderValueOption) map[string]string {
 	}
 	return h
 }
+
+func TestModels_ProcessRequestHeaders_aliases(t *testing.T) {
+	now := time.Now()
+	cfg := &processorConfig{declaredModels: []filterapi.Model{
+		{
+			Name:          "claude-sonnet",
+			OwnedBy:       "anthropic",
+			CreatedAt:     now,
+			Aliases:       []string{"sonnet", "sonnet-latest"},
+			ContextWindow: 200000,
+			Modalities:    []string{"Text", "Image"},
+		},
+	}}
+	p, err := NewModelsProcessor(cfg, nil, slog.Default(), tracing.NoopTracing{}, false)
+	require.NoError(t, err)
+	res, err := p.ProcessRequestHeaders(t.Context(), &corev3.HeaderMap{})
+	require.NoError(t, err)
+
+	var models openai.ModelList
+	require.NoError(t, json.Unmarshal(res.GetImmediateResponse().Body, &models))
+	require.Len(t, models.Data, 3)
+	for i, id := range []string{"claude-sonnet", "sonnet", "sonnet-latest"} {
+		require.Equal(t, id, models.Data[i].ID)
+		require.Equal(t, "anthropic", models.Data[i].OwnedBy)
+		require.Equal(t, 200000, models.Data[i].ContextWindow)
+		require.Equal(t, []string{"Text", "Image"}, models.Data[i].Modalities)
+	}
+	require.Equal(t, []string{"sonnet", "sonnet-latest"}, models.Data[0].Aliases)
+	require.Nil(t, models.Data[1].Aliases)
+}
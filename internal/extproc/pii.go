@@ -0,0 +1,89 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+	"github.com/envoyproxy/ai-gateway/internal/pii"
+)
+
+// piiDetectedErrorType is the type of the error returned when the request is rejected by the PII redaction.
+const piiDetectedErrorType = "pii_detected"
+
+// redactPII scans the request for the PII with the given function of the redactor when the PII redaction of the route
+// applies to it. With the Mask action, the detected values are masked in both the raw and the parsed body, and the
+// restorer to restore them in the response is returned. This returns the immediate response in the given format when
+// the request is rejected, otherwise nil.
+func (c *processorConfig) redactPII(ctx context.Context, m metrics.PIIMetrics, f errorFormat, model string, headers map[string]string,
+	rawBody *extprocv3.HttpBody, redact func(r *pii.Redactor, raw []byte) ([]byte, pii.Result, error),
+) (*pii.Restorer, *extprocv3.ProcessingResponse, error) {
+	r := c.piiRedactions.Find(model, headers)
+	if r == nil {
+		return nil, nil, nil
+	}
+	masked, res, err := redact(r, rawBody.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to redact PII: %w", err)
+	}
+	if m != nil {
+		for detector, n := range res.Detections {
+			m.RecordDetections(ctx, detector, string(r.Action()), n, headers)
+		}
+	}
+	if len(res.Detections) == 0 {
+		return nil, nil, nil
+	}
+	if r.Action() == filterapi.PIIRedactionActionReject {
+		return nil, piiRejectedResponse(f, res.Detections), nil
+	}
+	rawBody.Body = masked
+	return res.Restorer, nil, nil
+}
+
+// piiRejectedResponse returns the immediate response in the given format rejecting the request containing the PII
+// detected by the given detectors. The detected values are not included in the response.
+func piiRejectedResponse(f errorFormat, detections map[string]int) *extprocv3.ProcessingResponse {
+	detectors := make([]string, 0, len(detections))
+	for d := range detections {
+		detectors = append(detectors, d)
+	}
+	slices.Sort(detectors)
+	return errorResponse(f, typev3.StatusCode_BadRequest, &openai.ErrorType{
+		Type:    piiDetectedErrorType,
+		Message: fmt.Sprintf("the request contains personally identifiable information detected by %s", strings.Join(detectors, ", ")),
+	})
+}
+
+// restorePII restores the values masked in the request in the response body in the format of the API, i.e. the body
+// mutation by the translator, or the decoded response body when the translator doesn't mutate it. The restored body
+// is always returned as the body mutation since the restorer holds back the parts of the streaming response which
+// can be the beginning of a placeholder.
+func restorePII(r *pii.Restorer, stream bool, decoded []byte, bodyMutation *extprocv3.BodyMutation, endOfStream bool) *extprocv3.BodyMutation {
+	out := decoded
+	if bodyMutation != nil {
+		out = bodyMutation.GetBody()
+	}
+	if stream {
+		out = r.RestoreStream(out, endOfStream)
+	} else {
+		out = r.RestoreBody(out)
+	}
+	if out == nil {
+		// Nothing is released by this chunk, which is still a mutation of the body.
+		out = []byte{}
+	}
+	return &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: out}}
+}
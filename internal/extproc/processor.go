@@ -14,8 +14,22 @@ import (
 	"github.com/google/cel-go/cel"
 
 	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/adaptivelb"
+	"github.com/envoyproxy/ai-gateway/internal/admission"
+	"github.com/envoyproxy/ai-gateway/internal/bodyheader"
+	"github.com/envoyproxy/ai-gateway/internal/circuitbreaker"
 	"github.com/envoyproxy/ai-gateway/internal/extproc/backendauth"
+	"github.com/envoyproxy/ai-gateway/internal/guardrail"
+	"github.com/envoyproxy/ai-gateway/internal/hedging"
+	"github.com/envoyproxy/ai-gateway/internal/mirror"
+	"github.com/envoyproxy/ai-gateway/internal/pii"
+	"github.com/envoyproxy/ai-gateway/internal/quota"
+	"github.com/envoyproxy/ai-gateway/internal/requestpolicy"
+	"github.com/envoyproxy/ai-gateway/internal/responsecache"
+	"github.com/envoyproxy/ai-gateway/internal/sessionaffinity"
+	"github.com/envoyproxy/ai-gateway/internal/toolpolicy"
 	tracing "github.com/envoyproxy/ai-gateway/internal/tracing/api"
+	"github.com/envoyproxy/ai-gateway/internal/virtualkey"
 )
 
 // processorConfig is the configuration for the processor.
@@ -25,8 +39,88 @@ type processorConfig struct {
 	modelNameHeaderKey string
 	metadataNamespace  string
 	requestCosts       []processorConfigRequestCost
-	declaredModels     []filterapi.Model
-	backends           map[string]*processorConfigBackend
+	// hasPrices is true when any backend has the price of the model.
+	hasPrices      bool
+	declaredModels []filterapi.Model
+	// modelAliases maps the alias of the declared models to the name of the model.
+	modelAliases map[string]string
+	backends     map[string]*processorConfigBackend
+	// responseCache is nil when the response cache is not configured.
+	responseCache       responsecache.Cache
+	responseCacheConfig *filterapi.ResponseCacheConfig
+	// semanticCache is nil when the semantic lookup of the response cache is not configured.
+	semanticCache *responsecache.Semantic
+	// quota is nil when no quota policy is configured.
+	quota       *quota.Limiter
+	quotaConfig []filterapi.QuotaPolicy
+	// virtualKeys is nil when no virtual key policy is configured.
+	virtualKeys *virtualkey.Set
+	// loadBalancers selects the backends of the route rules with the adaptive load balancing.
+	loadBalancers *adaptivelb.Set
+	// bodyHeaders is nil when no header is derived from the request body.
+	bodyHeaders *bodyheader.Projector
+	// mirrors is nil when no route rule is mirrored.
+	mirrors *mirror.Set
+	// sessionAffinities is nil when no route rule has the session affinity.
+	sessionAffinities *sessionaffinity.Set
+	// hedgings is nil when no route rule has the hedging.
+	hedgings *hedging.Set
+	// circuitBreakers is nil when no AIServiceBackend has the circuit breaker enforced by the filter.
+	circuitBreakers *circuitbreaker.Set
+	// admission is nil when the admission control is not configured.
+	admission *admission.Controller
+	// piiRedactions is nil when no route has the PII redaction.
+	piiRedactions *pii.Set
+	// guardrails is nil when no route has the guardrail.
+	guardrails *guardrail.Set
+	// toolPolicies is nil when no route has the tool policy.
+	toolPolicies *toolpolicy.Set
+	// requestPolicies is nil when no route rule has the request policy.
+	requestPolicies *requestpolicy.Set
+}
+
+// resolveModelAlias returns the name of the declared model if the given model is its alias.
+// Otherwise, it returns the given model as is.
+func (c *processorConfig) resolveModelAlias(model string) string {
+	if name, ok := c.modelAliases[model]; ok {
+		return name
+	}
+	return model
+}
+
+// recordsCosts returns true when the costs of the requests are recorded in the dynamic metadata,
+// i.e. any request cost or the price of any backend is configured.
+func (c *processorConfig) recordsCosts() bool {
+	return len(c.requestCosts) > 0 || c.hasPrices
+}
+
+// backendPrice returns the price of the model served by the given backend, or nil if it is not configured.
+func (c *processorConfig) backendPrice(backendName string) *filterapi.ModelPrice {
+	if b, ok := c.backends[backendName]; ok {
+		return b.b.Price
+	}
+	return nil
+}
+
+// projectBodyHeaders derives the configured headers from the given request body, and returns the headers to set
+// and the names of the headers to remove. The given request headers are updated accordingly. The headers sent by
+// the client with the same names are removed when not derived so that the clients cannot pick the route by themselves.
+// The input tokens of the given estimated usage of the request are the estimated prompt tokens of the CEL expressions.
+func (c *processorConfig) projectBodyHeaders(body []byte, requestHeaders map[string]string, estimate quota.Usage) (set []*corev3.HeaderValueOption, removed []string) {
+	if c.bodyHeaders == nil {
+		return nil, nil
+	}
+	values := c.bodyHeaders.Project(body, uint64(estimate.InputTokens))
+	for _, name := range c.bodyHeaders.Names() {
+		if v, ok := values[name]; ok {
+			requestHeaders[name] = v
+			set = append(set, &corev3.HeaderValueOption{Header: &corev3.HeaderValue{Key: name, RawValue: []byte(v)}})
+		} else if _, ok = requestHeaders[name]; ok {
+			delete(requestHeaders, name)
+			removed = append(removed, name)
+		}
+	}
+	return
 }
 
 type processorConfigBackend struct {
@@ -64,6 +158,26 @@ type Processor interface {
 	SetBackend(ctx context.Context, backend *filterapi.Backend, handler backendauth.Handler, routerProcessor Processor) error
 }
 
+// upstreamResponseClassifier is implemented by the upstream filter level processors which classify the responses of
+// the backend for the fallback. Envoy only sends the response to the upstream filter when the fallback is configured for
+// the backend, so that the classification happens before the router decides whether to retry the request.
+//
+// This is separate from [Processor.ProcessResponseHeaders] and [Processor.ProcessResponseBody] since the final response is
+// processed at the router filter level.
+type upstreamResponseClassifier interface {
+	// ClassifyResponseHeaders processes the response headers message at the upstream filter.
+	ClassifyResponseHeaders(context.Context, *extprocv3.HttpHeaders) (*extprocv3.ProcessingResponse, error)
+	// ClassifyResponseBody processes the buffered body of the error response at the upstream filter.
+	ClassifyResponseBody(context.Context, *extprocv3.HttpBody) (*extprocv3.ProcessingResponse, error)
+}
+
+// processorCloser is implemented by the processors which hold the resources until the end of the request, e.g. the
+// admission of the request on the backend. Close is called once the gRPC stream ends, including when the request is
+// aborted before the end of the response.
+type processorCloser interface {
+	Close()
+}
+
 // passThroughProcessor implements the Processor interface.
 type passThroughProcessor struct{}
 
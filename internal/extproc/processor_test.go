@@ -10,6 +10,10 @@ import (
 
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/bodyheader"
+	"github.com/envoyproxy/ai-gateway/internal/quota"
 )
 
 func Test_passThroughProcessor(t *testing.T) { // This is mostly for coverage.
@@ -38,3 +42,46 @@ func Test_passThroughProcessor(t *testing.T) { // This is mostly for coverage.
 	_, ok = resp.Response.(*extprocv3.ProcessingResponse_ResponseBody)
 	require.True(t, ok)
 }
+
+func Test_processorConfig_resolveModelAlias(t *testing.T) {
+	c := &processorConfig{}
+	require.Equal(t, "sonnet", c.resolveModelAlias("sonnet"))
+	c.modelAliases = map[string]string{"sonnet": "claude-sonnet"}
+	require.Equal(t, "claude-sonnet", c.resolveModelAlias("sonnet"))
+	require.Equal(t, "gpt-4o", c.resolveModelAlias("gpt-4o"))
+}
+
+func Test_processorConfig_projectBodyHeaders(t *testing.T) {
+	set, removed := (&processorConfig{}).projectBodyHeaders([]byte(`{}`), map[string]string{}, quota.Usage{})
+	require.Nil(t, set)
+	require.Nil(t, removed)
+
+	projector, err := bodyheader.New([]filterapi.BodyHeader{
+		{Name: "x-response-format", JSONPath: "$.response_format.type"},
+		{Name: "x-has-tools", CEL: "has(request.tools)"},
+	})
+	require.NoError(t, err)
+	c := &processorConfig{bodyHeaders: projector}
+
+	headers := map[string]string{"x-response-format": "spoofed", "x-has-tools": "spoofed"}
+	set, removed = c.projectBodyHeaders([]byte(`{"tools": []}`), headers, quota.Usage{})
+	require.Len(t, set, 1)
+	require.Equal(t, "x-has-tools", set[0].Header.Key)
+	require.Equal(t, []byte("true"), set[0].Header.RawValue)
+	require.Equal(t, []string{"x-response-format"}, removed)
+	require.Equal(t, map[string]string{"x-has-tools": "true"}, headers)
+
+	headers = map[string]string{}
+	set, removed = c.projectBodyHeaders([]byte(`{"response_format": {"type": "json_object"}}`), headers, quota.Usage{})
+	require.Len(t, set, 2)
+	require.Empty(t, removed)
+	require.Equal(t, map[string]string{"x-response-format": "json_object", "x-has-tools": "false"}, headers)
+
+	// The estimated prompt tokens are the input tokens of the estimated usage of the request.
+	projector, err = bodyheader.New([]filterapi.BodyHeader{{Name: "x-prompt-tokens", CEL: "estimated_prompt_tokens"}})
+	require.NoError(t, err)
+	c = &processorConfig{bodyHeaders: projector}
+	headers = map[string]string{}
+	_, _ = c.projectBodyHeaders([]byte(`{}`), headers, quota.Usage{InputTokens: 42, OutputTokens: 100})
+	require.Equal(t, map[string]string{"x-prompt-tokens": "42"}, headers)
+}
@@ -0,0 +1,136 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
+	"github.com/envoyproxy/ai-gateway/internal/quota"
+)
+
+// quotaExceededErrorCode is the code of the error returned when the quota is exhausted, which is the same as the one
+// of OpenAI for the rate limits.
+const quotaExceededErrorCode = "rate_limit_exceeded"
+
+// reserveQuota reserves the given estimated usage of the request to the model against the quotas of the client, and
+// returns the immediate response with 429 in the given format when the quota is exhausted. The reservation is nil
+// when no quota policy applies to the request.
+//
+// The errors of the quota store are not propagated to the client so that the outage of the store doesn't take down
+// the traffic, in which case the request is not counted against the quota.
+func (c *processorConfig) reserveQuota(ctx context.Context, logger *slog.Logger, f errorFormat, model string, headers map[string]string, estimate quota.Usage) (*quota.Reservation, *extprocv3.ProcessingResponse) {
+	if c.quota == nil {
+		return nil, nil
+	}
+	r, err := c.quota.Reserve(ctx, model, headers, estimate)
+	var exceeded *quota.ExceededError
+	if errors.As(err, &exceeded) {
+		code := quotaExceededErrorCode
+		return nil, errorResponse(f, typev3.StatusCode_TooManyRequests, &openai.ErrorType{Type: "tokens", Code: &code, Message: exceeded.Error()})
+	} else if err != nil {
+		logger.Warn("failed to reserve the quota", slog.String("error", err.Error()))
+	}
+	return r, nil
+}
+
+// quotaTracker tracks the quota reservation of the request at the router filter until it is reconciled with the
+// actual usage of the request. This is embedded in the router filters, which implements [processorCloser] for them.
+type quotaTracker struct {
+	// quotaReservation is the estimated usage reserved against the quota of the client. This is reconciled with
+	// the actual usage at the end of the response, and nil when no quota policy applies to the request.
+	quotaReservation *quota.Reservation
+	config           *processorConfig
+	logger           *slog.Logger
+	// upstreamFilter points to the upstream filter of the router filter, which is updated when the request is retried.
+	upstreamFilter *Processor
+}
+
+// reserveQuota reserves the estimated usage of the request whose response is processed by the given upstream filter.
+// See processorConfig.reserveQuota.
+func (q *quotaTracker) reserveQuota(ctx context.Context, config *processorConfig, logger *slog.Logger, upstreamFilter *Processor,
+	f errorFormat, model string, headers map[string]string, estimate quota.Usage,
+) (resp *extprocv3.ProcessingResponse) {
+	q.config, q.logger, q.upstreamFilter = config, logger, upstreamFilter
+	q.quotaReservation, resp = config.reserveQuota(ctx, logger, f, model, headers, estimate)
+	return
+}
+
+// reconcileAtEnd reconciles the quota reservation when the given response body is the end of the response.
+func (q *quotaTracker) reconcileAtEnd(ctx context.Context, body *extprocv3.HttpBody) {
+	if body.GetEndOfStream() {
+		q.reconcile(ctx)
+	}
+}
+
+// Close implements [processorCloser.Close].
+//
+// The quota reservation is normally reconciled at the end of the response, so this only reconciles the one of
+// the request whose stream is aborted or fails before the end of the response.
+func (q *quotaTracker) Close() {
+	// The context of the stream is already done at this point.
+	q.reconcile(context.Background())
+}
+
+// reconcile reconciles the quota reservation, if any, with the usage of the request at the upstream filter.
+func (q *quotaTracker) reconcile(ctx context.Context) {
+	if q.quotaReservation == nil {
+		return
+	}
+	q.config.reconcileQuota(ctx, q.logger, q.quotaReservation, *q.upstreamFilter)
+	q.quotaReservation = nil
+}
+
+// reconcileQuota replaces the reserved estimate of the given reservation with the actual usage of the request at
+// the given upstream filter. The usage is zero when the request failed before the response completes successfully,
+// including when the upstream filter is nil.
+//
+// The spend is calculated from the price of the model served by the backend, which is zero when the price is not
+// configured.
+func (c *processorConfig) reconcileQuota(ctx context.Context, logger *slog.Logger, r *quota.Reservation, upstreamFilter Processor) {
+	backendName, costs := upstreamUsage(upstreamFilter)
+	actual := quota.Usage{InputTokens: int64(costs.InputTokens), OutputTokens: int64(costs.OutputTokens)}
+	if price := c.backendPrice(backendName); price != nil {
+		usd := llmcostcel.USDCost(price, costs.InputTokens, costs.CachedInputTokens, costs.OutputTokens)
+		actual.SpendMicroUSD = int64(math.Ceil(usd * 1e6))
+	}
+	if err := r.Reconcile(ctx, actual); err != nil {
+		logger.Warn("failed to reconcile the quota", slog.String("error", err.Error()))
+	}
+}
+
+// upstreamUsage returns the backend and the token usage of the request at the given upstream filter. The audio speech
+// requests have no token usage since they are counted in characters.
+func upstreamUsage(upstreamFilter Processor) (backendName string, costs translator.LLMTokenUsage) {
+	switch u := upstreamFilter.(type) {
+	case *chatCompletionProcessorUpstreamFilter:
+		return u.backendName, u.costs
+	case *completionsProcessorUpstreamFilter:
+		return u.backendName, u.costs
+	case *responsesProcessorUpstreamFilter:
+		return u.backendName, u.costs
+	case *messagesProcessorUpstreamFilter:
+		return u.backendName, u.costs
+	case *embeddingsProcessorUpstreamFilter:
+		return u.backendName, u.costs
+	case *imageGenerationProcessorUpstreamFilter:
+		return u.backendName, u.costs
+	case *audioTranscriptionProcessorUpstreamFilter:
+		return u.backendName, u.costs
+	case *audioSpeechProcessorUpstreamFilter:
+		return u.backendName, translator.LLMTokenUsage{}
+	default:
+		return "", translator.LLMTokenUsage{}
+	}
+}
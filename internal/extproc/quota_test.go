@@ -0,0 +1,121 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+	"github.com/envoyproxy/ai-gateway/internal/quota"
+)
+
+func Test_processorConfig_reserveQuota(t *testing.T) {
+	t.Run("no quota", func(t *testing.T) {
+		r, resp := (&processorConfig{}).reserveQuota(t.Context(), slog.Default(), chatCompletionFormat{}, "gpt-4o", map[string]string{}, quota.Usage{})
+		require.Nil(t, r)
+		require.Nil(t, resp)
+	})
+
+	limiter, err := quota.New([]filterapi.QuotaPolicy{{
+		Name:            "ns/keys/key/a",
+		ClientKeyHeader: "x-client",
+		Budgets:         []filterapi.QuotaBudget{{Type: filterapi.QuotaBudgetTypeSpend, Limit: 500_000, Window: time.Hour}},
+	}})
+	require.NoError(t, err)
+	config := &processorConfig{
+		quota: limiter,
+		backends: map[string]*processorConfigBackend{"priced": {b: &filterapi.Backend{
+			Name: "priced", Price: &filterapi.ModelPrice{InputPerMillionTokens: 1, OutputPerMillionTokens: 2},
+		}}},
+	}
+	headers := map[string]string{"x-client": "a"}
+
+	// The request failed before the response is not counted.
+	r, resp := config.reserveQuota(t.Context(), slog.Default(), chatCompletionFormat{}, "gpt-4o", headers, quota.Usage{InputTokens: 10})
+	require.Nil(t, resp)
+	require.NotNil(t, r)
+	config.reconcileQuota(t.Context(), slog.Default(), r, nil)
+
+	// The spend is calculated from the price of the backend, which is $1 here.
+	r, resp = config.reserveQuota(t.Context(), slog.Default(), chatCompletionFormat{}, "gpt-4o", headers, quota.Usage{InputTokens: 10})
+	require.Nil(t, resp)
+	config.reconcileQuota(t.Context(), slog.Default(), r, &embeddingsProcessorUpstreamFilter{
+		backendName: "priced", costs: translator.LLMTokenUsage{InputTokens: 1_000_000},
+	})
+
+	_, resp = config.reserveQuota(t.Context(), slog.Default(), messagesFormat{}, "gpt-4o", headers, quota.Usage{})
+	ir := resp.GetImmediateResponse()
+	require.NotNil(t, ir)
+	require.Equal(t, http.StatusTooManyRequests, int(ir.Status.Code))
+	require.JSONEq(t, `{"type":"error","error":{"type":"tokens","message":"quota ns/keys/key/a exceeded: Spend budget of 0.50 USD per 1h0m0s"}}`, string(ir.Body))
+}
+
+func Test_embeddingsProcessorRouterFilter_Quota(t *testing.T) {
+	limiter, err := quota.New([]filterapi.QuotaPolicy{{
+		Name:            "p",
+		ClientKeyHeader: "x-client",
+		Budgets:         []filterapi.QuotaBudget{{Type: filterapi.QuotaBudgetTypeInputToken, Limit: 2, Window: time.Hour}},
+	}})
+	require.NoError(t, err)
+	config := &processorConfig{modelNameHeaderKey: "x-ai-gateway-model-key", quota: limiter}
+	process := func() (*embeddingsProcessorRouterFilter, *extprocv3.ProcessingResponse) {
+		p := &embeddingsProcessorRouterFilter{
+			config: config, requestHeaders: map[string]string{":path": "/v1/embeddings", "x-client": "a"}, logger: slog.Default(),
+		}
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(`{"model":"text-embedding-3-small","input":"12345678"}`)})
+		require.NoError(t, err)
+		return p, resp
+	}
+
+	// The estimate of the request fits in the budget.
+	p, resp := process()
+	require.Nil(t, resp.GetImmediateResponse())
+	require.NotNil(t, p.quotaReservation)
+	_, resp = process()
+	ir := resp.GetImmediateResponse()
+	require.NotNil(t, ir)
+	require.Equal(t, http.StatusTooManyRequests, int(ir.Status.Code))
+
+	// The reservation of the aborted request is released at the end of the stream.
+	p.Close()
+	require.Nil(t, p.quotaReservation)
+	p, resp = process()
+	require.Nil(t, resp.GetImmediateResponse())
+	require.NotNil(t, p.quotaReservation)
+}
+
+func Test_audioSpeechProcessorRouterFilter_Quota(t *testing.T) {
+	limiter, err := quota.New([]filterapi.QuotaPolicy{{
+		Name:            "p",
+		ClientKeyHeader: "x-client",
+		Budgets:         []filterapi.QuotaBudget{{Type: filterapi.QuotaBudgetTypeTotalToken, Limit: 1, Window: time.Hour}},
+	}})
+	require.NoError(t, err)
+	r, err := limiter.Reserve(t.Context(), "tts-1", map[string]string{"x-client": "a"}, quota.Usage{InputTokens: 1})
+	require.NoError(t, err)
+	require.NotNil(t, r)
+
+	p := &audioSpeechProcessorRouterFilter{
+		config:         &processorConfig{modelNameHeaderKey: "x-ai-gateway-model-key", quota: limiter},
+		requestHeaders: map[string]string{":path": "/v1/audio/speech", "x-client": "a"},
+		logger:         slog.Default(),
+	}
+	resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(`{"model":"tts-1","input":"hello","voice":"alloy"}`)})
+	require.NoError(t, err)
+	ir := resp.GetImmediateResponse()
+	require.NotNil(t, ir)
+	require.Equal(t, http.StatusTooManyRequests, int(ir.Status.Code))
+	// The error is in the format of the OpenAI API, not specific to the chat completions.
+	require.JSONEq(t, `{"type":"error","error":{"type":"tokens","code":"rate_limit_exceeded",`+
+		`"message":"quota p exceeded: TotalToken budget of 1 tokens per 1h0m0s"}}`, string(ir.Body))
+}
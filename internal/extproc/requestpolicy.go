@@ -0,0 +1,91 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/requestpolicy"
+)
+
+const (
+	// requestPolicyErrorCode is the code of the OpenAI error returned when the request is rejected by the request
+	// policy.
+	requestPolicyErrorCode = "request_policy_violation"
+	// requestPolicyClampedMetadataKey is the key of the dynamic metadata recording the comma-separated fields clamped
+	// by the request policy.
+	requestPolicyClampedMetadataKey = "request_policy_clamped"
+)
+
+// applyRequestPolicy applies the request policy of the route rule when it applies to the request. When the policy
+// changes the request, both the raw and the parsed body are replaced. This returns the immediate response when the
+// request is rejected, otherwise nil.
+func (c *chatCompletionProcessorRouterFilter) applyRequestPolicy(model string, rawBody *extprocv3.HttpBody, body *openai.ChatCompletionRequest) (*extprocv3.ProcessingResponse, error) {
+	p := c.config.requestPolicies.Find(model, c.requestHeaders)
+	if p == nil {
+		return nil, nil
+	}
+	res, err := p.Apply(rawBody.Body, body)
+	var violation *requestpolicy.ViolationError
+	if errors.As(err, &violation) {
+		return requestPolicyRejectedResponse(chatCompletionFormat{}, violation), nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to apply request policy %s: %w", p.Name(), err)
+	}
+	if res.Body != nil {
+		rawBody.Body = res.Body
+		c.requestPolicyMutated = true
+	}
+	c.requestPolicyClamped = res.Clamped
+	return nil, nil
+}
+
+// rejectUnsupportedRequestPolicy returns the immediate response in the given format rejecting the request when the
+// request policy of a route rule applies to it, otherwise nil. This is used by the endpoints other than the chat
+// completions, which the request policy doesn't support, so that their requests don't bypass the policy.
+func (c *processorConfig) rejectUnsupportedRequestPolicy(f errorFormat, model string, headers map[string]string) *extprocv3.ProcessingResponse {
+	if c.requestPolicies.Find(model, headers) == nil {
+		return nil
+	}
+	return requestPolicyRejectedResponse(f, &requestpolicy.ViolationError{
+		Message: "the request policy of the route only supports the chat completions API",
+	})
+}
+
+// requestPolicyRejectedResponse returns the immediate response in the given format rejecting the request with the
+// given violation of the request policy.
+func requestPolicyRejectedResponse(f errorFormat, violation *requestpolicy.ViolationError) *extprocv3.ProcessingResponse {
+	code := requestPolicyErrorCode
+	e := &openai.ErrorType{Type: "invalid_request_error", Code: &code, Message: violation.Message}
+	if violation.Param != "" {
+		e.Param = &violation.Param
+	}
+	return errorResponse(f, typev3.StatusCode_BadRequest, e)
+}
+
+// requestPolicyDynamicMetadata returns the dynamic metadata recording the fields clamped by the request policy, or
+// nil if nothing is clamped.
+func (c *chatCompletionProcessorRouterFilter) requestPolicyDynamicMetadata() *structpb.Struct {
+	if len(c.requestPolicyClamped) == 0 {
+		return nil
+	}
+	return &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			c.config.metadataNamespace: structpb.NewStructValue(&structpb.Struct{
+				Fields: map[string]*structpb.Value{
+					requestPolicyClampedMetadataKey: structpb.NewStringValue(strings.Join(c.requestPolicyClamped, ",")),
+				},
+			}),
+		},
+	}
+}
@@ -0,0 +1,480 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/backendauth"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+	"github.com/envoyproxy/ai-gateway/internal/guardrail"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+	"github.com/envoyproxy/ai-gateway/internal/pii"
+	"github.com/envoyproxy/ai-gateway/internal/quota"
+	"github.com/envoyproxy/ai-gateway/internal/toolpolicy"
+	tracing "github.com/envoyproxy/ai-gateway/internal/tracing/api"
+)
+
+// ResponsesProcessorFactory returns a factory method to instantiate the responses processor.
+//
+// The responses processor shares the [metrics.ChatCompletionMetrics] with the chat completion processor
+// since the Responses API is the successor of the chat completions API and has the same token semantics. The
+// [metrics.PIIMetrics] is only recorded for the routes with the PII redaction.
+func ResponsesProcessorFactory(ccm metrics.ChatCompletionMetrics, pm metrics.PIIMetrics) ProcessorFactory {
+	return func(config *processorConfig, requestHeaders map[string]string, logger *slog.Logger, _ tracing.Tracing, isUpstreamFilter bool) (Processor, error) {
+		logger = logger.With("processor", "responses", "isUpstreamFilter", fmt.Sprintf("%v", isUpstreamFilter))
+		if !isUpstreamFilter {
+			return &responsesProcessorRouterFilter{
+				config:         config,
+				requestHeaders: requestHeaders,
+				logger:         logger,
+				piiMetrics:     pm,
+			}, nil
+		}
+		return &responsesProcessorUpstreamFilter{
+			config:         config,
+			requestHeaders: requestHeaders,
+			logger:         logger,
+			metrics:        ccm,
+		}, nil
+	}
+}
+
+// responsesProcessorRouterFilter implements [Processor] for the `/v1/responses` endpoint.
+//
+// This is primarily used to select the route for the request based on the model name.
+type responsesProcessorRouterFilter struct {
+	passThroughProcessor
+	quotaTracker
+	// upstreamFilter is the upstream filter that is used to process the request at the upstream filter.
+	// This will be updated when the request is retried.
+	//
+	// On the response handling path, we don't need to do any operation until successful, so we use the implementation
+	// of the upstream filter to handle the response at the router filter.
+	upstreamFilter Processor
+	logger         *slog.Logger
+	config         *processorConfig
+	requestHeaders map[string]string
+	// originalRequestBody is the original request body that is passed to the upstream filter.
+	// This is used to perform the transformation of the request body on the original input
+	// when the request is retried.
+	originalRequestBody    *openai.ResponseRequest
+	originalRequestBodyRaw []byte
+	// upstreamFilterCount is the number of upstream filters that have been processed.
+	// This is used to determine if the request is a retry request.
+	upstreamFilterCount int
+	// guardrail is the guardrail of the route matching the request, otherwise nil.
+	guardrail *guardrail.Guardrail
+	// guardrailMutated is set to true when the request body is mutated by the guardrail.
+	guardrailMutated bool
+	// guardrailDecision is the decision of the guardrail on the request, which is recorded in the log.
+	guardrailDecision *guardrail.Decision
+	// toolValidator validates the tool calls of the response when the tool policy of the route applies to the request
+	// declaring the functions, otherwise nil.
+	toolValidator *toolpolicy.Validator
+	// piiRestorer restores the values masked by the PII redaction in the response, and nil when nothing is masked.
+	piiRestorer *pii.Restorer
+	piiMetrics  metrics.PIIMetrics
+}
+
+// ProcessResponseHeaders implements [Processor.ProcessResponseHeaders].
+func (r *responsesProcessorRouterFilter) ProcessResponseHeaders(ctx context.Context, headerMap *corev3.HeaderMap) (*extprocv3.ProcessingResponse, error) {
+	// If the request failed to route and/or immediate response was returned before the upstream filter was set,
+	// r.upstreamFilter can be nil.
+	if r.upstreamFilter != nil { // See the comment on the "upstreamFilter" field.
+		return r.upstreamFilter.ProcessResponseHeaders(ctx, headerMap)
+	}
+	return r.passThroughProcessor.ProcessResponseHeaders(ctx, headerMap)
+}
+
+// ProcessResponseBody implements [Processor.ProcessResponseBody].
+func (r *responsesProcessorRouterFilter) ProcessResponseBody(ctx context.Context, body *extprocv3.HttpBody) (resp *extprocv3.ProcessingResponse, err error) {
+	// If the request failed to route and/or immediate response was returned before the upstream filter was set,
+	// r.upstreamFilter can be nil.
+	if r.upstreamFilter != nil { // See the comment on the "upstreamFilter" field.
+		resp, err = r.upstreamFilter.ProcessResponseBody(ctx, body)
+	} else {
+		resp, err = r.passThroughProcessor.ProcessResponseBody(ctx, body)
+	}
+	r.reconcileAtEnd(ctx, body)
+	if upstream, ok := r.upstreamFilter.(*responsesProcessorUpstreamFilter); ok && upstream.responseGuardrail != nil && body.GetEndOfStream() {
+		recordGuardrail(guardrailLog{r.logger}, guardrail.PhaseResponse, upstream.responseGuardrail.decision)
+	}
+	return
+}
+
+// ProcessRequestBody implements [Processor.ProcessRequestBody].
+func (r *responsesProcessorRouterFilter) ProcessRequestBody(ctx context.Context, rawBody *extprocv3.HttpBody) (*extprocv3.ProcessingResponse, error) {
+	model, body, err := parseOpenAIResponseBody(rawBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request body: %w", err)
+	}
+	model = r.config.resolveModelAlias(model)
+	keyHeaders, keyRemovedHeaders, resp := r.config.checkVirtualKey(model, r.requestHeaders)
+	if resp != nil {
+		return resp, nil
+	}
+	if resp = r.config.rejectUnsupportedRequestPolicy(responsesFormat{}, model, r.requestHeaders); resp != nil {
+		return resp, nil
+	}
+	// The PII is redacted before anything else sees the body, including the guardrail service.
+	r.piiRestorer, resp, err = r.config.redactPII(ctx, r.piiMetrics, responsesFormat{}, model, r.requestHeaders, rawBody,
+		func(p *pii.Redactor, raw []byte) ([]byte, pii.Result, error) { return p.RedactResponses(raw, body) })
+	if err != nil || resp != nil {
+		return resp, err
+	}
+	if resp, err = r.checkRequestGuardrail(ctx, model, rawBody, body); err != nil || resp != nil {
+		return resp, err
+	}
+	// The tool policy sees the functions declared by the request after the guardrail may have mutated it.
+	if resp = r.checkToolPolicy(model, body); resp != nil {
+		return resp, nil
+	}
+
+	estimate := quota.EstimateResponses(body)
+	resp = r.reserveQuota(ctx, r.config, r.logger, &r.upstreamFilter, responsesFormat{}, model, r.requestHeaders, estimate)
+	if resp != nil {
+		return resp, nil
+	}
+
+	r.requestHeaders[r.config.modelNameHeaderKey] = model
+
+	var additionalHeaders []*corev3.HeaderValueOption
+	additionalHeaders = append(additionalHeaders, &corev3.HeaderValueOption{
+		// Set the model name to the request header with the key `x-ai-eg-model`.
+		Header: &corev3.HeaderValue{Key: r.config.modelNameHeaderKey, RawValue: []byte(model)},
+	}, &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{Key: originalPathHeader, RawValue: []byte(r.requestHeaders[":path"])},
+	})
+	bodyHeaders, removedHeaders := r.config.projectBodyHeaders(rawBody.Body, r.requestHeaders, estimate)
+	additionalHeaders = append(additionalHeaders, bodyHeaders...)
+	additionalHeaders = append(additionalHeaders, keyHeaders...)
+	removedHeaders = append(removedHeaders, keyRemovedHeaders...)
+	r.originalRequestBody = body
+	r.originalRequestBodyRaw = rawBody.Body
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_RequestBody{
+			RequestBody: &extprocv3.BodyResponse{
+				Response: &extprocv3.CommonResponse{
+					HeaderMutation: &extprocv3.HeaderMutation{
+						SetHeaders:    additionalHeaders,
+						RemoveHeaders: removedHeaders,
+					},
+					ClearRouteCache: true,
+				},
+			},
+		},
+	}, nil
+}
+
+// responsesProcessorUpstreamFilter implements [Processor] for the `/v1/responses` endpoint at the upstream filter.
+//
+// This is created per retry and handles the translation as well as the authentication of the request.
+type responsesProcessorUpstreamFilter struct {
+	logger                 *slog.Logger
+	config                 *processorConfig
+	requestHeaders         map[string]string
+	responseHeaders        map[string]string
+	responseEncoding       string
+	modelNameOverride      string
+	backendName            string
+	handler                backendauth.Handler
+	originalRequestBodyRaw []byte
+	originalRequestBody    *openai.ResponseRequest
+	translator             translator.OpenAIResponsesTranslator
+	// onRetry is true if this is a retry request at the upstream filter.
+	onRetry bool
+	// cost is the cost of the request that is accumulated during the processing of the response.
+	costs translator.LLMTokenUsage
+	// metrics tracking.
+	metrics metrics.ChatCompletionMetrics
+	// stream is set to true if the request is a streaming request.
+	stream bool
+	// See the comment on the `guardrailMutated` field in the router filter.
+	guardrailMutated bool
+	// responseGuardrail holds back the response until the guardrail decides on it. This is nil when the guardrail
+	// doesn't check the response.
+	responseGuardrail *responseGuardrail
+	// toolPolicy validates the tool calls of the response. This is nil when the tool policy doesn't apply to the
+	// request. See the comment on the `toolValidator` field in the router filter.
+	toolPolicy *responseToolPolicy
+	// See the comment on the `piiRestorer` field in the router filter.
+	piiRestorer *pii.Restorer
+}
+
+// selectTranslator selects the translator based on the output schema.
+func (r *responsesProcessorUpstreamFilter) selectTranslator(out filterapi.VersionedAPISchema) error {
+	switch out.Name {
+	case filterapi.APISchemaOpenAI:
+		r.translator = translator.NewResponsesOpenAIToOpenAITranslator(out.Version, r.modelNameOverride)
+	case filterapi.APISchemaAzureOpenAI:
+		r.translator = translator.NewResponsesOpenAIToAzureOpenAITranslator(out.Version, r.modelNameOverride)
+	case filterapi.APISchemaAWSBedrock:
+		r.translator = translator.NewResponsesOpenAIToAWSBedrockTranslator(r.modelNameOverride)
+	case filterapi.APISchemaGCPVertexAI:
+		r.translator = translator.NewResponsesOpenAIToGCPVertexAITranslator(r.modelNameOverride)
+	case filterapi.APISchemaGCPAnthropic:
+		r.translator = translator.NewResponsesOpenAIToGCPAnthropicTranslator(out.Version, r.modelNameOverride)
+	case filterapi.APISchemaAnthropic:
+		r.translator = translator.NewResponsesOpenAIToAnthropicTranslator(out.Version, r.modelNameOverride)
+	default:
+		return fmt.Errorf("unsupported API schema: backend=%s", out)
+	}
+	return nil
+}
+
+// ProcessRequestHeaders implements [Processor.ProcessRequestHeaders].
+//
+// At the upstream filter, we already have the original request body at request headers phase.
+// So, we simply do the translation and upstream auth at this stage, and send them back to Envoy
+// with the status CONTINUE_AND_REPLACE. This will allows Envoy to not send the request body again
+// to the extproc.
+func (r *responsesProcessorUpstreamFilter) ProcessRequestHeaders(ctx context.Context, _ *corev3.HeaderMap) (res *extprocv3.ProcessingResponse, err error) {
+	defer func() {
+		if err != nil {
+			r.metrics.RecordRequestCompletion(ctx, false, r.requestHeaders)
+		}
+	}()
+
+	// Start tracking metrics for this request.
+	r.metrics.StartRequest(r.requestHeaders)
+	r.metrics.SetModel(r.requestHeaders[r.config.modelNameHeaderKey])
+
+	// We force the body mutation on retry because the body mutation might have happened the previous iteration, and
+	// when the guardrail mutated the request body or the PII is masked in it.
+	forceBodyMutation := r.onRetry || r.guardrailMutated || r.piiRestorer != nil
+	headerMutation, bodyMutation, err := r.translator.RequestBody(r.originalRequestBodyRaw, r.originalRequestBody, forceBodyMutation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform request: %w", err)
+	}
+	if headerMutation == nil {
+		headerMutation = &extprocv3.HeaderMutation{}
+	} else {
+		for _, h := range headerMutation.SetHeaders {
+			r.requestHeaders[h.Header.Key] = string(h.Header.RawValue)
+		}
+	}
+	if h := r.handler; h != nil {
+		if err = h.Do(ctx, r.requestHeaders, headerMutation, bodyMutation); err != nil {
+			return nil, fmt.Errorf("failed to do auth request: %w", err)
+		}
+	}
+
+	var dm *structpb.Struct
+	if bm := bodyMutation.GetBody(); bm != nil {
+		dm = buildContentLengthDynamicMetadataOnRequest(r.config, len(bm))
+	}
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_RequestHeaders{
+			RequestHeaders: &extprocv3.HeadersResponse{
+				Response: &extprocv3.CommonResponse{
+					HeaderMutation: headerMutation, BodyMutation: bodyMutation,
+					Status: extprocv3.CommonResponse_CONTINUE_AND_REPLACE,
+				},
+			},
+		},
+		DynamicMetadata: dm,
+	}, nil
+}
+
+// ProcessRequestBody implements [Processor.ProcessRequestBody].
+func (r *responsesProcessorUpstreamFilter) ProcessRequestBody(context.Context, *extprocv3.HttpBody) (res *extprocv3.ProcessingResponse, err error) {
+	panic("BUG: ProcessRequestBody should not be called in the upstream filter")
+}
+
+// ProcessResponseHeaders implements [Processor.ProcessResponseHeaders].
+func (r *responsesProcessorUpstreamFilter) ProcessResponseHeaders(ctx context.Context, headers *corev3.HeaderMap) (res *extprocv3.ProcessingResponse, err error) {
+	defer func() {
+		if err != nil {
+			r.metrics.RecordRequestCompletion(ctx, false, r.requestHeaders)
+		}
+	}()
+
+	r.responseHeaders = headersToMap(headers)
+	if enc := r.responseHeaders["content-encoding"]; enc != "" {
+		r.responseEncoding = enc
+	}
+	headerMutation, err := r.translator.ResponseHeaders(r.responseHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform response headers: %w", err)
+	}
+	var mode *extprocv3http.ProcessingMode
+	if r.stream && r.responseHeaders[":status"] == "200" {
+		// We only stream the response if the status code is 200 and the response is a stream.
+		mode = &extprocv3http.ProcessingMode{ResponseBodyMode: extprocv3http.ProcessingMode_STREAMED}
+	}
+	return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseHeaders{
+		ResponseHeaders: &extprocv3.HeadersResponse{
+			Response: &extprocv3.CommonResponse{HeaderMutation: headerMutation},
+		},
+	}, ModeOverride: mode}, nil
+}
+
+// ProcessResponseBody implements [Processor.ProcessResponseBody].
+func (r *responsesProcessorUpstreamFilter) ProcessResponseBody(ctx context.Context, body *extprocv3.HttpBody) (res *extprocv3.ProcessingResponse, err error) {
+	defer func() {
+		r.metrics.RecordRequestCompletion(ctx, err == nil, r.requestHeaders)
+	}()
+	var br io.Reader
+	var isGzip bool
+	switch r.responseEncoding {
+	case "gzip":
+		br, err = gzip.NewReader(bytes.NewReader(body.Body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode gzip: %w", err)
+		}
+		isGzip = true
+	default:
+		br = bytes.NewReader(body.Body)
+	}
+
+	// Assume all responses have a valid status code header.
+	if code, _ := strconv.Atoi(r.responseHeaders[":status"]); !isGoodStatusCode(code) {
+		var headerMutation *extprocv3.HeaderMutation
+		var bodyMutation *extprocv3.BodyMutation
+		headerMutation, bodyMutation, err = r.translator.ResponseError(r.responseHeaders, br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transform response error: %w", err)
+		}
+		return &extprocv3.ProcessingResponse{
+			Response: &extprocv3.ProcessingResponse_ResponseBody{
+				ResponseBody: &extprocv3.BodyResponse{
+					Response: &extprocv3.CommonResponse{
+						HeaderMutation: headerMutation,
+						BodyMutation:   bodyMutation,
+					},
+				},
+			},
+		}, nil
+	}
+
+	var decoded []byte
+	if r.piiRestorer != nil || r.responseGuardrail != nil || r.toolPolicy != nil {
+		// Keep the decoded body to restore the PII in it, to check it with the guardrail, or to validate the tool calls,
+		// when the translator doesn't mutate it.
+		if decoded, err = io.ReadAll(br); err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		br = bytes.NewReader(decoded)
+	}
+	headerMutation, bodyMutation, tokenUsage, err := r.translator.ResponseBody(r.responseHeaders, br, body.EndOfStream)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform response: %w", err)
+	}
+	var blocked *extprocv3.ImmediateResponse
+	if r.toolPolicy != nil {
+		part := decoded
+		if bodyMutation != nil {
+			part = bodyMutation.GetBody()
+		}
+		bodyMutation, blocked = r.toolPolicy.check(part, body.EndOfStream)
+	}
+	if r.responseGuardrail != nil && blocked == nil {
+		part := decoded
+		if bodyMutation != nil {
+			part = bodyMutation.GetBody()
+		}
+		bodyMutation, blocked = r.responseGuardrail.check(ctx, part, body.EndOfStream)
+	}
+	// The PII is restored after the checks so that the original values are not sent to the guardrail service.
+	if r.piiRestorer != nil && blocked == nil {
+		bodyMutation = restorePII(r.piiRestorer, r.stream, decoded, bodyMutation, body.EndOfStream)
+	}
+	if bodyMutation != nil && isGzip {
+		if headerMutation == nil {
+			headerMutation = &extprocv3.HeaderMutation{}
+		}
+		// If the response was gzipped, ensure we remove the content-encoding header since the mutated body is not compressed.
+		headerMutation.RemoveHeaders = append(headerMutation.RemoveHeaders, "content-encoding")
+	}
+
+	resp := &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ResponseBody{
+			ResponseBody: &extprocv3.BodyResponse{
+				Response: &extprocv3.CommonResponse{
+					HeaderMutation: headerMutation,
+					BodyMutation:   bodyMutation,
+				},
+			},
+		},
+	}
+	if blocked != nil {
+		// The blocked response is replaced while the usage of the backend is still accounted below.
+		resp.Response = &extprocv3.ProcessingResponse_ImmediateResponse{ImmediateResponse: blocked}
+	}
+
+	r.costs.InputTokens += tokenUsage.InputTokens
+	r.costs.OutputTokens += tokenUsage.OutputTokens
+	r.costs.TotalTokens += tokenUsage.TotalTokens
+
+	// Update metrics with token usage.
+	r.metrics.RecordTokenUsage(ctx, tokenUsage.InputTokens, tokenUsage.OutputTokens, tokenUsage.TotalTokens, r.requestHeaders)
+	if r.stream {
+		// Token latency is only recorded for streaming responses, otherwise it doesn't make sense since
+		// these metrics are defined as a difference between the two output events.
+		r.metrics.RecordTokenLatency(ctx, tokenUsage.OutputTokens, r.requestHeaders)
+	}
+
+	if body.EndOfStream && r.config.recordsCosts() {
+		resp.DynamicMetadata, err = buildDynamicMetadata(r.config, &r.costs, r.requestHeaders, r.modelNameOverride, r.backendName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build dynamic metadata: %w", err)
+		}
+	}
+	return resp, nil
+}
+
+// SetBackend implements [Processor.SetBackend].
+func (r *responsesProcessorUpstreamFilter) SetBackend(ctx context.Context, b *filterapi.Backend, backendHandler backendauth.Handler, routeProcessor Processor) (err error) {
+	defer func() {
+		r.metrics.RecordRequestCompletion(ctx, err == nil, r.requestHeaders)
+	}()
+	rp, ok := routeProcessor.(*responsesProcessorRouterFilter)
+	if !ok {
+		panic("BUG: expected routeProcessor to be of type *responsesProcessorRouterFilter")
+	}
+	rp.upstreamFilterCount++
+	r.metrics.SetBackend(b)
+	r.modelNameOverride = b.ModelNameOverride
+	r.backendName = b.Name
+	if err = r.selectTranslator(b.Schema); err != nil {
+		return fmt.Errorf("failed to select translator: %w", err)
+	}
+	r.handler = backendHandler
+	r.originalRequestBody = rp.originalRequestBody
+	r.originalRequestBodyRaw = rp.originalRequestBodyRaw
+	r.onRetry = rp.upstreamFilterCount > 1
+	r.stream = r.originalRequestBody.Stream
+	r.guardrailMutated = rp.guardrailMutated
+	r.piiRestorer = rp.piiRestorer
+	r.responseGuardrail = newResponseGuardrail(rp.guardrail, responsesFormat{}, r.logger,
+		r.requestHeaders[r.config.modelNameHeaderKey], r.originalRequestBodyRaw, r.stream)
+	r.toolPolicy = newResponseToolPolicy(rp.toolValidator, responsesFormat{}, r.logger, r.stream, false)
+	rp.upstreamFilter = r
+	return
+}
+
+func parseOpenAIResponseBody(body *extprocv3.HttpBody) (modelName string, rb *openai.ResponseRequest, err error) {
+	var openAIReq openai.ResponseRequest
+	if err := json.Unmarshal(body.Body, &openAIReq); err != nil {
+		return "", nil, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	return openAIReq.Model, &openAIReq, nil
+}
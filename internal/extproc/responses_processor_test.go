@@ -0,0 +1,496 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+	"github.com/envoyproxy/ai-gateway/internal/guardrail"
+	"github.com/envoyproxy/ai-gateway/internal/pii"
+	"github.com/envoyproxy/ai-gateway/internal/toolpolicy"
+	tracing "github.com/envoyproxy/ai-gateway/internal/tracing/api"
+)
+
+func TestResponses_Schema(t *testing.T) {
+	t.Run("on route", func(t *testing.T) {
+		p, err := ResponsesProcessorFactory(nil, nil)(&processorConfig{}, nil, slog.Default(), tracing.NoopTracing{}, false)
+		require.NoError(t, err)
+		require.IsType(t, &responsesProcessorRouterFilter{}, p)
+	})
+	t.Run("on upstream", func(t *testing.T) {
+		p, err := ResponsesProcessorFactory(nil, nil)(&processorConfig{}, nil, slog.Default(), tracing.NoopTracing{}, true)
+		require.NoError(t, err)
+		require.IsType(t, &responsesProcessorUpstreamFilter{}, p)
+	})
+}
+
+func Test_responsesProcessorUpstreamFilter_SelectTranslator(t *testing.T) {
+	r := &responsesProcessorUpstreamFilter{}
+	t.Run("unsupported", func(t *testing.T) {
+		err := r.selectTranslator(filterapi.VersionedAPISchema{Name: "Bar", Version: "v123"})
+		require.ErrorContains(t, err, "unsupported API schema: backend={Bar v123}")
+	})
+	for _, s := range []filterapi.APISchemaName{
+		filterapi.APISchemaOpenAI,
+		filterapi.APISchemaAzureOpenAI,
+		filterapi.APISchemaAWSBedrock,
+		filterapi.APISchemaGCPVertexAI,
+		filterapi.APISchemaGCPAnthropic,
+		filterapi.APISchemaAnthropic,
+	} {
+		t.Run(string(s), func(t *testing.T) {
+			r.translator = nil
+			require.NoError(t, r.selectTranslator(filterapi.VersionedAPISchema{Name: s}))
+			require.NotNil(t, r.translator)
+		})
+	}
+}
+
+func responsesBodyFromModel(_ *testing.T, model string, stream bool) []byte {
+	return fmt.Appendf(nil, `{"model":"%s","input":"hello","stream":%v}`, model, stream)
+}
+
+func Test_responsesProcessorRouterFilter_ProcessRequestBody(t *testing.T) {
+	t.Run("body parser error", func(t *testing.T) {
+		p := &responsesProcessorRouterFilter{}
+		_, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte("nonjson")})
+		require.ErrorContains(t, err, "invalid character 'o' in literal null")
+	})
+	t.Run("ok", func(t *testing.T) {
+		headers := map[string]string{":path": "/v1/responses"}
+		const modelKey = "x-ai-gateway-model-key"
+		p := &responsesProcessorRouterFilter{
+			config:         &processorConfig{modelNameHeaderKey: modelKey},
+			requestHeaders: headers,
+			logger:         slog.Default(),
+		}
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: responsesBodyFromModel(t, "some-model", false)})
+		require.NoError(t, err)
+		re, ok := resp.Response.(*extprocv3.ProcessingResponse_RequestBody)
+		require.True(t, ok)
+		require.True(t, re.RequestBody.GetResponse().GetClearRouteCache())
+		setHeaders := re.RequestBody.GetResponse().GetHeaderMutation().SetHeaders
+		require.Len(t, setHeaders, 2)
+		require.Equal(t, modelKey, setHeaders[0].Header.Key)
+		require.Equal(t, "some-model", string(setHeaders[0].Header.RawValue))
+		require.Equal(t, originalPathHeader, setHeaders[1].Header.Key)
+		require.Equal(t, "/v1/responses", string(setHeaders[1].Header.RawValue))
+		require.Equal(t, "some-model", headers[modelKey])
+		require.Equal(t, "hello", *p.originalRequestBody.Input.Text)
+	})
+}
+
+func Test_responsesProcessorUpstreamFilter_ProcessRequestHeaders(t *testing.T) {
+	const modelKey = "x-ai-gateway-model-key"
+	for _, onRetry := range []bool{false, true} {
+		t.Run(fmt.Sprintf("ok/onRetry=%v", onRetry), func(t *testing.T) {
+			someBody := responsesBodyFromModel(t, "some-model", false)
+			headers := map[string]string{":path": "/v1/responses", modelKey: "some-model"}
+			headerMut := &extprocv3.HeaderMutation{
+				SetHeaders: []*corev3.HeaderValueOption{{Header: &corev3.HeaderValue{Key: "foo", RawValue: []byte("bar")}}},
+			}
+			bodyMut := &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: []byte("some body")}}
+			var expBody openai.ResponseRequest
+			require.NoError(t, json.Unmarshal(someBody, &expBody))
+			mt := &mockResponsesTranslator{
+				t: t, expRequestBody: &expBody, retHeaderMutation: headerMut, retBodyMutation: bodyMut,
+				expForceRequestBodyMutation: onRetry,
+			}
+			mm := &mockChatCompletionMetrics{}
+			p := &responsesProcessorUpstreamFilter{
+				config:                 &processorConfig{modelNameHeaderKey: modelKey, metadataNamespace: "ns"},
+				requestHeaders:         headers,
+				logger:                 slog.Default(),
+				metrics:                mm,
+				translator:             mt,
+				originalRequestBodyRaw: someBody,
+				originalRequestBody:    &expBody,
+				handler:                &mockBackendAuthHandler{},
+				onRetry:                onRetry,
+			}
+			resp, err := p.ProcessRequestHeaders(t.Context(), nil)
+			require.NoError(t, err)
+			commonRes := resp.Response.(*extprocv3.ProcessingResponse_RequestHeaders).RequestHeaders.Response
+			require.Equal(t, headerMut, commonRes.HeaderMutation)
+			require.Equal(t, bodyMut, commonRes.BodyMutation)
+			require.Equal(t, extprocv3.CommonResponse_CONTINUE_AND_REPLACE, commonRes.Status)
+			require.Equal(t, "bar", headers["foo"])
+			require.Equal(t, float64(len("some body")),
+				resp.DynamicMetadata.Fields["ns"].GetStructValue().Fields["content_length"].GetNumberValue())
+			mm.RequireRequestNotCompleted(t)
+			mm.RequireSelectedModel(t, "some-model")
+		})
+	}
+	t.Run("translator error", func(t *testing.T) {
+		someBody := responsesBodyFromModel(t, "some-model", false)
+		var body openai.ResponseRequest
+		require.NoError(t, json.Unmarshal(someBody, &body))
+		mm := &mockChatCompletionMetrics{}
+		p := &responsesProcessorUpstreamFilter{
+			config:              &processorConfig{modelNameHeaderKey: modelKey},
+			requestHeaders:      map[string]string{modelKey: "some-model"},
+			logger:              slog.Default(),
+			metrics:             mm,
+			translator:          &mockResponsesTranslator{t: t, expRequestBody: &body, retErr: errors.New("test error")},
+			originalRequestBody: &body,
+		}
+		_, err := p.ProcessRequestHeaders(t.Context(), nil)
+		require.ErrorContains(t, err, "failed to transform request: test error")
+		mm.RequireRequestFailure(t)
+	})
+}
+
+func Test_responsesProcessorUpstreamFilter_ProcessResponseHeaders(t *testing.T) {
+	t.Run("error translation", func(t *testing.T) {
+		mm := &mockChatCompletionMetrics{}
+		mt := &mockResponsesTranslator{t: t, expHeaders: make(map[string]string), retErr: errors.New("test error")}
+		p := &responsesProcessorUpstreamFilter{translator: mt, metrics: mm}
+		_, err := p.ProcessResponseHeaders(t.Context(), nil)
+		require.ErrorContains(t, err, "test error")
+		mm.RequireRequestFailure(t)
+	})
+	for _, stream := range []bool{false, true} {
+		t.Run(fmt.Sprintf("ok/stream=%v", stream), func(t *testing.T) {
+			inHeaders := &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}}
+			mm := &mockChatCompletionMetrics{}
+			mt := &mockResponsesTranslator{t: t, expHeaders: map[string]string{":status": "200"}}
+			p := &responsesProcessorUpstreamFilter{translator: mt, metrics: mm, stream: stream}
+			res, err := p.ProcessResponseHeaders(t.Context(), inHeaders)
+			require.NoError(t, err)
+			if stream {
+				require.Equal(t, extprocv3http.ProcessingMode_STREAMED, res.ModeOverride.ResponseBodyMode)
+			} else {
+				require.Nil(t, res.ModeOverride)
+			}
+			mm.RequireRequestNotCompleted(t)
+		})
+	}
+}
+
+func Test_responsesProcessorUpstreamFilter_ProcessResponseBody(t *testing.T) {
+	t.Run("error translation", func(t *testing.T) {
+		mm := &mockChatCompletionMetrics{}
+		mt := &mockResponsesTranslator{t: t, retErr: errors.New("test error")}
+		p := &responsesProcessorUpstreamFilter{translator: mt, metrics: mm, responseHeaders: map[string]string{":status": "200"}}
+		_, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{})
+		require.ErrorContains(t, err, "test error")
+		mm.RequireRequestFailure(t)
+		mm.RequireTokensRecorded(t, 0)
+	})
+	t.Run("ok", func(t *testing.T) {
+		inBody := &extprocv3.HttpBody{Body: []byte("some-body"), EndOfStream: true}
+		expBodyMut := &extprocv3.BodyMutation{}
+		expHeadMut := &extprocv3.HeaderMutation{}
+		mm := &mockChatCompletionMetrics{}
+		mt := &mockResponsesTranslator{
+			t: t, expResponseBody: inBody,
+			retBodyMutation: expBodyMut, retHeaderMutation: expHeadMut,
+			retUsedToken: translator.LLMTokenUsage{InputTokens: 1, OutputTokens: 2, TotalTokens: 3},
+		}
+		p := &responsesProcessorUpstreamFilter{
+			translator: mt,
+			logger:     slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})),
+			metrics:    mm,
+			config: &processorConfig{
+				metadataNamespace: "ai_gateway_llm_ns",
+				requestCosts: []processorConfigRequestCost{
+					{LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeInputToken, MetadataKey: "input_token_usage"}},
+					{LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeOutputToken, MetadataKey: "output_token_usage"}},
+					{LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeTotalToken, MetadataKey: "total_token_usage"}},
+				},
+			},
+			backendName:     "some_backend",
+			responseHeaders: map[string]string{":status": "200"},
+			stream:          true,
+		}
+		res, err := p.ProcessResponseBody(t.Context(), inBody)
+		require.NoError(t, err)
+		commonRes := res.Response.(*extprocv3.ProcessingResponse_ResponseBody).ResponseBody.Response
+		require.Equal(t, expBodyMut, commonRes.BodyMutation)
+		require.Equal(t, expHeadMut, commonRes.HeaderMutation)
+		mm.RequireRequestSuccess(t)
+		mm.RequireTokensRecorded(t, 1)
+
+		ns := res.DynamicMetadata.Fields["ai_gateway_llm_ns"].GetStructValue()
+		require.Equal(t, float64(1), ns.Fields["input_token_usage"].GetNumberValue())
+		require.Equal(t, float64(2), ns.Fields["output_token_usage"].GetNumberValue())
+		require.Equal(t, float64(3), ns.Fields["total_token_usage"].GetNumberValue())
+		require.Equal(t, "some_backend", ns.Fields["backend_name"].GetStringValue())
+	})
+	t.Run("error response", func(t *testing.T) {
+		inBody := &extprocv3.HttpBody{Body: []byte("some-body"), EndOfStream: true}
+		mt := &mockResponsesTranslator{t: t}
+		p := &responsesProcessorUpstreamFilter{
+			translator:      mt,
+			metrics:         &mockChatCompletionMetrics{},
+			config:          &processorConfig{},
+			responseHeaders: map[string]string{":status": "500"},
+		}
+		_, err := p.ProcessResponseBody(t.Context(), inBody)
+		require.NoError(t, err)
+		require.True(t, mt.responseErrorCalled)
+	})
+}
+
+func Test_responsesProcessorUpstreamFilter_SetBackend(t *testing.T) {
+	t.Run("unsupported", func(t *testing.T) {
+		mm := &mockChatCompletionMetrics{}
+		p := &responsesProcessorUpstreamFilter{
+			config:         &processorConfig{},
+			requestHeaders: map[string]string{},
+			logger:         slog.Default(),
+			metrics:        mm,
+		}
+		err := p.SetBackend(t.Context(), &filterapi.Backend{
+			Name:   "some-backend",
+			Schema: filterapi.VersionedAPISchema{Name: "some-schema", Version: "v10.0"},
+		}, nil, &responsesProcessorRouterFilter{})
+		require.ErrorContains(t, err, "unsupported API schema: backend={some-schema v10.0}")
+		mm.RequireRequestFailure(t)
+		mm.RequireSelectedBackend(t, "some-backend")
+	})
+	t.Run("ok", func(t *testing.T) {
+		mm := &mockChatCompletionMetrics{}
+		p := &responsesProcessorUpstreamFilter{
+			config:         &processorConfig{},
+			requestHeaders: map[string]string{},
+			logger:         slog.Default(),
+			metrics:        mm,
+		}
+		rp := &responsesProcessorRouterFilter{originalRequestBody: &openai.ResponseRequest{Stream: true}, upstreamFilterCount: 1}
+		err := p.SetBackend(t.Context(), &filterapi.Backend{
+			Name:              "some-backend",
+			ModelNameOverride: "override",
+			Schema:            filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock},
+		}, nil, rp)
+		require.NoError(t, err)
+		require.True(t, p.stream)
+		require.True(t, p.onRetry)
+		require.Equal(t, "override", p.modelNameOverride)
+		require.Equal(t, p, rp.upstreamFilter)
+	})
+}
+
+func TestResponsesProcessorRouterFilter_ProcessResponseHeaders_ProcessResponseBody(t *testing.T) {
+	p := &responsesProcessorRouterFilter{}
+	_, err := p.ProcessResponseHeaders(t.Context(), nil)
+	require.NoError(t, err)
+	_, err = p.ProcessResponseBody(t.Context(), nil)
+	require.NoError(t, err)
+}
+
+func Test_responsesProcessor_Guardrail(t *testing.T) {
+	var decide func(check *guardrail.Check) *guardrail.Decision
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var check guardrail.Check
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&check))
+		require.Equal(t, guardrail.APIResponses, check.API)
+		require.Equal(t, "gpt-4o", check.Model)
+		_ = json.NewEncoder(w).Encode(decide(&check))
+	}))
+	defer service.Close()
+	s, err := guardrail.NewSet([]filterapi.Guardrail{{
+		Name: "ns/route", Matches: []filterapi.RouteRuleMatch{{Model: "gpt-4o"}}, Endpoint: service.URL, Request: true, Response: true,
+	}}, nil)
+	require.NoError(t, err)
+	defer func() { _ = s.CloseExcept(nil) }()
+	config := &processorConfig{modelNameHeaderKey: "x-ai-gateway-model-key", guardrails: s}
+	newRouter := func() *responsesProcessorRouterFilter {
+		return &responsesProcessorRouterFilter{
+			config: config, requestHeaders: map[string]string{":path": "/v1/responses"}, logger: slog.Default(),
+		}
+	}
+
+	t.Run("block request", func(t *testing.T) {
+		decide = func(*guardrail.Check) *guardrail.Decision {
+			return &guardrail.Decision{Action: guardrail.ActionBlock, Reason: "jailbreak"}
+		}
+		var logs bytes.Buffer
+		p := newRouter()
+		p.logger = slog.New(slog.NewTextHandler(&logs, nil))
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: responsesBodyFromModel(t, "gpt-4o", false)})
+		require.NoError(t, err)
+		ir := resp.GetImmediateResponse()
+		require.NotNil(t, ir)
+		require.Equal(t, http.StatusBadRequest, int(ir.Status.Code))
+		require.JSONEq(t, `{"type":"error","error":{"type":"guardrail_blocked","message":"the request is blocked by the guardrail"}}`, string(ir.Body))
+		// The decision on the blocked request is recorded as well.
+		require.Equal(t, guardrail.ActionBlock, p.guardrailDecision.Action)
+		require.Contains(t, logs.String(), `msg="the guardrail decided" phase=request action=block reason=jailbreak`)
+	})
+
+	t.Run("mutate request and block response", func(t *testing.T) {
+		const mutated = `{"model":"gpt-4o","input":"[redacted]"}`
+		decide = func(check *guardrail.Check) *guardrail.Decision {
+			if check.Phase == guardrail.PhaseRequest {
+				return &guardrail.Decision{Action: guardrail.ActionMutate, Request: []byte(mutated)}
+			}
+			require.JSONEq(t, mutated, string(check.Request))
+			require.JSONEq(t, `{"id":"resp_1","output":[]}`, string(check.Response))
+			return &guardrail.Decision{Action: guardrail.ActionBlock}
+		}
+		p := newRouter()
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: responsesBodyFromModel(t, "gpt-4o", false)})
+		require.NoError(t, err)
+		require.Nil(t, resp.GetImmediateResponse())
+		upstream := &responsesProcessorUpstreamFilter{
+			config: config, requestHeaders: p.requestHeaders, logger: slog.Default(), metrics: &mockChatCompletionMetrics{},
+		}
+		require.NoError(t, upstream.SetBackend(t.Context(), &filterapi.Backend{
+			Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+		}, nil, p))
+		// The mutated body is sent to the backend.
+		resp, err = upstream.ProcessRequestHeaders(t.Context(), nil)
+		require.NoError(t, err)
+		require.JSONEq(t, mutated, string(resp.GetRequestHeaders().GetResponse().GetBodyMutation().GetBody()))
+
+		_, err = p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}})
+		require.NoError(t, err)
+		resp, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte(`{"id":"resp_1","output":[]}`), EndOfStream: true})
+		require.NoError(t, err)
+		ir := resp.GetImmediateResponse()
+		require.NotNil(t, ir)
+		require.JSONEq(t, `{"type":"error","error":{"type":"guardrail_blocked","message":"the response is blocked by the guardrail"}}`, string(ir.Body))
+	})
+}
+
+func Test_responsesProcessor_ToolPolicy(t *testing.T) {
+	config := &processorConfig{modelNameHeaderKey: "x-ai-gateway-model-key", toolPolicies: toolpolicy.NewSet([]filterapi.ToolPolicy{{
+		Name: "ns/route", Matches: []filterapi.RouteRuleMatch{{Model: "gpt-4o"}}, AllowedFunctions: []string{"get_weather"},
+		Action: filterapi.ToolPolicyActionBlock,
+	}})}
+	newRouter := func() *responsesProcessorRouterFilter {
+		return &responsesProcessorRouterFilter{
+			config: config, requestHeaders: map[string]string{":path": "/v1/responses"}, logger: slog.Default(),
+		}
+	}
+
+	t.Run("not allowed", func(t *testing.T) {
+		resp, err := newRouter().ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(
+			`{"model":"gpt-4o","input":"hi","tools":[{"type":"function","name":"get_weather"},{"type":"function","name":"rm"}]}`)})
+		require.NoError(t, err)
+		ir := resp.GetImmediateResponse()
+		require.NotNil(t, ir)
+		require.Equal(t, http.StatusBadRequest, int(ir.Status.Code))
+		require.JSONEq(t, `{"type":"error","error":{"type":"tool_not_allowed","message":"the request declares the tools not allowed by the route: rm"}}`, string(ir.Body))
+	})
+
+	t.Run("block response", func(t *testing.T) {
+		p := newRouter()
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(
+			`{"model":"gpt-4o","input":"hi","tools":[{"type":"function","name":"get_weather"}]}`)})
+		require.NoError(t, err)
+		require.Nil(t, resp.GetImmediateResponse())
+		upstream := &responsesProcessorUpstreamFilter{
+			config: config, requestHeaders: p.requestHeaders, logger: slog.Default(), metrics: &mockChatCompletionMetrics{},
+		}
+		require.NoError(t, upstream.SetBackend(t.Context(), &filterapi.Backend{
+			Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+		}, nil, p))
+		_, err = upstream.ProcessRequestHeaders(t.Context(), nil)
+		require.NoError(t, err)
+		_, err = p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}})
+		require.NoError(t, err)
+
+		const body = `{"id":"resp_1","output":[{"type":"function_call","id":"fc_1","call_id":"1","name":"rm","arguments":"{}"}]}`
+		resp, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte(body), EndOfStream: true})
+		require.NoError(t, err)
+		ir := resp.GetImmediateResponse()
+		require.NotNil(t, ir)
+		require.Equal(t, http.StatusBadGateway, int(ir.Status.Code))
+		require.JSONEq(t, `{"type":"error","error":{"type":"tool_call_violation",`+
+			`"message":"the response contains the tool calls violating the tool policy of the route: rm: the function is not allowed"}}`, string(ir.Body))
+	})
+}
+
+func Test_responsesProcessor_PIIRedaction(t *testing.T) {
+	newConfig := func(t *testing.T, action filterapi.PIIRedactionAction) *processorConfig {
+		s, err := pii.NewSet([]filterapi.PIIRedaction{{
+			Name:      "ns/route",
+			Matches:   []filterapi.RouteRuleMatch{{Model: "gpt-4o"}},
+			Action:    action,
+			Detectors: []filterapi.PIIDetector{{Name: "email", Type: filterapi.PIIDetectorTypeEmail}},
+		}})
+		require.NoError(t, err)
+		return &processorConfig{modelNameHeaderKey: "x-ai-gateway-model-key", piiRedactions: s}
+	}
+	newRouter := func(config *processorConfig, pm *mockPIIMetrics) *responsesProcessorRouterFilter {
+		return &responsesProcessorRouterFilter{
+			config: config, requestHeaders: map[string]string{":path": "/v1/responses"}, logger: slog.Default(), piiMetrics: pm,
+		}
+	}
+
+	t.Run("reject", func(t *testing.T) {
+		pm := &mockPIIMetrics{}
+		p := newRouter(newConfig(t, filterapi.PIIRedactionActionReject), pm)
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{
+			Body: []byte(`{"model":"gpt-4o","input":"mail me at alice@example.com"}`),
+		})
+		require.NoError(t, err)
+		ir := resp.GetImmediateResponse()
+		require.NotNil(t, ir)
+		require.Equal(t, http.StatusBadRequest, int(ir.Status.Code))
+		require.JSONEq(t, `{"type":"error","error":{"type":"pii_detected","message":"the request contains personally identifiable information detected by email"}}`, string(ir.Body))
+		require.Equal(t, []string{"email/Reject/1"}, pm.detections)
+	})
+
+	t.Run("mask streaming", func(t *testing.T) {
+		config := newConfig(t, filterapi.PIIRedactionActionMask)
+		pm := &mockPIIMetrics{}
+		p := newRouter(config, pm)
+		_, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{
+			Body: []byte(`{"model":"gpt-4o","stream":true,"input":"mail me at alice@example.com"}`),
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"email/Mask/1"}, pm.detections)
+		upstream := &responsesProcessorUpstreamFilter{
+			config: config, requestHeaders: p.requestHeaders, logger: slog.Default(), metrics: &mockChatCompletionMetrics{},
+		}
+		require.NoError(t, upstream.SetBackend(t.Context(), &filterapi.Backend{
+			Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+		}, nil, p))
+
+		// The masked body is sent to the backend.
+		resp, err := upstream.ProcessRequestHeaders(t.Context(), nil)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"model":"gpt-4o","stream":true,"input":"mail me at [EMAIL_1]"}`,
+			string(resp.GetRequestHeaders().GetResponse().GetBodyMutation().GetBody()))
+
+		// The placeholder split across the chunks is restored once it completes.
+		_, err = upstream.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}})
+		require.NoError(t, err)
+		var out []byte
+		for i, chunk := range []string{
+			"event: response.output_text.delta\n" +
+				`data: {"type":"response.output_text.delta","output_index":0,"content_index":0,"delta":"Sent to [EMA"}` + "\n\n",
+			"event: response.output_text.delta\n" +
+				`data: {"type":"response.output_text.delta","output_index":0,"content_index":0,"delta":"IL_1]."}` + "\n\n",
+		} {
+			resp, err = upstream.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte(chunk), EndOfStream: i == 1})
+			require.NoError(t, err)
+			out = append(out, resp.GetResponseBody().GetResponse().GetBodyMutation().GetBody()...)
+		}
+		require.Equal(t, "event: response.output_text.delta\n"+
+			`data: {"type":"response.output_text.delta","output_index":0,"content_index":0,"delta":"Sent to alice@example.com."}`+"\n\n",
+			string(out))
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"reflect"
 	"slices"
 	"strings"
 	"sync"
@@ -26,10 +27,24 @@ import (
 	"google.golang.org/protobuf/encoding/prototext"
 
 	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/adaptivelb"
+	"github.com/envoyproxy/ai-gateway/internal/admission"
+	"github.com/envoyproxy/ai-gateway/internal/bodyheader"
+	"github.com/envoyproxy/ai-gateway/internal/circuitbreaker"
 	"github.com/envoyproxy/ai-gateway/internal/extproc/backendauth"
+	"github.com/envoyproxy/ai-gateway/internal/guardrail"
+	"github.com/envoyproxy/ai-gateway/internal/hedging"
 	"github.com/envoyproxy/ai-gateway/internal/internalapi"
 	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
+	"github.com/envoyproxy/ai-gateway/internal/mirror"
+	"github.com/envoyproxy/ai-gateway/internal/pii"
+	"github.com/envoyproxy/ai-gateway/internal/quota"
+	"github.com/envoyproxy/ai-gateway/internal/requestpolicy"
+	"github.com/envoyproxy/ai-gateway/internal/responsecache"
+	"github.com/envoyproxy/ai-gateway/internal/sessionaffinity"
+	"github.com/envoyproxy/ai-gateway/internal/toolpolicy"
 	tracing "github.com/envoyproxy/ai-gateway/internal/tracing/api"
+	"github.com/envoyproxy/ai-gateway/internal/virtualkey"
 )
 
 var (
@@ -47,6 +62,38 @@ type Server struct {
 	routerProcessorsPerReqIDMutex sync.RWMutex
 }
 
+// closeNewResources closes the resources created for the configuration which failed to load, except the ones kept from
+// the previous configuration which are still in use.
+func (s *Server) closeNewResources(prevConfig *processorConfig, limiter *quota.Limiter, cache responsecache.Cache,
+	semantic *responsecache.Semantic, mirrors *mirror.Set, guardrails *guardrail.Set,
+) {
+	var prev processorConfig
+	if prevConfig != nil {
+		prev = *prevConfig
+	}
+	if limiter != nil && limiter != prev.quota {
+		if err := limiter.Close(); err != nil {
+			s.logger.Warn("failed to close the quota limiter", slog.String("error", err.Error()))
+		}
+	}
+	if cache != nil && cache != prev.responseCache {
+		if err := cache.Close(); err != nil {
+			s.logger.Warn("failed to close the response cache", slog.String("error", err.Error()))
+		}
+	}
+	if semantic != nil && semantic != prev.semanticCache {
+		if err := semantic.Close(); err != nil {
+			s.logger.Warn("failed to close the semantic response cache", slog.String("error", err.Error()))
+		}
+	}
+	if err := mirrors.CloseExcept(prev.mirrors); err != nil {
+		s.logger.Warn("failed to close the mirrors", slog.String("error", err.Error()))
+	}
+	if err := guardrails.CloseExcept(prev.guardrails); err != nil {
+		s.logger.Warn("failed to close the guardrails", slog.String("error", err.Error()))
+	}
+}
+
 // NewServer creates a new external processor server.
 func NewServer(logger *slog.Logger, tracing tracing.Tracing) (*Server, error) {
 	srv := &Server{
@@ -59,8 +106,9 @@ func NewServer(logger *slog.Logger, tracing tracing.Tracing) (*Server, error) {
 }
 
 // LoadConfig updates the configuration of the external processor.
-func (s *Server) LoadConfig(ctx context.Context, config *filterapi.Config) error {
+func (s *Server) LoadConfig(ctx context.Context, config *filterapi.Config) (err error) {
 	backends := make(map[string]*processorConfigBackend, len(config.Backends))
+	var hasPrices bool
 	for _, backend := range config.Backends {
 		b := backend
 		var h backendauth.Handler
@@ -72,6 +120,7 @@ func (s *Server) LoadConfig(ctx context.Context, config *filterapi.Config) error
 			}
 		}
 		backends[b.Name] = &processorConfigBackend{b: &b, handler: h}
+		hasPrices = hasPrices || b.Price != nil
 	}
 
 	costs := make([]processorConfigRequestCost, 0, len(config.LLMRequestCosts))
@@ -88,18 +137,186 @@ func (s *Server) LoadConfig(ctx context.Context, config *filterapi.Config) error
 		costs = append(costs, processorConfigRequestCost{LLMRequestCost: c, celProg: prog})
 	}
 
+	prevConfig := s.config
+	var (
+		limiter    *quota.Limiter
+		cache      responsecache.Cache
+		semantic   *responsecache.Semantic
+		mirrors    *mirror.Set
+		guardrails *guardrail.Set
+	)
+	defer func() {
+		if err != nil {
+			s.closeNewResources(prevConfig, limiter, cache, semantic, mirrors, guardrails)
+		}
+	}()
+	if len(config.Quotas) > 0 {
+		if prevConfig != nil && prevConfig.quota != nil && reflect.DeepEqual(prevConfig.quotaConfig, config.Quotas) {
+			// Keep the tracked spend across the configuration updates unrelated to the quotas.
+			limiter = prevConfig.quota
+		} else {
+			var err error
+			limiter, err = quota.New(config.Quotas)
+			if err != nil {
+				return fmt.Errorf("cannot create quota limiter: %w", err)
+			}
+		}
+	}
+
+	if rc := config.ResponseCache; rc != nil {
+		if prevConfig != nil && prevConfig.responseCache != nil && reflect.DeepEqual(prevConfig.responseCacheConfig, rc) {
+			// Keep the cached responses across the configuration updates unrelated to the cache.
+			cache, semantic = prevConfig.responseCache, prevConfig.semanticCache
+		} else {
+			var err error
+			cache, err = responsecache.New(rc)
+			if err != nil {
+				return fmt.Errorf("cannot create response cache: %w", err)
+			}
+			if rc.Semantic != nil {
+				semantic, err = responsecache.NewSemantic(ctx, rc.Semantic)
+				if err != nil {
+					return fmt.Errorf("cannot create semantic response cache: %w", err)
+				}
+			}
+		}
+	}
+
+	var modelAliases map[string]string
+	for _, m := range config.Models {
+		for _, alias := range m.Aliases {
+			if modelAliases == nil {
+				modelAliases = make(map[string]string)
+			}
+			modelAliases[alias] = m.Name
+		}
+	}
+
+	// The observations of the unchanged route rules are kept across the configuration updates.
+	var prevLoadBalancers *adaptivelb.Set
+	if prevConfig != nil {
+		prevLoadBalancers = prevConfig.loadBalancers
+	}
+	loadBalancers := adaptivelb.NewSet(config.AdaptiveLoadBalancers, prevLoadBalancers)
+	bodyHeaders, err := bodyheader.New(config.BodyHeaders)
+	if err != nil {
+		return fmt.Errorf("cannot create body headers: %w", err)
+	}
+	// The mirrors of the unchanged route rules are kept across the configuration updates.
+	var prevMirrors *mirror.Set
+	if prevConfig != nil {
+		prevMirrors = prevConfig.mirrors
+	}
+	mirrors, err = mirror.NewSet(ctx, config.Mirrors, prevMirrors, s.logger)
+	if err != nil {
+		return fmt.Errorf("cannot create mirrors: %w", err)
+	}
+
+	// The states of the circuit breakers of the unchanged AIServiceBackends are kept across the configuration updates.
+	var prevCircuitBreakers *circuitbreaker.Set
+	if prevConfig != nil {
+		prevCircuitBreakers = prevConfig.circuitBreakers
+	}
+
+	// The in-flight requests and the consumed tokens of the backends with the unchanged limits are kept across the
+	// configuration updates.
+	var prevAdmission *admission.Controller
+	if prevConfig != nil {
+		prevAdmission = prevConfig.admission
+	}
+	admissionController, err := admission.New(config.AdmissionControl, prevAdmission)
+	if err != nil {
+		return fmt.Errorf("cannot create admission control: %w", err)
+	}
+	piiRedactions, err := pii.NewSet(config.PIIRedactions)
+	if err != nil {
+		return fmt.Errorf("cannot create PII redactions: %w", err)
+	}
+	// The connections to the guardrail services of the unchanged routes are kept across the configuration updates.
+	var prevGuardrails *guardrail.Set
+	if prevConfig != nil {
+		prevGuardrails = prevConfig.guardrails
+	}
+	guardrails, err = guardrail.NewSet(config.Guardrails, prevGuardrails)
+	if err != nil {
+		return fmt.Errorf("cannot create guardrails: %w", err)
+	}
+
 	newConfig := &processorConfig{
-		uuid:               config.UUID,
-		modelNameHeaderKey: config.ModelNameHeaderKey,
-		backends:           backends,
-		metadataNamespace:  config.MetadataNamespace,
-		requestCosts:       costs,
-		declaredModels:     config.Models,
+		uuid:                config.UUID,
+		modelNameHeaderKey:  config.ModelNameHeaderKey,
+		backends:            backends,
+		metadataNamespace:   config.MetadataNamespace,
+		requestCosts:        costs,
+		hasPrices:           hasPrices,
+		declaredModels:      config.Models,
+		modelAliases:        modelAliases,
+		responseCache:       cache,
+		responseCacheConfig: config.ResponseCache,
+		semanticCache:       semantic,
+		quota:               limiter,
+		quotaConfig:         config.Quotas,
+		virtualKeys:         virtualkey.NewSet(config.VirtualKeyPolicies),
+		loadBalancers:       loadBalancers,
+		bodyHeaders:         bodyHeaders,
+		mirrors:             mirrors,
+		sessionAffinities:   sessionaffinity.NewSet(config.SessionAffinities),
+		hedgings:            hedging.NewSet(config.Hedgings),
+		circuitBreakers:     circuitbreaker.NewSet(config.CircuitBreakers, prevCircuitBreakers),
+		admission:           admissionController,
+		piiRedactions:       piiRedactions,
+		guardrails:          guardrails,
+		toolPolicies:        toolpolicy.NewSet(config.ToolPolicies),
+		requestPolicies:     requestpolicy.NewSet(config.RequestPolicies),
 	}
 	s.config = newConfig // This is racey, but we don't care.
+	if prevConfig != nil && prevConfig.responseCache != nil && prevConfig.responseCache != cache {
+		if err := prevConfig.responseCache.Close(); err != nil {
+			s.logger.Warn("failed to close the previous response cache", slog.String("error", err.Error()))
+		}
+	}
+	if prevConfig != nil && prevConfig.semanticCache != nil && prevConfig.semanticCache != semantic {
+		if err := prevConfig.semanticCache.Close(); err != nil {
+			s.logger.Warn("failed to close the previous semantic response cache", slog.String("error", err.Error()))
+		}
+	}
+	if prevConfig != nil && prevConfig.quota != nil && prevConfig.quota != limiter {
+		if err := prevConfig.quota.Close(); err != nil {
+			s.logger.Warn("failed to close the previous quota limiter", slog.String("error", err.Error()))
+		}
+	}
+	if err := prevGuardrails.CloseExcept(guardrails); err != nil {
+		s.logger.Warn("failed to close the previous guardrails", slog.String("error", err.Error()))
+	}
+	if prevMirrors != nil {
+		// Closing the mirrors waits for the mirrored requests in flight, so this is done in the background.
+		go func() {
+			if err := prevMirrors.CloseExcept(mirrors); err != nil {
+				s.logger.Warn("failed to close the previous mirrors", slog.String("error", err.Error()))
+			}
+		}()
+	}
 	return nil
 }
 
+// BackendScores calls the given function with the name of the route rule, the name and the current score of each
+// backend of the route rules with the adaptive load balancing.
+func (s *Server) BackendScores(f func(rule, backend string, score float64)) {
+	if config := s.config; config != nil && config.loadBalancers != nil {
+		config.loadBalancers.Scores(f)
+	}
+}
+
+// CircuitBreakerStates calls the given function with the name of the AIServiceBackend and the current state of each
+// circuit breaker enforced by the filter.
+func (s *Server) CircuitBreakerStates(f func(backend string, state int64)) {
+	if config := s.config; config != nil {
+		config.circuitBreakers.States(func(name string, state circuitbreaker.State) {
+			f(name, int64(state))
+		})
+	}
+}
+
 // Register a new processor for the given request path.
 func (s *Server) Register(path string, newProcessor ProcessorFactory) {
 	s.processorFactories[path] = newProcessor
@@ -143,6 +360,9 @@ func (s *Server) Process(stream extprocv3.ExternalProcessor_ProcessServer) error
 	var reqID string
 	var logger *slog.Logger
 	defer func() {
+		if c, ok := p.(processorCloser); ok {
+			c.Close()
+		}
 		if !isUpstreamFilter {
 			s.routerProcessorsPerReqIDMutex.Lock()
 			defer s.routerProcessorsPerReqIDMutex.Unlock()
@@ -210,7 +430,12 @@ func (s *Server) Process(stream extprocv3.ExternalProcessor_ProcessServer) error
 		}
 
 		// At this point, p is guaranteed to be a valid processor either from the concrete processor or the passThroughProcessor.
-		resp, err := s.processMsg(ctx, logger, p, req)
+		var resp *extprocv3.ProcessingResponse
+		if isUpstreamFilter && (req.GetResponseHeaders() != nil || req.GetResponseBody() != nil) {
+			resp, err = s.processUpstreamResponseMsg(ctx, logger, p, req)
+		} else {
+			resp, err = s.processMsg(ctx, logger, p, req)
+		}
 		if err != nil {
 			s.logger.Error("error processing request message", slog.String("error", err.Error()))
 			return status.Errorf(codes.Unknown, "error processing request message: %v", err)
@@ -272,6 +497,38 @@ func (s *Server) processMsg(ctx context.Context, l *slog.Logger, p Processor, re
 	}
 }
 
+// processUpstreamResponseMsg processes the response messages at the upstream filter level, which are only sent
+// when the fallback is configured for the backend. See [upstreamResponseClassifier].
+func (s *Server) processUpstreamResponseMsg(ctx context.Context, l *slog.Logger, p Processor, req *extprocv3.ProcessingRequest) (*extprocv3.ProcessingResponse, error) {
+	c, ok := p.(upstreamResponseClassifier)
+	switch value := req.Request.(type) {
+	case *extprocv3.ProcessingRequest_ResponseHeaders:
+		if !ok {
+			return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseHeaders{
+				ResponseHeaders: &extprocv3.HeadersResponse{},
+			}}, nil
+		}
+		resp, err := c.ClassifyResponseHeaders(ctx, value.ResponseHeaders)
+		if err != nil {
+			return nil, fmt.Errorf("cannot classify response headers: %w", err)
+		}
+		l.Debug("upstream response headers classified", slog.Any("response", resp))
+		return resp, nil
+	default:
+		if !ok {
+			return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseBody{
+				ResponseBody: &extprocv3.BodyResponse{},
+			}}, nil
+		}
+		resp, err := c.ClassifyResponseBody(ctx, req.GetResponseBody())
+		if err != nil {
+			return nil, fmt.Errorf("cannot classify response body: %w", err)
+		}
+		l.Debug("upstream response body classified", slog.Any("response", resp))
+		return resp, nil
+	}
+}
+
 // setBackend retrieves the backend from the request attributes and sets it in the processor. This is only called
 // if the processor is an upstream filter.
 func (s *Server) setBackend(ctx context.Context, p Processor, reqID string, isEndpointPicker bool, req *extprocv3.ProcessingRequest) error {
@@ -11,10 +11,12 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"path/filepath"
 	"testing"
 	"time"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/stretchr/testify/require"
@@ -25,9 +27,12 @@ import (
 	"google.golang.org/protobuf/types/known/structpb"
 
 	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/adaptivelb"
+	"github.com/envoyproxy/ai-gateway/internal/circuitbreaker"
 	"github.com/envoyproxy/ai-gateway/internal/internalapi"
 	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
 	tracing "github.com/envoyproxy/ai-gateway/internal/tracing/api"
+	"github.com/envoyproxy/ai-gateway/internal/virtualkey"
 )
 
 func requireNewServerWithMockProcessor(t *testing.T) (*Server, *mockProcessor) {
@@ -58,7 +63,11 @@ func TestServer_LoadConfig(t *testing.T) {
 			Backends: []filterapi.Backend{
 				{Name: "kserve", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}},
 				{Name: "awsbedrock", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock}},
-				{Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}},
+				{
+					Name:   "openai",
+					Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+					Price:  &filterapi.ModelPrice{InputPerMillionTokens: 2.5, CachedInputPerMillionTokens: 1.25, OutputPerMillionTokens: 10},
+				},
 			},
 			Models: []filterapi.Model{
 				{
@@ -70,6 +79,7 @@ func TestServer_LoadConfig(t *testing.T) {
 					Name:      "gpt4.4444",
 					OwnedBy:   "openai",
 					CreatedAt: now,
+					Aliases:   []string{"gpt4", "gpt4-latest"},
 				},
 			},
 		}
@@ -88,10 +98,307 @@ func TestServer_LoadConfig(t *testing.T) {
 		require.Equal(t, "1 + 1", s.config.requestCosts[1].CEL)
 		prog := s.config.requestCosts[1].celProg
 		require.NotNil(t, prog)
-		val, err := llmcostcel.EvaluateProgram(prog, "", "", 1, 1, 1)
+		val, err := llmcostcel.EvaluateProgram(prog, "", "", 1, 1, 1, 0, 0, 0, nil)
 		require.NoError(t, err)
 		require.Equal(t, uint64(2), val)
 		require.Equal(t, config.Models, s.config.declaredModels)
+		require.Equal(t, map[string]string{"gpt4": "gpt4.4444", "gpt4-latest": "gpt4.4444"}, s.config.modelAliases)
+		require.Nil(t, s.config.responseCache)
+		require.True(t, s.config.hasPrices)
+		require.Equal(t, config.Backends[2].Price, s.config.backendPrice("openai"))
+		require.Nil(t, s.config.backendPrice("kserve"))
+		require.Nil(t, s.config.backendPrice("unknown"))
+	})
+	t.Run("response cache", func(t *testing.T) {
+		s, _ := requireNewServerWithMockProcessor(t)
+		config := &filterapi.Config{ResponseCache: &filterapi.ResponseCacheConfig{MaxEntries: 10}}
+		require.NoError(t, s.LoadConfig(t.Context(), config))
+		cache := s.config.responseCache
+		require.NotNil(t, cache)
+
+		// The cache is kept when the cache configuration is not changed.
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+			UUID:          "new",
+			ResponseCache: &filterapi.ResponseCacheConfig{MaxEntries: 10},
+		}))
+		require.Same(t, cache, s.config.responseCache)
+
+		// The cache is recreated when the cache configuration is changed.
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{ResponseCache: &filterapi.ResponseCacheConfig{MaxEntries: 20}}))
+		require.NotSame(t, cache, s.config.responseCache)
+
+		err := s.LoadConfig(t.Context(), &filterapi.Config{ResponseCache: &filterapi.ResponseCacheConfig{Redis: &filterapi.ResponseCacheRedisConfig{}}})
+		require.ErrorContains(t, err, "cannot create response cache: redis address is required")
+	})
+	t.Run("semantic response cache", func(t *testing.T) {
+		s, _ := requireNewServerWithMockProcessor(t)
+		newConfig := func(threshold float64) *filterapi.Config {
+			return &filterapi.Config{ResponseCache: &filterapi.ResponseCacheConfig{Semantic: &filterapi.SemanticResponseCacheConfig{
+				SimilarityThreshold: threshold,
+				Embedding: filterapi.SemanticResponseCacheEmbedding{
+					Endpoint: "http://localhost",
+					Backend:  filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}},
+				},
+			}}}
+		}
+		require.NoError(t, s.LoadConfig(t.Context(), newConfig(0.9)))
+		semantic := s.config.semanticCache
+		require.NotNil(t, semantic)
+
+		require.NoError(t, s.LoadConfig(t.Context(), newConfig(0.9)))
+		require.Same(t, semantic, s.config.semanticCache)
+
+		require.NoError(t, s.LoadConfig(t.Context(), newConfig(0.8)))
+		require.NotSame(t, semantic, s.config.semanticCache)
+
+		err := s.LoadConfig(t.Context(), newConfig(0))
+		require.ErrorContains(t, err, "cannot create semantic response cache: similarity threshold must be in the range (0, 1]")
+	})
+	t.Run("quotas", func(t *testing.T) {
+		s, _ := requireNewServerWithMockProcessor(t)
+		newConfig := func(limit int64) *filterapi.Config {
+			return &filterapi.Config{Quotas: []filterapi.QuotaPolicy{{
+				Name:            "ns/budget",
+				ClientKeyHeader: "x-api-key",
+				Budgets:         []filterapi.QuotaBudget{{Type: filterapi.QuotaBudgetTypeTotalToken, Limit: limit, Window: time.Hour}},
+			}}}
+		}
+		require.NoError(t, s.LoadConfig(t.Context(), newConfig(100)))
+		limiter := s.config.quota
+		require.NotNil(t, limiter)
+
+		// The tracked spend is kept while the quotas are unchanged.
+		require.NoError(t, s.LoadConfig(t.Context(), newConfig(100)))
+		require.Same(t, limiter, s.config.quota)
+
+		require.NoError(t, s.LoadConfig(t.Context(), newConfig(200)))
+		require.NotSame(t, limiter, s.config.quota)
+
+		err := s.LoadConfig(t.Context(), newConfig(0))
+		require.ErrorContains(t, err, "cannot create quota limiter: invalid quota policy ns/budget")
+	})
+	t.Run("adaptive load balancers", func(t *testing.T) {
+		s, _ := requireNewServerWithMockProcessor(t)
+		newConfig := func(window time.Duration) *filterapi.Config {
+			return &filterapi.Config{AdaptiveLoadBalancers: []filterapi.AdaptiveLoadBalancer{{
+				Name:          "ns/route/rule/0",
+				Matches:       []filterapi.RouteRuleMatch{{Model: "some-model"}},
+				Backends:      []filterapi.AdaptiveLoadBalancerBackend{{Name: "a", Weight: 1}},
+				LatencyWindow: window,
+			}}}
+		}
+		require.NoError(t, s.LoadConfig(t.Context(), newConfig(time.Second)))
+		lb := s.config.loadBalancers.Find("some-model", nil)
+		require.NotNil(t, lb)
+		lb.Observe("a", adaptivelb.Observation{TimeToFirstToken: time.Second})
+
+		scores := func() map[string]float64 {
+			ret := make(map[string]float64)
+			s.BackendScores(func(rule, backend string, score float64) { ret[rule+"/"+backend] = score })
+			return ret
+		}
+		require.Equal(t, map[string]float64{"ns/route/rule/0/a": 1}, scores())
+
+		// The observations are kept while the rule is unchanged.
+		require.NoError(t, s.LoadConfig(t.Context(), newConfig(time.Second)))
+		require.Same(t, lb, s.config.loadBalancers.Find("some-model", nil))
+		require.NoError(t, s.LoadConfig(t.Context(), newConfig(time.Minute)))
+		require.NotSame(t, lb, s.config.loadBalancers.Find("some-model", nil))
+		require.Equal(t, map[string]float64{"ns/route/rule/0/a": 0}, scores())
+	})
+	t.Run("body headers", func(t *testing.T) {
+		s, _ := requireNewServerWithMockProcessor(t)
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+			BodyHeaders: []filterapi.BodyHeader{{Name: "x-has-tools", CEL: "has(request.tools)"}},
+		}))
+		require.NotNil(t, s.config.bodyHeaders)
+		require.Equal(t, []string{"x-has-tools"}, s.config.bodyHeaders.Names())
+
+		err := s.LoadConfig(t.Context(), &filterapi.Config{
+			BodyHeaders: []filterapi.BodyHeader{{Name: "x-has-tools", CEL: "has("}},
+		})
+		require.ErrorContains(t, err, "cannot create body headers: invalid body header x-has-tools")
+	})
+	t.Run("failed load keeps the previous config", func(t *testing.T) {
+		s, _ := requireNewServerWithMockProcessor(t)
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+			ResponseCache: &filterapi.ResponseCacheConfig{MaxEntries: 10},
+			Quotas:        []filterapi.QuotaPolicy{{Name: "ns/policy", ClientKeyHeader: "x-user-id", Budgets: []filterapi.QuotaBudget{{Type: filterapi.QuotaBudgetTypeTotalToken, Limit: 10, Window: time.Minute}}}},
+		}))
+		prev := s.config
+
+		err := s.LoadConfig(t.Context(), &filterapi.Config{
+			ResponseCache: &filterapi.ResponseCacheConfig{MaxEntries: 20},
+			Quotas:        []filterapi.QuotaPolicy{{Name: "ns/policy", ClientKeyHeader: "x-user-id", Budgets: []filterapi.QuotaBudget{{Type: filterapi.QuotaBudgetTypeTotalToken, Limit: 20, Window: time.Minute}}}},
+			BodyHeaders:   []filterapi.BodyHeader{{Name: "x-has-tools", CEL: "has("}},
+		})
+		require.ErrorContains(t, err, "cannot create body headers")
+		require.Same(t, prev, s.config)
+	})
+	t.Run("mirrors", func(t *testing.T) {
+		s, _ := requireNewServerWithMockProcessor(t)
+		dir := t.TempDir()
+		newConfig := func(percentage int, file string) *filterapi.Config {
+			return &filterapi.Config{Mirrors: []filterapi.Mirror{{
+				Name:       "ns/route/rule/0",
+				Backends:   []string{"a"},
+				Endpoint:   "http://localhost",
+				Percentage: percentage,
+				Backend:    filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}},
+				Sink:       filterapi.MirrorSink{Type: filterapi.MirrorSinkTypeFile, FilePath: filepath.Join(dir, file)},
+			}}}
+		}
+		require.NoError(t, s.LoadConfig(t.Context(), newConfig(10, "mirror.jsonl")))
+		m := s.config.mirrors.Find("a")
+		require.NotNil(t, m)
+		require.Nil(t, s.config.mirrors.Find("b"))
+
+		// The mirror is kept while the rule is unchanged.
+		require.NoError(t, s.LoadConfig(t.Context(), newConfig(10, "mirror.jsonl")))
+		require.Same(t, m, s.config.mirrors.Find("a"))
+		require.NoError(t, s.LoadConfig(t.Context(), newConfig(20, "mirror.jsonl")))
+		require.NotSame(t, m, s.config.mirrors.Find("a"))
+
+		err := s.LoadConfig(t.Context(), newConfig(10, "no-such-dir/mirror.jsonl"))
+		require.ErrorContains(t, err, "cannot create mirrors: cannot create mirror ns/route/rule/0")
+	})
+	t.Run("session affinities", func(t *testing.T) {
+		s, _ := requireNewServerWithMockProcessor(t)
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{}))
+		require.Nil(t, s.config.sessionAffinities)
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{SessionAffinities: []filterapi.SessionAffinity{{
+			Name:    "ns/route/rule/0",
+			Matches: []filterapi.RouteRuleMatch{{Model: "m"}},
+			Type:    filterapi.SessionAffinityTypeHeader,
+			Header:  "x-session-id",
+		}}}))
+		require.Equal(t, "ns/route/rule/0", s.config.sessionAffinities.Find("m", nil).Name)
+	})
+	t.Run("hedgings", func(t *testing.T) {
+		s, _ := requireNewServerWithMockProcessor(t)
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{}))
+		require.Nil(t, s.config.hedgings)
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{Hedgings: []filterapi.Hedging{{
+			Name:    "ns/route/rule/0",
+			Matches: []filterapi.RouteRuleMatch{{Model: "m"}},
+			Delay:   time.Second,
+		}}}))
+		require.Equal(t, "ns/route/rule/0", s.config.hedgings.Find("m", nil).Name)
+	})
+	t.Run("circuit breakers", func(t *testing.T) {
+		s, _ := requireNewServerWithMockProcessor(t)
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{}))
+		require.Nil(t, s.config.circuitBreakers)
+		config := &filterapi.Config{CircuitBreakers: []filterapi.CircuitBreaker{{
+			Name:                "vertex.ns",
+			Backends:            []string{"ns/vertex/route/route/rule/0/ref/0"},
+			ConsecutiveFailures: 1,
+			FailOn:              []filterapi.CircuitBreakerErrorClass{filterapi.CircuitBreakerErrorClassThrottled},
+			OpenDuration:        time.Minute,
+			HalfOpenProbes:      1,
+		}}}
+		require.NoError(t, s.LoadConfig(t.Context(), config))
+		cb := s.config.circuitBreakers.Find("ns/vertex/route/route/rule/0/ref/0")
+		require.NotNil(t, cb)
+		cb.Failure(filterapi.CircuitBreakerErrorClassThrottled)
+
+		// The state of the unchanged circuit breaker is kept across the configuration updates.
+		require.NoError(t, s.LoadConfig(t.Context(), config))
+		states := map[string]int64{}
+		s.CircuitBreakerStates(func(backend string, state int64) { states[backend] = state })
+		require.Equal(t, map[string]int64{"vertex.ns": int64(circuitbreaker.StateOpen)}, states)
+	})
+	t.Run("admission control", func(t *testing.T) {
+		s, _ := requireNewServerWithMockProcessor(t)
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{}))
+		require.Nil(t, s.config.admission)
+		err := s.LoadConfig(t.Context(), &filterapi.Config{AdmissionControl: &filterapi.AdmissionControl{}})
+		require.ErrorContains(t, err, "cannot create admission control")
+
+		config := &filterapi.Config{AdmissionControl: &filterapi.AdmissionControl{
+			Classes:  []filterapi.PriorityClass{{Name: "paid"}},
+			Backends: []filterapi.AdmissionBackend{{Name: "openai", MaxInFlight: 1}},
+		}}
+		require.NoError(t, s.LoadConfig(t.Context(), config))
+		class := s.config.admission.Classify(map[string]string{})
+		_, _, admitted := s.config.admission.Admit("openai", class)
+		require.True(t, admitted)
+
+		// The in-flight requests are kept across the configuration updates.
+		require.NoError(t, s.LoadConfig(t.Context(), config))
+		_, _, admitted = s.config.admission.Admit("openai", class)
+		require.False(t, admitted)
+	})
+	t.Run("pii redactions", func(t *testing.T) {
+		s, _ := requireNewServerWithMockProcessor(t)
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{}))
+		require.Nil(t, s.config.piiRedactions)
+		err := s.LoadConfig(t.Context(), &filterapi.Config{PIIRedactions: []filterapi.PIIRedaction{{
+			Name:      "ns/route",
+			Detectors: []filterapi.PIIDetector{{Name: "bad", Type: filterapi.PIIDetectorTypeRegex, Regex: "("}},
+		}}})
+		require.ErrorContains(t, err, "cannot create PII redactions")
+
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{PIIRedactions: []filterapi.PIIRedaction{{
+			Name:      "ns/route",
+			Matches:   []filterapi.RouteRuleMatch{{Model: "gpt-4o"}},
+			Detectors: []filterapi.PIIDetector{{Name: "email", Type: filterapi.PIIDetectorTypeEmail}},
+		}}}))
+		require.NotNil(t, s.config.piiRedactions.Find("gpt-4o", nil))
+	})
+	t.Run("guardrails", func(t *testing.T) {
+		s, _ := requireNewServerWithMockProcessor(t)
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{}))
+		require.Nil(t, s.config.guardrails)
+		err := s.LoadConfig(t.Context(), &filterapi.Config{Guardrails: []filterapi.Guardrail{{Name: "ns/route", Protocol: "SMTP"}}})
+		require.ErrorContains(t, err, "cannot create guardrails")
+
+		config := &filterapi.Config{Guardrails: []filterapi.Guardrail{{
+			Name:     "ns/route",
+			Matches:  []filterapi.RouteRuleMatch{{Model: "gpt-4o"}},
+			Endpoint: "http://localhost:8080/check",
+			Request:  true,
+		}}}
+		require.NoError(t, s.LoadConfig(t.Context(), config))
+		g := s.config.guardrails.Find("gpt-4o", nil)
+		require.NotNil(t, g)
+		// The unchanged guardrail is kept across the configuration updates.
+		require.NoError(t, s.LoadConfig(t.Context(), config))
+		require.Same(t, g, s.config.guardrails.Find("gpt-4o", nil))
+	})
+	t.Run("tool policies", func(t *testing.T) {
+		s, _ := requireNewServerWithMockProcessor(t)
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{}))
+		require.Nil(t, s.config.toolPolicies)
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{ToolPolicies: []filterapi.ToolPolicy{{
+			Name:    "ns/route",
+			Matches: []filterapi.RouteRuleMatch{{Model: "gpt-4o"}},
+		}}}))
+		require.Equal(t, "ns/route", s.config.toolPolicies.Find("gpt-4o", nil).Name())
+	})
+	t.Run("request policies", func(t *testing.T) {
+		s, _ := requireNewServerWithMockProcessor(t)
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{}))
+		require.Nil(t, s.config.requestPolicies)
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{RequestPolicies: []filterapi.RequestPolicy{{
+			Name:    "ns/route/rule/0",
+			Matches: []filterapi.RouteRuleMatch{{Model: "gpt-4o"}},
+		}}}))
+		require.Equal(t, "ns/route/rule/0", s.config.requestPolicies.Find("gpt-4o", nil).Name())
+	})
+	t.Run("virtual keys", func(t *testing.T) {
+		s, _ := requireNewServerWithMockProcessor(t)
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{}))
+		require.Nil(t, s.config.virtualKeys)
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{VirtualKeyPolicies: []filterapi.VirtualKeyPolicy{{
+			Name:            "ns/keys",
+			Models:          []string{"gpt-4o"},
+			ClientKeyHeader: "authorization",
+			Keys:            []filterapi.VirtualKey{{Name: "ns/keys/a", Hash: virtualkey.Hash("sk-a"), TeamID: "team-a"}},
+		}}}))
+		k, err := s.config.virtualKeys.Authenticate("gpt-4o", map[string]string{"authorization": "Bearer sk-a"})
+		require.NoError(t, err)
+		require.Equal(t, "ns/keys/a", k.Name())
 	})
 }
 
@@ -209,6 +516,33 @@ func TestServer_processMsg(t *testing.T) {
 	})
 }
 
+func TestServer_processUpstreamResponseMsg(t *testing.T) {
+	t.Run("not classifier", func(t *testing.T) {
+		s, p := requireNewServerWithMockProcessor(t)
+		resp, err := s.processUpstreamResponseMsg(t.Context(), slog.Default(), p, &extprocv3.ProcessingRequest{
+			Request: &extprocv3.ProcessingRequest_ResponseHeaders{ResponseHeaders: &extprocv3.HttpHeaders{}},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, resp.GetResponseHeaders())
+		resp, err = s.processUpstreamResponseMsg(t.Context(), slog.Default(), p, &extprocv3.ProcessingRequest{
+			Request: &extprocv3.ProcessingRequest_ResponseBody{ResponseBody: &extprocv3.HttpBody{}},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, resp.GetResponseBody())
+	})
+	t.Run("classifier", func(t *testing.T) {
+		s, _ := requireNewServerWithMockProcessor(t)
+		p := &chatCompletionProcessorUpstreamFilter{logger: slog.Default()}
+		resp, err := s.processUpstreamResponseMsg(t.Context(), slog.Default(), p, &extprocv3.ProcessingRequest{
+			Request: &extprocv3.ProcessingRequest_ResponseHeaders{ResponseHeaders: &extprocv3.HttpHeaders{
+				Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "503"}}},
+			}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, extprocv3http.ProcessingMode_BUFFERED, resp.GetModeOverride().GetResponseBodyMode())
+	})
+}
+
 func TestServer_Process(t *testing.T) {
 	t.Run("context done", func(t *testing.T) {
 		s, _ := requireNewServerWithMockProcessor(t)
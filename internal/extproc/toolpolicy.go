@@ -0,0 +1,189 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/toolpolicy"
+)
+
+const (
+	// toolNotAllowedErrorType is the type of the error returned when the request declares the functions not
+	// allowed by the tool policy.
+	toolNotAllowedErrorType = "tool_not_allowed"
+	// toolCallViolationErrorType is the type of the error returned when the response is blocked by the tool
+	// policy.
+	toolCallViolationErrorType = "tool_call_violation"
+)
+
+// checkToolPolicy checks the functions declared by the request with the tool policy of the route when it applies to
+// the request, and remembers the validator of the tool calls of the response. This returns the immediate response
+// when the request is rejected, otherwise nil.
+func (c *chatCompletionProcessorRouterFilter) checkToolPolicy(model string, body *openai.ChatCompletionRequest) *extprocv3.ProcessingResponse {
+	p := c.config.toolPolicies.Find(model, c.requestHeaders)
+	if p == nil {
+		return nil
+	}
+	v, schemaErrs, err := p.Check(body)
+	if resp := toolPolicyRequest(c.logger, p, chatCompletionFormat{}, schemaErrs, err); resp != nil {
+		return resp
+	}
+	c.toolValidator = v
+	return nil
+}
+
+// checkToolPolicy is the same as the one of the chat completion processor for the responses API.
+func (r *responsesProcessorRouterFilter) checkToolPolicy(model string, body *openai.ResponseRequest) *extprocv3.ProcessingResponse {
+	p := r.config.toolPolicies.Find(model, r.requestHeaders)
+	if p == nil {
+		return nil
+	}
+	v, schemaErrs, err := p.CheckResponses(body)
+	if resp := toolPolicyRequest(r.logger, p, responsesFormat{}, schemaErrs, err); resp != nil {
+		return resp
+	}
+	r.toolValidator = v
+	return nil
+}
+
+// checkToolPolicy is the same as the one of the chat completion processor for the Anthropic messages API.
+func (r *messagesProcessorRouterFilter) checkToolPolicy(model string, body *anthropic.MessagesRequest) *extprocv3.ProcessingResponse {
+	p := r.config.toolPolicies.Find(model, r.requestHeaders)
+	if p == nil {
+		return nil
+	}
+	v, schemaErrs, err := p.CheckMessages(body)
+	if resp := toolPolicyRequest(r.logger, p, messagesFormat{}, schemaErrs, err); resp != nil {
+		return resp
+	}
+	r.toolValidator = v
+	return nil
+}
+
+// toolPolicyRequest returns the immediate response in the given format rejecting the request when checking its
+// declared functions with the given policy fails with [*toolpolicy.NotAllowedError]. Otherwise, this logs the
+// parameters which are not validated, and returns nil.
+func toolPolicyRequest(logger *slog.Logger, p *toolpolicy.Policy, f apiFormat, schemaErrs []error, err error) *extprocv3.ProcessingResponse {
+	var notAllowed *toolpolicy.NotAllowedError
+	if errors.As(err, &notAllowed) {
+		return errorResponse(f, typev3.StatusCode_BadRequest, &openai.ErrorType{Type: toolNotAllowedErrorType, Message: notAllowed.Error()})
+	}
+	for _, e := range schemaErrs {
+		logger.Warn("the arguments of the function are not validated", slog.String("route", p.Name()), slog.String("error", e.Error()))
+	}
+	return nil
+}
+
+// responseToolPolicy validates the tool calls of the response to the request checked by the tool policy.
+type responseToolPolicy struct {
+	validator *toolpolicy.Validator
+	format    apiFormat
+	logger    *slog.Logger
+	stream    bool
+	// includeUsage is true when the streaming chat completion response includes the usage chunk.
+	includeUsage bool
+	// buf holds back the non-streaming response body until its tool calls are validated.
+	buf []byte
+	// violated is set to true when the response contains the tool calls violating the policy.
+	violated bool
+}
+
+// newResponseToolPolicy returns the [responseToolPolicy] validating the tool calls of the response in the given
+// format with the given validator. This returns nil when the validator is nil, i.e. when the tool policy doesn't
+// apply to the request, or the request declares no tool.
+func newResponseToolPolicy(v *toolpolicy.Validator, f apiFormat, logger *slog.Logger, stream, includeUsage bool) *responseToolPolicy {
+	if v == nil {
+		return nil
+	}
+	return &responseToolPolicy{validator: v, format: f, logger: logger, stream: stream, includeUsage: includeUsage}
+}
+
+// check validates the tool calls of the given part of the response in the format of the API, i.e. the body mutation
+// by the translator, or the decoded response body when the translator doesn't mutate it. The non-streaming response
+// is held back until its end, and the streaming one from the first event with the tool calls.
+//
+// The returned body mutation releases the response as is, or rewritten without the violating tool calls. When the
+// tool policy blocks the non-streaming response, this returns the immediate response replacing it instead. The
+// blocked streaming response ends with the error event since the response headers have already been sent to the
+// client.
+func (t *responseToolPolicy) check(part []byte, endOfStream bool) (*extprocv3.BodyMutation, *extprocv3.ImmediateResponse) {
+	out := part
+	var violations []toolpolicy.Violation
+	var err error
+	if t.stream {
+		out, violations, err = t.validator.Stream(out, endOfStream, t.includeUsage)
+	} else {
+		t.buf = append(t.buf, out...)
+		if !endOfStream {
+			return &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: []byte{}}}, nil
+		}
+		out, t.buf = t.buf, nil
+		violations, err = t.validator.Validate(out)
+	}
+	if err != nil {
+		// The response which cannot be validated is released as is since it cannot contain the valid tool calls either.
+		t.logger.Warn("failed to validate the tool calls of the response", slog.String("error", err.Error()))
+	}
+	if len(violations) > 0 {
+		p := t.validator.Policy()
+		t.logger.Info("the response contains the tool calls violating the tool policy",
+			slog.String("route", p.Name()), slog.String("action", string(p.Action())), slog.String("violations", toolCallViolations(violations)))
+		t.violated = true
+		rewrite := p.Action() == filterapi.ToolPolicyActionRewrite
+		switch {
+		case !t.stream && rewrite:
+			if out, err = t.validator.Rewrite(out, violations); err != nil {
+				t.logger.Warn("failed to rewrite the response", slog.String("error", err.Error()))
+				return nil, toolCallViolationResponse(t.format, violations)
+			}
+		case !t.stream:
+			return nil, toolCallViolationResponse(t.format, violations)
+		case !rewrite:
+			// The validator has dropped the events from the first tool call, and the rewritten streaming response
+			// needs nothing more.
+			e := toolCallViolationError(violations)
+			out = append(out, t.format.errorEvents(&e)...)
+		}
+	}
+	if out == nil {
+		// Nothing is released by this chunk, which is still a mutation of the body.
+		out = []byte{}
+	}
+	return &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: out}}, nil
+}
+
+// toolCallViolations returns the description of the given violations.
+func toolCallViolations(violations []toolpolicy.Violation) string {
+	descs := make([]string, len(violations))
+	for i, v := range violations {
+		descs[i] = v.String()
+	}
+	return strings.Join(descs, "; ")
+}
+
+// toolCallViolationError returns the OpenAI error of the response blocked with the given violations.
+func toolCallViolationError(violations []toolpolicy.Violation) openai.ErrorType {
+	return openai.ErrorType{
+		Type:    toolCallViolationErrorType,
+		Message: "the response contains the tool calls violating the tool policy of the route: " + toolCallViolations(violations),
+	}
+}
+
+// toolCallViolationResponse returns the immediate response in the given format replacing the non-streaming response
+// blocked with the given violations.
+func toolCallViolationResponse(f apiFormat, violations []toolpolicy.Violation) *extprocv3.ImmediateResponse {
+	e := toolCallViolationError(violations)
+	return errorResponse(f, typev3.StatusCode_BadGateway, &e).GetImmediateResponse()
+}
@@ -0,0 +1,52 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"fmt"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/tidwall/sjson"
+
+	anthropicschema "github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+)
+
+// NewMessagesAnthropicToAnthropicTranslator implements [Factory] for Anthropic to Anthropic translation
+// for /v1/messages. The body is passed through except for the model name override, and the
+// "anthropic-version" header is set.
+func NewMessagesAnthropicToAnthropicTranslator(apiVersion string, modelNameOverride string) AnthropicMessagesTranslator {
+	return &anthropicToAnthropicTranslatorV1Messages{
+		anthropicToGCPAnthropicTranslatorV1Messages: anthropicToGCPAnthropicTranslatorV1Messages{
+			apiVersion:        apiVersion,
+			modelNameOverride: modelNameOverride,
+		},
+	}
+}
+
+// anthropicToAnthropicTranslatorV1Messages inherits the response handling of the GCP Anthropic translator
+// since the response format is the same.
+type anthropicToAnthropicTranslatorV1Messages struct {
+	anthropicToGCPAnthropicTranslatorV1Messages
+}
+
+// RequestBody implements [AnthropicMessagesTranslator.RequestBody].
+func (a *anthropicToAnthropicTranslatorV1Messages) RequestBody(raw []byte, req *anthropicschema.MessagesRequest, forceBodyMutation bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	a.stream = req.Stream
+	var body []byte
+	if a.modelNameOverride != "" {
+		body, err = sjson.SetBytesOptions(raw, "model", a.modelNameOverride, SJSONOptions)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to set model name: %w", err)
+		}
+	} else if forceBodyMutation {
+		body = raw
+	}
+	headerMutation, bodyMutation = buildRequestMutations(anthropicMessagesPath, body)
+	setAnthropicVersionHeader(headerMutation, a.apiVersion)
+	return
+}
@@ -0,0 +1,77 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	anthropicschema "github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+)
+
+func TestAnthropicToAnthropicTranslatorV1Messages_RequestBody(t *testing.T) {
+	const raw = `{"model":"claude-3","max_tokens":10,"messages":[{"role":"user","content":"hi"}]}`
+	for _, tc := range []struct {
+		name              string
+		apiVersion        string
+		override          string
+		forceBodyMutation bool
+		expBody           string
+		expVersion        string
+	}{
+		{name: "passthrough", expVersion: "2023-06-01"},
+		{name: "force body mutation", forceBodyMutation: true, expBody: raw, expVersion: "2023-06-01"},
+		{
+			name:       "override",
+			apiVersion: "2099-01-01",
+			override:   "claude-override",
+			expBody:    strings.Replace(raw, "claude-3", "claude-override", 1),
+			expVersion: "2099-01-01",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var req anthropicschema.MessagesRequest
+			require.NoError(t, json.Unmarshal([]byte(raw), &req))
+			tr := NewMessagesAnthropicToAnthropicTranslator(tc.apiVersion, tc.override)
+			hm, bm, err := tr.RequestBody([]byte(raw), &req, tc.forceBodyMutation)
+			require.NoError(t, err)
+
+			headers := map[string]string{}
+			for _, h := range hm.SetHeaders {
+				headers[h.Header.Key] = string(h.Header.RawValue)
+			}
+			require.Equal(t, "/v1/messages", headers[":path"])
+			require.Equal(t, tc.expVersion, headers["anthropic-version"])
+			if tc.expBody == "" {
+				require.Nil(t, bm)
+				return
+			}
+			require.JSONEq(t, tc.expBody, string(bm.GetBody()))
+		})
+	}
+}
+
+func TestAnthropicToAnthropicTranslatorV1Messages_ResponseBody(t *testing.T) {
+	tr := NewMessagesAnthropicToAnthropicTranslator("", "")
+	req := &anthropicschema.MessagesRequest{Model: "claude", Stream: true}
+	_, _, err := tr.RequestBody([]byte(`{"model":"claude","stream":true}`), req, false)
+	require.NoError(t, err)
+
+	const events = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"model":"claude","usage":{"input_tokens":25,"output_tokens":1}}}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn","stop_sequence":null},"usage":{"output_tokens":15}}
+
+`
+	_, bm, usage, err := tr.ResponseBody(nil, strings.NewReader(events), true)
+	require.NoError(t, err)
+	require.Nil(t, bm)
+	require.Equal(t, LLMTokenUsage{InputTokens: 25, OutputTokens: 15, TotalTokens: 40}, usage)
+}
@@ -0,0 +1,585 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/google/uuid"
+	"k8s.io/utils/ptr"
+
+	anthropicschema "github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// NewMessagesAnthropicToOpenAITranslator implements [Factory] for Anthropic Messages to OpenAI chat completion translation.
+func NewMessagesAnthropicToOpenAITranslator(apiVersion string, modelNameOverride string) AnthropicMessagesTranslator {
+	return newAnthropicToChatCompletionTranslator(NewChatCompletionOpenAIToOpenAITranslator(apiVersion, modelNameOverride), modelNameOverride)
+}
+
+// NewMessagesAnthropicToAWSBedrockTranslator implements [Factory] for Anthropic Messages to AWS Bedrock Converse translation.
+func NewMessagesAnthropicToAWSBedrockTranslator(modelNameOverride string) AnthropicMessagesTranslator {
+	return newAnthropicToChatCompletionTranslator(NewChatCompletionOpenAIToAWSBedrockTranslator(modelNameOverride), modelNameOverride)
+}
+
+func newAnthropicToChatCompletionTranslator(chat OpenAIChatCompletionTranslator, modelNameOverride string) *anthropicToChatCompletionTranslator {
+	return &anthropicToChatCompletionTranslator{
+		chat:              chat,
+		modelNameOverride: modelNameOverride,
+		id:                "msg_" + strings.ReplaceAll(uuid.NewString(), "-", ""),
+		openBlockIndex:    -1,
+		toolCallIndexes:   make(map[int]int),
+	}
+}
+
+// anthropicToChatCompletionTranslator implements [AnthropicMessagesTranslator] for the backends that do not natively
+// support the Anthropic Messages API.
+//
+// Like the translators for the Responses API, the request is first converted into the [openai.ChatCompletionRequest],
+// and then translated by the existing [OpenAIChatCompletionTranslator] of the backend. The response is translated back
+// by the same translator into the OpenAI chat completion format, which is then converted into the
+// [anthropicschema.Message] or the sequence of [anthropicschema.StreamEvent].
+type anthropicToChatCompletionTranslator struct {
+	chat              OpenAIChatCompletionTranslator
+	modelNameOverride string
+	// id is used as the message ID since the backends do not have the equivalent.
+	id     string
+	model  string
+	stream bool
+	// The following fields are used only for streaming responses.
+	//
+	// buffered is the partial SSE line produced by the chat completion translator that is not yet processed.
+	buffered []byte
+	// started is true once the message_start event is sent.
+	started bool
+	// stopped is true once the message_stop event is sent.
+	stopped bool
+	// blockCount is the number of content blocks started so far.
+	blockCount int
+	// openBlockIndex is the index of the content block that is being streamed, or -1 if none.
+	openBlockIndex int
+	// openBlockIsText is true if the open block is a text block.
+	openBlockIsText bool
+	// toolCallIndexes maps the chat completion tool call index to the index of the content block.
+	toolCallIndexes map[int]int
+	finishReason    openai.ChatCompletionChoicesFinishReason
+	usage           LLMTokenUsage
+}
+
+// RequestBody implements [AnthropicMessagesTranslator.RequestBody].
+func (a *anthropicToChatCompletionTranslator) RequestBody(_ []byte, req *anthropicschema.MessagesRequest, _ bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	chatReq, err := anthropicRequestToChatCompletionRequest(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to convert messages request to chat completion request: %w", err)
+	}
+	a.stream = req.Stream
+	a.model = req.Model
+	if a.modelNameOverride != "" {
+		a.model = a.modelNameOverride
+	}
+	raw, err := json.Marshal(chatReq)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal chat completion request: %w", err)
+	}
+	// The body is always mutated since the schema of the body is different.
+	return a.chat.RequestBody(raw, chatReq, true)
+}
+
+// ResponseHeaders implements [AnthropicMessagesTranslator.ResponseHeaders].
+func (a *anthropicToChatCompletionTranslator) ResponseHeaders(headers map[string]string) (*extprocv3.HeaderMutation, error) {
+	return a.chat.ResponseHeaders(headers)
+}
+
+// ResponseError implements [AnthropicMessagesTranslator.ResponseError].
+//
+// The chat completion translator converts the backend error into the OpenAI error format, which is then
+// converted into the Anthropic error format.
+func (a *anthropicToChatCompletionTranslator) ResponseError(respHeaders map[string]string, body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	buf, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read error body: %w", err)
+	}
+	_, chatBodyMutation, err := a.chat.ResponseError(respHeaders, bytes.NewReader(buf))
+	if err != nil {
+		return nil, nil, err
+	}
+	if b := chatBodyMutation.GetBody(); b != nil {
+		buf = b
+	}
+	message := string(buf)
+	var openAIErr openai.Error
+	if json.Unmarshal(buf, &openAIErr) == nil && openAIErr.Error.Message != "" {
+		message = openAIErr.Error.Message
+	}
+	return anthropicErrorMutation(respHeaders[statusHeaderName], message)
+}
+
+// ResponseBody implements [AnthropicMessagesTranslator.ResponseBody].
+func (a *anthropicToChatCompletionTranslator) ResponseBody(respHeaders map[string]string, body io.Reader, endOfStream bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	buf, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to read body: %w", err)
+	}
+	_, chatBodyMutation, tokenUsage, err := a.chat.ResponseBody(respHeaders, bytes.NewReader(buf), endOfStream)
+	if err != nil {
+		return nil, nil, tokenUsage, err
+	}
+	// The chat completion translator returns nil body mutation when the body is not changed,
+	// e.g. the OpenAI backend.
+	if chatBodyMutation != nil {
+		buf = chatBodyMutation.GetBody()
+	}
+	mut := &extprocv3.BodyMutation_Body{}
+	if a.stream {
+		a.usage.InputTokens += tokenUsage.InputTokens
+		a.usage.OutputTokens += tokenUsage.OutputTokens
+		a.usage.TotalTokens += tokenUsage.TotalTokens
+		mut.Body = a.convertChatCompletionStream(buf, endOfStream)
+		return nil, &extprocv3.BodyMutation{Mutation: mut}, tokenUsage, nil
+	}
+
+	var chatResp openai.ChatCompletionResponse
+	if err = json.Unmarshal(buf, &chatResp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal chat completion response: %w", err)
+	}
+	msg, err := a.chatCompletionResponseToMessage(&chatResp)
+	if err != nil {
+		return nil, nil, tokenUsage, err
+	}
+	mut.Body, err = json.Marshal(msg)
+	if err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	headerMutation = &extprocv3.HeaderMutation{}
+	setContentLength(headerMutation, mut.Body)
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, tokenUsage, nil
+}
+
+// chatCompletionResponseToMessage converts the [openai.ChatCompletionResponse] into the [anthropicschema.Message].
+func (a *anthropicToChatCompletionTranslator) chatCompletionResponseToMessage(chatResp *openai.ChatCompletionResponse) (*anthropicschema.Message, error) {
+	msg := a.newMessage()
+	var finishReason openai.ChatCompletionChoicesFinishReason
+	if len(chatResp.Choices) > 0 {
+		choice := &chatResp.Choices[0]
+		finishReason = choice.FinishReason
+		if content := choice.Message.Content; content != nil && *content != "" {
+			msg.Content = append(msg.Content, anthropicschema.ContentBlock{Type: anthropicschema.ContentBlockTypeText, Text: content})
+		}
+		for i := range choice.Message.ToolCalls {
+			tc := &choice.Message.ToolCalls[i]
+			input := json.RawMessage(jsonOrEmptyObject(tc.Function.Arguments))
+			if !json.Valid(input) {
+				return nil, fmt.Errorf("invalid tool call arguments: %s", tc.Function.Arguments)
+			}
+			msg.Content = append(msg.Content, anthropicschema.ContentBlock{
+				Type:  anthropicschema.ContentBlockTypeToolUse,
+				ID:    ptr.Deref(tc.ID, ""),
+				Name:  tc.Function.Name,
+				Input: input,
+			})
+		}
+	}
+	msg.StopReason = ptr.To(chatFinishReasonToAnthropicStopReason(finishReason))
+	msg.Usage = anthropicschema.Usage{
+		InputTokens:  int64(chatResp.Usage.PromptTokens),
+		OutputTokens: int64(chatResp.Usage.CompletionTokens),
+	}
+	return msg, nil
+}
+
+func (a *anthropicToChatCompletionTranslator) newMessage() *anthropicschema.Message {
+	return &anthropicschema.Message{
+		ID:      a.id,
+		Type:    "message",
+		Role:    anthropicschema.RoleAssistant,
+		Content: []anthropicschema.ContentBlock{},
+		Model:   a.model,
+	}
+}
+
+func jsonOrEmptyObject(s string) string {
+	if s == "" {
+		return "{}"
+	}
+	return s
+}
+
+// chatFinishReasonToAnthropicStopReason converts the chat completion finish reason into the Anthropic stop reason.
+func chatFinishReasonToAnthropicStopReason(reason openai.ChatCompletionChoicesFinishReason) string {
+	switch reason {
+	case openai.ChatCompletionChoicesFinishReasonLength:
+		return anthropicschema.StopReasonMaxTokens
+	case openai.ChatCompletionChoicesFinishReasonToolCalls:
+		return anthropicschema.StopReasonToolUse
+	case openai.ChatCompletionChoicesFinishReasonContentFilter:
+		return anthropicschema.StopReasonRefusal
+	default:
+		return anthropicschema.StopReasonEndTurn
+	}
+}
+
+// convertChatCompletionStream converts the chat completion SSE chunks into the Anthropic SSE events.
+func (a *anthropicToChatCompletionTranslator) convertChatCompletionStream(chatBody []byte, endOfStream bool) []byte {
+	var out []byte
+	if !a.started {
+		a.started = true
+		out = appendAnthropicEvent(out, &anthropicschema.StreamEvent{
+			Type: anthropicschema.StreamEventTypeMessageStart, Message: a.newMessage(),
+		})
+	}
+
+	a.buffered = append(a.buffered, chatBody...)
+	for {
+		i := bytes.IndexByte(a.buffered, '\n')
+		if i == -1 {
+			break
+		}
+		line := bytes.TrimSpace(a.buffered[:i])
+		a.buffered = a.buffered[i+1:]
+		if !bytes.HasPrefix(line, dataPrefix) {
+			continue
+		}
+		data := bytes.TrimPrefix(line, dataPrefix)
+		if bytes.Equal(data, sseDoneMessage) {
+			out = a.stop(out)
+			continue
+		}
+		var chunk openai.ChatCompletionResponseChunk
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			continue
+		}
+		out = a.handleChunk(out, &chunk)
+	}
+	if endOfStream {
+		out = a.stop(out)
+	}
+	return out
+}
+
+// handleChunk converts a single chat completion chunk into the Anthropic events.
+func (a *anthropicToChatCompletionTranslator) handleChunk(out []byte, chunk *openai.ChatCompletionResponseChunk) []byte {
+	if a.stopped {
+		return out
+	}
+	for i := range chunk.Choices {
+		choice := &chunk.Choices[i]
+		if choice.FinishReason != "" {
+			a.finishReason = choice.FinishReason
+		}
+		delta := choice.Delta
+		if delta == nil {
+			continue
+		}
+		if delta.Content != nil && *delta.Content != "" {
+			if a.openBlockIndex == -1 || !a.openBlockIsText {
+				out = a.closeBlock(out)
+				out = a.startBlock(out, &anthropicschema.ContentBlock{Type: anthropicschema.ContentBlockTypeText, Text: ptr.To("")}, true)
+			}
+			out = appendAnthropicEvent(out, &anthropicschema.StreamEvent{
+				Type:  anthropicschema.StreamEventTypeContentBlockDelta,
+				Index: ptr.To(a.openBlockIndex),
+				Delta: &anthropicschema.StreamDelta{Type: anthropicschema.DeltaTypeText, Text: *delta.Content},
+			})
+		}
+		for j := range delta.ToolCalls {
+			out = a.handleToolCallDelta(out, &delta.ToolCalls[j])
+		}
+	}
+	return out
+}
+
+func (a *anthropicToChatCompletionTranslator) handleToolCallDelta(out []byte, tc *openai.ChatCompletionMessageToolCallParam) []byte {
+	index := a.openBlockIndex
+	if tc.ID != nil && *tc.ID != "" {
+		out = a.closeBlock(out)
+		out = a.startBlock(out, &anthropicschema.ContentBlock{
+			Type:  anthropicschema.ContentBlockTypeToolUse,
+			ID:    *tc.ID,
+			Name:  tc.Function.Name,
+			Input: json.RawMessage("{}"),
+		}, false)
+		index = a.openBlockIndex
+		if tc.Index != nil {
+			a.toolCallIndexes[*tc.Index] = index
+		}
+	} else if tc.Index != nil {
+		if idx, ok := a.toolCallIndexes[*tc.Index]; ok {
+			index = idx
+		}
+	}
+	if index == -1 || tc.Function.Arguments == "" {
+		return out
+	}
+	return appendAnthropicEvent(out, &anthropicschema.StreamEvent{
+		Type:  anthropicschema.StreamEventTypeContentBlockDelta,
+		Index: ptr.To(index),
+		Delta: &anthropicschema.StreamDelta{Type: anthropicschema.DeltaTypeInputJSON, PartialJSON: ptr.To(tc.Function.Arguments)},
+	})
+}
+
+func (a *anthropicToChatCompletionTranslator) startBlock(out []byte, block *anthropicschema.ContentBlock, isText bool) []byte {
+	a.openBlockIndex = a.blockCount
+	a.openBlockIsText = isText
+	a.blockCount++
+	return appendAnthropicEvent(out, &anthropicschema.StreamEvent{
+		Type:         anthropicschema.StreamEventTypeContentBlockStart,
+		Index:        ptr.To(a.openBlockIndex),
+		ContentBlock: block,
+	})
+}
+
+func (a *anthropicToChatCompletionTranslator) closeBlock(out []byte) []byte {
+	if a.openBlockIndex == -1 {
+		return out
+	}
+	index := a.openBlockIndex
+	a.openBlockIndex = -1
+	return appendAnthropicEvent(out, &anthropicschema.StreamEvent{
+		Type:  anthropicschema.StreamEventTypeContentBlockStop,
+		Index: ptr.To(index),
+	})
+}
+
+// stop closes the open content block and sends the message_delta with the stop reason and the usage,
+// followed by the message_stop event.
+func (a *anthropicToChatCompletionTranslator) stop(out []byte) []byte {
+	if a.stopped {
+		return out
+	}
+	a.stopped = true
+	out = a.closeBlock(out)
+	out = appendAnthropicEvent(out, &anthropicschema.StreamEvent{
+		Type:  anthropicschema.StreamEventTypeMessageDelta,
+		Delta: &anthropicschema.StreamDelta{StopReason: chatFinishReasonToAnthropicStopReason(a.finishReason)},
+		Usage: &anthropicschema.Usage{
+			InputTokens:  int64(a.usage.InputTokens),
+			OutputTokens: int64(a.usage.OutputTokens),
+		},
+	})
+	return appendAnthropicEvent(out, &anthropicschema.StreamEvent{Type: anthropicschema.StreamEventTypeMessageStop})
+}
+
+// appendAnthropicEvent appends the SSE encoded event to the buffer.
+func appendAnthropicEvent(out []byte, event *anthropicschema.StreamEvent) []byte {
+	data, err := json.Marshal(event)
+	if err != nil {
+		panic(fmt.Errorf("failed to marshal event: %w", err))
+	}
+	out = append(out, "event: "...)
+	out = append(out, event.Type...)
+	out = append(out, '\n')
+	out = append(out, dataPrefix...)
+	out = append(out, data...)
+	return append(out, '\n', '\n')
+}
+
+// anthropicRequestToChatCompletionRequest converts the [anthropicschema.MessagesRequest] into the [openai.ChatCompletionRequest].
+func anthropicRequestToChatCompletionRequest(req *anthropicschema.MessagesRequest) (*openai.ChatCompletionRequest, error) {
+	chatReq := &openai.ChatCompletionRequest{
+		Model:       req.Model,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		Stream:      req.Stream,
+	}
+	if req.MaxTokens > 0 {
+		chatReq.MaxCompletionTokens = ptr.To(req.MaxTokens)
+	}
+	if len(req.StopSequences) > 0 {
+		chatReq.Stop = req.StopSequences
+	}
+	if req.Stream {
+		// Token usage is only reported in the last chunk when include_usage is set.
+		chatReq.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	}
+	if req.Metadata != nil {
+		chatReq.User = req.Metadata.UserID
+	}
+	if s := req.System; s != nil {
+		var system string
+		if s.Text != nil {
+			system = *s.Text
+		}
+		for i := range s.Blocks {
+			if s.Blocks[i].Type != anthropicschema.ContentBlockTypeText {
+				return nil, fmt.Errorf("unsupported system content block type: %s", s.Blocks[i].Type)
+			}
+			if i > 0 {
+				system += "\n"
+			}
+			system += s.Blocks[i].Text
+		}
+		chatReq.Messages = append(chatReq.Messages, openai.ChatCompletionMessageParamUnion{
+			Type: openai.ChatMessageRoleSystem,
+			Value: openai.ChatCompletionSystemMessageParam{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: openai.StringOrArray{Value: system},
+			},
+		})
+	}
+	for i := range req.Messages {
+		var err error
+		chatReq.Messages, err = appendAnthropicMessageAsChatMessages(chatReq.Messages, &req.Messages[i])
+		if err != nil {
+			return nil, fmt.Errorf("messages[%d]: %w", i, err)
+		}
+	}
+
+	for i := range req.Tools {
+		tool := &req.Tools[i]
+		if tool.Type != "" && tool.Type != "custom" {
+			return nil, fmt.Errorf("unsupported tool type: %s", tool.Type)
+		}
+		fd := &openai.FunctionDefinition{Name: tool.Name, Description: tool.Description}
+		if tool.InputSchema != nil {
+			fd.Parameters = tool.InputSchema
+		}
+		chatReq.Tools = append(chatReq.Tools, openai.Tool{Type: openai.ToolTypeFunction, Function: fd})
+	}
+
+	if tc := req.ToolChoice; tc != nil {
+		switch tc.Type {
+		case anthropicschema.ToolChoiceTypeAuto:
+			chatReq.ToolChoice = "auto"
+		case anthropicschema.ToolChoiceTypeAny:
+			chatReq.ToolChoice = "required"
+		case anthropicschema.ToolChoiceTypeNone:
+			chatReq.ToolChoice = "none"
+		case anthropicschema.ToolChoiceTypeTool:
+			chatReq.ToolChoice = openai.ToolChoice{Type: openai.ToolTypeFunction, Function: openai.ToolFunction{Name: tc.Name}}
+		default:
+			return nil, fmt.Errorf("unsupported tool_choice type: %s", tc.Type)
+		}
+		if tc.DisableParallelToolUse != nil && len(chatReq.Tools) > 0 {
+			chatReq.ParallelToolCalls = ptr.To(!*tc.DisableParallelToolUse)
+		}
+	}
+	return chatReq, nil
+}
+
+// appendAnthropicMessageAsChatMessages converts the [anthropicschema.MessageParam] into the chat completion messages
+// and appends them. A user message can be converted into multiple messages since the tool results are separate
+// "tool" messages in the chat completion API.
+func appendAnthropicMessageAsChatMessages(msgs []openai.ChatCompletionMessageParamUnion, m *anthropicschema.MessageParam) ([]openai.ChatCompletionMessageParamUnion, error) {
+	switch m.Role {
+	case anthropicschema.RoleUser:
+		if m.Content.Text != nil {
+			return append(msgs, openai.ChatCompletionMessageParamUnion{Type: openai.ChatMessageRoleUser, Value: openai.ChatCompletionUserMessageParam{
+				Role: openai.ChatMessageRoleUser, Content: openai.StringOrUserRoleContentUnion{Value: *m.Content.Text},
+			}}), nil
+		}
+		var parts []openai.ChatCompletionContentPartUserUnionParam
+		for i := range m.Content.Blocks {
+			block := &m.Content.Blocks[i]
+			switch block.Type {
+			case anthropicschema.ContentBlockTypeText:
+				parts = append(parts, openai.ChatCompletionContentPartUserUnionParam{
+					TextContent: &openai.ChatCompletionContentPartTextParam{Type: string(openai.ChatCompletionContentPartTextTypeText), Text: block.Text},
+				})
+			case anthropicschema.ContentBlockTypeImage:
+				url, err := anthropicSourceToURL(block.Source)
+				if err != nil {
+					return nil, err
+				}
+				parts = append(parts, openai.ChatCompletionContentPartUserUnionParam{
+					ImageContent: &openai.ChatCompletionContentPartImageParam{
+						Type:     openai.ChatCompletionContentPartImageTypeImageURL,
+						ImageURL: openai.ChatCompletionContentPartImageImageURLParam{URL: url},
+					},
+				})
+			case anthropicschema.ContentBlockTypeToolResult:
+				content, err := anthropicToolResultToText(block.Content)
+				if err != nil {
+					return nil, err
+				}
+				msgs = append(msgs, openai.ChatCompletionMessageParamUnion{Type: openai.ChatMessageRoleTool, Value: openai.ChatCompletionToolMessageParam{
+					Role: openai.ChatMessageRoleTool, ToolCallID: block.ToolUseID, Content: openai.StringOrArray{Value: content},
+				}})
+			default:
+				return nil, fmt.Errorf("unsupported content block type for user message: %s", block.Type)
+			}
+		}
+		if len(parts) == 0 {
+			return msgs, nil
+		}
+		return append(msgs, openai.ChatCompletionMessageParamUnion{Type: openai.ChatMessageRoleUser, Value: openai.ChatCompletionUserMessageParam{
+			Role: openai.ChatMessageRoleUser, Content: openai.StringOrUserRoleContentUnion{Value: parts},
+		}}), nil
+	case anthropicschema.RoleAssistant:
+		assistant := openai.ChatCompletionAssistantMessageParam{Role: openai.ChatMessageRoleAssistant}
+		var text string
+		if m.Content.Text != nil {
+			text = *m.Content.Text
+		}
+		for i := range m.Content.Blocks {
+			block := &m.Content.Blocks[i]
+			switch block.Type {
+			case anthropicschema.ContentBlockTypeText:
+				text += block.Text
+			case anthropicschema.ContentBlockTypeToolUse:
+				assistant.ToolCalls = append(assistant.ToolCalls, openai.ChatCompletionMessageToolCallParam{
+					ID:   ptr.To(block.ID),
+					Type: openai.ChatCompletionMessageToolCallTypeFunction,
+					Function: openai.ChatCompletionMessageToolCallFunctionParam{
+						Name:      block.Name,
+						Arguments: jsonOrEmptyObject(string(block.Input)),
+					},
+				})
+			case anthropicschema.ContentBlockTypeThinking, anthropicschema.ContentBlockTypeRedactedThinking:
+				// Thinking blocks are specific to Anthropic models, so they are dropped.
+			default:
+				return nil, fmt.Errorf("unsupported content block type for assistant message: %s", block.Type)
+			}
+		}
+		assistant.Content = openai.StringOrAssistantRoleContentUnion{Value: text}
+		return append(msgs, openai.ChatCompletionMessageParamUnion{Type: openai.ChatMessageRoleAssistant, Value: assistant}), nil
+	default:
+		return nil, fmt.Errorf("unsupported message role: %s", m.Role)
+	}
+}
+
+// anthropicSourceToURL converts the image source into the URL accepted by the chat completion API.
+func anthropicSourceToURL(source *anthropicschema.Source) (string, error) {
+	if source == nil {
+		return "", fmt.Errorf("image source is required")
+	}
+	switch source.Type {
+	case anthropicschema.SourceTypeBase64:
+		return fmt.Sprintf("data:%s;base64,%s", source.MediaType, source.Data), nil
+	case anthropicschema.SourceTypeURL:
+		return source.URL, nil
+	default:
+		return "", fmt.Errorf("unsupported image source type: %s", source.Type)
+	}
+}
+
+// anthropicToolResultToText converts the content of the tool result into the text.
+func anthropicToolResultToText(content *anthropicschema.MessageContent) (string, error) {
+	if content == nil {
+		return "", nil
+	}
+	if content.Text != nil {
+		return *content.Text, nil
+	}
+	var sb strings.Builder
+	for i := range content.Blocks {
+		if content.Blocks[i].Type != anthropicschema.ContentBlockTypeText {
+			return "", fmt.Errorf("unsupported content block type for tool result: %s", content.Blocks[i].Type)
+		}
+		sb.WriteString(content.Blocks[i].Text)
+	}
+	return sb.String(), nil
+}
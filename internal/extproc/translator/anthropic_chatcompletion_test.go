@@ -0,0 +1,275 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	anthropicschema "github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestAnthropicRequestToChatCompletionRequest(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		const in = `{
+"model":"claude","max_tokens":100,"temperature":0.5,"stream":true,"stop_sequences":["END"],
+"system":[{"type":"text","text":"be nice"},{"type":"text","text":"be brief"}],
+"metadata":{"user_id":"user-1"},
+"messages":[
+  {"role":"user","content":"what is the weather?"},
+  {"role":"assistant","content":[{"type":"thinking","thinking":"hmm","signature":"sig"},{"type":"text","text":"Let me check."},
+    {"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"city":"SF"}}]},
+  {"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":[{"type":"text","text":"sunny"}]},
+    {"type":"text","text":"and this?"},{"type":"image","source":{"type":"base64","media_type":"image/png","data":"AAA"}}]}
+],
+"tools":[{"name":"get_weather","description":"weather","input_schema":{"type":"object"}}],
+"tool_choice":{"type":"any","disable_parallel_tool_use":true}
+}`
+		var req anthropicschema.MessagesRequest
+		require.NoError(t, json.Unmarshal([]byte(in), &req))
+		chatReq, err := anthropicRequestToChatCompletionRequest(&req)
+		require.NoError(t, err)
+
+		require.Equal(t, "claude", chatReq.Model)
+		require.Equal(t, ptr.To[int64](100), chatReq.MaxCompletionTokens)
+		require.Equal(t, ptr.To(0.5), chatReq.Temperature)
+		require.Equal(t, []string{"END"}, chatReq.Stop)
+		require.Equal(t, "user-1", chatReq.User)
+		require.True(t, chatReq.Stream)
+		require.Equal(t, &openai.StreamOptions{IncludeUsage: true}, chatReq.StreamOptions)
+		require.Len(t, chatReq.Messages, 5)
+		require.Equal(t, openai.ChatCompletionSystemMessageParam{
+			Role: openai.ChatMessageRoleSystem, Content: openai.StringOrArray{Value: "be nice\nbe brief"},
+		}, chatReq.Messages[0].Value)
+		require.Equal(t, openai.ChatCompletionUserMessageParam{
+			Role: openai.ChatMessageRoleUser, Content: openai.StringOrUserRoleContentUnion{Value: "what is the weather?"},
+		}, chatReq.Messages[1].Value)
+		require.Equal(t, openai.ChatCompletionAssistantMessageParam{
+			Role:    openai.ChatMessageRoleAssistant,
+			Content: openai.StringOrAssistantRoleContentUnion{Value: "Let me check."},
+			ToolCalls: []openai.ChatCompletionMessageToolCallParam{{
+				ID:       ptr.To("toolu_1"),
+				Type:     openai.ChatCompletionMessageToolCallTypeFunction,
+				Function: openai.ChatCompletionMessageToolCallFunctionParam{Name: "get_weather", Arguments: `{"city":"SF"}`},
+			}},
+		}, chatReq.Messages[2].Value)
+		require.Equal(t, openai.ChatCompletionToolMessageParam{
+			Role: openai.ChatMessageRoleTool, ToolCallID: "toolu_1", Content: openai.StringOrArray{Value: "sunny"},
+		}, chatReq.Messages[3].Value)
+		user := chatReq.Messages[4].Value.(openai.ChatCompletionUserMessageParam)
+		parts := user.Content.Value.([]openai.ChatCompletionContentPartUserUnionParam)
+		require.Len(t, parts, 2)
+		require.Equal(t, "and this?", parts[0].TextContent.Text)
+		require.Equal(t, "data:image/png;base64,AAA", parts[1].ImageContent.ImageURL.URL)
+
+		require.Equal(t, []openai.Tool{{Type: openai.ToolTypeFunction, Function: &openai.FunctionDefinition{
+			Name: "get_weather", Description: "weather", Parameters: map[string]any{"type": "object"},
+		}}}, chatReq.Tools)
+		require.Equal(t, "required", chatReq.ToolChoice)
+		require.Equal(t, ptr.To(false), chatReq.ParallelToolCalls)
+	})
+	t.Run("tool choice", func(t *testing.T) {
+		for _, tc := range []struct {
+			in  *anthropicschema.ToolChoice
+			exp any
+		}{
+			{in: &anthropicschema.ToolChoice{Type: "auto"}, exp: "auto"},
+			{in: &anthropicschema.ToolChoice{Type: "none"}, exp: "none"},
+			{in: &anthropicschema.ToolChoice{Type: "tool", Name: "f"}, exp: openai.ToolChoice{Type: openai.ToolTypeFunction, Function: openai.ToolFunction{Name: "f"}}},
+		} {
+			chatReq, err := anthropicRequestToChatCompletionRequest(&anthropicschema.MessagesRequest{ToolChoice: tc.in})
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, chatReq.ToolChoice)
+		}
+	})
+
+	for _, tc := range []struct {
+		name   string
+		in     string
+		expErr string
+	}{
+		{name: "role", in: `{"messages":[{"role":"system","content":"hi"}]}`, expErr: "messages[0]: unsupported message role: system"},
+		{name: "user block", in: `{"messages":[{"role":"user","content":[{"type":"document","source":{"type":"url","url":"a"}}]}]}`, expErr: "unsupported content block type for user message: document"},
+		{name: "assistant block", in: `{"messages":[{"role":"assistant","content":[{"type":"image"}]}]}`, expErr: "unsupported content block type for assistant message: image"},
+		{name: "image source", in: `{"messages":[{"role":"user","content":[{"type":"image","source":{"type":"file"}}]}]}`, expErr: "unsupported image source type: file"},
+		{name: "tool result", in: `{"messages":[{"role":"user","content":[{"type":"tool_result","content":[{"type":"image"}]}]}]}`, expErr: "unsupported content block type for tool result: image"},
+		{name: "system", in: `{"system":[{"type":"image"}],"messages":[]}`, expErr: "unsupported system content block type: image"},
+		{name: "tool type", in: `{"messages":[],"tools":[{"type":"web_search_20250305","name":"web_search"}]}`, expErr: "unsupported tool type: web_search_20250305"},
+		{name: "tool choice", in: `{"messages":[],"tool_choice":{"type":"foo"}}`, expErr: "unsupported tool_choice type: foo"},
+	} {
+		t.Run("error/"+tc.name, func(t *testing.T) {
+			var req anthropicschema.MessagesRequest
+			require.NoError(t, json.Unmarshal([]byte(tc.in), &req))
+			_, err := anthropicRequestToChatCompletionRequest(&req)
+			require.ErrorContains(t, err, tc.expErr)
+		})
+	}
+}
+
+func TestAnthropicToChatCompletionTranslator_OpenAI(t *testing.T) {
+	tr := NewMessagesAnthropicToOpenAITranslator("v1", "gpt-4o")
+	req := &anthropicschema.MessagesRequest{
+		Model: "claude", MaxTokens: 10,
+		Messages: []anthropicschema.MessageParam{{Role: "user", Content: anthropicschema.MessageContent{Text: ptr.To("hello")}}},
+	}
+	hm, bm, err := tr.RequestBody(nil, req, false)
+	require.NoError(t, err)
+	require.Equal(t, "/v1/chat/completions", string(hm.SetHeaders[0].Header.RawValue))
+	var chatReq openai.ChatCompletionRequest
+	require.NoError(t, json.Unmarshal(bm.GetBody(), &chatReq))
+	require.Equal(t, "gpt-4o", chatReq.Model)
+
+	const chatResp = `{"id":"chatcmpl-1","object":"chat.completion","choices":[{"index":0,"finish_reason":"tool_calls",
+"message":{"role":"assistant","content":"hi there","tool_calls":[{"id":"call_1","type":"function","function":{"name":"f","arguments":"{\"a\":1}"}}]}}],
+"usage":{"prompt_tokens":3,"completion_tokens":4,"total_tokens":7}}`
+	hm, bm, usage, err := tr.ResponseBody(map[string]string{":status": "200"}, strings.NewReader(chatResp), true)
+	require.NoError(t, err)
+	require.Equal(t, LLMTokenUsage{InputTokens: 3, OutputTokens: 4, TotalTokens: 7}, usage)
+	require.Equal(t, "content-length", hm.SetHeaders[0].Header.Key)
+	var msg anthropicschema.Message
+	require.NoError(t, json.Unmarshal(bm.GetBody(), &msg))
+	require.True(t, strings.HasPrefix(msg.ID, "msg_"))
+	require.Equal(t, "message", msg.Type)
+	require.Equal(t, "gpt-4o", msg.Model)
+	require.Equal(t, anthropicschema.StopReasonToolUse, *msg.StopReason)
+	require.Len(t, msg.Content, 2)
+	require.Equal(t, "hi there", *msg.Content[0].Text)
+	require.Equal(t, anthropicschema.ContentBlockTypeToolUse, msg.Content[1].Type)
+	require.Equal(t, "call_1", msg.Content[1].ID)
+	require.JSONEq(t, `{"a":1}`, string(msg.Content[1].Input))
+	require.Equal(t, anthropicschema.Usage{InputTokens: 3, OutputTokens: 4}, msg.Usage)
+}
+
+func TestAnthropicToChatCompletionTranslator_AWSBedrock(t *testing.T) {
+	tr := NewMessagesAnthropicToAWSBedrockTranslator("")
+	req := &anthropicschema.MessagesRequest{
+		Model: "anthropic.claude-3", MaxTokens: 10,
+		Messages: []anthropicschema.MessageParam{{Role: "user", Content: anthropicschema.MessageContent{Text: ptr.To("hello")}}},
+	}
+	hm, bm, err := tr.RequestBody(nil, req, false)
+	require.NoError(t, err)
+	require.Equal(t, "/model/anthropic.claude-3/converse", string(hm.SetHeaders[0].Header.RawValue))
+	var bedrockReq awsbedrock.ConverseInput
+	require.NoError(t, json.Unmarshal(bm.GetBody(), &bedrockReq))
+	require.Equal(t, ptr.To[int64](10), bedrockReq.InferenceConfig.MaxTokens)
+	require.Equal(t, "hello", *bedrockReq.Messages[0].Content[0].Text)
+
+	const bedrockResp = `{"output":{"message":{"role":"assistant","content":[{"text":"hi there"}]}},
+"stopReason":"max_tokens","usage":{"inputTokens":3,"outputTokens":4,"totalTokens":7}}`
+	_, bm, usage, err := tr.ResponseBody(map[string]string{":status": "200"}, strings.NewReader(bedrockResp), true)
+	require.NoError(t, err)
+	require.Equal(t, LLMTokenUsage{InputTokens: 3, OutputTokens: 4, TotalTokens: 7}, usage)
+	var msg anthropicschema.Message
+	require.NoError(t, json.Unmarshal(bm.GetBody(), &msg))
+	require.Equal(t, anthropicschema.StopReasonMaxTokens, *msg.StopReason)
+	require.Equal(t, "hi there", *msg.Content[0].Text)
+}
+
+func TestAnthropicToChatCompletionTranslator_Streaming(t *testing.T) {
+	tr := NewMessagesAnthropicToOpenAITranslator("v1", "")
+	_, _, err := tr.RequestBody(nil, &anthropicschema.MessagesRequest{Model: "some-model", MaxTokens: 10, Stream: true}, false)
+	require.NoError(t, err)
+
+	chunks := []string{
+		`data: {"choices":[{"index":0,"delta":{"role":"assistant","content":""}}]}` + "\n\n",
+		`data: {"choices":[{"index":0,"delta":{"content":"Hel"}}]}` + "\n\n" + `data: {"choices":[{"index":0,"delta":{"con`,
+		`tent":"lo"}}]}` + "\n\n",
+		`data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"f","arguments":""}}]}}]}` + "\n\n",
+		`data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"type":"function","function":{"arguments":"{\"a\":1}"}}]}}]}` + "\n\n",
+		`data: {"choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}` + "\n\n",
+		`data: {"choices":[],"usage":{"prompt_tokens":5,"completion_tokens":6,"total_tokens":11}}` + "\n\n",
+		"data: [DONE]\n\n",
+	}
+	var out []byte
+	var total LLMTokenUsage
+	for i, c := range chunks {
+		_, bm, usage, err := tr.ResponseBody(nil, strings.NewReader(c), i == len(chunks)-1)
+		require.NoError(t, err)
+		out = append(out, bm.GetBody()...)
+		total.TotalTokens += usage.TotalTokens
+	}
+	require.Equal(t, uint32(11), total.TotalTokens)
+
+	var events []anthropicschema.StreamEvent
+	for _, block := range strings.Split(strings.TrimSpace(string(out)), "\n\n") {
+		lines := strings.Split(block, "\n")
+		require.Len(t, lines, 2)
+		var ev anthropicschema.StreamEvent
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &ev))
+		require.Equal(t, "event: "+ev.Type, lines[0])
+		events = append(events, ev)
+	}
+	var types []string
+	for _, ev := range events {
+		types = append(types, ev.Type)
+	}
+	require.Equal(t, []string{
+		anthropicschema.StreamEventTypeMessageStart,
+		anthropicschema.StreamEventTypeContentBlockStart,
+		anthropicschema.StreamEventTypeContentBlockDelta,
+		anthropicschema.StreamEventTypeContentBlockDelta,
+		anthropicschema.StreamEventTypeContentBlockStop,
+		anthropicschema.StreamEventTypeContentBlockStart,
+		anthropicschema.StreamEventTypeContentBlockDelta,
+		anthropicschema.StreamEventTypeContentBlockStop,
+		anthropicschema.StreamEventTypeMessageDelta,
+		anthropicschema.StreamEventTypeMessageStop,
+	}, types)
+	require.Equal(t, "some-model", events[0].Message.Model)
+	require.Equal(t, 0, *events[1].Index)
+	require.Equal(t, "Hel", events[2].Delta.Text)
+	require.Equal(t, "lo", events[3].Delta.Text)
+	require.Equal(t, 1, *events[5].Index)
+	require.Equal(t, "call_1", events[5].ContentBlock.ID)
+	require.Equal(t, "f", events[5].ContentBlock.Name)
+	require.Equal(t, anthropicschema.DeltaTypeInputJSON, events[6].Delta.Type)
+	require.JSONEq(t, `{"a":1}`, *events[6].Delta.PartialJSON)
+	require.Equal(t, anthropicschema.StopReasonToolUse, events[8].Delta.StopReason)
+	require.Equal(t, &anthropicschema.Usage{InputTokens: 5, OutputTokens: 6}, events[8].Usage)
+}
+
+func TestAnthropicToChatCompletionTranslator_ResponseError(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		headers    map[string]string
+		body       string
+		expType    string
+		expMessage string
+	}{
+		{
+			name:       "openai json error",
+			headers:    map[string]string{statusHeaderName: "401", contentTypeHeaderName: jsonContentType},
+			body:       `{"error":{"type":"invalid_request_error","message":"invalid api key"}}`,
+			expType:    anthropicschema.ErrorTypeAuthentication,
+			expMessage: "invalid api key",
+		},
+		{
+			name:       "non-json error",
+			headers:    map[string]string{statusHeaderName: "503", contentTypeHeaderName: "text/plain"},
+			body:       "service unavailable",
+			expType:    anthropicschema.ErrorTypeOverloaded,
+			expMessage: "service unavailable",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := NewMessagesAnthropicToOpenAITranslator("v1", "")
+			hm, bm, err := tr.ResponseError(tc.headers, strings.NewReader(tc.body))
+			require.NoError(t, err)
+			require.Equal(t, "content-length", hm.SetHeaders[0].Header.Key)
+			var anthropicErr anthropicschema.ErrorResponse
+			require.NoError(t, json.Unmarshal(bm.GetBody(), &anthropicErr))
+			require.Equal(t, "error", anthropicErr.Type)
+			require.Equal(t, tc.expType, anthropicErr.Error.Type)
+			require.Equal(t, tc.expMessage, anthropicErr.Error.Message)
+		})
+	}
+}
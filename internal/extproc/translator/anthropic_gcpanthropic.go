@@ -0,0 +1,178 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	anthropicVertex "github.com/anthropics/anthropic-sdk-go/vertex"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/tidwall/sjson"
+
+	anthropicschema "github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+)
+
+// NewMessagesAnthropicToGCPAnthropicTranslator implements [Factory] for Anthropic to GCP Anthropic translation
+// for /v1/messages. Since GCP Anthropic follows the Anthropic Messages API, the body is passed through
+// except for the model name which is moved into the path and the "anthropic_version" field.
+func NewMessagesAnthropicToGCPAnthropicTranslator(apiVersion string, modelNameOverride string) AnthropicMessagesTranslator {
+	return &anthropicToGCPAnthropicTranslatorV1Messages{apiVersion: apiVersion, modelNameOverride: modelNameOverride}
+}
+
+type anthropicToGCPAnthropicTranslatorV1Messages struct {
+	apiVersion        string
+	modelNameOverride string
+	stream            bool
+	// buffered is the partial SSE event that is not yet processed.
+	buffered []byte
+}
+
+// RequestBody implements [AnthropicMessagesTranslator.RequestBody].
+func (a *anthropicToGCPAnthropicTranslatorV1Messages) RequestBody(raw []byte, req *anthropicschema.MessagesRequest, _ bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	modelName := req.Model
+	if a.modelNameOverride != "" {
+		modelName = a.modelNameOverride
+	}
+	specifier := "rawPredict"
+	if req.Stream {
+		specifier = "streamRawPredict"
+		a.stream = true
+	}
+	pathSuffix := buildGCPModelPathSuffix(gcpModelPublisherAnthropic, modelName, specifier)
+
+	// The model is specified in the path, and GCP rejects the body with the "model" field.
+	body, err := sjson.DeleteBytes(raw, "model")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to delete model field: %w", err)
+	}
+	anthropicVersion := anthropicVertex.DefaultVersion
+	if a.apiVersion != "" {
+		anthropicVersion = a.apiVersion
+	}
+	body, err = sjson.SetBytesOptions(body, anthropicVersionKey, anthropicVersion, SJSONOptions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to set anthropic_version: %w", err)
+	}
+	headerMutation, bodyMutation = buildRequestMutations(pathSuffix, body)
+	return
+}
+
+// ResponseHeaders implements [AnthropicMessagesTranslator.ResponseHeaders].
+func (a *anthropicToGCPAnthropicTranslatorV1Messages) ResponseHeaders(map[string]string) (*extprocv3.HeaderMutation, error) {
+	return nil, nil
+}
+
+// ResponseBody implements [AnthropicMessagesTranslator.ResponseBody].
+//
+// The body is passed through as-is, and this only extracts the token usage.
+func (a *anthropicToGCPAnthropicTranslatorV1Messages) ResponseBody(_ map[string]string, body io.Reader, _ bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	if a.stream {
+		buf, err := io.ReadAll(body)
+		if err != nil {
+			return nil, nil, tokenUsage, fmt.Errorf("failed to read body: %w", err)
+		}
+		a.buffered = append(a.buffered, buf...)
+		tokenUsage = a.extractUsageFromBufferedEvents()
+		return nil, nil, tokenUsage, nil
+	}
+	var resp anthropicschema.Message
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	tokenUsage = anthropicUsageToLLMTokenUsage(&resp.Usage)
+	return
+}
+
+// extractUsageFromBufferedEvents extracts the token usage from the complete events in the buffer.
+//
+// The input tokens are reported in the message_start event, and the output tokens are reported in the
+// message_delta event as the cumulative count.
+func (a *anthropicToGCPAnthropicTranslatorV1Messages) extractUsageFromBufferedEvents() (tokenUsage LLMTokenUsage) {
+	for {
+		i := bytes.IndexByte(a.buffered, '\n')
+		if i == -1 {
+			return
+		}
+		line := bytes.TrimSpace(a.buffered[:i])
+		a.buffered = a.buffered[i+1:]
+		if !bytes.HasPrefix(line, sseDataPrefix) {
+			continue
+		}
+		var event anthropicschema.StreamEvent
+		if err := json.Unmarshal(bytes.TrimPrefix(line, sseDataPrefix), &event); err != nil {
+			continue
+		}
+		switch event.Type {
+		case anthropicschema.StreamEventTypeMessageStart:
+			if event.Message != nil {
+				tokenUsage.InputTokens += uint32(event.Message.Usage.InputTokens) //nolint:gosec
+			}
+		case anthropicschema.StreamEventTypeMessageDelta:
+			if event.Usage != nil {
+				tokenUsage.OutputTokens += uint32(event.Usage.OutputTokens) //nolint:gosec
+			}
+		}
+		tokenUsage.TotalTokens = tokenUsage.InputTokens + tokenUsage.OutputTokens
+	}
+}
+
+// ResponseError implements [AnthropicMessagesTranslator.ResponseError].
+//
+// The error in the Anthropic format is passed through as-is, and the other errors such as the ones
+// generated by GCP itself are converted into the Anthropic format.
+func (a *anthropicToGCPAnthropicTranslatorV1Messages) ResponseError(respHeaders map[string]string, body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	buf, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read error body: %w", err)
+	}
+	if v, ok := respHeaders[contentTypeHeaderName]; ok && strings.Contains(v, jsonContentType) {
+		var anthropicErr anthropicschema.ErrorResponse
+		if json.Unmarshal(buf, &anthropicErr) == nil && anthropicErr.Type == anthropicschema.StreamEventTypeError {
+			return nil, nil, nil
+		}
+	}
+	return anthropicErrorMutation(respHeaders[statusHeaderName], string(buf))
+}
+
+// anthropicErrorMutation builds the mutations that replace the response body with the Anthropic error.
+func anthropicErrorMutation(statusCode string, message string) (*extprocv3.HeaderMutation, *extprocv3.BodyMutation, error) {
+	code, _ := strconv.Atoi(statusCode)
+	anthropicErr := anthropicschema.ErrorResponse{
+		Type: anthropicschema.StreamEventTypeError,
+		Error: anthropicschema.ErrorDetail{
+			Type:    anthropicschema.ErrorTypeFromStatusCode(code),
+			Message: message,
+		},
+	}
+	mut := &extprocv3.BodyMutation_Body{}
+	var err error
+	mut.Body, err = json.Marshal(anthropicErr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal error body: %w", err)
+	}
+	headerMutation := &extprocv3.HeaderMutation{}
+	setContentLength(headerMutation, mut.Body)
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, nil
+}
+
+func anthropicUsageToLLMTokenUsage(u *anthropicschema.Usage) LLMTokenUsage {
+	return LLMTokenUsage{
+		InputTokens:  uint32(u.InputTokens),                  //nolint:gosec
+		OutputTokens: uint32(u.OutputTokens),                 //nolint:gosec
+		TotalTokens:  uint32(u.InputTokens + u.OutputTokens), //nolint:gosec
+	}
+}
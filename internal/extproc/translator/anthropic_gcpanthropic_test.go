@@ -0,0 +1,136 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	anthropicschema "github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+)
+
+func TestAnthropicToGCPAnthropicTranslatorV1Messages_RequestBody(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		apiVersion string
+		override   string
+		stream     bool
+		expPath    string
+		expVersion string
+	}{
+		{
+			name:       "default version",
+			expPath:    "publishers/anthropic/models/claude-3:rawPredict",
+			expVersion: "vertex-2023-10-16",
+		},
+		{
+			name:       "override and stream",
+			apiVersion: "vertex-2099-01-01",
+			override:   "claude-override",
+			stream:     true,
+			expPath:    "publishers/anthropic/models/claude-override:streamRawPredict",
+			expVersion: "vertex-2099-01-01",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			raw := `{"model":"claude-3","max_tokens":10,"messages":[{"role":"user","content":"hi"}]}`
+			if tc.stream {
+				raw = strings.Replace(raw, `{"model"`, `{"stream":true,"model"`, 1)
+			}
+			var req anthropicschema.MessagesRequest
+			require.NoError(t, json.Unmarshal([]byte(raw), &req))
+			tr := NewMessagesAnthropicToGCPAnthropicTranslator(tc.apiVersion, tc.override)
+			hm, bm, err := tr.RequestBody([]byte(raw), &req, false)
+			require.NoError(t, err)
+			require.Equal(t, ":path", hm.SetHeaders[0].Header.Key)
+			require.Equal(t, tc.expPath, string(hm.SetHeaders[0].Header.RawValue))
+			var body map[string]any
+			require.NoError(t, json.Unmarshal(bm.GetBody(), &body))
+			require.NotContains(t, body, "model")
+			require.Equal(t, tc.expVersion, body["anthropic_version"])
+			require.Equal(t, float64(10), body["max_tokens"])
+		})
+	}
+}
+
+func TestAnthropicToGCPAnthropicTranslatorV1Messages_ResponseBody(t *testing.T) {
+	t.Run("non-streaming", func(t *testing.T) {
+		tr := &anthropicToGCPAnthropicTranslatorV1Messages{}
+		const body = `{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"hi"}],
+"model":"claude","stop_reason":"end_turn","usage":{"input_tokens":3,"output_tokens":5}}`
+		hm, bm, usage, err := tr.ResponseBody(nil, strings.NewReader(body), true)
+		require.NoError(t, err)
+		require.Nil(t, hm)
+		require.Nil(t, bm)
+		require.Equal(t, LLMTokenUsage{InputTokens: 3, OutputTokens: 5, TotalTokens: 8}, usage)
+	})
+	t.Run("invalid body", func(t *testing.T) {
+		tr := &anthropicToGCPAnthropicTranslatorV1Messages{}
+		_, _, _, err := tr.ResponseBody(nil, strings.NewReader("invalid"), true)
+		require.ErrorContains(t, err, "failed to unmarshal body")
+	})
+	t.Run("streaming", func(t *testing.T) {
+		tr := &anthropicToGCPAnthropicTranslatorV1Messages{stream: true}
+		const events = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"model":"claude","usage":{"input_tokens":25,"output_tokens":1}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn","stop_sequence":null},"usage":{"output_tokens":15}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+		split := strings.Index(events, `"usage":{"output_tokens"`)
+		_, bm, usage, err := tr.ResponseBody(nil, strings.NewReader(events[:split]), false)
+		require.NoError(t, err)
+		require.Nil(t, bm)
+		require.Equal(t, LLMTokenUsage{InputTokens: 25, TotalTokens: 25}, usage)
+		_, bm, usage, err = tr.ResponseBody(nil, strings.NewReader(events[split:]), true)
+		require.NoError(t, err)
+		require.Nil(t, bm)
+		require.Equal(t, LLMTokenUsage{OutputTokens: 15, TotalTokens: 15}, usage)
+	})
+}
+
+func TestAnthropicToGCPAnthropicTranslatorV1Messages_ResponseError(t *testing.T) {
+	tr := &anthropicToGCPAnthropicTranslatorV1Messages{}
+	t.Run("anthropic error is passed through", func(t *testing.T) {
+		hm, bm, err := tr.ResponseError(map[string]string{statusHeaderName: "400", contentTypeHeaderName: jsonContentType},
+			strings.NewReader(`{"type":"error","error":{"type":"invalid_request_error","message":"bad"}}`))
+		require.NoError(t, err)
+		require.Nil(t, hm)
+		require.Nil(t, bm)
+	})
+	for _, tc := range []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{name: "gcp json error", contentType: jsonContentType, body: `{"error":{"code":429,"message":"quota","status":"RESOURCE_EXHAUSTED"}}`},
+		{name: "non-json error", contentType: "text/plain", body: "quota"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			hm, bm, err := tr.ResponseError(map[string]string{statusHeaderName: "429", contentTypeHeaderName: tc.contentType}, strings.NewReader(tc.body))
+			require.NoError(t, err)
+			require.Equal(t, "content-length", hm.SetHeaders[0].Header.Key)
+			var anthropicErr anthropicschema.ErrorResponse
+			require.NoError(t, json.Unmarshal(bm.GetBody(), &anthropicErr))
+			require.Equal(t, "error", anthropicErr.Type)
+			require.Equal(t, anthropicschema.ErrorTypeRateLimit, anthropicErr.Error.Type)
+			require.Equal(t, tc.body, anthropicErr.Error.Message)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/tidwall/sjson"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+const (
+	// anthropicMessagesPath is the path of the Anthropic Messages API.
+	anthropicMessagesPath = "/v1/messages"
+	// anthropicVersionHeaderName is the header used by the Anthropic API to select the API version.
+	anthropicVersionHeaderName = "anthropic-version"
+	// anthropicDefaultVersion is the default version of the Anthropic API.
+	// https://docs.anthropic.com/en/api/versioning
+	anthropicDefaultVersion = "2023-06-01"
+)
+
+// NewChatCompletionOpenAIToAnthropicTranslator implements [Factory] for OpenAI to Anthropic translation.
+// This reuses the conversion of the GCP Anthropic translator, but the request is sent to the native
+// /v1/messages endpoint with the model in the body and the version in the "anthropic-version" header.
+func NewChatCompletionOpenAIToAnthropicTranslator(apiVersion string, modelNameOverride string) OpenAIChatCompletionTranslator {
+	return &openAIToAnthropicTranslatorV1ChatCompletion{
+		openAIToGCPAnthropicTranslatorV1ChatCompletion: &openAIToGCPAnthropicTranslatorV1ChatCompletion{
+			apiVersion:        apiVersion,
+			modelNameOverride: modelNameOverride,
+		},
+	}
+}
+
+// openAIToAnthropicTranslatorV1ChatCompletion only differs from the GCP Anthropic translator in how the
+// request is sent, so the response handling is inherited as-is.
+type openAIToAnthropicTranslatorV1ChatCompletion struct {
+	*openAIToGCPAnthropicTranslatorV1ChatCompletion
+}
+
+// RequestBody implements [OpenAIChatCompletionTranslator.RequestBody] for Anthropic.
+func (o *openAIToAnthropicTranslatorV1ChatCompletion) RequestBody(_ []byte, openAIReq *openai.ChatCompletionRequest, _ bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	params, err := buildAnthropicParams(openAIReq)
+	if err != nil {
+		return
+	}
+
+	modelName := openAIReq.Model
+	if o.modelNameOverride != "" {
+		// Use modelName override if set.
+		modelName = o.modelNameOverride
+	}
+	params.Model = anthropic.Model(modelName)
+
+	body, err := json.Marshal(params)
+	if err != nil {
+		return
+	}
+	if openAIReq.Stream {
+		o.streamParser = newAnthropicStreamParser(modelName)
+		body, err = sjson.SetBytesOptions(body, "stream", true, SJSONOptions)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to set stream: %w", err)
+		}
+	}
+
+	headerMutation, bodyMutation = buildRequestMutations(anthropicMessagesPath, body)
+	setAnthropicVersionHeader(headerMutation, o.apiVersion)
+	return
+}
+
+// setAnthropicVersionHeader sets the "anthropic-version" header which is required by the Anthropic API.
+func setAnthropicVersionHeader(headerMutation *extprocv3.HeaderMutation, apiVersion string) {
+	anthropicVersion := anthropicDefaultVersion
+	if apiVersion != "" {
+		anthropicVersion = apiVersion
+	}
+	headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{Key: anthropicVersionHeaderName, RawValue: []byte(anthropicVersion)},
+	})
+}
@@ -0,0 +1,82 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestOpenAIToAnthropicTranslatorV1ChatCompletion_RequestBody(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		apiVersion string
+		override   string
+		stream     bool
+		expModel   string
+		expVersion string
+	}{
+		{name: "default", expModel: claudeTestModel, expVersion: "2023-06-01"},
+		{name: "override and stream", apiVersion: "2099-01-01", override: "claude-override", stream: true, expModel: "claude-override", expVersion: "2099-01-01"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			openAIReq := &openai.ChatCompletionRequest{
+				Model:  claudeTestModel,
+				Stream: tc.stream,
+				Messages: []openai.ChatCompletionMessageParamUnion{{
+					Type:  openai.ChatMessageRoleUser,
+					Value: openai.ChatCompletionUserMessageParam{Content: openai.StringOrUserRoleContentUnion{Value: "Hello!"}},
+				}},
+				MaxTokens: ptr.To(int64(1024)),
+			}
+			tr := NewChatCompletionOpenAIToAnthropicTranslator(tc.apiVersion, tc.override)
+			hm, bm, err := tr.RequestBody(nil, openAIReq, false)
+			require.NoError(t, err)
+
+			headers := map[string]string{}
+			for _, h := range hm.SetHeaders {
+				headers[h.Header.Key] = string(h.Header.RawValue)
+			}
+			require.Equal(t, "/v1/messages", headers[":path"])
+			require.Equal(t, tc.expVersion, headers["anthropic-version"])
+
+			body := bm.GetBody()
+			require.Equal(t, tc.expModel, gjson.GetBytes(body, "model").String())
+			require.Equal(t, tc.stream, gjson.GetBytes(body, "stream").Bool())
+			require.Equal(t, int64(1024), gjson.GetBytes(body, "max_tokens").Int())
+			require.False(t, gjson.GetBytes(body, "anthropic_version").Exists())
+		})
+	}
+}
+
+func TestOpenAIToAnthropicTranslatorV1ChatCompletion_ResponseBody(t *testing.T) {
+	tr := NewChatCompletionOpenAIToAnthropicTranslator("", "")
+	_, _, err := tr.RequestBody(nil, &openai.ChatCompletionRequest{
+		Model: claudeTestModel,
+		Messages: []openai.ChatCompletionMessageParamUnion{{
+			Type:  openai.ChatMessageRoleUser,
+			Value: openai.ChatCompletionUserMessageParam{Content: openai.StringOrUserRoleContentUnion{Value: "Hello!"}},
+		}},
+		MaxTokens: ptr.To(int64(1024)),
+	}, false)
+	require.NoError(t, err)
+
+	const body = `{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"hi"}],
+"model":"claude","stop_reason":"end_turn","usage":{"input_tokens":3,"output_tokens":5}}`
+	_, bm, usage, err := tr.ResponseBody(nil, bytes.NewReader([]byte(body)), true)
+	require.NoError(t, err)
+	require.Equal(t, LLMTokenUsage{InputTokens: 3, OutputTokens: 5, TotalTokens: 8}, usage)
+	mut, ok := bm.Mutation.(*extprocv3.BodyMutation_Body)
+	require.True(t, ok)
+	require.Equal(t, "hi", gjson.GetBytes(mut.Body, "choices.0.message.content").String())
+}
@@ -0,0 +1,307 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"mime/multipart"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/tidwall/sjson"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// NewAudioTranscriptionOpenAIToOpenAITranslator implements [Factory] for OpenAI to OpenAI translation for audio transcriptions.
+func NewAudioTranscriptionOpenAIToOpenAITranslator(apiVersion string, modelNameOverride string) OpenAIAudioTranscriptionTranslator {
+	return &openAIToOpenAITranslatorV1AudioTranscription{modelNameOverride: modelNameOverride, path: path.Join("/", apiVersion, "audio/transcriptions")}
+}
+
+// openAIToOpenAITranslatorV1AudioTranscription implements [OpenAIAudioTranscriptionTranslator] for /audio/transcriptions.
+type openAIToOpenAITranslatorV1AudioTranscription struct {
+	modelNameOverride string
+	// The path of the audio transcriptions endpoint to be used for the request. It is prefixed with the OpenAI path prefix.
+	path string
+}
+
+// RequestBody implements [OpenAIAudioTranscriptionTranslator.RequestBody].
+func (o *openAIToOpenAITranslatorV1AudioTranscription) RequestBody(original []byte, contentType string, _ *openai.AudioTranscriptionRequest, onRetry bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	newBody, err := o.requestBody(original, contentType, onRetry)
+	if err != nil {
+		return nil, nil, err
+	}
+	headerMutation, bodyMutation = buildRequestMutations(o.path, newBody)
+	return
+}
+
+// requestBody returns the new request body which is nil if the original body can be sent as is.
+func (o *openAIToOpenAITranslatorV1AudioTranscription) requestBody(original []byte, contentType string, onRetry bool) (newBody []byte, err error) {
+	if o.modelNameOverride != "" {
+		// If modelName is set we override the model to be used for the request.
+		newBody, err = setMultipartFormField(original, contentType, "model", o.modelNameOverride)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set model name: %w", err)
+		}
+	}
+	// On retry, the body might have been modified for a different provider. So, this will ensure that the original body is sent.
+	if onRetry && len(newBody) == 0 {
+		newBody = original
+	}
+	return newBody, nil
+}
+
+// ResponseHeaders implements [OpenAIAudioTranscriptionTranslator.ResponseHeaders].
+func (o *openAIToOpenAITranslatorV1AudioTranscription) ResponseHeaders(map[string]string) (headerMutation *extprocv3.HeaderMutation, err error) {
+	return nil, nil
+}
+
+// ResponseBody implements [OpenAIAudioTranscriptionTranslator.ResponseBody].
+//
+// The usage is only reported in the JSON response formats, so the plain text formats such as "text", "srt" and "vtt"
+// result in the zero usage.
+func (o *openAIToOpenAITranslatorV1AudioTranscription) ResponseBody(respHeaders map[string]string, body io.Reader, _ bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	if !strings.HasPrefix(respHeaders[contentTypeHeaderName], jsonContentType) {
+		return
+	}
+	var resp openai.AudioTranscriptionResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	tokenUsage = audioTranscriptionResponseUsage(&resp)
+	return
+}
+
+// ResponseError implements [OpenAIAudioTranscriptionTranslator.ResponseError].
+// For OpenAI based backend we return the OpenAI error type as is.
+// If connection fails the error body is translated to OpenAI error type for events such as HTTP 503 or 504.
+func (o *openAIToOpenAITranslatorV1AudioTranscription) ResponseError(respHeaders map[string]string, body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	return openAIBackendErrorToOpenAIError(respHeaders, body)
+}
+
+// NewAudioTranscriptionOpenAIToAzureOpenAITranslator implements [Factory] for OpenAI to Azure OpenAI translation
+// for audio transcriptions. Only the request path differs from the OpenAI translator.
+// https://learn.microsoft.com/en-us/azure/ai-services/openai/reference#transcriptions---create
+func NewAudioTranscriptionOpenAIToAzureOpenAITranslator(apiVersion string, modelNameOverride string) OpenAIAudioTranscriptionTranslator {
+	return &openAIToAzureOpenAITranslatorV1AudioTranscription{
+		apiVersion: apiVersion,
+		openAIToOpenAITranslatorV1AudioTranscription: openAIToOpenAITranslatorV1AudioTranscription{
+			modelNameOverride: modelNameOverride,
+		},
+	}
+}
+
+type openAIToAzureOpenAITranslatorV1AudioTranscription struct {
+	apiVersion string
+	openAIToOpenAITranslatorV1AudioTranscription
+}
+
+// RequestBody implements [OpenAIAudioTranscriptionTranslator.RequestBody].
+func (o *openAIToAzureOpenAITranslatorV1AudioTranscription) RequestBody(raw []byte, contentType string, req *openai.AudioTranscriptionRequest, onRetry bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	modelName := req.Model
+	if o.modelNameOverride != "" {
+		// If modelName is set we override the model to be used for the request.
+		modelName = o.modelNameOverride
+	}
+	newBody, err := o.requestBody(raw, contentType, onRetry)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Assume deployment_id is same as model name.
+	headerMutation, bodyMutation = buildRequestMutations(
+		fmt.Sprintf("/openai/deployments/%s/audio/transcriptions?api-version=%s", modelName, o.apiVersion), newBody)
+	return
+}
+
+// NewAudioSpeechOpenAIToOpenAITranslator implements [Factory] for OpenAI to OpenAI translation for audio speech.
+func NewAudioSpeechOpenAIToOpenAITranslator(apiVersion string, modelNameOverride string) OpenAIAudioSpeechTranslator {
+	return &openAIToOpenAITranslatorV1AudioSpeech{modelNameOverride: modelNameOverride, path: path.Join("/", apiVersion, "audio/speech")}
+}
+
+// openAIToOpenAITranslatorV1AudioSpeech implements [OpenAIAudioSpeechTranslator] for /audio/speech.
+type openAIToOpenAITranslatorV1AudioSpeech struct {
+	modelNameOverride string
+	// The path of the audio speech endpoint to be used for the request. It is prefixed with the OpenAI path prefix.
+	path string
+	// characters is the number of characters in the input text, which is reported at the end of the response.
+	characters uint32
+}
+
+// RequestBody implements [OpenAIAudioSpeechTranslator.RequestBody].
+func (o *openAIToOpenAITranslatorV1AudioSpeech) RequestBody(original []byte, req *openai.AudioSpeechRequest, onRetry bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	newBody, err := o.requestBody(original, req, onRetry)
+	if err != nil {
+		return nil, nil, err
+	}
+	headerMutation, bodyMutation = buildRequestMutations(o.path, newBody)
+	return
+}
+
+// requestBody records the number of the input characters and returns the new request body which is nil
+// if the original body can be sent as is.
+func (o *openAIToOpenAITranslatorV1AudioSpeech) requestBody(original []byte, req *openai.AudioSpeechRequest, onRetry bool) (newBody []byte, err error) {
+	o.characters = uint32(utf8.RuneCountInString(req.Input)) //nolint:gosec
+	if o.modelNameOverride != "" {
+		// If modelName is set we override the model to be used for the request.
+		newBody, err = sjson.SetBytesOptions(original, "model", o.modelNameOverride, SJSONOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set model name: %w", err)
+		}
+	}
+	// On retry, the body might have been modified for a different provider. So, this will ensure that the original body is sent.
+	if onRetry && len(newBody) == 0 {
+		newBody = original
+	}
+	return newBody, nil
+}
+
+// ResponseHeaders implements [OpenAIAudioSpeechTranslator.ResponseHeaders].
+func (o *openAIToOpenAITranslatorV1AudioSpeech) ResponseHeaders(map[string]string) (headerMutation *extprocv3.HeaderMutation, err error) {
+	return nil, nil
+}
+
+// ResponseBody implements [OpenAIAudioSpeechTranslator.ResponseBody].
+//
+// The audio content is passed through as is, and the number of the input characters is reported once at the end of the stream.
+func (o *openAIToOpenAITranslatorV1AudioSpeech) ResponseBody(_ map[string]string, _ io.Reader, endOfStream bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	if endOfStream {
+		tokenUsage.Characters = o.characters
+	}
+	return
+}
+
+// ResponseError implements [OpenAIAudioSpeechTranslator.ResponseError].
+// For OpenAI based backend we return the OpenAI error type as is.
+// If connection fails the error body is translated to OpenAI error type for events such as HTTP 503 or 504.
+func (o *openAIToOpenAITranslatorV1AudioSpeech) ResponseError(respHeaders map[string]string, body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	return openAIBackendErrorToOpenAIError(respHeaders, body)
+}
+
+// NewAudioSpeechOpenAIToAzureOpenAITranslator implements [Factory] for OpenAI to Azure OpenAI translation
+// for audio speech. Only the request path differs from the OpenAI translator.
+// https://learn.microsoft.com/en-us/azure/ai-services/openai/reference#speech---create
+func NewAudioSpeechOpenAIToAzureOpenAITranslator(apiVersion string, modelNameOverride string) OpenAIAudioSpeechTranslator {
+	return &openAIToAzureOpenAITranslatorV1AudioSpeech{
+		apiVersion: apiVersion,
+		openAIToOpenAITranslatorV1AudioSpeech: openAIToOpenAITranslatorV1AudioSpeech{
+			modelNameOverride: modelNameOverride,
+		},
+	}
+}
+
+type openAIToAzureOpenAITranslatorV1AudioSpeech struct {
+	apiVersion string
+	openAIToOpenAITranslatorV1AudioSpeech
+}
+
+// RequestBody implements [OpenAIAudioSpeechTranslator.RequestBody].
+func (o *openAIToAzureOpenAITranslatorV1AudioSpeech) RequestBody(raw []byte, req *openai.AudioSpeechRequest, onRetry bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	modelName := req.Model
+	if o.modelNameOverride != "" {
+		// If modelName is set we override the model to be used for the request.
+		modelName = o.modelNameOverride
+	}
+	newBody, err := o.requestBody(raw, req, onRetry)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Assume deployment_id is same as model name.
+	headerMutation, bodyMutation = buildRequestMutations(
+		fmt.Sprintf("/openai/deployments/%s/audio/speech?api-version=%s", modelName, o.apiVersion), newBody)
+	return
+}
+
+// audioTranscriptionResponseUsage returns the audio seconds or the tokens depending on what the model reports.
+// The audio seconds are rounded up so that a partial second is accounted as a whole second.
+func audioTranscriptionResponseUsage(resp *openai.AudioTranscriptionResponse) (tokenUsage LLMTokenUsage) {
+	switch u := resp.Usage; {
+	case u != nil && u.Type == openai.AudioTranscriptionUsageTypeTokens:
+		tokenUsage.InputTokens = uint32(u.InputTokens)   //nolint:gosec
+		tokenUsage.OutputTokens = uint32(u.OutputTokens) //nolint:gosec
+		tokenUsage.TotalTokens = uint32(u.TotalTokens)   //nolint:gosec
+	case u != nil && u.Type == openai.AudioTranscriptionUsageTypeDuration:
+		tokenUsage.AudioSeconds = uint32(math.Ceil(u.Seconds))
+	case resp.Duration != nil:
+		tokenUsage.AudioSeconds = uint32(math.Ceil(*resp.Duration))
+	}
+	return
+}
+
+// setMultipartFormField returns a copy of the multipart/form-data body where the value of the form field `name` is
+// replaced with `value`. The other parts including the files are copied as is with the same boundary, so the
+// content type of the request doesn't change.
+func setMultipartFormField(body []byte, contentType, name, value string) ([]byte, error) {
+	boundary, err := multipartBoundary(contentType)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	if err = w.SetBoundary(boundary); err != nil {
+		return nil, fmt.Errorf("failed to set multipart boundary: %w", err)
+	}
+	r := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		p, err := r.NextRawPart()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read multipart body: %w", err)
+		}
+		pw, err := w.CreatePart(p.Header)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write multipart body: %w", err)
+		}
+		if p.FormName() == name && p.FileName() == "" {
+			_, err = io.WriteString(pw, value)
+		} else {
+			_, err = io.Copy(pw, p)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to write multipart body: %w", err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write multipart body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// multipartBoundary returns the boundary of the multipart/form-data content type.
+func multipartBoundary(contentType string) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse content type %q: %w", contentType, err)
+	}
+	if mediaType != "multipart/form-data" || params["boundary"] == "" {
+		return "", fmt.Errorf("content type %q is not multipart/form-data with a boundary", contentType)
+	}
+	return params["boundary"], nil
+}
@@ -0,0 +1,213 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// transcriptionMultipartBody returns the multipart/form-data body of the audio transcription request and its content type.
+func transcriptionMultipartBody(t *testing.T, model string) ([]byte, string) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	fw, err := w.CreateFormFile("file", "audio.mp3")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte("fake-audio-content"))
+	require.NoError(t, err)
+	require.NoError(t, w.WriteField("model", model))
+	require.NoError(t, w.WriteField("response_format", "json"))
+	require.NoError(t, w.Close())
+	return buf.Bytes(), w.FormDataContentType()
+}
+
+// multipartFormFields reads the non-file form fields as well as the file contents keyed by the file name.
+func multipartFormFields(t *testing.T, body []byte, contentType string) map[string]string {
+	boundary, err := multipartBoundary(contentType)
+	require.NoError(t, err)
+	r := multipart.NewReader(bytes.NewReader(body), boundary)
+	fields := map[string]string{}
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		v, err := io.ReadAll(p)
+		require.NoError(t, err)
+		key := p.FormName()
+		if p.FileName() != "" {
+			key = p.FileName()
+		}
+		fields[key] = string(v)
+	}
+	return fields
+}
+
+func TestOpenAIToOpenAITranslatorV1AudioTranscription_RequestBody(t *testing.T) {
+	raw, contentType := transcriptionMultipartBody(t, "whisper-1")
+	t.Run("passthrough", func(t *testing.T) {
+		tr := NewAudioTranscriptionOpenAIToOpenAITranslator("v1", "")
+		hm, bm, err := tr.RequestBody(raw, contentType, &openai.AudioTranscriptionRequest{Model: "whisper-1"}, false)
+		require.NoError(t, err)
+		require.Equal(t, ":path", hm.SetHeaders[0].Header.Key)
+		require.Equal(t, "/v1/audio/transcriptions", string(hm.SetHeaders[0].Header.RawValue))
+		require.Nil(t, bm)
+	})
+	t.Run("on retry", func(t *testing.T) {
+		tr := NewAudioTranscriptionOpenAIToOpenAITranslator("v1", "")
+		_, bm, err := tr.RequestBody(raw, contentType, &openai.AudioTranscriptionRequest{Model: "whisper-1"}, true)
+		require.NoError(t, err)
+		require.Equal(t, raw, bm.GetBody())
+	})
+	t.Run("override", func(t *testing.T) {
+		tr := NewAudioTranscriptionOpenAIToOpenAITranslator("v1", "gpt-4o-transcribe")
+		_, bm, err := tr.RequestBody(raw, contentType, &openai.AudioTranscriptionRequest{Model: "whisper-1"}, false)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			"model":           "gpt-4o-transcribe",
+			"response_format": "json",
+			"audio.mp3":       "fake-audio-content",
+		}, multipartFormFields(t, bm.GetBody(), contentType))
+	})
+	t.Run("override with invalid content type", func(t *testing.T) {
+		tr := NewAudioTranscriptionOpenAIToOpenAITranslator("v1", "gpt-4o-transcribe")
+		_, _, err := tr.RequestBody(raw, "application/json", &openai.AudioTranscriptionRequest{Model: "whisper-1"}, false)
+		require.ErrorContains(t, err, "failed to set model name")
+	})
+}
+
+func TestOpenAIToOpenAITranslatorV1AudioTranscription_ResponseBody(t *testing.T) {
+	tr := NewAudioTranscriptionOpenAIToOpenAITranslator("v1", "")
+	jsonHeaders := map[string]string{contentTypeHeaderName: "application/json"}
+	for _, tc := range []struct {
+		name     string
+		headers  map[string]string
+		body     string
+		expUsage LLMTokenUsage
+	}{
+		{
+			name:     "duration usage",
+			headers:  jsonHeaders,
+			body:     `{"text":"hello","usage":{"type":"duration","seconds":2.1}}`,
+			expUsage: LLMTokenUsage{AudioSeconds: 3},
+		},
+		{
+			name:     "tokens usage",
+			headers:  jsonHeaders,
+			body:     `{"text":"hello","usage":{"type":"tokens","input_tokens":14,"output_tokens":45,"total_tokens":59}}`,
+			expUsage: LLMTokenUsage{InputTokens: 14, OutputTokens: 45, TotalTokens: 59},
+		},
+		{
+			name:     "verbose json",
+			headers:  map[string]string{contentTypeHeaderName: "application/json; charset=utf-8"},
+			body:     `{"text":"hello","duration":8.0}`,
+			expUsage: LLMTokenUsage{AudioSeconds: 8},
+		},
+		{
+			name:    "text",
+			headers: map[string]string{contentTypeHeaderName: "text/plain; charset=utf-8"},
+			body:    "hello",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			hm, bm, usage, err := tr.ResponseBody(tc.headers, strings.NewReader(tc.body), true)
+			require.NoError(t, err)
+			require.Nil(t, hm)
+			require.Nil(t, bm)
+			require.Equal(t, tc.expUsage, usage)
+		})
+	}
+	t.Run("invalid body", func(t *testing.T) {
+		_, _, _, err := tr.ResponseBody(jsonHeaders, strings.NewReader("invalid"), true)
+		require.ErrorContains(t, err, "failed to unmarshal body")
+	})
+}
+
+func TestOpenAIToOpenAITranslatorV1AudioTranscription_ResponseError(t *testing.T) {
+	tr := NewAudioTranscriptionOpenAIToOpenAITranslator("v1", "")
+	_, bm, err := tr.ResponseError(map[string]string{statusHeaderName: "503", contentTypeHeaderName: "text/plain"}, strings.NewReader("unavailable"))
+	require.NoError(t, err)
+	var openAIErr openai.Error
+	require.NoError(t, json.Unmarshal(bm.GetBody(), &openAIErr))
+	require.Equal(t, openAIBackendError, openAIErr.Error.Type)
+	require.Equal(t, "unavailable", openAIErr.Error.Message)
+}
+
+func TestOpenAIToAzureOpenAITranslatorV1AudioTranscription_RequestBody(t *testing.T) {
+	raw, contentType := transcriptionMultipartBody(t, "whisper-1")
+	t.Run("no override", func(t *testing.T) {
+		tr := NewAudioTranscriptionOpenAIToAzureOpenAITranslator("2024-10-21", "")
+		hm, bm, err := tr.RequestBody(raw, contentType, &openai.AudioTranscriptionRequest{Model: "whisper-1"}, false)
+		require.NoError(t, err)
+		require.Equal(t, "/openai/deployments/whisper-1/audio/transcriptions?api-version=2024-10-21", string(hm.SetHeaders[0].Header.RawValue))
+		require.Nil(t, bm)
+	})
+	t.Run("override", func(t *testing.T) {
+		tr := NewAudioTranscriptionOpenAIToAzureOpenAITranslator("2024-10-21", "my-deployment")
+		hm, bm, err := tr.RequestBody(raw, contentType, &openai.AudioTranscriptionRequest{Model: "whisper-1"}, false)
+		require.NoError(t, err)
+		require.Equal(t, "/openai/deployments/my-deployment/audio/transcriptions?api-version=2024-10-21", string(hm.SetHeaders[0].Header.RawValue))
+		require.Equal(t, "my-deployment", multipartFormFields(t, bm.GetBody(), contentType)["model"])
+	})
+}
+
+func TestOpenAIToOpenAITranslatorV1AudioSpeech_RequestBody(t *testing.T) {
+	const raw = `{"model":"tts-1","input":"héllo","voice":"alloy"}`
+	req := &openai.AudioSpeechRequest{Model: "tts-1", Input: "héllo", Voice: "alloy"}
+	for _, tc := range []struct {
+		name     string
+		override string
+		onRetry  bool
+		expBody  string
+	}{
+		{name: "passthrough"},
+		{name: "on retry", onRetry: true, expBody: raw},
+		{name: "override", override: "gpt-4o-mini-tts", expBody: `{"model":"gpt-4o-mini-tts","input":"héllo","voice":"alloy"}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := NewAudioSpeechOpenAIToOpenAITranslator("v1", tc.override)
+			hm, bm, err := tr.RequestBody([]byte(raw), req, tc.onRetry)
+			require.NoError(t, err)
+			require.Equal(t, ":path", hm.SetHeaders[0].Header.Key)
+			require.Equal(t, "/v1/audio/speech", string(hm.SetHeaders[0].Header.RawValue))
+			if tc.expBody == "" {
+				require.Nil(t, bm)
+			} else {
+				require.JSONEq(t, tc.expBody, string(bm.GetBody()))
+			}
+
+			// The number of characters is only reported at the end of the stream.
+			_, _, usage, err := tr.ResponseBody(nil, strings.NewReader("audio-chunk"), false)
+			require.NoError(t, err)
+			require.Equal(t, LLMTokenUsage{}, usage)
+			_, _, usage, err = tr.ResponseBody(nil, strings.NewReader("audio-chunk"), true)
+			require.NoError(t, err)
+			require.Equal(t, LLMTokenUsage{Characters: 5}, usage)
+		})
+	}
+}
+
+func TestOpenAIToAzureOpenAITranslatorV1AudioSpeech_RequestBody(t *testing.T) {
+	const raw = `{"model":"tts-1","input":"hello","voice":"alloy"}`
+	tr := NewAudioSpeechOpenAIToAzureOpenAITranslator("2024-10-21", "my-deployment")
+	hm, bm, err := tr.RequestBody([]byte(raw), &openai.AudioSpeechRequest{Model: "tts-1", Input: "hello"}, false)
+	require.NoError(t, err)
+	require.Equal(t, "/openai/deployments/my-deployment/audio/speech?api-version=2024-10-21", string(hm.SetHeaders[0].Header.RawValue))
+	require.JSONEq(t, `{"model":"my-deployment","input":"hello","voice":"alloy"}`, string(bm.GetBody()))
+
+	_, _, usage, err := tr.ResponseBody(nil, strings.NewReader("audio"), true)
+	require.NoError(t, err)
+	require.Equal(t, LLMTokenUsage{Characters: 5}, usage)
+}
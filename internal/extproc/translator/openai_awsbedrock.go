@@ -490,6 +490,13 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) bedrockToolUseToOpenAICal
 // If AWS Bedrock connection fails the error body is translated to OpenAI error type for events such as HTTP 503 or 504.
 func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) ResponseError(respHeaders map[string]string, body io.Reader) (
 	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	return awsBedrockErrorToOpenAIError(respHeaders, body)
+}
+
+// awsBedrockErrorToOpenAIError builds the mutations that replace the AWS Bedrock error response with the OpenAI error.
+func awsBedrockErrorToOpenAIError(respHeaders map[string]string, body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
 ) {
 	statusCode := respHeaders[statusHeaderName]
 	var openaiError openai.Error
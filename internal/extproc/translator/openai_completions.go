@@ -0,0 +1,128 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/tidwall/sjson"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// NewCompletionOpenAIToOpenAITranslator implements [Factory] for OpenAI to OpenAI translation for the legacy completions.
+func NewCompletionOpenAIToOpenAITranslator(apiVersion string, modelNameOverride string) OpenAICompletionTranslator {
+	return &openAIToOpenAITranslatorV1Completion{modelNameOverride: modelNameOverride, path: path.Join("/", apiVersion, "completions")}
+}
+
+// openAIToOpenAITranslatorV1Completion implements [OpenAICompletionTranslator] for /completions.
+type openAIToOpenAITranslatorV1Completion struct {
+	modelNameOverride string
+	stream            bool
+	buffered          []byte
+	bufferingDone     bool
+	// The path of the completions endpoint to be used for the request. It is prefixed with the OpenAI path prefix.
+	path string
+}
+
+// RequestBody implements [OpenAICompletionTranslator.RequestBody].
+func (o *openAIToOpenAITranslatorV1Completion) RequestBody(original []byte, req *openai.CompletionRequest, forceBodyMutation bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	o.stream = req.Stream
+	var newBody []byte
+	if o.modelNameOverride != "" {
+		// If modelName is set we override the model to be used for the request.
+		newBody, err = sjson.SetBytesOptions(original, "model", o.modelNameOverride, SJSONOptions)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to set model name: %w", err)
+		}
+	}
+	if forceBodyMutation && len(newBody) == 0 {
+		newBody = original
+	}
+	headerMutation, bodyMutation = buildRequestMutations(o.path, newBody)
+	return
+}
+
+// ResponseHeaders implements [OpenAICompletionTranslator.ResponseHeaders].
+func (o *openAIToOpenAITranslatorV1Completion) ResponseHeaders(map[string]string) (headerMutation *extprocv3.HeaderMutation, err error) {
+	return nil, nil
+}
+
+// ResponseBody implements [OpenAICompletionTranslator.ResponseBody].
+func (o *openAIToOpenAITranslatorV1Completion) ResponseBody(_ map[string]string, body io.Reader, _ bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	if o.stream {
+		if !o.bufferingDone {
+			buf, err := io.ReadAll(body)
+			if err != nil {
+				return nil, nil, tokenUsage, fmt.Errorf("failed to read body: %w", err)
+			}
+			o.buffered = append(o.buffered, buf...)
+			tokenUsage = o.extractUsageFromBufferEvent()
+		}
+		return
+	}
+	var resp openai.CompletionResponse
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	if usage := resp.Usage; usage != nil {
+		tokenUsage = completionUsageToLLMTokenUsage(usage)
+	}
+	return
+}
+
+// extractUsageFromBufferEvent extracts the token usage from the buffered event.
+// Once the usage is extracted, it returns the number of tokens used, and bufferingDone is set to true.
+func (o *openAIToOpenAITranslatorV1Completion) extractUsageFromBufferEvent() (tokenUsage LLMTokenUsage) {
+	for {
+		i := bytes.IndexByte(o.buffered, '\n')
+		if i == -1 {
+			return
+		}
+		line := o.buffered[:i]
+		o.buffered = o.buffered[i+1:]
+		if !bytes.HasPrefix(line, dataPrefix) {
+			continue
+		}
+		var event openai.CompletionResponse
+		if err := json.Unmarshal(bytes.TrimPrefix(line, dataPrefix), &event); err != nil {
+			continue
+		}
+		if usage := event.Usage; usage != nil {
+			tokenUsage = completionUsageToLLMTokenUsage(usage)
+			o.bufferingDone = true
+			o.buffered = nil
+			return
+		}
+	}
+}
+
+// ResponseError implements [OpenAICompletionTranslator.ResponseError].
+// For OpenAI based backend we return the OpenAI error type as is.
+// If connection fails the error body is translated to OpenAI error type for events such as HTTP 503 or 504.
+func (o *openAIToOpenAITranslatorV1Completion) ResponseError(respHeaders map[string]string, body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	return openAIBackendErrorToOpenAIError(respHeaders, body)
+}
+
+// completionUsageToLLMTokenUsage converts the OpenAI usage into the [LLMTokenUsage].
+func completionUsageToLLMTokenUsage(usage *openai.ChatCompletionResponseUsage) LLMTokenUsage {
+	return LLMTokenUsage{
+		InputTokens:  uint32(usage.PromptTokens),     //nolint:gosec
+		OutputTokens: uint32(usage.CompletionTokens), //nolint:gosec
+		TotalTokens:  uint32(usage.TotalTokens),      //nolint:gosec
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestOpenAIToOpenAITranslatorV1Completion_RequestBody(t *testing.T) {
+	const raw = `{"model":"codellama","prompt":"def fib(n):"}`
+	for _, tc := range []struct {
+		name              string
+		override          string
+		forceBodyMutation bool
+		expBody           string
+	}{
+		{name: "passthrough"},
+		{name: "force body mutation", forceBodyMutation: true, expBody: raw},
+		{name: "override", override: "codellama-13b", expBody: `{"model":"codellama-13b","prompt":"def fib(n):"}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := NewCompletionOpenAIToOpenAITranslator("v1", tc.override)
+			hm, bm, err := tr.RequestBody([]byte(raw), &openai.CompletionRequest{Model: "codellama"}, tc.forceBodyMutation)
+			require.NoError(t, err)
+			require.Equal(t, ":path", hm.SetHeaders[0].Header.Key)
+			require.Equal(t, "/v1/completions", string(hm.SetHeaders[0].Header.RawValue))
+			if tc.expBody == "" {
+				require.Nil(t, bm)
+				return
+			}
+			require.JSONEq(t, tc.expBody, string(bm.GetBody()))
+		})
+	}
+}
+
+func TestOpenAIToOpenAITranslatorV1Completion_ResponseBody(t *testing.T) {
+	t.Run("non-streaming", func(t *testing.T) {
+		tr := NewCompletionOpenAIToOpenAITranslator("v1", "")
+		_, _, err := tr.RequestBody(nil, &openai.CompletionRequest{}, false)
+		require.NoError(t, err)
+		const body = `{"id":"cmpl-1","object":"text_completion","choices":[{"text":" return n","index":0}],
+"usage":{"prompt_tokens":5,"completion_tokens":7,"total_tokens":12}}`
+		hm, bm, usage, err := tr.ResponseBody(nil, strings.NewReader(body), true)
+		require.NoError(t, err)
+		require.Nil(t, hm)
+		require.Nil(t, bm)
+		require.Equal(t, LLMTokenUsage{InputTokens: 5, OutputTokens: 7, TotalTokens: 12}, usage)
+	})
+	t.Run("non-streaming without usage", func(t *testing.T) {
+		tr := NewCompletionOpenAIToOpenAITranslator("v1", "")
+		_, _, err := tr.RequestBody(nil, &openai.CompletionRequest{}, false)
+		require.NoError(t, err)
+		_, _, usage, err := tr.ResponseBody(nil, strings.NewReader(`{"choices":[]}`), true)
+		require.NoError(t, err)
+		require.Equal(t, LLMTokenUsage{}, usage)
+	})
+	t.Run("invalid body", func(t *testing.T) {
+		tr := NewCompletionOpenAIToOpenAITranslator("v1", "")
+		_, _, err := tr.RequestBody(nil, &openai.CompletionRequest{}, false)
+		require.NoError(t, err)
+		_, _, _, err = tr.ResponseBody(nil, strings.NewReader("invalid"), true)
+		require.ErrorContains(t, err, "failed to unmarshal body")
+	})
+	t.Run("streaming", func(t *testing.T) {
+		tr := NewCompletionOpenAIToOpenAITranslator("v1", "")
+		_, _, err := tr.RequestBody(nil, &openai.CompletionRequest{Stream: true}, false)
+		require.NoError(t, err)
+
+		_, _, usage, err := tr.ResponseBody(nil, strings.NewReader(`data: {"object":"text_completion","choices":[{"text":" return","index":0}]}
+
+data: {"object":"text_completion","choices":[{"text":" n","index":0,"finish_reason":"stop"}]}
+
+data: {"object":"text_`), false)
+		require.NoError(t, err)
+		require.Equal(t, LLMTokenUsage{}, usage)
+
+		// The usage event is split across the chunks.
+		hm, bm, usage, err := tr.ResponseBody(nil, strings.NewReader(`completion","choices":[],"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}
+
+data: [DONE]
+
+`), true)
+		require.NoError(t, err)
+		require.Nil(t, hm)
+		require.Nil(t, bm)
+		require.Equal(t, LLMTokenUsage{InputTokens: 5, OutputTokens: 2, TotalTokens: 7}, usage)
+	})
+}
+
+func TestOpenAIToOpenAITranslatorV1Completion_ResponseError(t *testing.T) {
+	tr := NewCompletionOpenAIToOpenAITranslator("v1", "")
+	t.Run("non-json", func(t *testing.T) {
+		_, bm, err := tr.ResponseError(map[string]string{statusHeaderName: "503", contentTypeHeaderName: "text/plain"}, strings.NewReader("unavailable"))
+		require.NoError(t, err)
+		var openAIErr openai.Error
+		require.NoError(t, json.Unmarshal(bm.GetBody(), &openAIErr))
+		require.Equal(t, openAIBackendError, openAIErr.Error.Type)
+		require.Equal(t, "unavailable", openAIErr.Error.Message)
+	})
+	t.Run("json", func(t *testing.T) {
+		hm, bm, err := tr.ResponseError(map[string]string{statusHeaderName: "400", contentTypeHeaderName: jsonContentType}, strings.NewReader(`{"error":{}}`))
+		require.NoError(t, err)
+		require.Nil(t, hm)
+		require.Nil(t, bm)
+	})
+}
@@ -97,6 +97,14 @@ func (o *openAIToOpenAITranslatorV1Embedding) ResponseBody(_ map[string]string,
 // If connection fails the error body is translated to OpenAI error type for events such as HTTP 503 or 504.
 func (o *openAIToOpenAITranslatorV1Embedding) ResponseError(respHeaders map[string]string, body io.Reader) (
 	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	return openAIBackendErrorToOpenAIError(respHeaders, body)
+}
+
+// openAIBackendErrorToOpenAIError translates the non-JSON error body returned by the OpenAI compatible backends
+// into the OpenAI error type. The JSON error body is assumed to be in the OpenAI format and returned as is.
+func openAIBackendErrorToOpenAIError(respHeaders map[string]string, body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
 ) {
 	statusCode := respHeaders[statusHeaderName]
 	if v, ok := respHeaders[contentTypeHeaderName]; ok && v != jsonContentType {
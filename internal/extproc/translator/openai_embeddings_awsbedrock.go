@@ -0,0 +1,145 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+const (
+	// awsBedrockInputTokenCountHeaderName is the header that AWS Bedrock InvokeModel uses to report the input token count.
+	awsBedrockInputTokenCountHeaderName = "x-amzn-bedrock-input-token-count"
+	// cohereEmbeddingInputTypeSearchDocument is the default input type for the Cohere embeddings since
+	// OpenAI has no equivalent and embeddings are most commonly used to index documents.
+	cohereEmbeddingInputTypeSearchDocument = "search_document"
+)
+
+// NewEmbeddingOpenAIToAWSBedrockTranslator implements [Factory] for OpenAI to AWS Bedrock translation for embeddings.
+// The request is sent to the InvokeModel API, and the body shape is selected by the model family: Cohere Embed
+// models use the "texts" array while Amazon Titan models only accept a single "inputText".
+func NewEmbeddingOpenAIToAWSBedrockTranslator(modelNameOverride string) OpenAIEmbeddingTranslator {
+	return &openAIToAWSBedrockTranslatorV1Embedding{modelNameOverride: modelNameOverride}
+}
+
+// openAIToAWSBedrockTranslatorV1Embedding implements [OpenAIEmbeddingTranslator] for AWS Bedrock InvokeModel.
+type openAIToAWSBedrockTranslatorV1Embedding struct {
+	modelNameOverride string
+	// modelName is the model name sent to AWS Bedrock, which is reported back in the response.
+	modelName string
+	// cohere is true when the request was sent in the Cohere Embed body shape.
+	cohere bool
+}
+
+// RequestBody implements [OpenAIEmbeddingTranslator.RequestBody].
+func (o *openAIToAWSBedrockTranslatorV1Embedding) RequestBody(_ []byte, req *openai.EmbeddingRequest, _ bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	o.modelName = req.Model
+	if o.modelNameOverride != "" {
+		// Use modelName override if set.
+		o.modelName = o.modelNameOverride
+	}
+	inputs, err := embeddingInputToStrings(req.Input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var body []byte
+	if o.cohere = strings.Contains(o.modelName, "cohere"); o.cohere {
+		body, err = json.Marshal(awsbedrock.CohereEmbeddingRequest{
+			Texts:     inputs,
+			InputType: cohereEmbeddingInputTypeSearchDocument,
+		})
+	} else {
+		if len(inputs) != 1 {
+			return nil, nil, fmt.Errorf("model %s only supports a single input, got %d", o.modelName, len(inputs))
+		}
+		body, err = json.Marshal(awsbedrock.TitanEmbeddingRequest{
+			InputText:  inputs[0],
+			Dimensions: req.Dimensions,
+		})
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	headerMutation, bodyMutation = buildRequestMutations(fmt.Sprintf("/model/%s/invoke", o.modelName), body)
+	return
+}
+
+// ResponseHeaders implements [OpenAIEmbeddingTranslator.ResponseHeaders].
+func (o *openAIToAWSBedrockTranslatorV1Embedding) ResponseHeaders(map[string]string) (headerMutation *extprocv3.HeaderMutation, err error) {
+	return nil, nil
+}
+
+// ResponseBody implements [OpenAIEmbeddingTranslator.ResponseBody].
+//
+// The input token count is taken from the response header which is set for all the models, and the
+// token count in the Titan response body is used as a fallback.
+func (o *openAIToAWSBedrockTranslatorV1Embedding) ResponseBody(respHeaders map[string]string, body io.Reader, _ bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	openAIResp := openai.EmbeddingResponse{Object: "list", Model: o.modelName}
+	inputTokens, _ := strconv.Atoi(respHeaders[awsBedrockInputTokenCountHeaderName])
+	if o.cohere {
+		var resp awsbedrock.CohereEmbeddingResponse
+		if err = json.NewDecoder(body).Decode(&resp); err != nil {
+			return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal body: %w", err)
+		}
+		for i, embedding := range resp.Embeddings {
+			openAIResp.Data = append(openAIResp.Data, openai.Embedding{Object: "embedding", Embedding: embedding, Index: i})
+		}
+	} else {
+		var resp awsbedrock.TitanEmbeddingResponse
+		if err = json.NewDecoder(body).Decode(&resp); err != nil {
+			return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal body: %w", err)
+		}
+		openAIResp.Data = append(openAIResp.Data, openai.Embedding{Object: "embedding", Embedding: resp.Embedding})
+		if inputTokens == 0 {
+			inputTokens = resp.InputTextTokenCount
+		}
+	}
+	openAIResp.Usage = openai.EmbeddingUsage{PromptTokens: inputTokens, TotalTokens: inputTokens}
+	tokenUsage = LLMTokenUsage{
+		InputTokens: uint32(inputTokens), //nolint:gosec
+		TotalTokens: uint32(inputTokens), //nolint:gosec
+	}
+
+	mut := &extprocv3.BodyMutation_Body{}
+	if mut.Body, err = json.Marshal(openAIResp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	headerMutation = &extprocv3.HeaderMutation{}
+	setContentLength(headerMutation, mut.Body)
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, tokenUsage, nil
+}
+
+// ResponseError implements [OpenAIEmbeddingTranslator.ResponseError].
+func (o *openAIToAWSBedrockTranslatorV1Embedding) ResponseError(respHeaders map[string]string, body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	return awsBedrockErrorToOpenAIError(respHeaders, body)
+}
+
+// embeddingInputToStrings returns the input of the embedding request as a list of strings.
+func embeddingInputToStrings(input openai.StringOrArray) ([]string, error) {
+	switch v := input.Value.(type) {
+	case string:
+		return []string{v}, nil
+	case []string:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unsupported embedding input type: %T", v)
+	}
+}
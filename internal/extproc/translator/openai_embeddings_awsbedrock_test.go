@@ -0,0 +1,115 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestOpenAIToAWSBedrockTranslatorV1Embedding_RequestBody(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		override string
+		req      openai.EmbeddingRequest
+		expPath  string
+		expBody  string
+		expErr   string
+	}{
+		{
+			name:    "titan",
+			req:     openai.EmbeddingRequest{Model: "amazon.titan-embed-text-v2:0", Input: openai.StringOrArray{Value: "hello"}, Dimensions: ptr.To(256)},
+			expPath: "/model/amazon.titan-embed-text-v2:0/invoke",
+			expBody: `{"inputText":"hello","dimensions":256}`,
+		},
+		{
+			name:   "titan with multiple inputs",
+			req:    openai.EmbeddingRequest{Model: "amazon.titan-embed-text-v2:0", Input: openai.StringOrArray{Value: []string{"a", "b"}}},
+			expErr: "model amazon.titan-embed-text-v2:0 only supports a single input, got 2",
+		},
+		{
+			name:     "cohere with override",
+			override: "cohere.embed-english-v3",
+			req:      openai.EmbeddingRequest{Model: "some-model", Input: openai.StringOrArray{Value: []string{"a", "b"}}},
+			expPath:  "/model/cohere.embed-english-v3/invoke",
+			expBody:  `{"texts":["a","b"],"input_type":"search_document"}`,
+		},
+		{
+			name:   "unsupported input",
+			req:    openai.EmbeddingRequest{Model: "amazon.titan-embed-text-v2:0", Input: openai.StringOrArray{Value: 1}},
+			expErr: "unsupported embedding input type: int",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := NewEmbeddingOpenAIToAWSBedrockTranslator(tc.override)
+			hm, bm, err := tr.RequestBody(nil, &tc.req, false)
+			if tc.expErr != "" {
+				require.EqualError(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, ":path", hm.SetHeaders[0].Header.Key)
+			require.Equal(t, tc.expPath, string(hm.SetHeaders[0].Header.RawValue))
+			require.JSONEq(t, tc.expBody, string(bm.GetBody()))
+		})
+	}
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1Embedding_ResponseBody(t *testing.T) {
+	t.Run("titan", func(t *testing.T) {
+		tr := &openAIToAWSBedrockTranslatorV1Embedding{modelName: "amazon.titan-embed-text-v2:0"}
+		hm, bm, usage, err := tr.ResponseBody(map[string]string{}, strings.NewReader(`{"embedding":[0.1,0.2],"inputTextTokenCount":4}`), true)
+		require.NoError(t, err)
+		require.NotNil(t, hm)
+		require.Equal(t, LLMTokenUsage{InputTokens: 4, TotalTokens: 4}, usage)
+		var resp openai.EmbeddingResponse
+		require.NoError(t, json.Unmarshal(bm.GetBody(), &resp))
+		require.Equal(t, openai.EmbeddingResponse{
+			Object: "list",
+			Model:  "amazon.titan-embed-text-v2:0",
+			Data:   []openai.Embedding{{Object: "embedding", Embedding: []float64{0.1, 0.2}}},
+			Usage:  openai.EmbeddingUsage{PromptTokens: 4, TotalTokens: 4},
+		}, resp)
+	})
+	t.Run("cohere", func(t *testing.T) {
+		tr := &openAIToAWSBedrockTranslatorV1Embedding{modelName: "cohere.embed-english-v3", cohere: true}
+		_, bm, usage, err := tr.ResponseBody(map[string]string{awsBedrockInputTokenCountHeaderName: "7"},
+			strings.NewReader(`{"id":"1","embeddings":[[0.1],[0.2]],"texts":["a","b"]}`), true)
+		require.NoError(t, err)
+		require.Equal(t, LLMTokenUsage{InputTokens: 7, TotalTokens: 7}, usage)
+		var resp openai.EmbeddingResponse
+		require.NoError(t, json.Unmarshal(bm.GetBody(), &resp))
+		require.Len(t, resp.Data, 2)
+		require.Equal(t, 1, resp.Data[1].Index)
+		require.Equal(t, []float64{0.2}, resp.Data[1].Embedding)
+	})
+	t.Run("invalid body", func(t *testing.T) {
+		tr := &openAIToAWSBedrockTranslatorV1Embedding{}
+		_, _, _, err := tr.ResponseBody(nil, strings.NewReader("invalid"), true)
+		require.ErrorContains(t, err, "failed to unmarshal body")
+	})
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1Embedding_ResponseError(t *testing.T) {
+	tr := &openAIToAWSBedrockTranslatorV1Embedding{}
+	hm, bm, err := tr.ResponseError(map[string]string{
+		statusHeaderName:       "400",
+		contentTypeHeaderName:  jsonContentType,
+		awsErrorTypeHeaderName: "ValidationException",
+	}, strings.NewReader(`{"message":"bad input"}`))
+	require.NoError(t, err)
+	require.NotNil(t, hm)
+	var openAIErr openai.Error
+	require.NoError(t, json.Unmarshal(bm.GetBody(), &openAIErr))
+	require.Equal(t, "ValidationException", openAIErr.Error.Type)
+	require.Equal(t, "bad input", openAIErr.Error.Message)
+}
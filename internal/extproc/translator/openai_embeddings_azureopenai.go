@@ -0,0 +1,67 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"fmt"
+	"strconv"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// NewEmbeddingOpenAIToAzureOpenAITranslator implements [Factory] for OpenAI to Azure OpenAI translation for embeddings.
+// Except RequestBody method which sets the deployment path
+// https://learn.microsoft.com/en-us/azure/ai-services/openai/reference#embeddings, other interface methods
+// are identical to NewEmbeddingOpenAIToOpenAITranslator's interface implementations.
+func NewEmbeddingOpenAIToAzureOpenAITranslator(apiVersion string, modelNameOverride string) OpenAIEmbeddingTranslator {
+	return &openAIToAzureOpenAITranslatorV1Embedding{
+		apiVersion: apiVersion,
+		openAIToOpenAITranslatorV1Embedding: openAIToOpenAITranslatorV1Embedding{
+			modelNameOverride: modelNameOverride,
+		},
+	}
+}
+
+type openAIToAzureOpenAITranslatorV1Embedding struct {
+	apiVersion string
+	openAIToOpenAITranslatorV1Embedding
+}
+
+// RequestBody implements [OpenAIEmbeddingTranslator.RequestBody].
+func (o *openAIToAzureOpenAITranslatorV1Embedding) RequestBody(raw []byte, req *openai.EmbeddingRequest, onRetry bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	modelName := req.Model
+	if o.modelNameOverride != "" {
+		// If modelName is set we override the model to be used for the request.
+		modelName = o.modelNameOverride
+	}
+	// Assume deployment_id is same as model name.
+	pathTemplate := "/openai/deployments/%s/embeddings?api-version=%s"
+	headerMutation = &extprocv3.HeaderMutation{
+		SetHeaders: []*corev3.HeaderValueOption{
+			{Header: &corev3.HeaderValue{
+				Key:      ":path",
+				RawValue: []byte(fmt.Sprintf(pathTemplate, modelName, o.apiVersion)),
+			}},
+		},
+	}
+
+	// On retry, the body might have been modified for a different provider. So, this will ensure that the original body is sent.
+	if onRetry {
+		headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{Header: &corev3.HeaderValue{
+			Key:      "content-length",
+			RawValue: []byte(strconv.Itoa(len(raw))),
+		}})
+		bodyMutation = &extprocv3.BodyMutation{
+			Mutation: &extprocv3.BodyMutation_Body{Body: raw},
+		}
+	}
+	return
+}
@@ -0,0 +1,38 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestOpenAIToAzureOpenAITranslatorV1Embedding_RequestBody(t *testing.T) {
+	const raw = `{"model":"text-embedding-3-small","input":"hello"}`
+	var req openai.EmbeddingRequest
+	require.NoError(t, json.Unmarshal([]byte(raw), &req))
+
+	t.Run("ok", func(t *testing.T) {
+		tr := NewEmbeddingOpenAIToAzureOpenAITranslator("2024-10-21", "")
+		hm, bm, err := tr.RequestBody([]byte(raw), &req, false)
+		require.NoError(t, err)
+		require.Nil(t, bm)
+		require.Len(t, hm.SetHeaders, 1)
+		require.Equal(t, "/openai/deployments/text-embedding-3-small/embeddings?api-version=2024-10-21", string(hm.SetHeaders[0].Header.RawValue))
+	})
+	t.Run("override on retry", func(t *testing.T) {
+		tr := NewEmbeddingOpenAIToAzureOpenAITranslator("2024-10-21", "my-deployment")
+		hm, bm, err := tr.RequestBody([]byte(raw), &req, true)
+		require.NoError(t, err)
+		require.Equal(t, "/openai/deployments/my-deployment/embeddings?api-version=2024-10-21", string(hm.SetHeaders[0].Header.RawValue))
+		require.Equal(t, raw, string(bm.GetBody()))
+		require.Equal(t, "content-length", hm.SetHeaders[1].Header.Key)
+	})
+}
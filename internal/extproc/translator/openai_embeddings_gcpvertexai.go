@@ -0,0 +1,138 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/gcp"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// gcpMethodPredict is the method of the GCP Vertex AI API used by the text embeddings models.
+const gcpMethodPredict = "predict"
+
+// NewEmbeddingOpenAIToGCPVertexAITranslator implements [Factory] for OpenAI to GCP Vertex AI translation for embeddings.
+// https://cloud.google.com/vertex-ai/generative-ai/docs/model-reference/text-embeddings-api
+func NewEmbeddingOpenAIToGCPVertexAITranslator(modelNameOverride string) OpenAIEmbeddingTranslator {
+	return &openAIToGCPVertexAITranslatorV1Embedding{modelNameOverride: modelNameOverride}
+}
+
+// openAIToGCPVertexAITranslatorV1Embedding implements [OpenAIEmbeddingTranslator] for GCP Vertex AI :predict.
+type openAIToGCPVertexAITranslatorV1Embedding struct {
+	modelNameOverride string
+	// modelName is the model name sent to GCP Vertex AI, which is reported back in the response.
+	modelName string
+}
+
+// RequestBody implements [OpenAIEmbeddingTranslator.RequestBody].
+func (o *openAIToGCPVertexAITranslatorV1Embedding) RequestBody(_ []byte, req *openai.EmbeddingRequest, _ bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	o.modelName = req.Model
+	if o.modelNameOverride != "" {
+		// Use modelName override if set.
+		o.modelName = o.modelNameOverride
+	}
+	inputs, err := embeddingInputToStrings(req.Input)
+	if err != nil {
+		return nil, nil, err
+	}
+	predictReq := gcp.PredictEmbeddingRequest{Instances: make([]gcp.PredictEmbeddingInstance, 0, len(inputs))}
+	for _, input := range inputs {
+		predictReq.Instances = append(predictReq.Instances, gcp.PredictEmbeddingInstance{Content: input})
+	}
+	if req.Dimensions != nil {
+		predictReq.Parameters = &gcp.PredictEmbeddingParameters{OutputDimensionality: req.Dimensions}
+	}
+	body, err := json.Marshal(predictReq)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	pathSuffix := buildGCPModelPathSuffix(gcpModelPublisherGoogle, o.modelName, gcpMethodPredict)
+	headerMutation, bodyMutation = buildRequestMutations(pathSuffix, body)
+	return
+}
+
+// ResponseHeaders implements [OpenAIEmbeddingTranslator.ResponseHeaders].
+func (o *openAIToGCPVertexAITranslatorV1Embedding) ResponseHeaders(map[string]string) (headerMutation *extprocv3.HeaderMutation, err error) {
+	return nil, nil
+}
+
+// ResponseBody implements [OpenAIEmbeddingTranslator.ResponseBody].
+func (o *openAIToGCPVertexAITranslatorV1Embedding) ResponseBody(_ map[string]string, body io.Reader, _ bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	var resp gcp.PredictEmbeddingResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	openAIResp := openai.EmbeddingResponse{Object: "list", Model: o.modelName}
+	var inputTokens int
+	for i, prediction := range resp.Predictions {
+		openAIResp.Data = append(openAIResp.Data, openai.Embedding{Object: "embedding", Embedding: prediction.Embeddings.Values, Index: i})
+		inputTokens += prediction.Embeddings.Statistics.TokenCount
+	}
+	openAIResp.Usage = openai.EmbeddingUsage{PromptTokens: inputTokens, TotalTokens: inputTokens}
+	tokenUsage = LLMTokenUsage{
+		InputTokens: uint32(inputTokens), //nolint:gosec
+		TotalTokens: uint32(inputTokens), //nolint:gosec
+	}
+
+	mut := &extprocv3.BodyMutation_Body{}
+	if mut.Body, err = json.Marshal(openAIResp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	headerMutation = &extprocv3.HeaderMutation{}
+	setContentLength(headerMutation, mut.Body)
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, tokenUsage, nil
+}
+
+// ResponseError implements [OpenAIEmbeddingTranslator.ResponseError].
+func (o *openAIToGCPVertexAITranslatorV1Embedding) ResponseError(respHeaders map[string]string, body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	return gcpVertexAIErrorToOpenAIError(respHeaders, body)
+}
+
+// gcpVertexAIErrorToOpenAIError builds the mutations that replace the GCP Vertex AI error response with the OpenAI
+// error. The canonical error code of the GCP error is used as the type.
+func gcpVertexAIErrorToOpenAIError(respHeaders map[string]string, body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	statusCode := respHeaders[statusHeaderName]
+	buf, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read error body: %w", err)
+	}
+	openaiError := openai.Error{
+		Type: "error",
+		Error: openai.ErrorType{
+			Type:    gcpVertexAIBackendError,
+			Message: string(buf),
+			Code:    &statusCode,
+		},
+	}
+	if v, ok := respHeaders[contentTypeHeaderName]; ok && strings.Contains(v, jsonContentType) {
+		var gcpError gcp.Error
+		if json.Unmarshal(buf, &gcpError) == nil && gcpError.Error.Message != "" {
+			openaiError.Error.Type = gcpError.Error.Status
+			openaiError.Error.Message = gcpError.Error.Message
+		}
+	}
+	mut := &extprocv3.BodyMutation_Body{}
+	if mut.Body, err = json.Marshal(openaiError); err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal error body: %w", err)
+	}
+	headerMutation = &extprocv3.HeaderMutation{}
+	setContentLength(headerMutation, mut.Body)
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, nil
+}
@@ -0,0 +1,99 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestOpenAIToGCPVertexAITranslatorV1Embedding_RequestBody(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		tr := NewEmbeddingOpenAIToGCPVertexAITranslator("")
+		hm, bm, err := tr.RequestBody(nil, &openai.EmbeddingRequest{
+			Model:      "text-embedding-005",
+			Input:      openai.StringOrArray{Value: []string{"a", "b"}},
+			Dimensions: ptr.To(128),
+		}, false)
+		require.NoError(t, err)
+		require.Equal(t, "publishers/google/models/text-embedding-005:predict", string(hm.SetHeaders[0].Header.RawValue))
+		require.JSONEq(t, `{"instances":[{"content":"a"},{"content":"b"}],"parameters":{"outputDimensionality":128}}`, string(bm.GetBody()))
+	})
+	t.Run("override", func(t *testing.T) {
+		tr := NewEmbeddingOpenAIToGCPVertexAITranslator("text-multilingual-embedding-002")
+		hm, bm, err := tr.RequestBody(nil, &openai.EmbeddingRequest{Model: "foo", Input: openai.StringOrArray{Value: "a"}}, false)
+		require.NoError(t, err)
+		require.Equal(t, "publishers/google/models/text-multilingual-embedding-002:predict", string(hm.SetHeaders[0].Header.RawValue))
+		require.JSONEq(t, `{"instances":[{"content":"a"}]}`, string(bm.GetBody()))
+	})
+}
+
+func TestOpenAIToGCPVertexAITranslatorV1Embedding_ResponseBody(t *testing.T) {
+	tr := &openAIToGCPVertexAITranslatorV1Embedding{modelName: "text-embedding-005"}
+	const body = `{"predictions":[
+{"embeddings":{"values":[0.1,0.2],"statistics":{"token_count":3,"truncated":false}}},
+{"embeddings":{"values":[0.3,0.4],"statistics":{"token_count":5,"truncated":false}}}]}`
+	_, bm, usage, err := tr.ResponseBody(nil, strings.NewReader(body), true)
+	require.NoError(t, err)
+	require.Equal(t, LLMTokenUsage{InputTokens: 8, TotalTokens: 8}, usage)
+	var resp openai.EmbeddingResponse
+	require.NoError(t, json.Unmarshal(bm.GetBody(), &resp))
+	require.Equal(t, openai.EmbeddingResponse{
+		Object: "list",
+		Model:  "text-embedding-005",
+		Data: []openai.Embedding{
+			{Object: "embedding", Embedding: []float64{0.1, 0.2}},
+			{Object: "embedding", Embedding: []float64{0.3, 0.4}, Index: 1},
+		},
+		Usage: openai.EmbeddingUsage{PromptTokens: 8, TotalTokens: 8},
+	}, resp)
+
+	_, _, _, err = tr.ResponseBody(nil, strings.NewReader("invalid"), true)
+	require.ErrorContains(t, err, "failed to unmarshal body")
+}
+
+func TestOpenAIToGCPVertexAITranslatorV1Embedding_ResponseError(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		contentType string
+		body        string
+		expType     string
+		expMessage  string
+	}{
+		{
+			name:        "gcp error",
+			contentType: jsonContentType,
+			body:        `{"error":{"code":400,"message":"bad input","status":"INVALID_ARGUMENT"}}`,
+			expType:     "INVALID_ARGUMENT",
+			expMessage:  "bad input",
+		},
+		{
+			name:        "non-json error",
+			contentType: "text/plain",
+			body:        "upstream connect error",
+			expType:     gcpVertexAIBackendError,
+			expMessage:  "upstream connect error",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := &openAIToGCPVertexAITranslatorV1Embedding{}
+			hm, bm, err := tr.ResponseError(map[string]string{statusHeaderName: "400", contentTypeHeaderName: tc.contentType}, strings.NewReader(tc.body))
+			require.NoError(t, err)
+			require.NotNil(t, hm)
+			var openAIErr openai.Error
+			require.NoError(t, json.Unmarshal(bm.GetBody(), &openAIErr))
+			require.Equal(t, tc.expType, openAIErr.Error.Type)
+			require.Equal(t, tc.expMessage, openAIErr.Error.Message)
+			require.Equal(t, "400", *openAIErr.Error.Code)
+		})
+	}
+}
@@ -305,9 +305,8 @@ func (o *openAIToGCPVertexAITranslatorV1ChatCompletion) geminiResponseToOpenAIMe
 }
 
 // ResponseError implements [OpenAIChatCompletionTranslator.ResponseError] for GCP Vertex AI.
-func (o *openAIToGCPVertexAITranslatorV1ChatCompletion) ResponseError(map[string]string, io.Reader) (
+func (o *openAIToGCPVertexAITranslatorV1ChatCompletion) ResponseError(respHeaders map[string]string, body io.Reader) (
 	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
 ) {
-	// TODO: add support for converting GCP Vertex AI error responses to OpenAI error format.
-	return nil, nil, nil
+	return gcpVertexAIErrorToOpenAIError(respHeaders, body)
 }
@@ -639,6 +639,26 @@ data: [DONE]
 	}
 }
 
+func TestOpenAIToGCPVertexAITranslatorV1ChatCompletion_ResponseError(t *testing.T) {
+	tr := NewChatCompletionOpenAIToGCPVertexAITranslator("")
+	hm, bm, err := tr.ResponseError(map[string]string{statusHeaderName: "429", contentTypeHeaderName: jsonContentType},
+		strings.NewReader(`{"error":{"code":429,"message":"Resource exhausted.","status":"RESOURCE_EXHAUSTED"}}`))
+	require.NoError(t, err)
+	require.NotNil(t, hm)
+	var openAIErr openai.Error
+	require.NoError(t, json.Unmarshal(bm.GetBody(), &openAIErr))
+	require.Equal(t, "RESOURCE_EXHAUSTED", openAIErr.Error.Type)
+	require.Equal(t, "Resource exhausted.", openAIErr.Error.Message)
+	require.Equal(t, ptr.To("429"), openAIErr.Error.Code)
+
+	_, bm, err = tr.ResponseError(map[string]string{statusHeaderName: "503", contentTypeHeaderName: "text/plain"},
+		strings.NewReader("upstream connect error"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(bm.GetBody(), &openAIErr))
+	require.Equal(t, gcpVertexAIBackendError, openAIErr.Error.Type)
+	require.Equal(t, "upstream connect error", openAIErr.Error.Message)
+}
+
 func TestOpenAIToGCPVertexAITranslatorV1ChatCompletion_StreamingResponseHeaders(t *testing.T) {
 	eventStreamHeaderMutation := &extprocv3.HeaderMutation{
 		SetHeaders: []*corev3.HeaderValueOption{
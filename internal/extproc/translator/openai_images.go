@@ -0,0 +1,195 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/tidwall/sjson"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// NewImageGenerationOpenAIToOpenAITranslator implements [Factory] for OpenAI to OpenAI translation for image generation.
+func NewImageGenerationOpenAIToOpenAITranslator(apiVersion string, modelNameOverride string) OpenAIImageGenerationTranslator {
+	return &openAIToOpenAITranslatorV1ImageGeneration{modelNameOverride: modelNameOverride, path: path.Join("/", apiVersion, "images/generations")}
+}
+
+// openAIToOpenAITranslatorV1ImageGeneration implements [OpenAIImageGenerationTranslator] for /images/generations.
+type openAIToOpenAITranslatorV1ImageGeneration struct {
+	modelNameOverride string
+	// The path of the image generation endpoint to be used for the request. It is prefixed with the OpenAI path prefix.
+	path string
+}
+
+// RequestBody implements [OpenAIImageGenerationTranslator.RequestBody].
+func (o *openAIToOpenAITranslatorV1ImageGeneration) RequestBody(original []byte, _ *openai.ImageGenerationRequest, onRetry bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	var newBody []byte
+	if o.modelNameOverride != "" {
+		// If modelName is set we override the model to be used for the request.
+		newBody, err = sjson.SetBytesOptions(original, "model", o.modelNameOverride, SJSONOptions)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to set model name: %w", err)
+		}
+	}
+	if onRetry && len(newBody) == 0 {
+		newBody = original
+	}
+	headerMutation, bodyMutation = buildRequestMutations(o.path, newBody)
+	return
+}
+
+// ResponseHeaders implements [OpenAIImageGenerationTranslator.ResponseHeaders].
+func (o *openAIToOpenAITranslatorV1ImageGeneration) ResponseHeaders(map[string]string) (headerMutation *extprocv3.HeaderMutation, err error) {
+	return nil, nil
+}
+
+// ResponseBody implements [OpenAIImageGenerationTranslator.ResponseBody].
+func (o *openAIToOpenAITranslatorV1ImageGeneration) ResponseBody(_ map[string]string, body io.Reader, _ bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	var resp openai.ImageGenerationResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	tokenUsage = imageGenerationResponseUsage(&resp)
+	return
+}
+
+// ResponseError implements [OpenAIImageGenerationTranslator.ResponseError].
+// For OpenAI based backend we return the OpenAI error type as is.
+// If connection fails the error body is translated to OpenAI error type for events such as HTTP 503 or 504.
+func (o *openAIToOpenAITranslatorV1ImageGeneration) ResponseError(respHeaders map[string]string, body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	return openAIBackendErrorToOpenAIError(respHeaders, body)
+}
+
+// NewImageGenerationOpenAIToAzureOpenAITranslator implements [Factory] for OpenAI to Azure OpenAI translation
+// for image generation. Only the request path differs from the OpenAI translator.
+// https://learn.microsoft.com/en-us/azure/ai-services/openai/reference#image-generation
+func NewImageGenerationOpenAIToAzureOpenAITranslator(apiVersion string, modelNameOverride string) OpenAIImageGenerationTranslator {
+	return &openAIToAzureOpenAITranslatorV1ImageGeneration{
+		apiVersion: apiVersion,
+		openAIToOpenAITranslatorV1ImageGeneration: openAIToOpenAITranslatorV1ImageGeneration{
+			modelNameOverride: modelNameOverride,
+		},
+	}
+}
+
+type openAIToAzureOpenAITranslatorV1ImageGeneration struct {
+	apiVersion string
+	openAIToOpenAITranslatorV1ImageGeneration
+}
+
+// RequestBody implements [OpenAIImageGenerationTranslator.RequestBody].
+func (o *openAIToAzureOpenAITranslatorV1ImageGeneration) RequestBody(raw []byte, req *openai.ImageGenerationRequest, onRetry bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	modelName := req.Model
+	if o.modelNameOverride != "" {
+		// If modelName is set we override the model to be used for the request.
+		modelName = o.modelNameOverride
+	}
+	// Assume deployment_id is same as model name.
+	pathTemplate := "/openai/deployments/%s/images/generations?api-version=%s"
+	headerMutation = &extprocv3.HeaderMutation{
+		SetHeaders: []*corev3.HeaderValueOption{
+			{Header: &corev3.HeaderValue{
+				Key:      ":path",
+				RawValue: []byte(fmt.Sprintf(pathTemplate, modelName, o.apiVersion)),
+			}},
+		},
+	}
+	// On retry, the body might have been modified for a different provider. So, this will ensure that the original body is sent.
+	if onRetry {
+		bodyMutation = &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: raw}}
+		setContentLength(headerMutation, raw)
+	}
+	return
+}
+
+// imageGenerationResponseUsage returns the number of the generated images as well as the tokens if reported.
+func imageGenerationResponseUsage(resp *openai.ImageGenerationResponse) LLMTokenUsage {
+	tokenUsage := LLMTokenUsage{ImageCount: uint32(len(resp.Data))} //nolint:gosec
+	if u := resp.Usage; u != nil {
+		tokenUsage.InputTokens = uint32(u.InputTokens)   //nolint:gosec
+		tokenUsage.OutputTokens = uint32(u.OutputTokens) //nolint:gosec
+		tokenUsage.TotalTokens = uint32(u.TotalTokens)   //nolint:gosec
+	}
+	return tokenUsage
+}
+
+// imageGenerationResponseMutations builds the mutations that replace the response body with the OpenAI image
+// generation response built from the base64-encoded images.
+func imageGenerationResponseMutations(images []openai.ImageData) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	resp := openai.ImageGenerationResponse{Created: openai.JSONUNIXTime(time.Now()), Data: images}
+	tokenUsage = imageGenerationResponseUsage(&resp)
+	mut := &extprocv3.BodyMutation_Body{}
+	if mut.Body, err = json.Marshal(resp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	headerMutation = &extprocv3.HeaderMutation{}
+	setContentLength(headerMutation, mut.Body)
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, tokenUsage, nil
+}
+
+// validateBase64ImageResponseFormat returns an error if the client explicitly asked for the URLs of the generated
+// images, since the translated backends only return the base64-encoded images.
+func validateBase64ImageResponseFormat(req *openai.ImageGenerationRequest) error {
+	if req.ResponseFormat == openai.ImageResponseFormatURL {
+		return fmt.Errorf("response_format %q is not supported by the backend, use %q instead",
+			openai.ImageResponseFormatURL, openai.ImageResponseFormatB64JSON)
+	}
+	return nil
+}
+
+// parseImageSize parses the OpenAI image size in the form of "{width}x{height}".
+func parseImageSize(size string) (width, height int, err error) {
+	w, h, ok := strings.Cut(size, "x")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid image size %q", size)
+	}
+	if width, err = strconv.Atoi(w); err != nil {
+		return 0, 0, fmt.Errorf("invalid image size %q: %w", size, err)
+	}
+	if height, err = strconv.Atoi(h); err != nil {
+		return 0, 0, fmt.Errorf("invalid image size %q: %w", size, err)
+	}
+	return width, height, nil
+}
+
+// imageSizeToAspectRatio converts the OpenAI image size into the aspect ratio such as "16:9".
+// An empty string is returned if the size is not set.
+func imageSizeToAspectRatio(size string) (string, error) {
+	if size == "" || size == "auto" {
+		return "", nil
+	}
+	width, height, err := parseImageSize(size)
+	if err != nil {
+		return "", err
+	}
+	a, b := width, height
+	for b != 0 {
+		a, b = b, a%b
+	}
+	if a == 0 {
+		return "", fmt.Errorf("invalid image size %q", size)
+	}
+	return fmt.Sprintf("%d:%d", width/a, height/a), nil
+}
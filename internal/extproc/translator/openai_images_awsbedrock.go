@@ -0,0 +1,143 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+const (
+	// titanImageTaskTypeTextImage is the task type of the Amazon image models for the text to image generation.
+	titanImageTaskTypeTextImage = "TEXT_IMAGE"
+	titanImageQualityStandard   = "standard"
+	titanImageQualityPremium    = "premium"
+)
+
+// NewImageGenerationOpenAIToAWSBedrockTranslator implements [Factory] for OpenAI to AWS Bedrock translation for
+// image generation. The request is sent to the InvokeModel API, and the body shape is selected by the model
+// family: Stability AI models use the "prompt" body while the Amazon Titan Image Generator and Nova Canvas models
+// use the "TEXT_IMAGE" task.
+func NewImageGenerationOpenAIToAWSBedrockTranslator(modelNameOverride string) OpenAIImageGenerationTranslator {
+	return &openAIToAWSBedrockTranslatorV1ImageGeneration{modelNameOverride: modelNameOverride}
+}
+
+// openAIToAWSBedrockTranslatorV1ImageGeneration implements [OpenAIImageGenerationTranslator] for AWS Bedrock InvokeModel.
+type openAIToAWSBedrockTranslatorV1ImageGeneration struct {
+	modelNameOverride string
+}
+
+// RequestBody implements [OpenAIImageGenerationTranslator.RequestBody].
+func (o *openAIToAWSBedrockTranslatorV1ImageGeneration) RequestBody(_ []byte, req *openai.ImageGenerationRequest, _ bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	modelName := req.Model
+	if o.modelNameOverride != "" {
+		// Use modelName override if set.
+		modelName = o.modelNameOverride
+	}
+	if err = validateBase64ImageResponseFormat(req); err != nil {
+		return nil, nil, err
+	}
+
+	var body []byte
+	if strings.Contains(modelName, "stability") {
+		body, err = openAIToStabilityImageGenerationRequest(modelName, req)
+	} else {
+		body, err = openAIToTitanImageGenerationRequest(req)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	headerMutation, bodyMutation = buildRequestMutations(fmt.Sprintf("/model/%s/invoke", modelName), body)
+	return
+}
+
+// openAIToStabilityImageGenerationRequest builds the request body for the Stability AI models which only
+// generate a single image per request.
+func openAIToStabilityImageGenerationRequest(modelName string, req *openai.ImageGenerationRequest) ([]byte, error) {
+	if req.N != nil && *req.N > 1 {
+		return nil, fmt.Errorf("model %s only supports generating a single image, got n=%d", modelName, *req.N)
+	}
+	aspectRatio, err := imageSizeToAspectRatio(req.Size)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(awsbedrock.StabilityImageGenerationRequest{
+		Prompt:       req.Prompt,
+		AspectRatio:  aspectRatio,
+		OutputFormat: req.OutputFormat,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	return body, nil
+}
+
+// openAIToTitanImageGenerationRequest builds the request body for the Amazon image models.
+func openAIToTitanImageGenerationRequest(req *openai.ImageGenerationRequest) ([]byte, error) {
+	titanReq := awsbedrock.TitanImageGenerationRequest{
+		TaskType:          titanImageTaskTypeTextImage,
+		TextToImageParams: awsbedrock.TitanTextToImageParams{Text: req.Prompt},
+	}
+	config := &awsbedrock.TitanImageGenerationConfig{NumberOfImages: req.N}
+	if req.Size != "" && req.Size != "auto" {
+		width, height, err := parseImageSize(req.Size)
+		if err != nil {
+			return nil, err
+		}
+		config.Width, config.Height = &width, &height
+	}
+	switch req.Quality {
+	case "":
+	case "hd", "high":
+		config.Quality = titanImageQualityPremium
+	default:
+		config.Quality = titanImageQualityStandard
+	}
+	if config.NumberOfImages != nil || config.Width != nil || config.Quality != "" {
+		titanReq.ImageGenerationConfig = config
+	}
+	body, err := json.Marshal(titanReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	return body, nil
+}
+
+// ResponseHeaders implements [OpenAIImageGenerationTranslator.ResponseHeaders].
+func (o *openAIToAWSBedrockTranslatorV1ImageGeneration) ResponseHeaders(map[string]string) (headerMutation *extprocv3.HeaderMutation, err error) {
+	return nil, nil
+}
+
+// ResponseBody implements [OpenAIImageGenerationTranslator.ResponseBody].
+func (o *openAIToAWSBedrockTranslatorV1ImageGeneration) ResponseBody(_ map[string]string, body io.Reader, _ bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	var resp awsbedrock.ImageGenerationResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	images := make([]openai.ImageData, 0, len(resp.Images))
+	for _, image := range resp.Images {
+		images = append(images, openai.ImageData{B64JSON: image})
+	}
+	return imageGenerationResponseMutations(images)
+}
+
+// ResponseError implements [OpenAIImageGenerationTranslator.ResponseError].
+func (o *openAIToAWSBedrockTranslatorV1ImageGeneration) ResponseError(respHeaders map[string]string, body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	return awsBedrockErrorToOpenAIError(respHeaders, body)
+}
@@ -0,0 +1,107 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestOpenAIToAWSBedrockTranslatorV1ImageGeneration_RequestBody(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		override string
+		req      openai.ImageGenerationRequest
+		expPath  string
+		expBody  string
+		expErr   string
+	}{
+		{
+			name:    "titan minimal",
+			req:     openai.ImageGenerationRequest{Model: "amazon.titan-image-generator-v2:0", Prompt: "a cat"},
+			expPath: "/model/amazon.titan-image-generator-v2:0/invoke",
+			expBody: `{"taskType":"TEXT_IMAGE","textToImageParams":{"text":"a cat"}}`,
+		},
+		{
+			name: "titan with config",
+			req: openai.ImageGenerationRequest{
+				Model: "amazon.nova-canvas-v1:0", Prompt: "a cat", N: ptr.To(2), Size: "1024x512", Quality: "hd",
+				ResponseFormat: openai.ImageResponseFormatB64JSON,
+			},
+			expPath: "/model/amazon.nova-canvas-v1:0/invoke",
+			expBody: `{"taskType":"TEXT_IMAGE","textToImageParams":{"text":"a cat"},
+"imageGenerationConfig":{"numberOfImages":2,"width":1024,"height":512,"quality":"premium"}}`,
+		},
+		{
+			name:     "stability with override",
+			override: "stability.stable-image-core-v1:1",
+			req:      openai.ImageGenerationRequest{Model: "foo", Prompt: "a cat", Size: "1792x1024", OutputFormat: "jpeg"},
+			expPath:  "/model/stability.stable-image-core-v1:1/invoke",
+			expBody:  `{"prompt":"a cat","aspect_ratio":"7:4","output_format":"jpeg"}`,
+		},
+		{
+			name:   "stability with multiple images",
+			req:    openai.ImageGenerationRequest{Model: "stability.sd3-5-large-v1:0", Prompt: "a cat", N: ptr.To(2)},
+			expErr: "model stability.sd3-5-large-v1:0 only supports generating a single image, got n=2",
+		},
+		{
+			name:   "url response format",
+			req:    openai.ImageGenerationRequest{Model: "amazon.titan-image-generator-v2:0", ResponseFormat: openai.ImageResponseFormatURL},
+			expErr: `response_format "url" is not supported by the backend, use "b64_json" instead`,
+		},
+		{
+			name:   "invalid size",
+			req:    openai.ImageGenerationRequest{Model: "amazon.titan-image-generator-v2:0", Size: "big"},
+			expErr: `invalid image size "big"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := NewImageGenerationOpenAIToAWSBedrockTranslator(tc.override)
+			hm, bm, err := tr.RequestBody(nil, &tc.req, false)
+			if tc.expErr != "" {
+				require.EqualError(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expPath, string(hm.SetHeaders[0].Header.RawValue))
+			require.JSONEq(t, tc.expBody, string(bm.GetBody()))
+		})
+	}
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1ImageGeneration_ResponseBody(t *testing.T) {
+	tr := NewImageGenerationOpenAIToAWSBedrockTranslator("")
+	hm, bm, usage, err := tr.ResponseBody(nil, strings.NewReader(`{"images":["aaa","bbb"],"error":null}`), true)
+	require.NoError(t, err)
+	require.NotNil(t, hm)
+	require.Equal(t, LLMTokenUsage{ImageCount: 2}, usage)
+	var resp openai.ImageGenerationResponse
+	require.NoError(t, json.Unmarshal(bm.GetBody(), &resp))
+	require.Equal(t, []openai.ImageData{{B64JSON: "aaa"}, {B64JSON: "bbb"}}, resp.Data)
+
+	_, _, _, err = tr.ResponseBody(nil, strings.NewReader("invalid"), true)
+	require.ErrorContains(t, err, "failed to unmarshal body")
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1ImageGeneration_ResponseError(t *testing.T) {
+	tr := NewImageGenerationOpenAIToAWSBedrockTranslator("")
+	_, bm, err := tr.ResponseError(map[string]string{
+		statusHeaderName:       "400",
+		contentTypeHeaderName:  jsonContentType,
+		awsErrorTypeHeaderName: "ValidationException",
+	}, strings.NewReader(`{"message":"blocked by content filters"}`))
+	require.NoError(t, err)
+	var openAIErr openai.Error
+	require.NoError(t, json.Unmarshal(bm.GetBody(), &openAIErr))
+	require.Equal(t, "ValidationException", openAIErr.Error.Type)
+	require.Equal(t, "blocked by content filters", openAIErr.Error.Message)
+}
@@ -0,0 +1,89 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/gcp"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// NewImageGenerationOpenAIToGCPVertexAITranslator implements [Factory] for OpenAI to GCP Vertex AI Imagen translation
+// for image generation.
+// https://cloud.google.com/vertex-ai/generative-ai/docs/model-reference/imagen-api
+func NewImageGenerationOpenAIToGCPVertexAITranslator(modelNameOverride string) OpenAIImageGenerationTranslator {
+	return &openAIToGCPVertexAITranslatorV1ImageGeneration{modelNameOverride: modelNameOverride}
+}
+
+// openAIToGCPVertexAITranslatorV1ImageGeneration implements [OpenAIImageGenerationTranslator] for GCP Vertex AI :predict.
+type openAIToGCPVertexAITranslatorV1ImageGeneration struct {
+	modelNameOverride string
+}
+
+// RequestBody implements [OpenAIImageGenerationTranslator.RequestBody].
+func (o *openAIToGCPVertexAITranslatorV1ImageGeneration) RequestBody(_ []byte, req *openai.ImageGenerationRequest, _ bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	modelName := req.Model
+	if o.modelNameOverride != "" {
+		// Use modelName override if set.
+		modelName = o.modelNameOverride
+	}
+	if err = validateBase64ImageResponseFormat(req); err != nil {
+		return nil, nil, err
+	}
+	aspectRatio, err := imageSizeToAspectRatio(req.Size)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	predictReq := gcp.PredictImageRequest{
+		Instances:  []gcp.PredictImageInstance{{Prompt: req.Prompt}},
+		Parameters: &gcp.PredictImageParameters{SampleCount: req.N, AspectRatio: aspectRatio},
+	}
+	if req.OutputFormat != "" {
+		predictReq.Parameters.OutputOptions = &gcp.PredictImageOutputOptions{MimeType: "image/" + req.OutputFormat}
+	}
+	body, err := json.Marshal(predictReq)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	pathSuffix := buildGCPModelPathSuffix(gcpModelPublisherGoogle, modelName, gcpMethodPredict)
+	headerMutation, bodyMutation = buildRequestMutations(pathSuffix, body)
+	return
+}
+
+// ResponseHeaders implements [OpenAIImageGenerationTranslator.ResponseHeaders].
+func (o *openAIToGCPVertexAITranslatorV1ImageGeneration) ResponseHeaders(map[string]string) (headerMutation *extprocv3.HeaderMutation, err error) {
+	return nil, nil
+}
+
+// ResponseBody implements [OpenAIImageGenerationTranslator.ResponseBody].
+func (o *openAIToGCPVertexAITranslatorV1ImageGeneration) ResponseBody(_ map[string]string, body io.Reader, _ bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	var resp gcp.PredictImageResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	images := make([]openai.ImageData, 0, len(resp.Predictions))
+	for _, prediction := range resp.Predictions {
+		images = append(images, openai.ImageData{B64JSON: prediction.BytesBase64Encoded, RevisedPrompt: prediction.Prompt})
+	}
+	return imageGenerationResponseMutations(images)
+}
+
+// ResponseError implements [OpenAIImageGenerationTranslator.ResponseError].
+func (o *openAIToGCPVertexAITranslatorV1ImageGeneration) ResponseError(respHeaders map[string]string, body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	return gcpVertexAIErrorToOpenAIError(respHeaders, body)
+}
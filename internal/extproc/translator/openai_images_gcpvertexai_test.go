@@ -0,0 +1,67 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestOpenAIToGCPVertexAITranslatorV1ImageGeneration_RequestBody(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		tr := NewImageGenerationOpenAIToGCPVertexAITranslator("")
+		hm, bm, err := tr.RequestBody(nil, &openai.ImageGenerationRequest{
+			Model: "imagen-3.0-generate-002", Prompt: "a cat", N: ptr.To(2), Size: "1024x1024", OutputFormat: "png",
+		}, false)
+		require.NoError(t, err)
+		require.Equal(t, "publishers/google/models/imagen-3.0-generate-002:predict", string(hm.SetHeaders[0].Header.RawValue))
+		require.JSONEq(t, `{"instances":[{"prompt":"a cat"}],
+"parameters":{"sampleCount":2,"aspectRatio":"1:1","outputOptions":{"mimeType":"image/png"}}}`, string(bm.GetBody()))
+	})
+	t.Run("override", func(t *testing.T) {
+		tr := NewImageGenerationOpenAIToGCPVertexAITranslator("imagen-4.0-generate-001")
+		hm, bm, err := tr.RequestBody(nil, &openai.ImageGenerationRequest{Model: "foo", Prompt: "a cat"}, false)
+		require.NoError(t, err)
+		require.Equal(t, "publishers/google/models/imagen-4.0-generate-001:predict", string(hm.SetHeaders[0].Header.RawValue))
+		require.JSONEq(t, `{"instances":[{"prompt":"a cat"}],"parameters":{}}`, string(bm.GetBody()))
+	})
+	t.Run("url response format", func(t *testing.T) {
+		tr := NewImageGenerationOpenAIToGCPVertexAITranslator("")
+		_, _, err := tr.RequestBody(nil, &openai.ImageGenerationRequest{ResponseFormat: openai.ImageResponseFormatURL}, false)
+		require.ErrorContains(t, err, `response_format "url" is not supported by the backend`)
+	})
+}
+
+func TestOpenAIToGCPVertexAITranslatorV1ImageGeneration_ResponseBody(t *testing.T) {
+	tr := NewImageGenerationOpenAIToGCPVertexAITranslator("")
+	const body = `{"predictions":[{"bytesBase64Encoded":"aaa","mimeType":"image/png","prompt":"a cute cat"},{"bytesBase64Encoded":"bbb","mimeType":"image/png"}]}`
+	_, bm, usage, err := tr.ResponseBody(nil, strings.NewReader(body), true)
+	require.NoError(t, err)
+	require.Equal(t, LLMTokenUsage{ImageCount: 2}, usage)
+	var resp openai.ImageGenerationResponse
+	require.NoError(t, json.Unmarshal(bm.GetBody(), &resp))
+	require.Equal(t, []openai.ImageData{{B64JSON: "aaa", RevisedPrompt: "a cute cat"}, {B64JSON: "bbb"}}, resp.Data)
+
+	_, _, _, err = tr.ResponseBody(nil, strings.NewReader("invalid"), true)
+	require.ErrorContains(t, err, "failed to unmarshal body")
+}
+
+func TestOpenAIToGCPVertexAITranslatorV1ImageGeneration_ResponseError(t *testing.T) {
+	tr := NewImageGenerationOpenAIToGCPVertexAITranslator("")
+	_, bm, err := tr.ResponseError(map[string]string{statusHeaderName: "429", contentTypeHeaderName: jsonContentType},
+		strings.NewReader(`{"error":{"code":429,"message":"quota exceeded","status":"RESOURCE_EXHAUSTED"}}`))
+	require.NoError(t, err)
+	var openAIErr openai.Error
+	require.NoError(t, json.Unmarshal(bm.GetBody(), &openAIErr))
+	require.Equal(t, "RESOURCE_EXHAUSTED", openAIErr.Error.Type)
+	require.Equal(t, "quota exceeded", openAIErr.Error.Message)
+}
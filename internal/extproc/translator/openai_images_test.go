@@ -0,0 +1,116 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestOpenAIToOpenAITranslatorV1ImageGeneration_RequestBody(t *testing.T) {
+	const raw = `{"model":"dall-e-3","prompt":"a cat"}`
+	for _, tc := range []struct {
+		name     string
+		override string
+		onRetry  bool
+		expBody  string
+	}{
+		{name: "passthrough"},
+		{name: "on retry", onRetry: true, expBody: raw},
+		{name: "override", override: "gpt-image-1", expBody: `{"model":"gpt-image-1","prompt":"a cat"}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := NewImageGenerationOpenAIToOpenAITranslator("v1", tc.override)
+			hm, bm, err := tr.RequestBody([]byte(raw), &openai.ImageGenerationRequest{Model: "dall-e-3"}, tc.onRetry)
+			require.NoError(t, err)
+			require.Equal(t, ":path", hm.SetHeaders[0].Header.Key)
+			require.Equal(t, "/v1/images/generations", string(hm.SetHeaders[0].Header.RawValue))
+			if tc.expBody == "" {
+				require.Nil(t, bm)
+				return
+			}
+			require.JSONEq(t, tc.expBody, string(bm.GetBody()))
+		})
+	}
+}
+
+func TestOpenAIToOpenAITranslatorV1ImageGeneration_ResponseBody(t *testing.T) {
+	tr := NewImageGenerationOpenAIToOpenAITranslator("v1", "")
+	t.Run("with usage", func(t *testing.T) {
+		const body = `{"created":1,"data":[{"b64_json":"a"},{"b64_json":"b"}],"usage":{"input_tokens":10,"output_tokens":20,"total_tokens":30}}`
+		hm, bm, usage, err := tr.ResponseBody(nil, strings.NewReader(body), true)
+		require.NoError(t, err)
+		require.Nil(t, hm)
+		require.Nil(t, bm)
+		require.Equal(t, LLMTokenUsage{InputTokens: 10, OutputTokens: 20, TotalTokens: 30, ImageCount: 2}, usage)
+	})
+	t.Run("without usage", func(t *testing.T) {
+		_, _, usage, err := tr.ResponseBody(nil, strings.NewReader(`{"created":1,"data":[{"url":"https://example.com"}]}`), true)
+		require.NoError(t, err)
+		require.Equal(t, LLMTokenUsage{ImageCount: 1}, usage)
+	})
+	t.Run("invalid body", func(t *testing.T) {
+		_, _, _, err := tr.ResponseBody(nil, strings.NewReader("invalid"), true)
+		require.ErrorContains(t, err, "failed to unmarshal body")
+	})
+}
+
+func TestOpenAIToOpenAITranslatorV1ImageGeneration_ResponseError(t *testing.T) {
+	tr := NewImageGenerationOpenAIToOpenAITranslator("v1", "")
+	hm, bm, err := tr.ResponseError(map[string]string{statusHeaderName: "503", contentTypeHeaderName: "text/plain"}, strings.NewReader("unavailable"))
+	require.NoError(t, err)
+	require.NotNil(t, hm)
+	var openAIErr openai.Error
+	require.NoError(t, json.Unmarshal(bm.GetBody(), &openAIErr))
+	require.Equal(t, openAIBackendError, openAIErr.Error.Type)
+	require.Equal(t, "unavailable", openAIErr.Error.Message)
+}
+
+func TestOpenAIToAzureOpenAITranslatorV1ImageGeneration_RequestBody(t *testing.T) {
+	const raw = `{"model":"dall-e-3","prompt":"a cat"}`
+	tr := NewImageGenerationOpenAIToAzureOpenAITranslator("2024-10-21", "my-deployment")
+	hm, bm, err := tr.RequestBody([]byte(raw), &openai.ImageGenerationRequest{Model: "dall-e-3"}, false)
+	require.NoError(t, err)
+	require.Nil(t, bm)
+	require.Equal(t, "/openai/deployments/my-deployment/images/generations?api-version=2024-10-21", string(hm.SetHeaders[0].Header.RawValue))
+
+	hm, bm, err = tr.RequestBody([]byte(raw), &openai.ImageGenerationRequest{Model: "dall-e-3"}, true)
+	require.NoError(t, err)
+	require.Equal(t, raw, string(bm.GetBody()))
+	require.Len(t, hm.SetHeaders, 2)
+}
+
+func Test_imageSizeToAspectRatio(t *testing.T) {
+	for _, tc := range []struct {
+		size   string
+		exp    string
+		expErr string
+	}{
+		{size: "", exp: ""},
+		{size: "auto", exp: ""},
+		{size: "1024x1024", exp: "1:1"},
+		{size: "1792x1024", exp: "7:4"},
+		{size: "1536x1024", exp: "3:2"},
+		{size: "1024", expErr: `invalid image size "1024"`},
+		{size: "axb", expErr: `invalid image size "axb"`},
+		{size: "0x0", expErr: `invalid image size "0x0"`},
+	} {
+		t.Run(tc.size, func(t *testing.T) {
+			got, err := imageSizeToAspectRatio(tc.size)
+			if tc.expErr != "" {
+				require.ErrorContains(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, got)
+		})
+	}
+}
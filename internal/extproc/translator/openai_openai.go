@@ -134,14 +134,23 @@ func (o *openAIToOpenAITranslatorV1ChatCompletion) ResponseBody(_ map[string]str
 	if err := json.NewDecoder(body).Decode(&resp); err != nil {
 		return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal body: %w", err)
 	}
-	tokenUsage = LLMTokenUsage{
-		InputTokens:  uint32(resp.Usage.PromptTokens),     //nolint:gosec
-		OutputTokens: uint32(resp.Usage.CompletionTokens), //nolint:gosec
-		TotalTokens:  uint32(resp.Usage.TotalTokens),      //nolint:gosec
-	}
+	tokenUsage = openAIUsageToLLMTokenUsage(&resp.Usage)
 	return
 }
 
+// openAIUsageToLLMTokenUsage converts the usage of the OpenAI chat completion response to LLMTokenUsage.
+func openAIUsageToLLMTokenUsage(usage *openai.ChatCompletionResponseUsage) LLMTokenUsage {
+	ret := LLMTokenUsage{
+		InputTokens:  uint32(usage.PromptTokens),     //nolint:gosec
+		OutputTokens: uint32(usage.CompletionTokens), //nolint:gosec
+		TotalTokens:  uint32(usage.TotalTokens),      //nolint:gosec
+	}
+	if d := usage.PromptTokensDetails; d != nil {
+		ret.CachedInputTokens = uint32(d.CachedTokens) //nolint:gosec
+	}
+	return ret
+}
+
 var dataPrefix = []byte("data: ")
 
 // extractUsageFromBufferEvent extracts the token usage from the buffered event.
@@ -162,11 +171,7 @@ func (o *openAIToOpenAITranslatorV1ChatCompletion) extractUsageFromBufferEvent()
 			continue
 		}
 		if usage := event.Usage; usage != nil {
-			tokenUsage = LLMTokenUsage{
-				InputTokens:  uint32(usage.PromptTokens),     //nolint:gosec
-				OutputTokens: uint32(usage.CompletionTokens), //nolint:gosec
-				TotalTokens:  uint32(usage.TotalTokens),      //nolint:gosec
-			}
+			tokenUsage = openAIUsageToLLMTokenUsage(usage)
 			o.bufferingDone = true
 			o.buffered = nil
 			return
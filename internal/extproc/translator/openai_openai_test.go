@@ -221,6 +221,13 @@ data: [DONE]
 			require.NoError(t, err)
 			require.Equal(t, LLMTokenUsage{TotalTokens: 42}, usedToken)
 		})
+		t.Run("cached tokens", func(t *testing.T) {
+			body := []byte(`{"usage":{"prompt_tokens":100,"completion_tokens":10,"total_tokens":110,"prompt_tokens_details":{"cached_tokens":80}}}`)
+			o := &openAIToOpenAITranslatorV1ChatCompletion{}
+			_, _, usedToken, err := o.ResponseBody(nil, bytes.NewBuffer(body), false)
+			require.NoError(t, err)
+			require.Equal(t, LLMTokenUsage{InputTokens: 100, CachedInputTokens: 80, OutputTokens: 10, TotalTokens: 110}, usedToken)
+		})
 	})
 }
 
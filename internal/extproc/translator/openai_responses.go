@@ -0,0 +1,186 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/tidwall/sjson"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// NewResponsesOpenAIToOpenAITranslator implements [Factory] for OpenAI to OpenAI translation for /v1/responses.
+func NewResponsesOpenAIToOpenAITranslator(apiVersion string, modelNameOverride string) OpenAIResponsesTranslator {
+	return &openAIToOpenAITranslatorV1Responses{modelNameOverride: modelNameOverride, path: path.Join("/", apiVersion, "responses")}
+}
+
+// NewResponsesOpenAIToAzureOpenAITranslator implements [Factory] for OpenAI to Azure OpenAI translation for /v1/responses.
+//
+// Unlike the chat completions API, the Azure OpenAI responses API takes the deployment name in the "model" field
+// of the request body, so only the path differs from the OpenAI to OpenAI translation.
+// https://learn.microsoft.com/en-us/azure/ai-foundry/openai/how-to/responses
+func NewResponsesOpenAIToAzureOpenAITranslator(apiVersion string, modelNameOverride string) OpenAIResponsesTranslator {
+	return &openAIToOpenAITranslatorV1Responses{
+		modelNameOverride: modelNameOverride,
+		path:              fmt.Sprintf("/openai/responses?api-version=%s", apiVersion),
+	}
+}
+
+// openAIToOpenAITranslatorV1Responses implements [OpenAIResponsesTranslator] for /responses.
+type openAIToOpenAITranslatorV1Responses struct {
+	modelNameOverride string
+	stream            bool
+	buffered          []byte
+	bufferingDone     bool
+	// The path of the responses endpoint to be used for the request.
+	path string
+}
+
+// RequestBody implements [OpenAIResponsesTranslator.RequestBody].
+func (o *openAIToOpenAITranslatorV1Responses) RequestBody(original []byte, req *openai.ResponseRequest, forceBodyMutation bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	o.stream = req.Stream
+	var newBody []byte
+	if o.modelNameOverride != "" {
+		// If modelName is set we override the model to be used for the request.
+		newBody, err = sjson.SetBytesOptions(original, "model", o.modelNameOverride, SJSONOptions)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to set model name: %w", err)
+		}
+	}
+
+	// Always set the path header to the responses endpoint so that the request is routed correctly.
+	headerMutation = &extprocv3.HeaderMutation{
+		SetHeaders: []*corev3.HeaderValueOption{
+			{Header: &corev3.HeaderValue{
+				Key:      ":path",
+				RawValue: []byte(o.path),
+			}},
+		},
+	}
+
+	if forceBodyMutation && len(newBody) == 0 {
+		newBody = original
+	}
+
+	if len(newBody) > 0 {
+		bodyMutation = &extprocv3.BodyMutation{
+			Mutation: &extprocv3.BodyMutation_Body{Body: newBody},
+		}
+		headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{Header: &corev3.HeaderValue{
+			Key:      "content-length",
+			RawValue: []byte(strconv.Itoa(len(newBody))),
+		}})
+	}
+	return
+}
+
+// ResponseHeaders implements [OpenAIResponsesTranslator.ResponseHeaders].
+func (o *openAIToOpenAITranslatorV1Responses) ResponseHeaders(map[string]string) (headerMutation *extprocv3.HeaderMutation, err error) {
+	return nil, nil
+}
+
+// ResponseBody implements [OpenAIResponsesTranslator.ResponseBody].
+func (o *openAIToOpenAITranslatorV1Responses) ResponseBody(_ map[string]string, body io.Reader, _ bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	if o.stream {
+		if !o.bufferingDone {
+			buf, err := io.ReadAll(body)
+			if err != nil {
+				return nil, nil, tokenUsage, fmt.Errorf("failed to read body: %w", err)
+			}
+			o.buffered = append(o.buffered, buf...)
+			tokenUsage = o.extractUsageFromBufferEvent()
+		}
+		return
+	}
+	var resp openai.Response
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	if resp.Usage != nil {
+		tokenUsage = responseUsageToLLMTokenUsage(resp.Usage)
+	}
+	return
+}
+
+// extractUsageFromBufferEvent extracts the token usage from the buffered event.
+// The usage is only reported in the terminal event, such as response.completed, that carries the whole response object.
+// Once the usage is extracted, it returns the number of tokens used, and bufferingDone is set to true.
+func (o *openAIToOpenAITranslatorV1Responses) extractUsageFromBufferEvent() (tokenUsage LLMTokenUsage) {
+	for {
+		i := bytes.IndexByte(o.buffered, '\n')
+		if i == -1 {
+			return
+		}
+		line := o.buffered[:i]
+		o.buffered = o.buffered[i+1:]
+		if !bytes.HasPrefix(line, dataPrefix) {
+			continue
+		}
+		var event openai.ResponseStreamEvent
+		if err := json.Unmarshal(bytes.TrimPrefix(line, dataPrefix), &event); err != nil {
+			continue
+		}
+		if event.Response != nil && event.Response.Usage != nil {
+			tokenUsage = responseUsageToLLMTokenUsage(event.Response.Usage)
+			o.bufferingDone = true
+			o.buffered = nil
+			return
+		}
+	}
+}
+
+// ResponseError implements [OpenAIResponsesTranslator.ResponseError]
+// For OpenAI based backend we return the OpenAI error type as is.
+// If connection fails the error body is translated to OpenAI error type for events such as HTTP 503 or 504.
+func (o *openAIToOpenAITranslatorV1Responses) ResponseError(respHeaders map[string]string, body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	statusCode := respHeaders[statusHeaderName]
+	if v, ok := respHeaders[contentTypeHeaderName]; ok && v != jsonContentType {
+		var openaiError openai.Error
+		buf, err := io.ReadAll(body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read error body: %w", err)
+		}
+		openaiError = openai.Error{
+			Type: "error",
+			Error: openai.ErrorType{
+				Type:    openAIBackendError,
+				Message: string(buf),
+				Code:    &statusCode,
+			},
+		}
+		mut := &extprocv3.BodyMutation_Body{}
+		mut.Body, err = json.Marshal(openaiError)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal error body: %w", err)
+		}
+		headerMutation = &extprocv3.HeaderMutation{}
+		setContentLength(headerMutation, mut.Body)
+		return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, nil
+	}
+	return nil, nil, nil
+}
+
+func responseUsageToLLMTokenUsage(usage *openai.ResponseUsage) LLMTokenUsage {
+	return LLMTokenUsage{
+		InputTokens:  uint32(usage.InputTokens),  //nolint:gosec
+		OutputTokens: uint32(usage.OutputTokens), //nolint:gosec
+		TotalTokens:  uint32(usage.TotalTokens),  //nolint:gosec
+	}
+}
@@ -0,0 +1,663 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/google/uuid"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// NewResponsesOpenAIToAWSBedrockTranslator implements [Factory] for OpenAI Responses to AWS Bedrock Converse translation.
+func NewResponsesOpenAIToAWSBedrockTranslator(modelNameOverride string) OpenAIResponsesTranslator {
+	return newResponsesToChatCompletionTranslator(NewChatCompletionOpenAIToAWSBedrockTranslator(modelNameOverride), modelNameOverride)
+}
+
+// NewResponsesOpenAIToGCPVertexAITranslator implements [Factory] for OpenAI Responses to GCP Vertex AI translation.
+func NewResponsesOpenAIToGCPVertexAITranslator(modelNameOverride string) OpenAIResponsesTranslator {
+	return newResponsesToChatCompletionTranslator(NewChatCompletionOpenAIToGCPVertexAITranslator(modelNameOverride), modelNameOverride)
+}
+
+// NewResponsesOpenAIToGCPAnthropicTranslator implements [Factory] for OpenAI Responses to GCP Anthropic translation.
+func NewResponsesOpenAIToGCPAnthropicTranslator(apiVersion string, modelNameOverride string) OpenAIResponsesTranslator {
+	return newResponsesToChatCompletionTranslator(NewChatCompletionOpenAIToGCPAnthropicTranslator(apiVersion, modelNameOverride), modelNameOverride)
+}
+
+// NewResponsesOpenAIToAnthropicTranslator implements [Factory] for OpenAI Responses to Anthropic translation.
+func NewResponsesOpenAIToAnthropicTranslator(apiVersion string, modelNameOverride string) OpenAIResponsesTranslator {
+	return newResponsesToChatCompletionTranslator(NewChatCompletionOpenAIToAnthropicTranslator(apiVersion, modelNameOverride), modelNameOverride)
+}
+
+func newResponsesToChatCompletionTranslator(chat OpenAIChatCompletionTranslator, modelNameOverride string) *responsesToChatCompletionTranslator {
+	return &responsesToChatCompletionTranslator{
+		chat:              chat,
+		modelNameOverride: modelNameOverride,
+		id:                "resp_" + strings.ReplaceAll(uuid.NewString(), "-", ""),
+		createdAt:         openai.JSONUNIXTime(time.Now()),
+	}
+}
+
+// responsesToChatCompletionTranslator implements [OpenAIResponsesTranslator] for the backends that do not natively
+// support the Responses API.
+//
+// The request is first converted into the [openai.ChatCompletionRequest], and then translated by the existing
+// [OpenAIChatCompletionTranslator] of the backend. The response is translated back by the same translator into
+// the OpenAI chat completion format, which is then converted into the [openai.Response] or the sequence of
+// [openai.ResponseStreamEvent]. This way, the provider specific logic is kept in a single place.
+type responsesToChatCompletionTranslator struct {
+	chat              OpenAIChatCompletionTranslator
+	modelNameOverride string
+	// id and createdAt are used to build the response object since the backends do not have the equivalent.
+	id        string
+	createdAt openai.JSONUNIXTime
+	model     string
+	stream    bool
+	// The following fields are used only for streaming responses.
+	//
+	// buffered is the partial SSE line produced by the chat completion translator that is not yet processed.
+	buffered []byte
+	// sequenceNumber is the sequence number of the next event.
+	sequenceNumber int
+	// started is true once the response.created event is sent.
+	started bool
+	// completed is true once the terminal event is sent.
+	completed bool
+	// output is the output items accumulated so far.
+	output []openai.ResponseOutputItem
+	// openMessageIndex is the index of the message item in output that is being streamed, or -1 if none.
+	openMessageIndex int
+	// openFunctionCallIndex is the index of the function_call item in output that is being streamed, or -1 if none.
+	openFunctionCallIndex int
+	// toolCallIndexes maps the chat completion tool call index to the index of the item in output.
+	toolCallIndexes map[int]int
+	finishReason    openai.ChatCompletionChoicesFinishReason
+	usage           LLMTokenUsage
+}
+
+// RequestBody implements [OpenAIResponsesTranslator.RequestBody].
+func (r *responsesToChatCompletionTranslator) RequestBody(_ []byte, req *openai.ResponseRequest, _ bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	chatReq, err := responseRequestToChatCompletionRequest(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to convert responses request to chat completion request: %w", err)
+	}
+	r.stream = req.Stream
+	r.model = req.Model
+	if r.modelNameOverride != "" {
+		r.model = r.modelNameOverride
+	}
+	r.openMessageIndex, r.openFunctionCallIndex = -1, -1
+	r.toolCallIndexes = make(map[int]int)
+	raw, err := json.Marshal(chatReq)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal chat completion request: %w", err)
+	}
+	// The body is always mutated since the schema of the body is different.
+	return r.chat.RequestBody(raw, chatReq, true)
+}
+
+// ResponseHeaders implements [OpenAIResponsesTranslator.ResponseHeaders].
+func (r *responsesToChatCompletionTranslator) ResponseHeaders(headers map[string]string) (*extprocv3.HeaderMutation, error) {
+	return r.chat.ResponseHeaders(headers)
+}
+
+// ResponseError implements [OpenAIResponsesTranslator.ResponseError].
+//
+// The chat completion translator already converts the backend error into the OpenAI error format, which is
+// shared by the Responses API.
+func (r *responsesToChatCompletionTranslator) ResponseError(respHeaders map[string]string, body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	return r.chat.ResponseError(respHeaders, body)
+}
+
+// ResponseBody implements [OpenAIResponsesTranslator.ResponseBody].
+func (r *responsesToChatCompletionTranslator) ResponseBody(respHeaders map[string]string, body io.Reader, endOfStream bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	_, chatBodyMutation, tokenUsage, err := r.chat.ResponseBody(respHeaders, body, endOfStream)
+	if err != nil {
+		return nil, nil, tokenUsage, err
+	}
+	chatBody := chatBodyMutation.GetBody()
+	mut := &extprocv3.BodyMutation_Body{}
+	if r.stream {
+		r.usage.InputTokens += tokenUsage.InputTokens
+		r.usage.OutputTokens += tokenUsage.OutputTokens
+		r.usage.TotalTokens += tokenUsage.TotalTokens
+		mut.Body = r.convertChatCompletionStream(chatBody, endOfStream)
+		return nil, &extprocv3.BodyMutation{Mutation: mut}, tokenUsage, nil
+	}
+
+	var chatResp openai.ChatCompletionResponse
+	if err = json.Unmarshal(chatBody, &chatResp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal chat completion response: %w", err)
+	}
+	resp := r.chatCompletionResponseToResponse(&chatResp)
+	mut.Body, err = json.Marshal(resp)
+	if err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	headerMutation = &extprocv3.HeaderMutation{}
+	setContentLength(headerMutation, mut.Body)
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, tokenUsage, nil
+}
+
+// chatCompletionResponseToResponse converts the [openai.ChatCompletionResponse] into the [openai.Response].
+func (r *responsesToChatCompletionTranslator) chatCompletionResponseToResponse(chatResp *openai.ChatCompletionResponse) *openai.Response {
+	resp := r.newResponse(openai.ResponseStatusCompleted)
+	resp.Output = []openai.ResponseOutputItem{}
+	if len(chatResp.Choices) > 0 {
+		choice := &chatResp.Choices[0]
+		if content := choice.Message.Content; content != nil && *content != "" {
+			resp.Output = append(resp.Output, openai.ResponseOutputItem{
+				Type:    openai.ResponseItemTypeMessage,
+				ID:      fmt.Sprintf("msg_%d", len(resp.Output)),
+				Status:  openai.ResponseStatusCompleted,
+				Role:    openai.ChatMessageRoleAssistant,
+				Content: []openai.ResponseContentPart{{Type: openai.ResponseContentTypeOutputText, Text: *content, Annotations: []any{}}},
+			})
+		}
+		for i := range choice.Message.ToolCalls {
+			tc := &choice.Message.ToolCalls[i]
+			var callID string
+			if tc.ID != nil {
+				callID = *tc.ID
+			}
+			resp.Output = append(resp.Output, openai.ResponseOutputItem{
+				Type:      openai.ResponseItemTypeFunctionCall,
+				ID:        fmt.Sprintf("fc_%d", len(resp.Output)),
+				Status:    openai.ResponseStatusCompleted,
+				CallID:    callID,
+				Name:      tc.Function.Name,
+				Arguments: tc.Function.Arguments,
+			})
+		}
+		setResponseStatusFromFinishReason(resp, choice.FinishReason)
+	}
+	u := chatResp.Usage
+	resp.Usage = &openai.ResponseUsage{
+		InputTokens:  u.PromptTokens,
+		OutputTokens: u.CompletionTokens,
+		TotalTokens:  u.TotalTokens,
+	}
+	if d := u.PromptTokensDetails; d != nil {
+		resp.Usage.InputTokensDetails.CachedTokens = d.CachedTokens
+	}
+	if d := u.CompletionTokensDetails; d != nil {
+		resp.Usage.OutputTokensDetails.ReasoningTokens = d.ReasoningTokens
+	}
+	return resp
+}
+
+func (r *responsesToChatCompletionTranslator) newResponse(status string) *openai.Response {
+	return &openai.Response{
+		ID:        r.id,
+		Object:    "response",
+		CreatedAt: r.createdAt,
+		Status:    status,
+		Model:     r.model,
+		Output:    []openai.ResponseOutputItem{},
+	}
+}
+
+// setResponseStatusFromFinishReason sets the status of the response based on the chat completion finish reason.
+func setResponseStatusFromFinishReason(resp *openai.Response, reason openai.ChatCompletionChoicesFinishReason) {
+	switch reason {
+	case openai.ChatCompletionChoicesFinishReasonLength:
+		resp.Status = openai.ResponseStatusIncomplete
+		resp.IncompleteDetails = &openai.ResponseIncompleteDetails{Reason: "max_output_tokens"}
+	case openai.ChatCompletionChoicesFinishReasonContentFilter:
+		resp.Status = openai.ResponseStatusIncomplete
+		resp.IncompleteDetails = &openai.ResponseIncompleteDetails{Reason: "content_filter"}
+	default:
+		resp.Status = openai.ResponseStatusCompleted
+	}
+}
+
+// convertChatCompletionStream converts the chat completion SSE chunks into the Responses API SSE events.
+func (r *responsesToChatCompletionTranslator) convertChatCompletionStream(chatBody []byte, endOfStream bool) []byte {
+	var out []byte
+	if !r.started {
+		r.started = true
+		resp := r.newResponse(openai.ResponseStatusInProgress)
+		out = r.appendEvent(out, &openai.ResponseStreamEvent{Type: openai.ResponseStreamEventTypeCreated, Response: resp})
+		out = r.appendEvent(out, &openai.ResponseStreamEvent{Type: openai.ResponseStreamEventTypeInProgress, Response: resp})
+	}
+
+	r.buffered = append(r.buffered, chatBody...)
+	for {
+		i := bytes.IndexByte(r.buffered, '\n')
+		if i == -1 {
+			break
+		}
+		line := bytes.TrimSpace(r.buffered[:i])
+		r.buffered = r.buffered[i+1:]
+		if !bytes.HasPrefix(line, dataPrefix) {
+			continue
+		}
+		data := bytes.TrimPrefix(line, dataPrefix)
+		if bytes.Equal(data, sseDoneMessage) {
+			out = r.complete(out)
+			continue
+		}
+		var chunk openai.ChatCompletionResponseChunk
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			continue
+		}
+		out = r.handleChunk(out, &chunk)
+	}
+	if endOfStream {
+		out = r.complete(out)
+	}
+	return out
+}
+
+// handleChunk converts a single chat completion chunk into the Responses API events.
+func (r *responsesToChatCompletionTranslator) handleChunk(out []byte, chunk *openai.ChatCompletionResponseChunk) []byte {
+	if r.completed {
+		return out
+	}
+	for i := range chunk.Choices {
+		choice := &chunk.Choices[i]
+		if choice.FinishReason != "" {
+			r.finishReason = choice.FinishReason
+		}
+		delta := choice.Delta
+		if delta == nil {
+			continue
+		}
+		if delta.Content != nil && *delta.Content != "" {
+			out = r.closeFunctionCall(out)
+			if r.openMessageIndex == -1 {
+				out = r.openMessage(out)
+			}
+			item := &r.output[r.openMessageIndex]
+			item.Content[0].Text += *delta.Content
+			out = r.appendEvent(out, &openai.ResponseStreamEvent{
+				Type:         openai.ResponseStreamEventTypeOutputTextDelta,
+				ItemID:       item.ID,
+				OutputIndex:  &r.openMessageIndex,
+				ContentIndex: ptrInt(0),
+				Delta:        *delta.Content,
+			})
+		}
+		for j := range delta.ToolCalls {
+			out = r.handleToolCallDelta(out, &delta.ToolCalls[j])
+		}
+	}
+	return out
+}
+
+func (r *responsesToChatCompletionTranslator) handleToolCallDelta(out []byte, tc *openai.ChatCompletionMessageToolCallParam) []byte {
+	if tc.ID != nil && *tc.ID != "" {
+		out = r.closeMessage(out)
+		out = r.closeFunctionCall(out)
+		r.openFunctionCallIndex = len(r.output)
+		if tc.Index != nil {
+			r.toolCallIndexes[*tc.Index] = r.openFunctionCallIndex
+		}
+		r.output = append(r.output, openai.ResponseOutputItem{
+			Type:   openai.ResponseItemTypeFunctionCall,
+			ID:     fmt.Sprintf("fc_%d", r.openFunctionCallIndex),
+			Status: openai.ResponseStatusInProgress,
+			CallID: *tc.ID,
+			Name:   tc.Function.Name,
+		})
+		item := r.output[r.openFunctionCallIndex]
+		out = r.appendEvent(out, &openai.ResponseStreamEvent{
+			Type:        openai.ResponseStreamEventTypeOutputItemAdded,
+			OutputIndex: ptrInt(r.openFunctionCallIndex),
+			Item:        &item,
+		})
+	} else if tc.Index != nil {
+		if idx, ok := r.toolCallIndexes[*tc.Index]; ok {
+			r.openFunctionCallIndex = idx
+		}
+	}
+	if r.openFunctionCallIndex == -1 || tc.Function.Arguments == "" {
+		return out
+	}
+	item := &r.output[r.openFunctionCallIndex]
+	item.Arguments += tc.Function.Arguments
+	return r.appendEvent(out, &openai.ResponseStreamEvent{
+		Type:        openai.ResponseStreamEventTypeFunctionCallArgsDelta,
+		ItemID:      item.ID,
+		OutputIndex: ptrInt(r.openFunctionCallIndex),
+		Delta:       tc.Function.Arguments,
+	})
+}
+
+func (r *responsesToChatCompletionTranslator) openMessage(out []byte) []byte {
+	r.openMessageIndex = len(r.output)
+	r.output = append(r.output, openai.ResponseOutputItem{
+		Type:    openai.ResponseItemTypeMessage,
+		ID:      fmt.Sprintf("msg_%d", r.openMessageIndex),
+		Status:  openai.ResponseStatusInProgress,
+		Role:    openai.ChatMessageRoleAssistant,
+		Content: []openai.ResponseContentPart{{Type: openai.ResponseContentTypeOutputText, Annotations: []any{}}},
+	})
+	item := r.output[r.openMessageIndex]
+	item.Content = []openai.ResponseContentPart{}
+	out = r.appendEvent(out, &openai.ResponseStreamEvent{
+		Type:        openai.ResponseStreamEventTypeOutputItemAdded,
+		OutputIndex: ptrInt(r.openMessageIndex),
+		Item:        &item,
+	})
+	return r.appendEvent(out, &openai.ResponseStreamEvent{
+		Type:         openai.ResponseStreamEventTypeContentPartAdded,
+		ItemID:       item.ID,
+		OutputIndex:  ptrInt(r.openMessageIndex),
+		ContentIndex: ptrInt(0),
+		Part:         &openai.ResponseContentPart{Type: openai.ResponseContentTypeOutputText, Annotations: []any{}},
+	})
+}
+
+func (r *responsesToChatCompletionTranslator) closeMessage(out []byte) []byte {
+	if r.openMessageIndex == -1 {
+		return out
+	}
+	idx := r.openMessageIndex
+	r.openMessageIndex = -1
+	item := &r.output[idx]
+	item.Status = openai.ResponseStatusCompleted
+	part := item.Content[0]
+	out = r.appendEvent(out, &openai.ResponseStreamEvent{
+		Type:         openai.ResponseStreamEventTypeOutputTextDone,
+		ItemID:       item.ID,
+		OutputIndex:  ptrInt(idx),
+		ContentIndex: ptrInt(0),
+		Text:         part.Text,
+	})
+	out = r.appendEvent(out, &openai.ResponseStreamEvent{
+		Type:         openai.ResponseStreamEventTypeContentPartDone,
+		ItemID:       item.ID,
+		OutputIndex:  ptrInt(idx),
+		ContentIndex: ptrInt(0),
+		Part:         &part,
+	})
+	done := *item
+	return r.appendEvent(out, &openai.ResponseStreamEvent{
+		Type:        openai.ResponseStreamEventTypeOutputItemDone,
+		OutputIndex: ptrInt(idx),
+		Item:        &done,
+	})
+}
+
+func (r *responsesToChatCompletionTranslator) closeFunctionCall(out []byte) []byte {
+	if r.openFunctionCallIndex == -1 {
+		return out
+	}
+	idx := r.openFunctionCallIndex
+	r.openFunctionCallIndex = -1
+	item := &r.output[idx]
+	if item.Status == openai.ResponseStatusCompleted {
+		return out
+	}
+	item.Status = openai.ResponseStatusCompleted
+	out = r.appendEvent(out, &openai.ResponseStreamEvent{
+		Type:        openai.ResponseStreamEventTypeFunctionCallArgsDone,
+		ItemID:      item.ID,
+		OutputIndex: ptrInt(idx),
+		Arguments:   item.Arguments,
+	})
+	done := *item
+	return r.appendEvent(out, &openai.ResponseStreamEvent{
+		Type:        openai.ResponseStreamEventTypeOutputItemDone,
+		OutputIndex: ptrInt(idx),
+		Item:        &done,
+	})
+}
+
+// complete closes all the open items and sends the terminal event with the whole response including the usage.
+func (r *responsesToChatCompletionTranslator) complete(out []byte) []byte {
+	if r.completed {
+		return out
+	}
+	r.completed = true
+	out = r.closeMessage(out)
+	for idx := range r.output {
+		r.openFunctionCallIndex = idx
+		if r.output[idx].Type == openai.ResponseItemTypeFunctionCall {
+			out = r.closeFunctionCall(out)
+		}
+	}
+	r.openFunctionCallIndex = -1
+	resp := r.newResponse(openai.ResponseStatusCompleted)
+	resp.Output = append(resp.Output, r.output...)
+	setResponseStatusFromFinishReason(resp, r.finishReason)
+	resp.Usage = &openai.ResponseUsage{
+		InputTokens:  int(r.usage.InputTokens),
+		OutputTokens: int(r.usage.OutputTokens),
+		TotalTokens:  int(r.usage.TotalTokens),
+	}
+	eventType := openai.ResponseStreamEventTypeCompleted
+	if resp.Status == openai.ResponseStatusIncomplete {
+		eventType = openai.ResponseStreamEventTypeIncomplete
+	}
+	return r.appendEvent(out, &openai.ResponseStreamEvent{Type: eventType, Response: resp})
+}
+
+// appendEvent appends the SSE encoded event to the buffer.
+func (r *responsesToChatCompletionTranslator) appendEvent(out []byte, event *openai.ResponseStreamEvent) []byte {
+	event.SequenceNumber = r.sequenceNumber
+	r.sequenceNumber++
+	data, err := json.Marshal(event)
+	if err != nil {
+		panic(fmt.Errorf("failed to marshal event: %w", err))
+	}
+	out = append(out, "event: "...)
+	out = append(out, event.Type...)
+	out = append(out, '\n')
+	out = append(out, dataPrefix...)
+	out = append(out, data...)
+	return append(out, '\n', '\n')
+}
+
+func ptrInt(i int) *int { return &i }
+
+// responseRequestToChatCompletionRequest converts the [openai.ResponseRequest] into the [openai.ChatCompletionRequest].
+func responseRequestToChatCompletionRequest(req *openai.ResponseRequest) (*openai.ChatCompletionRequest, error) {
+	if req.PreviousResponseID != "" {
+		return nil, fmt.Errorf("previous_response_id is not supported")
+	}
+	chatReq := &openai.ChatCompletionRequest{
+		Model:               req.Model,
+		MaxCompletionTokens: req.MaxOutputTokens,
+		Temperature:         req.Temperature,
+		TopP:                req.TopP,
+		Stream:              req.Stream,
+		ParallelToolCalls:   req.ParallelToolCalls,
+		Reasoning:           req.Reasoning,
+		User:                req.User,
+	}
+	if req.Stream {
+		// Token usage is only reported in the last chunk when include_usage is set.
+		chatReq.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	}
+	if req.Instructions != "" {
+		chatReq.Messages = append(chatReq.Messages, openai.ChatCompletionMessageParamUnion{
+			Type: openai.ChatMessageRoleSystem,
+			Value: openai.ChatCompletionSystemMessageParam{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: openai.StringOrArray{Value: req.Instructions},
+			},
+		})
+	}
+	if req.Input.Text != nil {
+		chatReq.Messages = append(chatReq.Messages, openai.ChatCompletionMessageParamUnion{
+			Type: openai.ChatMessageRoleUser,
+			Value: openai.ChatCompletionUserMessageParam{
+				Role:    openai.ChatMessageRoleUser,
+				Content: openai.StringOrUserRoleContentUnion{Value: *req.Input.Text},
+			},
+		})
+	}
+	for i := range req.Input.Items {
+		var err error
+		chatReq.Messages, err = appendResponseInputItemAsMessage(chatReq.Messages, &req.Input.Items[i])
+		if err != nil {
+			return nil, fmt.Errorf("input[%d]: %w", i, err)
+		}
+	}
+
+	for i := range req.Tools {
+		tool := &req.Tools[i]
+		if tool.Type != string(openai.ToolTypeFunction) {
+			return nil, fmt.Errorf("unsupported tool type: %s", tool.Type)
+		}
+		fd := &openai.FunctionDefinition{Name: tool.Name, Description: tool.Description, Parameters: tool.Parameters}
+		if tool.Strict != nil {
+			fd.Strict = *tool.Strict
+		}
+		chatReq.Tools = append(chatReq.Tools, openai.Tool{Type: openai.ToolTypeFunction, Function: fd})
+	}
+
+	switch tc := req.ToolChoice.(type) {
+	case nil:
+	case string:
+		chatReq.ToolChoice = tc
+	case map[string]any:
+		name, _ := tc["name"].(string)
+		if tc["type"] != string(openai.ToolTypeFunction) || name == "" {
+			return nil, fmt.Errorf("unsupported tool_choice: %v", tc)
+		}
+		chatReq.ToolChoice = openai.ToolChoice{Type: openai.ToolTypeFunction, Function: openai.ToolFunction{Name: name}}
+	default:
+		return nil, fmt.Errorf("unsupported tool_choice type: %T", req.ToolChoice)
+	}
+
+	if req.Text != nil && req.Text.Format != nil {
+		switch f := req.Text.Format; f.Type {
+		case "text":
+		case string(openai.ChatCompletionResponseFormatTypeJSONObject):
+			chatReq.ResponseFormat = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+		case string(openai.ChatCompletionResponseFormatTypeJSONSchema):
+			schema := &openai.ChatCompletionResponseFormatJSONSchema{Name: f.Name, Description: f.Description, Schema: f.Schema}
+			if f.Strict != nil {
+				schema.Strict = *f.Strict
+			}
+			chatReq.ResponseFormat = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONSchema, JSONSchema: schema}
+		default:
+			return nil, fmt.Errorf("unsupported text format type: %s", f.Type)
+		}
+	}
+	return chatReq, nil
+}
+
+// appendResponseInputItemAsMessage converts the [openai.ResponseInputItem] into the chat completion message and appends it.
+// Consecutive function calls are merged into a single assistant message with multiple tool calls.
+func appendResponseInputItemAsMessage(msgs []openai.ChatCompletionMessageParamUnion, item *openai.ResponseInputItem) ([]openai.ChatCompletionMessageParamUnion, error) {
+	switch item.Type {
+	case "", openai.ResponseItemTypeMessage:
+		return appendResponseInputMessage(msgs, item)
+	case openai.ResponseItemTypeFunctionCall:
+		callID := item.CallID
+		toolCall := openai.ChatCompletionMessageToolCallParam{
+			ID:       &callID,
+			Type:     openai.ChatCompletionMessageToolCallTypeFunction,
+			Function: openai.ChatCompletionMessageToolCallFunctionParam{Name: item.Name, Arguments: item.Arguments},
+		}
+		if n := len(msgs); n > 0 {
+			if last, ok := msgs[n-1].Value.(openai.ChatCompletionAssistantMessageParam); ok {
+				last.ToolCalls = append(last.ToolCalls, toolCall)
+				msgs[n-1].Value = last
+				return msgs, nil
+			}
+		}
+		return append(msgs, openai.ChatCompletionMessageParamUnion{
+			Type: openai.ChatMessageRoleAssistant,
+			Value: openai.ChatCompletionAssistantMessageParam{
+				Role:      openai.ChatMessageRoleAssistant,
+				ToolCalls: []openai.ChatCompletionMessageToolCallParam{toolCall},
+			},
+		}), nil
+	case openai.ResponseItemTypeFunctionCallOutput:
+		return append(msgs, openai.ChatCompletionMessageParamUnion{
+			Type: openai.ChatMessageRoleTool,
+			Value: openai.ChatCompletionToolMessageParam{
+				Role:       openai.ChatMessageRoleTool,
+				ToolCallID: item.CallID,
+				Content:    openai.StringOrArray{Value: item.Output},
+			},
+		}), nil
+	default:
+		return nil, fmt.Errorf("unsupported input item type: %s", item.Type)
+	}
+}
+
+func appendResponseInputMessage(msgs []openai.ChatCompletionMessageParamUnion, item *openai.ResponseInputItem) ([]openai.ChatCompletionMessageParamUnion, error) {
+	var text string
+	var userParts []openai.ChatCompletionContentPartUserUnionParam
+	if c := item.Content; c != nil {
+		if c.Text != nil {
+			text = *c.Text
+		}
+		for i := range c.Parts {
+			part := &c.Parts[i]
+			switch part.Type {
+			case openai.ResponseContentTypeInputText, openai.ResponseContentTypeOutputText:
+				text += part.Text
+				userParts = append(userParts, openai.ChatCompletionContentPartUserUnionParam{
+					TextContent: &openai.ChatCompletionContentPartTextParam{Type: string(openai.ChatCompletionContentPartTextTypeText), Text: part.Text},
+				})
+			case openai.ResponseContentTypeInputImage:
+				if item.Role != openai.ChatMessageRoleUser {
+					return nil, fmt.Errorf("input_image is only supported for user messages")
+				}
+				userParts = append(userParts, openai.ChatCompletionContentPartUserUnionParam{
+					ImageContent: &openai.ChatCompletionContentPartImageParam{
+						Type: openai.ChatCompletionContentPartImageTypeImageURL,
+						ImageURL: openai.ChatCompletionContentPartImageImageURLParam{
+							URL:    part.ImageURL,
+							Detail: openai.ChatCompletionContentPartImageImageURLDetail(part.Detail),
+						},
+					},
+				})
+			case openai.ResponseContentTypeRefusal:
+				text += part.Refusal
+			default:
+				return nil, fmt.Errorf("unsupported content type: %s", part.Type)
+			}
+		}
+	}
+
+	var msg openai.ChatCompletionMessageParamUnion
+	switch item.Role {
+	case openai.ChatMessageRoleUser:
+		content := openai.StringOrUserRoleContentUnion{Value: text}
+		if item.Content != nil && item.Content.Text == nil {
+			content.Value = userParts
+		}
+		msg = openai.ChatCompletionMessageParamUnion{Type: openai.ChatMessageRoleUser, Value: openai.ChatCompletionUserMessageParam{
+			Role: openai.ChatMessageRoleUser, Content: content,
+		}}
+	case openai.ChatMessageRoleAssistant:
+		msg = openai.ChatCompletionMessageParamUnion{Type: openai.ChatMessageRoleAssistant, Value: openai.ChatCompletionAssistantMessageParam{
+			Role: openai.ChatMessageRoleAssistant, Content: openai.StringOrAssistantRoleContentUnion{Value: text},
+		}}
+	case openai.ChatMessageRoleSystem:
+		msg = openai.ChatCompletionMessageParamUnion{Type: openai.ChatMessageRoleSystem, Value: openai.ChatCompletionSystemMessageParam{
+			Role: openai.ChatMessageRoleSystem, Content: openai.StringOrArray{Value: text},
+		}}
+	case openai.ChatMessageRoleDeveloper:
+		msg = openai.ChatCompletionMessageParamUnion{Type: openai.ChatMessageRoleDeveloper, Value: openai.ChatCompletionDeveloperMessageParam{
+			Role: openai.ChatMessageRoleDeveloper, Content: openai.StringOrArray{Value: text},
+		}}
+	default:
+		return nil, fmt.Errorf("unsupported message role: %s", item.Role)
+	}
+	return append(msgs, msg), nil
+}
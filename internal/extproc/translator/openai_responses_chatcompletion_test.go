@@ -0,0 +1,234 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestResponseRequestToChatCompletionRequest(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		const in = `{
+"model":"gpt-4.1","instructions":"be nice","max_output_tokens":100,"temperature":0.5,"stream":true,
+"input":[
+  {"role":"user","content":"what is the weather?"},
+  {"type":"function_call","call_id":"call_1","name":"get_weather","arguments":"{\"city\":\"SF\"}"},
+  {"type":"function_call","call_id":"call_2","name":"get_weather","arguments":"{\"city\":\"NY\"}"},
+  {"type":"function_call_output","call_id":"call_1","output":"sunny"},
+  {"role":"assistant","content":[{"type":"output_text","text":"It is sunny."}]},
+  {"role":"user","content":[{"type":"input_text","text":"and this?"},{"type":"input_image","image_url":"https://example.com/a.png","detail":"low"}]}
+],
+"tools":[{"type":"function","name":"get_weather","description":"weather","parameters":{"type":"object"},"strict":true}],
+"tool_choice":{"type":"function","name":"get_weather"},
+"text":{"format":{"type":"json_schema","name":"out","schema":{"type":"object"},"strict":true}}
+}`
+		var req openai.ResponseRequest
+		require.NoError(t, json.Unmarshal([]byte(in), &req))
+		chatReq, err := responseRequestToChatCompletionRequest(&req)
+		require.NoError(t, err)
+
+		require.Equal(t, "gpt-4.1", chatReq.Model)
+		require.Equal(t, ptr.To[int64](100), chatReq.MaxCompletionTokens)
+		require.Equal(t, ptr.To(0.5), chatReq.Temperature)
+		require.True(t, chatReq.Stream)
+		require.Equal(t, &openai.StreamOptions{IncludeUsage: true}, chatReq.StreamOptions)
+		require.Len(t, chatReq.Messages, 6)
+		require.Equal(t, openai.ChatCompletionSystemMessageParam{
+			Role: openai.ChatMessageRoleSystem, Content: openai.StringOrArray{Value: "be nice"},
+		}, chatReq.Messages[0].Value)
+		require.Equal(t, openai.ChatCompletionUserMessageParam{
+			Role: openai.ChatMessageRoleUser, Content: openai.StringOrUserRoleContentUnion{Value: "what is the weather?"},
+		}, chatReq.Messages[1].Value)
+		assistant := chatReq.Messages[2].Value.(openai.ChatCompletionAssistantMessageParam)
+		require.Len(t, assistant.ToolCalls, 2)
+		require.Equal(t, "call_1", *assistant.ToolCalls[0].ID)
+		require.Equal(t, "get_weather", assistant.ToolCalls[0].Function.Name)
+		require.Equal(t, `{"city":"NY"}`, assistant.ToolCalls[1].Function.Arguments)
+		require.Equal(t, openai.ChatCompletionToolMessageParam{
+			Role: openai.ChatMessageRoleTool, ToolCallID: "call_1", Content: openai.StringOrArray{Value: "sunny"},
+		}, chatReq.Messages[3].Value)
+		require.Equal(t, openai.ChatCompletionAssistantMessageParam{
+			Role: openai.ChatMessageRoleAssistant, Content: openai.StringOrAssistantRoleContentUnion{Value: "It is sunny."},
+		}, chatReq.Messages[4].Value)
+		user := chatReq.Messages[5].Value.(openai.ChatCompletionUserMessageParam)
+		parts := user.Content.Value.([]openai.ChatCompletionContentPartUserUnionParam)
+		require.Len(t, parts, 2)
+		require.Equal(t, "and this?", parts[0].TextContent.Text)
+		require.Equal(t, "https://example.com/a.png", parts[1].ImageContent.ImageURL.URL)
+
+		require.Equal(t, []openai.Tool{{Type: openai.ToolTypeFunction, Function: &openai.FunctionDefinition{
+			Name: "get_weather", Description: "weather", Parameters: map[string]any{"type": "object"}, Strict: true,
+		}}}, chatReq.Tools)
+		require.Equal(t, openai.ToolChoice{Type: openai.ToolTypeFunction, Function: openai.ToolFunction{Name: "get_weather"}}, chatReq.ToolChoice)
+		require.Equal(t, openai.ChatCompletionResponseFormatTypeJSONSchema, chatReq.ResponseFormat.Type)
+		require.Equal(t, "out", chatReq.ResponseFormat.JSONSchema.Name)
+	})
+
+	for _, tc := range []struct {
+		name   string
+		in     string
+		expErr string
+	}{
+		{name: "previous response", in: `{"model":"m","input":"hi","previous_response_id":"resp_1"}`, expErr: "previous_response_id is not supported"},
+		{name: "tool type", in: `{"model":"m","input":"hi","tools":[{"type":"web_search_preview"}]}`, expErr: "unsupported tool type: web_search_preview"},
+		{name: "item type", in: `{"model":"m","input":[{"type":"reasoning"}]}`, expErr: "input[0]: unsupported input item type: reasoning"},
+		{name: "role", in: `{"model":"m","input":[{"role":"foo","content":"hi"}]}`, expErr: "input[0]: unsupported message role: foo"},
+		{name: "image on assistant", in: `{"model":"m","input":[{"role":"assistant","content":[{"type":"input_image","image_url":"a"}]}]}`, expErr: "input_image is only supported for user messages"},
+		{name: "text format", in: `{"model":"m","input":"hi","text":{"format":{"type":"yaml"}}}`, expErr: "unsupported text format type: yaml"},
+	} {
+		t.Run("error/"+tc.name, func(t *testing.T) {
+			var req openai.ResponseRequest
+			require.NoError(t, json.Unmarshal([]byte(tc.in), &req))
+			_, err := responseRequestToChatCompletionRequest(&req)
+			require.ErrorContains(t, err, tc.expErr)
+		})
+	}
+}
+
+func TestResponsesToChatCompletionTranslator_AWSBedrock(t *testing.T) {
+	tr := NewResponsesOpenAIToAWSBedrockTranslator("anthropic.claude-3")
+	req := &openai.ResponseRequest{Model: "claude", Input: openai.ResponseInput{Text: ptr.To("hello")}}
+	hm, bm, err := tr.RequestBody(nil, req, false)
+	require.NoError(t, err)
+	require.Equal(t, "/model/anthropic.claude-3/converse", string(hm.SetHeaders[0].Header.RawValue))
+	var bedrockReq awsbedrock.ConverseInput
+	require.NoError(t, json.Unmarshal(bm.GetBody(), &bedrockReq))
+	require.Len(t, bedrockReq.Messages, 1)
+	require.Equal(t, "hello", *bedrockReq.Messages[0].Content[0].Text)
+
+	const bedrockResp = `{"output":{"message":{"role":"assistant","content":[{"text":"hi there"},
+{"toolUse":{"toolUseId":"tool_1","name":"get_weather","input":{"city":"SF"}}}]}},
+"stopReason":"tool_use","usage":{"inputTokens":3,"outputTokens":4,"totalTokens":7}}`
+	hm, bm, usage, err := tr.ResponseBody(map[string]string{":status": "200"}, strings.NewReader(bedrockResp), true)
+	require.NoError(t, err)
+	require.Equal(t, LLMTokenUsage{InputTokens: 3, OutputTokens: 4, TotalTokens: 7}, usage)
+	require.Equal(t, "content-length", hm.SetHeaders[0].Header.Key)
+	var resp openai.Response
+	require.NoError(t, json.Unmarshal(bm.GetBody(), &resp))
+	require.Equal(t, "response", resp.Object)
+	require.Equal(t, openai.ResponseStatusCompleted, resp.Status)
+	require.Equal(t, "anthropic.claude-3", resp.Model)
+	require.Len(t, resp.Output, 2)
+	require.Equal(t, "hi there", resp.Output[0].Content[0].Text)
+	require.Equal(t, openai.ResponseItemTypeFunctionCall, resp.Output[1].Type)
+	require.Equal(t, "tool_1", resp.Output[1].CallID)
+	require.JSONEq(t, `{"city":"SF"}`, resp.Output[1].Arguments)
+	require.Equal(t, &openai.ResponseUsage{InputTokens: 3, OutputTokens: 4, TotalTokens: 7}, resp.Usage)
+}
+
+// fakeChatCompletionTranslator implements [OpenAIChatCompletionTranslator] returning the given chunks as-is.
+type fakeChatCompletionTranslator struct {
+	OpenAIChatCompletionTranslator
+	usage LLMTokenUsage
+}
+
+func (f *fakeChatCompletionTranslator) ResponseBody(_ map[string]string, body io.Reader, _ bool) (
+	*extprocv3.HeaderMutation, *extprocv3.BodyMutation, LLMTokenUsage, error,
+) {
+	buf, _ := io.ReadAll(body)
+	usage := f.usage
+	if !bytes.Contains(buf, []byte(`"usage"`)) {
+		usage = LLMTokenUsage{}
+	}
+	return nil, &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: buf}}, usage, nil
+}
+
+func TestResponsesToChatCompletionTranslator_Streaming(t *testing.T) {
+	tr := newResponsesToChatCompletionTranslator(&fakeChatCompletionTranslator{usage: LLMTokenUsage{InputTokens: 5, OutputTokens: 6, TotalTokens: 11}}, "")
+	tr.stream = true
+	tr.model = "some-model"
+	tr.openMessageIndex, tr.openFunctionCallIndex = -1, -1
+	tr.toolCallIndexes = map[int]int{}
+
+	chunks := []string{
+		`data: {"choices":[{"index":0,"delta":{"role":"assistant","content":""}}]}` + "\n\n",
+		`data: {"choices":[{"index":0,"delta":{"content":"Hel"}}]}` + "\n\n" + `data: {"choices":[{"index":0,"delta":{"con`,
+		`tent":"lo"}}]}` + "\n\n",
+		`data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"f","arguments":""}}]}}]}` + "\n\n",
+		`data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"type":"function","function":{"arguments":"{\"a\":1}"}}]}}]}` + "\n\n",
+		`data: {"choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}` + "\n\n",
+		`data: {"choices":[],"usage":{"prompt_tokens":5,"completion_tokens":6,"total_tokens":11}}` + "\n\n",
+		"data: [DONE]\n",
+	}
+	var out []byte
+	var total LLMTokenUsage
+	for i, c := range chunks {
+		_, bm, usage, err := tr.ResponseBody(nil, strings.NewReader(c), i == len(chunks)-1)
+		require.NoError(t, err)
+		out = append(out, bm.GetBody()...)
+		total.TotalTokens += usage.TotalTokens
+	}
+	require.Equal(t, uint32(11), total.TotalTokens)
+
+	var events []openai.ResponseStreamEvent
+	for _, block := range strings.Split(strings.TrimSpace(string(out)), "\n\n") {
+		lines := strings.Split(block, "\n")
+		require.Len(t, lines, 2)
+		var ev openai.ResponseStreamEvent
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &ev))
+		require.Equal(t, "event: "+ev.Type, lines[0])
+		require.Equal(t, len(events), ev.SequenceNumber)
+		events = append(events, ev)
+	}
+	var types []string
+	for _, ev := range events {
+		types = append(types, ev.Type)
+	}
+	require.Equal(t, []string{
+		openai.ResponseStreamEventTypeCreated,
+		openai.ResponseStreamEventTypeInProgress,
+		openai.ResponseStreamEventTypeOutputItemAdded,
+		openai.ResponseStreamEventTypeContentPartAdded,
+		openai.ResponseStreamEventTypeOutputTextDelta,
+		openai.ResponseStreamEventTypeOutputTextDelta,
+		openai.ResponseStreamEventTypeOutputTextDone,
+		openai.ResponseStreamEventTypeContentPartDone,
+		openai.ResponseStreamEventTypeOutputItemDone,
+		openai.ResponseStreamEventTypeOutputItemAdded,
+		openai.ResponseStreamEventTypeFunctionCallArgsDelta,
+		openai.ResponseStreamEventTypeFunctionCallArgsDone,
+		openai.ResponseStreamEventTypeOutputItemDone,
+		openai.ResponseStreamEventTypeCompleted,
+	}, types)
+	require.Equal(t, "Hello", events[6].Text)
+	require.JSONEq(t, `{"a":1}`, events[11].Arguments)
+
+	final := events[len(events)-1].Response
+	require.Equal(t, openai.ResponseStatusCompleted, final.Status)
+	require.Equal(t, "some-model", final.Model)
+	require.Len(t, final.Output, 2)
+	require.Equal(t, "Hello", final.Output[0].Content[0].Text)
+	require.Equal(t, "call_1", final.Output[1].CallID)
+	require.Equal(t, &openai.ResponseUsage{InputTokens: 5, OutputTokens: 6, TotalTokens: 11}, final.Usage)
+}
+
+func TestResponsesToChatCompletionTranslator_StreamingIncomplete(t *testing.T) {
+	tr := newResponsesToChatCompletionTranslator(&fakeChatCompletionTranslator{}, "")
+	tr.stream = true
+	tr.openMessageIndex, tr.openFunctionCallIndex = -1, -1
+	tr.toolCallIndexes = map[int]int{}
+	_, bm, _, err := tr.ResponseBody(nil, strings.NewReader(
+		`data: {"choices":[{"index":0,"delta":{"content":"a"},"finish_reason":"length"}]}`+"\n\n"), true)
+	require.NoError(t, err)
+	body := string(bm.GetBody())
+	require.Contains(t, body, "event: "+openai.ResponseStreamEventTypeIncomplete)
+	require.Contains(t, body, `"incomplete_details":{"reason":"max_output_tokens"}`)
+	// Further calls must not emit another terminal event.
+	_, bm, _, err = tr.ResponseBody(nil, strings.NewReader("data: [DONE]\n"), true)
+	require.NoError(t, err)
+	require.Empty(t, bm.GetBody())
+}
@@ -0,0 +1,133 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestOpenAIToOpenAITranslatorV1Responses_RequestBody(t *testing.T) {
+	const raw = `{"model":"gpt-4.1","input":"hello"}`
+	for _, tc := range []struct {
+		name              string
+		translator        OpenAIResponsesTranslator
+		forceMutation     bool
+		expPath           string
+		expBody           string
+		expNoBodyMutation bool
+	}{
+		{
+			name:              "openai/no override",
+			translator:        NewResponsesOpenAIToOpenAITranslator("v1", ""),
+			expPath:           "/v1/responses",
+			expNoBodyMutation: true,
+		},
+		{
+			name:          "openai/force mutation",
+			translator:    NewResponsesOpenAIToOpenAITranslator("v1", ""),
+			forceMutation: true,
+			expPath:       "/v1/responses",
+			expBody:       raw,
+		},
+		{
+			name:       "openai/override",
+			translator: NewResponsesOpenAIToOpenAITranslator("v1", "gpt-4.1-mini"),
+			expPath:    "/v1/responses",
+			expBody:    `{"model":"gpt-4.1-mini","input":"hello"}`,
+		},
+		{
+			name:       "azure/override",
+			translator: NewResponsesOpenAIToAzureOpenAITranslator("2025-04-01-preview", "my-deployment"),
+			expPath:    "/openai/responses?api-version=2025-04-01-preview",
+			expBody:    `{"model":"my-deployment","input":"hello"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var req openai.ResponseRequest
+			require.NoError(t, json.Unmarshal([]byte(raw), &req))
+			hm, bm, err := tc.translator.RequestBody([]byte(raw), &req, tc.forceMutation)
+			require.NoError(t, err)
+			require.Equal(t, ":path", hm.SetHeaders[0].Header.Key)
+			require.Equal(t, tc.expPath, string(hm.SetHeaders[0].Header.RawValue))
+			if tc.expNoBodyMutation {
+				require.Nil(t, bm)
+				require.Len(t, hm.SetHeaders, 1)
+				return
+			}
+			require.JSONEq(t, tc.expBody, string(bm.GetBody()))
+			require.Equal(t, "content-length", hm.SetHeaders[1].Header.Key)
+		})
+	}
+}
+
+func TestOpenAIToOpenAITranslatorV1Responses_ResponseBody(t *testing.T) {
+	t.Run("non-streaming", func(t *testing.T) {
+		o := &openAIToOpenAITranslatorV1Responses{}
+		body := `{"id":"resp_1","object":"response","created_at":1,"status":"completed","model":"gpt-4.1","output":[],
+"usage":{"input_tokens":10,"output_tokens":20,"total_tokens":30,"input_tokens_details":{"cached_tokens":0},"output_tokens_details":{"reasoning_tokens":0}}}`
+		hm, bm, usage, err := o.ResponseBody(nil, strings.NewReader(body), true)
+		require.NoError(t, err)
+		require.Nil(t, hm)
+		require.Nil(t, bm)
+		require.Equal(t, LLMTokenUsage{InputTokens: 10, OutputTokens: 20, TotalTokens: 30}, usage)
+	})
+	t.Run("invalid body", func(t *testing.T) {
+		o := &openAIToOpenAITranslatorV1Responses{}
+		_, _, _, err := o.ResponseBody(nil, strings.NewReader("invalid"), true)
+		require.ErrorContains(t, err, "failed to unmarshal body")
+	})
+	t.Run("streaming", func(t *testing.T) {
+		o := &openAIToOpenAITranslatorV1Responses{stream: true}
+		const events = `event: response.created
+data: {"type":"response.created","sequence_number":0,"response":{"id":"resp_1","status":"in_progress","output":[]}}
+
+event: response.output_text.delta
+data: {"type":"response.output_text.delta","sequence_number":1,"delta":"hi"}
+
+event: response.completed
+data: {"type":"response.completed","sequence_number":2,"response":{"id":"resp_1","status":"completed","output":[],"usage":{"input_tokens":1,"output_tokens":2,"total_tokens":3}}}
+
+`
+		// Split the events into two chunks in the middle of the last event to ensure the buffering works.
+		split := strings.Index(events, `"usage"`)
+		_, bm, usage, err := o.ResponseBody(nil, bytes.NewReader([]byte(events[:split])), false)
+		require.NoError(t, err)
+		require.Nil(t, bm)
+		require.Equal(t, LLMTokenUsage{}, usage)
+		_, bm, usage, err = o.ResponseBody(nil, bytes.NewReader([]byte(events[split:])), true)
+		require.NoError(t, err)
+		require.Nil(t, bm)
+		require.Equal(t, LLMTokenUsage{InputTokens: 1, OutputTokens: 2, TotalTokens: 3}, usage)
+		require.True(t, o.bufferingDone)
+	})
+}
+
+func TestOpenAIToOpenAITranslatorV1Responses_ResponseError(t *testing.T) {
+	o := &openAIToOpenAITranslatorV1Responses{}
+	t.Run("json error is passed through", func(t *testing.T) {
+		hm, bm, err := o.ResponseError(map[string]string{statusHeaderName: "400", contentTypeHeaderName: jsonContentType}, strings.NewReader(`{}`))
+		require.NoError(t, err)
+		require.Nil(t, hm)
+		require.Nil(t, bm)
+	})
+	t.Run("non-json error is converted", func(t *testing.T) {
+		hm, bm, err := o.ResponseError(map[string]string{statusHeaderName: "503", contentTypeHeaderName: "text/plain"}, strings.NewReader("service unavailable"))
+		require.NoError(t, err)
+		require.NotNil(t, hm)
+		var openaiErr openai.Error
+		require.NoError(t, json.Unmarshal(bm.GetBody(), &openaiErr))
+		require.Equal(t, openAIBackendError, openaiErr.Error.Type)
+		require.Equal(t, "service unavailable", openaiErr.Error.Message)
+		require.Equal(t, "503", *openaiErr.Error.Code)
+	})
+}
@@ -13,17 +13,19 @@ import (
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	"github.com/tidwall/sjson"
 
+	anthropicschema "github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
 	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
 )
 
 const (
-	statusHeaderName       = ":status"
-	contentTypeHeaderName  = "content-type"
-	awsErrorTypeHeaderName = "x-amzn-errortype"
-	jsonContentType        = "application/json"
-	eventStreamContentType = "text/event-stream"
-	openAIBackendError     = "OpenAIBackendError"
-	awsBedrockBackendError = "AWSBedrockBackendError"
+	statusHeaderName        = ":status"
+	contentTypeHeaderName   = "content-type"
+	awsErrorTypeHeaderName  = "x-amzn-errortype"
+	jsonContentType         = "application/json"
+	eventStreamContentType  = "text/event-stream"
+	openAIBackendError      = "OpenAIBackendError"
+	awsBedrockBackendError  = "AWSBedrockBackendError"
+	gcpVertexAIBackendError = "GCPVertexAIBackendError"
 )
 
 // OpenAIChatCompletionTranslator translates the request and response messages between the client and the backend API schemas
@@ -76,6 +78,47 @@ func setContentLength(headers *extprocv3.HeaderMutation, body []byte) {
 	})
 }
 
+// OpenAICompletionTranslator translates the request and response messages between the client and the backend API schemas
+// for the legacy /v1/completions endpoint of OpenAI.
+//
+// This is created per request and is not thread-safe.
+type OpenAICompletionTranslator interface {
+	// RequestBody translates the request body.
+	// 	- `raw` is the raw request body.
+	// 	- `body` is the request body parsed into the [openai.CompletionRequest].
+	//	- `forceBodyMutation` is true if the translator should always mutate the body, even if no changes are made.
+	//	- This returns `headerMutation` and `bodyMutation` that can be nil to indicate no mutation.
+	RequestBody(raw []byte, body *openai.CompletionRequest, forceBodyMutation bool) (
+		headerMutation *extprocv3.HeaderMutation,
+		bodyMutation *extprocv3.BodyMutation,
+		err error,
+	)
+
+	// ResponseHeaders translates the response headers.
+	// 	- `headers` is the response headers.
+	//	- This returns `headerMutation` that can be nil to indicate no mutation.
+	ResponseHeaders(headers map[string]string) (
+		headerMutation *extprocv3.HeaderMutation,
+		err error,
+	)
+
+	// ResponseBody translates the response body. When stream=true, this is called for each chunk of the response body.
+	// 	- `body` is the response body either chunk or the entire body, depending on the context.
+	//	- This returns `headerMutation` and `bodyMutation` that can be nil to indicate no mutation.
+	//  - This returns `tokenUsage` that is extracted from the body and will be used to do token rate limiting.
+	ResponseBody(respHeaders map[string]string, body io.Reader, endOfStream bool) (
+		headerMutation *extprocv3.HeaderMutation,
+		bodyMutation *extprocv3.BodyMutation,
+		tokenUsage LLMTokenUsage,
+		err error,
+	)
+
+	// ResponseError translates the response error. This is called when the upstream response status code is not successful (2xx).
+	// 	- `respHeaders` is the response headers.
+	// 	- `body` is the response body that contains the error message.
+	ResponseError(respHeaders map[string]string, body io.Reader) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error)
+}
+
 // OpenAIEmbeddingTranslator translates the request and response messages between the client and the backend API schemas
 // for /v1/embeddings endpoint of OpenAI.
 //
@@ -117,14 +160,230 @@ type OpenAIEmbeddingTranslator interface {
 	ResponseError(respHeaders map[string]string, body io.Reader) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error)
 }
 
+// OpenAIImageGenerationTranslator translates the request and response messages between the client and the backend API schemas
+// for /v1/images/generations endpoint of OpenAI.
+//
+// This is created per request and is not thread-safe.
+type OpenAIImageGenerationTranslator interface {
+	// RequestBody translates the request body.
+	// 	- `raw` is the raw request body.
+	// 	- `body` is the request body parsed into the [openai.ImageGenerationRequest].
+	//	- `onRetry` is true if this is a retry request.
+	//	- This returns `headerMutation` and `bodyMutation` that can be nil to indicate no mutation.
+	RequestBody(raw []byte, body *openai.ImageGenerationRequest, onRetry bool) (
+		headerMutation *extprocv3.HeaderMutation,
+		bodyMutation *extprocv3.BodyMutation,
+		err error,
+	)
+
+	// ResponseHeaders translates the response headers.
+	// 	- `headers` is the response headers.
+	//	- This returns `headerMutation` that can be nil to indicate no mutation.
+	ResponseHeaders(headers map[string]string) (
+		headerMutation *extprocv3.HeaderMutation,
+		err error,
+	)
+
+	// ResponseBody translates the response body.
+	// 	- `body` is the response body.
+	//	- This returns `headerMutation` and `bodyMutation` that can be nil to indicate no mutation.
+	//  - This returns `tokenUsage` that contains the number of generated images as well as the tokens if reported.
+	ResponseBody(respHeaders map[string]string, body io.Reader, endOfStream bool) (
+		headerMutation *extprocv3.HeaderMutation,
+		bodyMutation *extprocv3.BodyMutation,
+		tokenUsage LLMTokenUsage,
+		err error,
+	)
+
+	// ResponseError translates the response error. This is called when the upstream response status code is not successful (2xx).
+	// 	- `respHeaders` is the response headers.
+	// 	- `body` is the response body that contains the error message.
+	ResponseError(respHeaders map[string]string, body io.Reader) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error)
+}
+
+// OpenAIAudioTranscriptionTranslator translates the request and response messages between the client and the backend API schemas
+// for /v1/audio/transcriptions endpoint of OpenAI.
+//
+// This is created per request and is not thread-safe.
+type OpenAIAudioTranscriptionTranslator interface {
+	// RequestBody translates the request body.
+	// 	- `raw` is the raw multipart/form-data request body.
+	// 	- `contentType` is the content type of the request including the multipart boundary.
+	// 	- `body` is the non-file form fields parsed into the [openai.AudioTranscriptionRequest].
+	//	- `onRetry` is true if this is a retry request.
+	//	- This returns `headerMutation` and `bodyMutation` that can be nil to indicate no mutation.
+	RequestBody(raw []byte, contentType string, body *openai.AudioTranscriptionRequest, onRetry bool) (
+		headerMutation *extprocv3.HeaderMutation,
+		bodyMutation *extprocv3.BodyMutation,
+		err error,
+	)
+
+	// ResponseHeaders translates the response headers.
+	// 	- `headers` is the response headers.
+	//	- This returns `headerMutation` that can be nil to indicate no mutation.
+	ResponseHeaders(headers map[string]string) (
+		headerMutation *extprocv3.HeaderMutation,
+		err error,
+	)
+
+	// ResponseBody translates the response body.
+	// 	- `body` is the response body.
+	//	- This returns `headerMutation` and `bodyMutation` that can be nil to indicate no mutation.
+	//  - This returns `tokenUsage` that contains the audio seconds or the tokens depending on what the model reports.
+	ResponseBody(respHeaders map[string]string, body io.Reader, endOfStream bool) (
+		headerMutation *extprocv3.HeaderMutation,
+		bodyMutation *extprocv3.BodyMutation,
+		tokenUsage LLMTokenUsage,
+		err error,
+	)
+
+	// ResponseError translates the response error. This is called when the upstream response status code is not successful (2xx).
+	// 	- `respHeaders` is the response headers.
+	// 	- `body` is the response body that contains the error message.
+	ResponseError(respHeaders map[string]string, body io.Reader) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error)
+}
+
+// OpenAIAudioSpeechTranslator translates the request and response messages between the client and the backend API schemas
+// for /v1/audio/speech endpoint of OpenAI.
+//
+// This is created per request and is not thread-safe.
+type OpenAIAudioSpeechTranslator interface {
+	// RequestBody translates the request body.
+	// 	- `raw` is the raw request body.
+	// 	- `body` is the request body parsed into the [openai.AudioSpeechRequest].
+	//	- `onRetry` is true if this is a retry request.
+	//	- This returns `headerMutation` and `bodyMutation` that can be nil to indicate no mutation.
+	RequestBody(raw []byte, body *openai.AudioSpeechRequest, onRetry bool) (
+		headerMutation *extprocv3.HeaderMutation,
+		bodyMutation *extprocv3.BodyMutation,
+		err error,
+	)
+
+	// ResponseHeaders translates the response headers.
+	// 	- `headers` is the response headers.
+	//	- This returns `headerMutation` that can be nil to indicate no mutation.
+	ResponseHeaders(headers map[string]string) (
+		headerMutation *extprocv3.HeaderMutation,
+		err error,
+	)
+
+	// ResponseBody translates the response body.
+	// 	- `body` is the binary audio content.
+	//	- This returns `headerMutation` and `bodyMutation` that can be nil to indicate no mutation.
+	//  - This returns `tokenUsage` that contains the number of synthesized characters at the end of the stream.
+	ResponseBody(respHeaders map[string]string, body io.Reader, endOfStream bool) (
+		headerMutation *extprocv3.HeaderMutation,
+		bodyMutation *extprocv3.BodyMutation,
+		tokenUsage LLMTokenUsage,
+		err error,
+	)
+
+	// ResponseError translates the response error. This is called when the upstream response status code is not successful (2xx).
+	// 	- `respHeaders` is the response headers.
+	// 	- `body` is the response body that contains the error message.
+	ResponseError(respHeaders map[string]string, body io.Reader) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error)
+}
+
+// OpenAIResponsesTranslator translates the request and response messages between the client and the backend API schemas
+// for /v1/responses endpoint of OpenAI.
+//
+// This is created per request and is not thread-safe.
+type OpenAIResponsesTranslator interface {
+	// RequestBody translates the request body.
+	// 	- `raw` is the raw request body.
+	// 	- `body` is the request body parsed into the [openai.ResponseRequest].
+	//	- `forceBodyMutation` is true if the translator should always mutate the body, even if no changes are made.
+	//	- This returns `headerMutation` and `bodyMutation` that can be nil to indicate no mutation.
+	RequestBody(raw []byte, body *openai.ResponseRequest, forceBodyMutation bool) (
+		headerMutation *extprocv3.HeaderMutation,
+		bodyMutation *extprocv3.BodyMutation,
+		err error,
+	)
+
+	// ResponseHeaders translates the response headers.
+	// 	- `headers` is the response headers.
+	//	- This returns `headerMutation` that can be nil to indicate no mutation.
+	ResponseHeaders(headers map[string]string) (
+		headerMutation *extprocv3.HeaderMutation,
+		err error,
+	)
+
+	// ResponseBody translates the response body. When stream=true, this is called for each chunk of the response body.
+	// 	- `body` is the response body either chunk or the entire body, depending on the context.
+	//	- This returns `headerMutation` and `bodyMutation` that can be nil to indicate no mutation.
+	//  - This returns `tokenUsage` that is extracted from the body and will be used to do token rate limiting.
+	ResponseBody(respHeaders map[string]string, body io.Reader, endOfStream bool) (
+		headerMutation *extprocv3.HeaderMutation,
+		bodyMutation *extprocv3.BodyMutation,
+		tokenUsage LLMTokenUsage,
+		err error,
+	)
+
+	// ResponseError translates the response error. This is called when the upstream response status code is not successful (2xx).
+	// 	- `respHeaders` is the response headers.
+	// 	- `body` is the response body that contains the error message.
+	ResponseError(respHeaders map[string]string, body io.Reader) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error)
+}
+
+// AnthropicMessagesTranslator translates the request and response messages between the client and the backend API schemas
+// for /v1/messages endpoint of Anthropic.
+//
+// This is created per request and is not thread-safe.
+type AnthropicMessagesTranslator interface {
+	// RequestBody translates the request body.
+	// 	- `raw` is the raw request body.
+	// 	- `body` is the request body parsed into the [anthropicschema.MessagesRequest].
+	//	- `forceBodyMutation` is true if the translator should always mutate the body, even if no changes are made.
+	//	- This returns `headerMutation` and `bodyMutation` that can be nil to indicate no mutation.
+	RequestBody(raw []byte, body *anthropicschema.MessagesRequest, forceBodyMutation bool) (
+		headerMutation *extprocv3.HeaderMutation,
+		bodyMutation *extprocv3.BodyMutation,
+		err error,
+	)
+
+	// ResponseHeaders translates the response headers.
+	// 	- `headers` is the response headers.
+	//	- This returns `headerMutation` that can be nil to indicate no mutation.
+	ResponseHeaders(headers map[string]string) (
+		headerMutation *extprocv3.HeaderMutation,
+		err error,
+	)
+
+	// ResponseBody translates the response body. When stream=true, this is called for each chunk of the response body.
+	// 	- `body` is the response body either chunk or the entire body, depending on the context.
+	//	- This returns `headerMutation` and `bodyMutation` that can be nil to indicate no mutation.
+	//  - This returns `tokenUsage` that is extracted from the body and will be used to do token rate limiting.
+	ResponseBody(respHeaders map[string]string, body io.Reader, endOfStream bool) (
+		headerMutation *extprocv3.HeaderMutation,
+		bodyMutation *extprocv3.BodyMutation,
+		tokenUsage LLMTokenUsage,
+		err error,
+	)
+
+	// ResponseError translates the response error into the Anthropic error format. This is called when the upstream
+	// response status code is not successful (2xx).
+	// 	- `respHeaders` is the response headers.
+	// 	- `body` is the response body that contains the error message.
+	ResponseError(respHeaders map[string]string, body io.Reader) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error)
+}
+
 // LLMTokenUsage represents the token usage reported usually by the backend API in the response body.
 type LLMTokenUsage struct {
 	// InputTokens is the number of tokens consumed from the input.
 	InputTokens uint32
+	// CachedInputTokens is the number of the input tokens read from the prompt cache of the provider,
+	// which are included in InputTokens.
+	CachedInputTokens uint32
 	// OutputTokens is the number of tokens consumed from the output.
 	OutputTokens uint32
 	// TotalTokens is the total number of tokens consumed.
 	TotalTokens uint32
+	// ImageCount is the number of images generated. This is only set for the image generation.
+	ImageCount uint32
+	// AudioSeconds is the duration of the transcribed audio in seconds, rounded up. This is only set for the audio transcription.
+	AudioSeconds uint32
+	// Characters is the number of characters synthesized into speech. This is only set for the audio speech.
+	Characters uint32
 }
 
 // SJSONOptions are the options used for sjson operations in the translator.
@@ -11,3 +11,9 @@ package extproc
 func isGoodStatusCode(code int) bool {
 	return code >= 200 && code < 300
 }
+
+// isBackendFailure checks if the HTTP status code of the upstream response indicates the failure of the backend
+// itself rather than of the request, i.e. 5xx or 429.
+func isBackendFailure(code int) bool {
+	return code >= 500 || code == 429
+}
@@ -19,3 +19,12 @@ func TestIsGoodStatusCode(t *testing.T) {
 		require.False(t, isGoodStatusCode(s))
 	}
 }
+
+func TestIsBackendFailure(t *testing.T) {
+	for _, s := range []int{429, 500, 503} {
+		require.True(t, isBackendFailure(s))
+	}
+	for _, s := range []int{200, 400, 404} {
+		require.False(t, isBackendFailure(s))
+	}
+}
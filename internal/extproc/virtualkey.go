@@ -0,0 +1,76 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"errors"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/internalapi"
+	"github.com/envoyproxy/ai-gateway/internal/virtualkey"
+)
+
+const (
+	// invalidVirtualKeyErrorCode is the code of the OpenAI error returned when the request doesn't have a known virtual
+	// key, which is the same as the one of OpenAI for the invalid API key.
+	invalidVirtualKeyErrorCode = "invalid_api_key"
+	// modelNotAllowedErrorCode is the code of the OpenAI error returned when the virtual key is not allowed to use the
+	// model.
+	modelNotAllowedErrorCode = "model_not_allowed"
+)
+
+// checkVirtualKey validates the virtual key of the request to the given model when the virtual key policy applies to
+// the model. This returns the immediate response when the request is rejected.
+//
+// Otherwise, the client key headers are removed from the given request headers, and the team ID and the name of the key
+// are set to them in place of the values sent by the client. The returned headers to set and remove apply the same
+// to the request so that the virtual key is never sent to the backend, and the credential of the provider is injected
+// by the backendauth.Handler of the upstream filter.
+func (c *processorConfig) checkVirtualKey(model string, headers map[string]string) (setHeaders []*corev3.HeaderValueOption, removeHeaders []string, resp *extprocv3.ProcessingResponse) {
+	k, err := c.virtualKeys.Authenticate(model, headers)
+	var notAllowed *virtualkey.ModelNotAllowedError
+	switch {
+	case errors.As(err, &notAllowed):
+		return nil, nil, virtualKeyErrorResponse(typev3.StatusCode_Forbidden, modelNotAllowedErrorCode, notAllowed.Error())
+	case err != nil:
+		return nil, nil, virtualKeyErrorResponse(typev3.StatusCode_Unauthorized, invalidVirtualKeyErrorCode, err.Error())
+	case k == nil:
+		return nil, nil, nil
+	}
+	for _, h := range k.ClientKeyHeaders() {
+		delete(headers, h)
+	}
+	headers[k.TeamIDHeader()] = k.TeamID()
+	headers[internalapi.VirtualKeyHeaderKey] = k.Name()
+	return []*corev3.HeaderValueOption{
+		{Header: &corev3.HeaderValue{Key: k.TeamIDHeader(), RawValue: []byte(k.TeamID())}},
+		{Header: &corev3.HeaderValue{Key: internalapi.VirtualKeyHeaderKey, RawValue: []byte(k.Name())}},
+	}, k.ClientKeyHeaders(), nil
+}
+
+// virtualKeyErrorResponse returns the immediate response rejecting the request with the OpenAI error of the given code.
+func virtualKeyErrorResponse(status typev3.StatusCode, code, message string) *extprocv3.ProcessingResponse {
+	body, _ := json.Marshal(openai.Error{
+		Type:  "error",
+		Error: openai.ErrorType{Type: "invalid_request_error", Code: &code, Message: message},
+	})
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status: &typev3.HttpStatus{Code: status},
+				Headers: &extprocv3.HeaderMutation{SetHeaders: []*corev3.HeaderValueOption{
+					{Header: &corev3.HeaderValue{Key: "content-type", RawValue: []byte("application/json")}},
+				}},
+				Body: body,
+			},
+		},
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package guardrail
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// grpcServiceName is the name of the gRPC service of the guardrail.
+	grpcServiceName = "aigateway.guardrail.v1.Guardrail"
+	// grpcCheckMethod is the full name of the method of the gRPC service called with the checks.
+	grpcCheckMethod = "/" + grpcServiceName + "/Check"
+)
+
+// httpClient is the [Client] posting the checks as JSON to the HTTP endpoint.
+type httpClient struct {
+	endpoint string
+	client   *http.Client
+}
+
+// newHTTPClient creates a new [httpClient] for the given URL.
+func newHTTPClient(endpoint string) *httpClient {
+	return &httpClient{endpoint: endpoint, client: &http.Client{}}
+}
+
+// Check implements [Client.Check].
+func (h *httpClient) Check(ctx context.Context, check *Check) (*Decision, error) {
+	body, err := json.Marshal(check)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the check: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the request: %w", err)
+	}
+	req.Header.Set("content-type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call the guardrail service: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("unexpected status code from the guardrail service: %d: %s", resp.StatusCode, b)
+	}
+	var d Decision
+	if err = json.NewDecoder(resp.Body).Decode(&d); err != nil {
+		return nil, fmt.Errorf("failed to decode the decision: %w", err)
+	}
+	return &d, nil
+}
+
+// Close implements [Client.Close].
+func (h *httpClient) Close() error {
+	h.client.CloseIdleConnections()
+	return nil
+}
+
+// grpcClient is the [Client] calling the gRPC service with the checks as google.protobuf.Struct.
+type grpcClient struct {
+	conn *grpc.ClientConn
+}
+
+// newGRPCClient creates a new [grpcClient] for the given host:port. The connection is established lazily.
+func newGRPCClient(endpoint string) (*grpcClient, error) {
+	conn, err := grpc.NewClient(endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the gRPC client: %w", err)
+	}
+	return &grpcClient{conn: conn}, nil
+}
+
+// Check implements [Client.Check].
+func (g *grpcClient) Check(ctx context.Context, check *Check) (*Decision, error) {
+	req, err := toStruct(check)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert the check: %w", err)
+	}
+	var resp structpb.Struct
+	if err = g.conn.Invoke(ctx, grpcCheckMethod, req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to call the guardrail service: %w", err)
+	}
+	var d Decision
+	if err = fromStruct(&resp, &d); err != nil {
+		return nil, fmt.Errorf("failed to convert the decision: %w", err)
+	}
+	return &d, nil
+}
+
+// Close implements [Client.Close].
+func (g *grpcClient) Close() error {
+	return g.conn.Close()
+}
+
+// GRPCServer is the server side of the gRPC contract of the guardrail service.
+type GRPCServer interface {
+	// Check returns the decision on the given check.
+	Check(ctx context.Context, check *Check) (*Decision, error)
+}
+
+// RegisterGRPCServer registers the given implementation of the guardrail service to the gRPC server. This is useful
+// to implement the service in Go, e.g. as a local stand-in.
+func RegisterGRPCServer(s grpc.ServiceRegistrar, srv GRPCServer) {
+	s.RegisterService(&grpc.ServiceDesc{
+		ServiceName: grpcServiceName,
+		HandlerType: (*GRPCServer)(nil),
+		Methods:     []grpc.MethodDesc{{MethodName: "Check", Handler: grpcCheckHandler}},
+	}, srv)
+}
+
+// grpcCheckHandler handles the calls to the Check method of the gRPC service.
+func grpcCheckHandler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := &structpb.Struct{}
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		var check Check
+		if err := fromStruct(req.(*structpb.Struct), &check); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid check: %v", err)
+		}
+		d, err := srv.(GRPCServer).Check(ctx, &check)
+		if err != nil {
+			return nil, err
+		}
+		return toStruct(d)
+	}
+	if interceptor == nil {
+		return handler(ctx, in)
+	}
+	return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: grpcCheckMethod}, handler)
+}
+
+// toStruct converts the given value to google.protobuf.Struct via its JSON.
+func toStruct(v any) (*structpb.Struct, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	s := &structpb.Struct{}
+	if err = protojson.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// fromStruct converts the given google.protobuf.Struct to v via its JSON.
+func fromStruct(s *structpb.Struct, v any) error {
+	b, err := protojson.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
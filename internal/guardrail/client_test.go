@@ -0,0 +1,112 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package guardrail
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestHTTPClient_Check(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("content-type"))
+		var check Check
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&check))
+		if check.Model == "broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("boom"))
+			return
+		}
+		require.Equal(t, Check{
+			Phase:    PhaseResponse,
+			Route:    "ns/route",
+			Model:    "gpt-4o",
+			Request:  json.RawMessage(`{"model":"gpt-4o"}`),
+			Response: json.RawMessage(`{"choices":[]}`),
+		}, check)
+		_, _ = w.Write([]byte(`{"action":"block","status":403,"error":{"type":"moderation","message":"flagged"}}`))
+	}))
+	defer srv.Close()
+
+	c := newHTTPClient(srv.URL)
+	defer func() {
+		require.NoError(t, c.Close())
+	}()
+	d, err := c.Check(t.Context(), &Check{
+		Phase:    PhaseResponse,
+		Route:    "ns/route",
+		Model:    "gpt-4o",
+		Request:  json.RawMessage(`{"model":"gpt-4o"}`),
+		Response: json.RawMessage(`{"choices":[]}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, &Decision{Action: ActionBlock, Status: 403, Error: &openai.ErrorType{Type: "moderation", Message: "flagged"}}, d)
+
+	_, err = c.Check(t.Context(), &Check{Phase: PhaseRequest, Model: "broken", Request: json.RawMessage(`{}`)})
+	require.EqualError(t, err, "unexpected status code from the guardrail service: 500: boom")
+}
+
+// grpcServer implements [GRPCServer] for testing.
+type grpcServer struct {
+	t *testing.T
+}
+
+// Check implements [GRPCServer.Check].
+func (s grpcServer) Check(_ context.Context, check *Check) (*Decision, error) {
+	if check.Model == "broken" {
+		return nil, status.Error(codes.Unavailable, "boom")
+	}
+	require.Equal(s.t, PhaseRequest, check.Phase)
+	require.Equal(s.t, "ns/route", check.Route)
+	require.JSONEq(s.t, `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`, string(check.Request))
+	return &Decision{
+		Action:  ActionMutate,
+		Reason:  "rewritten",
+		Request: json.RawMessage(`{"model":"gpt-4o","messages":[{"role":"user","content":"hello"}]}`),
+	}, nil
+}
+
+func TestGRPCClient_Check(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	RegisterGRPCServer(s, grpcServer{t: t})
+	go func() {
+		_ = s.Serve(l)
+	}()
+	defer s.Stop()
+
+	c, err := newGRPCClient(l.Addr().String())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, c.Close())
+	}()
+	d, err := c.Check(t.Context(), &Check{
+		Phase:   PhaseRequest,
+		Route:   "ns/route",
+		Model:   "gpt-4o",
+		Request: json.RawMessage(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, ActionMutate, d.Action)
+	require.Equal(t, "rewritten", d.Reason)
+	require.JSONEq(t, `{"model":"gpt-4o","messages":[{"role":"user","content":"hello"}]}`, string(d.Request))
+
+	_, err = c.Check(t.Context(), &Check{Phase: PhaseRequest, Model: "broken", Request: json.RawMessage(`{}`)})
+	require.ErrorContains(t, err, "failed to call the guardrail service: rpc error: code = Unavailable desc = boom")
+}
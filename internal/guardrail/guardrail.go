@@ -0,0 +1,291 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package guardrail provides the callout to the external guardrail services consulted on the requests and the
+// responses of the routes with the guardrail, i.e. those of the chat completions, the Responses and the Anthropic
+// Messages APIs.
+//
+// The service receives a [Check] of the request before it is routed, or of the response before its end is returned to
+// the client, and returns a [Decision] to allow, block or mutate it. The request and the response are in the format of
+// the [API] of the check.
+package guardrail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// Phase is the phase of the check.
+type Phase string
+
+const (
+	// PhaseRequest is the check of the request before it is routed to the backend.
+	PhaseRequest Phase = "request"
+	// PhaseResponse is the check of the response before its end is returned to the client.
+	PhaseResponse Phase = "response"
+)
+
+// API is the API of the checked request and response, which determines their format.
+type API string
+
+const (
+	// APIChatCompletions is the OpenAI chat completions API.
+	APIChatCompletions API = "chat_completions"
+	// APIResponses is the OpenAI Responses API.
+	APIResponses API = "responses"
+	// APIMessages is the Anthropic Messages API.
+	APIMessages API = "messages"
+)
+
+// Action is the action decided by the guardrail service.
+type Action string
+
+const (
+	// ActionAllow lets the request or the response through as is.
+	ActionAllow Action = "allow"
+	// ActionBlock rejects the request, or replaces the response, with the error of the decision.
+	ActionBlock Action = "block"
+	// ActionMutate replaces the request or the response with the one of the decision.
+	ActionMutate Action = "mutate"
+)
+
+// BlockedErrorType is the default type of the error returned to the client when the guardrail service blocks it.
+const BlockedErrorType = "guardrail_blocked"
+
+// Check is the check sent to the guardrail service.
+type Check struct {
+	// Phase is the phase of the check.
+	Phase Phase `json:"phase"`
+	// API is the API of the request and the response.
+	API API `json:"api"`
+	// Route is the name of the route in the "namespace/name" format.
+	Route string `json:"route"`
+	// Model is the model name of the request.
+	Model string `json:"model"`
+	// Request is the request in the format of the API.
+	Request json.RawMessage `json:"request"`
+	// Response is the response in the format of the API, which is only set in the response phase. The streaming
+	// response is assembled into the non-streaming one.
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// Decision is the decision of the guardrail service on a check.
+type Decision struct {
+	// Action is the action decided by the service.
+	Action Action `json:"action"`
+	// Reason is the reason of the decision recorded in the tracing span. Optional.
+	Reason string `json:"reason,omitempty"`
+	// Status is the HTTP status code returned to the client with the block action. Default is 400.
+	Status int `json:"status,omitempty"`
+	// Error is the OpenAI-style error returned to the client with the block action, which is converted to the error
+	// of the API for the Anthropic Messages API. The type defaults to BlockedErrorType.
+	Error *openai.ErrorType `json:"error,omitempty"`
+	// Request is the request replacing the checked one with the mutate action in the request phase, in the same format.
+	// The model must not be changed.
+	Request json.RawMessage `json:"request,omitempty"`
+	// Response is the response replacing the checked one with the mutate action in the response phase, in the same
+	// format. This is in the non-streaming format even for the streaming response, which is re-emitted as a stream.
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// validate validates the decision on the given check, and applies the defaults.
+func (d *Decision) validate(check *Check) error {
+	phase := check.Phase
+	switch d.Action {
+	case ActionAllow:
+	case ActionBlock:
+		if d.Status == 0 {
+			d.Status = http.StatusBadRequest
+		} else if d.Status < 400 || d.Status > 599 {
+			return fmt.Errorf("status of the block action must be an error status code: %d", d.Status)
+		}
+		if d.Error == nil {
+			d.Error = &openai.ErrorType{Message: fmt.Sprintf("the %s is blocked by the guardrail", phase)}
+		}
+		if d.Error.Type == "" {
+			d.Error.Type = BlockedErrorType
+		}
+	case ActionMutate:
+		if phase == PhaseRequest {
+			if len(d.Request) == 0 {
+				return errors.New("request must be set for the mutate action in the request phase")
+			}
+			var req, orig struct {
+				Model string `json:"model"`
+			}
+			if err := json.Unmarshal(d.Request, &req); err != nil {
+				return fmt.Errorf("invalid request of the mutate action: %w", err)
+			}
+			// The request has already been matched to the route by the model name.
+			if _ = json.Unmarshal(check.Request, &orig); req.Model != orig.Model {
+				return fmt.Errorf("model of the request must not be changed by the mutate action: %q", req.Model)
+			}
+		}
+		if phase == PhaseResponse && len(d.Response) == 0 {
+			return errors.New("response must be set for the mutate action in the response phase")
+		}
+	default:
+		return fmt.Errorf("unknown action: %q", d.Action)
+	}
+	return nil
+}
+
+// Client calls a guardrail service.
+type Client interface {
+	// Check sends the check to the service and returns its decision.
+	Check(ctx context.Context, check *Check) (*Decision, error)
+	// Close releases the resources of the client.
+	Close() error
+}
+
+// Guardrail is the guardrail of a route.
+type Guardrail struct {
+	config *filterapi.Guardrail
+	client Client
+}
+
+// New creates a new [Guardrail] from the given configuration.
+func New(config *filterapi.Guardrail) (*Guardrail, error) {
+	var client Client
+	var err error
+	switch config.Protocol {
+	case filterapi.GuardrailProtocolHTTP, "":
+		client = newHTTPClient(config.Endpoint)
+	case filterapi.GuardrailProtocolGRPC:
+		client, err = newGRPCClient(config.Endpoint)
+	default:
+		err = fmt.Errorf("unknown protocol: %s", config.Protocol)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newWithClient(config, client), nil
+}
+
+// newWithClient creates a new [Guardrail] calling the service with the given client.
+func newWithClient(config *filterapi.Guardrail, client Client) *Guardrail {
+	return &Guardrail{config: config, client: client}
+}
+
+// Name returns the name of the route of the guardrail.
+func (g *Guardrail) Name() string { return g.config.Name }
+
+// ChecksRequest reports whether the requests are checked.
+func (g *Guardrail) ChecksRequest() bool { return g.config.Request }
+
+// ChecksResponse reports whether the responses are checked.
+func (g *Guardrail) ChecksResponse() bool { return g.config.Response }
+
+// FailOpen reports whether the requests and the responses are let through when the guardrail cannot decide on them.
+func (g *Guardrail) FailOpen() bool { return g.config.FailOpen }
+
+// MaxResponseBytes returns the maximum size of the response held back until the guardrail decides on it. The zero
+// value means no limit.
+func (g *Guardrail) MaxResponseBytes() int { return g.config.MaxResponseBytes }
+
+// Check calls the guardrail service with the given check, which is sent with the name of the route. When the call
+// fails or the decision is invalid, this returns the allow decision if the guardrail fails open, or the error
+// otherwise. In the former case, the reason of the decision is the error.
+func (g *Guardrail) Check(ctx context.Context, check *Check) (*Decision, error) {
+	check.Route = g.config.Name
+	if g.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.config.Timeout)
+		defer cancel()
+	}
+	d, err := g.client.Check(ctx, check)
+	if err == nil {
+		err = d.validate(check)
+	}
+	if err != nil {
+		err = fmt.Errorf("guardrail %s failed: %w", g.config.Name, err)
+		if g.config.FailOpen {
+			return &Decision{Action: ActionAllow, Reason: err.Error()}, nil
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+// Close closes the client of the guardrail.
+func (g *Guardrail) Close() error {
+	return g.client.Close()
+}
+
+// Set is the set of the guardrails of the routes.
+type Set struct {
+	guardrails []*Guardrail
+	matches    filterapi.RouteRuleMatcher[*Guardrail]
+}
+
+// NewSet creates a new [Set] from the given configurations. This returns nil if there's no configuration. The
+// guardrails of the previous set whose configuration is not changed are reused so that their connections are kept
+// across the configuration updates.
+func NewSet(configs []filterapi.Guardrail, prev *Set) (*Set, error) {
+	if len(configs) == 0 {
+		return nil, nil
+	}
+	s := &Set{}
+	for i := range configs {
+		c := &configs[i]
+		var g *Guardrail
+		if prev != nil {
+			for _, pg := range prev.guardrails {
+				if reflect.DeepEqual(pg.config, c) {
+					g = pg
+					break
+				}
+			}
+		}
+		if g == nil {
+			var err error
+			if g, err = New(c); err != nil {
+				_ = s.CloseExcept(prev)
+				return nil, fmt.Errorf("cannot create guardrail %s: %w", c.Name, err)
+			}
+		}
+		s.guardrails = append(s.guardrails, g)
+		for _, m := range c.Matches {
+			s.matches.Add(m, g)
+		}
+	}
+	return s, nil
+}
+
+// Find returns the guardrail of the route matching the given model and the request headers, or nil if there's none
+// or the set is nil. When multiple rules match, the one matching the most headers is returned in the same way as the
+// precedence of the HTTPRoute rules.
+func (s *Set) Find(model string, headers map[string]string) *Guardrail {
+	if s == nil {
+		return nil
+	}
+	return s.matches.Find(model, headers)
+}
+
+// CloseExcept closes the guardrails of this set which are not reused by the given set. This is no-op if the set is
+// nil.
+func (s *Set) CloseExcept(next *Set) error {
+	if s == nil {
+		return nil
+	}
+	var errs []error
+	for _, g := range s.guardrails {
+		if next != nil && slices.Contains(next.guardrails, g) {
+			continue
+		}
+		if err := g.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close guardrail %s: %w", g.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,197 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package guardrail
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// mockClient implements [Client] for testing.
+type mockClient struct {
+	decision *Decision
+	err      error
+	// checks are the received checks.
+	checks []*Check
+	closed bool
+}
+
+// Check implements [Client.Check].
+func (m *mockClient) Check(ctx context.Context, check *Check) (*Decision, error) {
+	m.checks = append(m.checks, check)
+	if m.err != nil {
+		return nil, m.err
+	}
+	if m.decision == nil {
+		// Wait for the timeout.
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	d := *m.decision
+	return &d, nil
+}
+
+// Close implements [Client.Close].
+func (m *mockClient) Close() error {
+	m.closed = true
+	return nil
+}
+
+func TestGuardrail_Check(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		phase    Phase
+		failOpen bool
+		client   *mockClient
+		exp      *Decision
+		expErr   string
+	}{
+		{
+			name:   "allow",
+			phase:  PhaseRequest,
+			client: &mockClient{decision: &Decision{Action: ActionAllow, Reason: "ok"}},
+			exp:    &Decision{Action: ActionAllow, Reason: "ok"},
+		},
+		{
+			name:   "block with defaults",
+			phase:  PhaseResponse,
+			client: &mockClient{decision: &Decision{Action: ActionBlock}},
+			exp: &Decision{Action: ActionBlock, Status: 400, Error: &openai.ErrorType{
+				Type: BlockedErrorType, Message: "the response is blocked by the guardrail",
+			}},
+		},
+		{
+			name:   "block with custom error",
+			phase:  PhaseRequest,
+			client: &mockClient{decision: &Decision{Action: ActionBlock, Status: 403, Error: &openai.ErrorType{Type: "prompt_injection", Message: "nope"}}},
+			exp:    &Decision{Action: ActionBlock, Status: 403, Error: &openai.ErrorType{Type: "prompt_injection", Message: "nope"}},
+		},
+		{
+			name:   "block with invalid status",
+			phase:  PhaseRequest,
+			client: &mockClient{decision: &Decision{Action: ActionBlock, Status: 200}},
+			expErr: "guardrail ns/route failed: status of the block action must be an error status code: 200",
+		},
+		{
+			name:   "mutate",
+			phase:  PhaseRequest,
+			client: &mockClient{decision: &Decision{Action: ActionMutate, Request: []byte(`{"model":"gpt-4o"}`)}},
+			exp:    &Decision{Action: ActionMutate, Request: []byte(`{"model":"gpt-4o"}`)},
+		},
+		{
+			name:   "mutate changing the model",
+			phase:  PhaseRequest,
+			client: &mockClient{decision: &Decision{Action: ActionMutate, Request: []byte(`{"model":"gpt-4o-mini"}`)}},
+			expErr: `guardrail ns/route failed: model of the request must not be changed by the mutate action: "gpt-4o-mini"`,
+		},
+		{
+			name:   "mutate with invalid request",
+			phase:  PhaseRequest,
+			client: &mockClient{decision: &Decision{Action: ActionMutate, Request: []byte(`{`)}},
+			expErr: "guardrail ns/route failed: invalid request of the mutate action: unexpected end of JSON input",
+		},
+		{
+			name:   "mutate without response",
+			phase:  PhaseResponse,
+			client: &mockClient{decision: &Decision{Action: ActionMutate, Request: []byte(`{}`)}},
+			expErr: "guardrail ns/route failed: response must be set for the mutate action in the response phase",
+		},
+		{
+			name:   "unknown action",
+			phase:  PhaseRequest,
+			client: &mockClient{decision: &Decision{Action: "deny"}},
+			expErr: `guardrail ns/route failed: unknown action: "deny"`,
+		},
+		{
+			name:   "fail closed",
+			phase:  PhaseRequest,
+			client: &mockClient{err: errors.New("connection refused")},
+			expErr: "guardrail ns/route failed: connection refused",
+		},
+		{
+			name:     "fail open",
+			phase:    PhaseRequest,
+			failOpen: true,
+			client:   &mockClient{err: errors.New("connection refused")},
+			exp:      &Decision{Action: ActionAllow, Reason: "guardrail ns/route failed: connection refused"},
+		},
+		{
+			name:   "timeout",
+			phase:  PhaseRequest,
+			client: &mockClient{},
+			expErr: "guardrail ns/route failed: context deadline exceeded",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := newWithClient(&filterapi.Guardrail{Name: "ns/route", Timeout: 10 * time.Millisecond, FailOpen: tc.failOpen}, tc.client)
+			d, err := g.Check(t.Context(), &Check{Phase: tc.phase, Model: "gpt-4o", Request: []byte(`{"model":"gpt-4o"}`)})
+			if tc.expErr != "" {
+				require.EqualError(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, d)
+			require.Equal(t, "ns/route", tc.client.checks[0].Route)
+		})
+	}
+}
+
+func TestNewSet(t *testing.T) {
+	s, err := NewSet(nil, nil)
+	require.NoError(t, err)
+	require.Nil(t, s)
+	require.Nil(t, s.Find("gpt-4o", nil))
+	require.NoError(t, s.CloseExcept(nil))
+
+	_, err = NewSet([]filterapi.Guardrail{{Name: "ns/route", Protocol: "SMTP"}}, nil)
+	require.EqualError(t, err, "cannot create guardrail ns/route: unknown protocol: SMTP")
+
+	configs := []filterapi.Guardrail{
+		{
+			Name:     "ns/default",
+			Matches:  []filterapi.RouteRuleMatch{{Model: "gpt-4o"}},
+			Protocol: filterapi.GuardrailProtocolHTTP,
+			Endpoint: "http://localhost:8080/check",
+			Request:  true,
+		},
+		{
+			Name:     "ns/strict",
+			Matches:  []filterapi.RouteRuleMatch{{Model: "gpt-4o", Headers: map[string]string{"x-tenant": "acme"}}},
+			Protocol: filterapi.GuardrailProtocolGRPC,
+			Endpoint: "localhost:9090",
+			Response: true,
+		},
+	}
+	s, err = NewSet(configs, nil)
+	require.NoError(t, err)
+	g := s.Find("gpt-4o", map[string]string{"x-tenant": "other"})
+	require.NotNil(t, g)
+	require.Equal(t, "ns/default", g.Name())
+	require.True(t, g.ChecksRequest())
+	require.False(t, g.ChecksResponse())
+	require.False(t, g.FailOpen())
+	g = s.Find("gpt-4o", map[string]string{"x-tenant": "acme"})
+	require.NotNil(t, g)
+	require.Equal(t, "ns/strict", g.Name())
+	require.Nil(t, s.Find("gpt-4o-mini", nil))
+
+	// The unchanged guardrails are reused across the configuration updates.
+	changed := []filterapi.Guardrail{configs[0], configs[1]}
+	changed[1].Endpoint = "localhost:9091"
+	next, err := NewSet(changed, s)
+	require.NoError(t, err)
+	require.Same(t, s.guardrails[0], next.guardrails[0])
+	require.NotSame(t, s.guardrails[1], next.guardrails[1])
+	require.NoError(t, s.CloseExcept(next))
+	require.NoError(t, next.CloseExcept(nil))
+}
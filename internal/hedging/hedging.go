@@ -0,0 +1,89 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package hedging provides the hedging configurations of the route rules, and the estimation of the token usage of
+// the hedged requests.
+//
+// The hedged requests themselves are sent by Envoy on the per-try timeout set by the router filter, and the one whose
+// response starts first is served while the others are cancelled. Since the per-try timeout of Envoy ends at the
+// response headers, the delay is the time to the response headers rather than the time to first token. Most providers
+// send the headers of the streaming responses together with the first token, but the ones sending the headers
+// earlier are not hedged while they are slow to generate the first token.
+package hedging
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+)
+
+// PerTryTimeoutHeaderKey is the request header of Envoy to set the per-try timeout of the request in milliseconds.
+// The routes of the rules with the hedging send the hedged request on the per-try timeout instead of cancelling
+// the timed out attempt.
+const PerTryTimeoutHeaderKey = "x-envoy-upstream-rq-per-try-timeout-ms"
+
+// Set is the set of the hedging configurations of the route rules.
+type Set struct {
+	matches filterapi.RouteRuleMatcher[*filterapi.Hedging]
+}
+
+// NewSet creates a new [Set] from the given configurations. This returns nil if there's no configuration.
+func NewSet(configs []filterapi.Hedging) *Set {
+	if len(configs) == 0 {
+		return nil
+	}
+	s := &Set{}
+	for i := range configs {
+		c := &configs[i]
+		for _, m := range c.Matches {
+			s.matches.Add(m, c)
+		}
+	}
+	return s
+}
+
+// Find returns the hedging of the route rule matching the given model and the request headers, or nil if there's
+// none or the set is nil. When multiple route rules match, the one matching the most headers is returned in the same
+// way as the precedence of the HTTPRoute rules.
+func (s *Set) Find(model string, headers map[string]string) *filterapi.Hedging {
+	if s == nil {
+		return nil
+	}
+	return s.matches.Find(model, headers)
+}
+
+const (
+	// CancelledAttemptsMetadataKey is the key of the dynamic metadata with the number of the cancelled attempts,
+	// which is only set when the request has any.
+	CancelledAttemptsMetadataKey = "hedge_cancelled_attempts"
+	// EstimatedInputTokensMetadataKey is the key of the dynamic metadata with the input tokens estimated for the
+	// cancelled attempts by EstimateWithCancelledAttempts, which are included in the token costs of the request.
+	EstimatedInputTokensMetadataKey = "hedge_estimated_input_tokens"
+)
+
+// PerTryTimeout returns the value of the PerTryTimeoutHeaderKey header for the given delay.
+func PerTryTimeout(delay time.Duration) string {
+	return strconv.FormatInt(max(delay.Milliseconds(), 1), 10)
+}
+
+// EstimateWithCancelledAttempts returns the token usage of the served attempt added with the estimated usage of the
+// given number of the cancelled attempts.
+//
+// The actual usage of the cancelled attempts is never known since they are cancelled before their responses start,
+// so it is an estimate: they are assumed to have consumed the same input tokens as the served one since the providers
+// may have processed the same prompt, but no output tokens.
+func EstimateWithCancelledAttempts(served translator.LLMTokenUsage, cancelled int) translator.LLMTokenUsage {
+	if cancelled <= 0 {
+		return served
+	}
+	n := uint32(cancelled) // #nosec G115
+	ret := served
+	ret.InputTokens += n * served.InputTokens
+	ret.CachedInputTokens += n * served.CachedInputTokens
+	ret.TotalTokens += n * served.InputTokens
+	return ret
+}
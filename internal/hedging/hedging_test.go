@@ -0,0 +1,50 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package hedging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+)
+
+func TestSet_Find(t *testing.T) {
+	require.Nil(t, NewSet(nil))
+	require.Nil(t, (*Set)(nil).Find("m", nil))
+
+	s := NewSet([]filterapi.Hedging{
+		{Name: "default", Matches: []filterapi.RouteRuleMatch{{Model: "m"}}},
+		{Name: "fast", Matches: []filterapi.RouteRuleMatch{{Model: "m", Headers: map[string]string{"x-tier": "fast"}}}},
+		{Name: "other", Matches: []filterapi.RouteRuleMatch{{Model: "m2"}}},
+	})
+	require.Equal(t, "default", s.Find("m", map[string]string{}).Name)
+	require.Equal(t, "default", s.Find("m", map[string]string{"x-tier": "slow"}).Name)
+	require.Equal(t, "fast", s.Find("m", map[string]string{"x-tier": "fast"}).Name)
+	require.Equal(t, "other", s.Find("m2", nil).Name)
+	require.Nil(t, s.Find("unknown", nil))
+}
+
+func TestPerTryTimeout(t *testing.T) {
+	require.Equal(t, "300", PerTryTimeout(300*time.Millisecond))
+	require.Equal(t, "2000", PerTryTimeout(2*time.Second))
+	// A zero per-try timeout would disable the hedging.
+	require.Equal(t, "1", PerTryTimeout(0))
+}
+
+func TestEstimateWithCancelledAttempts(t *testing.T) {
+	served := translator.LLMTokenUsage{InputTokens: 100, CachedInputTokens: 20, OutputTokens: 50, TotalTokens: 150}
+	require.Equal(t, served, EstimateWithCancelledAttempts(served, 0))
+	require.Equal(t, translator.LLMTokenUsage{
+		InputTokens:       300,
+		CachedInputTokens: 60,
+		OutputTokens:      50,
+		TotalTokens:       350,
+	}, EstimateWithCancelledAttempts(served, 2))
+}
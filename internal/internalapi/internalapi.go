@@ -33,6 +33,24 @@ const (
 	XDSUpstreamHostMetadataKey = "xds.upstream_host_metadata"
 )
 
+const (
+	// FallbackRetryHeaderKey is the response header set by the upstream filter on the error responses which should be
+	// retried on the other backends. The retry policy of the routes with the fallback configured matches this header.
+	FallbackRetryHeaderKey = "x-ai-eg-fallback-retry"
+	// SelectedBackendHeaderKey is the request header set by the router filter to the name of the backend selected by
+	// the adaptive load balancing. The generated HTTPRoute matches this header to prefer the selected backend.
+	SelectedBackendHeaderKey = "x-ai-eg-selected-backend"
+	// SessionKeyHeaderKey is the request header set by the router filter to the session key of the request with
+	// the session affinity. The routes of the rules with the session affinity hash this header to select the backend.
+	SessionKeyHeaderKey = "x-ai-eg-session-key"
+	// HedgeAttemptHeaderKey is the response header set by the upstream filter to the number of the attempt of the
+	// hedged request, so that the router filter processes the response with the upstream filter of the served attempt.
+	HedgeAttemptHeaderKey = "x-ai-eg-hedge-attempt"
+	// VirtualKeyHeaderKey is the request header set by the router filter to the name of the validated virtual key.
+	// The budgets of the virtual keys are the quota policies identifying the clients by this header.
+	VirtualKeyHeaderKey = "x-ai-eg-virtual-key"
+)
+
 // PerRouteRuleRefBackendName generates a unique backend name for a per-route rule,
 // i.e., the unique identifier for a backend that is associated with a specific
 // route rule in a specific AIGatewayRoute.
@@ -11,8 +11,11 @@ package llmcostcel
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/google/cel-go/cel"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
 )
 
 const (
@@ -21,6 +24,13 @@ const (
 	celInputTokensKey  = "input_tokens"
 	celOutputTokensKey = "output_tokens"
 	celTotalTokensKey  = "total_tokens"
+	celAudioSecondsKey = "audio_seconds"
+	celCharactersKey   = "characters"
+
+	celCachedInputTokensKey = "cached_input_tokens"
+	celInputPriceKey        = "input_price"
+	celCachedInputPriceKey  = "cached_input_price"
+	celOutputPriceKey       = "output_price"
 )
 
 var env *cel.Env
@@ -33,6 +43,12 @@ func init() {
 		cel.Variable(celInputTokensKey, cel.UintType),
 		cel.Variable(celOutputTokensKey, cel.UintType),
 		cel.Variable(celTotalTokensKey, cel.UintType),
+		cel.Variable(celAudioSecondsKey, cel.UintType),
+		cel.Variable(celCharactersKey, cel.UintType),
+		cel.Variable(celCachedInputTokensKey, cel.UintType),
+		cel.Variable(celInputPriceKey, cel.DoubleType),
+		cel.Variable(celCachedInputPriceKey, cel.DoubleType),
+		cel.Variable(celOutputPriceKey, cel.DoubleType),
 	)
 	if err != nil {
 		panic(fmt.Sprintf("cannot create CEL environment: %v", err))
@@ -52,7 +68,7 @@ func NewProgram(expr string) (prog cel.Program, err error) {
 	}
 
 	// Sanity check by evaluating the expression with some dummy values.
-	_, err = EvaluateProgram(prog, "dummy", "dummy", 0, 0, 0)
+	_, err = EvaluateProgram(prog, "dummy", "dummy", 0, 0, 0, 0, 0, 0, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to evaluate CEL expression: %w", err)
 	}
@@ -60,13 +76,28 @@ func NewProgram(expr string) (prog cel.Program, err error) {
 }
 
 // EvaluateProgram evaluates the given CEL program with the given variables.
-func EvaluateProgram(prog cel.Program, modelName, backend string, inputTokens, outputTokens, totalTokens uint32) (uint64, error) {
+//
+// The price variables are in USD per million tokens and are zero when the price is nil. The double result
+// is rounded to the nearest integer, so that e.g. `input_tokens * input_price` is the cost in micro USD.
+func EvaluateProgram(prog cel.Program, modelName, backend string, inputTokens, outputTokens, totalTokens, audioSeconds, characters,
+	cachedInputTokens uint32, price *filterapi.ModelPrice,
+) (uint64, error) {
+	var p filterapi.ModelPrice
+	if price != nil {
+		p = *price
+	}
 	out, _, err := prog.Eval(map[string]interface{}{
-		celModelNameKey:    modelName,
-		celBackendKey:      backend,
-		celInputTokensKey:  inputTokens,
-		celOutputTokensKey: outputTokens,
-		celTotalTokensKey:  totalTokens,
+		celModelNameKey:         modelName,
+		celBackendKey:           backend,
+		celInputTokensKey:       inputTokens,
+		celOutputTokensKey:      outputTokens,
+		celTotalTokensKey:       totalTokens,
+		celAudioSecondsKey:      audioSeconds,
+		celCharactersKey:        characters,
+		celCachedInputTokensKey: cachedInputTokens,
+		celInputPriceKey:        p.InputPerMillionTokens,
+		celCachedInputPriceKey:  p.CachedInputPerMillionTokens,
+		celOutputPriceKey:       p.OutputPerMillionTokens,
 	})
 	if err != nil || out == nil {
 		return 0, fmt.Errorf("failed to evaluate CEL expression: %w", err)
@@ -81,7 +112,25 @@ func EvaluateProgram(prog cel.Program, modelName, backend string, inputTokens, o
 		return uint64(result), nil
 	case cel.UintType:
 		return out.Value().(uint64), nil
+	case cel.DoubleType:
+		result := math.Round(out.Value().(float64))
+		if result < 0 {
+			return 0, fmt.Errorf("CEL expression result is negative (%v)", result)
+		}
+		if math.IsNaN(result) || result >= math.MaxUint64 {
+			return 0, fmt.Errorf("CEL expression result is out of range (%v)", result)
+		}
+		return uint64(result), nil
 	default:
-		return 0, fmt.Errorf("CEL expression result is not an integer, got %v", out.Type())
+		return 0, fmt.Errorf("CEL expression result is not a number, got %v", out.Type())
 	}
 }
+
+// USDCost returns the cost in USD of the given token usage with the given price.
+// The cached input tokens are counted as a part of the input tokens.
+func USDCost(price *filterapi.ModelPrice, inputTokens, cachedInputTokens, outputTokens uint32) float64 {
+	cached := min(cachedInputTokens, inputTokens)
+	return (float64(inputTokens-cached)*price.InputPerMillionTokens +
+		float64(cached)*price.CachedInputPerMillionTokens +
+		float64(outputTokens)*price.OutputPerMillionTokens) / 1e6
+}
@@ -10,6 +10,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
 )
 
 func TestNewProgram(t *testing.T) {
@@ -28,14 +30,33 @@ func TestNewProgram(t *testing.T) {
 	t.Run("variables", func(t *testing.T) {
 		prog, err := NewProgram("model == 'cool_model' ?  input_tokens * output_tokens : total_tokens")
 		require.NoError(t, err)
-		v, err := EvaluateProgram(prog, "cool_model", "cool_backend", 100, 2, 3)
+		v, err := EvaluateProgram(prog, "cool_model", "cool_backend", 100, 2, 3, 0, 0, 0, nil)
 		require.NoError(t, err)
 		require.Equal(t, uint64(200), v)
 
-		v, err = EvaluateProgram(prog, "not_cool_model", "cool_backend", 100, 2, 3)
+		v, err = EvaluateProgram(prog, "not_cool_model", "cool_backend", 100, 2, 3, 0, 0, 0, nil)
 		require.NoError(t, err)
 		require.Equal(t, uint64(3), v)
 	})
+	t.Run("audio variables", func(t *testing.T) {
+		prog, err := NewProgram("audio_seconds * uint(2) + characters")
+		require.NoError(t, err)
+		v, err := EvaluateProgram(prog, "whisper-1", "cool_backend", 0, 0, 0, 30, 5, 0, nil)
+		require.NoError(t, err)
+		require.Equal(t, uint64(65), v)
+	})
+	t.Run("price variables", func(t *testing.T) {
+		prog, err := NewProgram("double(input_tokens - cached_input_tokens) * input_price + double(cached_input_tokens) * cached_input_price + double(output_tokens) * output_price")
+		require.NoError(t, err)
+		price := &filterapi.ModelPrice{InputPerMillionTokens: 2.5, CachedInputPerMillionTokens: 1.25, OutputPerMillionTokens: 10}
+		v, err := EvaluateProgram(prog, "gpt-4o", "cool_backend", 1000, 100, 1100, 0, 0, 400, price)
+		require.NoError(t, err)
+		require.Equal(t, uint64(3000), v) // 600*2.5 + 400*1.25 + 100*10 micro USD.
+
+		v, err = EvaluateProgram(prog, "gpt-4o", "cool_backend", 1000, 100, 1100, 0, 0, 400, nil)
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), v)
+	})
 
 	t.Run("uint", func(t *testing.T) {
 		_, err := NewProgram("uint(1)-uint(1200)")
@@ -61,13 +82,33 @@ func TestEvaluateProgram(t *testing.T) {
 	t.Run("signed integer negative", func(t *testing.T) {
 		prog, err := NewProgram("int(input_tokens) - int(output_tokens)")
 		require.NoError(t, err)
-		_, err = EvaluateProgram(prog, "cool_model", "cool_backend", 100, 2000, 3)
+		_, err = EvaluateProgram(prog, "cool_model", "cool_backend", 100, 2000, 3, 0, 0, 0, nil)
 		require.ErrorContains(t, err, "CEL expression result is negative (-1900)")
 	})
+	t.Run("double negative", func(t *testing.T) {
+		prog, err := NewProgram("double(input_tokens) * -input_price")
+		require.NoError(t, err)
+		_, err = EvaluateProgram(prog, "cool_model", "cool_backend", 100, 0, 0, 0, 0, 0, &filterapi.ModelPrice{InputPerMillionTokens: 1})
+		require.ErrorContains(t, err, "CEL expression result is negative (-100)")
+	})
+	t.Run("double rounded", func(t *testing.T) {
+		prog, err := NewProgram("double(input_tokens) * input_price")
+		require.NoError(t, err)
+		v, err := EvaluateProgram(prog, "cool_model", "cool_backend", 3, 0, 0, 0, 0, 0, &filterapi.ModelPrice{InputPerMillionTokens: 0.15})
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), v)
+		v, err = EvaluateProgram(prog, "cool_model", "cool_backend", 10, 0, 0, 0, 0, 0, &filterapi.ModelPrice{InputPerMillionTokens: 0.15})
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), v)
+	})
+	t.Run("not a number", func(t *testing.T) {
+		_, err := NewProgram("model")
+		require.ErrorContains(t, err, "CEL expression result is not a number, got string")
+	})
 	t.Run("unsigned integer overflow", func(t *testing.T) {
 		prog, err := NewProgram("input_tokens - output_tokens")
 		require.NoError(t, err)
-		_, err = EvaluateProgram(prog, "cool_model", "cool_backend", 100, 2000, 3)
+		_, err = EvaluateProgram(prog, "cool_model", "cool_backend", 100, 2000, 3, 0, 0, 0, nil)
 		require.ErrorContains(t, err, "failed to evaluate CEL expression: unsigned integer overflow")
 	})
 	t.Run("ensure concurrency safety", func(t *testing.T) {
@@ -80,7 +121,7 @@ func TestEvaluateProgram(t *testing.T) {
 		for i := 0; i < 100; i++ {
 			go func() {
 				defer wg.Done()
-				v, err := EvaluateProgram(prog, "cool_model", "cool_backend", 100, 2, 3)
+				v, err := EvaluateProgram(prog, "cool_model", "cool_backend", 100, 2, 3, 0, 0, 0, nil)
 				require.NoError(t, err)
 				require.Equal(t, uint64(200), v)
 			}()
@@ -88,3 +129,11 @@ func TestEvaluateProgram(t *testing.T) {
 		wg.Wait()
 	})
 }
+
+func TestUSDCost(t *testing.T) {
+	price := &filterapi.ModelPrice{InputPerMillionTokens: 2.5, CachedInputPerMillionTokens: 1.25, OutputPerMillionTokens: 10}
+	require.InDelta(t, 0.003, USDCost(price, 1000, 400, 100), 1e-12)
+	require.InDelta(t, 0.0035, USDCost(price, 1000, 0, 100), 1e-12)
+	// The cached input tokens more than the input tokens are capped.
+	require.InDelta(t, 0.00225, USDCost(price, 1000, 2000, 100), 1e-12)
+}
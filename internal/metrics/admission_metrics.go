@@ -0,0 +1,72 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// There's no semantic convention for the admission control, so these are AI Gateway specific.
+
+	aigwMetricAdmissionRequests  = "aigw.admission.requests"
+	aigwAttributePriority        = "aigw.priority"
+	aigwAttributeAdmissionResult = "aigw.admission.result"
+	aigwAdmissionResultAdmitted  = "admitted"
+	aigwAdmissionResultShed      = "shed"
+)
+
+// admission is the implementation for the admission control AI Gateway metrics.
+type admission struct {
+	requests                  metric.Int64Counter
+	operation                 string
+	requestHeaderLabelMapping map[string]string
+}
+
+// AdmissionMetrics is the interface for the admission control AI Gateway metrics.
+type AdmissionMetrics interface {
+	// RecordAdmission records whether the request of the given priority class is admitted on the backend or shed.
+	RecordAdmission(ctx context.Context, backend string, priority string, admitted bool, requestHeaders map[string]string)
+}
+
+// NewAdmission creates a new AdmissionMetrics instance for the chat completion requests.
+func NewAdmission(meter metric.Meter, requestHeaderLabelMapping map[string]string) AdmissionMetrics {
+	requests, err := meter.Int64Counter(aigwMetricAdmissionRequests,
+		metric.WithDescription("Number of requests admitted or shed by the admission control per priority class."),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		panic(err)
+	}
+	return &admission{
+		requests:                  requests,
+		operation:                 genaiOperationChat,
+		requestHeaderLabelMapping: requestHeaderLabelMapping,
+	}
+}
+
+// RecordAdmission implements [AdmissionMetrics.RecordAdmission].
+func (a *admission) RecordAdmission(ctx context.Context, backend string, priority string, admitted bool, requestHeaders map[string]string) {
+	result := aigwAdmissionResultShed
+	if admitted {
+		result = aigwAdmissionResultAdmitted
+	}
+	attrs := []attribute.KeyValue{
+		attribute.Key(genaiAttributeOperationName).String(a.operation),
+		attribute.Key(aigwAttributeBackendName).String(backend),
+		attribute.Key(aigwAttributePriority).String(priority),
+		attribute.Key(aigwAttributeAdmissionResult).String(result),
+	}
+	for headerName, labelName := range a.requestHeaderLabelMapping {
+		if headerValue, exists := requestHeaders[headerName]; exists {
+			attrs = append(attrs, attribute.Key(labelName).String(headerValue))
+		}
+	}
+	a.requests.Add(ctx, 1, metric.WithAttributes(attrs...))
+}
@@ -0,0 +1,58 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestAdmission_RecordAdmission(t *testing.T) {
+	mr := metric.NewManualReader()
+	meter := metric.NewMeterProvider(metric.WithReader(mr)).Meter("test")
+	a := NewAdmission(meter, map[string]string{"x-user-id": "user_id"})
+
+	headers := map[string]string{"x-user-id": "u1"}
+	a.RecordAdmission(t.Context(), "openai", "paid", true, headers)
+	a.RecordAdmission(t.Context(), "openai", "free", false, headers)
+	a.RecordAdmission(t.Context(), "openai", "free", false, headers)
+
+	var data metricdata.ResourceMetrics
+	require.NoError(t, mr.Collect(t.Context(), &data))
+	require.Len(t, data.ScopeMetrics, 1)
+	require.Len(t, data.ScopeMetrics[0].Metrics, 1)
+	m := data.ScopeMetrics[0].Metrics[0]
+	require.Equal(t, aigwMetricAdmissionRequests, m.Name)
+
+	admitted := attribute.NewSet(
+		attribute.Key(genaiAttributeOperationName).String(genaiOperationChat),
+		attribute.Key(aigwAttributeBackendName).String("openai"),
+		attribute.Key(aigwAttributePriority).String("paid"),
+		attribute.Key(aigwAttributeAdmissionResult).String(aigwAdmissionResultAdmitted),
+		attribute.Key("user_id").String("u1"),
+	)
+	shed := attribute.NewSet(
+		attribute.Key(genaiAttributeOperationName).String(genaiOperationChat),
+		attribute.Key(aigwAttributeBackendName).String("openai"),
+		attribute.Key(aigwAttributePriority).String("free"),
+		attribute.Key(aigwAttributeAdmissionResult).String(aigwAdmissionResultShed),
+		attribute.Key("user_id").String("u1"),
+	)
+	counts := map[string]int64{}
+	for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+		switch {
+		case dp.Attributes.Equals(&admitted):
+			counts["admitted"] = dp.Value
+		case dp.Attributes.Equals(&shed):
+			counts["shed"] = dp.Value
+		}
+	}
+	require.Equal(t, map[string]int64{"admitted": 1, "shed": 2}, counts)
+}
@@ -0,0 +1,69 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+// audio is the implementation for the audio transcription and speech AI Gateway metrics.
+type audio struct {
+	baseMetrics
+}
+
+// AudioMetrics is the interface for the audio transcription and speech AI Gateway metrics.
+type AudioMetrics interface {
+	// StartRequest initializes timing for a new request.
+	StartRequest(headers map[string]string)
+	// SetModel sets the model the request. This is usually called after parsing the request body .
+	SetModel(model string)
+	// SetBackend sets the selected backend when the routing decision has been made. This is usually called
+	// after parsing the request body to determine the model and invoke the routing logic.
+	SetBackend(backend *filterapi.Backend)
+
+	// RecordTokenUsage records token usage metrics. Only the token based transcription models report tokens,
+	// otherwise all the values are zero.
+	RecordTokenUsage(ctx context.Context, inputTokens, outputTokens, totalTokens uint32, requestHeaderLabelMapping map[string]string, extraAttrs ...attribute.KeyValue)
+	// RecordRequestCompletion records latency metrics for the entire request.
+	RecordRequestCompletion(ctx context.Context, success bool, requestHeaderLabelMapping map[string]string, extraAttrs ...attribute.KeyValue)
+}
+
+// NewAudioTranscription creates a new AudioMetrics instance for the audio transcription.
+func NewAudioTranscription(meter metric.Meter, requestHeaderLabelMapping map[string]string) AudioMetrics {
+	return &audio{
+		baseMetrics: newBaseMetrics(meter, genaiOperationTranscription, requestHeaderLabelMapping),
+	}
+}
+
+// NewAudioSpeech creates a new AudioMetrics instance for the audio speech.
+func NewAudioSpeech(meter metric.Meter, requestHeaderLabelMapping map[string]string) AudioMetrics {
+	return &audio{
+		baseMetrics: newBaseMetrics(meter, genaiOperationSpeech, requestHeaderLabelMapping),
+	}
+}
+
+// RecordTokenUsage implements [AudioMetrics.RecordTokenUsage].
+func (a *audio) RecordTokenUsage(ctx context.Context, inputTokens, outputTokens, totalTokens uint32, requestHeaders map[string]string, extraAttrs ...attribute.KeyValue) {
+	attrs := a.buildBaseAttributes(requestHeaders, extraAttrs...)
+
+	a.metrics.tokenUsage.Record(ctx, float64(inputTokens),
+		metric.WithAttributes(attrs...),
+		metric.WithAttributes(attribute.Key(genaiAttributeTokenType).String(genaiTokenTypeInput)),
+	)
+	a.metrics.tokenUsage.Record(ctx, float64(outputTokens),
+		metric.WithAttributes(attrs...),
+		metric.WithAttributes(attribute.Key(genaiAttributeTokenType).String(genaiTokenTypeOutput)),
+	)
+	a.metrics.tokenUsage.Record(ctx, float64(totalTokens),
+		metric.WithAttributes(attrs...),
+		metric.WithAttributes(attribute.Key(genaiAttributeTokenType).String(genaiTokenTypeTotal)),
+	)
+}
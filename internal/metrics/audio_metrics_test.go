@@ -0,0 +1,59 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestAudio_RecordTokenUsage(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		newFn     func(meter metric.Meter) AudioMetrics
+		operation string
+	}{
+		{name: "transcription", newFn: func(m metric.Meter) AudioMetrics { return NewAudioTranscription(m, nil) }, operation: genaiOperationTranscription},
+		{name: "speech", newFn: func(m metric.Meter) AudioMetrics { return NewAudioSpeech(m, nil) }, operation: genaiOperationSpeech},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mr := sdkmetric.NewManualReader()
+			meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(mr)).Meter("test")
+			am := tc.newFn(meter)
+
+			attrs := []attribute.KeyValue{
+				attribute.Key(genaiAttributeOperationName).String(tc.operation),
+				attribute.Key(genaiAttributeSystemName).String(genaiSystemOpenAI),
+				attribute.Key(genaiAttributeRequestModel).String("gpt-4o-transcribe"),
+			}
+			inputAttrs := attribute.NewSet(append(attrs, attribute.Key(genaiAttributeTokenType).String(genaiTokenTypeInput))...)
+			outputAttrs := attribute.NewSet(append(attrs, attribute.Key(genaiAttributeTokenType).String(genaiTokenTypeOutput))...)
+			totalAttrs := attribute.NewSet(append(attrs, attribute.Key(genaiAttributeTokenType).String(genaiTokenTypeTotal))...)
+
+			am.SetModel("gpt-4o-transcribe")
+			am.SetBackend(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}})
+			am.RecordTokenUsage(t.Context(), 14, 45, 59, nil)
+
+			count, sum := getHistogramValues(t, mr, genaiMetricClientTokenUsage, inputAttrs)
+			assert.Equal(t, uint64(1), count)
+			assert.Equal(t, 14.0, sum)
+
+			count, sum = getHistogramValues(t, mr, genaiMetricClientTokenUsage, outputAttrs)
+			assert.Equal(t, uint64(1), count)
+			assert.Equal(t, 45.0, sum)
+
+			count, sum = getHistogramValues(t, mr, genaiMetricClientTokenUsage, totalAttrs)
+			assert.Equal(t, uint64(1), count)
+			assert.Equal(t, 59.0, sum)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+const (
+	// There's no semantic convention for the attempts on the backends, so these are AI Gateway specific.
+
+	aigwMetricBackendAttempts          = "aigw.backend.attempts"
+	aigwAttributeBackendName           = "aigw.backend.name"
+	aigwAttributeBackendAttemptRetried = "aigw.backend.attempt.retried"
+)
+
+// backendAttempt is the implementation for the backend attempt AI Gateway metrics.
+type backendAttempt struct {
+	attempts                  metric.Int64Counter
+	operation                 string
+	requestHeaderLabelMapping map[string]string
+}
+
+// BackendAttemptMetrics is the interface for the metrics of each attempt of a request on the backends.
+// The request is attempted more than once when it is retried on the fallback backends.
+type BackendAttemptMetrics interface {
+	// RecordAttempt records the outcome of a single attempt of the request of the given model on the backend.
+	// errorType is empty when the attempt succeeded, and retried reports whether the request is retried on the other backends.
+	RecordAttempt(ctx context.Context, backend *filterapi.Backend, model string, errorType string, retried bool, requestHeaders map[string]string)
+}
+
+// NewBackendAttempt creates a new BackendAttemptMetrics instance for the chat completion requests.
+func NewBackendAttempt(meter metric.Meter, requestHeaderLabelMapping map[string]string) BackendAttemptMetrics {
+	attempts, err := meter.Int64Counter(aigwMetricBackendAttempts,
+		metric.WithDescription("Number of attempts of the requests on the backends."),
+		metric.WithUnit("{attempt}"),
+	)
+	if err != nil {
+		panic(err)
+	}
+	return &backendAttempt{
+		attempts:                  attempts,
+		operation:                 genaiOperationChat,
+		requestHeaderLabelMapping: requestHeaderLabelMapping,
+	}
+}
+
+// RecordAttempt implements [BackendAttemptMetrics.RecordAttempt].
+func (b *backendAttempt) RecordAttempt(ctx context.Context, backend *filterapi.Backend, model string, errorType string, retried bool, requestHeaders map[string]string) {
+	attrs := []attribute.KeyValue{
+		attribute.Key(genaiAttributeOperationName).String(b.operation),
+		attribute.Key(genaiAttributeSystemName).String(genaiSystemName(backend)),
+		attribute.Key(genaiAttributeRequestModel).String(model),
+		attribute.Key(aigwAttributeBackendName).String(backend.Name),
+		attribute.Key(aigwAttributeBackendAttemptRetried).Bool(retried),
+	}
+	if errorType != "" {
+		attrs = append(attrs, attribute.Key(genaiAttributeErrorType).String(errorType))
+	}
+	for headerName, labelName := range b.requestHeaderLabelMapping {
+		if headerValue, exists := requestHeaders[headerName]; exists {
+			attrs = append(attrs, attribute.Key(labelName).String(headerValue))
+		}
+	}
+	b.attempts.Add(ctx, 1, metric.WithAttributes(attrs...))
+}
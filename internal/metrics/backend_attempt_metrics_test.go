@@ -0,0 +1,65 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestBackendAttempt_RecordAttempt(t *testing.T) {
+	mr := metric.NewManualReader()
+	meter := metric.NewMeterProvider(metric.WithReader(mr)).Meter("test")
+	ba := NewBackendAttempt(meter, map[string]string{"x-user-id": "user_id"})
+
+	headers := map[string]string{"x-user-id": "u1"}
+	bedrock := &filterapi.Backend{Name: "bedrock", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock}}
+	vertex := &filterapi.Backend{Name: "vertex", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaGCPVertexAI}}
+	ba.RecordAttempt(t.Context(), bedrock, "claude", "throttled", true, headers)
+	ba.RecordAttempt(t.Context(), bedrock, "claude", "throttled", true, headers)
+	ba.RecordAttempt(t.Context(), vertex, "claude", "", false, headers)
+
+	var data metricdata.ResourceMetrics
+	require.NoError(t, mr.Collect(t.Context(), &data))
+	require.Len(t, data.ScopeMetrics, 1)
+	require.Len(t, data.ScopeMetrics[0].Metrics, 1)
+	m := data.ScopeMetrics[0].Metrics[0]
+	require.Equal(t, aigwMetricBackendAttempts, m.Name)
+
+	throttled := attribute.NewSet(
+		attribute.Key(genaiAttributeOperationName).String(genaiOperationChat),
+		attribute.Key(genaiAttributeSystemName).String(genAISystemAWSBedrock),
+		attribute.Key(genaiAttributeRequestModel).String("claude"),
+		attribute.Key(aigwAttributeBackendName).String("bedrock"),
+		attribute.Key(aigwAttributeBackendAttemptRetried).Bool(true),
+		attribute.Key(genaiAttributeErrorType).String("throttled"),
+		attribute.Key("user_id").String("u1"),
+	)
+	succeeded := attribute.NewSet(
+		attribute.Key(genaiAttributeOperationName).String(genaiOperationChat),
+		attribute.Key(genaiAttributeSystemName).String("vertex"),
+		attribute.Key(genaiAttributeRequestModel).String("claude"),
+		attribute.Key(aigwAttributeBackendName).String("vertex"),
+		attribute.Key(aigwAttributeBackendAttemptRetried).Bool(false),
+		attribute.Key("user_id").String("u1"),
+	)
+	counts := map[string]int64{}
+	for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+		switch {
+		case dp.Attributes.Equals(&throttled):
+			counts["throttled"] = dp.Value
+		case dp.Attributes.Equals(&succeeded):
+			counts["succeeded"] = dp.Value
+		}
+	}
+	require.Equal(t, map[string]int64{"throttled": 2, "succeeded": 1}, counts)
+}
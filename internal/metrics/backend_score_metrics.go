@@ -0,0 +1,40 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// There's no semantic convention for the adaptive load balancing, so these are AI Gateway specific.
+
+	aigwMetricBackendScore     = "aigw.backend.score"
+	aigwAttributeRouteRuleName = "aigw.route.rule"
+)
+
+// RegisterBackendScores registers the gauge of the current scores of the backends of the route rules with the adaptive
+// load balancing. The scores are reported by the given function when the metrics are collected.
+func RegisterBackendScores(meter metric.Meter, scores func(f func(rule, backend string, score float64))) {
+	_, err := meter.Float64ObservableGauge(aigwMetricBackendScore,
+		metric.WithDescription("Current score of the backends selected by the adaptive load balancing. The lower, the more likely selected."),
+		metric.WithFloat64Callback(func(_ context.Context, o metric.Float64Observer) error {
+			scores(func(rule, backend string, score float64) {
+				o.Observe(score, metric.WithAttributes(
+					attribute.Key(aigwAttributeRouteRuleName).String(rule),
+					attribute.Key(aigwAttributeBackendName).String(backend),
+				))
+			})
+			return nil
+		}),
+	)
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestRegisterBackendScores(t *testing.T) {
+	mr := metric.NewManualReader()
+	meter := metric.NewMeterProvider(metric.WithReader(mr)).Meter("test")
+	scores := map[string]float64{"a": 0.5, "b": 2}
+	RegisterBackendScores(meter, func(f func(rule, backend string, score float64)) {
+		for backend, score := range scores {
+			f("ns/route/rule/0", backend, score)
+		}
+	})
+
+	collect := func() map[string]float64 {
+		var data metricdata.ResourceMetrics
+		require.NoError(t, mr.Collect(t.Context(), &data))
+		require.Len(t, data.ScopeMetrics, 1)
+		require.Len(t, data.ScopeMetrics[0].Metrics, 1)
+		m := data.ScopeMetrics[0].Metrics[0]
+		require.Equal(t, aigwMetricBackendScore, m.Name)
+		ret := map[string]float64{}
+		for _, dp := range m.Data.(metricdata.Gauge[float64]).DataPoints {
+			rule, _ := dp.Attributes.Value(attribute.Key(aigwAttributeRouteRuleName))
+			require.Equal(t, "ns/route/rule/0", rule.AsString())
+			backend, _ := dp.Attributes.Value(attribute.Key(aigwAttributeBackendName))
+			ret[backend.AsString()] = dp.Value
+		}
+		return ret
+	}
+	require.Equal(t, map[string]float64{"a": 0.5, "b": 2}, collect())
+
+	// The scores are reported at each collection.
+	scores["a"] = 1
+	require.Equal(t, map[string]float64{"a": 1, "b": 2}, collect())
+}
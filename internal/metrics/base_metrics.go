@@ -49,13 +49,21 @@ func (b *baseMetrics) SetModel(model string) {
 // SetBackend sets the name of the backend to be reported in the metrics according to:
 // https://opentelemetry.io/docs/specs/semconv/attributes-registry/gen-ai/#gen-ai-system
 func (b *baseMetrics) SetBackend(backend *filterapi.Backend) {
+	b.backend = genaiSystemName(backend)
+}
+
+// genaiSystemName returns the gen_ai.system.name of the backend, which falls back to the backend name
+// when there's no well-known value for the schema of the backend.
+func genaiSystemName(backend *filterapi.Backend) string {
 	switch backend.Schema.Name {
 	case filterapi.APISchemaOpenAI:
-		b.backend = genaiSystemOpenAI
+		return genaiSystemOpenAI
 	case filterapi.APISchemaAWSBedrock:
-		b.backend = genAISystemAWSBedrock
+		return genAISystemAWSBedrock
+	case filterapi.APISchemaAnthropic:
+		return genAISystemAnthropic
 	default:
-		b.backend = backend.Name
+		return backend.Name
 	}
 }
 
@@ -79,6 +87,11 @@ func (b *baseMetrics) buildBaseAttributes(headers map[string]string, extraAttrs
 	return attrs
 }
 
+// RecordCost records the cost of the request in USD.
+func (b *baseMetrics) RecordCost(ctx context.Context, costUSD float64, requestHeaders map[string]string, extraAttrs ...attribute.KeyValue) {
+	b.metrics.cost.Add(ctx, costUSD, metric.WithAttributes(b.buildBaseAttributes(requestHeaders, extraAttrs...)...))
+}
+
 // RecordRequestCompletion records the completion of a request with success/failure status.
 func (b *baseMetrics) RecordRequestCompletion(ctx context.Context, success bool, requestHeaders map[string]string, extraAttrs ...attribute.KeyValue) {
 	attrs := b.buildBaseAttributes(requestHeaders, extraAttrs...)
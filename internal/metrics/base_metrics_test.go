@@ -0,0 +1,32 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestBaseMetrics_SetBackend(t *testing.T) {
+	for _, tc := range []struct {
+		schema filterapi.APISchemaName
+		exp    string
+	}{
+		{schema: filterapi.APISchemaOpenAI, exp: genaiSystemOpenAI},
+		{schema: filterapi.APISchemaAWSBedrock, exp: genAISystemAWSBedrock},
+		{schema: filterapi.APISchemaAnthropic, exp: genAISystemAnthropic},
+		{schema: filterapi.APISchemaGCPVertexAI, exp: "some-backend"},
+	} {
+		t.Run(string(tc.schema), func(t *testing.T) {
+			var b baseMetrics
+			b.SetBackend(&filterapi.Backend{Name: "some-backend", Schema: filterapi.VersionedAPISchema{Name: tc.schema}})
+			require.Equal(t, tc.exp, b.backend)
+		})
+	}
+}
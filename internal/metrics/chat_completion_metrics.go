@@ -36,6 +36,8 @@ type ChatCompletionMetrics interface {
 
 	// RecordTokenUsage records token usage metrics.
 	RecordTokenUsage(ctx context.Context, inputTokens, outputTokens, totalTokens uint32, requestHeaderLabelMapping map[string]string, extraAttrs ...attribute.KeyValue)
+	// RecordCost records the cost of the request in USD.
+	RecordCost(ctx context.Context, costUSD float64, requestHeaderLabelMapping map[string]string, extraAttrs ...attribute.KeyValue)
 	// RecordRequestCompletion records latency metrics for the entire request.
 	RecordRequestCompletion(ctx context.Context, success bool, requestHeaderLabelMapping map[string]string, extraAttrs ...attribute.KeyValue)
 	// RecordTokenLatency records latency metrics for token generation.
@@ -53,6 +55,14 @@ func NewChatCompletion(meter metric.Meter, requestHeaderLabelMapping map[string]
 	}
 }
 
+// NewTextCompletion creates a new x.ChatCompletionMetrics instance for the legacy completions. This shares the implementation
+// with the chat completion since both have the same token semantics including the streaming, and only differs in the operation name.
+func NewTextCompletion(meter metric.Meter, requestHeaderLabelMapping map[string]string) ChatCompletionMetrics {
+	return &chatCompletion{
+		baseMetrics: newBaseMetrics(meter, genaiOperationTextCompletion, requestHeaderLabelMapping),
+	}
+}
+
 // StartRequest initializes timing for a new request.
 func (c *chatCompletion) StartRequest(headers map[string]string) {
 	c.baseMetrics.StartRequest(headers)
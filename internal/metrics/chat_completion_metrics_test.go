@@ -80,6 +80,68 @@ func TestRecordTokenUsage(t *testing.T) {
 	assert.Equal(t, 15.0, sum)
 }
 
+func TestRecordCost(t *testing.T) {
+	var (
+		mr    = metric.NewManualReader()
+		meter = metric.NewMeterProvider(metric.WithReader(mr)).Meter("test")
+		pm    = NewChatCompletion(meter, map[string]string{"x-team": "team"}).(*chatCompletion)
+
+		attrs = attribute.NewSet(
+			attribute.Key(genaiAttributeOperationName).String(genaiOperationChat),
+			attribute.Key(genaiAttributeSystemName).String(genaiSystemOpenAI),
+			attribute.Key(genaiAttributeRequestModel).String("gpt-4o"),
+			attribute.Key("team").String("a"),
+		)
+	)
+
+	pm.SetModel("gpt-4o")
+	pm.SetBackend(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}})
+	pm.RecordCost(t.Context(), 0.25, map[string]string{"x-team": "a"})
+	pm.RecordCost(t.Context(), 0.5, map[string]string{"x-team": "a"})
+
+	var data metricdata.ResourceMetrics
+	require.NoError(t, mr.Collect(t.Context(), &data))
+	var sum float64
+	var found bool
+	for _, sm := range data.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != aigwMetricUsageCost {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[float64]).DataPoints {
+				if dp.Attributes.Equals(&attrs) {
+					sum, found = dp.Value, true
+				}
+			}
+		}
+	}
+	require.True(t, found)
+	require.Equal(t, 0.75, sum)
+}
+
+func TestTextCompletion_RecordTokenUsage(t *testing.T) {
+	var (
+		mr    = metric.NewManualReader()
+		meter = metric.NewMeterProvider(metric.WithReader(mr)).Meter("test")
+		pm    = NewTextCompletion(meter, nil).(*chatCompletion)
+
+		attrs = attribute.NewSet(
+			attribute.Key(genaiAttributeOperationName).String(genaiOperationTextCompletion),
+			attribute.Key(genaiAttributeSystemName).String(genaiSystemOpenAI),
+			attribute.Key(genaiAttributeRequestModel).String("codellama"),
+			attribute.Key(genaiAttributeTokenType).String(genaiTokenTypeOutput),
+		)
+	)
+
+	pm.SetModel("codellama")
+	pm.SetBackend(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}})
+	pm.RecordTokenUsage(t.Context(), 10, 5, 15, nil)
+
+	count, sum := getHistogramValues(t, mr, genaiMetricClientTokenUsage, attrs)
+	assert.Equal(t, uint64(1), count)
+	assert.Equal(t, 5.0, sum)
+}
+
 func TestRecordTokenLatency(t *testing.T) {
 	var (
 		mr    = metric.NewManualReader()
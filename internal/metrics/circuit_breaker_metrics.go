@@ -0,0 +1,34 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// There's no semantic convention for the circuit breakers, so this is AI Gateway specific.
+const aigwMetricCircuitBreakerState = "aigw.backend.circuit_breaker.state"
+
+// RegisterCircuitBreakerStates registers the gauge of the current states of the circuit breakers of the AIServiceBackends.
+// The states are reported by the given function when the metrics are collected: 0 for closed, 1 for half-open and
+// 2 for open.
+func RegisterCircuitBreakerStates(meter metric.Meter, states func(f func(backend string, state int64))) {
+	_, err := meter.Int64ObservableGauge(aigwMetricCircuitBreakerState,
+		metric.WithDescription("Current state of the circuit breakers of the backends: 0 for closed, 1 for half-open and 2 for open."),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			states(func(backend string, state int64) {
+				o.Observe(state, metric.WithAttributes(attribute.Key(aigwAttributeBackendName).String(backend)))
+			})
+			return nil
+		}),
+	)
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestRegisterCircuitBreakerStates(t *testing.T) {
+	mr := metric.NewManualReader()
+	meter := metric.NewMeterProvider(metric.WithReader(mr)).Meter("test")
+	states := map[string]int64{"a.ns": 0, "b.ns": 2}
+	RegisterCircuitBreakerStates(meter, func(f func(backend string, state int64)) {
+		for backend, state := range states {
+			f(backend, state)
+		}
+	})
+
+	collect := func() map[string]int64 {
+		var data metricdata.ResourceMetrics
+		require.NoError(t, mr.Collect(t.Context(), &data))
+		require.Len(t, data.ScopeMetrics, 1)
+		require.Len(t, data.ScopeMetrics[0].Metrics, 1)
+		m := data.ScopeMetrics[0].Metrics[0]
+		require.Equal(t, aigwMetricCircuitBreakerState, m.Name)
+		ret := map[string]int64{}
+		for _, dp := range m.Data.(metricdata.Gauge[int64]).DataPoints {
+			backend, _ := dp.Attributes.Value(attribute.Key(aigwAttributeBackendName))
+			ret[backend.AsString()] = dp.Value
+		}
+		return ret
+	}
+	require.Equal(t, map[string]int64{"a.ns": 0, "b.ns": 2}, collect())
+
+	// The states are reported at each collection.
+	states["b.ns"] = 1
+	require.Equal(t, map[string]int64{"a.ns": 0, "b.ns": 1}, collect())
+}
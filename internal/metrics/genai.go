@@ -22,14 +22,23 @@ const (
 	genaiAttributeTokenType     = "gen_ai.token.type" // #nosec G101: Potential hardcoded credentials
 	genaiAttributeErrorType     = "error.type"
 
-	genaiOperationChat      = "chat"
-	genaiOperationEmbedding = "embedding"
-	genaiSystemOpenAI       = "openai"
-	genAISystemAWSBedrock   = "aws.bedrock"
-	genaiTokenTypeInput     = "input"
-	genaiTokenTypeOutput    = "output"
-	genaiTokenTypeTotal     = "total"
-	genaiErrorTypeFallback  = "_OTHER"
+	genaiOperationChat            = "chat"
+	genaiOperationEmbedding       = "embedding"
+	genaiOperationTextCompletion  = "text_completion"
+	genaiOperationImageGeneration = "image_generation"
+	genaiOperationTranscription   = "audio_transcription"
+	genaiOperationSpeech          = "audio_speech"
+	genaiSystemOpenAI             = "openai"
+	genAISystemAWSBedrock         = "aws.bedrock"
+	genAISystemAnthropic          = "anthropic"
+	genaiTokenTypeInput           = "input"
+	genaiTokenTypeOutput          = "output"
+	genaiTokenTypeTotal           = "total"
+	genaiErrorTypeFallback        = "_OTHER"
+
+	// There's no semantic convention for the cost of the requests, so this is AI Gateway specific.
+
+	aigwMetricUsageCost = "aigw.usage.cost"
 )
 
 // genAI holds metrics according to the Semantic Conventions for Generative AI Metrics.
@@ -49,6 +58,9 @@ type genAI struct {
 	// outputTokenLatency is the latency between consecutive tokens, if supported, or by chunks/tokens otherwise, by backend, model.
 	// See: https://opentelemetry.io/docs/specs/semconv/gen-ai/gen-ai-metrics/#metric-gen_aiservertime_per_output_token
 	outputTokenLatency metric.Float64Histogram
+	// cost is the cost of the requests in USD calculated from the token usage and the price of the model at the backend.
+	// This is AI Gateway specific and recorded next to the tokenUsage.
+	cost metric.Float64Counter
 }
 
 // newGenAI creates a new genAI metrics instance.
@@ -78,6 +90,11 @@ func newGenAI(meter metric.Meter) *genAI {
 			metric.WithUnit("s"),
 			metric.WithExplicitBucketBoundaries(0.01, 0.025, 0.05, 0.075, 0.1, 0.15, 0.2, 0.3, 0.4, 0.5, 0.75, 1.0, 2.5),
 		),
+		cost: mustRegisterCounter(meter,
+			aigwMetricUsageCost,
+			metric.WithDescription("Cost of the requests calculated from the token usage and the price of the model."),
+			metric.WithUnit("USD"),
+		),
 	}
 }
 
@@ -89,3 +106,12 @@ func mustRegisterHistogram(meter metric.Meter, name string, options ...metric.Fl
 	}
 	return h
 }
+
+// mustRegisterCounter registers a counter with the meter and panics if it fails.
+func mustRegisterCounter(meter metric.Meter, name string, options ...metric.Float64CounterOption) metric.Float64Counter {
+	c, err := meter.Float64Counter(name, options...)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
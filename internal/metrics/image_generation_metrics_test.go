@@ -0,0 +1,49 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestImageGeneration_RecordTokenUsage(t *testing.T) {
+	mr := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(mr)).Meter("test")
+	im := NewImageGeneration(meter, nil).(*imageGeneration)
+
+	extra := attribute.Key("extra").String("value")
+	attrs := []attribute.KeyValue{
+		attribute.Key(genaiAttributeOperationName).String(genaiOperationImageGeneration),
+		attribute.Key(genaiAttributeSystemName).String(genaiSystemOpenAI),
+		attribute.Key(genaiAttributeRequestModel).String("gpt-image-1"),
+		extra,
+	}
+	inputAttrs := attribute.NewSet(append(attrs, attribute.Key(genaiAttributeTokenType).String(genaiTokenTypeInput))...)
+	outputAttrs := attribute.NewSet(append(attrs, attribute.Key(genaiAttributeTokenType).String(genaiTokenTypeOutput))...)
+	totalAttrs := attribute.NewSet(append(attrs, attribute.Key(genaiAttributeTokenType).String(genaiTokenTypeTotal))...)
+
+	im.SetModel("gpt-image-1")
+	im.SetBackend(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}})
+	im.RecordTokenUsage(t.Context(), 10, 4000, 4010, nil, extra)
+
+	count, sum := getHistogramValues(t, mr, genaiMetricClientTokenUsage, inputAttrs)
+	assert.Equal(t, uint64(1), count)
+	assert.Equal(t, 10.0, sum)
+
+	count, sum = getHistogramValues(t, mr, genaiMetricClientTokenUsage, outputAttrs)
+	assert.Equal(t, uint64(1), count)
+	assert.Equal(t, 4000.0, sum)
+
+	count, sum = getHistogramValues(t, mr, genaiMetricClientTokenUsage, totalAttrs)
+	assert.Equal(t, uint64(1), count)
+	assert.Equal(t, 4010.0, sum)
+}
@@ -0,0 +1,66 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// There's no semantic convention for the PII redaction, so these are AI Gateway specific.
+
+	aigwMetricPIIDetections  = "aigw.pii.detections"
+	aigwAttributePIIDetector = "aigw.pii.detector"
+	aigwAttributePIIAction   = "aigw.pii.action"
+)
+
+// pii is the implementation for the PII redaction AI Gateway metrics.
+type pii struct {
+	detections                metric.Int64Counter
+	operation                 string
+	requestHeaderLabelMapping map[string]string
+}
+
+// PIIMetrics is the interface for the PII redaction AI Gateway metrics.
+type PIIMetrics interface {
+	// RecordDetections records the number of the values detected by the given detector in a request, and the action
+	// taken on the request, i.e. "Mask" or "Reject".
+	RecordDetections(ctx context.Context, detector string, action string, count int, requestHeaders map[string]string)
+}
+
+// NewPII creates a new PIIMetrics instance for the chat completion requests.
+func NewPII(meter metric.Meter, requestHeaderLabelMapping map[string]string) PIIMetrics {
+	detections, err := meter.Int64Counter(aigwMetricPIIDetections,
+		metric.WithDescription("Number of the PII values detected in the requests per detector."),
+		metric.WithUnit("{detection}"),
+	)
+	if err != nil {
+		panic(err)
+	}
+	return &pii{
+		detections:                detections,
+		operation:                 genaiOperationChat,
+		requestHeaderLabelMapping: requestHeaderLabelMapping,
+	}
+}
+
+// RecordDetections implements [PIIMetrics.RecordDetections].
+func (p *pii) RecordDetections(ctx context.Context, detector string, action string, count int, requestHeaders map[string]string) {
+	attrs := []attribute.KeyValue{
+		attribute.Key(genaiAttributeOperationName).String(p.operation),
+		attribute.Key(aigwAttributePIIDetector).String(detector),
+		attribute.Key(aigwAttributePIIAction).String(action),
+	}
+	for headerName, labelName := range p.requestHeaderLabelMapping {
+		if headerValue, exists := requestHeaders[headerName]; exists {
+			attrs = append(attrs, attribute.Key(labelName).String(headerValue))
+		}
+	}
+	p.detections.Add(ctx, int64(count), metric.WithAttributes(attrs...))
+}
@@ -0,0 +1,56 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestPII_RecordDetections(t *testing.T) {
+	mr := metric.NewManualReader()
+	meter := metric.NewMeterProvider(metric.WithReader(mr)).Meter("test")
+	p := NewPII(meter, map[string]string{"x-user-id": "user_id"})
+
+	headers := map[string]string{"x-user-id": "u1"}
+	p.RecordDetections(t.Context(), "email", "Mask", 2, headers)
+	p.RecordDetections(t.Context(), "email", "Mask", 1, headers)
+	p.RecordDetections(t.Context(), "credit_card", "Reject", 1, headers)
+
+	var data metricdata.ResourceMetrics
+	require.NoError(t, mr.Collect(t.Context(), &data))
+	require.Len(t, data.ScopeMetrics, 1)
+	require.Len(t, data.ScopeMetrics[0].Metrics, 1)
+	m := data.ScopeMetrics[0].Metrics[0]
+	require.Equal(t, aigwMetricPIIDetections, m.Name)
+
+	email := attribute.NewSet(
+		attribute.Key(genaiAttributeOperationName).String(genaiOperationChat),
+		attribute.Key(aigwAttributePIIDetector).String("email"),
+		attribute.Key(aigwAttributePIIAction).String("Mask"),
+		attribute.Key("user_id").String("u1"),
+	)
+	creditCard := attribute.NewSet(
+		attribute.Key(genaiAttributeOperationName).String(genaiOperationChat),
+		attribute.Key(aigwAttributePIIDetector).String("credit_card"),
+		attribute.Key(aigwAttributePIIAction).String("Reject"),
+		attribute.Key("user_id").String("u1"),
+	)
+	counts := map[string]int64{}
+	for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+		switch {
+		case dp.Attributes.Equals(&email):
+			counts["email"] = dp.Value
+		case dp.Attributes.Equals(&creditCard):
+			counts["credit_card"] = dp.Value
+		}
+	}
+	require.Equal(t, map[string]int64{"email": 3, "credit_card": 1}, counts)
+}
@@ -0,0 +1,85 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// There's no semantic convention for the response cache, so these are AI Gateway specific.
+
+	aigwMetricResponseCacheLookups   = "aigw.response_cache.lookups"
+	aigwAttributeResponseCacheResult = "aigw.response_cache.result"
+	aigwAttributeResponseCacheType   = "aigw.response_cache.type"
+	aigwResponseCacheResultHit       = "hit"
+	aigwResponseCacheResultMiss      = "miss"
+	aigwResponseCacheTypeExact       = "exact"
+	aigwResponseCacheTypeSemantic    = "semantic"
+)
+
+// responseCache is the implementation for the response cache AI Gateway metrics.
+type responseCache struct {
+	lookups                   metric.Int64Counter
+	operation                 string
+	requestHeaderLabelMapping map[string]string
+}
+
+// ResponseCacheMetrics is the interface for the response cache AI Gateway metrics.
+type ResponseCacheMetrics interface {
+	// RecordLookup records the result of the exact-match response cache lookup for the request of the given model.
+	RecordLookup(ctx context.Context, hit bool, model string, requestHeaders map[string]string)
+	// RecordSemanticLookup records the result of the semantic response cache lookup for the request of the given model.
+	RecordSemanticLookup(ctx context.Context, hit bool, model string, requestHeaders map[string]string)
+}
+
+// NewResponseCache creates a new ResponseCacheMetrics instance for the chat completion responses.
+func NewResponseCache(meter metric.Meter, requestHeaderLabelMapping map[string]string) ResponseCacheMetrics {
+	lookups, err := meter.Int64Counter(aigwMetricResponseCacheLookups,
+		metric.WithDescription("Number of response cache lookups by the result."),
+		metric.WithUnit("{lookup}"),
+	)
+	if err != nil {
+		panic(err)
+	}
+	return &responseCache{
+		lookups:                   lookups,
+		operation:                 genaiOperationChat,
+		requestHeaderLabelMapping: requestHeaderLabelMapping,
+	}
+}
+
+// RecordLookup implements [ResponseCacheMetrics.RecordLookup].
+func (r *responseCache) RecordLookup(ctx context.Context, hit bool, model string, requestHeaders map[string]string) {
+	r.recordLookup(ctx, aigwResponseCacheTypeExact, hit, model, requestHeaders)
+}
+
+// RecordSemanticLookup implements [ResponseCacheMetrics.RecordSemanticLookup].
+func (r *responseCache) RecordSemanticLookup(ctx context.Context, hit bool, model string, requestHeaders map[string]string) {
+	r.recordLookup(ctx, aigwResponseCacheTypeSemantic, hit, model, requestHeaders)
+}
+
+func (r *responseCache) recordLookup(ctx context.Context, cacheType string, hit bool, model string, requestHeaders map[string]string) {
+	result := aigwResponseCacheResultMiss
+	if hit {
+		result = aigwResponseCacheResultHit
+	}
+	attrs := []attribute.KeyValue{
+		attribute.Key(genaiAttributeOperationName).String(r.operation),
+		attribute.Key(genaiAttributeRequestModel).String(model),
+		attribute.Key(aigwAttributeResponseCacheType).String(cacheType),
+		attribute.Key(aigwAttributeResponseCacheResult).String(result),
+	}
+	for headerName, labelName := range r.requestHeaderLabelMapping {
+		if headerValue, exists := requestHeaders[headerName]; exists {
+			attrs = append(attrs, attribute.Key(labelName).String(headerValue))
+		}
+	}
+	r.lookups.Add(ctx, 1, metric.WithAttributes(attrs...))
+}
@@ -0,0 +1,58 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestResponseCache_RecordLookup(t *testing.T) {
+	mr := metric.NewManualReader()
+	meter := metric.NewMeterProvider(metric.WithReader(mr)).Meter("test")
+	rc := NewResponseCache(meter, map[string]string{"x-user-id": "user_id"})
+
+	headers := map[string]string{"x-user-id": "u1"}
+	rc.RecordLookup(t.Context(), true, "gpt-4o", headers)
+	rc.RecordLookup(t.Context(), false, "gpt-4o", headers)
+	rc.RecordLookup(t.Context(), true, "gpt-4o", headers)
+	rc.RecordSemanticLookup(t.Context(), true, "gpt-4o", headers)
+
+	attrsFor := func(cacheType, result string) attribute.Set {
+		return attribute.NewSet(
+			attribute.Key(genaiAttributeOperationName).String(genaiOperationChat),
+			attribute.Key(genaiAttributeRequestModel).String("gpt-4o"),
+			attribute.Key(aigwAttributeResponseCacheType).String(cacheType),
+			attribute.Key(aigwAttributeResponseCacheResult).String(result),
+			attribute.Key("user_id").String("u1"),
+		)
+	}
+
+	var data metricdata.ResourceMetrics
+	require.NoError(t, mr.Collect(t.Context(), &data))
+	counts := map[string]int64{}
+	for _, sm := range data.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != aigwMetricResponseCacheLookups {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				for _, cacheType := range []string{aigwResponseCacheTypeExact, aigwResponseCacheTypeSemantic} {
+					for _, result := range []string{aigwResponseCacheResultHit, aigwResponseCacheResultMiss} {
+						if attrs := attrsFor(cacheType, result); dp.Attributes.Equals(&attrs) {
+							counts[cacheType+"/"+result] = dp.Value
+						}
+					}
+				}
+			}
+		}
+	}
+	require.Equal(t, map[string]int64{"exact/hit": 2, "exact/miss": 1, "semantic/hit": 1}, counts)
+}
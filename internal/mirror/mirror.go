@@ -0,0 +1,389 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package mirror provides the replay of the chat completion requests to a shadow backend for the offline evaluation.
+//
+// After the response of the primary backend is served, the original request is sent to the shadow backend in the
+// background, and the pair of the primary and the shadow responses is written to a [Sink].
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/backendauth"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+	"github.com/envoyproxy/ai-gateway/internal/responsecache"
+)
+
+// maxInFlight is the maximum number of the mirrored requests in flight per route rule. The requests sampled beyond
+// this are dropped so that a slow shadow backend cannot pile up the goroutines of the filter.
+const maxInFlight = 128
+
+// Record is the pair of the primary and the shadow responses of a mirrored request written to the [Sink].
+type Record struct {
+	// Time is when the primary response completed.
+	Time time.Time `json:"time"`
+	// Rule is the name of the route rule.
+	Rule string `json:"rule"`
+	// Request is the original request in the OpenAI format.
+	Request json.RawMessage `json:"request"`
+	// Primary is the response of the primary backend served to the client.
+	Primary Response `json:"primary"`
+	// Shadow is the response of the shadow backend.
+	Shadow Response `json:"shadow"`
+}
+
+// Response is the response of either the primary or the shadow backend.
+type Response struct {
+	// Backend is the name of the backend.
+	Backend string `json:"backend"`
+	// StatusCode is the status code of the response, or zero if the request failed without a response.
+	StatusCode int `json:"status_code,omitempty"`
+	// Body is the response in the OpenAI format. The streaming responses are assembled into the non-streaming ones.
+	Body json.RawMessage `json:"body,omitempty"`
+	// Error is the error of the request, if any.
+	Error string `json:"error,omitempty"`
+	// LatencyMs is the time from sending the request to the end of the response in milliseconds.
+	LatencyMs float64 `json:"latency_ms"`
+	// Usage is the token usage of the response.
+	Usage Usage `json:"usage"`
+}
+
+// Usage is the token usage of a response.
+type Usage struct {
+	InputTokens       uint32 `json:"input_tokens"`
+	CachedInputTokens uint32 `json:"cached_input_tokens,omitempty"`
+	OutputTokens      uint32 `json:"output_tokens"`
+	TotalTokens       uint32 `json:"total_tokens"`
+}
+
+// UsageFromLLMTokenUsage converts the token usage accumulated by the translator to [Usage].
+func UsageFromLLMTokenUsage(u translator.LLMTokenUsage) Usage {
+	return Usage{
+		InputTokens:       u.InputTokens,
+		CachedInputTokens: u.CachedInputTokens,
+		OutputTokens:      u.OutputTokens,
+		TotalTokens:       u.TotalTokens,
+	}
+}
+
+// Mirror replays the requests of a single route rule to its shadow backend.
+type Mirror struct {
+	config     *filterapi.Mirror
+	endpoint   string
+	handler    backendauth.Handler
+	sink       Sink
+	httpClient *http.Client
+	logger     *slog.Logger
+	// inFlight limits the number of the mirrored requests in flight.
+	inFlight chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New creates a new [Mirror] from the given configuration.
+func New(ctx context.Context, config *filterapi.Mirror, logger *slog.Logger) (*Mirror, error) {
+	if config.Endpoint == "" {
+		return nil, fmt.Errorf("mirror endpoint is required")
+	}
+	m := &Mirror{
+		config:     config,
+		endpoint:   strings.TrimSuffix(config.Endpoint, "/"),
+		httpClient: http.DefaultClient,
+		logger:     logger.With("mirror", config.Name),
+		inFlight:   make(chan struct{}, maxInFlight),
+	}
+	// Validate the schema at the configuration time rather than on each request.
+	if _, err := m.newTranslator(); err != nil {
+		return nil, err
+	}
+	if config.Backend.Auth != nil {
+		var err error
+		m.handler, err = backendauth.NewHandler(ctx, config.Backend.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create mirror backend auth handler: %w", err)
+		}
+	}
+	var err error
+	m.sink, err = NewSink(ctx, &config.Sink)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create mirror sink: %w", err)
+	}
+	return m, nil
+}
+
+// Name returns the name of the route rule.
+func (m *Mirror) Name() string { return m.config.Name }
+
+// Sample returns true if a request is to be mirrored according to the configured percentage.
+func (m *Mirror) Sample() bool {
+	switch p := m.config.Percentage; {
+	case p <= 0:
+		return false
+	case p >= 100:
+		return true
+	default:
+		return rand.IntN(100) < p //nolint:gosec
+	}
+}
+
+// Replay sends the given original request to the shadow backend in the background, and writes the record with
+// the given primary response to the sink. This returns immediately, and the failures are only logged.
+func (m *Mirror) Replay(raw []byte, req *openai.ChatCompletionRequest, primary Response) {
+	select {
+	case m.inFlight <- struct{}{}:
+	default:
+		m.logger.Warn("dropping the mirrored request since too many are in flight")
+		return
+	}
+	m.wg.Add(1)
+	now := time.Now()
+	go func() {
+		defer func() {
+			<-m.inFlight
+			m.wg.Done()
+		}()
+		record := &Record{Time: now, Rule: m.config.Name, Request: raw, Primary: primary}
+		record.Shadow = m.send(context.Background(), raw, req)
+		if err := m.sink.Write(context.Background(), record); err != nil {
+			m.logger.Warn("failed to write the mirrored response", slog.String("error", err.Error()))
+		}
+	}()
+}
+
+// Close waits for the mirrored requests in flight, and then closes the sink.
+func (m *Mirror) Close() error {
+	m.wg.Wait()
+	return m.sink.Close()
+}
+
+// newTranslator returns a new translator for the schema of the shadow backend. The translator is created per request
+// since the translators hold the state of the request.
+func (m *Mirror) newTranslator() (translator.OpenAIChatCompletionTranslator, error) {
+	schema, override := m.config.Backend.Schema, m.config.ModelNameOverride
+	switch schema.Name {
+	case filterapi.APISchemaOpenAI:
+		return translator.NewChatCompletionOpenAIToOpenAITranslator(schema.Version, override), nil
+	case filterapi.APISchemaAWSBedrock:
+		return translator.NewChatCompletionOpenAIToAWSBedrockTranslator(override), nil
+	case filterapi.APISchemaAzureOpenAI:
+		return translator.NewChatCompletionOpenAIToAzureOpenAITranslator(schema.Version, override), nil
+	case filterapi.APISchemaGCPVertexAI:
+		return translator.NewChatCompletionOpenAIToGCPVertexAITranslator(override), nil
+	case filterapi.APISchemaGCPAnthropic:
+		return translator.NewChatCompletionOpenAIToGCPAnthropicTranslator(schema.Version, override), nil
+	case filterapi.APISchemaAnthropic:
+		return translator.NewChatCompletionOpenAIToAnthropicTranslator(schema.Version, override), nil
+	default:
+		return nil, fmt.Errorf("unsupported API schema for mirror: backend=%s", schema)
+	}
+}
+
+// send sends the request to the shadow backend in the same way as the upstream filter processes the chat completion
+// requests, and returns the response translated into the OpenAI format.
+func (m *Mirror) send(ctx context.Context, raw []byte, req *openai.ChatCompletionRequest) (ret Response) {
+	ret.Backend = m.config.Backend.Name
+	if m.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.config.Timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	defer func() {
+		ret.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	}()
+	if err := m.do(ctx, raw, req, &ret); err != nil {
+		ret.Error = err.Error()
+	}
+	return
+}
+
+// do sends the request and fills the given response.
+func (m *Mirror) do(ctx context.Context, raw []byte, req *openai.ChatCompletionRequest, ret *Response) error {
+	tr, err := m.newTranslator()
+	if err != nil {
+		return err
+	}
+	// The body mutation is forced so that the auth handlers can always see the body to be sent.
+	headerMutation, bodyMutation, err := tr.RequestBody(raw, req, true)
+	if err != nil {
+		return fmt.Errorf("failed to transform request: %w", err)
+	}
+	requestHeaders := map[string]string{":method": http.MethodPost}
+	for _, h := range headerMutation.GetSetHeaders() {
+		requestHeaders[h.Header.Key] = string(h.Header.RawValue)
+	}
+	if m.handler != nil {
+		if err = m.handler.Do(ctx, requestHeaders, headerMutation, bodyMutation); err != nil {
+			return fmt.Errorf("failed to do auth request: %w", err)
+		}
+	}
+	if b := bodyMutation.GetBody(); b != nil {
+		raw = b
+	}
+
+	var path string
+	header := http.Header{"Content-Type": []string{"application/json"}}
+	for _, h := range headerMutation.GetSetHeaders() {
+		switch key := h.Header.Key; {
+		case key == ":path":
+			path = string(h.Header.RawValue)
+		case strings.HasPrefix(key, ":"), strings.EqualFold(key, "content-length"):
+			// The pseudo headers and the content length are derived from the request itself.
+		default:
+			header.Set(key, string(h.Header.RawValue))
+		}
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, m.endpoint+path, bytes.NewReader(raw))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header = header
+
+	resp, err := m.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	ret.StatusCode = resp.StatusCode
+
+	respHeaders := map[string]string{":status": strconv.Itoa(resp.StatusCode)}
+	for k := range resp.Header {
+		respHeaders[strings.ToLower(k)] = resp.Header.Get(k)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		_, errMutation, err := tr.ResponseError(respHeaders, bytes.NewReader(respBody))
+		if err != nil {
+			return fmt.Errorf("failed to transform response error: %w", err)
+		}
+		if b := errMutation.GetBody(); b != nil {
+			respBody = b
+		}
+		ret.Body = asJSON(respBody)
+		return fmt.Errorf("shadow backend returned status %d", resp.StatusCode)
+	}
+	_, respMutation, usage, err := tr.ResponseBody(respHeaders, bytes.NewReader(respBody), true)
+	if err != nil {
+		return fmt.Errorf("failed to transform response: %w", err)
+	}
+	if b := respMutation.GetBody(); b != nil {
+		respBody = b
+	}
+	ret.Usage = UsageFromLLMTokenUsage(usage)
+	if req.Stream {
+		respBody, err = responsecache.AssembleChatCompletionStream(respBody)
+		if err != nil {
+			return fmt.Errorf("failed to assemble streaming response: %w", err)
+		}
+	}
+	ret.Body = asJSON(respBody)
+	return nil
+}
+
+// ResponseBody returns the given response body of the primary backend in the OpenAI format to be recorded.
+// The streaming response is assembled into the non-streaming one.
+func ResponseBody(body []byte, stream bool) (json.RawMessage, error) {
+	if stream {
+		var err error
+		body, err = responsecache.AssembleChatCompletionStream(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return asJSON(body), nil
+}
+
+// asJSON returns the given body as is if it is a valid JSON, or otherwise encoded as a JSON string.
+func asJSON(body []byte) json.RawMessage {
+	if len(body) == 0 || json.Valid(body) {
+		return body
+	}
+	encoded, _ := json.Marshal(string(body))
+	return encoded
+}
+
+// Set is the set of the mirrors of the route rules.
+type Set struct {
+	mirrors   []*Mirror
+	byBackend map[string]*Mirror
+}
+
+// NewSet creates a new [Set] from the given configurations. The mirrors of the previous set whose configuration
+// is not changed are reused so that the sinks are kept open across the configuration updates.
+func NewSet(ctx context.Context, configs []filterapi.Mirror, prev *Set, logger *slog.Logger) (*Set, error) {
+	if len(configs) == 0 {
+		return nil, nil
+	}
+	s := &Set{byBackend: make(map[string]*Mirror)}
+	for i := range configs {
+		c := &configs[i]
+		var m *Mirror
+		if prev != nil {
+			for _, pm := range prev.mirrors {
+				if reflect.DeepEqual(pm.config, c) {
+					m = pm
+					break
+				}
+			}
+		}
+		if m == nil {
+			var err error
+			if m, err = New(ctx, c, logger); err != nil {
+				_ = s.CloseExcept(prev)
+				return nil, fmt.Errorf("cannot create mirror %s: %w", c.Name, err)
+			}
+		}
+		s.mirrors = append(s.mirrors, m)
+		for _, b := range c.Backends {
+			s.byBackend[b] = m
+		}
+	}
+	return s, nil
+}
+
+// Find returns the mirror of the route rule to which the given primary backend belongs, or nil if there's none
+// or the set is nil.
+func (s *Set) Find(backend string) *Mirror {
+	if s == nil {
+		return nil
+	}
+	return s.byBackend[backend]
+}
+
+// CloseExcept closes the mirrors of this set which are not reused by the given set. This is no-op if the set is nil.
+func (s *Set) CloseExcept(next *Set) error {
+	if s == nil {
+		return nil
+	}
+	var errs []error
+	for _, m := range s.mirrors {
+		if next != nil && slices.Contains(next.mirrors, m) {
+			continue
+		}
+		if err := m.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close mirror %s: %w", m.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
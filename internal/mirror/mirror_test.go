@@ -0,0 +1,266 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// recordingSink is the [Sink] recording the written records for the tests.
+type recordingSink struct {
+	mu      sync.Mutex
+	records []*Record
+	closed  bool
+}
+
+func (s *recordingSink) Write(_ context.Context, r *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, r)
+	return nil
+}
+
+func (s *recordingSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func newTestMirror(t *testing.T, endpoint string, timeout time.Duration) (*Mirror, *recordingSink) {
+	m, err := New(t.Context(), &filterapi.Mirror{
+		Name:              "ns/route/rule/0",
+		Backends:          []string{"primary"},
+		Endpoint:          endpoint + "/",
+		ModelNameOverride: "candidate",
+		Percentage:        100,
+		Timeout:           timeout,
+		Backend: filterapi.Backend{
+			Name:   "shadow.ns",
+			Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI, Version: "v1"},
+			Auth:   &filterapi.BackendAuth{APIKey: &filterapi.APIKeyAuth{Key: "sk-test"}},
+		},
+		Sink: filterapi.MirrorSink{Type: filterapi.MirrorSinkTypeFile, FilePath: filepath.Join(t.TempDir(), "mirror.jsonl")},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	sink := &recordingSink{}
+	require.NoError(t, m.sink.Close())
+	m.sink = sink
+	return m, sink
+}
+
+func TestMirror_Replay(t *testing.T) {
+	primary := Response{Backend: "primary", StatusCode: 200, Body: json.RawMessage(`{"id":"primary"}`), LatencyMs: 12.5, Usage: Usage{InputTokens: 3, OutputTokens: 4, TotalTokens: 7}}
+
+	t.Run("non-streaming", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/v1/chat/completions", r.URL.Path)
+			require.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+			var req openai.ChatCompletionRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			require.Equal(t, "candidate", req.Model)
+			_, _ = w.Write([]byte(`{"id":"shadow","object":"chat.completion","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":5,"total_tokens":8}}`))
+		}))
+		defer srv.Close()
+		m, sink := newTestMirror(t, srv.URL, time.Minute)
+
+		raw := []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`)
+		var req openai.ChatCompletionRequest
+		require.NoError(t, json.Unmarshal(raw, &req))
+		m.Replay(raw, &req, primary)
+		require.NoError(t, m.Close())
+		require.True(t, sink.closed)
+
+		require.Len(t, sink.records, 1)
+		r := sink.records[0]
+		require.Equal(t, "ns/route/rule/0", r.Rule)
+		require.JSONEq(t, string(raw), string(r.Request))
+		require.Equal(t, primary, r.Primary)
+		require.Equal(t, "shadow.ns", r.Shadow.Backend)
+		require.Equal(t, 200, r.Shadow.StatusCode)
+		require.Empty(t, r.Shadow.Error)
+		require.Equal(t, Usage{InputTokens: 3, OutputTokens: 5, TotalTokens: 8}, r.Shadow.Usage)
+		require.Contains(t, string(r.Shadow.Body), `"id":"shadow"`)
+		require.Positive(t, r.Shadow.LatencyMs)
+	})
+	t.Run("streaming", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte(`data: {"id":"shadow","object":"chat.completion.chunk","model":"candidate","choices":[{"index":0,"delta":{"role":"assistant","content":"hel"}}]}
+
+data: {"id":"shadow","object":"chat.completion.chunk","model":"candidate","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}
+
+data: {"id":"shadow","object":"chat.completion.chunk","model":"candidate","choices":[],"usage":{"prompt_tokens":1,"completion_tokens":2,"total_tokens":3}}
+
+data: [DONE]
+
+`))
+		}))
+		defer srv.Close()
+		m, sink := newTestMirror(t, srv.URL, time.Minute)
+
+		raw := []byte(`{"model":"gpt-4o","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+		var req openai.ChatCompletionRequest
+		require.NoError(t, json.Unmarshal(raw, &req))
+		m.Replay(raw, &req, primary)
+		require.NoError(t, m.Close())
+
+		require.Len(t, sink.records, 1)
+		shadow := sink.records[0].Shadow
+		require.Empty(t, shadow.Error)
+		require.Equal(t, Usage{InputTokens: 1, OutputTokens: 2, TotalTokens: 3}, shadow.Usage)
+		var resp openai.ChatCompletionResponse
+		require.NoError(t, json.Unmarshal(shadow.Body, &resp))
+		require.Equal(t, "hello", *resp.Choices[0].Message.Content)
+	})
+	t.Run("errors", func(t *testing.T) {
+		for _, tc := range []struct {
+			name      string
+			status    int
+			body      string
+			timeout   time.Duration
+			expStatus int
+			expErr    string
+			expBody   string
+		}{
+			{name: "status", status: http.StatusTooManyRequests, body: `{"error":{"message":"slow down"}}`, expStatus: 429, expErr: "shadow backend returned status 429", expBody: `{"error":{"message":"slow down"}}`},
+			{name: "invalid body", status: http.StatusOK, body: "{", expStatus: 200, expErr: "failed to transform response"},
+			{name: "timeout", status: http.StatusOK, body: `{}`, timeout: time.Millisecond, expErr: "context deadline exceeded"},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				release := make(chan struct{})
+				srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					if tc.timeout > 0 {
+						<-release
+					}
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(tc.status)
+					_, _ = w.Write([]byte(tc.body))
+				}))
+				defer srv.Close()
+				defer close(release)
+				m, sink := newTestMirror(t, srv.URL, tc.timeout)
+
+				raw := []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`)
+				var req openai.ChatCompletionRequest
+				require.NoError(t, json.Unmarshal(raw, &req))
+				m.Replay(raw, &req, primary)
+				require.NoError(t, m.Close())
+
+				require.Len(t, sink.records, 1)
+				shadow := sink.records[0].Shadow
+				require.Equal(t, tc.expStatus, shadow.StatusCode)
+				require.Contains(t, shadow.Error, tc.expErr)
+				if tc.expBody != "" {
+					require.JSONEq(t, tc.expBody, string(shadow.Body))
+				}
+			})
+		}
+	})
+}
+
+func TestMirror_Sample(t *testing.T) {
+	for _, tc := range []struct {
+		percentage int
+		min, max   int
+	}{
+		{percentage: 0, min: 0, max: 0},
+		{percentage: 100, min: 1000, max: 1000},
+		{percentage: 30, min: 200, max: 400},
+	} {
+		m := &Mirror{config: &filterapi.Mirror{Percentage: tc.percentage}}
+		var sampled int
+		for range 1000 {
+			if m.Sample() {
+				sampled++
+			}
+		}
+		require.GreaterOrEqual(t, sampled, tc.min, "percentage=%d", tc.percentage)
+		require.LessOrEqual(t, sampled, tc.max, "percentage=%d", tc.percentage)
+	}
+}
+
+func TestNew_errors(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, tc := range []struct {
+		name   string
+		config filterapi.Mirror
+		expErr string
+	}{
+		{name: "no endpoint", expErr: "mirror endpoint is required"},
+		{
+			name:   "unsupported schema",
+			config: filterapi.Mirror{Endpoint: "http://localhost", Backend: filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: "Unknown"}}},
+			expErr: "unsupported API schema for mirror",
+		},
+		{
+			name: "unknown sink",
+			config: filterapi.Mirror{
+				Endpoint: "http://localhost",
+				Backend:  filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}},
+				Sink:     filterapi.MirrorSink{Type: "Unknown"},
+			},
+			expErr: `cannot create mirror sink: unknown mirror sink type: "Unknown"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New(t.Context(), &tc.config, logger)
+			require.ErrorContains(t, err, tc.expErr)
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dir := t.TempDir()
+	newConfig := func(name, backend, file string) filterapi.Mirror {
+		return filterapi.Mirror{
+			Name:       name,
+			Backends:   []string{backend},
+			Endpoint:   "http://localhost",
+			Percentage: 100,
+			Backend:    filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}},
+			Sink:       filterapi.MirrorSink{Type: filterapi.MirrorSinkTypeFile, FilePath: filepath.Join(dir, file)},
+		}
+	}
+
+	s, err := NewSet(t.Context(), nil, nil, logger)
+	require.NoError(t, err)
+	require.Nil(t, s)
+	require.Nil(t, s.Find("a"))
+	require.NoError(t, s.CloseExcept(nil))
+
+	s, err = NewSet(t.Context(), []filterapi.Mirror{newConfig("rule-a", "a", "a.jsonl"), newConfig("rule-b", "b", "b.jsonl")}, nil, logger)
+	require.NoError(t, err)
+	require.Equal(t, "rule-a", s.Find("a").Name())
+	require.Equal(t, "rule-b", s.Find("b").Name())
+	require.Nil(t, s.Find("c"))
+
+	// The unchanged mirror is reused while the changed one is recreated.
+	next, err := NewSet(t.Context(), []filterapi.Mirror{newConfig("rule-a", "a", "a.jsonl"), newConfig("rule-b", "b", "b2.jsonl")}, s, logger)
+	require.NoError(t, err)
+	require.Same(t, s.Find("a"), next.Find("a"))
+	require.NotSame(t, s.Find("b"), next.Find("b"))
+	require.NoError(t, s.CloseExcept(next))
+	require.NoError(t, next.CloseExcept(nil))
+
+	_, err = NewSet(t.Context(), []filterapi.Mirror{newConfig("rule-a", "a", "a.jsonl"), newConfig("rule-c", "c", "no-such-dir/c.jsonl")}, nil, logger)
+	require.ErrorContains(t, err, "cannot create mirror rule-c")
+}
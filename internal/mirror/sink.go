@@ -0,0 +1,132 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+// Sink is where the [Record]s of the mirrored requests are written.
+type Sink interface {
+	// Write writes the given record. This is called concurrently.
+	Write(ctx context.Context, r *Record) error
+	// Close flushes and closes the sink.
+	Close() error
+}
+
+// NewSink creates a new [Sink] from the given configuration.
+func NewSink(ctx context.Context, config *filterapi.MirrorSink) (Sink, error) {
+	switch config.Type {
+	case filterapi.MirrorSinkTypeFile:
+		return newFileSink(config.FilePath)
+	case filterapi.MirrorSinkTypeOTLPLogs:
+		return newOTLPLogsSink(ctx, config.OTLPEndpoint)
+	default:
+		return nil, fmt.Errorf("unknown mirror sink type: %q", config.Type)
+	}
+}
+
+// fileSink is the [Sink] appending the records to a file as JSON lines.
+type fileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func newFileSink(path string) (*fileSink, error) {
+	if path == "" {
+		return nil, fmt.Errorf("file path is required")
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	return &fileSink{file: f}, nil
+}
+
+// Write implements [Sink.Write].
+func (s *fileSink) Write(_ context.Context, r *Record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to encode record: %w", err)
+	}
+	line = append(line, '\n')
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.file.Write(line)
+	return err
+}
+
+// Close implements [Sink.Close].
+func (s *fileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// otlpLogsEventName is the event name of the log records exported by the OTLPLogs sink.
+const otlpLogsEventName = "aigw.mirror"
+
+// otlpLogsSink is the [Sink] exporting the records as OpenTelemetry log records. The body of each log record is
+// the JSON-encoded record, and the attributes are the fields commonly used to filter and aggregate the records.
+type otlpLogsSink struct {
+	provider *sdklog.LoggerProvider
+	logger   otellog.Logger
+}
+
+func newOTLPLogsSink(ctx context.Context, endpoint string) (*otlpLogsSink, error) {
+	var opts []otlploghttp.Option
+	if endpoint != "" {
+		opts = append(opts, otlploghttp.WithEndpointURL(endpoint))
+	}
+	exporter, err := otlploghttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP logs exporter: %w", err)
+	}
+	return newOTLPLogsSinkWithExporter(exporter), nil
+}
+
+func newOTLPLogsSinkWithExporter(exporter sdklog.Exporter) *otlpLogsSink {
+	provider := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)))
+	return &otlpLogsSink{provider: provider, logger: provider.Logger("envoyproxy/ai-gateway")}
+}
+
+// Write implements [Sink.Write].
+func (s *otlpLogsSink) Write(ctx context.Context, r *Record) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to encode record: %w", err)
+	}
+	var rec otellog.Record
+	rec.SetEventName(otlpLogsEventName)
+	rec.SetTimestamp(r.Time)
+	rec.SetSeverity(otellog.SeverityInfo)
+	rec.SetBody(otellog.StringValue(string(body)))
+	rec.AddAttributes(
+		otellog.String("aigw.mirror.rule", r.Rule),
+		otellog.String("aigw.mirror.primary.backend", r.Primary.Backend),
+		otellog.Float64("aigw.mirror.primary.latency_ms", r.Primary.LatencyMs),
+		otellog.String("aigw.mirror.shadow.backend", r.Shadow.Backend),
+		otellog.Int("aigw.mirror.shadow.status_code", r.Shadow.StatusCode),
+		otellog.Float64("aigw.mirror.shadow.latency_ms", r.Shadow.LatencyMs),
+	)
+	s.logger.Emit(ctx, rec)
+	return nil
+}
+
+// Close implements [Sink.Close].
+func (s *otlpLogsSink) Close() error {
+	return s.provider.Shutdown(context.Background())
+}
@@ -0,0 +1,106 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+var testRecord = &Record{
+	Time:    time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	Rule:    "ns/route/rule/0",
+	Request: json.RawMessage(`{"model":"gpt-4o"}`),
+	Primary: Response{Backend: "primary", StatusCode: 200, Body: json.RawMessage(`{"id":"a"}`), LatencyMs: 10, Usage: Usage{InputTokens: 1, OutputTokens: 2, TotalTokens: 3}},
+	Shadow:  Response{Backend: "shadow", StatusCode: 500, Error: "shadow backend returned status 500", LatencyMs: 20},
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mirror.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("existing\n"), 0o600))
+	s, err := NewSink(t.Context(), &filterapi.MirrorSink{Type: filterapi.MirrorSinkTypeFile, FilePath: path})
+	require.NoError(t, err)
+	require.NoError(t, s.Write(t.Context(), testRecord))
+	require.NoError(t, s.Write(t.Context(), testRecord))
+	require.NoError(t, s.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := bytes.Split(bytes.TrimSpace(content), []byte("\n"))
+	require.Len(t, lines, 3)
+	require.Equal(t, "existing", string(lines[0]))
+	require.JSONEq(t, `{
+  "time": "2025-01-02T03:04:05Z",
+  "rule": "ns/route/rule/0",
+  "request": {"model": "gpt-4o"},
+  "primary": {"backend": "primary", "status_code": 200, "body": {"id": "a"}, "latency_ms": 10, "usage": {"input_tokens": 1, "output_tokens": 2, "total_tokens": 3}},
+  "shadow": {"backend": "shadow", "status_code": 500, "error": "shadow backend returned status 500", "latency_ms": 20, "usage": {"input_tokens": 0, "output_tokens": 0, "total_tokens": 0}}
+}`, string(lines[1]))
+
+	_, err = NewSink(t.Context(), &filterapi.MirrorSink{Type: filterapi.MirrorSinkTypeFile})
+	require.ErrorContains(t, err, "file path is required")
+}
+
+// recordingExporter is the [sdklog.Exporter] recording the exported log records for the tests.
+type recordingExporter struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (e *recordingExporter) Export(_ context.Context, records []sdklog.Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range records {
+		e.records = append(e.records, r.Clone())
+	}
+	return nil
+}
+
+func (e *recordingExporter) Shutdown(context.Context) error   { return nil }
+func (e *recordingExporter) ForceFlush(context.Context) error { return nil }
+
+func TestOTLPLogsSink(t *testing.T) {
+	exporter := &recordingExporter{}
+	s := newOTLPLogsSinkWithExporter(exporter)
+	require.NoError(t, s.Write(t.Context(), testRecord))
+	// Closing the sink flushes the batched records.
+	require.NoError(t, s.Close())
+
+	require.Len(t, exporter.records, 1)
+	r := exporter.records[0]
+	require.Equal(t, otlpLogsEventName, r.EventName())
+	require.Equal(t, testRecord.Time, r.Timestamp())
+	var body Record
+	require.NoError(t, json.Unmarshal([]byte(r.Body().AsString()), &body))
+	require.Equal(t, testRecord.Rule, body.Rule)
+	require.Equal(t, testRecord.Shadow, body.Shadow)
+
+	attrs := map[string]otellog.Value{}
+	r.WalkAttributes(func(kv otellog.KeyValue) bool {
+		attrs[kv.Key] = kv.Value
+		return true
+	})
+	require.Equal(t, "ns/route/rule/0", attrs["aigw.mirror.rule"].AsString())
+	require.Equal(t, "primary", attrs["aigw.mirror.primary.backend"].AsString())
+	require.Equal(t, "shadow", attrs["aigw.mirror.shadow.backend"].AsString())
+	require.Equal(t, int64(500), attrs["aigw.mirror.shadow.status_code"].AsInt64())
+	require.InDelta(t, 20.0, attrs["aigw.mirror.shadow.latency_ms"].AsFloat64(), 0)
+
+	_, err := NewSink(t.Context(), &filterapi.MirrorSink{Type: filterapi.MirrorSinkTypeOTLPLogs, OTLPEndpoint: "http://localhost:4318/v1/logs"})
+	require.NoError(t, err)
+}
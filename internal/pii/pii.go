@@ -0,0 +1,409 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package pii provides the detection and the redaction of the personally identifiable information (PII) in the
+// chat completion, completion, Responses API and Anthropic Messages API requests of the routes with the PII redaction.
+//
+// The detected values are either masked with the placeholders such as "[EMAIL_1]", which are restored in the response
+// by [Restorer], or the requests containing them are rejected.
+package pii
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/sjson"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+var (
+	emailRegex       = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`)
+	phoneNumberRegex = regexp.MustCompile(`(?:\+\d{1,3}[ .\-]?)?(?:\(\d{1,4}\)[ .\-]?)?\d(?:[ .\-]?\d){6,14}`)
+	creditCardRegex  = regexp.MustCompile(`\d(?:[ \-]?\d){12,18}`)
+	nationalIDRegex  = regexp.MustCompile(`\d{3}-\d{2}-\d{4}`)
+)
+
+// Set is the set of the PII redactions of the routes.
+type Set struct {
+	matches filterapi.RouteRuleMatcher[*Redactor]
+}
+
+// NewSet creates a new [Set] from the given configurations. This returns nil if there's no configuration.
+func NewSet(configs []filterapi.PIIRedaction) (*Set, error) {
+	if len(configs) == 0 {
+		return nil, nil
+	}
+	s := &Set{}
+	for i := range configs {
+		c := &configs[i]
+		r, err := NewRedactor(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PII redaction %s: %w", c.Name, err)
+		}
+		for _, m := range c.Matches {
+			s.matches.Add(m, r)
+		}
+	}
+	return s, nil
+}
+
+// Find returns the redactor of the route matching the given model and the request headers, or nil if there's none
+// or the set is nil. When multiple rules match, the one matching the most headers is returned in the same way as the
+// precedence of the HTTPRoute rules.
+func (s *Set) Find(model string, headers map[string]string) *Redactor {
+	if s == nil {
+		return nil
+	}
+	return s.matches.Find(model, headers)
+}
+
+// Redactor detects and redacts the PII in the requests of a route.
+type Redactor struct {
+	action    filterapi.PIIRedactionAction
+	detectors []detector
+}
+
+// detector is a detector of the PII.
+type detector struct {
+	name string
+	// placeholder is the prefix of the placeholders of the masked values, e.g. "EMAIL" for "[EMAIL_1]".
+	placeholder string
+	regex       *regexp.Regexp
+	// valid reports whether the match of the regex is the PII, or nil if all the matches are. The matches of the
+	// built-in detectors with valid are also required not to be a part of a longer word or number.
+	valid func(s string) bool
+}
+
+// NewRedactor creates a new [Redactor] from the given configuration.
+func NewRedactor(config *filterapi.PIIRedaction) (*Redactor, error) {
+	r := &Redactor{action: config.Action}
+	if r.action == "" {
+		r.action = filterapi.PIIRedactionActionMask
+	}
+	for _, d := range config.Detectors {
+		det := detector{name: d.Name, placeholder: strings.ToUpper(d.Name)}
+		switch d.Type {
+		case filterapi.PIIDetectorTypeEmail:
+			det.regex = emailRegex
+		case filterapi.PIIDetectorTypePhoneNumber:
+			det.regex, det.valid = phoneNumberRegex, validPhoneNumber
+		case filterapi.PIIDetectorTypeCreditCard:
+			det.regex, det.valid = creditCardRegex, validCreditCard
+		case filterapi.PIIDetectorTypeNationalID:
+			det.regex, det.valid = nationalIDRegex, validNationalID
+		case filterapi.PIIDetectorTypeRegex:
+			var err error
+			if det.regex, err = regexp.Compile(d.Regex); err != nil {
+				return nil, fmt.Errorf("invalid regex of the detector %s: %w", d.Name, err)
+			}
+		default:
+			return nil, fmt.Errorf("unknown type of the detector %s: %s", d.Name, d.Type)
+		}
+		r.detectors = append(r.detectors, det)
+	}
+	return r, nil
+}
+
+// Action returns what is done with the requests containing the PII.
+func (r *Redactor) Action() filterapi.PIIRedactionAction {
+	return r.action
+}
+
+// Result is the result of the redaction of a request.
+type Result struct {
+	// Detections is the number of the detected values keyed by the name of the detector.
+	Detections map[string]int
+	// Restorer restores the masked values in the response, or nil if nothing is masked.
+	Restorer *Restorer
+}
+
+// Redact scans the text of the messages of the given request for the PII. With the Mask action, the detected values
+// are replaced with the placeholders both in the parsed body and in the raw body, and the updated raw body is
+// returned. Otherwise, the request is left as is.
+func (r *Redactor) Redact(raw []byte, body *openai.ChatCompletionRequest) ([]byte, Result, error) {
+	return r.redact(raw, formatChatCompletion, func(f func(path string, text string) string) {
+		for i := range body.Messages {
+			forEachText(&body.Messages[i], func(path string, text string) string {
+				return f(fmt.Sprintf("messages.%d.%s", i, path), text)
+			})
+		}
+	})
+}
+
+// RedactCompletion is the same as [Redactor.Redact] for the prompt and the suffix of the completion requests.
+func (r *Redactor) RedactCompletion(raw []byte, body *openai.CompletionRequest) ([]byte, Result, error) {
+	return r.redact(raw, formatCompletion, func(f func(path string, text string) string) {
+		switch p := body.Prompt.Value.(type) {
+		case string:
+			body.Prompt.Value = f("prompt", p)
+		case []string:
+			for i := range p {
+				p[i] = f(fmt.Sprintf("prompt.%d", i), p[i])
+			}
+		}
+		if body.Suffix != nil {
+			suffix := f("suffix", *body.Suffix)
+			body.Suffix = &suffix
+		}
+	})
+}
+
+// RedactResponses is the same as [Redactor.Redact] for the instructions and the input of the Responses API requests.
+func (r *Redactor) RedactResponses(raw []byte, body *openai.ResponseRequest) ([]byte, Result, error) {
+	return r.redact(raw, formatResponses, func(f func(path string, text string) string) {
+		if body.Instructions != "" {
+			body.Instructions = f("instructions", body.Instructions)
+		}
+		if t := body.Input.Text; t != nil {
+			text := f("input", *t)
+			body.Input.Text = &text
+		}
+		for i := range body.Input.Items {
+			item := &body.Input.Items[i]
+			if c := item.Content; c != nil {
+				if c.Text != nil {
+					text := f(fmt.Sprintf("input.%d.content", i), *c.Text)
+					c.Text = &text
+				}
+				for j := range c.Parts {
+					if c.Parts[j].Text != "" {
+						c.Parts[j].Text = f(fmt.Sprintf("input.%d.content.%d.text", i, j), c.Parts[j].Text)
+					}
+				}
+			}
+			if item.Output != "" {
+				item.Output = f(fmt.Sprintf("input.%d.output", i), item.Output)
+			}
+		}
+	})
+}
+
+// RedactMessages is the same as [Redactor.Redact] for the system prompt and the messages of the Anthropic Messages API
+// requests, including the content of the tool results.
+func (r *Redactor) RedactMessages(raw []byte, body *anthropic.MessagesRequest) ([]byte, Result, error) {
+	return r.redact(raw, formatMessages, func(f func(path string, text string) string) {
+		if s := body.System; s != nil {
+			if s.Text != nil {
+				text := f("system", *s.Text)
+				s.Text = &text
+			}
+			forEachBlockText(s.Blocks, "system", f)
+		}
+		for i := range body.Messages {
+			forEachContentText(&body.Messages[i].Content, fmt.Sprintf("messages.%d.content", i), f)
+		}
+	})
+}
+
+// redact calls the given walk function with the function masking each text of the request at the given path in the
+// raw body, and returns the result of the redaction whose restorer restores the response in the given format.
+func (r *Redactor) redact(
+	raw []byte, rf format, walk func(f func(path string, text string) string),
+) ([]byte, Result, error) {
+	m := masker{
+		redactor:     r,
+		values:       make(map[string]string),
+		placeholders: make(map[string]string),
+		counts:       make(map[string]int),
+		detections:   make(map[string]int),
+	}
+	var err error
+	walk(func(path string, text string) string {
+		masked := m.mask(text)
+		if masked == text || err != nil || r.action != filterapi.PIIRedactionActionMask {
+			return text
+		}
+		raw, err = sjson.SetBytes(raw, path, masked)
+		return masked
+	})
+	if err != nil {
+		return nil, Result{}, fmt.Errorf("failed to mask the request body: %w", err)
+	}
+	ret := Result{Detections: m.detections}
+	if len(m.values) > 0 && r.action == filterapi.PIIRedactionActionMask {
+		ret.Restorer = newRestorer(m.values, rf)
+	}
+	return raw, ret, nil
+}
+
+// masker replaces the values detected in the texts of a request with the placeholders.
+type masker struct {
+	redactor *Redactor
+	// values maps the placeholders to the original values, and placeholders is the reverse.
+	values       map[string]string
+	placeholders map[string]string
+	// counts is the number of the distinct values per detector, and detections is the number of the detected values.
+	counts     map[string]int
+	detections map[string]int
+}
+
+// mask returns the given text with the detected values replaced with the placeholders. The same value is replaced with
+// the same placeholder across the texts of the request.
+func (m *masker) mask(text string) string {
+	for _, d := range m.redactor.detectors {
+		locs := d.regex.FindAllStringIndex(text, -1)
+		if len(locs) == 0 {
+			continue
+		}
+		var b strings.Builder
+		last := 0
+		for _, loc := range locs {
+			v := text[loc[0]:loc[1]]
+			if d.valid != nil && (!bounded(text, loc[0], loc[1]) || !d.valid(v)) {
+				continue
+			}
+			m.detections[d.name]++
+			p, ok := m.placeholders[v]
+			if !ok {
+				m.counts[d.name]++
+				p = "[" + d.placeholder + "_" + strconv.Itoa(m.counts[d.name]) + "]"
+				m.placeholders[v], m.values[p] = p, v
+			}
+			b.WriteString(text[last:loc[0]])
+			b.WriteString(p)
+			last = loc[1]
+		}
+		if last > 0 {
+			b.WriteString(text[last:])
+			text = b.String()
+		}
+	}
+	return text
+}
+
+// bounded reports whether the match at the given range of the text is not a part of a longer word or number.
+func bounded(text string, start, end int) bool {
+	isAlnum := func(c byte) bool {
+		return c >= '0' && c <= '9' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z'
+	}
+	return (start == 0 || !isAlnum(text[start-1])) && (end == len(text) || !isAlnum(text[end]))
+}
+
+// forEachText calls the given function with each text of the message and its path relative to the message in the raw
+// body, and replaces the text with the returned one.
+func forEachText(msg *openai.ChatCompletionMessageParamUnion, f func(path string, text string) string) {
+	switch m := msg.Value.(type) {
+	case openai.ChatCompletionUserMessageParam:
+		switch c := m.Content.Value.(type) {
+		case string:
+			m.Content.Value = f("content", c)
+		case []openai.ChatCompletionContentPartUserUnionParam:
+			for j := range c {
+				if t := c[j].TextContent; t != nil {
+					t.Text = f(fmt.Sprintf("content.%d.text", j), t.Text)
+				}
+			}
+		}
+		msg.Value = m
+	case openai.ChatCompletionAssistantMessageParam:
+		switch c := m.Content.Value.(type) {
+		case string:
+			m.Content.Value = f("content", c)
+		case []openai.ChatCompletionAssistantMessageParamContent:
+			for j := range c {
+				if t := c[j].Text; t != nil {
+					text := f(fmt.Sprintf("content.%d.text", j), *t)
+					c[j].Text = &text
+				}
+			}
+		}
+		msg.Value = m
+	case openai.ChatCompletionSystemMessageParam:
+		forEachStringOrArrayText(&m.Content, f)
+		msg.Value = m
+	case openai.ChatCompletionDeveloperMessageParam:
+		forEachStringOrArrayText(&m.Content, f)
+		msg.Value = m
+	case openai.ChatCompletionToolMessageParam:
+		forEachStringOrArrayText(&m.Content, f)
+		msg.Value = m
+	}
+}
+
+// forEachStringOrArrayText is the same as forEachText for the content of the system, developer and tool messages.
+func forEachStringOrArrayText(content *openai.StringOrArray, f func(path string, text string) string) {
+	switch c := content.Value.(type) {
+	case string:
+		content.Value = f("content", c)
+	case []string:
+		for j := range c {
+			c[j] = f(fmt.Sprintf("content.%d", j), c[j])
+		}
+	case []openai.ChatCompletionContentPartTextParam:
+		for j := range c {
+			c[j].Text = f(fmt.Sprintf("content.%d.text", j), c[j].Text)
+		}
+	}
+}
+
+// forEachContentText is the same as forEachText for the content of the Anthropic messages at the given path.
+func forEachContentText(content *anthropic.MessageContent, path string, f func(path string, text string) string) {
+	if content.Text != nil {
+		text := f(path, *content.Text)
+		content.Text = &text
+	}
+	forEachBlockText(content.Blocks, path, f)
+}
+
+// forEachBlockText is the same as forEachText for the text and the tool result blocks at the given path.
+func forEachBlockText(blocks []anthropic.ContentBlockParam, path string, f func(path string, text string) string) {
+	for j := range blocks {
+		b := &blocks[j]
+		switch {
+		case b.Text != "":
+			b.Text = f(fmt.Sprintf("%s.%d.text", path, j), b.Text)
+		case b.Content != nil:
+			forEachContentText(b.Content, fmt.Sprintf("%s.%d.content", path, j), f)
+		}
+	}
+}
+
+// digits returns the digits in the given string.
+func digits(s string) []byte {
+	ret := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			ret = append(ret, s[i]-'0')
+		}
+	}
+	return ret
+}
+
+// validPhoneNumber reports whether the match of the phone number regex has 10 to 15 digits.
+func validPhoneNumber(s string) bool {
+	n := len(digits(s))
+	return n >= 10 && n <= 15
+}
+
+// validCreditCard reports whether the match of the credit card regex passes the Luhn check.
+func validCreditCard(s string) bool {
+	d := digits(s)
+	if len(d) < 13 || len(d) > 19 {
+		return false
+	}
+	var sum int
+	for i := range d {
+		v := int(d[len(d)-1-i])
+		if i%2 == 1 {
+			if v *= 2; v > 9 {
+				v -= 9
+			}
+		}
+		sum += v
+	}
+	return sum%10 == 0
+}
+
+// validNationalID reports whether the match of the national ID regex is a valid US Social Security Number, whose
+// area number is not 000, 666 or 900-999, the group number is not 00 and the serial number is not 0000.
+func validNationalID(s string) bool {
+	area, group, serial := s[0:3], s[4:6], s[7:11]
+	return area != "000" && area != "666" && area[0] != '9' && group != "00" && serial != "0000"
+}
@@ -0,0 +1,271 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package pii
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestNewSet(t *testing.T) {
+	s, err := NewSet(nil)
+	require.NoError(t, err)
+	require.Nil(t, s)
+	require.Nil(t, s.Find("gpt-4o", nil))
+
+	_, err = NewSet([]filterapi.PIIRedaction{{
+		Name:      "ns/route",
+		Detectors: []filterapi.PIIDetector{{Name: "bad", Type: filterapi.PIIDetectorTypeRegex, Regex: "("}},
+	}})
+	require.ErrorContains(t, err, "invalid PII redaction ns/route: invalid regex of the detector bad")
+
+	_, err = NewSet([]filterapi.PIIRedaction{{
+		Name:      "ns/route",
+		Detectors: []filterapi.PIIDetector{{Name: "unknown", Type: "Unknown"}},
+	}})
+	require.EqualError(t, err, "invalid PII redaction ns/route: unknown type of the detector unknown: Unknown")
+}
+
+func TestSet_Find(t *testing.T) {
+	s, err := NewSet([]filterapi.PIIRedaction{
+		{
+			Name:      "ns/default",
+			Matches:   []filterapi.RouteRuleMatch{{Model: "gpt-4o"}},
+			Detectors: []filterapi.PIIDetector{{Name: "email", Type: filterapi.PIIDetectorTypeEmail}},
+		},
+		{
+			Name:      "ns/strict",
+			Matches:   []filterapi.RouteRuleMatch{{Model: "gpt-4o", Headers: map[string]string{"x-tenant": "acme"}}},
+			Action:    filterapi.PIIRedactionActionReject,
+			Detectors: []filterapi.PIIDetector{{Name: "email", Type: filterapi.PIIDetectorTypeEmail}},
+		},
+	})
+	require.NoError(t, err)
+
+	r := s.Find("gpt-4o", map[string]string{"x-tenant": "other"})
+	require.NotNil(t, r)
+	require.Equal(t, filterapi.PIIRedactionActionMask, r.Action())
+	r = s.Find("gpt-4o", map[string]string{"x-tenant": "acme"})
+	require.NotNil(t, r)
+	require.Equal(t, filterapi.PIIRedactionActionReject, r.Action())
+	require.Nil(t, s.Find("gpt-4o-mini", nil))
+}
+
+func TestRedactor_Detectors(t *testing.T) {
+	for _, tc := range []struct {
+		typ      filterapi.PIIDetectorType
+		regex    string
+		detected []string
+		ignored  []string
+	}{
+		{
+			typ:      filterapi.PIIDetectorTypeEmail,
+			detected: []string{"alice@example.com", "bob.smith+ai@mail.example.co.uk"},
+			ignored:  []string{"alice@localhost", "@example.com"},
+		},
+		{
+			typ:      filterapi.PIIDetectorTypePhoneNumber,
+			detected: []string{"+1 415-555-0132", "(415) 555-0132", "+44 20 7946 0958", "4155550132"},
+			ignored:  []string{"555-0132", "version 1.2.3.4", "order 1234567890123456789"},
+		},
+		{
+			typ:      filterapi.PIIDetectorTypeCreditCard,
+			detected: []string{"4111 1111 1111 1111", "5500-0000-0000-0004", "378282246310005"},
+			ignored:  []string{"4111 1111 1111 1112", "1234"},
+		},
+		{
+			typ:      filterapi.PIIDetectorTypeNationalID,
+			detected: []string{"123-45-6789"},
+			ignored:  []string{"000-12-3456", "666-12-3456", "912-34-5678", "123-00-4567", "123-45-0000", "1123-45-6789"},
+		},
+		{
+			typ:      filterapi.PIIDetectorTypeRegex,
+			regex:    `EMP-[0-9]{6}`,
+			detected: []string{"EMP-123456"},
+			ignored:  []string{"EMP-12345"},
+		},
+	} {
+		t.Run(string(tc.typ), func(t *testing.T) {
+			r, err := NewRedactor(&filterapi.PIIRedaction{
+				Action:    filterapi.PIIRedactionActionReject,
+				Detectors: []filterapi.PIIDetector{{Name: "d", Type: tc.typ, Regex: tc.regex}},
+			})
+			require.NoError(t, err)
+			for _, v := range tc.detected {
+				_, res := redact(t, r, "value: "+v+".")
+				require.Equal(t, map[string]int{"d": 1}, res.Detections, v)
+			}
+			for _, v := range tc.ignored {
+				_, res := redact(t, r, "value: "+v+".")
+				require.Empty(t, res.Detections, v)
+			}
+		})
+	}
+}
+
+func TestRedactor_Redact(t *testing.T) {
+	r, err := NewRedactor(&filterapi.PIIRedaction{
+		Detectors: []filterapi.PIIDetector{
+			{Name: "email", Type: filterapi.PIIDetectorTypeEmail},
+			{Name: "employee_id", Type: filterapi.PIIDetectorTypeRegex, Regex: `EMP-[0-9]{6}`},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, filterapi.PIIRedactionActionMask, r.Action())
+
+	raw := []byte(`{"model":"gpt-4o","messages":[` +
+		`{"role":"system","content":"Contact admin@example.com for help."},` +
+		`{"role":"user","content":[{"type":"text","text":"I am EMP-123456, mail me at alice@example.com or admin@example.com."},` +
+		`{"type":"image_url","image_url":{"url":"https://example.com/alice@example.com.png"}}]},` +
+		`{"role":"assistant","content":"Hello!"}]}`)
+	var body openai.ChatCompletionRequest
+	require.NoError(t, json.Unmarshal(raw, &body))
+
+	masked, res, err := r.Redact(raw, &body)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"email": 3, "employee_id": 1}, res.Detections)
+	require.NotNil(t, res.Restorer)
+	require.JSONEq(t, `{"model":"gpt-4o","messages":[`+
+		`{"role":"system","content":"Contact [EMAIL_1] for help."},`+
+		`{"role":"user","content":[{"type":"text","text":"I am [EMPLOYEE_ID_1], mail me at [EMAIL_2] or [EMAIL_1]."},`+
+		`{"type":"image_url","image_url":{"url":"https://example.com/alice@example.com.png"}}]},`+
+		`{"role":"assistant","content":"Hello!"}]}`, string(masked))
+
+	// The parsed body is also updated.
+	system := body.Messages[0].Value.(openai.ChatCompletionSystemMessageParam)
+	require.Equal(t, "Contact [EMAIL_1] for help.", system.Content.Value)
+	user := body.Messages[1].Value.(openai.ChatCompletionUserMessageParam)
+	parts := user.Content.Value.([]openai.ChatCompletionContentPartUserUnionParam)
+	require.Equal(t, "I am [EMPLOYEE_ID_1], mail me at [EMAIL_2] or [EMAIL_1].", parts[0].TextContent.Text)
+
+	require.Equal(t, `{"content":"Write to alice@example.com"}`,
+		string(res.Restorer.RestoreBody([]byte(`{"content":"Write to [EMAIL_2]"}`))))
+
+	// Nothing is masked.
+	raw = []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"Hello!"}]}`)
+	body = openai.ChatCompletionRequest{}
+	require.NoError(t, json.Unmarshal(raw, &body))
+	masked, res, err = r.Redact(raw, &body)
+	require.NoError(t, err)
+	require.Equal(t, raw, masked)
+	require.Empty(t, res.Detections)
+	require.Nil(t, res.Restorer)
+}
+
+func TestRedactor_RedactCompletion(t *testing.T) {
+	r, err := NewRedactor(&filterapi.PIIRedaction{
+		Detectors: []filterapi.PIIDetector{{Name: "email", Type: filterapi.PIIDetectorTypeEmail}},
+	})
+	require.NoError(t, err)
+
+	raw := []byte(`{"model":"gpt-3.5-turbo-instruct","prompt":["Mail alice@example.com","Hi"],"suffix":"to bob@example.com"}`)
+	var body openai.CompletionRequest
+	require.NoError(t, json.Unmarshal(raw, &body))
+
+	masked, res, err := r.RedactCompletion(raw, &body)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"email": 2}, res.Detections)
+	require.JSONEq(t, `{"model":"gpt-3.5-turbo-instruct","prompt":["Mail [EMAIL_1]","Hi"],"suffix":"to [EMAIL_2]"}`,
+		string(masked))
+	require.Equal(t, []string{"Mail [EMAIL_1]", "Hi"}, body.Prompt.Value)
+	require.Equal(t, "to [EMAIL_2]", *body.Suffix)
+	require.Equal(t, `data: {"choices":[{"index":0,"text":"alice@example.com","finish_reason":"stop"}]}`+"\n\n",
+		string(res.Restorer.RestoreStream(
+			[]byte(`data: {"choices":[{"index":0,"text":"[EMAIL_1]","finish_reason":"stop"}]}`+"\n\n"), true)))
+}
+
+func TestRedactor_RedactResponses(t *testing.T) {
+	r, err := NewRedactor(&filterapi.PIIRedaction{
+		Detectors: []filterapi.PIIDetector{{Name: "email", Type: filterapi.PIIDetectorTypeEmail}},
+	})
+	require.NoError(t, err)
+
+	raw := []byte(`{"model":"gpt-4o","instructions":"Contact admin@example.com.","input":[` +
+		`{"role":"user","content":"Mail alice@example.com"},` +
+		`{"role":"user","content":[{"type":"input_text","text":"or admin@example.com"}]},` +
+		`{"type":"function_call_output","call_id":"call_1","output":"bob@example.com"}]}`)
+	var body openai.ResponseRequest
+	require.NoError(t, json.Unmarshal(raw, &body))
+
+	masked, res, err := r.RedactResponses(raw, &body)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"email": 4}, res.Detections)
+	require.JSONEq(t, `{"model":"gpt-4o","instructions":"Contact [EMAIL_1].","input":[`+
+		`{"role":"user","content":"Mail [EMAIL_2]"},`+
+		`{"role":"user","content":[{"type":"input_text","text":"or [EMAIL_1]"}]},`+
+		`{"type":"function_call_output","call_id":"call_1","output":"[EMAIL_3]"}]}`, string(masked))
+	require.Equal(t, "Contact [EMAIL_1].", body.Instructions)
+	require.Equal(t, "Mail [EMAIL_2]", *body.Input.Items[0].Content.Text)
+	require.Equal(t, "or [EMAIL_1]", body.Input.Items[1].Content.Parts[0].Text)
+	require.Equal(t, "[EMAIL_3]", body.Input.Items[2].Output)
+
+	// The input as a string.
+	raw = []byte(`{"model":"gpt-4o","input":"Mail alice@example.com"}`)
+	body = openai.ResponseRequest{}
+	require.NoError(t, json.Unmarshal(raw, &body))
+	masked, _, err = r.RedactResponses(raw, &body)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"model":"gpt-4o","input":"Mail [EMAIL_1]"}`, string(masked))
+	require.Equal(t, "Mail [EMAIL_1]", *body.Input.Text)
+}
+
+func TestRedactor_RedactMessages(t *testing.T) {
+	r, err := NewRedactor(&filterapi.PIIRedaction{
+		Detectors: []filterapi.PIIDetector{{Name: "email", Type: filterapi.PIIDetectorTypeEmail}},
+	})
+	require.NoError(t, err)
+
+	raw := []byte(`{"model":"claude-sonnet-4","max_tokens":1024,"system":[{"type":"text","text":"Contact admin@example.com."}],` +
+		`"messages":[{"role":"user","content":"Mail alice@example.com"},` +
+		`{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"bob@example.com"},` +
+		`{"type":"text","text":"or admin@example.com"}]}]}`)
+	var body anthropic.MessagesRequest
+	require.NoError(t, json.Unmarshal(raw, &body))
+
+	masked, res, err := r.RedactMessages(raw, &body)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"email": 4}, res.Detections)
+	require.JSONEq(t, `{"model":"claude-sonnet-4","max_tokens":1024,"system":[{"type":"text","text":"Contact [EMAIL_1]."}],`+
+		`"messages":[{"role":"user","content":"Mail [EMAIL_2]"},`+
+		`{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"[EMAIL_3]"},`+
+		`{"type":"text","text":"or [EMAIL_1]"}]}]}`, string(masked))
+	require.Equal(t, "Contact [EMAIL_1].", body.System.Blocks[0].Text)
+	require.Equal(t, "Mail [EMAIL_2]", *body.Messages[0].Content.Text)
+	require.Equal(t, "[EMAIL_3]", *body.Messages[1].Content.Blocks[0].Content.Text)
+	require.Equal(t, "or [EMAIL_1]", body.Messages[1].Content.Blocks[1].Text)
+}
+
+func TestRedactor_Redact_reject(t *testing.T) {
+	r, err := NewRedactor(&filterapi.PIIRedaction{
+		Action:    filterapi.PIIRedactionActionReject,
+		Detectors: []filterapi.PIIDetector{{Name: "email", Type: filterapi.PIIDetectorTypeEmail}},
+	})
+	require.NoError(t, err)
+
+	masked, res := redact(t, r, "mail me at alice@example.com")
+	require.JSONEq(t, `{"model":"gpt-4o","messages":[{"role":"user","content":"mail me at alice@example.com"}]}`, string(masked))
+	require.Equal(t, map[string]int{"email": 1}, res.Detections)
+	require.Nil(t, res.Restorer)
+}
+
+func redact(t *testing.T, r *Redactor, content string) ([]byte, Result) {
+	raw, err := json.Marshal(map[string]any{
+		"model":    "gpt-4o",
+		"messages": []map[string]any{{"role": "user", "content": content}},
+	})
+	require.NoError(t, err)
+	var body openai.ChatCompletionRequest
+	require.NoError(t, json.Unmarshal(raw, &body))
+	masked, res, err := r.Redact(raw, &body)
+	require.NoError(t, err)
+	return masked, res
+}
@@ -0,0 +1,261 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package pii
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Restorer restores the values masked in a request in its response. This is not safe for concurrent use.
+type Restorer struct {
+	values map[string]string
+	format format
+	// replacer replaces the placeholders with the values escaped as JSON strings.
+	replacer *strings.Replacer
+	// buf is the incomplete server-sent event carried over to the next chunk of the streaming response.
+	buf []byte
+	// held is the tail of the content of each choice which can be the beginning of a placeholder split across
+	// the events. This is carried over to the next event of the same choice.
+	held map[int64]string
+	// pending is the text delta events held back since they end with the beginning of a placeholder in the format
+	// whose text deltas are typed events. These are merged into the next delta of the same content.
+	pending []pendingDelta
+}
+
+// format is the format of the response restored by [Restorer].
+type format int
+
+const (
+	formatChatCompletion format = iota
+	formatCompletion
+	formatResponses
+	formatMessages
+)
+
+// pendingDelta is a text delta event held back by [Restorer].
+type pendingDelta struct {
+	// key identifies the content the delta belongs to.
+	key string
+	// lines is the lines of the event, and line is the index of the data line whose JSON is data.
+	lines [][]byte
+	line  int
+	data  []byte
+	// path is the path of the text in data. restored is the text restored so far, and held is the rest.
+	path     string
+	restored string
+	held     string
+}
+
+// newRestorer creates a new [Restorer] from the given map of the placeholders to the original values.
+func newRestorer(values map[string]string, f format) *Restorer {
+	oldnew := make([]string, 0, len(values)*2)
+	for p, v := range values {
+		var escaped bytes.Buffer
+		enc := json.NewEncoder(&escaped)
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(v)
+		// Trim the quotes and the trailing newline added by the encoder.
+		oldnew = append(oldnew, p, string(escaped.Bytes()[1:escaped.Len()-2]))
+	}
+	return &Restorer{values: values, format: f, replacer: strings.NewReplacer(oldnew...), held: make(map[int64]string)}
+}
+
+// RestoreBody restores the masked values in the non-streaming response body.
+func (r *Restorer) RestoreBody(body []byte) []byte {
+	return []byte(r.replacer.Replace(string(body)))
+}
+
+// RestoreStream restores the masked values in the content of the server-sent events of the streaming response, and
+// returns the events completed by the given chunk. The incomplete event at the end of the chunk is returned along with
+// the next chunk, and so is the end of the content which can be the beginning of a placeholder split across the
+// events. The rest is flushed at the end of the stream.
+func (r *Restorer) RestoreStream(chunk []byte, endOfStream bool) []byte {
+	r.buf = append(r.buf, chunk...)
+	var out []byte
+	for {
+		i := bytes.Index(r.buf, []byte("\n\n"))
+		if i < 0 {
+			break
+		}
+		out = append(out, r.restoreEvent(r.buf[:i+2])...)
+		r.buf = r.buf[i+2:]
+	}
+	if endOfStream {
+		out = append(out, r.flushPending()...)
+		out = append(out, r.buf...)
+		r.buf = nil
+	}
+	return out
+}
+
+// restoreEvent restores the masked values in the data of the given server-sent event.
+func (r *Restorer) restoreEvent(event []byte) []byte {
+	if r.format == formatResponses || r.format == formatMessages {
+		return r.restoreTypedEvent(event)
+	}
+	path := "delta.content"
+	if r.format == formatCompletion {
+		path = "text"
+	}
+	lines := bytes.Split(event, []byte("\n"))
+	for i, line := range lines {
+		data, ok := bytes.CutPrefix(line, []byte("data:"))
+		if !ok {
+			continue
+		}
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 || data[0] != '{' {
+			continue
+		}
+		lines[i] = append([]byte("data: "), r.restoreChunk(data, path)...)
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// restoreChunk restores the masked values in the text at the given path of the choices of the given chunk, which is
+// the delta content of the chat completion chunks and the text of the completion chunks.
+func (r *Restorer) restoreChunk(data []byte, path string) []byte {
+	choices := gjson.GetBytes(data, "choices")
+	if !choices.IsArray() {
+		return data
+	}
+	for k, c := range choices.Array() {
+		idx := c.Get("index").Int()
+		content := c.Get(path)
+		finishReason := c.Get("finish_reason")
+		restored, held := r.restoreText(r.held[idx]+content.String(), finishReason.Exists() && finishReason.Type != gjson.Null)
+		if held != "" {
+			r.held[idx] = held
+		} else {
+			delete(r.held, idx)
+		}
+		if restored == content.String() && (content.Exists() || restored == "") {
+			continue
+		}
+		if updated, err := sjson.SetBytes(data, fmt.Sprintf("choices.%d.%s", k, path), restored); err == nil {
+			data = updated
+		}
+	}
+	return data
+}
+
+// restoreTypedEvent restores the masked values in the given event of the Responses API or the Anthropic Messages API,
+// whose text deltas are typed events. The text delta ending with the beginning of a placeholder is held back and merged
+// into the next delta of the same content. The other events such as the end of the content carry the whole text, so
+// the placeholders in them are replaced as a whole after the held deltas are flushed.
+func (r *Restorer) restoreTypedEvent(event []byte) []byte {
+	lines := bytes.Split(event, []byte("\n"))
+	for i, line := range lines {
+		data, ok := bytes.CutPrefix(line, []byte("data:"))
+		if !ok {
+			continue
+		}
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 || data[0] != '{' {
+			continue
+		}
+		key, path := r.textDelta(data)
+		if key == "" {
+			lines[i] = append([]byte("data: "), r.replacer.Replace(string(data))...)
+			return append(r.flushPending(), bytes.Join(lines, []byte("\n"))...)
+		}
+		var prefix, held string
+		for k := range r.pending {
+			if p := r.pending[k]; p.key == key {
+				prefix, held = p.restored, p.held
+				r.pending = append(r.pending[:k], r.pending[k+1:]...)
+				break
+			}
+		}
+		restored, held := r.restoreText(held+gjson.GetBytes(data, path).String(), false)
+		p := pendingDelta{key: key, lines: lines, line: i, data: data, path: path, restored: prefix + restored, held: held}
+		if held != "" {
+			r.pending = append(r.pending, p)
+			return nil
+		}
+		return p.event()
+	}
+	return append(r.flushPending(), event...)
+}
+
+// textDelta returns the key of the content and the path of the text if the given data is a text delta event, or
+// empty strings otherwise.
+func (r *Restorer) textDelta(data []byte) (key string, path string) {
+	typ := gjson.GetBytes(data, "type").String()
+	switch {
+	case r.format == formatResponses && typ == "response.output_text.delta":
+		res := gjson.GetManyBytes(data, "output_index", "content_index")
+		return res[0].Raw + ":" + res[1].Raw, "delta"
+	case r.format == formatMessages && typ == "content_block_delta" &&
+		gjson.GetBytes(data, "delta.type").String() == "text_delta":
+		return gjson.GetBytes(data, "index").Raw, "delta.text"
+	}
+	return "", ""
+}
+
+// flushPending returns the held text delta events with the text held back as is, since it has turned out not to be a
+// placeholder.
+func (r *Restorer) flushPending() []byte {
+	var out []byte
+	for _, p := range r.pending {
+		p.restored += p.held
+		out = append(out, p.event()...)
+	}
+	r.pending = nil
+	return out
+}
+
+// event returns the event with the text replaced with the restored one.
+func (p *pendingDelta) event() []byte {
+	data := p.data
+	if updated, err := sjson.SetBytes(data, p.path, p.restored); err == nil {
+		data = updated
+	}
+	p.lines[p.line] = append([]byte("data: "), data...)
+	return bytes.Join(p.lines, []byte("\n"))
+}
+
+// restoreText returns the given text with the placeholders replaced with the original values, and the end of the
+// text held back since it can be the beginning of a placeholder. Nothing is held back when flush is true.
+func (r *Restorer) restoreText(text string, flush bool) (restored string, held string) {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(text, '[')
+		if i < 0 {
+			b.WriteString(text)
+			return b.String(), ""
+		}
+		b.WriteString(text[:i])
+		text = text[i:]
+		if j := strings.IndexByte(text, ']'); j > 0 {
+			if v, ok := r.values[text[:j+1]]; ok {
+				b.WriteString(v)
+				text = text[j+1:]
+				continue
+			}
+		} else if !flush && r.isPlaceholderPrefix(text) {
+			return b.String(), text
+		}
+		b.WriteByte('[')
+		text = text[1:]
+	}
+}
+
+// isPlaceholderPrefix reports whether the given text is the beginning of any placeholder.
+func (r *Restorer) isPlaceholderPrefix(text string) bool {
+	for p := range r.values {
+		if strings.HasPrefix(p, text) {
+			return true
+		}
+	}
+	return false
+}
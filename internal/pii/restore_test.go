@@ -0,0 +1,120 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package pii
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestorer_RestoreBody(t *testing.T) {
+	r := newRestorer(map[string]string{"[EMAIL_1]": "alice@example.com", "[NAME_1]": `"Bob" <b>`}, formatChatCompletion)
+	require.Equal(t,
+		`{"choices":[{"message":{"content":"Hi \"Bob\" <b>, I wrote to alice@example.com. [EMAIL_2] is unknown."}}]}`,
+		string(r.RestoreBody([]byte(`{"choices":[{"message":{"content":"Hi [NAME_1], I wrote to [EMAIL_1]. [EMAIL_2] is unknown."}}]}`))),
+	)
+}
+
+func TestRestorer_RestoreStream(t *testing.T) {
+	r := newRestorer(map[string]string{"[EMAIL_1]": "alice@example.com", "[EMAIL_12]": "bob@example.com"}, formatChatCompletion)
+
+	var out []byte
+	for _, chunk := range []string{
+		// The placeholder is split across the events, and the second event is split across the chunks.
+		`data: {"choices":[{"index":0,"delta":{"content":"Mail [EM"},"finish_reason":null}]}` + "\n\n" + `data: {"choi`,
+		`ces":[{"index":0,"delta":{"content":"AIL_1] and [EMAIL_1"},"finish_reason":null}]}` + "\n\n",
+		// "[EMAIL_1" turns out to be the beginning of the other placeholder.
+		`data: {"choices":[{"index":0,"delta":{"content":"2]. See [1"},"finish_reason":null}]}` + "\n\n",
+		// The text held back is flushed when the choice finishes.
+		`data: {"choices":[{"index":0,"delta":{"content":"] or [E"},"finish_reason":null}]}` + "\n\n",
+		`data: {"choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}` + "\n\n",
+		`data: [DONE]` + "\n\n",
+	} {
+		out = append(out, r.RestoreStream([]byte(chunk), false)...)
+	}
+	out = append(out, r.RestoreStream(nil, true)...)
+
+	require.Equal(t, `data: {"choices":[{"index":0,"delta":{"content":"Mail "},"finish_reason":null}]}`+"\n\n"+
+		`data: {"choices":[{"index":0,"delta":{"content":"alice@example.com and "},"finish_reason":null}]}`+"\n\n"+
+		`data: {"choices":[{"index":0,"delta":{"content":"bob@example.com. See [1"},"finish_reason":null}]}`+"\n\n"+
+		`data: {"choices":[{"index":0,"delta":{"content":"] or "},"finish_reason":null}]}`+"\n\n"+
+		`data: {"choices":[{"index":0,"delta":{"content":"[E"},"finish_reason":"stop"}]}`+"\n\n"+
+		`data: [DONE]`+"\n\n", string(out))
+}
+
+func TestRestorer_RestoreStream_endOfStream(t *testing.T) {
+	r := newRestorer(map[string]string{"[EMAIL_1]": "alice@example.com"}, formatChatCompletion)
+	require.Empty(t, r.RestoreStream([]byte(`data: {"choices":[{"index":0,"delta":{"content":"[EMAIL_1]"}}]}`), false))
+	require.Equal(t, `data: {"choices":[{"index":0,"delta":{"content":"[EMAIL_1]"}}]}`, string(r.RestoreStream(nil, true)))
+}
+
+func TestRestorer_RestoreStream_completion(t *testing.T) {
+	r := newRestorer(map[string]string{"[EMAIL_1]": "alice@example.com"}, formatCompletion)
+
+	var out []byte
+	for _, chunk := range []string{
+		`data: {"choices":[{"index":0,"text":"Mail [EM","finish_reason":null}]}` + "\n\n",
+		`data: {"choices":[{"index":0,"text":"AIL_1].","finish_reason":"stop"}]}` + "\n\n",
+		`data: [DONE]` + "\n\n",
+	} {
+		out = append(out, r.RestoreStream([]byte(chunk), false)...)
+	}
+	out = append(out, r.RestoreStream(nil, true)...)
+
+	require.Equal(t, `data: {"choices":[{"index":0,"text":"Mail ","finish_reason":null}]}`+"\n\n"+
+		`data: {"choices":[{"index":0,"text":"alice@example.com.","finish_reason":"stop"}]}`+"\n\n"+
+		`data: [DONE]`+"\n\n", string(out))
+}
+
+func TestRestorer_RestoreStream_responses(t *testing.T) {
+	r := newRestorer(map[string]string{"[EMAIL_1]": "alice@example.com"}, formatResponses)
+
+	var out []byte
+	for _, chunk := range []string{
+		// The delta ending with the beginning of the placeholder is held back and merged into the next one.
+		"event: response.output_text.delta\n" +
+			`data: {"type":"response.output_text.delta","output_index":0,"content_index":0,"delta":"Mail [EM"}` + "\n\n",
+		"event: response.output_text.delta\n" +
+			`data: {"type":"response.output_text.delta","output_index":0,"content_index":0,"delta":"AIL_1] or [E"}` + "\n\n",
+		// The held delta is flushed before the other events, whose placeholders are replaced as a whole.
+		"event: response.output_text.done\n" +
+			`data: {"type":"response.output_text.done","output_index":0,"content_index":0,"text":"Mail [EMAIL_1] or [E"}` + "\n\n",
+	} {
+		out = append(out, r.RestoreStream([]byte(chunk), false)...)
+	}
+	out = append(out, r.RestoreStream(nil, true)...)
+
+	require.Equal(t, "event: response.output_text.delta\n"+
+		`data: {"type":"response.output_text.delta","output_index":0,"content_index":0,"delta":"Mail alice@example.com or [E"}`+"\n\n"+
+		"event: response.output_text.done\n"+
+		`data: {"type":"response.output_text.done","output_index":0,"content_index":0,"text":"Mail alice@example.com or [E"}`+"\n\n",
+		string(out))
+}
+
+func TestRestorer_RestoreStream_messages(t *testing.T) {
+	r := newRestorer(map[string]string{"[EMAIL_1]": "alice@example.com"}, formatMessages)
+
+	var out []byte
+	for _, chunk := range []string{
+		"event: content_block_delta\n" +
+			`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Mail [EMAIL"}}` + "\n\n",
+		"event: content_block_delta\n" +
+			`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"_1]."}}` + "\n\n",
+		// The held delta is flushed at the end of the stream.
+		"event: content_block_delta\n" +
+			`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" [EMAIL_"}}` + "\n\n",
+	} {
+		out = append(out, r.RestoreStream([]byte(chunk), false)...)
+	}
+	out = append(out, r.RestoreStream(nil, true)...)
+
+	require.Equal(t, "event: content_block_delta\n"+
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Mail alice@example.com."}}`+"\n\n"+
+		"event: content_block_delta\n"+
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" [EMAIL_"}}`+"\n\n",
+		string(out))
+}
@@ -0,0 +1,242 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package quota
+
+import (
+	"encoding/json"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+const (
+	// bytesPerToken is the rough number of bytes per token of the English text with the common tokenizers.
+	bytesPerToken = 4
+	// tokensPerMessage is the overhead of each message for the role and the delimiters.
+	tokensPerMessage = 4
+	// tokensPerImage is the number of tokens of an image in the low detail, which is the lower bound of any image.
+	tokensPerImage = 85
+)
+
+// EstimateChatCompletion returns the estimated usage of the chat completion request before it is sent to the backend.
+//
+// The input tokens are estimated from the size of the text in the request without tokenizing it, so this is only
+// meant to reject the requests that obviously don't fit in the budget. The output tokens are the maximum number of
+// tokens the client allows to be generated, which is zero when the client doesn't limit it.
+func EstimateChatCompletion(req *openai.ChatCompletionRequest) Usage {
+	var textBytes, tokens int
+	for _, msg := range req.Messages {
+		tokens += tokensPerMessage
+		switch m := msg.Value.(type) {
+		case openai.ChatCompletionUserMessageParam:
+			switch content := m.Content.Value.(type) {
+			case string:
+				textBytes += len(content)
+			case []openai.ChatCompletionContentPartUserUnionParam:
+				for _, part := range content {
+					switch {
+					case part.TextContent != nil:
+						textBytes += len(part.TextContent.Text)
+					case part.ImageContent != nil:
+						tokens += tokensPerImage
+					}
+				}
+			}
+		case openai.ChatCompletionAssistantMessageParam:
+			switch content := m.Content.Value.(type) {
+			case string:
+				textBytes += len(content)
+			case []openai.ChatCompletionAssistantMessageParamContent:
+				for _, part := range content {
+					if part.Text != nil {
+						textBytes += len(*part.Text)
+					}
+				}
+			}
+			for _, call := range m.ToolCalls {
+				textBytes += len(call.Function.Name) + len(call.Function.Arguments)
+			}
+		case openai.ChatCompletionSystemMessageParam:
+			textBytes += stringOrArrayLen(m.Content)
+		case openai.ChatCompletionDeveloperMessageParam:
+			textBytes += stringOrArrayLen(m.Content)
+		case openai.ChatCompletionToolMessageParam:
+			textBytes += stringOrArrayLen(m.Content)
+		}
+	}
+	textBytes += jsonLen(req.Tools)
+	tokens += (textBytes + bytesPerToken - 1) / bytesPerToken
+
+	var output int64
+	if req.MaxCompletionTokens != nil {
+		output = *req.MaxCompletionTokens
+	} else if req.MaxTokens != nil {
+		output = *req.MaxTokens
+	}
+	if req.N != nil && *req.N > 1 {
+		output *= int64(*req.N)
+	}
+	return Usage{InputTokens: int64(tokens), OutputTokens: output}
+}
+
+// EstimateResponses returns the estimated usage of the Responses API request in the same way as
+// [EstimateChatCompletion].
+func EstimateResponses(req *openai.ResponseRequest) Usage {
+	textBytes, tokens := len(req.Instructions), 0
+	if req.Input.Text != nil {
+		tokens += tokensPerMessage
+		textBytes += len(*req.Input.Text)
+	}
+	for i := range req.Input.Items {
+		item := &req.Input.Items[i]
+		tokens += tokensPerMessage
+		textBytes += len(item.Name) + len(item.Arguments) + len(item.Output)
+		if item.Content == nil {
+			continue
+		}
+		if item.Content.Text != nil {
+			textBytes += len(*item.Content.Text)
+		}
+		for _, part := range item.Content.Parts {
+			if part.Type == "input_image" {
+				tokens += tokensPerImage
+			}
+			textBytes += len(part.Text) + len(part.Refusal)
+		}
+	}
+	textBytes += jsonLen(req.Tools)
+	tokens += (textBytes + bytesPerToken - 1) / bytesPerToken
+
+	var output int64
+	if req.MaxOutputTokens != nil {
+		output = *req.MaxOutputTokens
+	}
+	return Usage{InputTokens: int64(tokens), OutputTokens: output}
+}
+
+// EstimateMessages returns the estimated usage of the Anthropic Messages API request in the same way as
+// [EstimateChatCompletion].
+func EstimateMessages(req *anthropic.MessagesRequest) Usage {
+	var textBytes, tokens int
+	if req.System != nil {
+		if req.System.Text != nil {
+			textBytes += len(*req.System.Text)
+		}
+		textBytes, tokens = contentBlocksLen(req.System.Blocks, textBytes, tokens)
+	}
+	for i := range req.Messages {
+		tokens += tokensPerMessage
+		textBytes, tokens = messageContentLen(&req.Messages[i].Content, textBytes, tokens)
+	}
+	textBytes += jsonLen(req.Tools)
+	tokens += (textBytes + bytesPerToken - 1) / bytesPerToken
+	return Usage{InputTokens: int64(tokens), OutputTokens: req.MaxTokens}
+}
+
+// EstimateCompletion returns the estimated usage of the legacy completion request in the same way as
+// [EstimateChatCompletion]. The prompts given as the token arrays are counted as they are.
+func EstimateCompletion(req *openai.CompletionRequest) Usage {
+	var textBytes, tokens int
+	switch p := req.Prompt.Value.(type) {
+	case string:
+		textBytes += len(p)
+	case []string:
+		for _, str := range p {
+			textBytes += len(str)
+		}
+	case []int64:
+		tokens += len(p)
+	case [][]int64:
+		for _, t := range p {
+			tokens += len(t)
+		}
+	}
+	if req.Suffix != nil {
+		textBytes += len(*req.Suffix)
+	}
+	tokens += (textBytes + bytesPerToken - 1) / bytesPerToken
+
+	var output int64
+	if req.MaxTokens != nil {
+		output = *req.MaxTokens
+	}
+	// The best_of completions are all generated and billed, even though only n of them are returned.
+	n := 1
+	if req.N != nil {
+		n = *req.N
+	}
+	if req.BestOf != nil {
+		n = max(n, *req.BestOf)
+	}
+	if n > 1 {
+		output *= int64(n)
+	}
+	return Usage{InputTokens: int64(tokens), OutputTokens: output}
+}
+
+// EstimateEmbedding returns the estimated usage of the embedding request in the same way as
+// [EstimateChatCompletion]. The embeddings have no output tokens.
+func EstimateEmbedding(req *openai.EmbeddingRequest) Usage {
+	textBytes := stringOrArrayLen(req.Input)
+	return Usage{InputTokens: int64((textBytes + bytesPerToken - 1) / bytesPerToken)}
+}
+
+func messageContentLen(c *anthropic.MessageContent, textBytes, tokens int) (int, int) {
+	if c.Text != nil {
+		textBytes += len(*c.Text)
+	}
+	return contentBlocksLen(c.Blocks, textBytes, tokens)
+}
+
+func contentBlocksLen(blocks []anthropic.ContentBlockParam, textBytes, tokens int) (int, int) {
+	for i := range blocks {
+		b := &blocks[i]
+		switch b.Type {
+		case "image", "document":
+			tokens += tokensPerImage
+		case "tool_result":
+			if b.Content != nil {
+				textBytes, tokens = messageContentLen(b.Content, textBytes, tokens)
+			}
+		default:
+			textBytes += len(b.Text) + len(b.Name) + len(b.Input) + len(b.Thinking)
+		}
+	}
+	return textBytes, tokens
+}
+
+// jsonLen returns the size of the given value encoded in JSON, or zero when it is empty.
+func jsonLen[T any](v []T) int {
+	if len(v) == 0 {
+		return 0
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return len(encoded)
+}
+
+func stringOrArrayLen(s openai.StringOrArray) int {
+	switch v := s.Value.(type) {
+	case string:
+		return len(v)
+	case []string:
+		var n int
+		for _, str := range v {
+			n += len(str)
+		}
+		return n
+	case []openai.ChatCompletionContentPartTextParam:
+		var n int
+		for _, part := range v {
+			n += len(part.Text)
+		}
+		return n
+	default:
+		return 0
+	}
+}
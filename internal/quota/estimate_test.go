@@ -0,0 +1,123 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package quota
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestEstimateChatCompletion(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		body string
+		exp  Usage
+	}{
+		{
+			name: "text",
+			// 4 tokens per message plus 14 bytes of text.
+			body: `{"messages":[{"role":"system","content":"Be brief."},{"role":"user","content":"Hello"}]}`,
+			exp:  Usage{InputTokens: 4 + 4 + 4},
+		},
+		{
+			name: "parts",
+			body: `{"messages":[{"role":"user","content":[{"type":"text","text":"12345678"},{"type":"image_url","image_url":{"url":"https://example.com/a.png"}}]}],"max_tokens":100,"n":2}`,
+			exp:  Usage{InputTokens: 4 + 85 + 2, OutputTokens: 200},
+		},
+		{
+			name: "assistant and tool",
+			body: `{"messages":[{"role":"assistant","content":"abcd","tool_calls":[{"id":"1","type":"function","function":{"name":"ab","arguments":"{}"}}]},{"role":"tool","tool_call_id":"1","content":[{"type":"text","text":"abcd"}]}],"max_completion_tokens":10,"max_tokens":20}`,
+			exp:  Usage{InputTokens: 4 + 4 + 3, OutputTokens: 10},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var req openai.ChatCompletionRequest
+			require.NoError(t, json.Unmarshal([]byte(tc.body), &req))
+			require.Equal(t, tc.exp, EstimateChatCompletion(&req))
+		})
+	}
+}
+
+func TestEstimateResponses(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		body string
+		exp  Usage
+	}{
+		{
+			name: "text",
+			// 4 tokens for the input plus 14 bytes of text.
+			body: `{"input":"Hello","instructions":"Be brief.","max_output_tokens":100}`,
+			exp:  Usage{InputTokens: 4 + 4, OutputTokens: 100},
+		},
+		{
+			name: "items",
+			body: `{"input":[{"role":"user","content":[{"type":"input_text","text":"12345678"},{"type":"input_image","image_url":"https://example.com/a.png"}]},{"type":"function_call_output","call_id":"1","output":"abcd"}]}`,
+			exp:  Usage{InputTokens: 4 + 4 + 85 + 3},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var req openai.ResponseRequest
+			require.NoError(t, json.Unmarshal([]byte(tc.body), &req))
+			require.Equal(t, tc.exp, EstimateResponses(&req))
+		})
+	}
+}
+
+func TestEstimateMessages(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		body string
+		exp  Usage
+	}{
+		{
+			name: "text",
+			// 4 tokens per message plus 14 bytes of text.
+			body: `{"system":"Be brief.","messages":[{"role":"user","content":"Hello"}],"max_tokens":100}`,
+			exp:  Usage{InputTokens: 4 + 4, OutputTokens: 100},
+		},
+		{
+			name: "blocks",
+			body: `{"messages":[{"role":"assistant","content":[{"type":"tool_use","id":"1","name":"ab","input":{}}]},{"role":"user","content":[{"type":"tool_result","tool_use_id":"1","content":"abcd"},{"type":"image","source":{"type":"url","url":"https://example.com/a.png"}}]}],"max_tokens":10}`,
+			exp:  Usage{InputTokens: 4 + 4 + 85 + 2, OutputTokens: 10},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var req anthropic.MessagesRequest
+			require.NoError(t, json.Unmarshal([]byte(tc.body), &req))
+			require.Equal(t, tc.exp, EstimateMessages(&req))
+		})
+	}
+}
+
+func TestEstimateCompletion(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		body string
+		exp  Usage
+	}{
+		{name: "text", body: `{"prompt":"12345678","max_tokens":10,"n":2}`, exp: Usage{InputTokens: 2, OutputTokens: 20}},
+		{name: "best of", body: `{"prompt":["1234","5678"],"max_tokens":10,"n":2,"best_of":3}`, exp: Usage{InputTokens: 2, OutputTokens: 30}},
+		{name: "tokens", body: `{"prompt":[1,2,3]}`, exp: Usage{InputTokens: 3}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var req openai.CompletionRequest
+			require.NoError(t, json.Unmarshal([]byte(tc.body), &req))
+			require.Equal(t, tc.exp, EstimateCompletion(&req))
+		})
+	}
+}
+
+func TestEstimateEmbedding(t *testing.T) {
+	var req openai.EmbeddingRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"input":["1234","56789"]}`), &req))
+	require.Equal(t, Usage{InputTokens: 3}, EstimateEmbedding(&req))
+}
@@ -0,0 +1,76 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package quota
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memorySweepInterval is the minimum interval between the sweeps of the stale counters.
+const memorySweepInterval = time.Minute
+
+// memoryStore is the [Store] that keeps the spend in memory per filter instance.
+type memoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]*memoryCounter
+	lastSweep time.Time
+}
+
+// memoryCounter is the spend of a key in the current and the previous fixed windows.
+type memoryCounter struct {
+	window            time.Duration
+	index             int64
+	current, previous int64
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{counters: map[string]*memoryCounter{}}
+}
+
+// Add implements [Store.Add].
+func (m *memoryStore) Add(_ context.Context, key string, window time.Duration, now time.Time, n int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maybeSweep(now)
+	c, ok := m.counters[key]
+	if !ok {
+		c = &memoryCounter{window: window}
+		m.counters[key] = c
+	}
+	c.advance(windowIndex(window, now))
+	c.current += n
+	return slidingSpend(window, now, c.current, c.previous), nil
+}
+
+// Close implements [Store.Close].
+func (m *memoryStore) Close() error { return nil }
+
+// advance moves the counter to the window of the given index.
+func (c *memoryCounter) advance(index int64) {
+	switch {
+	case index == c.index:
+	case index == c.index+1:
+		c.previous, c.current = c.current, 0
+	default:
+		c.previous, c.current = 0, 0
+	}
+	c.index = index
+}
+
+// maybeSweep removes the counters that have no spend in the sliding window anymore. This must be called with the lock held.
+func (m *memoryStore) maybeSweep(now time.Time) {
+	if now.Sub(m.lastSweep) < memorySweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for key, c := range m.counters {
+		if windowIndex(c.window, now) > c.index+1 {
+			delete(m.counters, key)
+		}
+	}
+}
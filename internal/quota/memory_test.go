@@ -0,0 +1,40 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package quota
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	m := newMemoryStore()
+	defer func() { require.NoError(t, m.Close()) }()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	spent, err := m.Add(t.Context(), "a", time.Minute, start.Add(30*time.Second), 100)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), spent)
+	spent, err = m.Add(t.Context(), "b", time.Minute, start.Add(30*time.Second), 7)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), spent)
+
+	// A quarter of the previous window overlaps with the sliding window.
+	spent, err = m.Add(t.Context(), "a", time.Minute, start.Add(105*time.Second), 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(35), spent)
+	spent, err = m.Add(t.Context(), "a", time.Minute, start.Add(105*time.Second), -5)
+	require.NoError(t, err)
+	require.Equal(t, int64(30), spent)
+
+	// Nothing is left after two windows, and the stale counters are swept.
+	spent, err = m.Add(t.Context(), "a", time.Minute, start.Add(5*time.Minute), 0)
+	require.NoError(t, err)
+	require.Zero(t, spent)
+	require.Len(t, m.counters, 1)
+}
@@ -0,0 +1,237 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package quota provides the token and spend budgets of the clients enforced by the external processor.
+//
+// The budget is reserved with the estimated usage before the request is sent to the backend, so that
+// the request is rejected up front when the budget is exhausted, and the reservation is reconciled with
+// the actual usage once the response completes.
+package quota
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+// Usage is the number of tokens and the cost spent by a single request.
+type Usage struct {
+	InputTokens  int64
+	OutputTokens int64
+	// SpendMicroUSD is the cost of the request in micro US dollars.
+	SpendMicroUSD int64
+}
+
+// amount returns the usage counted against the budget of the given type.
+func (u Usage) amount(t filterapi.QuotaBudgetType) int64 {
+	switch t {
+	case filterapi.QuotaBudgetTypeInputToken:
+		return u.InputTokens
+	case filterapi.QuotaBudgetTypeOutputToken:
+		return u.OutputTokens
+	case filterapi.QuotaBudgetTypeSpend:
+		return u.SpendMicroUSD
+	default:
+		return u.InputTokens + u.OutputTokens
+	}
+}
+
+// Store keeps the spend of each key over the sliding windows.
+type Store interface {
+	// Add adds n, which can be negative, to the spend of the key at the given time, and returns
+	// the spend within the window ending at that time after the addition.
+	Add(ctx context.Context, key string, window time.Duration, now time.Time, n int64) (int64, error)
+	// Close releases the resources held by the store.
+	Close() error
+}
+
+// ExceededError is returned by [Limiter.Reserve] when the budget of the client is exhausted.
+type ExceededError struct {
+	// Policy is the name of the policy whose budget is exhausted.
+	Policy string
+	// Budget is the exhausted budget.
+	Budget filterapi.QuotaBudget
+}
+
+// Error implements [error].
+func (e *ExceededError) Error() string {
+	if e.Budget.Type == filterapi.QuotaBudgetTypeSpend {
+		return fmt.Sprintf("quota %s exceeded: %s budget of %.2f USD per %s", e.Policy, e.Budget.Type, float64(e.Budget.Limit)/1e6, e.Budget.Window)
+	}
+	return fmt.Sprintf("quota %s exceeded: %s budget of %d tokens per %s", e.Policy, e.Budget.Type, e.Budget.Limit, e.Budget.Window)
+}
+
+// Limiter enforces the quota policies.
+type Limiter struct {
+	policies []*policy
+	now      func() time.Time
+}
+
+type policy struct {
+	*filterapi.QuotaPolicy
+	models map[string]struct{}
+	store  Store
+}
+
+// New creates a new [Limiter] from the given policies.
+func New(configs []filterapi.QuotaPolicy) (*Limiter, error) {
+	l := &Limiter{now: time.Now}
+	for i := range configs {
+		c := &configs[i]
+		if err := validate(c); err != nil {
+			_ = l.Close()
+			return nil, fmt.Errorf("invalid quota policy %s: %w", c.Name, err)
+		}
+		p := &policy{QuotaPolicy: c, models: make(map[string]struct{}, len(c.Models))}
+		for _, m := range c.Models {
+			p.models[m] = struct{}{}
+		}
+		if c.Redis != nil {
+			store, err := newRedisStore(c.Redis)
+			if err != nil {
+				_ = l.Close()
+				return nil, fmt.Errorf("invalid quota policy %s: %w", c.Name, err)
+			}
+			p.store = store
+		} else {
+			p.store = newMemoryStore()
+		}
+		l.policies = append(l.policies, p)
+	}
+	return l, nil
+}
+
+func validate(c *filterapi.QuotaPolicy) error {
+	if c.Name == "" {
+		return errors.New("name is required")
+	}
+	if c.ClientKeyHeader == "" {
+		return errors.New("client key header is required")
+	}
+	if c.Redis != nil && c.Redis.Address == "" {
+		return errors.New("redis address is required")
+	}
+	for _, b := range c.Budgets {
+		switch b.Type {
+		case filterapi.QuotaBudgetTypeInputToken, filterapi.QuotaBudgetTypeOutputToken, filterapi.QuotaBudgetTypeTotalToken,
+			filterapi.QuotaBudgetTypeSpend:
+		default:
+			return fmt.Errorf("unknown budget type: %s", b.Type)
+		}
+		if b.Limit <= 0 || b.Window <= 0 {
+			return fmt.Errorf("budget limit and window must be positive: %d per %s", b.Limit, b.Window)
+		}
+	}
+	return nil
+}
+
+// Reserve reserves the estimated usage of the request against the budgets of the client that apply to the model.
+// The client is identified by the request headers for each policy. This returns [*ExceededError] when a budget is
+// already exhausted or the estimate doesn't fit in the rest of the budget, in which case nothing is reserved.
+//
+// The returned reservation must be reconciled with the actual usage with [Reservation.Reconcile]. This returns
+// nil without an error when no policy applies to the request.
+func (l *Limiter) Reserve(ctx context.Context, model string, headers map[string]string, estimate Usage) (*Reservation, error) {
+	r := &Reservation{estimate: estimate, now: l.now}
+	now := l.now()
+	for _, p := range l.policies {
+		if _, ok := p.models[model]; len(p.models) > 0 && !ok {
+			continue
+		}
+		client := headers[p.ClientKeyHeader]
+		if client == "" || (len(p.ClientKeys) > 0 && !slices.Contains(p.ClientKeys, client)) {
+			continue
+		}
+		// The client key is usually a credential, so it is hashed not to be stored in the clear.
+		h := sha256.Sum256([]byte(client))
+		clientHash := hex.EncodeToString(h[:])
+		for _, b := range p.Budgets {
+			key := fmt.Sprintf("%s:%s:%s:%d", p.Name, clientHash, b.Type, b.Window.Milliseconds())
+			n := estimate.amount(b.Type)
+			spent, err := p.store.Add(ctx, key, b.Window, now, n)
+			if err != nil {
+				return nil, r.release(ctx, fmt.Errorf("failed to reserve quota %s: %w", p.Name, err))
+			}
+			r.budgets = append(r.budgets, reservedBudget{store: p.store, key: key, budget: b})
+			// The budget is exhausted if nothing is left before the reservation, and the estimate must also fit.
+			if spent-n >= b.Limit || spent > b.Limit {
+				return nil, r.release(ctx, &ExceededError{Policy: p.Name, Budget: b})
+			}
+		}
+	}
+	if len(r.budgets) == 0 {
+		return nil, nil
+	}
+	return r, nil
+}
+
+// Close releases the resources held by the stores. The in-flight reservations can still be reconciled after Close.
+func (l *Limiter) Close() error {
+	var errs []error
+	for _, p := range l.policies {
+		errs = append(errs, p.store.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Reservation is the estimated usage of a request reserved against the budgets.
+type Reservation struct {
+	budgets  []reservedBudget
+	estimate Usage
+	now      func() time.Time
+}
+
+type reservedBudget struct {
+	store  Store
+	key    string
+	budget filterapi.QuotaBudget
+}
+
+// Reconcile replaces the estimated usage with the actual usage of the request.
+//
+// The difference is added to the spend at the time of the call, which is in the window different from
+// the one of the reservation when the request spans the windows.
+func (r *Reservation) Reconcile(ctx context.Context, actual Usage) error {
+	now := r.now()
+	var errs []error
+	for _, rb := range r.budgets {
+		if delta := actual.amount(rb.budget.Type) - r.estimate.amount(rb.budget.Type); delta != 0 {
+			if _, err := rb.store.Add(ctx, rb.key, rb.budget.Window, now, delta); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// release gives back the budgets reserved so far when the reservation fails with the given cause,
+// and returns the cause joined with the error of the release if any.
+func (r *Reservation) release(ctx context.Context, cause error) error {
+	if err := r.Reconcile(ctx, Usage{}); err != nil {
+		return errors.Join(cause, fmt.Errorf("failed to release the reserved quota: %w", err))
+	}
+	return cause
+}
+
+// slidingSpend returns the spend within the window ending at now from the counters of the current and the previous
+// fixed windows. The previous one is weighted by its overlap with the sliding window, assuming the spend in it
+// was evenly distributed.
+func slidingSpend(window time.Duration, now time.Time, current, previous int64) int64 {
+	elapsed := now.UnixNano() % int64(window)
+	weight := 1 - float64(elapsed)/float64(window)
+	spend := current + int64(float64(previous)*weight)
+	return max(spend, 0)
+}
+
+// windowIndex returns the index of the fixed window containing now.
+func windowIndex(window time.Duration, now time.Time) int64 {
+	return now.UnixNano() / int64(window)
+}
@@ -0,0 +1,206 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestLimiter(t *testing.T) {
+	l, err := New([]filterapi.QuotaPolicy{
+		{
+			Name:            "ns/per-key",
+			Models:          []string{"gpt-4o"},
+			ClientKeyHeader: "x-api-key",
+			Budgets: []filterapi.QuotaBudget{
+				{Type: filterapi.QuotaBudgetTypeTotalToken, Limit: 100, Window: time.Hour},
+				{Type: filterapi.QuotaBudgetTypeOutputToken, Limit: 50, Window: time.Minute},
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, l.Close()) }()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	headers := map[string]string{"x-api-key": "sk-a"}
+
+	t.Run("not applicable", func(t *testing.T) {
+		r, err := l.Reserve(t.Context(), "gpt-4o-mini", headers, Usage{InputTokens: 1000})
+		require.NoError(t, err)
+		require.Nil(t, r)
+		r, err = l.Reserve(t.Context(), "gpt-4o", map[string]string{}, Usage{InputTokens: 1000})
+		require.NoError(t, err)
+		require.Nil(t, r)
+	})
+
+	r, err := l.Reserve(t.Context(), "gpt-4o", headers, Usage{InputTokens: 30, OutputTokens: 20})
+	require.NoError(t, err)
+	require.NotNil(t, r)
+	require.Len(t, r.budgets, 2)
+
+	// The estimate doesn't fit in the rest of the total budget.
+	_, err = l.Reserve(t.Context(), "gpt-4o", headers, Usage{InputTokens: 51})
+	var exceeded *ExceededError
+	require.ErrorAs(t, err, &exceeded)
+	require.Equal(t, "ns/per-key", exceeded.Policy)
+	require.Equal(t, filterapi.QuotaBudgetTypeTotalToken, exceeded.Budget.Type)
+	require.Equal(t, "quota ns/per-key exceeded: TotalToken budget of 100 tokens per 1h0m0s", err.Error())
+
+	// The other clients have their own budgets.
+	_, err = l.Reserve(t.Context(), "gpt-4o", map[string]string{"x-api-key": "sk-b"}, Usage{InputTokens: 90})
+	require.NoError(t, err)
+
+	// The actual usage is larger than the estimate.
+	require.NoError(t, r.Reconcile(t.Context(), Usage{InputTokens: 30, OutputTokens: 60}))
+	_, err = l.Reserve(t.Context(), "gpt-4o", headers, Usage{InputTokens: 1})
+	require.ErrorAs(t, err, &exceeded)
+	require.Equal(t, filterapi.QuotaBudgetTypeOutputToken, exceeded.Budget.Type)
+
+	// The output budget is refilled after its window, and the rejected reservations were released.
+	now = now.Add(2 * time.Minute)
+	r, err = l.Reserve(t.Context(), "gpt-4o", headers, Usage{InputTokens: 10})
+	require.NoError(t, err)
+	require.NotNil(t, r)
+	_, err = l.Reserve(t.Context(), "gpt-4o", headers, Usage{})
+	require.ErrorAs(t, err, &exceeded)
+	require.Equal(t, filterapi.QuotaBudgetTypeTotalToken, exceeded.Budget.Type)
+}
+
+func TestLimiter_ClientKeys(t *testing.T) {
+	l, err := New([]filterapi.QuotaPolicy{{
+		Name:            "ns/keys/key/a",
+		ClientKeyHeader: "x-ai-eg-virtual-key",
+		ClientKeys:      []string{"ns/keys/a"},
+		Budgets:         []filterapi.QuotaBudget{{Type: filterapi.QuotaBudgetTypeInputToken, Limit: 10, Window: time.Hour}},
+	}})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, l.Close()) }()
+
+	// The other keys are not subject to the policy.
+	r, err := l.Reserve(t.Context(), "gpt-4o", map[string]string{"x-ai-eg-virtual-key": "ns/keys/b"}, Usage{InputTokens: 100})
+	require.NoError(t, err)
+	require.Nil(t, r)
+	_, err = l.Reserve(t.Context(), "gpt-4o", map[string]string{"x-ai-eg-virtual-key": "ns/keys/a"}, Usage{InputTokens: 100})
+	var exceeded *ExceededError
+	require.ErrorAs(t, err, &exceeded)
+	require.Equal(t, "ns/keys/key/a", exceeded.Policy)
+}
+
+func TestLimiter_Spend(t *testing.T) {
+	l, err := New([]filterapi.QuotaPolicy{{
+		Name:            "ns/keys/key/a",
+		ClientKeyHeader: "x-ai-eg-virtual-key",
+		Budgets:         []filterapi.QuotaBudget{{Type: filterapi.QuotaBudgetTypeSpend, Limit: 1_000_000, Window: time.Hour}},
+	}})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, l.Close()) }()
+	headers := map[string]string{"x-ai-eg-virtual-key": "ns/keys/a"}
+
+	// The spend is not known up front, so the request is admitted while the budget is not exhausted.
+	r, err := l.Reserve(t.Context(), "gpt-4o", headers, Usage{InputTokens: 1000})
+	require.NoError(t, err)
+	require.NotNil(t, r)
+	require.NoError(t, r.Reconcile(t.Context(), Usage{InputTokens: 1000, SpendMicroUSD: 600_000}))
+	r, err = l.Reserve(t.Context(), "gpt-4o", headers, Usage{})
+	require.NoError(t, err)
+	require.NoError(t, r.Reconcile(t.Context(), Usage{SpendMicroUSD: 600_000}))
+
+	_, err = l.Reserve(t.Context(), "gpt-4o", headers, Usage{})
+	var exceeded *ExceededError
+	require.ErrorAs(t, err, &exceeded)
+	require.Equal(t, "quota ns/keys/key/a exceeded: Spend budget of 1.00 USD per 1h0m0s", err.Error())
+}
+
+// errStore is the [Store] that fails on the given call.
+type errStore struct {
+	*memoryStore
+	calls, failAt int
+}
+
+func (e *errStore) Add(ctx context.Context, key string, window time.Duration, now time.Time, n int64) (int64, error) {
+	e.calls++
+	if e.calls >= e.failAt {
+		return 0, errors.New("connection reset")
+	}
+	return e.memoryStore.Add(ctx, key, window, now, n)
+}
+
+func TestLimiter_StoreError(t *testing.T) {
+	l, err := New([]filterapi.QuotaPolicy{{
+		Name:            "p",
+		ClientKeyHeader: "x-api-key",
+		Budgets: []filterapi.QuotaBudget{
+			{Type: filterapi.QuotaBudgetTypeInputToken, Limit: 10, Window: time.Minute},
+			{Type: filterapi.QuotaBudgetTypeInputToken, Limit: 10, Window: time.Hour},
+		},
+	}})
+	require.NoError(t, err)
+	store := &errStore{memoryStore: newMemoryStore(), failAt: 2}
+	l.policies[0].store = store
+	_, err = l.Reserve(t.Context(), "any", map[string]string{"x-api-key": "k"}, Usage{InputTokens: 5})
+	require.EqualError(t, err, "failed to reserve quota p: connection reset\n"+
+		"failed to release the reserved quota: connection reset")
+}
+
+func TestNew(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		policy filterapi.QuotaPolicy
+		expErr string
+	}{
+		{name: "no name", policy: filterapi.QuotaPolicy{}, expErr: "name is required"},
+		{name: "no header", policy: filterapi.QuotaPolicy{Name: "p"}, expErr: "client key header is required"},
+		{
+			name:   "no redis address",
+			policy: filterapi.QuotaPolicy{Name: "p", ClientKeyHeader: "h", Redis: &filterapi.QuotaRedisConfig{}},
+			expErr: "redis address is required",
+		},
+		{
+			name: "invalid redis CA certificate",
+			policy: filterapi.QuotaPolicy{Name: "p", ClientKeyHeader: "h", Redis: &filterapi.QuotaRedisConfig{
+				Address: "localhost:6379", TLS: &filterapi.RedisTLSConfig{CACertificate: "invalid"},
+			}},
+			expErr: "no valid CA certificate found",
+		},
+		{
+			name: "unknown type",
+			policy: filterapi.QuotaPolicy{Name: "p", ClientKeyHeader: "h", Budgets: []filterapi.QuotaBudget{
+				{Type: "Image", Limit: 1, Window: time.Second},
+			}},
+			expErr: "unknown budget type: Image",
+		},
+		{
+			name: "zero window",
+			policy: filterapi.QuotaPolicy{Name: "p", ClientKeyHeader: "h", Budgets: []filterapi.QuotaBudget{
+				{Type: filterapi.QuotaBudgetTypeTotalToken, Limit: 1},
+			}},
+			expErr: "budget limit and window must be positive: 1 per 0s",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New([]filterapi.QuotaPolicy{tc.policy})
+			require.ErrorContains(t, err, tc.expErr)
+		})
+	}
+	l, err := New([]filterapi.QuotaPolicy{{Name: "p", ClientKeyHeader: "h", Redis: &filterapi.QuotaRedisConfig{Address: "localhost:6379"}}})
+	require.NoError(t, err)
+	require.IsType(t, &redisStore{}, l.policies[0].store)
+	require.NoError(t, l.Close())
+}
+
+func TestSlidingSpend(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, int64(110), slidingSpend(time.Minute, start, 10, 100))
+	require.Equal(t, int64(35), slidingSpend(time.Minute, start.Add(45*time.Second), 10, 100))
+	require.Equal(t, int64(0), slidingSpend(time.Minute, start, -10, 5))
+}
@@ -0,0 +1,102 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/redisclient"
+)
+
+// redisStore is the [Store] backed by a Redis-compatible server, so that the spend is shared across the filter instances.
+//
+// Each fixed window has its own counter key, which expires once it no longer overlaps with the sliding window.
+// The counter is incremented before checking the budget, so the concurrent requests never overshoot the budget
+// while they might be rejected with the spend that is released right after.
+type redisStore struct {
+	client    *redisclient.Client
+	keyPrefix string
+}
+
+func newRedisStore(config *filterapi.QuotaRedisConfig) (*redisStore, error) {
+	opts, err := redisclient.NewOptions(config.Username, config.Password, config.TLS)
+	if err != nil {
+		return nil, err
+	}
+	return &redisStore{client: redisclient.New(config.Address, opts), keyPrefix: config.KeyPrefix}, nil
+}
+
+// Add implements [Store.Add].
+func (r *redisStore) Add(ctx context.Context, key string, window time.Duration, now time.Time, n int64) (int64, error) {
+	index := windowIndex(window, now)
+	currentKey := r.keyPrefix + key + ":" + strconv.FormatInt(index, 10)
+	previousKey := r.keyPrefix + key + ":" + strconv.FormatInt(index-1, 10)
+
+	var current int64
+	var err error
+	if n == 0 {
+		// INCRBY would create the counter without the expiration.
+		current, err = r.get(ctx, currentKey)
+	} else {
+		// The counter and its expiration are set in a transaction, so that the counter never outlives the window
+		// when the connection is lost in between.
+		current, err = r.incrBy(ctx, currentKey, n, 2*window)
+	}
+	if err != nil {
+		return 0, err
+	}
+	previous, err := r.get(ctx, previousKey)
+	if err != nil {
+		return 0, err
+	}
+	return slidingSpend(window, now, current, previous), nil
+}
+
+// Close implements [Store.Close].
+func (r *redisStore) Close() error {
+	return r.client.Close()
+}
+
+func (r *redisStore) incrBy(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error) {
+	replies, err := r.client.Tx(ctx,
+		[]any{"INCRBY", key, strconv.FormatInt(n, 10)},
+		[]any{"PEXPIRE", key, strconv.FormatInt(ttl.Milliseconds(), 10)},
+	)
+	if err != nil {
+		return 0, err
+	}
+	switch v := replies[0].(type) {
+	case int64:
+		return v, nil
+	case redisclient.Error:
+		return 0, v
+	default:
+		return 0, fmt.Errorf("unexpected reply to INCRBY: %v", v)
+	}
+}
+
+func (r *redisStore) get(ctx context.Context, key string) (int64, error) {
+	reply, err := r.client.Do(ctx, "GET", key)
+	if errors.Is(err, redisclient.ErrNil) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	b, ok := reply.([]byte)
+	if !ok {
+		return 0, fmt.Errorf("unexpected reply to GET: %v", reply)
+	}
+	v, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid counter value %q: %w", b, err)
+	}
+	return v, nil
+}
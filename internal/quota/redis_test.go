@@ -0,0 +1,71 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package quota
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	internaltesting "github.com/envoyproxy/ai-gateway/internal/testing"
+)
+
+func TestRedisStore(t *testing.T) {
+	s := internaltesting.NewFakeRedisServer(t)
+	r, err := newRedisStore(&filterapi.QuotaRedisConfig{Address: s.Address(), KeyPrefix: "aigw:"})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	start := time.Unix(600, 0)
+
+	spent, err := r.Add(t.Context(), "k", time.Minute, start, 0)
+	require.NoError(t, err)
+	require.Zero(t, spent)
+	spent, err = r.Add(t.Context(), "k", time.Minute, start, 100)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), spent)
+	spent, err = r.Add(t.Context(), "k", time.Minute, start.Add(75*time.Second), 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(85), spent)
+
+	require.Equal(t, []string{
+		"GET aigw:k:10",
+		"GET aigw:k:9",
+		"MULTI",
+		"INCRBY aigw:k:10 100",
+		"PEXPIRE aigw:k:10 120000",
+		"EXEC",
+		"GET aigw:k:9",
+		"MULTI",
+		"INCRBY aigw:k:11 10",
+		"PEXPIRE aigw:k:11 120000",
+		"EXEC",
+		"GET aigw:k:10",
+	}, s.Commands())
+}
+
+func TestRedisStore_Errors(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+	r, err := newRedisStore(&filterapi.QuotaRedisConfig{Address: addr})
+	require.NoError(t, err)
+	_, err = r.Add(t.Context(), "k", time.Minute, time.Now(), 1)
+	require.ErrorContains(t, err, "failed to connect to redis")
+
+	s := internaltesting.NewFakeRedisServer(t)
+	r, err = newRedisStore(&filterapi.QuotaRedisConfig{Address: s.Address()})
+	require.NoError(t, err)
+	_, err = r.client.Do(t.Context(), "SET", "k:10", "abc")
+	require.NoError(t, err)
+	_, err = r.Add(t.Context(), "k", time.Minute, time.Unix(600, 0), 0)
+	require.ErrorContains(t, err, `invalid counter value "abc"`)
+	_, err = r.Add(t.Context(), "k", time.Minute, time.Unix(600, 0), 1)
+	require.ErrorContains(t, err, "redis: ERR value is not an integer or out of range")
+}
@@ -0,0 +1,326 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package redisclient provides a minimal client of the Redis-compatible servers speaking RESP.
+//
+// This only implements the small subset of the protocol needed by the filter, so that any Redis-compatible
+// server such as Valkey, KeyDB, Dragonfly, etc. can be used without extra dependencies.
+package redisclient
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+const (
+	// defaultTimeout is the timeout of each command when the context has no deadline.
+	defaultTimeout = time.Second
+	// maxIdleConns is the maximum number of idle connections kept in the pool.
+	maxIdleConns = 16
+)
+
+// Client sends the commands to a Redis-compatible server over the pooled connections.
+type Client struct {
+	address string
+	options Options
+	dialer  net.Dialer
+
+	mu     sync.Mutex
+	idle   []*conn
+	closed bool
+}
+
+// conn is a single connection to the server.
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// ErrNil is returned by [Client.Do] for the null reply, e.g. the key does not exist.
+var ErrNil = errors.New("redis: nil")
+
+// Error is the error reply returned by the server.
+type Error string
+
+// Error implements [error].
+func (e Error) Error() string { return "redis: " + string(e) }
+
+// Options configures the connections of a [Client]. The zero value connects over plain TCP without authentication.
+type Options struct {
+	// Username is the ACL user authenticated with the Password. When this is empty, the Password authenticates
+	// the default user.
+	Username string
+	// Password is sent with the AUTH command on each new connection when not empty.
+	Password string
+	// TLS enables TLS on the connections when not nil.
+	TLS *tls.Config
+}
+
+// NewOptions creates the [Options] from the settings of the filter configuration.
+func NewOptions(username, password string, tlsConfig *filterapi.RedisTLSConfig) (Options, error) {
+	opts := Options{Username: username, Password: password}
+	if tlsConfig != nil {
+		opts.TLS = &tls.Config{ServerName: tlsConfig.ServerName, MinVersion: tls.VersionTLS12}
+		if tlsConfig.CACertificate != "" {
+			opts.TLS.RootCAs = x509.NewCertPool()
+			if !opts.TLS.RootCAs.AppendCertsFromPEM([]byte(tlsConfig.CACertificate)) {
+				return Options{}, errors.New("no valid CA certificate found")
+			}
+		}
+	}
+	return opts, nil
+}
+
+// New creates a new [Client] for the server at the given host:port. The connections are dialed lazily.
+func New(address string, options Options) *Client {
+	return &Client{address: address, options: options}
+}
+
+// reply is a single reply read from the server.
+type reply struct {
+	value any
+	err   error
+}
+
+// Do sends the command to the server and returns the reply. Each argument must be a string or []byte.
+//
+// The simple strings and bulk strings are returned as []byte, the integers as int64 and the arrays as []any.
+// The null reply is returned as [ErrNil], and the error reply as [Error].
+func (c *Client) Do(ctx context.Context, args ...any) (any, error) {
+	replies, err := c.pipeline(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return replies[0].value, replies[0].err
+}
+
+// Tx sends the commands in a MULTI/EXEC transaction, so that they are executed atomically, and returns their replies
+// in order. See [Client.Do] for the types of the replies. The error reply of a single command is returned as the
+// [Error] element, while the error returned means that the transaction was not executed.
+func (c *Client) Tx(ctx context.Context, cmds ...[]any) ([]any, error) {
+	all := make([][]any, 0, len(cmds)+2)
+	all = append(all, []any{"MULTI"})
+	all = append(all, cmds...)
+	all = append(all, []any{"EXEC"})
+	replies, err := c.pipeline(ctx, all...)
+	if err != nil {
+		return nil, err
+	}
+	// The error replies to MULTI or to the queued commands abort the transaction, which are also reported by EXEC.
+	for _, r := range replies {
+		if r.err != nil {
+			return nil, r.err
+		}
+	}
+	elems, ok := replies[len(replies)-1].value.([]any)
+	if !ok || len(elems) != len(cmds) {
+		return nil, fmt.Errorf("unexpected reply to EXEC: %v", replies[len(replies)-1].value)
+	}
+	return elems, nil
+}
+
+// pipeline sends the commands on a single connection and reads their replies.
+func (c *Client) pipeline(ctx context.Context, cmds ...[]any) ([]reply, error) {
+	cn, err := c.conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultTimeout)
+	}
+	if err = cn.SetDeadline(deadline); err != nil {
+		_ = cn.Close()
+		return nil, err
+	}
+	var buf []byte
+	for _, cmd := range cmds {
+		buf = append(buf, EncodeCommand(cmd...)...)
+	}
+	if _, err = cn.Write(buf); err != nil {
+		_ = cn.Close()
+		return nil, fmt.Errorf("failed to send redis command: %w", err)
+	}
+	replies := make([]reply, len(cmds))
+	for i := range replies {
+		v, err := ReadReply(cn.r)
+		var serverErr Error
+		if err != nil && !errors.Is(err, ErrNil) && !errors.As(err, &serverErr) {
+			// The connection is in an unknown state, so it cannot be reused.
+			_ = cn.Close()
+			return nil, fmt.Errorf("failed to read redis reply: %w", err)
+		}
+		replies[i] = reply{value: v, err: err}
+	}
+	c.release(cn)
+	return replies, nil
+}
+
+// Close closes the idle connections. The client can still be used after Close by the in-flight requests,
+// but the connections are no longer pooled.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	var errs []error
+	for _, cn := range c.idle {
+		errs = append(errs, cn.Close())
+	}
+	c.idle = nil
+	return errors.Join(errs...)
+}
+
+// conn returns an idle connection or dials a new one.
+func (c *Client) conn(ctx context.Context) (*conn, error) {
+	c.mu.Lock()
+	if n := len(c.idle); n > 0 {
+		cn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return cn, nil
+	}
+	c.mu.Unlock()
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+	var nc net.Conn
+	var err error
+	if c.options.TLS != nil {
+		// The server name defaults to the host of the address when not set in the config.
+		d := tls.Dialer{NetDialer: &c.dialer, Config: c.options.TLS}
+		nc, err = d.DialContext(ctx, "tcp", c.address)
+	} else {
+		nc, err = c.dialer.DialContext(ctx, "tcp", c.address)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cn := &conn{Conn: nc, r: bufio.NewReader(nc)}
+	if c.options.Password != "" {
+		if err = cn.auth(ctx, c.options.Username, c.options.Password); err != nil {
+			_ = cn.Close()
+			return nil, fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+	return cn, nil
+}
+
+// auth authenticates the connection with the AUTH command.
+func (cn *conn) auth(ctx context.Context, username, password string) error {
+	deadline, _ := ctx.Deadline()
+	if err := cn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	args := []any{"AUTH", password}
+	if username != "" {
+		args = []any{"AUTH", username, password}
+	}
+	if _, err := cn.Write(EncodeCommand(args...)); err != nil {
+		return err
+	}
+	_, err := ReadReply(cn.r)
+	return err
+}
+
+// release returns the connection to the pool, or closes it if the pool is full or closed.
+func (c *Client) release(cn *conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || len(c.idle) >= maxIdleConns {
+		_ = cn.Close()
+		return
+	}
+	c.idle = append(c.idle, cn)
+}
+
+// EncodeCommand encodes the command as a RESP array of bulk strings. Each argument must be a string or []byte.
+func EncodeCommand(args ...any) []byte {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		var b []byte
+		switch v := arg.(type) {
+		case string:
+			b = []byte(v)
+		case []byte:
+			b = v
+		default:
+			panic(fmt.Sprintf("BUG: unsupported redis argument type %T", arg))
+		}
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(b)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, b...)
+		buf = append(buf, '\r', '\n')
+	}
+	return buf
+}
+
+// ReadReply reads a single RESP reply. See [Client.Do] for the types of the returned values.
+// The error replies nested in an array are returned as the [Error] elements.
+func ReadReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadSlice('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("invalid redis reply: %q", line)
+	}
+	kind, payload := line[0], string(line[1:len(line)-2])
+	switch kind {
+	case '+':
+		return []byte(payload), nil
+	case '-':
+		return nil, Error(payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis bulk string length: %w", err)
+		}
+		if n < 0 {
+			return nil, ErrNil
+		}
+		buf := make([]byte, n+2)
+		if _, err = io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis array length: %w", err)
+		}
+		if n < 0 {
+			return nil, ErrNil
+		}
+		elems := make([]any, n)
+		for i := range elems {
+			var serverErr Error
+			elems[i], err = ReadReply(r)
+			if errors.As(err, &serverErr) {
+				elems[i] = serverErr
+			} else if err != nil && !errors.Is(err, ErrNil) {
+				return nil, err
+			}
+		}
+		return elems, nil
+	default:
+		return nil, fmt.Errorf("unsupported redis reply type: %q", kind)
+	}
+}
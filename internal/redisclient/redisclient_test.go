@@ -0,0 +1,185 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package redisclient_test
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/redisclient"
+	internaltesting "github.com/envoyproxy/ai-gateway/internal/testing"
+)
+
+func TestClient(t *testing.T) {
+	s := internaltesting.NewFakeRedisServer(t)
+	c := redisclient.New(s.Address(), redisclient.Options{})
+	defer func() { require.NoError(t, c.Close()) }()
+
+	_, err := c.Do(t.Context(), "GET", "key")
+	require.ErrorIs(t, err, redisclient.ErrNil)
+	reply, err := c.Do(t.Context(), "SET", "key", []byte("a\r\nb"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("OK"), reply)
+	reply, err = c.Do(t.Context(), "GET", "key")
+	require.NoError(t, err)
+	require.Equal(t, []byte("a\r\nb"), reply)
+	reply, err = c.Do(t.Context(), "INCRBY", "counter", "3")
+	require.NoError(t, err)
+	require.Equal(t, int64(3), reply)
+
+	// The server error doesn't break the connection, which is reused across the commands.
+	_, err = c.Do(t.Context(), "PING")
+	require.ErrorContains(t, err, "redis: ERR unknown command")
+	var serverErr redisclient.Error
+	require.ErrorAs(t, err, &serverErr)
+	_, err = c.Do(t.Context(), "GET", "key")
+	require.NoError(t, err)
+	require.Equal(t, 1, s.Conns())
+}
+
+func TestClient_Tx(t *testing.T) {
+	s := internaltesting.NewFakeRedisServer(t)
+	c := redisclient.New(s.Address(), redisclient.Options{})
+	defer func() { require.NoError(t, c.Close()) }()
+
+	replies, err := c.Tx(t.Context(), []any{"INCRBY", "counter", "3"}, []any{"PEXPIRE", "counter", "1000"})
+	require.NoError(t, err)
+	require.Equal(t, []any{int64(3), int64(1)}, replies)
+
+	// The error reply of a single command is returned as the element.
+	_, err = c.Do(t.Context(), "SET", "key", "v")
+	require.NoError(t, err)
+	replies, err = c.Tx(t.Context(), []any{"INCRBY", "key", "1"}, []any{"GET", "key"})
+	require.NoError(t, err)
+	require.Len(t, replies, 2)
+	require.IsType(t, redisclient.Error(""), replies[0])
+	require.Equal(t, []byte("v"), replies[1])
+
+	// The transaction is aborted when a command cannot be queued.
+	_, err = c.Tx(t.Context(), []any{"PING"})
+	require.ErrorContains(t, err, "redis: ERR unknown command")
+	require.Equal(t, 1, s.Conns())
+	require.Equal(t, []string{
+		"MULTI", "INCRBY counter 3", "PEXPIRE counter 1000", "EXEC",
+		"SET key v",
+		"MULTI", "INCRBY key 1", "GET key", "EXEC",
+		"MULTI", "PING", "EXEC",
+	}, s.Commands())
+}
+
+func TestClient_Auth(t *testing.T) {
+	s := internaltesting.NewFakeRedisServer(t)
+	s.RequireAuth("user", "secret")
+
+	_, err := redisclient.New(s.Address(), redisclient.Options{}).Do(t.Context(), "GET", "key")
+	require.ErrorContains(t, err, "redis: NOAUTH Authentication required.")
+	_, err = redisclient.New(s.Address(), redisclient.Options{Password: "secret"}).Do(t.Context(), "GET", "key")
+	require.ErrorContains(t, err, "failed to connect to redis: failed to authenticate: redis: WRONGPASS")
+
+	c := redisclient.New(s.Address(), redisclient.Options{Username: "user", Password: "secret"})
+	defer func() { require.NoError(t, c.Close()) }()
+	for range 2 {
+		_, err = c.Do(t.Context(), "GET", "key")
+		require.ErrorIs(t, err, redisclient.ErrNil)
+	}
+	// The connection is authenticated once.
+	require.Equal(t, []string{"GET key", "AUTH secret", "AUTH user secret", "GET key", "GET key"}, s.Commands())
+}
+
+func TestClient_TLS(t *testing.T) {
+	s, ca := internaltesting.NewFakeRedisTLSServer(t)
+
+	opts, err := redisclient.NewOptions("", "", &filterapi.RedisTLSConfig{CACertificate: string(ca)})
+	require.NoError(t, err)
+	c := redisclient.New(s.Address(), opts)
+	defer func() { require.NoError(t, c.Close()) }()
+	_, err = c.Do(t.Context(), "SET", "key", "v")
+	require.NoError(t, err)
+
+	// The certificate is verified against the system roots by default.
+	opts, err = redisclient.NewOptions("", "", &filterapi.RedisTLSConfig{})
+	require.NoError(t, err)
+	_, err = redisclient.New(s.Address(), opts).Do(t.Context(), "GET", "key")
+	require.ErrorContains(t, err, "failed to connect to redis: tls: failed to verify certificate")
+
+	opts, err = redisclient.NewOptions("", "", &filterapi.RedisTLSConfig{CACertificate: string(ca), ServerName: "redis.example"})
+	require.NoError(t, err)
+	_, err = redisclient.New(s.Address(), opts).Do(t.Context(), "GET", "key")
+	require.ErrorContains(t, err, "certificate is valid for")
+}
+
+func TestNewOptions(t *testing.T) {
+	opts, err := redisclient.NewOptions("user", "secret", nil)
+	require.NoError(t, err)
+	require.Equal(t, redisclient.Options{Username: "user", Password: "secret"}, opts)
+
+	_, err = redisclient.NewOptions("", "", &filterapi.RedisTLSConfig{CACertificate: "not a certificate"})
+	require.ErrorContains(t, err, "no valid CA certificate found")
+}
+
+func TestClient_Errors(t *testing.T) {
+	t.Run("connection refused", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := l.Addr().String()
+		require.NoError(t, l.Close())
+		_, err = redisclient.New(addr, redisclient.Options{}).Do(t.Context(), "GET", "key")
+		require.ErrorContains(t, err, "failed to connect to redis")
+	})
+	t.Run("closed", func(t *testing.T) {
+		s := internaltesting.NewFakeRedisServer(t)
+		c := redisclient.New(s.Address(), redisclient.Options{})
+		_, err := c.Do(t.Context(), "SET", "key", "v")
+		require.NoError(t, err)
+		require.NoError(t, c.Close())
+		// The in-flight requests can still use the client, but the connection is not pooled.
+		for range 2 {
+			_, err = c.Do(t.Context(), "GET", "key")
+			require.NoError(t, err)
+		}
+		require.Equal(t, 3, s.Conns())
+	})
+}
+
+func TestReadReply(t *testing.T) {
+	for _, tc := range []struct {
+		in     string
+		exp    any
+		expErr string
+	}{
+		{in: "+OK\r\n", exp: []byte("OK")},
+		{in: ":42\r\n", exp: int64(42)},
+		{in: "$5\r\nhello\r\n", exp: []byte("hello")},
+		{in: "*2\r\n$1\r\na\r\n:1\r\n", exp: []any{[]byte("a"), int64(1)}},
+		{in: "*2\r\n$-1\r\n:1\r\n", exp: []any{nil, int64(1)}},
+		{in: "*2\r\n-ERR boom\r\n:1\r\n", exp: []any{redisclient.Error("ERR boom"), int64(1)}},
+		{in: "$-1\r\n", expErr: "redis: nil"},
+		{in: "-ERR boom\r\n", expErr: "redis: ERR boom"},
+		{in: "+OK\n", expErr: "invalid redis reply"},
+		{in: "!3\r\n", expErr: "unsupported redis reply type"},
+		{in: "$abc\r\n", expErr: "invalid redis bulk string length"},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			reply, err := redisclient.ReadReply(bufio.NewReader(bytes.NewReader([]byte(tc.in))))
+			if tc.expErr != "" {
+				require.ErrorContains(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, reply)
+		})
+	}
+}
+
+func TestEncodeCommand(t *testing.T) {
+	require.Equal(t, "*2\r\n$3\r\nGET\r\n$1\r\nk\r\n", string(redisclient.EncodeCommand("GET", []byte("k"))))
+	require.Panics(t, func() { redisclient.EncodeCommand(1) })
+}
@@ -0,0 +1,271 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package requestpolicy provides the request policies of the route rules, which limit the chat completion requests
+// before they are routed.
+//
+// [Policy.Apply] sets the default values to the fields the request doesn't set, and then either rejects the request
+// violating the limits with [*ViolationError], or clamps it to the limits.
+package requestpolicy
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/quota"
+)
+
+const (
+	// ModalityText is the modality of the text content.
+	ModalityText = "Text"
+	// ModalityImage is the modality of the image content parts.
+	ModalityImage = "Image"
+	// ModalityAudio is the modality of the input audio content parts and the audio output.
+	ModalityAudio = "Audio"
+)
+
+// Set is the set of the request policies of the route rules.
+type Set struct {
+	matches filterapi.RouteRuleMatcher[*Policy]
+}
+
+// NewSet creates a new [Set] from the given configurations. This returns nil if there's no configuration.
+func NewSet(configs []filterapi.RequestPolicy) *Set {
+	if len(configs) == 0 {
+		return nil
+	}
+	s := &Set{}
+	for i := range configs {
+		c := &configs[i]
+		p := &Policy{config: c}
+		for _, m := range c.Matches {
+			s.matches.Add(m, p)
+		}
+	}
+	return s
+}
+
+// Find returns the request policy of the route rule matching the given model and the request headers, or nil if
+// there's none or the set is nil. When multiple route rules match, the one matching the most headers is returned in
+// the same way as the precedence of the HTTPRoute rules.
+func (s *Set) Find(model string, headers map[string]string) *Policy {
+	if s == nil {
+		return nil
+	}
+	return s.matches.Find(model, headers)
+}
+
+// Policy is the request policy of a route rule.
+type Policy struct {
+	config *filterapi.RequestPolicy
+}
+
+// Name returns the name of the route rule of the policy.
+func (p *Policy) Name() string { return p.config.Name }
+
+// ViolationError is the error returned by [Policy.Apply] when the request violates the policy.
+type ViolationError struct {
+	// Param is the field of the request violating the policy.
+	Param string
+	// Message describes the violation.
+	Message string
+}
+
+// Error implements [error].
+func (e *ViolationError) Error() string { return e.Message }
+
+// Result is the result of [Policy.Apply] on the request which doesn't violate the policy.
+type Result struct {
+	// Body is the request body with the defaults and the clamps applied, which is nil when nothing is changed.
+	Body []byte
+	// Clamped is the list of the clamped fields, i.e. "max_tokens", "max_completion_tokens", "n" and "messages".
+	Clamped []string
+}
+
+// Apply applies the policy to the given raw and parsed request body. The defaults are set first, and then the limits
+// are enforced. This returns [*ViolationError] when the request violates the policy. When the body is changed, the
+// parsed body is replaced with the changed one as well.
+func (p *Policy) Apply(raw []byte, req *openai.ChatCompletionRequest) (*Result, error) {
+	c := p.config
+	for _, f := range c.ForbiddenFields {
+		if v := gjson.GetBytes(raw, f); v.Exists() && v.Type != gjson.Null {
+			return nil, &ViolationError{Param: f, Message: fmt.Sprintf("the %s field is not allowed", f)}
+		}
+	}
+
+	var res Result
+	body, parsed := raw, req
+	var err error
+	for _, d := range c.Defaults {
+		if gjson.GetBytes(body, d.Field).Exists() {
+			continue
+		}
+		if body, err = sjson.SetRawBytes(body, d.Field, d.Value); err != nil {
+			return nil, fmt.Errorf("failed to set the default of %s: %w", d.Field, err)
+		}
+		res.Body = body
+	}
+	if res.Body != nil {
+		// The defaults can change the modalities and the limited fields.
+		if parsed, err = parse(body); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = p.checkModalities(parsed); err != nil {
+		return nil, err
+	}
+	for _, l := range []struct {
+		field string
+		limit *filterapi.RequestPolicyLimit
+	}{
+		{field: "max_tokens", limit: c.MaxTokens},
+		{field: "max_completion_tokens", limit: c.MaxTokens},
+		{field: "n", limit: c.MaxN},
+	} {
+		v := gjson.GetBytes(body, l.field)
+		if l.limit == nil || v.Type != gjson.Number || v.Int() <= l.limit.Max {
+			continue
+		}
+		if !l.limit.Clamp {
+			return nil, &ViolationError{Param: l.field, Message: fmt.Sprintf("%s must be less than or equal to %d", l.field, l.limit.Max)}
+		}
+		if body, err = sjson.SetBytes(body, l.field, l.limit.Max); err != nil {
+			return nil, fmt.Errorf("failed to clamp %s: %w", l.field, err)
+		}
+		res.Body = body
+		res.Clamped = append(res.Clamped, l.field)
+	}
+	if m := c.MaxMessages; m != nil && int64(len(parsed.Messages)) > m.Max {
+		if !m.Clamp {
+			return nil, &ViolationError{Param: "messages", Message: fmt.Sprintf("the number of the messages must be less than or equal to %d", m.Max)}
+		}
+		if body, err = clampMessages(body, m.Max); err != nil {
+			return nil, err
+		}
+		res.Body = body
+		res.Clamped = append(res.Clamped, "messages")
+	}
+	if res.Body != nil {
+		if parsed, err = parse(body); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.MaxPromptBytes > 0 && int64(len(gjson.GetBytes(body, "messages").Raw)) > c.MaxPromptBytes {
+		return nil, &ViolationError{Param: "messages", Message: fmt.Sprintf("the messages must be less than or equal to %d bytes", c.MaxPromptBytes)}
+	}
+	if c.MaxEstimatedPromptTokens > 0 && quota.EstimateChatCompletion(parsed).InputTokens > c.MaxEstimatedPromptTokens {
+		return nil, &ViolationError{
+			Param:   "messages",
+			Message: fmt.Sprintf("the estimated number of the tokens of the prompt must be less than or equal to %d", c.MaxEstimatedPromptTokens),
+		}
+	}
+	if res.Body != nil {
+		*req = *parsed
+	}
+	return &res, nil
+}
+
+// parse parses the changed request body.
+func parse(body []byte) (*openai.ChatCompletionRequest, error) {
+	var req openai.ChatCompletionRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("failed to parse the changed request: %w", err)
+	}
+	return &req, nil
+}
+
+// checkModalities returns [*ViolationError] when the request uses the modality not allowed by the policy.
+func (p *Policy) checkModalities(req *openai.ChatCompletionRequest) error {
+	allowed := p.config.AllowedModalities
+	if len(allowed) == 0 {
+		return nil
+	}
+	check := func(modality, param string) error {
+		if slices.Contains(allowed, modality) {
+			return nil
+		}
+		return &ViolationError{Param: param, Message: fmt.Sprintf("the %s modality is not allowed", strings.ToLower(modality))}
+	}
+	for _, msg := range req.Messages {
+		user, ok := msg.Value.(openai.ChatCompletionUserMessageParam)
+		if !ok {
+			// The other messages only have the text content.
+			if err := check(ModalityText, "messages"); err != nil {
+				return err
+			}
+			continue
+		}
+		parts, ok := user.Content.Value.([]openai.ChatCompletionContentPartUserUnionParam)
+		if !ok {
+			if err := check(ModalityText, "messages"); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, part := range parts {
+			var err error
+			switch {
+			case part.TextContent != nil:
+				err = check(ModalityText, "messages")
+			case part.ImageContent != nil:
+				err = check(ModalityImage, "messages")
+			case part.InputAudioContent != nil:
+				err = check(ModalityAudio, "messages")
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if req.Audio != nil || slices.Contains(req.Modalities, openai.ChatCompletionModalityAudio) {
+		return check(ModalityAudio, "modalities")
+	}
+	return nil
+}
+
+// clampMessages drops the oldest messages following the leading system and developer messages so that the number of
+// the messages doesn't exceed the given maximum, along with the tool messages left without the assistant message
+// calling them.
+func clampMessages(body []byte, limit int64) ([]byte, error) {
+	messages := gjson.GetBytes(body, "messages").Array()
+	leading := 0
+	for leading < len(messages) {
+		if role := messages[leading].Get("role").String(); role != "system" && role != "developer" {
+			break
+		}
+		leading++
+	}
+	if int64(leading) >= limit {
+		return nil, &ViolationError{
+			Param:   "messages",
+			Message: fmt.Sprintf("the number of the system and developer messages must be less than %d", limit),
+		}
+	}
+	rest := messages[len(messages)-int(limit)+leading:]
+	for len(rest) > 0 && rest[0].Get("role").String() == "tool" {
+		rest = rest[1:]
+	}
+	kept := make([]string, 0, leading+len(rest))
+	for _, m := range messages[:leading] {
+		kept = append(kept, m.Raw)
+	}
+	for _, m := range rest {
+		kept = append(kept, m.Raw)
+	}
+	body, err := sjson.SetRawBytes(body, "messages", []byte("["+strings.Join(kept, ",")+"]"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to clamp messages: %w", err)
+	}
+	return body, nil
+}
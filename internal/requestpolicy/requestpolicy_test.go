@@ -0,0 +1,193 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package requestpolicy
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestSet_Find(t *testing.T) {
+	require.Nil(t, NewSet(nil))
+	require.Nil(t, (*Set)(nil).Find("m", nil))
+
+	s := NewSet([]filterapi.RequestPolicy{
+		{Name: "default", Matches: []filterapi.RouteRuleMatch{{Model: "m"}}},
+		{Name: "free", Matches: []filterapi.RouteRuleMatch{{Model: "m", Headers: map[string]string{"x-tier": "free"}}}},
+		{Name: "other", Matches: []filterapi.RouteRuleMatch{{Model: "m2"}}},
+	})
+	require.Equal(t, "default", s.Find("m", map[string]string{}).Name())
+	require.Equal(t, "default", s.Find("m", map[string]string{"x-tier": "paid"}).Name())
+	require.Equal(t, "free", s.Find("m", map[string]string{"x-tier": "free"}).Name())
+	require.Equal(t, "other", s.Find("m2", nil).Name())
+	require.Nil(t, s.Find("unknown", nil))
+}
+
+func TestPolicy_Apply(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		config     filterapi.RequestPolicy
+		body       string
+		expBody    string
+		expClamped []string
+		expErr     *ViolationError
+	}{
+		{
+			name:   "no limit",
+			config: filterapi.RequestPolicy{},
+			body:   `{"model":"m","messages":[{"role":"user","content":"hi"}],"max_tokens":200000}`,
+		},
+		{
+			name:   "forbidden field",
+			config: filterapi.RequestPolicy{ForbiddenFields: []string{"audio", "logprobs"}},
+			body:   `{"model":"m","messages":[],"logprobs":true}`,
+			expErr: &ViolationError{Param: "logprobs", Message: "the logprobs field is not allowed"},
+		},
+		{
+			name:   "forbidden field set to null",
+			config: filterapi.RequestPolicy{ForbiddenFields: []string{"logprobs"}},
+			body:   `{"model":"m","messages":[],"logprobs":null}`,
+		},
+		{
+			name: "defaults",
+			config: filterapi.RequestPolicy{Defaults: []filterapi.RequestPolicyDefault{
+				{Field: "temperature", Value: json.RawMessage(`0.2`)},
+				{Field: "max_completion_tokens", Value: json.RawMessage(`1024`)},
+			}},
+			body:    `{"model":"m","messages":[],"temperature":1}`,
+			expBody: `{"model":"m","messages":[],"temperature":1,"max_completion_tokens":1024}`,
+		},
+		{
+			name: "default clamped",
+			config: filterapi.RequestPolicy{
+				MaxTokens: &filterapi.RequestPolicyLimit{Max: 512, Clamp: true},
+				Defaults:  []filterapi.RequestPolicyDefault{{Field: "max_completion_tokens", Value: json.RawMessage(`1024`)}},
+			},
+			body:       `{"model":"m","messages":[]}`,
+			expBody:    `{"model":"m","messages":[],"max_completion_tokens":512}`,
+			expClamped: []string{"max_completion_tokens"},
+		},
+		{
+			name:   "max tokens rejected",
+			config: filterapi.RequestPolicy{MaxTokens: &filterapi.RequestPolicyLimit{Max: 4096}},
+			body:   `{"model":"m","messages":[],"max_tokens":200000}`,
+			expErr: &ViolationError{Param: "max_tokens", Message: "max_tokens must be less than or equal to 4096"},
+		},
+		{
+			name:   "max tokens within the limit",
+			config: filterapi.RequestPolicy{MaxTokens: &filterapi.RequestPolicyLimit{Max: 4096}},
+			body:   `{"model":"m","messages":[],"max_completion_tokens":4096}`,
+		},
+		{
+			name: "clamped",
+			config: filterapi.RequestPolicy{
+				MaxTokens: &filterapi.RequestPolicyLimit{Max: 4096, Clamp: true},
+				MaxN:      &filterapi.RequestPolicyLimit{Max: 1, Clamp: true},
+			},
+			body:       `{"model":"m","messages":[],"max_tokens":200000,"max_completion_tokens":100000,"n":4}`,
+			expBody:    `{"model":"m","messages":[],"max_tokens":4096,"max_completion_tokens":4096,"n":1}`,
+			expClamped: []string{"max_tokens", "max_completion_tokens", "n"},
+		},
+		{
+			name:   "n rejected",
+			config: filterapi.RequestPolicy{MaxN: &filterapi.RequestPolicyLimit{Max: 2}},
+			body:   `{"model":"m","messages":[],"n":3}`,
+			expErr: &ViolationError{Param: "n", Message: "n must be less than or equal to 2"},
+		},
+		{
+			name:   "messages rejected",
+			config: filterapi.RequestPolicy{MaxMessages: &filterapi.RequestPolicyLimit{Max: 1}},
+			body:   `{"model":"m","messages":[{"role":"user","content":"a"},{"role":"user","content":"b"}]}`,
+			expErr: &ViolationError{Param: "messages", Message: "the number of the messages must be less than or equal to 1"},
+		},
+		{
+			name:   "messages clamped",
+			config: filterapi.RequestPolicy{MaxMessages: &filterapi.RequestPolicyLimit{Max: 4, Clamp: true}},
+			body: `{"model":"m","messages":[{"role":"system","content":"s"},{"role":"user","content":"1"},` +
+				`{"role":"assistant","tool_calls":[{"id":"c","type":"function","function":{"name":"f","arguments":"{}"}}]},` +
+				`{"role":"tool","tool_call_id":"c","content":"r"},{"role":"assistant","content":"2"},{"role":"user","content":"3"}]}`,
+			// The tool message is dropped along with the assistant message calling it.
+			expBody:    `{"model":"m","messages":[{"role":"system","content":"s"},{"role":"assistant","content":"2"},{"role":"user","content":"3"}]}`,
+			expClamped: []string{"messages"},
+		},
+		{
+			name:   "system messages exceeding the limit",
+			config: filterapi.RequestPolicy{MaxMessages: &filterapi.RequestPolicyLimit{Max: 2, Clamp: true}},
+			body: `{"model":"m","messages":[{"role":"system","content":"s"},{"role":"developer","content":"d"},` +
+				`{"role":"user","content":"1"}]}`,
+			expErr: &ViolationError{Param: "messages", Message: "the number of the system and developer messages must be less than 2"},
+		},
+		{
+			name:   "prompt bytes",
+			config: filterapi.RequestPolicy{MaxPromptBytes: 40},
+			body:   `{"model":"m","messages":[{"role":"user","content":"` + strings.Repeat("a", 40) + `"}]}`,
+			expErr: &ViolationError{Param: "messages", Message: "the messages must be less than or equal to 40 bytes"},
+		},
+		{
+			name: "prompt bytes after clamping messages",
+			config: filterapi.RequestPolicy{
+				MaxMessages:    &filterapi.RequestPolicyLimit{Max: 1, Clamp: true},
+				MaxPromptBytes: 40,
+			},
+			body:       `{"model":"m","messages":[{"role":"user","content":"` + strings.Repeat("a", 40) + `"},{"role":"user","content":"b"}]}`,
+			expBody:    `{"model":"m","messages":[{"role":"user","content":"b"}]}`,
+			expClamped: []string{"messages"},
+		},
+		{
+			name:   "estimated prompt tokens",
+			config: filterapi.RequestPolicy{MaxEstimatedPromptTokens: 10},
+			body:   `{"model":"m","messages":[{"role":"user","content":"` + strings.Repeat("a", 40) + `"}]}`,
+			expErr: &ViolationError{Param: "messages", Message: "the estimated number of the tokens of the prompt must be less than or equal to 10"},
+		},
+		{
+			name:   "image not allowed",
+			config: filterapi.RequestPolicy{AllowedModalities: []string{ModalityText}},
+			body: `{"model":"m","messages":[{"role":"user","content":[{"type":"text","text":"what is this?"},` +
+				`{"type":"image_url","image_url":{"url":"https://example.com/cat.png"}}]}]}`,
+			expErr: &ViolationError{Param: "messages", Message: "the image modality is not allowed"},
+		},
+		{
+			name:   "audio output not allowed",
+			config: filterapi.RequestPolicy{AllowedModalities: []string{ModalityText, ModalityImage}},
+			body:   `{"model":"m","messages":[{"role":"user","content":"hi"}],"modalities":["text","audio"]}`,
+			expErr: &ViolationError{Param: "modalities", Message: "the audio modality is not allowed"},
+		},
+		{
+			name:   "text not allowed",
+			config: filterapi.RequestPolicy{AllowedModalities: []string{ModalityAudio}},
+			body:   `{"model":"m","messages":[{"role":"system","content":"s"}]}`,
+			expErr: &ViolationError{Param: "messages", Message: "the text modality is not allowed"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var req openai.ChatCompletionRequest
+			require.NoError(t, json.Unmarshal([]byte(tc.body), &req))
+			p := &Policy{config: &tc.config}
+			res, err := p.Apply([]byte(tc.body), &req)
+			if tc.expErr != nil {
+				require.Equal(t, tc.expErr, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expClamped, res.Clamped)
+			if tc.expBody == "" {
+				require.Nil(t, res.Body)
+				return
+			}
+			require.JSONEq(t, tc.expBody, string(res.Body))
+			// The parsed body is replaced as well.
+			var exp openai.ChatCompletionRequest
+			require.NoError(t, json.Unmarshal([]byte(tc.expBody), &exp))
+			require.Equal(t, exp, req)
+		})
+	}
+}
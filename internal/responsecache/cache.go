@@ -0,0 +1,68 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package responsecache provides the exact-match and semantic cache of the chat completion responses
+// used by the external processor to serve identical or similar requests without calling the backends.
+package responsecache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// Cache is the storage of the cached responses keyed by [Key].
+type Cache interface {
+	// Get returns the cached response for the given key. The second return value is false on a cache miss.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores the response for the given key.
+	Set(ctx context.Context, key string, value []byte) error
+	// Close releases the resources held by the cache. The cache can still be used after Close
+	// by the in-flight requests, but it will not hold any idle resources.
+	Close() error
+}
+
+// New creates a new [Cache] from the given configuration.
+func New(config *filterapi.ResponseCacheConfig) (Cache, error) {
+	if config.Redis != nil {
+		if config.Redis.Address == "" {
+			return nil, fmt.Errorf("redis address is required")
+		}
+		return newRedis(config.Redis, config.TTL)
+	}
+	return newLRU(config.MaxEntries, config.TTL), nil
+}
+
+// Key returns the cache key of the given chat completion request for the given model on the given backend.
+//
+// The model is the one resolved by the router filter, so it is used instead of the one in the request. The backend is
+// the name of the backend selected for the request, since the backends of the same model, e.g. the weighted ones or
+// the ones with the model name overrides, don't necessarily return the same response.
+//
+// The streaming options are not part of the key since both streaming and non-streaming requests are
+// served from the same cached response. The rest of the request is normalized by re-encoding the parsed request,
+// so the difference in the field order and the white spaces doesn't result in a different key.
+func Key(backend, model string, req *openai.ChatCompletionRequest) (string, error) {
+	normalized := *req
+	normalized.Model = ""
+	normalized.Stream = false
+	normalized.StreamOptions = nil
+	encoded, err := json.Marshal(&normalized)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	h := sha256.New()
+	h.Write([]byte(backend))
+	h.Write([]byte{0})
+	h.Write([]byte(model))
+	h.Write([]byte{0})
+	h.Write(encoded)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
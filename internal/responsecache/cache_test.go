@@ -0,0 +1,70 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package responsecache
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		c, err := New(&filterapi.ResponseCacheConfig{MaxEntries: 10})
+		require.NoError(t, err)
+		require.IsType(t, &lru{}, c)
+	})
+	t.Run("redis", func(t *testing.T) {
+		c, err := New(&filterapi.ResponseCacheConfig{Redis: &filterapi.ResponseCacheRedisConfig{Address: "localhost:6379"}})
+		require.NoError(t, err)
+		require.IsType(t, &redis{}, c)
+		require.NoError(t, c.Close())
+	})
+	t.Run("redis without address", func(t *testing.T) {
+		_, err := New(&filterapi.ResponseCacheConfig{Redis: &filterapi.ResponseCacheRedisConfig{}})
+		require.ErrorContains(t, err, "redis address is required")
+	})
+	t.Run("redis with invalid CA certificate", func(t *testing.T) {
+		_, err := New(&filterapi.ResponseCacheConfig{Redis: &filterapi.ResponseCacheRedisConfig{
+			Address: "localhost:6379", TLS: &filterapi.RedisTLSConfig{CACertificate: "invalid"},
+		}})
+		require.ErrorContains(t, err, "no valid CA certificate found")
+	})
+}
+
+func TestKey(t *testing.T) {
+	parse := func(body string) *openai.ChatCompletionRequest {
+		var req openai.ChatCompletionRequest
+		require.NoError(t, json.Unmarshal([]byte(body), &req))
+		return &req
+	}
+	key := func(model, body string) string {
+		k, err := Key("openai", model, parse(body))
+		require.NoError(t, err)
+		return k
+	}
+
+	base := key("gpt-4o", `{"model":"gpt-4o","temperature":0,"messages":[{"role":"user","content":"hi"}]}`)
+	require.Len(t, base, 64)
+
+	// The field order, white spaces and the streaming options don't matter.
+	require.Equal(t, base, key("gpt-4o", `{ "messages": [{"content":"hi","role":"user"}], "temperature": 0, "model": "gpt-4o" }`))
+	require.Equal(t, base, key("gpt-4o", `{"model":"gpt-4o","temperature":0,"stream":true,"stream_options":{"include_usage":true},"messages":[{"role":"user","content":"hi"}]}`))
+	// The resolved model is used instead of the one in the request.
+	require.Equal(t, base, key("gpt-4o", `{"model":"alias","temperature":0,"messages":[{"role":"user","content":"hi"}]}`))
+
+	require.NotEqual(t, base, key("gpt-4o-mini", `{"model":"gpt-4o","temperature":0,"messages":[{"role":"user","content":"hi"}]}`))
+	// The responses of the different backends of the same model are cached separately.
+	other, err := Key("aws", "gpt-4o", parse(`{"model":"gpt-4o","temperature":0,"messages":[{"role":"user","content":"hi"}]}`))
+	require.NoError(t, err)
+	require.NotEqual(t, base, other)
+	require.NotEqual(t, base, key("gpt-4o", `{"model":"gpt-4o","temperature":0,"messages":[{"role":"user","content":"hello"}]}`))
+	require.NotEqual(t, base, key("gpt-4o", `{"model":"gpt-4o","temperature":0.1,"messages":[{"role":"user","content":"hi"}]}`))
+}
@@ -0,0 +1,175 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package responsecache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/backendauth"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+)
+
+// Embedder returns the embedding of the text used by the semantic lookup.
+type Embedder interface {
+	// Embed returns the embedding of the given text.
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+// embedder is the [Embedder] that calls the embeddings backend directly from the filter.
+//
+// The request is built in the OpenAI format and then translated to the backend schema, followed by the backend auth,
+// in the same way as the requests to the "/v1/embeddings" endpoint are processed at the upstream filter.
+type embedder struct {
+	endpoint   string
+	model      string
+	timeout    time.Duration
+	schema     filterapi.VersionedAPISchema
+	handler    backendauth.Handler
+	httpClient *http.Client
+}
+
+// newEmbedder creates a new [Embedder] for the given embeddings backend.
+func newEmbedder(ctx context.Context, config *filterapi.SemanticResponseCacheEmbedding) (*embedder, error) {
+	if config.Endpoint == "" {
+		return nil, fmt.Errorf("embedding endpoint is required")
+	}
+	e := &embedder{
+		endpoint:   strings.TrimSuffix(config.Endpoint, "/"),
+		model:      config.Model,
+		timeout:    config.Timeout,
+		schema:     config.Backend.Schema,
+		httpClient: http.DefaultClient,
+	}
+	// Validate the schema at the configuration time rather than on each request.
+	if _, err := e.newTranslator(); err != nil {
+		return nil, err
+	}
+	if config.Backend.Auth != nil {
+		var err error
+		e.handler, err = backendauth.NewHandler(ctx, config.Backend.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create embedding backend auth handler: %w", err)
+		}
+	}
+	return e, nil
+}
+
+// newTranslator returns a new translator for the backend schema. The translator is created per request since
+// some of the translators hold the state of the request.
+func (e *embedder) newTranslator() (translator.OpenAIEmbeddingTranslator, error) {
+	switch e.schema.Name {
+	case filterapi.APISchemaOpenAI:
+		return translator.NewEmbeddingOpenAIToOpenAITranslator(e.schema.Version, ""), nil
+	case filterapi.APISchemaAWSBedrock:
+		return translator.NewEmbeddingOpenAIToAWSBedrockTranslator(""), nil
+	case filterapi.APISchemaGCPVertexAI:
+		return translator.NewEmbeddingOpenAIToGCPVertexAITranslator(""), nil
+	case filterapi.APISchemaAzureOpenAI:
+		return translator.NewEmbeddingOpenAIToAzureOpenAITranslator(e.schema.Version, ""), nil
+	default:
+		return nil, fmt.Errorf("unsupported API schema for embedding: backend=%s", e.schema)
+	}
+}
+
+// Embed implements [Embedder.Embed].
+func (e *embedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+	tr, err := e.newTranslator()
+	if err != nil {
+		return nil, err
+	}
+	req := &openai.EmbeddingRequest{Model: e.model, Input: openai.StringOrArray{Value: text}}
+	raw, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode embedding request: %w", err)
+	}
+	// The body mutation is forced so that the auth handlers can always see the body to be sent.
+	headerMutation, bodyMutation, err := tr.RequestBody(raw, req, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform embedding request: %w", err)
+	}
+	requestHeaders := map[string]string{":method": http.MethodPost}
+	for _, h := range headerMutation.GetSetHeaders() {
+		requestHeaders[h.Header.Key] = string(h.Header.RawValue)
+	}
+	if e.handler != nil {
+		if err = e.handler.Do(ctx, requestHeaders, headerMutation, bodyMutation); err != nil {
+			return nil, fmt.Errorf("failed to do auth request: %w", err)
+		}
+	}
+	if b := bodyMutation.GetBody(); b != nil {
+		raw = b
+	}
+
+	var path string
+	header := http.Header{"Content-Type": []string{"application/json"}}
+	for _, h := range headerMutation.GetSetHeaders() {
+		switch key := h.Header.Key; {
+		case key == ":path":
+			path = string(h.Header.RawValue)
+		case strings.HasPrefix(key, ":"), strings.EqualFold(key, "content-length"):
+			// The pseudo headers and the content length are derived from the request itself.
+		default:
+			header.Set(key, string(h.Header.RawValue))
+		}
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint+path, bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding request: %w", err)
+	}
+	httpReq.Header = header
+
+	resp, err := e.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send embedding request: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedding response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embedding backend returned status %d: %s", resp.StatusCode, respBody)
+	}
+
+	respHeaders := map[string]string{":status": strconv.Itoa(resp.StatusCode)}
+	for k := range resp.Header {
+		respHeaders[strings.ToLower(k)] = resp.Header.Get(k)
+	}
+	_, respMutation, _, err := tr.ResponseBody(respHeaders, bytes.NewReader(respBody), true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform embedding response: %w", err)
+	}
+	if b := respMutation.GetBody(); b != nil {
+		respBody = b
+	}
+	var embeddingResp openai.EmbeddingResponse
+	if err = json.Unmarshal(respBody, &embeddingResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal embedding response: %w", err)
+	}
+	if len(embeddingResp.Data) == 0 || len(embeddingResp.Data[0].Embedding) == 0 {
+		return nil, fmt.Errorf("embedding response has no embedding")
+	}
+	ret := make([]float32, len(embeddingResp.Data[0].Embedding))
+	for i, v := range embeddingResp.Data[0].Embedding {
+		ret[i] = float32(v)
+	}
+	return ret, nil
+}
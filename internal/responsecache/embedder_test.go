@@ -0,0 +1,116 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package responsecache
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestEmbedder(t *testing.T) {
+	t.Run("openai", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/v1/embeddings", r.URL.Path)
+			require.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+			require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			var req openai.EmbeddingRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			require.Equal(t, "text-embedding-3-small", req.Model)
+			require.Equal(t, "hello", req.Input.Value)
+			_, _ = w.Write([]byte(`{"object":"list","data":[{"object":"embedding","embedding":[0.5,-0.25],"index":0}],"usage":{"prompt_tokens":1,"total_tokens":1}}`))
+		}))
+		defer srv.Close()
+		e, err := newEmbedder(t.Context(), &filterapi.SemanticResponseCacheEmbedding{
+			Endpoint: srv.URL + "/",
+			Model:    "text-embedding-3-small",
+			Backend: filterapi.Backend{
+				Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI, Version: "v1"},
+				Auth:   &filterapi.BackendAuth{APIKey: &filterapi.APIKeyAuth{Key: "sk-test"}},
+			},
+		})
+		require.NoError(t, err)
+		v, err := e.Embed(t.Context(), "hello")
+		require.NoError(t, err)
+		require.Equal(t, []float32{0.5, -0.25}, v)
+	})
+	t.Run("azure", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/openai/deployments/embedding/embeddings", r.URL.Path)
+			require.Equal(t, "2024-10-21", r.URL.Query().Get("api-version"))
+			_, _ = w.Write([]byte(`{"data":[{"embedding":[1]}]}`))
+		}))
+		defer srv.Close()
+		e, err := newEmbedder(t.Context(), &filterapi.SemanticResponseCacheEmbedding{
+			Endpoint: srv.URL,
+			Model:    "embedding",
+			Backend:  filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAzureOpenAI, Version: "2024-10-21"}},
+		})
+		require.NoError(t, err)
+		v, err := e.Embed(t.Context(), "hello")
+		require.NoError(t, err)
+		require.Equal(t, []float32{1}, v)
+	})
+	t.Run("errors", func(t *testing.T) {
+		for _, tc := range []struct {
+			name    string
+			status  int
+			body    string
+			timeout time.Duration
+			expErr  string
+		}{
+			{name: "status", status: http.StatusTooManyRequests, body: "slow down", expErr: "embedding backend returned status 429: slow down"},
+			{name: "invalid body", status: http.StatusOK, body: "{", expErr: "failed to transform embedding response"},
+			{name: "no embedding", status: http.StatusOK, body: `{"data":[]}`, expErr: "embedding response has no embedding"},
+			{name: "timeout", status: http.StatusOK, body: `{"data":[{"embedding":[1]}]}`, timeout: time.Millisecond, expErr: "context deadline exceeded"},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				release := make(chan struct{})
+				srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					if tc.timeout > 0 {
+						<-release
+					}
+					w.WriteHeader(tc.status)
+					_, _ = w.Write([]byte(tc.body))
+				}))
+				defer srv.Close()
+				defer close(release)
+				e, err := newEmbedder(t.Context(), &filterapi.SemanticResponseCacheEmbedding{
+					Endpoint: srv.URL,
+					Timeout:  tc.timeout,
+					Backend:  filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI, Version: "v1"}},
+				})
+				require.NoError(t, err)
+				_, err = e.Embed(t.Context(), "hello")
+				require.ErrorContains(t, err, tc.expErr)
+			})
+		}
+	})
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := newEmbedder(t.Context(), &filterapi.SemanticResponseCacheEmbedding{})
+		require.ErrorContains(t, err, "embedding endpoint is required")
+		_, err = newEmbedder(t.Context(), &filterapi.SemanticResponseCacheEmbedding{
+			Endpoint: "http://localhost",
+			Backend:  filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAnthropic}},
+		})
+		require.ErrorContains(t, err, "unsupported API schema for embedding")
+		_, err = newEmbedder(t.Context(), &filterapi.SemanticResponseCacheEmbedding{
+			Endpoint: "http://localhost",
+			Backend: filterapi.Backend{
+				Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+				Auth:   &filterapi.BackendAuth{},
+			},
+		})
+		require.ErrorContains(t, err, "cannot create embedding backend auth handler")
+	})
+}
@@ -0,0 +1,361 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package responsecache
+
+import (
+	"container/heap"
+	"container/list"
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sync"
+)
+
+// VectorStore is the storage of the embeddings used by the semantic lookup.
+//
+// The embeddings are grouped into partitions, and only the embeddings in the same partition are compared.
+// Each embedding is associated with the key of the cached response in the [Cache].
+type VectorStore interface {
+	// Search returns the key of the most similar embedding to the given one in the partition with its cosine similarity.
+	// The third return value is false if the partition is empty.
+	Search(ctx context.Context, partition string, vector []float32) (key string, similarity float32, found bool, err error)
+	// Add stores the embedding for the key in the partition, replacing the existing one for the same key.
+	Add(ctx context.Context, partition string, vector []float32, key string) error
+	// Delete removes the embedding for the key from the partition. This is no-op if the key doesn't exist.
+	Delete(ctx context.Context, partition, key string) error
+	// Close releases the resources held by the store.
+	Close() error
+}
+
+const (
+	// hnswM is the number of the neighbors of each node on the upper layers.
+	hnswM = 16
+	// hnswMaxNeighbors0 is the number of the neighbors of each node on the bottom layer.
+	hnswMaxNeighbors0 = 2 * hnswM
+	// hnswEfConstruction is the size of the dynamic candidate list during the insertion.
+	hnswEfConstruction = 100
+	// hnswEfSearch is the size of the dynamic candidate list during the search.
+	hnswEfSearch = 64
+)
+
+// hnswStore is the in-memory [VectorStore] that holds a Hierarchical Navigable Small World graph per partition.
+// See https://arxiv.org/abs/1603.09320 for the algorithm.
+//
+// When the number of the embeddings reaches maxEntries, the oldest one is evicted.
+type hnswStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	indexes    map[string]*hnswIndex
+	// order holds the hnswRef of the stored embeddings from the oldest to the newest.
+	order *list.List
+	refs  map[hnswRef]*list.Element
+	rng   *rand.Rand
+}
+
+// hnswRef identifies an embedding in the store.
+type hnswRef struct {
+	partition, key string
+}
+
+// newHNSWStore creates a new in-memory vector store. Zero maxEntries means no limit.
+func newHNSWStore(maxEntries int) *hnswStore {
+	return &hnswStore{
+		maxEntries: maxEntries,
+		indexes:    map[string]*hnswIndex{},
+		order:      list.New(),
+		refs:       map[hnswRef]*list.Element{},
+		// The randomness is only used to assign the levels of the nodes, so a fixed seed is fine.
+		rng: rand.New(rand.NewPCG(1, 2)), //nolint:gosec
+	}
+}
+
+// Search implements [VectorStore.Search].
+func (s *hnswStore) Search(_ context.Context, partition string, vector []float32) (string, float32, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	idx, ok := s.indexes[partition]
+	if !ok {
+		return "", 0, false, nil
+	}
+	if len(vector) != idx.dimension {
+		return "", 0, false, fmt.Errorf("embedding dimension mismatch: expected %d, got %d", idx.dimension, len(vector))
+	}
+	key, similarity, found := idx.search(normalize(vector))
+	return key, similarity, found, nil
+}
+
+// Add implements [VectorStore.Add].
+func (s *hnswStore) Add(_ context.Context, partition string, vector []float32, key string) error {
+	if len(vector) == 0 {
+		return fmt.Errorf("embedding is empty")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if idx, ok := s.indexes[partition]; ok && len(vector) != idx.dimension {
+		return fmt.Errorf("embedding dimension mismatch: expected %d, got %d", idx.dimension, len(vector))
+	}
+
+	ref := hnswRef{partition: partition, key: key}
+	// Delete the existing embedding first since the index is removed once it becomes empty.
+	s.deleteLocked(ref)
+	idx, ok := s.indexes[partition]
+	if !ok {
+		idx = newHNSWIndex(len(vector), s.rng)
+		s.indexes[partition] = idx
+	}
+	idx.insert(key, normalize(vector))
+	s.refs[ref] = s.order.PushBack(ref)
+	for s.maxEntries > 0 && s.order.Len() > s.maxEntries {
+		s.deleteLocked(s.order.Front().Value.(hnswRef))
+	}
+	return nil
+}
+
+// Delete implements [VectorStore.Delete].
+func (s *hnswStore) Delete(_ context.Context, partition, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleteLocked(hnswRef{partition: partition, key: key})
+	return nil
+}
+
+// deleteLocked removes the embedding from the store. The caller must hold the lock.
+func (s *hnswStore) deleteLocked(ref hnswRef) {
+	elem, ok := s.refs[ref]
+	if !ok {
+		return
+	}
+	s.order.Remove(elem)
+	delete(s.refs, ref)
+	idx := s.indexes[ref.partition]
+	idx.delete(ref.key)
+	if len(idx.ids) == 0 {
+		delete(s.indexes, ref.partition)
+	}
+}
+
+// Close implements [VectorStore.Close].
+func (s *hnswStore) Close() error { return nil }
+
+// hnswIndex is the HNSW graph of a partition. The vectors are normalized, so the cosine similarity is the dot product.
+//
+// The deleted nodes are kept in the graph as tombstones to navigate the search, and the graph is rebuilt
+// from the live nodes once the tombstones outnumber them.
+type hnswIndex struct {
+	dimension int
+	nodes     []*hnswNode
+	// ids maps the key to the live node.
+	ids      map[string]int32
+	entry    int32
+	maxLevel int
+	deleted  int
+	// levelMult is the normalization factor of the level generation, 1/ln(M).
+	levelMult float64
+	rng       *rand.Rand
+}
+
+// hnswNode is a vector in the graph with its neighbors on each layer up to its level.
+type hnswNode struct {
+	key       string
+	vector    []float32
+	neighbors [][]int32
+	deleted   bool
+}
+
+func newHNSWIndex(dimension int, rng *rand.Rand) *hnswIndex {
+	return &hnswIndex{
+		dimension: dimension,
+		ids:       map[string]int32{},
+		entry:     -1,
+		levelMult: 1 / math.Log(hnswM),
+		rng:       rng,
+	}
+}
+
+// insert adds the normalized vector to the graph.
+func (h *hnswIndex) insert(key string, vector []float32) {
+	level := int(-math.Log(1-h.rng.Float64()) * h.levelMult)
+	id := int32(len(h.nodes)) //nolint:gosec
+	node := &hnswNode{key: key, vector: vector, neighbors: make([][]int32, level+1)}
+	h.nodes = append(h.nodes, node)
+	h.ids[key] = id
+	if h.entry < 0 {
+		h.entry, h.maxLevel = id, level
+		return
+	}
+
+	ep := h.entry
+	for l := h.maxLevel; l > level; l-- {
+		ep = h.searchLayer(vector, ep, 1, l)[0].id
+	}
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		candidates := h.searchLayer(vector, ep, hnswEfConstruction, l)
+		maxNeighbors := hnswM
+		if l == 0 {
+			maxNeighbors = hnswMaxNeighbors0
+		}
+		for i := 0; i < len(candidates) && i < hnswM; i++ {
+			n := candidates[i].id
+			node.neighbors[l] = append(node.neighbors[l], n)
+			h.connect(n, id, l, maxNeighbors)
+		}
+		ep = candidates[0].id
+	}
+	if level > h.maxLevel {
+		h.entry, h.maxLevel = id, level
+	}
+}
+
+// connect adds the edge from the node to the neighbor on the layer, keeping only the closest maxNeighbors neighbors.
+func (h *hnswIndex) connect(from, to int32, level, maxNeighbors int) {
+	node := h.nodes[from]
+	node.neighbors[level] = append(node.neighbors[level], to)
+	if len(node.neighbors[level]) <= maxNeighbors {
+		return
+	}
+	candidates := make([]hnswCandidate, len(node.neighbors[level]))
+	for i, n := range node.neighbors[level] {
+		candidates[i] = hnswCandidate{id: n, similarity: dot(node.vector, h.nodes[n].vector)}
+	}
+	closest := &candidateHeap{items: candidates, max: true}
+	heap.Init(closest)
+	pruned := node.neighbors[level][:0]
+	for range maxNeighbors {
+		pruned = append(pruned, heap.Pop(closest).(hnswCandidate).id)
+	}
+	node.neighbors[level] = pruned
+}
+
+// delete marks the node of the key as deleted, and rebuilds the graph if the tombstones outnumber the live nodes.
+func (h *hnswIndex) delete(key string) {
+	id, ok := h.ids[key]
+	if !ok {
+		return
+	}
+	delete(h.ids, key)
+	h.nodes[id].deleted = true
+	h.deleted++
+	if h.deleted <= len(h.ids) {
+		return
+	}
+	nodes := h.nodes
+	h.nodes, h.ids, h.entry, h.maxLevel, h.deleted = nil, map[string]int32{}, -1, 0, 0
+	for _, n := range nodes {
+		if !n.deleted {
+			h.insert(n.key, n.vector)
+		}
+	}
+}
+
+// search returns the key of the most similar live node to the normalized vector.
+func (h *hnswIndex) search(vector []float32) (string, float32, bool) {
+	if len(h.ids) == 0 {
+		return "", 0, false
+	}
+	ep := h.entry
+	for l := h.maxLevel; l > 0; l-- {
+		ep = h.searchLayer(vector, ep, 1, l)[0].id
+	}
+	for _, c := range h.searchLayer(vector, ep, hnswEfSearch, 0) {
+		if n := h.nodes[c.id]; !n.deleted {
+			return n.key, c.similarity, true
+		}
+	}
+	return "", 0, false
+}
+
+// searchLayer returns up to ef nodes closest to the vector on the layer starting from the entry point,
+// sorted by the similarity in the descending order.
+func (h *hnswIndex) searchLayer(vector []float32, ep int32, ef int, level int) []hnswCandidate {
+	start := hnswCandidate{id: ep, similarity: dot(vector, h.nodes[ep].vector)}
+	visited := map[int32]struct{}{ep: {}}
+	candidates := &candidateHeap{items: []hnswCandidate{start}, max: true}
+	results := &candidateHeap{items: []hnswCandidate{start}}
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(hnswCandidate)
+		if c.similarity < results.items[0].similarity && results.Len() >= ef {
+			break
+		}
+		for _, n := range h.nodes[c.id].neighbors[level] {
+			if _, ok := visited[n]; ok {
+				continue
+			}
+			visited[n] = struct{}{}
+			nc := hnswCandidate{id: n, similarity: dot(vector, h.nodes[n].vector)}
+			if results.Len() < ef || nc.similarity > results.items[0].similarity {
+				heap.Push(candidates, nc)
+				heap.Push(results, nc)
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+	sorted := make([]hnswCandidate, results.Len())
+	for i := len(sorted) - 1; i >= 0; i-- {
+		sorted[i] = heap.Pop(results).(hnswCandidate)
+	}
+	return sorted
+}
+
+// hnswCandidate is a node with its similarity to the query vector.
+type hnswCandidate struct {
+	id         int32
+	similarity float32
+}
+
+// candidateHeap implements [heap.Interface] for the candidates. The most similar candidate is on the top
+// when max is true, otherwise the least similar one is.
+type candidateHeap struct {
+	items []hnswCandidate
+	max   bool
+}
+
+func (c *candidateHeap) Len() int { return len(c.items) }
+
+func (c *candidateHeap) Less(i, j int) bool {
+	if c.max {
+		return c.items[i].similarity > c.items[j].similarity
+	}
+	return c.items[i].similarity < c.items[j].similarity
+}
+
+func (c *candidateHeap) Swap(i, j int) { c.items[i], c.items[j] = c.items[j], c.items[i] }
+
+func (c *candidateHeap) Push(x any) { c.items = append(c.items, x.(hnswCandidate)) }
+
+func (c *candidateHeap) Pop() any {
+	last := c.items[len(c.items)-1]
+	c.items = c.items[:len(c.items)-1]
+	return last
+}
+
+// normalize returns the copy of the vector scaled to the unit length.
+func normalize(vector []float32) []float32 {
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	ret := make([]float32, len(vector))
+	if norm == 0 {
+		return ret
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i, v := range vector {
+		ret[i] = v * scale
+	}
+	return ret
+}
+
+// dot returns the dot product of the vectors of the same length.
+func dot(a, b []float32) float32 {
+	var ret float32
+	for i := range a {
+		ret += a[i] * b[i]
+	}
+	return ret
+}
@@ -0,0 +1,134 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package responsecache
+
+import (
+	"math/rand/v2"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHNSWStore(t *testing.T) {
+	t.Run("search", func(t *testing.T) {
+		s := newHNSWStore(0)
+		_, _, found, err := s.Search(t.Context(), "p", []float32{1, 0})
+		require.NoError(t, err)
+		require.False(t, found)
+
+		require.NoError(t, s.Add(t.Context(), "p", []float32{1, 0}, "a"))
+		require.NoError(t, s.Add(t.Context(), "p", []float32{0, 2}, "b"))
+		require.NoError(t, s.Add(t.Context(), "other", []float32{1, 1}, "c"))
+
+		key, similarity, found, err := s.Search(t.Context(), "p", []float32{3, 1})
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, "a", key)
+		require.InDelta(t, 0.9487, similarity, 0.0001)
+
+		// The embeddings in the other partitions are not compared.
+		key, _, found, err = s.Search(t.Context(), "p", []float32{1, 1})
+		require.NoError(t, err)
+		require.True(t, found)
+		require.NotEqual(t, "c", key)
+	})
+	t.Run("recall", func(t *testing.T) {
+		const n, dim = 2000, 16
+		rng := rand.New(rand.NewPCG(3, 4)) //nolint:gosec
+		randomVector := func() []float32 {
+			v := make([]float32, dim)
+			for i := range v {
+				v[i] = rng.Float32()*2 - 1
+			}
+			return v
+		}
+		s := newHNSWStore(0)
+		vectors := make([][]float32, n)
+		for i := range vectors {
+			vectors[i] = randomVector()
+			require.NoError(t, s.Add(t.Context(), "p", vectors[i], strconv.Itoa(i)))
+		}
+
+		var correct int
+		const queries = 100
+		for range queries {
+			q := randomVector()
+			var best string
+			var bestSimilarity float32 = -2
+			nq := normalize(q)
+			for i, v := range vectors {
+				if sim := dot(nq, normalize(v)); sim > bestSimilarity {
+					best, bestSimilarity = strconv.Itoa(i), sim
+				}
+			}
+			key, _, found, err := s.Search(t.Context(), "p", q)
+			require.NoError(t, err)
+			require.True(t, found)
+			if key == best {
+				correct++
+			}
+		}
+		require.GreaterOrEqual(t, correct, queries*9/10)
+	})
+	t.Run("eviction", func(t *testing.T) {
+		s := newHNSWStore(2)
+		require.NoError(t, s.Add(t.Context(), "p", []float32{1, 0}, "a"))
+		require.NoError(t, s.Add(t.Context(), "q", []float32{0, 1}, "b"))
+		require.NoError(t, s.Add(t.Context(), "p", []float32{1, 1}, "c"))
+
+		key, _, found, err := s.Search(t.Context(), "p", []float32{1, 0})
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, "c", key)
+		require.Len(t, s.refs, 2)
+
+		// Re-adding the same key replaces the embedding instead of adding a new one.
+		require.NoError(t, s.Add(t.Context(), "q", []float32{1, 0}, "b"))
+		require.Len(t, s.refs, 2)
+		_, similarity, _, err := s.Search(t.Context(), "q", []float32{1, 0})
+		require.NoError(t, err)
+		require.InDelta(t, 1, similarity, 0.0001)
+	})
+	t.Run("delete", func(t *testing.T) {
+		s := newHNSWStore(0)
+		for i := range 10 {
+			require.NoError(t, s.Add(t.Context(), "p", []float32{float32(i), 1}, strconv.Itoa(i)))
+		}
+		require.NoError(t, s.Delete(t.Context(), "p", "9"))
+		require.NoError(t, s.Delete(t.Context(), "p", "unknown"))
+		key, _, found, err := s.Search(t.Context(), "p", []float32{1, 0})
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, "8", key)
+		require.Equal(t, 1, s.indexes["p"].deleted)
+
+		// The graph is rebuilt from the live nodes once the tombstones outnumber them.
+		for i := range 5 {
+			require.NoError(t, s.Delete(t.Context(), "p", strconv.Itoa(i+4)))
+		}
+		require.Zero(t, s.indexes["p"].deleted)
+		require.Len(t, s.indexes["p"].nodes, 4)
+		key, _, found, err = s.Search(t.Context(), "p", []float32{1, 0})
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, "3", key)
+
+		for i := range 4 {
+			require.NoError(t, s.Delete(t.Context(), "p", strconv.Itoa(i)))
+		}
+		require.Empty(t, s.indexes)
+		require.NoError(t, s.Close())
+	})
+	t.Run("invalid", func(t *testing.T) {
+		s := newHNSWStore(0)
+		require.ErrorContains(t, s.Add(t.Context(), "p", nil, "a"), "embedding is empty")
+		require.NoError(t, s.Add(t.Context(), "p", []float32{1, 0}, "a"))
+		require.ErrorContains(t, s.Add(t.Context(), "p", []float32{1, 0, 0}, "b"), "embedding dimension mismatch: expected 2, got 3")
+		_, _, _, err := s.Search(t.Context(), "p", []float32{1})
+		require.ErrorContains(t, err, "embedding dimension mismatch: expected 2, got 1")
+	})
+}
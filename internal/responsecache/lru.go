@@ -0,0 +1,66 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package responsecache
+
+import (
+	"context"
+	"math"
+	"time"
+
+	lrucache "github.com/hashicorp/golang-lru/v2"
+)
+
+// lru is the in-memory [Cache] that evicts the least recently used response when the size limit is reached.
+//
+// The expiration is checked lazily on Get instead of using a background goroutine, so that the cache
+// replaced on the configuration update can simply be garbage collected.
+type lru struct {
+	entries *lrucache.Cache[string, lruEntry]
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// lruEntry is the cached response with its expiration time. Zero expiresAt means the entry never expires.
+type lruEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// newLRU creates a new in-memory cache. Zero maxEntries or ttl means no limit.
+func newLRU(maxEntries int, ttl time.Duration) *lru {
+	if maxEntries <= 0 {
+		maxEntries = math.MaxInt32
+	}
+	// The error is only returned for the non-positive size, which is handled above.
+	entries, _ := lrucache.New[string, lruEntry](maxEntries)
+	return &lru{entries: entries, ttl: ttl, now: time.Now}
+}
+
+// Get implements [Cache.Get].
+func (l *lru) Get(_ context.Context, key string) ([]byte, bool, error) {
+	e, ok := l.entries.Get(key)
+	if !ok {
+		return nil, false, nil
+	}
+	if !e.expiresAt.IsZero() && !l.now().Before(e.expiresAt) {
+		l.entries.Remove(key)
+		return nil, false, nil
+	}
+	return e.value, true, nil
+}
+
+// Set implements [Cache.Set].
+func (l *lru) Set(_ context.Context, key string, value []byte) error {
+	e := lruEntry{value: value}
+	if l.ttl > 0 {
+		e.expiresAt = l.now().Add(l.ttl)
+	}
+	l.entries.Add(key, e)
+	return nil
+}
+
+// Close implements [Cache.Close].
+func (l *lru) Close() error { return nil }
@@ -0,0 +1,58 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package responsecache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU(t *testing.T) {
+	t.Run("eviction", func(t *testing.T) {
+		c := newLRU(2, 0)
+		require.NoError(t, c.Set(t.Context(), "a", []byte("1")))
+		require.NoError(t, c.Set(t.Context(), "b", []byte("2")))
+		// Touch "a" so that "b" is the least recently used one.
+		_, ok, err := c.Get(t.Context(), "a")
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.NoError(t, c.Set(t.Context(), "c", []byte("3")))
+
+		_, ok, _ = c.Get(t.Context(), "b")
+		require.False(t, ok)
+		v, ok, _ := c.Get(t.Context(), "a")
+		require.True(t, ok)
+		require.Equal(t, []byte("1"), v)
+		v, ok, _ = c.Get(t.Context(), "c")
+		require.True(t, ok)
+		require.Equal(t, []byte("3"), v)
+	})
+	t.Run("expiration", func(t *testing.T) {
+		now := time.Unix(1000, 0)
+		c := newLRU(0, time.Minute)
+		c.now = func() time.Time { return now }
+		require.NoError(t, c.Set(t.Context(), "a", []byte("1")))
+
+		now = now.Add(59 * time.Second)
+		_, ok, _ := c.Get(t.Context(), "a")
+		require.True(t, ok)
+
+		now = now.Add(time.Second)
+		_, ok, _ = c.Get(t.Context(), "a")
+		require.False(t, ok)
+		require.Equal(t, 0, c.entries.Len())
+	})
+	t.Run("unlimited", func(t *testing.T) {
+		c := newLRU(0, 0)
+		for _, k := range []string{"a", "b", "c"} {
+			require.NoError(t, c.Set(t.Context(), k, []byte(k)))
+		}
+		require.Equal(t, 3, c.entries.Len())
+		require.NoError(t, c.Close())
+	})
+}
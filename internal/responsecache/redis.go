@@ -0,0 +1,63 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package responsecache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/redisclient"
+)
+
+// redis is the [Cache] backed by a Redis-compatible server.
+type redis struct {
+	client    *redisclient.Client
+	keyPrefix string
+	ttl       time.Duration
+}
+
+// newRedis creates a new Redis-backed cache. Zero ttl means that the responses never expire.
+func newRedis(config *filterapi.ResponseCacheRedisConfig, ttl time.Duration) (*redis, error) {
+	opts, err := redisclient.NewOptions(config.Username, config.Password, config.TLS)
+	if err != nil {
+		return nil, err
+	}
+	return &redis{client: redisclient.New(config.Address, opts), keyPrefix: config.KeyPrefix, ttl: ttl}, nil
+}
+
+// Get implements [Cache.Get].
+func (r *redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := r.client.Do(ctx, "GET", r.keyPrefix+key)
+	if errors.Is(err, redisclient.ErrNil) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	v, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("unexpected reply to GET: %v", reply)
+	}
+	return v, true, nil
+}
+
+// Set implements [Cache.Set].
+func (r *redis) Set(ctx context.Context, key string, value []byte) error {
+	args := []any{"SET", r.keyPrefix + key, value}
+	if r.ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(r.ttl.Milliseconds(), 10))
+	}
+	_, err := r.client.Do(ctx, args...)
+	return err
+}
+
+// Close implements [Cache.Close].
+func (r *redis) Close() error {
+	return r.client.Close()
+}
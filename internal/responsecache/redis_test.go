@@ -0,0 +1,63 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package responsecache
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	internaltesting "github.com/envoyproxy/ai-gateway/internal/testing"
+)
+
+func TestRedis(t *testing.T) {
+	s := internaltesting.NewFakeRedisServer(t)
+	c, err := newRedis(&filterapi.ResponseCacheRedisConfig{Address: s.Address(), KeyPrefix: "aigw:"}, time.Minute)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, c.Close()) }()
+
+	_, ok, err := c.Get(t.Context(), "key")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	value := []byte("{\"id\":\"1\"}\r\nwith crlf")
+	require.NoError(t, c.Set(t.Context(), "key", value))
+	v, ok, err := c.Get(t.Context(), "key")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, value, v)
+
+	require.Equal(t, []string{
+		"GET aigw:key",
+		"SET aigw:key " + string(value) + " PX 60000",
+		"GET aigw:key",
+	}, s.Commands())
+	// The connection is reused across the commands.
+	require.Equal(t, 1, s.Conns())
+}
+
+func TestRedis_Errors(t *testing.T) {
+	t.Run("connection refused", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := l.Addr().String()
+		require.NoError(t, l.Close())
+		c, err := newRedis(&filterapi.ResponseCacheRedisConfig{Address: addr}, 0)
+		require.NoError(t, err)
+		_, _, err = c.Get(t.Context(), "key")
+		require.ErrorContains(t, err, "failed to connect to redis")
+	})
+	t.Run("no expiration", func(t *testing.T) {
+		s := internaltesting.NewFakeRedisServer(t)
+		c, err := newRedis(&filterapi.ResponseCacheRedisConfig{Address: s.Address()}, 0)
+		require.NoError(t, err)
+		require.NoError(t, c.Set(t.Context(), "key", []byte("v")))
+		require.Equal(t, []string{"SET key v"}, s.Commands())
+	})
+}
@@ -0,0 +1,132 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package responsecache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// Semantic is the semantic lookup of the cached responses.
+//
+// The last user message of the request is embedded, and the most similar previous request is looked up
+// in the [VectorStore] among the ones that share the rest of the request, which is the partition of the store.
+// The found request is then resolved to the cached response with its key in the [Cache].
+type Semantic struct {
+	embedder  Embedder
+	store     VectorStore
+	threshold float32
+	models    map[string]struct{}
+}
+
+// SemanticQuery is the embedded chat completion request used to look up and store the responses.
+type SemanticQuery struct {
+	// Partition is the key of the request without the last user message.
+	Partition string
+	// Vector is the embedding of the last user message.
+	Vector []float32
+}
+
+// NewSemantic creates a new [Semantic] from the given configuration with the in-memory vector store.
+func NewSemantic(ctx context.Context, config *filterapi.SemanticResponseCacheConfig) (*Semantic, error) {
+	if config.SimilarityThreshold <= 0 || config.SimilarityThreshold > 1 {
+		return nil, fmt.Errorf("similarity threshold must be in the range (0, 1]: %v", config.SimilarityThreshold)
+	}
+	e, err := newEmbedder(ctx, &config.Embedding)
+	if err != nil {
+		return nil, err
+	}
+	return newSemantic(e, newHNSWStore(config.MaxEntries), config.SimilarityThreshold, config.Models), nil
+}
+
+func newSemantic(e Embedder, store VectorStore, threshold float64, models []string) *Semantic {
+	s := &Semantic{embedder: e, store: store, threshold: float32(threshold), models: make(map[string]struct{}, len(models))}
+	for _, m := range models {
+		s.models[m] = struct{}{}
+	}
+	return s
+}
+
+// Query returns the [SemanticQuery] of the given request for the given model on the given backend in the same way as
+// [Key]. This returns nil without an error when the semantic lookup is not enabled for the model, or the last message
+// of the request is not a text from the user.
+func (s *Semantic) Query(ctx context.Context, backend, model string, req *openai.ChatCompletionRequest) (*SemanticQuery, error) {
+	if _, ok := s.models[model]; !ok || len(req.Messages) == 0 {
+		return nil, nil
+	}
+	text, ok := userMessageText(req.Messages[len(req.Messages)-1])
+	if !ok {
+		return nil, nil
+	}
+	rest := *req
+	rest.Messages = req.Messages[:len(req.Messages)-1]
+	partition, err := Key(backend, model, &rest)
+	if err != nil {
+		return nil, err
+	}
+	vector, err := s.embedder.Embed(ctx, text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed the user message: %w", err)
+	}
+	return &SemanticQuery{Partition: partition, Vector: vector}, nil
+}
+
+// Lookup returns the key of the cached response of the most similar request to the query with its similarity.
+// The third return value is false if there's no request whose similarity is above the threshold.
+func (s *Semantic) Lookup(ctx context.Context, q *SemanticQuery) (key string, similarity float32, found bool, err error) {
+	key, similarity, found, err = s.store.Search(ctx, q.Partition, q.Vector)
+	if err != nil || !found || similarity < s.threshold {
+		return "", similarity, false, err
+	}
+	return key, similarity, true, nil
+}
+
+// Add associates the query with the key of the cached response.
+func (s *Semantic) Add(ctx context.Context, q *SemanticQuery, key string) error {
+	return s.store.Add(ctx, q.Partition, q.Vector, key)
+}
+
+// Forget removes the key of the cached response from the query's partition. This is used when the cached
+// response has been evicted or expired from the [Cache].
+func (s *Semantic) Forget(ctx context.Context, q *SemanticQuery, key string) error {
+	return s.store.Delete(ctx, q.Partition, key)
+}
+
+// Close releases the resources held by the vector store.
+func (s *Semantic) Close() error {
+	return s.store.Close()
+}
+
+// userMessageText returns the text of the user message. The second return value is false if the message
+// is not from the user, or it has non-text content parts.
+func userMessageText(msg openai.ChatCompletionMessageParamUnion) (string, bool) {
+	userMsg, ok := msg.Value.(openai.ChatCompletionUserMessageParam)
+	if !ok {
+		return "", false
+	}
+	switch content := userMsg.Content.Value.(type) {
+	case string:
+		return content, content != ""
+	case []openai.ChatCompletionContentPartUserUnionParam:
+		var b strings.Builder
+		for _, part := range content {
+			if part.TextContent == nil {
+				return "", false
+			}
+			if b.Len() > 0 {
+				b.WriteByte('\n')
+			}
+			b.WriteString(part.TextContent.Text)
+		}
+		return b.String(), b.Len() > 0
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package responsecache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// fakeEmbedder implements [Embedder] with the fixed embeddings.
+type fakeEmbedder map[string][]float32
+
+// Embed implements [Embedder.Embed].
+func (f fakeEmbedder) Embed(_ context.Context, text string) ([]float32, error) {
+	v, ok := f[text]
+	if !ok {
+		return nil, errors.New("unknown text")
+	}
+	return v, nil
+}
+
+func TestSemantic(t *testing.T) {
+	s := newSemantic(fakeEmbedder{
+		"What is the capital of France?": {1, 0},
+		"Tell me the capital of France.": {0.99, 0.1},
+		"How tall is Mt. Fuji?":          {0, 1},
+		"describe":                       {0.5, 0.5},
+	}, newHNSWStore(0), 0.95, []string{"gpt-4o"})
+	defer func() { require.NoError(t, s.Close()) }()
+
+	request := func(t *testing.T, messages string) *openai.ChatCompletionRequest {
+		var req openai.ChatCompletionRequest
+		require.NoError(t, json.Unmarshal([]byte(`{"model":"gpt-4o","messages":`+messages+`}`), &req))
+		return &req
+	}
+
+	q, err := s.Query(t.Context(), "openai", "gpt-4o", request(t, `[{"role":"user","content":"What is the capital of France?"}]`))
+	require.NoError(t, err)
+	require.NotNil(t, q)
+	_, _, found, err := s.Lookup(t.Context(), q)
+	require.NoError(t, err)
+	require.False(t, found)
+	require.NoError(t, s.Add(t.Context(), q, "france"))
+
+	t.Run("similar", func(t *testing.T) {
+		q, err := s.Query(t.Context(), "openai", "gpt-4o", request(t, `[{"role":"user","content":[{"type":"text","text":"Tell me the capital of France."}]}]`))
+		require.NoError(t, err)
+		key, similarity, found, err := s.Lookup(t.Context(), q)
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, "france", key)
+		require.InDelta(t, 0.995, similarity, 0.001)
+	})
+	t.Run("below threshold", func(t *testing.T) {
+		q, err := s.Query(t.Context(), "openai", "gpt-4o", request(t, `[{"role":"user","content":"How tall is Mt. Fuji?"}]`))
+		require.NoError(t, err)
+		_, similarity, found, err := s.Lookup(t.Context(), q)
+		require.NoError(t, err)
+		require.False(t, found)
+		require.InDelta(t, 0, similarity, 0.001)
+	})
+	t.Run("different context", func(t *testing.T) {
+		// The system message is different, so the request is in the different partition.
+		q, err := s.Query(t.Context(), "openai", "gpt-4o", request(t, `[{"role":"system","content":"Be brief."},{"role":"user","content":"Tell me the capital of France."}]`))
+		require.NoError(t, err)
+		_, _, found, err := s.Lookup(t.Context(), q)
+		require.NoError(t, err)
+		require.False(t, found)
+	})
+	t.Run("different backend", func(t *testing.T) {
+		q, err := s.Query(t.Context(), "aws", "gpt-4o", request(t, `[{"role":"user","content":"Tell me the capital of France."}]`))
+		require.NoError(t, err)
+		_, _, found, err := s.Lookup(t.Context(), q)
+		require.NoError(t, err)
+		require.False(t, found)
+	})
+	t.Run("forget", func(t *testing.T) {
+		q, err := s.Query(t.Context(), "openai", "gpt-4o", request(t, `[{"role":"user","content":"Tell me the capital of France."}]`))
+		require.NoError(t, err)
+		require.NoError(t, s.Forget(t.Context(), q, "france"))
+		_, _, found, err := s.Lookup(t.Context(), q)
+		require.NoError(t, err)
+		require.False(t, found)
+	})
+	t.Run("not applicable", func(t *testing.T) {
+		for _, tc := range []struct {
+			name, model, messages string
+		}{
+			{name: "model not opted in", model: "gpt-4o-mini", messages: `[{"role":"user","content":"describe"}]`},
+			{name: "no messages", model: "gpt-4o", messages: `[]`},
+			{name: "last message from assistant", model: "gpt-4o", messages: `[{"role":"user","content":"describe"},{"role":"assistant","content":"ok"}]`},
+			{name: "image", model: "gpt-4o", messages: `[{"role":"user","content":[{"type":"text","text":"describe"},{"type":"image_url","image_url":{"url":"https://example.com/a.png"}}]}]`},
+			{name: "empty text", model: "gpt-4o", messages: `[{"role":"user","content":""}]`},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				q, err := s.Query(t.Context(), "openai", tc.model, request(t, tc.messages))
+				require.NoError(t, err)
+				require.Nil(t, q)
+			})
+		}
+	})
+	t.Run("embed error", func(t *testing.T) {
+		_, err := s.Query(t.Context(), "openai", "gpt-4o", request(t, `[{"role":"user","content":"unknown"}]`))
+		require.ErrorContains(t, err, "failed to embed the user message: unknown text")
+	})
+}
+
+func TestNewSemantic(t *testing.T) {
+	for _, threshold := range []float64{0, -0.5, 1.5} {
+		_, err := NewSemantic(t.Context(), &filterapi.SemanticResponseCacheConfig{
+			SimilarityThreshold: threshold,
+			Embedding:           filterapi.SemanticResponseCacheEmbedding{Endpoint: "http://localhost"},
+		})
+		require.ErrorContains(t, err, "similarity threshold must be in the range (0, 1]")
+	}
+	_, err := NewSemantic(t.Context(), &filterapi.SemanticResponseCacheConfig{SimilarityThreshold: 0.9})
+	require.ErrorContains(t, err, "embedding endpoint is required")
+	s, err := NewSemantic(t.Context(), &filterapi.SemanticResponseCacheConfig{
+		SimilarityThreshold: 0.9,
+		Embedding: filterapi.SemanticResponseCacheEmbedding{
+			Endpoint: "http://localhost",
+			Backend:  filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}},
+		},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, s)
+}
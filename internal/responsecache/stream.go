@@ -0,0 +1,164 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package responsecache
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+var (
+	sseDataPrefix = []byte("data: ")
+	sseDone       = []byte("[DONE]")
+)
+
+// AssembleChatCompletionStream assembles the server-sent events of a streaming chat completion response
+// into the encoded non-streaming [openai.ChatCompletionResponse], so that it can be cached the same way as
+// the non-streaming responses.
+func AssembleChatCompletionStream(body []byte) ([]byte, error) {
+	resp := openai.ChatCompletionResponse{Object: "chat.completion"}
+	var choices []openai.ChatCompletionResponseChoice
+	var done bool
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if !bytes.HasPrefix(line, sseDataPrefix) {
+			continue
+		}
+		data := bytes.TrimPrefix(line, sseDataPrefix)
+		if bytes.Equal(data, sseDone) {
+			done = true
+			break
+		}
+		var chunk openai.ChatCompletionResponseChunk
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal chunk: %w", err)
+		}
+		resp.ID, resp.Created, resp.Model = chunk.ID, chunk.Created, chunk.Model
+		resp.ServiceTier, resp.SystemFingerprint = chunk.ServiceTier, chunk.SystemFingerprint
+		if chunk.Usage != nil {
+			resp.Usage = *chunk.Usage
+		}
+		for i := range chunk.Choices {
+			c := &chunk.Choices[i]
+			for int64(len(choices)) <= c.Index {
+				choices = append(choices, openai.ChatCompletionResponseChoice{Index: int64(len(choices))})
+			}
+			choice := &choices[c.Index]
+			if c.FinishReason != "" {
+				choice.FinishReason = c.FinishReason
+			}
+			if c.Delta != nil {
+				mergeChatCompletionDelta(&choice.Message, c.Delta)
+			}
+		}
+	}
+	if !done {
+		return nil, errors.New("stream is not terminated with [DONE]")
+	}
+	resp.Choices = choices
+	return json.Marshal(&resp)
+}
+
+// mergeChatCompletionDelta appends the delta of a streaming chunk to the message.
+func mergeChatCompletionDelta(msg *openai.ChatCompletionResponseChoiceMessage, delta *openai.ChatCompletionResponseChunkChoiceDelta) {
+	if delta.Role != "" {
+		msg.Role = delta.Role
+	}
+	if delta.Content != nil {
+		content := *delta.Content
+		if msg.Content != nil {
+			content = *msg.Content + content
+		}
+		msg.Content = &content
+	}
+	for _, tc := range delta.ToolCalls {
+		index := len(msg.ToolCalls)
+		if tc.Index != nil {
+			index = *tc.Index
+		}
+		for len(msg.ToolCalls) <= index {
+			msg.ToolCalls = append(msg.ToolCalls, openai.ChatCompletionMessageToolCallParam{})
+		}
+		call := &msg.ToolCalls[index]
+		if tc.ID != nil {
+			call.ID = tc.ID
+		}
+		if tc.Type != "" {
+			call.Type = tc.Type
+		}
+		if tc.Function.Name != "" {
+			call.Function.Name = tc.Function.Name
+		}
+		call.Function.Arguments += tc.Function.Arguments
+	}
+}
+
+// ChatCompletionStreamBody converts the cached non-streaming chat completion response into the server-sent events
+// of the streaming response. Each choice is sent as a single chunk, followed by the usage chunk if includeUsage is true.
+func ChatCompletionStreamBody(cached []byte, includeUsage bool) ([]byte, error) {
+	var resp openai.ChatCompletionResponse
+	if err := json.Unmarshal(cached, &resp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cached response: %w", err)
+	}
+	newChunk := func() openai.ChatCompletionResponseChunk {
+		return openai.ChatCompletionResponseChunk{
+			ID:                resp.ID,
+			Created:           resp.Created,
+			Model:             resp.Model,
+			ServiceTier:       resp.ServiceTier,
+			SystemFingerprint: resp.SystemFingerprint,
+			Object:            "chat.completion.chunk",
+		}
+	}
+	var buf bytes.Buffer
+	writeEvent := func(chunk *openai.ChatCompletionResponseChunk) error {
+		data, err := json.Marshal(chunk)
+		if err != nil {
+			return err
+		}
+		buf.Write(sseDataPrefix)
+		buf.Write(data)
+		buf.WriteString("\n\n")
+		return nil
+	}
+	for i := range resp.Choices {
+		choice := &resp.Choices[i]
+		toolCalls := make([]openai.ChatCompletionMessageToolCallParam, len(choice.Message.ToolCalls))
+		for j := range choice.Message.ToolCalls {
+			toolCalls[j] = choice.Message.ToolCalls[j]
+			index := j
+			toolCalls[j].Index = &index
+		}
+		chunk := newChunk()
+		chunk.Choices = []openai.ChatCompletionResponseChunkChoice{{
+			Index: choice.Index,
+			Delta: &openai.ChatCompletionResponseChunkChoiceDelta{
+				Role:      choice.Message.Role,
+				Content:   choice.Message.Content,
+				ToolCalls: toolCalls,
+			},
+			FinishReason: choice.FinishReason,
+		}}
+		if err := writeEvent(&chunk); err != nil {
+			return nil, err
+		}
+	}
+	if includeUsage {
+		chunk := newChunk()
+		chunk.Usage = &resp.Usage
+		if err := writeEvent(&chunk); err != nil {
+			return nil, err
+		}
+	}
+	buf.Write(sseDataPrefix)
+	buf.Write(sseDone)
+	buf.WriteString("\n\n")
+	return buf.Bytes(), nil
+}
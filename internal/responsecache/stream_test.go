@@ -0,0 +1,97 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package responsecache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssembleChatCompletionStream(t *testing.T) {
+	t.Run("content", func(t *testing.T) {
+		const body = `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1731000000,"model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":""}}]}
+
+data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1731000000,"model":"gpt-4o","choices":[{"index":0,"delta":{"content":"Hello"}}]}
+
+data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1731000000,"model":"gpt-4o","choices":[{"index":0,"delta":{"content":" world"},"finish_reason":"stop"}]}
+
+data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1731000000,"model":"gpt-4o","choices":[],"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}
+
+data: [DONE]
+
+`
+		out, err := AssembleChatCompletionStream([]byte(body))
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+  "id": "chatcmpl-1",
+  "object": "chat.completion",
+  "created": 1731000000,
+  "model": "gpt-4o",
+  "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "Hello world"}}],
+  "usage": {"prompt_tokens": 5, "completion_tokens": 2, "total_tokens": 7}
+}`, string(out))
+	})
+	t.Run("tool calls", func(t *testing.T) {
+		const body = `data: {"id":"chatcmpl-2","choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}
+
+data: {"id":"chatcmpl-2","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}
+
+data: {"id":"chatcmpl-2","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Tokyo\"}"}}]},"finish_reason":"tool_calls"}]}
+
+data: [DONE]
+`
+		out, err := AssembleChatCompletionStream([]byte(body))
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+  "id": "chatcmpl-2",
+  "object": "chat.completion",
+  "choices": [{"index": 0, "finish_reason": "tool_calls", "message": {"role": "assistant", "tool_calls": [
+    {"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Tokyo\"}"}}
+  ]}}]
+}`, string(out))
+	})
+	t.Run("not terminated", func(t *testing.T) {
+		_, err := AssembleChatCompletionStream([]byte(`data: {"id":"chatcmpl-1","choices":[]}` + "\n\n"))
+		require.ErrorContains(t, err, "stream is not terminated with [DONE]")
+	})
+	t.Run("invalid chunk", func(t *testing.T) {
+		_, err := AssembleChatCompletionStream([]byte("data: {invalid\n\n"))
+		require.ErrorContains(t, err, "failed to unmarshal chunk")
+	})
+}
+
+func TestChatCompletionStreamBody(t *testing.T) {
+	const cached = `{"id":"chatcmpl-1","object":"chat.completion","created":1731000000,"model":"gpt-4o",
+"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"Hello world"}}],
+"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`
+	t.Run("with usage", func(t *testing.T) {
+		out, err := ChatCompletionStreamBody([]byte(cached), true)
+		require.NoError(t, err)
+		require.Equal(t, `data: {"id":"chatcmpl-1","choices":[{"index":0,"delta":{"content":"Hello world","role":"assistant"},"finish_reason":"stop"}],"created":1731000000,"model":"gpt-4o","object":"chat.completion.chunk"}
+
+data: {"id":"chatcmpl-1","created":1731000000,"model":"gpt-4o","object":"chat.completion.chunk","usage":{"completion_tokens":2,"prompt_tokens":5,"total_tokens":7}}
+
+data: [DONE]
+
+`, string(out))
+
+		// The re-emitted stream can be assembled back to the same response.
+		assembled, err := AssembleChatCompletionStream(out)
+		require.NoError(t, err)
+		require.JSONEq(t, cached, string(assembled))
+	})
+	t.Run("without usage", func(t *testing.T) {
+		out, err := ChatCompletionStreamBody([]byte(cached), false)
+		require.NoError(t, err)
+		require.NotContains(t, string(out), "usage")
+		require.Contains(t, string(out), "data: [DONE]\n\n")
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ChatCompletionStreamBody([]byte("invalid"), false)
+		require.ErrorContains(t, err, "failed to unmarshal cached response")
+	})
+}
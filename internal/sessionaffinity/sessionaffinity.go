@@ -0,0 +1,89 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package sessionaffinity provides the session keys of the requests of the route rules with the session affinity.
+//
+// The session key is either the value of a request header, or the hash of the leading messages of the conversation,
+// which stay the same across the turns of a multi-turn conversation. The route hashes the key to select the backend
+// consistently, so that the conversation keeps hitting the prompt cache of the same backend.
+package sessionaffinity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// Set is the set of the session affinities of the route rules.
+type Set struct {
+	matches filterapi.RouteRuleMatcher[*filterapi.SessionAffinity]
+}
+
+// NewSet creates a new [Set] from the given configurations. This returns nil if there's no configuration.
+func NewSet(configs []filterapi.SessionAffinity) *Set {
+	if len(configs) == 0 {
+		return nil
+	}
+	s := &Set{}
+	for i := range configs {
+		c := &configs[i]
+		for _, m := range c.Matches {
+			s.matches.Add(m, c)
+		}
+	}
+	return s
+}
+
+// Find returns the session affinity of the route rule matching the given model and the request headers, or nil if
+// there's none or the set is nil. When multiple route rules match, the one matching the most headers is returned in
+// the same way as the precedence of the HTTPRoute rules.
+func (s *Set) Find(model string, headers map[string]string) *filterapi.SessionAffinity {
+	if s == nil {
+		return nil
+	}
+	return s.matches.Find(model, headers)
+}
+
+// Key returns the session key of the chat completion request of the given model, or an empty string if the request
+// doesn't belong to any route rule with the session affinity or the key cannot be computed from the request.
+func (s *Set) Key(model string, headers map[string]string, messages []openai.ChatCompletionMessageParamUnion) string {
+	config := s.Find(model, headers)
+	if config == nil {
+		return ""
+	}
+	switch config.Type {
+	case filterapi.SessionAffinityTypeHeader:
+		return headers[config.Header]
+	case filterapi.SessionAffinityTypeConversationPrefix:
+		return conversationPrefixKey(messages, config.UserMessages)
+	default:
+		return ""
+	}
+}
+
+// conversationPrefixKey returns the hex-encoded SHA-256 hash of the messages up to and including the given number of
+// the user messages, or an empty string if there are fewer user messages.
+func conversationPrefixKey(messages []openai.ChatCompletionMessageParamUnion, userMessages int) string {
+	users := 0
+	for i := range messages {
+		if messages[i].Type != openai.ChatMessageRoleUser {
+			continue
+		}
+		users++
+		if users < userMessages {
+			continue
+		}
+		prefix, err := json.Marshal(messages[:i+1])
+		if err != nil {
+			return ""
+		}
+		sum := sha256.Sum256(prefix)
+		return hex.EncodeToString(sum[:])
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package sessionaffinity
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func messages(t *testing.T, raw string) []openai.ChatCompletionMessageParamUnion {
+	var ret []openai.ChatCompletionMessageParamUnion
+	require.NoError(t, json.Unmarshal([]byte(raw), &ret))
+	return ret
+}
+
+func TestSet_Find(t *testing.T) {
+	require.Nil(t, NewSet(nil))
+	require.Nil(t, (*Set)(nil).Find("m", nil))
+	require.Empty(t, (*Set)(nil).Key("m", nil, nil))
+
+	s := NewSet([]filterapi.SessionAffinity{
+		{Name: "default", Matches: []filterapi.RouteRuleMatch{{Model: "m"}}},
+		{Name: "eu", Matches: []filterapi.RouteRuleMatch{{Model: "m", Headers: map[string]string{"x-region": "eu"}}}},
+		{Name: "other", Matches: []filterapi.RouteRuleMatch{{Model: "m2"}}},
+	})
+	require.Equal(t, "default", s.Find("m", map[string]string{}).Name)
+	require.Equal(t, "default", s.Find("m", map[string]string{"x-region": "us"}).Name)
+	require.Equal(t, "eu", s.Find("m", map[string]string{"x-region": "eu"}).Name)
+	require.Equal(t, "other", s.Find("m2", nil).Name)
+	require.Nil(t, s.Find("unknown", nil))
+}
+
+func TestSet_Key_header(t *testing.T) {
+	s := NewSet([]filterapi.SessionAffinity{
+		{Matches: []filterapi.RouteRuleMatch{{Model: "m"}}, Type: filterapi.SessionAffinityTypeHeader, Header: "x-session-id"},
+	})
+	require.Equal(t, "abc", s.Key("m", map[string]string{"x-session-id": "abc"}, nil))
+	require.Empty(t, s.Key("m", map[string]string{}, nil))
+	require.Empty(t, s.Key("unknown", map[string]string{"x-session-id": "abc"}, nil))
+}
+
+func TestSet_Key_conversationPrefix(t *testing.T) {
+	const (
+		firstTurn  = `[{"role":"system","content":"be brief"},{"role":"user","content":"hi"}]`
+		secondTurn = `[{"role":"system","content":"be brief"},{"role":"user","content":"hi"},{"role":"assistant","content":"hello"},{"role":"user","content":"how are you?"}]`
+		otherTurn  = `[{"role":"system","content":"be brief"},{"role":"user","content":"bye"}]`
+		noUser     = `[{"role":"system","content":"be brief"}]`
+	)
+	newSet := func(userMessages int) *Set {
+		return NewSet([]filterapi.SessionAffinity{
+			{Matches: []filterapi.RouteRuleMatch{{Model: "m"}}, Type: filterapi.SessionAffinityTypeConversationPrefix, UserMessages: userMessages},
+		})
+	}
+
+	t.Run("first user message", func(t *testing.T) {
+		s := newSet(1)
+		first := s.Key("m", nil, messages(t, firstTurn))
+		require.Len(t, first, 64)
+		// The later turns of the same conversation have the same key.
+		require.Equal(t, first, s.Key("m", nil, messages(t, secondTurn)))
+		require.NotEqual(t, first, s.Key("m", nil, messages(t, otherTurn)))
+		require.Empty(t, s.Key("m", nil, messages(t, noUser)))
+	})
+	t.Run("second user message", func(t *testing.T) {
+		s := newSet(2)
+		require.Empty(t, s.Key("m", nil, messages(t, firstTurn)))
+		second := s.Key("m", nil, messages(t, secondTurn))
+		require.NotEmpty(t, second)
+		require.NotEqual(t, newSet(1).Key("m", nil, messages(t, secondTurn)), second)
+	})
+}
@@ -0,0 +1,200 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package internaltesting
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/pem"
+	"net"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/redisclient"
+)
+
+// FakeRedisServer is a minimal Redis-compatible server that supports GET, SET, INCRBY, PEXPIRE, MULTI/EXEC and AUTH,
+// recording the received commands. The expiration is recorded but never enforced.
+type FakeRedisServer struct {
+	l        net.Listener
+	mu       sync.Mutex
+	data     map[string][]byte
+	commands []string
+	conns    int
+	// username and password are required by AUTH when password is not empty.
+	username, password string
+}
+
+// NewFakeRedisServer starts a new [FakeRedisServer] listening on a random local port,
+// which is stopped when the test completes.
+func NewFakeRedisServer(t *testing.T) *FakeRedisServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	return startFakeRedisServer(t, l)
+}
+
+// NewFakeRedisTLSServer starts a new [FakeRedisServer] serving TLS with a self-signed certificate for 127.0.0.1,
+// and returns it with the PEM-encoded certificate.
+func NewFakeRedisTLSServer(t *testing.T) (*FakeRedisServer, []byte) {
+	// The test certificate of httptest is valid for 127.0.0.1.
+	hs := httptest.NewUnstartedServer(nil)
+	hs.StartTLS()
+	config, cert := hs.TLS, hs.Certificate()
+	hs.Close()
+	l, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	require.NoError(t, err)
+	return startFakeRedisServer(t, l), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+}
+
+// RequireAuth makes the server reject the commands of the connections until authenticated with the given credentials.
+// The empty username means the default user.
+func (s *FakeRedisServer) RequireAuth(username, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.username, s.password = username, password
+}
+
+func startFakeRedisServer(t *testing.T, l net.Listener) *FakeRedisServer {
+	s := &FakeRedisServer{l: l, data: map[string][]byte{}}
+	t.Cleanup(func() { _ = l.Close() })
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns++
+			s.mu.Unlock()
+			go s.serve(c)
+		}
+	}()
+	return s
+}
+
+// Address returns the host:port of the server.
+func (s *FakeRedisServer) Address() string { return s.l.Addr().String() }
+
+// Commands returns a copy of the received commands with the arguments joined by a space.
+func (s *FakeRedisServer) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+// Conns returns the number of the accepted connections.
+func (s *FakeRedisServer) Conns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conns
+}
+
+func (s *FakeRedisServer) serve(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	// queue is the commands queued by MULTI, which is nil outside of the transaction.
+	var queue [][]string
+	authenticated, aborted := false, false
+	for {
+		req, err := redisclient.ReadReply(r)
+		if err != nil {
+			return
+		}
+		var args [][]byte
+		var strArgs []string
+		for _, a := range req.([]any) {
+			args = append(args, a.([]byte))
+			strArgs = append(strArgs, string(a.([]byte)))
+		}
+		s.mu.Lock()
+		s.commands = append(s.commands, strings.Join(strArgs, " "))
+		var reply []byte
+		switch {
+		case strArgs[0] == "AUTH":
+			username, password := "", strArgs[len(strArgs)-1]
+			if len(strArgs) == 3 {
+				username = strArgs[1]
+			}
+			authenticated = username == s.username && password == s.password
+			if authenticated {
+				reply = []byte("+OK\r\n")
+			} else {
+				reply = []byte("-WRONGPASS invalid username-password pair\r\n")
+			}
+		case s.password != "" && !authenticated:
+			reply = []byte("-NOAUTH Authentication required.\r\n")
+		case strArgs[0] == "MULTI":
+			queue = [][]string{}
+			reply = []byte("+OK\r\n")
+		case strArgs[0] == "EXEC" && aborted:
+			reply = []byte("-EXECABORT Transaction discarded because of previous errors.\r\n")
+			queue, aborted = nil, false
+		case strArgs[0] == "EXEC":
+			reply = []byte("*" + strconv.Itoa(len(queue)) + "\r\n")
+			for _, q := range queue {
+				qArgs := make([][]byte, len(q))
+				for i, a := range q {
+					qArgs[i] = []byte(a)
+				}
+				reply = append(reply, s.handle(q, qArgs)...)
+			}
+			queue = nil
+		case queue != nil && !slices.Contains([]string{"GET", "SET", "INCRBY", "PEXPIRE"}, strArgs[0]):
+			aborted = true
+			reply = []byte("-ERR unknown command\r\n")
+		case queue != nil:
+			queue = append(queue, strArgs)
+			reply = []byte("+QUEUED\r\n")
+		default:
+			reply = s.handle(strArgs, args)
+		}
+		s.mu.Unlock()
+		if _, err = c.Write(reply); err != nil {
+			return
+		}
+	}
+}
+
+// handle returns the encoded reply to the command. This must be called with the lock held.
+func (s *FakeRedisServer) handle(strArgs []string, args [][]byte) []byte {
+	switch strArgs[0] {
+	case "GET":
+		v, ok := s.data[strArgs[1]]
+		if !ok {
+			return []byte("$-1\r\n")
+		}
+		return redisclient.EncodeCommand(v)[4:] // Strip the array header "*1\r\n".
+	case "SET":
+		s.data[strArgs[1]] = args[2]
+		return []byte("+OK\r\n")
+	case "INCRBY":
+		var cur int64
+		var err error
+		if v, ok := s.data[strArgs[1]]; ok {
+			cur, err = strconv.ParseInt(string(v), 10, 64)
+		}
+		n, nErr := strconv.ParseInt(strArgs[2], 10, 64)
+		if err != nil || nErr != nil {
+			return []byte("-ERR value is not an integer or out of range\r\n")
+		}
+		cur += n
+		s.data[strArgs[1]] = []byte(strconv.FormatInt(cur, 10))
+		return []byte(":" + strconv.FormatInt(cur, 10) + "\r\n")
+	case "PEXPIRE":
+		if _, ok := s.data[strArgs[1]]; !ok {
+			return []byte(":0\r\n")
+		}
+		return []byte(":1\r\n")
+	default:
+		return []byte("-ERR unknown command\r\n")
+	}
+}
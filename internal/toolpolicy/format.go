@@ -0,0 +1,411 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package toolpolicy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/tidwall/sjson"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/responsecache"
+)
+
+// format is the format of the responses of an API whose tool calls are validated.
+type format interface {
+	// calls returns the tool calls of the non-streaming response body.
+	calls(body []byte) ([]call, error)
+	// rewrite removes the given violating tool calls from the non-streaming response body.
+	rewrite(body []byte, violations []Violation) ([]byte, error)
+	// startsToolCalls reports whether the given data of the server-sent event starts the tool calls, from which the
+	// events of the streaming response are held back.
+	startsToolCalls(data []byte) bool
+	// streamCalls returns the tool calls of the server-sent events held back until the end of the stream.
+	streamCalls(held []byte) ([]call, error)
+	// rewriteStream re-emits the server-sent events held back without the given violating tool calls.
+	rewriteStream(held []byte, violations []Violation, includeUsage bool) ([]byte, error)
+}
+
+// call is a tool call of the response.
+type call struct {
+	// choice and index are the position of the call as in [Violation].
+	choice, index int
+	name          string
+	// arguments is the JSON of the arguments of the call.
+	arguments string
+}
+
+// removedIndexes returns the sorted positions of the given violations, which are all in the same choice.
+func removedIndexes(violations []Violation) []int {
+	removed := make([]int, 0, len(violations))
+	for _, v := range violations {
+		removed = append(removed, v.Index)
+	}
+	slices.Sort(removed)
+	return slices.Compact(removed)
+}
+
+// chatCompletionFormat is the format of the OpenAI chat completions API.
+type chatCompletionFormat struct{}
+
+func (chatCompletionFormat) calls(body []byte) ([]call, error) {
+	var resp openai.ChatCompletionResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	var calls []call
+	for i := range resp.Choices {
+		for j := range resp.Choices[i].Message.ToolCalls {
+			f := &resp.Choices[i].Message.ToolCalls[j].Function
+			calls = append(calls, call{choice: i, index: j, name: f.Name, arguments: f.Arguments})
+		}
+	}
+	return calls, nil
+}
+
+// rewrite also changes the finish reason of the choice whose tool calls are all removed to "stop".
+func (chatCompletionFormat) rewrite(body []byte, violations []Violation) ([]byte, error) {
+	byChoice := make(map[int][]int)
+	for _, v := range violations {
+		byChoice[v.Choice] = append(byChoice[v.Choice], v.Index)
+	}
+	var resp openai.ChatCompletionResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the response: %w", err)
+	}
+	var err error
+	for choice, indexes := range byChoice {
+		if choice >= len(resp.Choices) {
+			continue
+		}
+		prefix := "choices." + strconv.Itoa(choice)
+		if len(indexes) >= len(resp.Choices[choice].Message.ToolCalls) {
+			if body, err = sjson.DeleteBytes(body, prefix+".message.tool_calls"); err != nil {
+				return nil, err
+			}
+			if resp.Choices[choice].FinishReason == openai.ChatCompletionChoicesFinishReasonToolCalls {
+				if body, err = sjson.SetBytes(body, prefix+".finish_reason", openai.ChatCompletionChoicesFinishReasonStop); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		// Delete from the last one so that the positions of the rest are not shifted.
+		slices.Sort(indexes)
+		for _, i := range slices.Backward(indexes) {
+			if body, err = sjson.DeleteBytes(body, prefix+".message.tool_calls."+strconv.Itoa(i)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return body, nil
+}
+
+func (chatCompletionFormat) startsToolCalls(data []byte) bool {
+	if !bytes.Contains(data, []byte(`"tool_calls"`)) {
+		return false
+	}
+	var chunk openai.ChatCompletionResponseChunk
+	if json.Unmarshal(data, &chunk) != nil {
+		return false
+	}
+	for i := range chunk.Choices {
+		if d := chunk.Choices[i].Delta; d != nil && len(d.ToolCalls) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (f chatCompletionFormat) streamCalls(held []byte) ([]call, error) {
+	assembled, err := responsecache.AssembleChatCompletionStream(held)
+	if err != nil {
+		return nil, err
+	}
+	return f.calls(assembled)
+}
+
+// rewriteStream re-emits the whole response assembled from the held events since the chunks of the tool calls cannot
+// be removed one by one.
+func (f chatCompletionFormat) rewriteStream(held []byte, violations []Violation, includeUsage bool) ([]byte, error) {
+	assembled, err := responsecache.AssembleChatCompletionStream(held)
+	if err != nil {
+		return nil, err
+	}
+	if assembled, err = f.rewrite(assembled, violations); err != nil {
+		return nil, err
+	}
+	return responsecache.ChatCompletionStreamBody(assembled, includeUsage)
+}
+
+// responsesFormat is the format of the OpenAI responses API, whose tool calls are the function call items of the
+// output.
+type responsesFormat struct{}
+
+// responseCalls returns the function calls of the given response.
+func responseCalls(resp *openai.Response) []call {
+	var calls []call
+	for i := range resp.Output {
+		if item := &resp.Output[i]; item.Type == "function_call" {
+			calls = append(calls, call{index: i, name: item.Name, arguments: item.Arguments})
+		}
+	}
+	return calls
+}
+
+func (responsesFormat) calls(body []byte) ([]call, error) {
+	var resp openai.Response
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	return responseCalls(&resp), nil
+}
+
+func (responsesFormat) rewrite(body []byte, violations []Violation) ([]byte, error) {
+	return deleteIndexes(body, "output.", removedIndexes(violations))
+}
+
+func (responsesFormat) startsToolCalls(data []byte) bool {
+	if !bytes.Contains(data, []byte(`"function_call"`)) {
+		return false
+	}
+	var event openai.ResponseStreamEvent
+	if json.Unmarshal(data, &event) != nil {
+		return false
+	}
+	return event.Type == openai.ResponseStreamEventTypeOutputItemAdded && event.Item != nil && event.Item.Type == "function_call"
+}
+
+// streamCalls takes the function calls from the final event carrying the whole response.
+func (responsesFormat) streamCalls(held []byte) ([]call, error) {
+	var calls []call
+	terminated := false
+	err := forEachEvent(held, func(_, data []byte) error {
+		var event openai.ResponseStreamEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return err
+		}
+		switch event.Type {
+		case openai.ResponseStreamEventTypeCompleted, openai.ResponseStreamEventTypeIncomplete, openai.ResponseStreamEventTypeFailed:
+			if event.Response != nil {
+				calls, terminated = responseCalls(event.Response), true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !terminated {
+		return nil, errors.New("stream is not terminated with the response")
+	}
+	return calls, nil
+}
+
+// rewriteStream drops the events of the violating function call items, and renumbers the output indexes of the
+// following items and the sequence numbers of the events so that the client sees no gap. The violating items are
+// also removed from the responses carried by the events.
+func (responsesFormat) rewriteStream(held []byte, violations []Violation, _ bool) ([]byte, error) {
+	removed := removedIndexes(violations)
+	var out []byte
+	seq := -1
+	err := forEachEvent(held, func(event, data []byte) error {
+		var e openai.ResponseStreamEvent
+		if json.Unmarshal(data, &e) != nil {
+			out = append(out, event...)
+			return nil
+		}
+		if seq < 0 {
+			// The events are renumbered from the first one held back.
+			seq = e.SequenceNumber
+		}
+		var err error
+		if e.OutputIndex != nil {
+			if _, found := slices.BinarySearch(removed, *e.OutputIndex); found {
+				return nil
+			}
+			if n := removedBefore(removed, *e.OutputIndex); n > 0 {
+				if data, err = sjson.SetBytes(data, "output_index", *e.OutputIndex-n); err != nil {
+					return err
+				}
+			}
+		}
+		if e.Response != nil && len(e.Response.Output) > 0 {
+			if data, err = deleteIndexes(data, "response.output.", removed[:removedBefore(removed, len(e.Response.Output))]); err != nil {
+				return err
+			}
+		}
+		if data, err = sjson.SetBytes(data, "sequence_number", seq); err != nil {
+			return err
+		}
+		seq++
+		out = append(out, replaceEventData(event, data)...)
+		return nil
+	})
+	return out, err
+}
+
+// messagesFormat is the format of the Anthropic messages API, whose tool calls are the tool use blocks of the
+// content.
+type messagesFormat struct{}
+
+func (messagesFormat) calls(body []byte) ([]call, error) {
+	var msg anthropic.Message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, err
+	}
+	var calls []call
+	for i := range msg.Content {
+		if b := &msg.Content[i]; b.Type == anthropic.ContentBlockTypeToolUse {
+			calls = append(calls, call{index: i, name: b.Name, arguments: string(b.Input)})
+		}
+	}
+	return calls, nil
+}
+
+// rewrite also changes the stop reason of the message whose tool use blocks are all removed to "end_turn".
+func (f messagesFormat) rewrite(body []byte, violations []Violation) ([]byte, error) {
+	calls, err := f.calls(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the response: %w", err)
+	}
+	removed := removedIndexes(violations)
+	if body, err = deleteIndexes(body, "content.", removed); err != nil {
+		return nil, err
+	}
+	if len(removed) >= len(calls) {
+		var msg anthropic.Message
+		if err = json.Unmarshal(body, &msg); err != nil {
+			return nil, err
+		}
+		if msg.StopReason != nil && *msg.StopReason == anthropic.StopReasonToolUse {
+			return sjson.SetBytes(body, "stop_reason", anthropic.StopReasonEndTurn)
+		}
+	}
+	return body, nil
+}
+
+func (messagesFormat) startsToolCalls(data []byte) bool {
+	if !bytes.Contains(data, []byte(`"tool_use"`)) {
+		return false
+	}
+	var event anthropic.StreamEvent
+	if json.Unmarshal(data, &event) != nil {
+		return false
+	}
+	return event.Type == anthropic.StreamEventTypeContentBlockStart && event.ContentBlock != nil &&
+		event.ContentBlock.Type == anthropic.ContentBlockTypeToolUse
+}
+
+// streamCalls assembles the tool use blocks from their start events and the partial JSON of their input deltas.
+func (messagesFormat) streamCalls(held []byte) ([]call, error) {
+	var calls []call
+	// byIndex is the position in the calls of the tool use block of each index, and hasDelta is whether its input is
+	// given by the deltas instead of the start event.
+	byIndex := make(map[int]int)
+	hasDelta := make(map[int]bool)
+	stopped := false
+	err := forEachEvent(held, func(_, data []byte) error {
+		var e anthropic.StreamEvent
+		if err := json.Unmarshal(data, &e); err != nil {
+			return err
+		}
+		switch e.Type {
+		case anthropic.StreamEventTypeContentBlockStart:
+			if e.Index != nil && e.ContentBlock != nil && e.ContentBlock.Type == anthropic.ContentBlockTypeToolUse {
+				byIndex[*e.Index] = len(calls)
+				calls = append(calls, call{index: *e.Index, name: e.ContentBlock.Name, arguments: string(e.ContentBlock.Input)})
+			}
+		case anthropic.StreamEventTypeContentBlockDelta:
+			if e.Index == nil || e.Delta == nil || e.Delta.PartialJSON == nil {
+				return nil
+			}
+			i, ok := byIndex[*e.Index]
+			if !ok {
+				return nil
+			}
+			if !hasDelta[*e.Index] {
+				calls[i].arguments, hasDelta[*e.Index] = "", true
+			}
+			calls[i].arguments += *e.Delta.PartialJSON
+		case anthropic.StreamEventTypeMessageStop:
+			stopped = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !stopped {
+		return nil, errors.New("stream is not terminated with the message_stop event")
+	}
+	return calls, nil
+}
+
+// rewriteStream drops the events of the violating tool use blocks, and renumbers the indexes of the following blocks
+// so that the client sees no gap. The stop reason becomes "end_turn" when all the tool use blocks are removed.
+func (messagesFormat) rewriteStream(held []byte, violations []Violation, _ bool) ([]byte, error) {
+	removed := removedIndexes(violations)
+	toolUses := 0
+	_ = forEachEvent(held, func(_, data []byte) error {
+		if (messagesFormat{}).startsToolCalls(data) {
+			toolUses++
+		}
+		return nil
+	})
+	var out []byte
+	err := forEachEvent(held, func(event, data []byte) error {
+		var e anthropic.StreamEvent
+		if json.Unmarshal(data, &e) != nil {
+			out = append(out, event...)
+			return nil
+		}
+		changed := false
+		var err error
+		if e.Index != nil {
+			if _, found := slices.BinarySearch(removed, *e.Index); found {
+				return nil
+			}
+			if n := removedBefore(removed, *e.Index); n > 0 {
+				if data, err = sjson.SetBytes(data, "index", *e.Index-n); err != nil {
+					return err
+				}
+				changed = true
+			}
+		}
+		if e.Type == anthropic.StreamEventTypeMessageDelta && e.Delta != nil && e.Delta.StopReason == anthropic.StopReasonToolUse &&
+			len(removed) >= toolUses {
+			if data, err = sjson.SetBytes(data, "delta.stop_reason", anthropic.StopReasonEndTurn); err != nil {
+				return err
+			}
+			changed = true
+		}
+		if changed {
+			event = replaceEventData(event, data)
+		}
+		out = append(out, event...)
+		return nil
+	})
+	return out, err
+}
+
+// deleteIndexes deletes the elements at the given sorted indexes of the array at the given path prefix of the JSON.
+func deleteIndexes(body []byte, prefix string, indexes []int) ([]byte, error) {
+	var err error
+	// Delete from the last one so that the positions of the rest are not shifted.
+	for _, i := range slices.Backward(indexes) {
+		if body, err = sjson.DeleteBytes(body, prefix+strconv.Itoa(i)); err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
@@ -0,0 +1,194 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package toolpolicy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// stream passes the given chunks to the validator, and returns the released events and the violations.
+func stream(t *testing.T, v *Validator, chunks ...string) (out []byte, violations []Violation) {
+	for i, c := range chunks {
+		o, vs, err := v.Stream([]byte(c), i == len(chunks)-1, false)
+		require.NoError(t, err)
+		out = append(out, o...)
+		violations = append(violations, vs...)
+	}
+	return
+}
+
+func TestPolicy_CheckResponses(t *testing.T) {
+	p := NewPolicy(&filterapi.ToolPolicy{AllowedFunctions: []string{"get_weather"}, ValidateArguments: true})
+	_, _, err := p.CheckResponses(&openai.ResponseRequest{Tools: []openai.ResponseTool{
+		{Type: "function", Name: "get_weather"}, {Type: "function", Name: "rm"}, {Type: "web_search"},
+	}})
+	require.EqualError(t, err, "the request declares the tools not allowed by the route: rm")
+
+	v, _, err := p.CheckResponses(&openai.ResponseRequest{})
+	require.NoError(t, err)
+	require.Nil(t, v)
+
+	v, _, err = p.CheckResponses(&openai.ResponseRequest{Tools: []openai.ResponseTool{
+		{Type: "function", Name: "get_weather", Parameters: weatherTool.Function.Parameters},
+	}})
+	require.NoError(t, err)
+
+	const body = `{"id":"resp_1","object":"response","status":"completed","model":"gpt-4o","output":[` +
+		`{"type":"message","id":"msg_1","role":"assistant","content":[{"type":"output_text","text":"Hi"}]},` +
+		`{"type":"function_call","id":"fc_1","call_id":"1","name":"get_weather","arguments":"{\"town\":\"Tokyo\"}"},` +
+		`{"type":"function_call","id":"fc_2","call_id":"2","name":"get_weather","arguments":"{\"city\":\"Tokyo\"}"}]}`
+	violations, err := v.Validate([]byte(body))
+	require.NoError(t, err)
+	require.Len(t, violations, 1)
+	require.Equal(t, 1, violations[0].Index)
+
+	rewritten, err := v.Rewrite([]byte(body), violations)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":"resp_1","object":"response","status":"completed","model":"gpt-4o","output":[`+
+		`{"type":"message","id":"msg_1","role":"assistant","content":[{"type":"output_text","text":"Hi"}]},`+
+		`{"type":"function_call","id":"fc_2","call_id":"2","name":"get_weather","arguments":"{\"city\":\"Tokyo\"}"}]}`, string(rewritten))
+}
+
+func TestValidator_Stream_Responses(t *testing.T) {
+	const (
+		text    = "event: response.output_text.delta\n" + `data: {"type":"response.output_text.delta","sequence_number":3,"output_index":0,"delta":"Hi"}` + "\n\n"
+		call1   = "event: response.output_item.added\n" + `data: {"type":"response.output_item.added","sequence_number":4,"output_index":1,"item":{"type":"function_call","id":"fc_1","name":"rm"}}` + "\n\n"
+		call1b  = "event: response.function_call_arguments.done\n" + `data: {"type":"response.function_call_arguments.done","sequence_number":5,"output_index":1,"arguments":"{}"}` + "\n\n"
+		call2   = "event: response.output_item.added\n" + `data: {"type":"response.output_item.added","sequence_number":6,"output_index":2,"item":{"type":"function_call","id":"fc_2","name":"get_weather"}}` + "\n\n"
+		resp    = `{"id":"resp_1","object":"response","status":"completed","model":"gpt-4o","output":[{"type":"message","id":"msg_1"},{"type":"function_call","id":"fc_1","name":"rm","arguments":"{}"},{"type":"function_call","id":"fc_2","name":"get_weather","arguments":"{\"city\":\"Tokyo\"}"}]}`
+		final   = "event: response.completed\n" + `data: {"type":"response.completed","sequence_number":7,"response":` + resp + "}\n\n"
+		newPart = "event: response.output_item.added\n" + `data: {"type":"response.output_item.added","sequence_number":4,"output_index":1,"item":{"type":"function_call","id":"fc_2","name":"get_weather"}}` + "\n\n"
+	)
+	req := &openai.ResponseRequest{Tools: []openai.ResponseTool{{Type: "function", Name: "get_weather"}, {Type: "function", Name: "rm"}}}
+
+	t.Run("allowed", func(t *testing.T) {
+		v, _, err := NewPolicy(&filterapi.ToolPolicy{}).CheckResponses(req)
+		require.NoError(t, err)
+		out, violations := stream(t, v, text, call1, call1b, call2, final)
+		require.Empty(t, violations)
+		require.Equal(t, text+call1+call1b+call2+final, string(out))
+	})
+	t.Run("block", func(t *testing.T) {
+		v, _, err := NewPolicy(&filterapi.ToolPolicy{AllowedFunctions: []string{"get_weather"}}).
+			CheckResponses(&openai.ResponseRequest{Tools: []openai.ResponseTool{{Type: "function", Name: "get_weather"}}})
+		require.NoError(t, err)
+		out, violations := stream(t, v, text, call1, call1b, call2, final)
+		require.Equal(t, []Violation{{Index: 1, Name: "rm", Reason: "the function is not allowed"}}, violations)
+		require.Equal(t, text, string(out))
+	})
+	t.Run("rewrite", func(t *testing.T) {
+		v, _, err := NewPolicy(&filterapi.ToolPolicy{AllowedFunctions: []string{"get_weather"}, Action: filterapi.ToolPolicyActionRewrite}).
+			CheckResponses(&openai.ResponseRequest{Tools: []openai.ResponseTool{{Type: "function", Name: "get_weather"}}})
+		require.NoError(t, err)
+		out, violations := stream(t, v, text, call1, call1b, call2, final)
+		require.Len(t, violations, 1)
+		// The events of the violating item are dropped, and the rest are renumbered.
+		require.Equal(t, text+newPart+"event: response.completed\n"+
+			`data: {"type":"response.completed","sequence_number":5,"response":{"id":"resp_1","object":"response","status":"completed","model":"gpt-4o",`+
+			`"output":[{"type":"message","id":"msg_1"},{"type":"function_call","id":"fc_2","name":"get_weather","arguments":"{\"city\":\"Tokyo\"}"}]}}`+"\n\n", string(out))
+	})
+	t.Run("not terminated", func(t *testing.T) {
+		v, _, err := NewPolicy(&filterapi.ToolPolicy{}).CheckResponses(req)
+		require.NoError(t, err)
+		_, _, err = v.Stream([]byte(call1), false, false)
+		require.NoError(t, err)
+		out, _, err := v.Stream(nil, true, false)
+		require.ErrorContains(t, err, "stream is not terminated with the response")
+		require.Equal(t, call1, string(out))
+	})
+}
+
+func TestPolicy_CheckMessages(t *testing.T) {
+	p := NewPolicy(&filterapi.ToolPolicy{AllowedFunctions: []string{"get_weather"}, ValidateArguments: true})
+	// The server tools are not checked.
+	_, _, err := p.CheckMessages(&anthropic.MessagesRequest{Tools: []anthropic.Tool{
+		{Name: "get_weather"}, {Type: "custom", Name: "rm"}, {Type: "web_search_20250305", Name: "web_search"},
+	}})
+	require.EqualError(t, err, "the request declares the tools not allowed by the route: rm")
+
+	v, _, err := p.CheckMessages(&anthropic.MessagesRequest{})
+	require.NoError(t, err)
+	require.Nil(t, v)
+
+	v, _, err = p.CheckMessages(&anthropic.MessagesRequest{Tools: []anthropic.Tool{
+		{Name: "get_weather", InputSchema: weatherTool.Function.Parameters.(map[string]any)},
+	}})
+	require.NoError(t, err)
+
+	const body = `{"id":"msg_1","type":"message","role":"assistant","model":"claude","stop_reason":"tool_use","stop_sequence":null,` +
+		`"content":[{"type":"text","text":"Hi"},{"type":"tool_use","id":"tu_1","name":"get_weather","input":{"town":"Tokyo"}}],` +
+		`"usage":{"input_tokens":1,"output_tokens":2}}`
+	violations, err := v.Validate([]byte(body))
+	require.NoError(t, err)
+	require.Len(t, violations, 1)
+	require.Equal(t, 1, violations[0].Index)
+
+	rewritten, err := v.Rewrite([]byte(body), violations)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":"msg_1","type":"message","role":"assistant","model":"claude","stop_reason":"end_turn","stop_sequence":null,`+
+		`"content":[{"type":"text","text":"Hi"}],"usage":{"input_tokens":1,"output_tokens":2}}`, string(rewritten))
+}
+
+func TestValidator_Stream_Messages(t *testing.T) {
+	const (
+		text   = "event: content_block_delta\n" + `data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi"}}` + "\n\n"
+		start1 = "event: content_block_start\n" + `data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"tu_1","name":"rm","input":{}}}` + "\n\n"
+		stop1  = "event: content_block_stop\n" + `data: {"type":"content_block_stop","index":1}` + "\n\n"
+		start2 = "event: content_block_start\n" + `data: {"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"tu_2","name":"get_weather","input":{}}}` + "\n\n"
+		delta2 = "event: content_block_delta\n" + `data: {"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"town\":\"Tokyo\"}"}}` + "\n\n"
+		stop2  = "event: content_block_stop\n" + `data: {"type":"content_block_stop","index":2}` + "\n\n"
+		delta  = "event: message_delta\n" + `data: {"type":"message_delta","delta":{"stop_reason":"tool_use","stop_sequence":null},"usage":{"output_tokens":7}}` + "\n\n"
+		stop   = "event: message_stop\n" + `data: {"type":"message_stop"}` + "\n\n"
+	)
+	req := &anthropic.MessagesRequest{Tools: []anthropic.Tool{
+		{Name: "get_weather", InputSchema: weatherTool.Function.Parameters.(map[string]any)}, {Name: "rm"},
+	}}
+
+	t.Run("block", func(t *testing.T) {
+		v, _, err := NewPolicy(&filterapi.ToolPolicy{ValidateArguments: true}).CheckMessages(req)
+		require.NoError(t, err)
+		out, violations := stream(t, v, text, start1, stop1, start2, delta2, stop2, delta, stop)
+		require.Len(t, violations, 1)
+		require.Equal(t, 2, violations[0].Index)
+		require.Contains(t, violations[0].Reason, "the arguments do not match the parameters")
+		require.Equal(t, text, string(out))
+	})
+	t.Run("rewrite", func(t *testing.T) {
+		v, _, err := NewPolicy(&filterapi.ToolPolicy{AllowedFunctions: []string{"get_weather"}, Action: filterapi.ToolPolicyActionRewrite}).
+			CheckMessages(&anthropic.MessagesRequest{Tools: []anthropic.Tool{{Name: "get_weather"}}})
+		require.NoError(t, err)
+		out, violations := stream(t, v, text, start1, stop1, start2, delta2, stop2, delta, stop)
+		require.Equal(t, []Violation{{Index: 1, Name: "rm", Reason: "the function is not allowed"}}, violations)
+		// The events of the violating block are dropped, and the following block is renumbered.
+		require.Equal(t, text+
+			"event: content_block_start\n"+`data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"tu_2","name":"get_weather","input":{}}}`+"\n\n"+
+			"event: content_block_delta\n"+`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"town\":\"Tokyo\"}"}}`+"\n\n"+
+			"event: content_block_stop\n"+`data: {"type":"content_block_stop","index":1}`+"\n\n"+
+			delta+stop, string(out))
+	})
+	t.Run("rewrite all", func(t *testing.T) {
+		v, _, err := NewPolicy(&filterapi.ToolPolicy{AllowedFunctions: []string{"get_weather"}, Action: filterapi.ToolPolicyActionRewrite}).
+			CheckMessages(&anthropic.MessagesRequest{Tools: []anthropic.Tool{{Name: "get_weather"}}})
+		require.NoError(t, err)
+		out, violations := stream(t, v, text, start1, stop1, delta, stop)
+		require.Len(t, violations, 1)
+		require.Equal(t, text+"event: message_delta\n"+
+			`data: {"type":"message_delta","delta":{"stop_reason":"end_turn","stop_sequence":null},"usage":{"output_tokens":7}}`+"\n\n"+stop, string(out))
+	})
+	t.Run("not terminated", func(t *testing.T) {
+		v, _, err := NewPolicy(&filterapi.ToolPolicy{}).CheckMessages(req)
+		require.NoError(t, err)
+		out, _, err := v.Stream([]byte(start1), true, false)
+		require.ErrorContains(t, err, "stream is not terminated with the message_stop event")
+		require.Equal(t, start1, string(out))
+	})
+}
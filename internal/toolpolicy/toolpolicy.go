@@ -0,0 +1,404 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package toolpolicy provides the tool policies of the routes, which restrict the functions the requests of the
+// OpenAI chat completions and responses APIs and the Anthropic messages API may declare, and validate the tool calls
+// returned by the models against the declared functions.
+//
+// The requests declaring the functions not allowed by the route are rejected by [Policy.Check],
+// [Policy.CheckResponses] or [Policy.CheckMessages] depending on the API. The tool calls of the responses are
+// validated by the [Validator] returned by them in the format of the API, and the responses with the violating ones
+// are either blocked or rewritten without them.
+package toolpolicy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// Set is the set of the tool policies of the routes.
+type Set struct {
+	matches filterapi.RouteRuleMatcher[*Policy]
+}
+
+// NewSet creates a new [Set] from the given configurations. This returns nil if there's no configuration.
+func NewSet(configs []filterapi.ToolPolicy) *Set {
+	if len(configs) == 0 {
+		return nil
+	}
+	s := &Set{}
+	for i := range configs {
+		c := &configs[i]
+		p := NewPolicy(c)
+		for _, m := range c.Matches {
+			s.matches.Add(m, p)
+		}
+	}
+	return s
+}
+
+// Find returns the tool policy of the route matching the given model and the request headers, or nil if there's none
+// or the set is nil. When multiple rules match, the one matching the most headers is returned in the same way as the
+// precedence of the HTTPRoute rules.
+func (s *Set) Find(model string, headers map[string]string) *Policy {
+	if s == nil {
+		return nil
+	}
+	return s.matches.Find(model, headers)
+}
+
+// Policy is the tool policy of a route.
+type Policy struct {
+	config *filterapi.ToolPolicy
+	// allowed is the set of the names of the allowed functions, which is nil when any function is allowed.
+	allowed map[string]struct{}
+}
+
+// NewPolicy creates a new [Policy] from the given configuration.
+func NewPolicy(config *filterapi.ToolPolicy) *Policy {
+	p := &Policy{config: config}
+	if len(config.AllowedFunctions) > 0 {
+		p.allowed = make(map[string]struct{}, len(config.AllowedFunctions))
+		for _, name := range config.AllowedFunctions {
+			p.allowed[name] = struct{}{}
+		}
+	}
+	return p
+}
+
+// Name returns the name of the route of the policy.
+func (p *Policy) Name() string { return p.config.Name }
+
+// Action returns what is done with the responses containing the violating tool calls.
+func (p *Policy) Action() filterapi.ToolPolicyAction { return p.config.Action }
+
+// allows reports whether the function of the given name is allowed by the policy.
+func (p *Policy) allows(name string) bool {
+	if p.allowed == nil {
+		return true
+	}
+	_, ok := p.allowed[name]
+	return ok
+}
+
+// NotAllowedError is the error returned by [Policy.Check], [Policy.CheckResponses] and [Policy.CheckMessages] when the request declares the functions not allowed by the
+// policy.
+type NotAllowedError struct {
+	// Names are the names of the functions not allowed by the policy in the order of the declaration.
+	Names []string
+}
+
+// Error implements [error].
+func (e *NotAllowedError) Error() string {
+	return "the request declares the tools not allowed by the route: " + strings.Join(e.Names, ", ")
+}
+
+// function is a function declared by the request.
+type function struct {
+	name string
+	// parameters is the JSON Schema of the parameters of the function, which is nil when it has none.
+	parameters any
+}
+
+// Check checks the functions declared by the given chat completion request, and returns the [Validator] of the tool
+// calls of its response. This returns [*NotAllowedError] when the request declares the functions not allowed by the
+// policy, and nil validator when the request declares no tool since the model cannot call any tool then.
+//
+// The parameters of the functions which cannot be compiled as JSON Schema are not validated. The compilation errors
+// are returned along with the validator so that they can be logged.
+func (p *Policy) Check(req *openai.ChatCompletionRequest) (*Validator, []error, error) {
+	if len(req.Tools) == 0 {
+		return nil, nil, nil
+	}
+	var functions []function
+	for i := range req.Tools {
+		if f := req.Tools[i].Function; f != nil {
+			functions = append(functions, function{name: f.Name, parameters: f.Parameters})
+		}
+	}
+	return p.check(functions, chatCompletionFormat{})
+}
+
+// CheckResponses is the same as [Policy.Check] for the request of the OpenAI responses API, whose function tools
+// are checked.
+func (p *Policy) CheckResponses(req *openai.ResponseRequest) (*Validator, []error, error) {
+	if len(req.Tools) == 0 {
+		return nil, nil, nil
+	}
+	var functions []function
+	for i := range req.Tools {
+		if t := &req.Tools[i]; t.Type == "function" {
+			functions = append(functions, function{name: t.Name, parameters: t.Parameters})
+		}
+	}
+	return p.check(functions, responsesFormat{})
+}
+
+// CheckMessages is the same as [Policy.Check] for the request of the Anthropic messages API, whose client tools are
+// checked. The server tools are run by the provider, and their calls are not returned as the tool use blocks.
+func (p *Policy) CheckMessages(req *anthropic.MessagesRequest) (*Validator, []error, error) {
+	if len(req.Tools) == 0 {
+		return nil, nil, nil
+	}
+	var functions []function
+	for i := range req.Tools {
+		t := &req.Tools[i]
+		if t.Type != "" && t.Type != "custom" {
+			continue
+		}
+		f := function{name: t.Name}
+		if t.InputSchema != nil {
+			f.parameters = t.InputSchema
+		}
+		functions = append(functions, f)
+	}
+	return p.check(functions, messagesFormat{})
+}
+
+// check checks the given functions declared by the request, and returns the [Validator] of the tool calls of its
+// response in the given format.
+func (p *Policy) check(functions []function, f format) (*Validator, []error, error) {
+	var notAllowed []string
+	for _, fn := range functions {
+		if !p.allows(fn.name) {
+			notAllowed = append(notAllowed, fn.name)
+		}
+	}
+	if len(notAllowed) > 0 {
+		return nil, nil, &NotAllowedError{Names: notAllowed}
+	}
+	v := &Validator{policy: p, format: f, declared: make(map[string]*jsonschema.Schema, len(functions))}
+	var errs []error
+	for _, fn := range functions {
+		var schema *jsonschema.Schema
+		if p.config.ValidateArguments && fn.parameters != nil {
+			var err error
+			if schema, err = compileSchema(fn.name, fn.parameters); err != nil {
+				errs = append(errs, fmt.Errorf("invalid parameters of function %s: %w", fn.name, err))
+			}
+		}
+		v.declared[fn.name] = schema
+	}
+	return v, errs, nil
+}
+
+// compileSchema compiles the given parameters of the function as JSON Schema. The external references are not
+// loaded since the schema is given by the client.
+func compileSchema(name string, parameters any) (*jsonschema.Schema, error) {
+	raw, err := json.Marshal(parameters)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	c := jsonschema.NewCompiler()
+	c.UseLoader(jsonschema.SchemeURLLoader{})
+	url := "urn:tool:" + name
+	if err = c.AddResource(url, doc); err != nil {
+		return nil, err
+	}
+	return c.Compile(url)
+}
+
+// Violation is a tool call of a response violating the policy.
+type Violation struct {
+	// Choice is the position of the choice in the chat completion response, which is always zero for the other APIs.
+	Choice int
+	// Index is the position of the tool call in the message of the choice, of the function call item in the output of
+	// the response, or of the tool use block in the content of the message, depending on the API.
+	Index int
+	// Name is the name of the called function.
+	Name string
+	// Reason describes the violation.
+	Reason string
+}
+
+// String returns the description of the violation.
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Name, v.Reason)
+}
+
+// Validator validates the tool calls of the response of a request against the functions declared by the request.
+// This is not safe for concurrent use.
+type Validator struct {
+	policy *Policy
+	// format is the format of the responses of the API of the request.
+	format format
+	// declared is the compiled schema of the parameters of each declared function, which is nil when the arguments
+	// are not validated.
+	declared map[string]*jsonschema.Schema
+	// buf is the incomplete server-sent event carried over to the next chunk of the streaming response.
+	buf []byte
+	// held is the server-sent events held back since the first event with the tool calls.
+	held []byte
+}
+
+// Policy returns the policy of the validator.
+func (v *Validator) Policy() *Policy { return v.policy }
+
+// Validate returns the violating tool calls of the given non-streaming response body in the format of the API.
+func (v *Validator) Validate(body []byte) ([]Violation, error) {
+	calls, err := v.format.calls(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the response: %w", err)
+	}
+	return v.violations(calls), nil
+}
+
+// violations returns the violations of the given tool calls.
+func (v *Validator) violations(calls []call) []Violation {
+	var violations []Violation
+	for _, c := range calls {
+		if reason := v.validateCall(c.name, c.arguments); reason != "" {
+			violations = append(violations, Violation{Choice: c.choice, Index: c.index, Name: c.name, Reason: reason})
+		}
+	}
+	return violations
+}
+
+// validateCall returns the reason why the call of the given function violates the policy, or empty if it doesn't.
+func (v *Validator) validateCall(name, arguments string) string {
+	if !v.policy.allows(name) {
+		return "the function is not allowed"
+	}
+	schema, ok := v.declared[name]
+	if !ok {
+		return "the function is not declared by the request"
+	}
+	if !v.policy.config.ValidateArguments {
+		return ""
+	}
+	if strings.TrimSpace(arguments) == "" {
+		// Some providers return no arguments for the functions without parameters.
+		arguments = "{}"
+	}
+	inst, err := jsonschema.UnmarshalJSON(strings.NewReader(arguments))
+	if err != nil {
+		return "the arguments are not valid JSON"
+	}
+	if schema == nil {
+		return ""
+	}
+	if err = schema.Validate(inst); err != nil {
+		return "the arguments do not match the parameters: " + strings.ReplaceAll(err.Error(), "\n", " ")
+	}
+	return ""
+}
+
+// Rewrite removes the given violating tool calls from the non-streaming response body in the format of the API. The
+// response whose tool calls are all removed no longer stops for them, e.g. the finish reason of the chat completion
+// choice becomes "stop".
+func (v *Validator) Rewrite(body []byte, violations []Violation) ([]byte, error) {
+	return v.format.rewrite(body, violations)
+}
+
+// Stream validates the tool calls of the streaming response in the format of the API, and returns the server-sent
+// events to release to the client. The events are released as is until the first one with the tool calls, and the rest are
+// held back until the end of the stream where the tool calls are validated.
+//
+// When there's no violation, the held events are released. Otherwise, the violations are returned, and the held
+// events are either dropped with [filterapi.ToolPolicyActionBlock], or re-emitted without the violating tool calls
+// with [filterapi.ToolPolicyActionRewrite]. When the held events cannot be validated, they are released along with the
+// error. The includeUsage is only used by the chat completions API whose re-emitted events include the usage chunk.
+func (v *Validator) Stream(chunk []byte, endOfStream bool, includeUsage bool) ([]byte, []Violation, error) {
+	v.buf = append(v.buf, chunk...)
+	var out []byte
+	for {
+		i := bytes.Index(v.buf, []byte("\n\n"))
+		if i < 0 {
+			break
+		}
+		event := v.buf[:i+2]
+		if v.held != nil || v.format.startsToolCalls(eventData(event)) {
+			v.held = append(v.held, event...)
+		} else {
+			out = append(out, event...)
+		}
+		v.buf = v.buf[i+2:]
+	}
+	if !endOfStream {
+		return out, nil, nil
+	}
+	held := v.held
+	held = append(held, v.buf...)
+	v.held, v.buf = nil, nil
+	if len(held) == 0 {
+		return out, nil, nil
+	}
+	calls, err := v.format.streamCalls(held)
+	if err != nil {
+		return append(out, held...), nil, fmt.Errorf("failed to assemble the streaming response: %w", err)
+	}
+	violations := v.violations(calls)
+	switch {
+	case len(violations) == 0:
+		return append(out, held...), nil, nil
+	case v.policy.Action() == filterapi.ToolPolicyActionRewrite:
+		rewritten, err := v.format.rewriteStream(held, violations, includeUsage)
+		if err != nil {
+			return append(out, held...), nil, fmt.Errorf("failed to rewrite the streaming response: %w", err)
+		}
+		return append(out, rewritten...), violations, nil
+	default:
+		return out, violations, nil
+	}
+}
+
+// eventData returns the data of the given server-sent event, or nil if it has none.
+func eventData(event []byte) []byte {
+	for _, line := range bytes.Split(event, []byte("\n")) {
+		if data, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+			return bytes.TrimSpace(data)
+		}
+	}
+	return nil
+}
+
+// replaceEventData returns the given server-sent event with its data replaced with the given one.
+func replaceEventData(event, data []byte) []byte {
+	lines := bytes.Split(bytes.TrimRight(event, "\n"), []byte("\n"))
+	var out []byte
+	for _, line := range lines {
+		if bytes.HasPrefix(line, []byte("data:")) {
+			line = append([]byte("data: "), data...)
+		}
+		out = append(out, line...)
+		out = append(out, '\n')
+	}
+	return append(out, '\n')
+}
+
+// forEachEvent calls the given function with each server-sent event of the given body and its data. The incomplete
+// event at the end of the body is also passed as is.
+func forEachEvent(body []byte, f func(event, data []byte) error) error {
+	for len(body) > 0 {
+		event := body
+		if i := bytes.Index(body, []byte("\n\n")); i >= 0 {
+			event = body[:i+2]
+		}
+		if err := f(event, eventData(event)); err != nil {
+			return err
+		}
+		body = body[len(event):]
+	}
+	return nil
+}
+
+// removedBefore returns the number of the given sorted indexes less than the given index.
+func removedBefore(removed []int, index int) int {
+	n, _ := slices.BinarySearch(removed, index)
+	return n
+}
@@ -0,0 +1,218 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package toolpolicy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestSet_Find(t *testing.T) {
+	require.Nil(t, NewSet(nil))
+	require.Nil(t, (*Set)(nil).Find("m", nil))
+
+	s := NewSet([]filterapi.ToolPolicy{
+		{Name: "default", Matches: []filterapi.RouteRuleMatch{{Model: "m"}}},
+		{Name: "agents", Matches: []filterapi.RouteRuleMatch{{Model: "m", Headers: map[string]string{"x-team": "agents"}}}},
+		{Name: "other", Matches: []filterapi.RouteRuleMatch{{Model: "m2"}}},
+	})
+	require.Equal(t, "default", s.Find("m", map[string]string{}).Name())
+	require.Equal(t, "default", s.Find("m", map[string]string{"x-team": "search"}).Name())
+	require.Equal(t, "agents", s.Find("m", map[string]string{"x-team": "agents"}).Name())
+	require.Equal(t, "other", s.Find("m2", nil).Name())
+	require.Nil(t, s.Find("unknown", nil))
+}
+
+// weatherTool is the tool of the get_weather function with the required city parameter.
+var weatherTool = openai.Tool{Type: openai.ToolTypeFunction, Function: &openai.FunctionDefinition{
+	Name: "get_weather",
+	Parameters: map[string]any{
+		"type":                 "object",
+		"properties":           map[string]any{"city": map[string]any{"type": "string"}},
+		"required":             []any{"city"},
+		"additionalProperties": false,
+	},
+}}
+
+func tool(name string) openai.Tool {
+	return openai.Tool{Type: openai.ToolTypeFunction, Function: &openai.FunctionDefinition{Name: name}}
+}
+
+func TestPolicy_Check(t *testing.T) {
+	p := NewPolicy(&filterapi.ToolPolicy{Name: "ns/route", AllowedFunctions: []string{"get_weather", "get_time"}, ValidateArguments: true})
+
+	t.Run("not allowed", func(t *testing.T) {
+		_, _, err := p.Check(&openai.ChatCompletionRequest{Tools: []openai.Tool{weatherTool, tool("rm"), tool("exec")}})
+		var notAllowed *NotAllowedError
+		require.ErrorAs(t, err, &notAllowed)
+		require.Equal(t, []string{"rm", "exec"}, notAllowed.Names)
+		require.EqualError(t, err, "the request declares the tools not allowed by the route: rm, exec")
+	})
+	t.Run("no tools", func(t *testing.T) {
+		v, errs, err := p.Check(&openai.ChatCompletionRequest{})
+		require.NoError(t, err)
+		require.Empty(t, errs)
+		require.Nil(t, v)
+	})
+	t.Run("any function", func(t *testing.T) {
+		v, _, err := NewPolicy(&filterapi.ToolPolicy{}).Check(&openai.ChatCompletionRequest{Tools: []openai.Tool{tool("rm")}})
+		require.NoError(t, err)
+		require.NotNil(t, v)
+	})
+	t.Run("invalid schema", func(t *testing.T) {
+		invalid := openai.Tool{Type: openai.ToolTypeFunction, Function: &openai.FunctionDefinition{
+			Name:       "get_time",
+			Parameters: map[string]any{"type": 1},
+		}}
+		v, errs, err := p.Check(&openai.ChatCompletionRequest{Tools: []openai.Tool{weatherTool, invalid}})
+		require.NoError(t, err)
+		require.Len(t, errs, 1)
+		require.ErrorContains(t, errs[0], "invalid parameters of function get_time")
+		// The arguments of the function are still required to be valid JSON.
+		violations, err := v.Validate([]byte(`{"choices":[{"message":{"tool_calls":[
+{"id":"1","type":"function","function":{"name":"get_time","arguments":"{\"tz\":\"UTC\"}"}},
+{"id":"2","type":"function","function":{"name":"get_time","arguments":"{"}}]}}]}`))
+		require.NoError(t, err)
+		require.Equal(t, []Violation{{Choice: 0, Index: 1, Name: "get_time", Reason: "the arguments are not valid JSON"}}, violations)
+	})
+	t.Run("external reference", func(t *testing.T) {
+		ref := openai.Tool{Type: openai.ToolTypeFunction, Function: &openai.FunctionDefinition{
+			Name:       "get_time",
+			Parameters: map[string]any{"$ref": "http://127.0.0.1:1/schema.json"},
+		}}
+		_, errs, err := p.Check(&openai.ChatCompletionRequest{Tools: []openai.Tool{ref}})
+		require.NoError(t, err)
+		require.Len(t, errs, 1)
+	})
+}
+
+func TestValidator_Validate(t *testing.T) {
+	req := &openai.ChatCompletionRequest{Tools: []openai.Tool{weatherTool, tool("get_time")}}
+	body := []byte(`{"choices":[
+{"index":0,"finish_reason":"tool_calls","message":{"role":"assistant","tool_calls":[
+{"id":"1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Tokyo\"}"}},
+{"id":"2","type":"function","function":{"name":"get_weather","arguments":"{\"town\":\"Tokyo\"}"}},
+{"id":"3","type":"function","function":{"name":"get_time","arguments":""}},
+{"id":"4","type":"function","function":{"name":"get_stock","arguments":"{}"}}]}},
+{"index":1,"finish_reason":"tool_calls","message":{"role":"assistant","tool_calls":[
+{"id":"5","type":"function","function":{"name":"rm","arguments":"{}"}}]}}]}`)
+
+	t.Run("arguments", func(t *testing.T) {
+		p := NewPolicy(&filterapi.ToolPolicy{AllowedFunctions: []string{"get_weather", "get_time", "get_stock"}, ValidateArguments: true})
+		v, _, err := p.Check(req)
+		require.NoError(t, err)
+		violations, err := v.Validate(body)
+		require.NoError(t, err)
+		require.Len(t, violations, 3)
+		require.Equal(t, 1, violations[0].Index)
+		require.Equal(t, "get_weather", violations[0].Name)
+		require.True(t, strings.HasPrefix(violations[0].Reason, "the arguments do not match the parameters: "), violations[0].Reason)
+		require.Equal(t, Violation{Choice: 0, Index: 3, Name: "get_stock", Reason: "the function is not declared by the request"}, violations[1])
+		require.Equal(t, Violation{Choice: 1, Index: 0, Name: "rm", Reason: "the function is not allowed"}, violations[2])
+		require.Equal(t, "rm: the function is not allowed", violations[2].String())
+	})
+	t.Run("names only", func(t *testing.T) {
+		v, _, err := NewPolicy(&filterapi.ToolPolicy{}).Check(req)
+		require.NoError(t, err)
+		violations, err := v.Validate(body)
+		require.NoError(t, err)
+		require.Equal(t, []Violation{
+			{Choice: 0, Index: 3, Name: "get_stock", Reason: "the function is not declared by the request"},
+			{Choice: 1, Index: 0, Name: "rm", Reason: "the function is not declared by the request"},
+		}, violations)
+	})
+	t.Run("invalid body", func(t *testing.T) {
+		v, _, err := NewPolicy(&filterapi.ToolPolicy{}).Check(req)
+		require.NoError(t, err)
+		_, err = v.Validate([]byte("{"))
+		require.Error(t, err)
+	})
+}
+
+func TestValidator_Rewrite(t *testing.T) {
+	v, _, err := NewPolicy(&filterapi.ToolPolicy{}).Check(&openai.ChatCompletionRequest{Tools: []openai.Tool{weatherTool}})
+	require.NoError(t, err)
+	body := []byte(`{"choices":[{"index":0,"finish_reason":"tool_calls","message":{"tool_calls":[{"id":"1"},{"id":"2"},{"id":"3"}]}},` +
+		`{"index":1,"finish_reason":"tool_calls","message":{"content":"hi","tool_calls":[{"id":"4"}]}}]}`)
+	rewritten, err := v.Rewrite(body, []Violation{{Choice: 0, Index: 0}, {Choice: 0, Index: 2}, {Choice: 1, Index: 0}})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"choices":[{"index":0,"finish_reason":"tool_calls","message":{"tool_calls":[{"id":"2"}]}},`+
+		`{"index":1,"finish_reason":"stop","message":{"content":"hi"}}]}`, string(rewritten))
+
+	_, err = v.Rewrite([]byte("{"), nil)
+	require.Error(t, err)
+}
+
+func TestValidator_Stream(t *testing.T) {
+	const (
+		content = `data: {"id":"c","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"role":"assistant","content":"Let me check."}}]}` + "\n\n"
+		call1   = `data: {"id":"c","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"1","type":"function","function":{"name":"get_weather","arguments":"{\"ci"}}]}}]}` + "\n\n"
+		call1b  = `data: {"id":"c","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"ty\":\"Tokyo\"}"}}]}}]}` + "\n\n"
+		call2   = `data: {"id":"c","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"2","type":"function","function":{"name":"rm","arguments":"{}"}}]}}]}` + "\n\n"
+		finish  = `data: {"id":"c","object":"chat.completion.chunk","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}` + "\n\n"
+		done    = "data: [DONE]\n\n"
+	)
+	req := &openai.ChatCompletionRequest{Tools: []openai.Tool{weatherTool, tool("rm")}}
+
+	t.Run("allowed", func(t *testing.T) {
+		v, _, err := NewPolicy(&filterapi.ToolPolicy{ValidateArguments: true}).Check(req)
+		require.NoError(t, err)
+		// The event split across the chunks is carried over, and the events are held back from the first tool call.
+		out, _, err := v.Stream([]byte(content+call1[:20]), false, false)
+		require.NoError(t, err)
+		require.Equal(t, content, string(out))
+		out, _, err = v.Stream([]byte(call1[20:]+call1b+call2+finish), false, false)
+		require.NoError(t, err)
+		require.Empty(t, out)
+		out, violations, err := v.Stream([]byte(done), true, false)
+		require.NoError(t, err)
+		require.Empty(t, violations)
+		require.Equal(t, call1+call1b+call2+finish+done, string(out))
+	})
+	t.Run("no tool calls", func(t *testing.T) {
+		v, _, err := NewPolicy(&filterapi.ToolPolicy{}).Check(req)
+		require.NoError(t, err)
+		out, violations := stream(t, v, content, finish, done)
+		require.Empty(t, violations)
+		require.Equal(t, content+finish+done, string(out))
+	})
+	t.Run("block", func(t *testing.T) {
+		v, _, err := NewPolicy(&filterapi.ToolPolicy{AllowedFunctions: []string{"get_weather"}, Action: filterapi.ToolPolicyActionBlock}).
+			Check(&openai.ChatCompletionRequest{Tools: []openai.Tool{weatherTool}})
+		require.NoError(t, err)
+		out, violations := stream(t, v, content, call1, call1b, call2, finish, done)
+		require.Equal(t, []Violation{{Choice: 0, Index: 1, Name: "rm", Reason: "the function is not allowed"}}, violations)
+		require.Equal(t, content, string(out))
+	})
+	t.Run("rewrite", func(t *testing.T) {
+		v, _, err := NewPolicy(&filterapi.ToolPolicy{ValidateArguments: true, Action: filterapi.ToolPolicyActionRewrite}).
+			Check(&openai.ChatCompletionRequest{Tools: []openai.Tool{weatherTool}})
+		require.NoError(t, err)
+		out, violations := stream(t, v, content, call1, call1b, call2, finish, done)
+		require.Len(t, violations, 1)
+		require.Equal(t, "rm", violations[0].Name)
+		require.True(t, strings.HasPrefix(string(out), content))
+		require.True(t, strings.HasSuffix(string(out), done))
+		require.Contains(t, string(out), `"name":"get_weather"`)
+		require.NotContains(t, string(out), `"name":"rm"`)
+	})
+	t.Run("not terminated", func(t *testing.T) {
+		v, _, err := NewPolicy(&filterapi.ToolPolicy{}).Check(req)
+		require.NoError(t, err)
+		_, _, err = v.Stream([]byte(call1), false, false)
+		require.NoError(t, err)
+		out, violations, err := v.Stream(nil, true, false)
+		require.ErrorContains(t, err, "failed to assemble the streaming response")
+		require.Empty(t, violations)
+		// The held events are released as is.
+		require.Equal(t, call1, string(out))
+	})
+}
@@ -72,6 +72,15 @@ type ChatCompletionSpan interface {
 	//   - statusCode: HTTP status code of the response or zero if unknown.
 	//   - body: the entire buffered response body, which is SSE chunks when streaming.
 	EndSpan(statusCode int, body []byte)
+
+	// RecordGuardrail records the decision of the external guardrail service as a span event.
+	//
+	// Parameters:
+	//   - phase: "request" or "response".
+	//   - action: "allow", "block" or "mutate".
+	//   - reason: the reason of the decision, or the error of the call to the
+	//     service when it failed open or closed. Empty if not given.
+	RecordGuardrail(phase, action, reason string)
 }
 
 // ChatCompletionRecorder records attributes to a span according to a semantic
@@ -6,6 +6,7 @@
 package tracing
 
 import (
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 
 	tracing "github.com/envoyproxy/ai-gateway/internal/tracing/api"
@@ -31,3 +32,15 @@ func (s *chatCompletionSpan) EndSpan(statusCode int, body []byte) {
 	s.recorder.RecordResponse(s.span, statusCode, body)
 	s.span.End()
 }
+
+// RecordGuardrail adds the "guardrail" event with the decision of the guardrail service.
+func (s *chatCompletionSpan) RecordGuardrail(phase, action, reason string) {
+	attrs := []attribute.KeyValue{
+		attribute.String("aigw.guardrail.phase", phase),
+		attribute.String("aigw.guardrail.action", action),
+	}
+	if reason != "" {
+		attrs = append(attrs, attribute.String("aigw.guardrail.reason", reason))
+	}
+	s.span.AddEvent("guardrail", trace.WithAttributes(attrs...))
+}
@@ -49,3 +49,30 @@ func TestChatCompletionSpan_EndSpan(t *testing.T) {
 		attribute.Int("respBodyLen", len(respBody)),
 	}, actualSpan.Attributes)
 }
+
+func TestChatCompletionSpan_RecordGuardrail(t *testing.T) {
+	actualSpan := testotel.RecordWithSpan(t, func(span oteltrace.Span) bool {
+		s := &chatCompletionSpan{span: span, recorder: testChatCompletionRecorder{}}
+		s.RecordGuardrail("request", "allow", "")
+		s.RecordGuardrail("response", "block", "flagged")
+		return false // Recording of the guardrail decisions shouldn't end the span.
+	})
+
+	require.Equal(t, []trace.Event{
+		{
+			Name: "guardrail",
+			Attributes: []attribute.KeyValue{
+				attribute.String("aigw.guardrail.phase", "request"),
+				attribute.String("aigw.guardrail.action", "allow"),
+			},
+		},
+		{
+			Name: "guardrail",
+			Attributes: []attribute.KeyValue{
+				attribute.String("aigw.guardrail.phase", "response"),
+				attribute.String("aigw.guardrail.action", "block"),
+				attribute.String("aigw.guardrail.reason", "flagged"),
+			},
+		},
+	}, actualSpan.Events)
+}
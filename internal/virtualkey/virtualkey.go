@@ -0,0 +1,152 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package virtualkey provides the virtual keys issued by the gateway to the clients, which are validated by the
+// external processor instead of the credentials of the providers.
+//
+// Only the SHA-256 hashes of the keys are configured, and the keys presented by the clients are looked up by their
+// hashes, so the keys are never held in the clear.
+package virtualkey
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+const (
+	// authorizationHeader is the default header carrying the virtual key in the Bearer scheme.
+	authorizationHeader = "authorization"
+	// apiKeyHeader is the header carrying the API key of the Anthropic clients, which is also looked up when the
+	// virtual key is carried by the authorizationHeader.
+	apiKeyHeader = "x-api-key"
+)
+
+// Hash returns the hex-encoded SHA-256 hash of the given key.
+func Hash(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
+
+// ErrInvalidKey is returned by [Set.Authenticate] when the request doesn't have a known virtual key.
+var ErrInvalidKey = errors.New("the virtual key is missing or invalid")
+
+// ModelNotAllowedError is returned by [Set.Authenticate] when the virtual key is not allowed to use the model.
+type ModelNotAllowedError struct {
+	// Key is the name of the virtual key.
+	Key string
+	// Model is the model of the request.
+	Model string
+}
+
+// Error implements [error].
+func (e *ModelNotAllowedError) Error() string {
+	return fmt.Sprintf("the virtual key is not allowed to use the model %s", e.Model)
+}
+
+// Key is a validated virtual key.
+type Key struct {
+	config *filterapi.VirtualKey
+	policy *policy
+}
+
+// Name returns the name of the key, e.g. "namespace/policy/key".
+func (k *Key) Name() string { return k.config.Name }
+
+// TeamID returns the ID of the team to which the key is issued.
+func (k *Key) TeamID() string { return k.config.TeamID }
+
+// ClientKeyHeaders returns the request headers which may carry the key, which are stripped from the request.
+func (k *Key) ClientKeyHeaders() []string { return k.policy.clientKeyHeaders }
+
+// TeamIDHeader returns the request header set to the team ID.
+func (k *Key) TeamIDHeader() string { return k.policy.config.TeamIDHeader }
+
+// Set is the set of the virtual key policies.
+type Set struct {
+	byModel map[string][]*policy
+}
+
+// policy is a virtual key policy with the keys indexed by their hashes.
+type policy struct {
+	config *filterapi.VirtualKeyPolicy
+	keys   map[string]*Key
+	// clientKeyHeaders are the request headers looked up for the key in order. The Authorization header comes with
+	// the x-api-key header so that both the OpenAI and the Anthropic clients can send the key as their API key.
+	clientKeyHeaders []string
+}
+
+// NewSet creates a new [Set] from the given configurations. This returns nil if there's no configuration.
+func NewSet(configs []filterapi.VirtualKeyPolicy) *Set {
+	if len(configs) == 0 {
+		return nil
+	}
+	s := &Set{byModel: make(map[string][]*policy)}
+	for i := range configs {
+		c := &configs[i]
+		p := &policy{config: c, keys: make(map[string]*Key, len(c.Keys)), clientKeyHeaders: []string{c.ClientKeyHeader}}
+		if c.ClientKeyHeader == authorizationHeader {
+			p.clientKeyHeaders = append(p.clientKeyHeaders, apiKeyHeader)
+		}
+		for j := range c.Keys {
+			k := &c.Keys[j]
+			p.keys[strings.ToLower(k.Hash)] = &Key{config: k, policy: p}
+		}
+		for _, m := range c.Models {
+			s.byModel[m] = append(s.byModel[m], p)
+		}
+	}
+	return s
+}
+
+// Authenticate validates the virtual key of the request to the given model. This returns nil without an error when
+// no policy applies to the model or the set is nil.
+//
+// When multiple policies apply to the model, the key of any of them is accepted. This returns [ErrInvalidKey] when
+// the request doesn't have the key of any of them, and [*ModelNotAllowedError] when the key is not allowed to use
+// the model.
+func (s *Set) Authenticate(model string, headers map[string]string) (*Key, error) {
+	if s == nil {
+		return nil, nil
+	}
+	policies := s.byModel[model]
+	if len(policies) == 0 {
+		return nil, nil
+	}
+	for _, p := range policies {
+		for _, h := range p.clientKeyHeaders {
+			value := headers[h]
+			if h == authorizationHeader {
+				value = bearerToken(value)
+			}
+			if value == "" {
+				continue
+			}
+			k, ok := p.keys[Hash(value)]
+			if !ok {
+				continue
+			}
+			if allowed := k.config.AllowedModels; len(allowed) > 0 && !slices.Contains(allowed, model) {
+				return nil, &ModelNotAllowedError{Key: k.Name(), Model: model}
+			}
+			return k, nil
+		}
+	}
+	return nil, ErrInvalidKey
+}
+
+// bearerToken returns the token of the given Authorization header value in the Bearer scheme, whose name is
+// case-insensitive. The value is returned as is when it is not in the Bearer scheme.
+func bearerToken(value string) string {
+	if scheme, token, ok := strings.Cut(value, " "); ok && strings.EqualFold(scheme, "bearer") {
+		return strings.TrimSpace(token)
+	}
+	return value
+}
@@ -0,0 +1,91 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package virtualkey
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestHash(t *testing.T) {
+	require.Equal(t, "8879f6a4ae35c420a15d35fed3b8dd07577207803d404f6d4cc4fa829dafa910", Hash("sk-team-a"))
+}
+
+func TestSet_Authenticate(t *testing.T) {
+	k, err := (*Set)(nil).Authenticate("gpt-4o", nil)
+	require.NoError(t, err)
+	require.Nil(t, k)
+	require.Nil(t, NewSet(nil))
+
+	s := NewSet([]filterapi.VirtualKeyPolicy{
+		{
+			Name:            "ns/keys",
+			Models:          []string{"gpt-4o", "gpt-4o-mini"},
+			ClientKeyHeader: "authorization",
+			TeamIDHeader:    "x-ai-eg-team-id",
+			Keys: []filterapi.VirtualKey{
+				{Name: "ns/keys/a", Hash: Hash("sk-a"), TeamID: "team-a"},
+				{Name: "ns/keys/b", Hash: Hash("sk-b"), TeamID: "team-b", AllowedModels: []string{"gpt-4o-mini"}},
+			},
+		},
+		{
+			Name:            "ns/other",
+			Models:          []string{"gpt-4o"},
+			ClientKeyHeader: "x-api-key",
+			TeamIDHeader:    "x-team",
+			Keys:            []filterapi.VirtualKey{{Name: "ns/other/c", Hash: Hash("sk-c"), TeamID: "team-c"}},
+		},
+	})
+
+	for _, tc := range []struct {
+		name    string
+		model   string
+		headers map[string]string
+		expKey  string
+		expErr  error
+	}{
+		{name: "not governed", model: "claude", headers: map[string]string{}},
+		{name: "missing", model: "gpt-4o", headers: map[string]string{}, expErr: ErrInvalidKey},
+		{name: "unknown", model: "gpt-4o", headers: map[string]string{"authorization": "Bearer sk-x"}, expErr: ErrInvalidKey},
+		{name: "bearer", model: "gpt-4o", headers: map[string]string{"authorization": "Bearer sk-a"}, expKey: "ns/keys/a"},
+		{name: "bearer case-insensitive", model: "gpt-4o", headers: map[string]string{"authorization": "bearer  sk-a"}, expKey: "ns/keys/a"},
+		{name: "x-api-key", model: "gpt-4o-mini", headers: map[string]string{"x-api-key": "sk-a"}, expKey: "ns/keys/a"},
+		{
+			name: "x-api-key after unknown authorization", model: "gpt-4o-mini",
+			headers: map[string]string{"authorization": "Bearer sk-provider", "x-api-key": "sk-a"}, expKey: "ns/keys/a",
+		},
+		{name: "other policy", model: "gpt-4o", headers: map[string]string{"x-api-key": "sk-c"}, expKey: "ns/other/c"},
+		{name: "other policy not applicable", model: "gpt-4o-mini", headers: map[string]string{"x-api-key": "sk-c"}, expErr: ErrInvalidKey},
+		{name: "allowed model", model: "gpt-4o-mini", headers: map[string]string{"authorization": "Bearer sk-b"}, expKey: "ns/keys/b"},
+		{
+			name: "model not allowed", model: "gpt-4o", headers: map[string]string{"authorization": "Bearer sk-b"},
+			expErr: &ModelNotAllowedError{Key: "ns/keys/b", Model: "gpt-4o"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			k, err := s.Authenticate(tc.model, tc.headers)
+			require.Equal(t, tc.expErr, err)
+			if tc.expKey == "" {
+				require.Nil(t, k)
+				return
+			}
+			require.Equal(t, tc.expKey, k.Name())
+		})
+	}
+
+	k, err = s.Authenticate("gpt-4o", map[string]string{"x-api-key": "sk-c"})
+	require.NoError(t, err)
+	require.Equal(t, "team-c", k.TeamID())
+	require.Equal(t, []string{"x-api-key"}, k.ClientKeyHeaders())
+	require.Equal(t, "x-team", k.TeamIDHeader())
+	k, err = s.Authenticate("gpt-4o", map[string]string{"authorization": "Bearer sk-a"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"authorization", "x-api-key"}, k.ClientKeyHeaders())
+	require.Equal(t, "the virtual key is not allowed to use the model gpt-4o", (&ModelNotAllowedError{Model: "gpt-4o"}).Error())
+}
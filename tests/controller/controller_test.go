@@ -703,7 +703,8 @@ func TestSecretController(t *testing.T) {
 	require.NoError(t, err)
 
 	eventCh := internaltesting.NewControllerEventChan[*aigv1a1.BackendSecurityPolicy]()
-	sc := controller.NewSecretController(mgr.GetClient(), k, defaultLogger(), eventCh.Ch)
+	virtualKeyEventCh := internaltesting.NewControllerEventChan[*aigv1a1.AIGatewayVirtualKeyPolicy]()
+	sc := controller.NewSecretController(mgr.GetClient(), k, defaultLogger(), eventCh.Ch, virtualKeyEventCh.Ch)
 	const secretName, secretNamespace = "mysecret", "default"
 
 	err = ctrl.NewControllerManagedBy(mgr).For(&corev1.Secret{}).Complete(sc)
@@ -61,6 +61,82 @@ func TestAIGatewayRoutes(t *testing.T) {
 			name:   "inference_pool_unsupported_group.yaml",
 			expErr: "spec.rules[0].backendRefs[0]: Invalid value: \"object\": only InferencePool from inference.networking.k8s.io group is supported",
 		},
+		{name: "fallback.yaml"},
+		{
+			name:   "fallback_inference_pool.yaml",
+			expErr: "spec.rules[0]: Invalid value: \"object\": fallback cannot be used with InferencePool backends",
+		},
+		{name: "adaptive_load_balancing.yaml"},
+		{
+			name:   "adaptive_load_balancing_inference_pool.yaml",
+			expErr: "spec.rules[0]: Invalid value: \"object\": adaptiveLoadBalancing cannot be used with InferencePool backends",
+		},
+		{name: "body_headers.yaml"},
+		{
+			name:   "body_headers_both_expressions.yaml",
+			expErr: "spec.bodyHeaders[0]: Invalid value: \"object\": exactly one of cel or jsonPath must be specified",
+		},
+		{
+			name:   "body_headers_reserved_name.yaml",
+			expErr: "spec.bodyHeaders[0].name: Invalid value: \"string\": name must not start with x-ai-eg-",
+		},
+		{name: "pii_redaction.yaml"},
+		{
+			name:   "pii_redaction_regex_missing.yaml",
+			expErr: "spec.piiRedaction.detectors[0]: Invalid value: \"object\": regex must be specified only for the Regex type",
+		},
+		{name: "guardrail.yaml"},
+		{
+			name:   "guardrail_grpc_url.yaml",
+			expErr: "spec.guardrail: Invalid value: \"object\": endpoint must be a URL for the HTTP protocol and host:port for the GRPC protocol",
+		},
+		{name: "tool_policy.yaml"},
+		{
+			name:   "tool_policy_unknown_action.yaml",
+			expErr: "spec.toolPolicy.action: Unsupported value: \"Drop\": supported values: \"Block\", \"Rewrite\"",
+		},
+		{name: "request_policy.yaml"},
+		{
+			name:   "request_policy_default_model.yaml",
+			expErr: "spec.rules[0].requestPolicy.defaults[0]: Invalid value: \"object\": field must not be model, messages or stream",
+		},
+		{name: "mirror.yaml"},
+		{name: "mirror_otlp_logs.yaml"},
+		{
+			name:   "mirror_file_missing.yaml",
+			expErr: "spec.rules[0].mirror.sink: Invalid value: \"object\": file must be specified only for the File type",
+		},
+		{
+			name:   "mirror_otlp_logs_with_file.yaml",
+			expErr: "spec.rules[0].mirror.sink: Invalid value: \"object\": file must be specified only for the File type",
+		},
+		{name: "session_affinity.yaml"},
+		{
+			name:   "session_affinity_header_missing.yaml",
+			expErr: "spec.rules[0].sessionAffinity: Invalid value: \"object\": header must be specified only for the Header type",
+		},
+		{
+			name:   "session_affinity_adaptive_load_balancing.yaml",
+			expErr: "spec.rules[0]: Invalid value: \"object\": sessionAffinity cannot be used with adaptiveLoadBalancing",
+		},
+		{name: "hedging.yaml"},
+		{
+			name:   "hedging_single_backend.yaml",
+			expErr: "spec.rules[0]: Invalid value: \"object\": hedging requires at least two AIServiceBackend references",
+		},
+		{name: "models.yaml"},
+		{
+			name:   "models_alias_same_as_name.yaml",
+			expErr: "spec.models[0]: Invalid value: \"object\": aliases must not contain the model name",
+		},
+		{
+			name:   "models_invalid_price.yaml",
+			expErr: "spec.models[0].backends[0].price.inputPerMillionTokens: Invalid value: \"$3\": spec.models[0].backends[0].price.inputPerMillionTokens in body should match '^[0-9]+(\\.[0-9]+)?$'",
+		},
+		{
+			name:   "models_unknown_modality.yaml",
+			expErr: "spec.models[0].modalities[0]: Unsupported value: \"Smell\": supported values: \"Text\", \"Image\", \"Audio\", \"Video\"",
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			data, err := testdata.ReadFile(path.Join("testdata/aigatewayroutes", tc.name))
@@ -95,6 +171,11 @@ func TestAIServiceBackends(t *testing.T) {
 			name:   "unknown_schema.yaml",
 			expErr: "spec.schema.name: Unsupported value: \"SomeRandomVendor\": supported values: \"OpenAI\", \"AWSBedrock\"",
 		},
+		{name: "circuit_breaker.yaml"},
+		{
+			name:   "circuit_breaker_unknown_error_class.yaml",
+			expErr: "spec.circuitBreaker.failOn[0]: Unsupported value: \"Timeout\": supported values: \"Throttled\", \"ServerError\"",
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			data, err := testdata.ReadFile(path.Join("testdata/aiservicebackends", tc.name))
@@ -214,3 +295,93 @@ func TestBackendSecurityPolicies(t *testing.T) {
 		})
 	}
 }
+
+func TestAIGatewayQuotaPolicies(t *testing.T) {
+	c, _, _ := testsinternal.NewEnvTest(t)
+	ctx := t.Context()
+
+	for _, tc := range []struct {
+		name   string
+		expErr string
+	}{
+		{name: "basic.yaml"},
+		{name: "redis.yaml"},
+		{
+			name:   "redis_missing_config.yaml",
+			expErr: "redis must be specified when type is Redis",
+		},
+		{
+			name:   "targetrefs_invalid_kind.yaml",
+			expErr: "targetRefs must reference AIGatewayRoute resources",
+		},
+		{
+			name:   "unknown_budget_type.yaml",
+			expErr: "spec.budgets[0].type: Unsupported value: \"Image\": supported values: \"InputToken\", \"OutputToken\", \"TotalToken\", \"Spend\"",
+		},
+		{
+			name:   "no_budgets.yaml",
+			expErr: "spec.budgets: Invalid value: 0: spec.budgets in body should have at least 1 items",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := testdata.ReadFile(path.Join("testdata/aigatewayquotapolicies", tc.name))
+			require.NoError(t, err)
+
+			quotaPolicy := &aigv1a1.AIGatewayQuotaPolicy{}
+			err = yaml.UnmarshalStrict(data, quotaPolicy)
+			require.NoError(t, err)
+
+			if tc.expErr != "" {
+				require.ErrorContains(t, c.Create(ctx, quotaPolicy), tc.expErr)
+			} else {
+				require.NoError(t, c.Create(ctx, quotaPolicy))
+				require.NoError(t, c.Delete(ctx, quotaPolicy))
+			}
+		})
+	}
+}
+
+func TestAIGatewayVirtualKeyPolicies(t *testing.T) {
+	c, _, _ := testsinternal.NewEnvTest(t)
+	ctx := t.Context()
+
+	for _, tc := range []struct {
+		name   string
+		expErr string
+	}{
+		{name: "basic.yaml"},
+		{name: "redis.yaml"},
+		{
+			name:   "redis_missing_config.yaml",
+			expErr: "redis must be specified when type is Redis",
+		},
+		{
+			name:   "targetrefs_invalid_kind.yaml",
+			expErr: "targetRefs must reference AIGatewayRoute resources",
+		},
+		{
+			name:   "no_keys.yaml",
+			expErr: "spec.keys: Invalid value: 0: spec.keys in body should have at least 1 items",
+		},
+		{
+			name:   "invalid_key_name.yaml",
+			expErr: "spec.keys[0].name in body should match '^[a-z0-9]([-a-z0-9]*[a-z0-9])?$'",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := testdata.ReadFile(path.Join("testdata/aigatewayvirtualkeypolicies", tc.name))
+			require.NoError(t, err)
+
+			virtualKeyPolicy := &aigv1a1.AIGatewayVirtualKeyPolicy{}
+			err = yaml.UnmarshalStrict(data, virtualKeyPolicy)
+			require.NoError(t, err)
+
+			if tc.expErr != "" {
+				require.ErrorContains(t, c.Create(ctx, virtualKeyPolicy), tc.expErr)
+			} else {
+				require.NoError(t, c.Create(ctx, virtualKeyPolicy))
+				require.NoError(t, c.Delete(ctx, virtualKeyPolicy))
+			}
+		})
+	}
+}